@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"runtime"
+
+	"a-la-carte/internal/ui/components"
+)
+
+// openQuestion identifies the link menu among the answers of the confirm dialog.
+const openQuestion = "open"
+
+// openBrowser opens url in the default browser without waiting for it: with open on
+// macOS, the URL protocol handler on Windows and xdg-open elsewhere. Only http and https
+// URLs are opened, so a manifest cannot make it run files or other protocol handlers.
+func openBrowser(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("not an http or https URL")
+	}
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", rawURL)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", rawURL)
+	default:
+		cmd = exec.Command("xdg-open", rawURL)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+// entryLinks returns the links of key as link menu answers, with the URL of each:
+// its _docs, _home and _github URLs, those it has.
+func (m *model) entryLinks(key string) ([]components.ConfirmChoice, map[string]string) {
+	entry := m.manifest[key]
+	var choices []components.ConfirmChoice
+	urls := make(map[string]string)
+	for _, link := range []struct{ key, label, url string }{
+		{"d", "Docs", entry.Docs},
+		{"h", "Homepage", entry.Home},
+		{"g", "GitHub", entry.Github},
+	} {
+		if link.url == "" {
+			continue
+		}
+		choices = append(choices, components.ConfirmChoice{Key: link.key, Label: link.label + " (" + link.url + ")"})
+		urls[link.key] = link.url
+	}
+	return choices, urls
+}
+
+// startOpen opens the link of the highlighted entry in the browser, or the link menu
+// when it has more than one.
+func (m *model) startOpen() {
+	key := m.highlightedKey()
+	if key == "" {
+		return
+	}
+	choices, urls := m.entryLinks(key)
+	switch {
+	case len(choices) == 0:
+		m.toast(components.ToastInfo, key+" has no docs, homepage or GitHub URL")
+	case len(choices) == 1 || m.confirmDialog == nil:
+		m.openLink(urls[choices[0].Key])
+	default:
+		m.openURLs = urls
+		m.confirmDialog.Show(openQuestion, "Open in browser", "Which page of "+key+" to open?", choices...)
+		m.openOverlay(m.confirmDialog)
+	}
+}
+
+// openLink opens url in the browser and reports the result.
+func (m *model) openLink(url string) {
+	open := m.openURL
+	if open == nil {
+		open = openBrowser
+	}
+	if err := open(url); err != nil {
+		m.toast(components.ToastError, fmt.Sprintf("Cannot open %s: %v", url, err))
+		return
+	}
+	m.toast(components.ToastSuccess, "Opened "+url)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"a-la-carte/internal/ui/components"
+	"a-la-carte/internal/ui/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// copyQuestion identifies the copy menu among the answers of the confirm dialog.
+const copyQuestion = "copy"
+
+// clipboardTools are the clipboard commands tried, in order. Each reads the text from
+// stdin.
+var clipboardTools = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+}
+
+// errNoClipboardTool is returned by systemClipboard when none of clipboardTools is
+// installed.
+var errNoClipboardTool = errors.New("no clipboard tool (pbcopy, wl-copy, xclip, xsel) is installed")
+
+// systemClipboard copies text with the first installed clipboard tool and returns its
+// name.
+func systemClipboard(text string) (string, error) {
+	for _, tool := range clipboardTools {
+		if _, err := exec.LookPath(tool[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(tool[0], tool[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("%s: %w", tool[0], err)
+		}
+		return tool[0], nil
+	}
+	return "", errNoClipboardTool
+}
+
+// supportsOSC52 reports whether the terminal on stdout can take an OSC 52 copy.
+func supportsOSC52() bool {
+	return isTerminal(os.Stdout) && os.Getenv("TERM") != "dumb" && os.Getenv("TERM") != "linux"
+}
+
+// copiedMsg reports the result of a copy to the clipboard.
+type copiedMsg struct {
+	text string
+	via  string
+	err  error
+}
+
+// copyText copies text to the clipboard outside of Update. Without a clipboard tool it
+// is sent to the terminal as an OSC 52 sequence on the program's output, which also
+// reaches the local clipboard over SSH and from inside tmux or screen.
+func (m *model) copyText(text string) tea.Cmd {
+	clipboard, output := m.clipboard, m.osc52Output
+	if clipboard == nil {
+		clipboard = systemClipboard
+	}
+	return func() tea.Msg {
+		via, err := clipboard(text)
+		if errors.Is(err, errNoClipboardTool) {
+			if output == nil {
+				return copiedMsg{err: fmt.Errorf("%w and the terminal does not support OSC 52", err)}
+			}
+			via = "OSC 52"
+			_, err = io.WriteString(output, core.Passthrough(ansi.SetSystemClipboard(text), os.Getenv))
+		}
+		return copiedMsg{text: text, via: via, err: err}
+	}
+}
+
+// installCommand returns the shell commands that install key on this system, one per
+// line, as the install preview shows them. It returns "" until the plan is known or
+// when there is nothing to install.
+func (m *model) installCommand(key string) string {
+	kp := m.keyPlans[key]
+	if kp == nil || !kp.done || kp.err != nil {
+		return ""
+	}
+	return strings.Join(kp.commands, "\n")
+}
+
+// startCopy opens the copy menu for the highlighted entry, offering its install command
+// (once planned), its GitHub URL and its key.
+func (m *model) startCopy() {
+	key := m.highlightedKey()
+	if key == "" || m.confirmDialog == nil {
+		return
+	}
+	var choices []components.ConfirmChoice
+	m.copyTexts = make(map[string]string)
+	if cmd := m.installCommand(key); cmd != "" {
+		choices = append(choices, components.ConfirmChoice{Key: "c", Label: "Install command"})
+		m.copyTexts["c"] = cmd
+	}
+	if github := m.manifest[key].Github; github != "" {
+		choices = append(choices, components.ConfirmChoice{Key: "g", Label: "GitHub URL (" + github + ")"})
+		m.copyTexts["g"] = github
+	}
+	choices = append(choices, components.ConfirmChoice{Key: "k", Label: "Key (" + key + ")"})
+	m.copyTexts["k"] = key
+	m.confirmDialog.Show(copyQuestion, "Copy to clipboard", "What to copy for "+key+"?", choices...)
+	m.openOverlay(m.confirmDialog)
+}
+
+// copyChoice copies the text of an answer of the copy menu.
+func (m *model) copyChoice(choice string) tea.Cmd {
+	text, ok := m.copyTexts[choice]
+	if !ok {
+		return nil
+	}
+	return m.copyText(text)
+}
+
+// handleCopied reports the result of a copy.
+func (m *model) handleCopied(msg copiedMsg) {
+	if msg.err != nil {
+		m.toast(components.ToastError, "Cannot copy: "+msg.err.Error())
+		return
+	}
+	summary := msg.text
+	if first, _, multiline := strings.Cut(msg.text, "\n"); multiline {
+		summary = first + " ..."
+	}
+	m.toast(components.ToastSuccess, fmt.Sprintf("Copied %s (via %s)", summary, msg.via))
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"a-la-carte/internal/app"
+	"a-la-carte/internal/app/provision"
+	"a-la-carte/internal/ui/components"
+	"a-la-carte/internal/ui/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Columns of the column view, numbered by the keys that sort by them.
+const (
+	columnName = iota + 1
+	columnInstaller
+	columnGroups
+	columnStatus
+)
+
+// installedMsg carries the installed packages, queried for the status column.
+type installedMsg struct {
+	installed map[string]bool
+}
+
+// queryInstalled queries the installed packages in the background.
+func queryInstalled() tea.Cmd {
+	return func() tea.Msg {
+		return installedMsg{installed: provision.GetInstalledPackages(queryRunner{})}
+	}
+}
+
+// findInstallers returns the installer each manifest key is installed with on the
+// running platform, for the installer column. Keys with no install method are left out.
+func findInstallers(manifest app.Manifest) map[string]string {
+	prov := provision.NewProvisioner(provision.DetectSystem(), manifest, nil)
+	result := make(map[string]string)
+	for key := range manifest {
+		if installer, _ := prov.Installer(key); installer != "" {
+			result[key] = installer
+		}
+	}
+	return result
+}
+
+// toggleColumnView switches the left list between names only and the column view,
+// querying the installed packages for the status column the first time.
+func (m *model) toggleColumnView() tea.Cmd {
+	m.columnView = !m.columnView
+	if m.installers == nil {
+		m.installers = findInstallers(m.manifest)
+	}
+	if m.columnView && m.installed == nil {
+		return queryInstalled()
+	}
+	return nil
+}
+
+// sortByColumn sorts the left list by a column of the column view; sorting by the same
+// column again reverses the order.
+func (m *model) sortByColumn(column int) {
+	if m.sortColumn == column {
+		m.sortDesc = !m.sortDesc
+	} else {
+		m.sortColumn, m.sortDesc = column, false
+	}
+	m.filter()
+}
+
+// sortVisible orders keys by the sort column, keeping the manifest order among equal
+// values. Without a sort column the keys are ordered by the sort mode.
+func (m *model) sortVisible(keys []string) {
+	if m.sortColumn == 0 {
+		m.sortByMode(keys)
+		return
+	}
+	slices.SortStableFunc(keys, func(a, b string) int {
+		c := strings.Compare(m.columnValue(a, m.sortColumn), m.columnValue(b, m.sortColumn))
+		if m.sortDesc {
+			return -c
+		}
+		return c
+	})
+}
+
+// columnValue returns the text of a key's cell in a column, lower-cased for sorting.
+func (m *model) columnValue(key string, column int) string {
+	entry := m.manifest[key]
+	switch column {
+	case columnInstaller:
+		return m.installerCell(key, &entry)
+	case columnGroups:
+		return strings.ToLower(strings.Join(entry.Groups, ","))
+	case columnStatus:
+		return m.statusCell(key)
+	default:
+		return strings.ToLower(entry.Name)
+	}
+}
+
+// installerCell returns the installer column of a key: the installer used here, "meta"
+// for meta-packages or "-" if there is none.
+func (m *model) installerCell(key string, entry *app.SoftwareEntry) string {
+	switch {
+	case m.installers[key] != "":
+		return m.installers[key]
+	case entry.IsMeta():
+		return "meta"
+	default:
+		return "-"
+	}
+}
+
+// statusCell returns the status column of a key: installed, missing, n/a when it cannot
+// be installed here, or ... while the installed packages are being queried.
+func (m *model) statusCell(key string) string {
+	_, bare := app.SplitKey(key)
+	switch {
+	case m.installed == nil:
+		return "..."
+	case m.installed[key] || m.installed[bare]:
+		return "installed"
+	case m.notInstallable[key] != "":
+		return "n/a"
+	default:
+		return "missing"
+	}
+}
+
+// renderColumnList renders the visible keys of the left list as a table with a header,
+// taking the same height as the plain list.
+func (m *model) renderColumnList(keys []string, focused bool, width int) string {
+	styles := core.CurrentStyles()
+	table := components.NewTable(width,
+		components.TableColumn{Title: "Name", Min: 12},
+		components.TableColumn{Title: "Installer", Width: 9},
+		components.TableColumn{Title: "Groups", Width: 12},
+		components.TableColumn{Title: "Status", Width: 9},
+	)
+	lines := []string{styles.SubtitleStyle.Render(table.Header(m.sortColumn-1, m.sortDesc))}
+	start, end := m.calculateVisibleRange(keys, listHeight-1)
+	for i := start; i < end; i++ {
+		key := keys[i]
+		entry := m.manifest[key]
+		name := m.formatItemText(key, &entry, table.Width(0))
+		if focused && i == m.uiActiveListIndex {
+			name = core.Focused(name)
+		}
+		row := table.Row(
+			name,
+			m.installerCell(key, &entry),
+			strings.Join(entry.Groups, ","),
+			m.statusCell(key),
+		)
+		lines = append(lines, m.itemStyle(key, i, focused).Render(row))
+	}
+	return m.ensureConsistentHeight(strings.Join(lines, "\n")+"\n", listHeight)
+}
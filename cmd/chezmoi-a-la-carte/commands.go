@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"a-la-carte/internal/flags"
+)
+
+// newCLI returns the command line of chezmoi-a-la-carte: the picker (tui, run when no
+// command is given) and the commands around it. A new subcommand is added here, with
+// its flags on its flags.Command; usage and shell completion follow from the list.
+func newCLI() *flags.CLI {
+	cli := flags.New()
+	cli.Commands = []*flags.Command{
+		tuiCommand(),
+		provisionCommand(),
+		exportCommand(),
+		importCommand(),
+		configCommand(),
+		doctorCommand(),
+		schemaCommand(),
+		completionCommand(cli),
+		logsCommand(),
+		newCommand(),
+		newEntryCommand(),
+	}
+	cli.LongHelp = func(opts *flags.Options) int {
+		return runLongHelp(cli, opts)
+	}
+	return cli
+}
+
+// tuiCommand returns the "tui" command, which starts the picker.
+func tuiCommand() *flags.Command {
+	return &flags.Command{
+		Name:    "tui",
+		Summary: "Browse the manifests and pick software to install",
+		Flags:   flags.TUIFlags,
+		Run:     runTUI,
+	}
+}
+
+// completionCommand returns the "completion" subcommand, which prints a completion
+// script for the commands and flags of cli.
+//
+// # Usage
+//
+//	source <(chezmoi-a-la-carte completion bash)
+//	chezmoi-a-la-carte completion fish > ~/.config/fish/completions/chezmoi-a-la-carte.fish
+func completionCommand(cli *flags.CLI) *flags.Command {
+	return &flags.Command{
+		Name:    "completion",
+		Args:    strings.Join(flags.CompletionShells, "|"),
+		Summary: "Print a shell completion script",
+		Run: func(_ *flags.Options, args []string) int {
+			if len(args) != 1 {
+				return flags.ExitUsage
+			}
+			script, err := cli.Completion(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return flags.ExitUsage
+			}
+			fmt.Print(script)
+			return 0
+		},
+	}
+}
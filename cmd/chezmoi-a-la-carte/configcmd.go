@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"a-la-carte/internal/config"
+	"a-la-carte/internal/flags"
+)
+
+// configCommand returns the "config" subcommand.
+func configCommand() *flags.Command {
+	return &flags.Command{
+		Name:    "config",
+		Args:    "get <key> | set <key> <value> | list",
+		Summary: "Read or change settings of the config file, e.g. config set ui.theme light",
+		Run:     runConfigCommand,
+	}
+}
+
+// runConfigCommand implements the "config" subcommand, which reads and changes the
+// config file without editing it by hand, and returns the exit code. Keys are the dotted
+// paths of the config file, e.g. ui.theme; set validates the new configuration before
+// writing it and keeps the file's comments. Without --config, the file in use by the
+// picker is read and written (A_LA_CARTE_CONFIG, or the default location).
+//
+// # Usage
+//
+//	chezmoi-a-la-carte config get ui.theme
+//	chezmoi-a-la-carte config set software.manifestPath ~/manifests/software.yml
+//	chezmoi-a-la-carte config list --output json
+func runConfigCommand(opts *flags.Options, args []string) int {
+	if len(args) == 0 {
+		return flags.ExitUsage
+	}
+	path := opts.ConfigPath
+	if path == "" {
+		path = config.FindConfigFile()
+	}
+
+	positional := args[1:]
+	switch {
+	case args[0] == "get" && len(positional) == 1:
+		cfg, err := loadConfigFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		value, err := cfg.Get(positional[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return printConfigOutput(config.Setting{Key: positional[0], Value: value}, config.FormatSetting(value), opts.OutputFormat)
+	case args[0] == "set" && len(positional) == 2:
+		if path == "" {
+			var err error
+			if path, err = config.DefaultConfigPath(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+		}
+		if err := config.SaveSetting(path, positional[0], positional[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Set %s to %s in %s\n", positional[0], positional[1], path)
+		return 0
+	case args[0] == "list" && len(positional) == 0:
+		cfg, err := loadConfigFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		settings := cfg.Settings()
+		lines := make([]string, 0, len(settings))
+		for _, setting := range settings {
+			lines = append(lines, setting.Key+": "+config.FormatSetting(setting.Value))
+		}
+		return printConfigOutput(settings, lines, opts.OutputFormat)
+	}
+	return flags.ExitUsage
+}
+
+// loadConfigFile loads the config file at path, or the defaults when path is empty.
+func loadConfigFile(path string) (*config.Config, error) {
+	if path == "" {
+		return config.DefaultConfig(), nil
+	}
+	cfg, err := config.Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config.DefaultConfig(), nil
+	}
+	return cfg, err
+}
+
+// printConfigOutput prints data as JSON, or text as plain text, and returns the exit code.
+func printConfigOutput(data, text any, output string) int {
+	if config.OutputFormat(output) == config.OutputFormatJSON {
+		text = data
+	}
+	formatted, err := config.FormatOutput(text, config.OutputFormat(output))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Println(formatted)
+	return 0
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// selectedConflicts returns the selected keys that key conflicts with (see
+// app.Manifest.Conflict), whether or not key itself is selected.
+func (m *model) selectedConflicts(key string) []string {
+	var result []string
+	for _, other := range m.selectedKeys {
+		if _, found := m.manifest.Conflict(key, other); found {
+			result = append(result, other)
+		}
+	}
+	return result
+}
+
+// conflictWarning returns the footer warning about conflicting selected entries, or ""
+// if the selection has none. Provisioning keeps only the first key of each pair.
+func (m *model) conflictWarning() string {
+	conflicts := m.manifest.Conflicts(m.selectedKeys)
+	switch len(conflicts) {
+	case 0:
+		return ""
+	case 1:
+		return "Warning: " + conflicts[0].String()
+	default:
+		return fmt.Sprintf("Warning: %s (and %d more conflicts)", conflicts[0], len(conflicts)-1)
+	}
+}
+
+// conflictDetail returns the details line value for the selected keys key conflicts
+// with, e.g. "conflicts with the selected neovim", or "" if there are none.
+func (m *model) conflictDetail(key string) string {
+	conflicting := m.selectedConflicts(key)
+	if len(conflicting) == 0 {
+		return ""
+	}
+	return "conflicts with the selected " + strings.Join(conflicting, ", ")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"a-la-carte/internal/app"
+	"a-la-carte/internal/config"
+	"a-la-carte/internal/ui/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// diagnosticsWarning returns the footer hint about the manifest diagnostics, or "" if
+// there are none.
+func (m *model) diagnosticsWarning() string {
+	switch len(m.duplicates) {
+	case 0:
+		return ""
+	case 1:
+		return "!: 1 manifest warning"
+	}
+	return fmt.Sprintf("!: %d manifest warnings", len(m.duplicates))
+}
+
+// printManifestWarnings prints the warnings of the diagnostics view about the configured
+// manifests for --debug, one per line; manifests that do not load are left to the picker
+// to report.
+func printManifestWarnings(cfg *config.Config) {
+	manifest, err := app.LoadManifests(manifestSources(cfg))
+	if err != nil {
+		return
+	}
+	duplicates := manifest.Duplicates()
+	fmt.Printf("Manifest warnings: %d\n", len(duplicates))
+	for _, d := range duplicates {
+		fmt.Printf("  %s\n", d)
+	}
+}
+
+// handleDiagnosticsKey handles key input when the diagnostics view is shown.
+func (m *model) handleDiagnosticsKey(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "esc", "!":
+		m.showDiagnostics = false
+	case "ctrl+c":
+		return m, tea.Quit
+	case "q":
+		return m, m.requestQuit()
+	}
+	return m, nil
+}
+
+// renderDiagnosticsView renders the warnings about the loaded manifests: the
+// executables and packages that entries under different keys define.
+func (m *model) renderDiagnosticsView(width int) string {
+	styles := core.CurrentStyles()
+	title := styles.HeaderStyle.Render("Diagnostics: manifest warnings")
+	lines := []string{styles.SubtitleStyle.Render(fmt.Sprintf("Defined under different keys (%d)", len(m.duplicates)))}
+	if len(m.duplicates) == 0 {
+		lines = append(lines, styles.DescriptionStyle.Render("No entries define the same executable or package."))
+	}
+	for _, d := range m.duplicates {
+		lines = append(lines, styles.ItemStyle.Render(wrap(core.Glyphs("• ")+d.String(), max(width-6, 20))))
+	}
+	body := strings.Join(lines, "\n")
+	return lipgloss.NewStyle().Width(width).Padding(1, 2).Render(lipgloss.JoinVertical(lipgloss.Left, title, "", body))
+}
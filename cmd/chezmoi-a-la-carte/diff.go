@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"a-la-carte/internal/app"
+	"a-la-carte/internal/app/provision"
+	"a-la-carte/internal/ui/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// diffMsg carries the comparison of the selection with the installed packages.
+type diffMsg struct {
+	diff provision.PlanDiff
+	err  error
+}
+
+// queryRunner is a provision.ExecRunner that only runs read-only queries; the picker
+// never installs anything itself.
+type queryRunner struct{}
+
+func (queryRunner) Run(cmd string, args ...string) error {
+	return queryRunner{}.RunContext(context.Background(), cmd, args...)
+}
+
+func (queryRunner) RunContext(_ context.Context, cmd string, _ ...string) error {
+	if cmd == "section" || cmd == "info" {
+		return nil
+	}
+	return errors.New("the picker does not run commands")
+}
+
+func (queryRunner) Output(cmd string, args ...string) ([]byte, error) {
+	return exec.Command(cmd, args...).Output()
+}
+
+// computeDiff compares the selected keys with the installed packages in the background,
+// with the index of the loaded manifest.
+func computeDiff(index *app.ManifestIndex, namespaces, keys []string) tea.Cmd {
+	return func() tea.Msg {
+		prov := provision.NewProvisioner(provision.DetectSystem(), index.Manifest(), nil)
+		prov.Index = index
+		prov.Namespaces = namespaces
+		prov.BinOnPath = true
+		diff, err := prov.Diff(keys, provision.GetInstalledPackages(queryRunner{}))
+		return diffMsg{diff: diff, err: err}
+	}
+}
+
+// toggleDiff shows or hides the diff view, comparing the current selection each time
+// it is shown.
+func (m *model) toggleDiff() tea.Cmd {
+	m.showDiff = !m.showDiff
+	if !m.showDiff {
+		return nil
+	}
+	m.diff, m.diffErr = nil, nil
+	return computeDiff(m.manifestIndex(), m.namespaces, append([]string(nil), m.selectedKeys...))
+}
+
+// handleDiffKey handles key input when the diff view is shown.
+func (m *model) handleDiffKey(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "esc", "i":
+		m.showDiff = false
+	case "ctrl+c":
+		return m, tea.Quit
+	case "q":
+		return m, m.requestQuit()
+	}
+	return m, nil
+}
+
+// renderDiffView renders the diff as three columns: will install, already installed,
+// and installed but not selected.
+func (m *model) renderDiffView(width int) string {
+	styles := core.CurrentStyles()
+	title := styles.HeaderStyle.Render("Diff: selection vs. installed packages")
+	var body string
+	switch {
+	case m.diffErr != nil:
+		body = styles.ErrorStyle.Render(fmt.Sprintf("Comparison failed: %v", m.diffErr))
+	case m.diff == nil:
+		body = styles.DescriptionStyle.Render("Checking installed packages...")
+	default:
+		colWidth := max((width-4)/3, 0)
+		columns := []struct {
+			title string
+			keys  []string
+		}{
+			{"Will install", m.diff.Install},
+			{"Already installed", m.diff.Installed},
+			{"Not selected", m.diff.Unselected},
+		}
+		var rendered []string
+		for _, col := range columns {
+			lines := []string{styles.SubtitleStyle.Render(fmt.Sprintf("%s (%d)", col.title, len(col.keys)))}
+			for _, key := range col.keys {
+				lines = append(lines, styles.ItemStyle.Render(key))
+			}
+			rendered = append(rendered, lipgloss.NewStyle().Width(colWidth).Render(strings.Join(lines, "\n")))
+		}
+		body = lipgloss.JoinHorizontal(lipgloss.Top, rendered...)
+	}
+	return lipgloss.NewStyle().Width(width).Padding(1, 2).Render(lipgloss.JoinVertical(lipgloss.Left, title, "", body))
+}
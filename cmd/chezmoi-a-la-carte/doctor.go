@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"a-la-carte/internal/app"
+	"a-la-carte/internal/app/provision"
+	"a-la-carte/internal/config"
+	"a-la-carte/internal/flags"
+)
+
+// doctorCheck is the result of one check of the doctor command.
+//
+// # Fields
+//   - Name:   What was checked, e.g. "config"
+//   - OK:     Whether the check passed
+//   - Detail: What was found, or what is wrong
+type doctorCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+// doctorCommand returns the "doctor" subcommand.
+func doctorCommand() *flags.Command {
+	return &flags.Command{
+		Name:    "doctor",
+		Summary: "Check the config file, the manifests and the provisioner",
+		Run:     runDoctorCommand,
+	}
+}
+
+// runDoctorCommand implements the "doctor" subcommand, which checks that the picker and
+// provisioner can run, and returns the exit code: 1 when a check fails.
+//
+// # Usage
+//
+//	chezmoi-a-la-carte doctor [--config <file>] [--manifest <file>] [--output json]
+func runDoctorCommand(opts *flags.Options, args []string) int {
+	if len(args) != 0 {
+		return flags.ExitUsage
+	}
+	checks := doctorChecks(opts)
+
+	code := 0
+	lines := make([]string, 0, len(checks))
+	for _, check := range checks {
+		status := "ok"
+		if !check.OK {
+			status, code = "FAIL", 1
+		}
+		lines = append(lines, fmt.Sprintf("%-4s  %s: %s", status, check.Name, check.Detail))
+	}
+	if printConfigOutput(checks, lines, opts.OutputFormat) != 0 {
+		return 1
+	}
+	return code
+}
+
+// doctorChecks runs the checks of the doctor command: the config file, each manifest,
+// the provisioner executable and the detected system.
+func doctorChecks(opts *flags.Options) []doctorCheck {
+	var checks []doctorCheck
+	cfg, err := loadConfig(opts)
+	switch {
+	case err != nil:
+		checks = append(checks, doctorCheck{Name: "config", Detail: err.Error()})
+	case cfg.ConfigPath != "":
+		checks = append(checks, doctorCheck{Name: "config", OK: true, Detail: cfg.ConfigPath})
+	default:
+		checks = append(checks, doctorCheck{Name: "config", OK: true, Detail: "no config file, using the defaults"})
+	}
+
+	if cfg != nil {
+		for _, named := range cfg.ResolveManifests() {
+			checks = append(checks, checkManifest(cfg, named))
+		}
+	}
+
+	if path, err := findProvisioner(); err != nil {
+		checks = append(checks, doctorCheck{Name: "provisioner", Detail: err.Error()})
+	} else {
+		checks = append(checks, doctorCheck{Name: "provisioner", OK: true, Detail: path})
+	}
+
+	sys := provision.DetectSystem()
+	checks = append(checks, doctorCheck{Name: "system", OK: true, Detail: fmt.Sprintf("%s/%s (%s)", sys.OS(), sys.Arch(), sys.ID())})
+	return checks
+}
+
+// checkManifest checks that a manifest loads, counting its entries; with --strict or
+// software.strict, unknown keys of its entries fail the check.
+func checkManifest(cfg *config.Config, named config.NamedManifest) doctorCheck {
+	name := "manifest"
+	if named.Name != "" {
+		name += " " + named.Name
+	}
+	manifest, err := app.LoadManifests([]app.ManifestSource{{Path: named.Path, Strict: cfg.Software.Strict, Render: provision.RenderTemplate}})
+	switch {
+	case err == nil:
+		return doctorCheck{Name: name, OK: true, Detail: fmt.Sprintf("%s (%d entries)", named.Path, len(manifest))}
+	case os.IsNotExist(err) && cfg.Software.ManifestURL != "":
+		return doctorCheck{Name: name, Detail: fmt.Sprintf("%s has not been downloaded from %s yet; start the picker to fetch it", named.Path, cfg.Software.ManifestURL)}
+	}
+	return doctorCheck{Name: name, Detail: err.Error()}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"a-la-carte/internal/app"
+	"a-la-carte/internal/ui/components"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// highlightedKey returns the key highlighted in the focused software pane, or "".
+func (m *model) highlightedKey() string {
+	keys := m.visible
+	if !m.softwarePaneLeft {
+		keys = m.selectedKeys
+	}
+	if m.uiActiveListIndex < 0 || m.uiActiveListIndex >= len(keys) {
+		return ""
+	}
+	if _, heading := groupHeading(keys[m.uiActiveListIndex]); heading {
+		return ""
+	}
+	return keys[m.uiActiveListIndex]
+}
+
+// manifestFileFor returns the manifest file that holds entries of the given namespace.
+// New entries without a namespace go to the highest-priority manifest.
+func (m *model) manifestFileFor(namespace string) (string, error) {
+	if m.config != nil && m.config.Software.ManifestURL != "" {
+		return "", fmt.Errorf("the manifest is fetched from %s and cannot be edited here", m.config.Software.ManifestURL)
+	}
+	for _, src := range m.manifestSources {
+		if namespace == "" || src.Name == namespace {
+			return src.Path, nil
+		}
+	}
+	return "", fmt.Errorf("no manifest file for %q", namespace)
+}
+
+// startEdit opens the entry editor for key, or for a new entry if key is "".
+func (m *model) startEdit(key string) {
+	if m.entryEditor == nil {
+		return
+	}
+	namespace, bare := app.SplitKey(key)
+	if key != "" && len(m.namespaces) == 0 {
+		namespace, bare = "", key
+	}
+	path, err := m.manifestFileFor(namespace)
+	if err != nil {
+		m.toast(components.ToastError, "Cannot edit: "+err.Error())
+		return
+	}
+	if key == "" {
+		m.entryEditor.Show("New entry in "+path, "", components.EntryFormData{})
+		m.openOverlay(m.entryEditor)
+		return
+	}
+	edit, err := app.LoadEntryEdit(path, bare)
+	if err != nil {
+		m.toast(components.ToastError, "Cannot edit: "+err.Error())
+		return
+	}
+	m.entryEditor.Show("Edit "+key, key, components.EntryFormData{
+		Key:        edit.Key,
+		Name:       edit.Name,
+		Desc:       edit.Desc,
+		Installers: app.FormatInstallers(edit.Installers),
+		Groups:     strings.Join(edit.Groups, ", "),
+	})
+	m.openOverlay(m.entryEditor)
+}
+
+// handleEntryEditorMsg handles the results of the entry editor. A saved entry is
+// written back to its manifest file and the manifests are reloaded; errors keep the
+// editor open.
+func (m *model) handleEntryEditorMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case components.EntryEditorCancelledMsg:
+		m.entryEditor.Hide()
+	case components.EntryEditorSubmittedMsg:
+		if err := m.saveEntry(msg.Original, msg.Data); err != nil {
+			m.entryEditor.SetError(err.Error())
+			return m, nil
+		}
+		m.entryEditor.Hide()
+	}
+	return m, nil
+}
+
+// saveEntry validates the editor's fields, writes the entry and reloads the manifests.
+func (m *model) saveEntry(original string, data components.EntryFormData) error {
+	namespace, bare := app.SplitKey(original)
+	if len(m.namespaces) == 0 {
+		namespace, bare = "", original
+	}
+	path, err := m.manifestFileFor(namespace)
+	if err != nil {
+		return err
+	}
+	installers, err := app.ParseInstallers(data.Installers)
+	if err != nil {
+		return err
+	}
+	edit := app.EntryEdit{
+		Key:        strings.TrimSpace(data.Key),
+		Name:       strings.TrimSpace(data.Name),
+		Desc:       strings.TrimSpace(data.Desc),
+		Installers: installers,
+	}
+	for _, group := range strings.Split(data.Groups, ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			edit.Groups = append(edit.Groups, group)
+		}
+	}
+	if err := app.SaveEntry(path, bare, edit); err != nil {
+		return err
+	}
+	// Keep a renamed entry selected
+	renamed := edit.Key
+	if namespace != "" {
+		renamed = namespace + app.NamespaceSeparator + edit.Key
+	}
+	for i, key := range m.selectedKeys {
+		if original != "" && key == original {
+			m.selectedKeys[i] = renamed
+		}
+	}
+	sort.Strings(m.selectedKeys)
+	manifest, err := app.LoadManifests(m.manifestSources)
+	if err != nil {
+		return fmt.Errorf("saved %s, but reloading failed: %w", path, err)
+	}
+	m.reloadManifest(manifest)
+	m.toast(components.ToastSuccess, fmt.Sprintf("Saved %s to %s", edit.Key, path))
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"a-la-carte/internal/app"
+	"a-la-carte/internal/ui/core"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// groupHeaderPrefix marks the rows of the left list that are group headings rather
+// than keys. Manifest keys cannot contain a NUL byte.
+const groupHeaderPrefix = "\x00group:"
+
+// otherGroup is the heading of entries without _groups.
+const otherGroup = "Other"
+
+// primaryGroup returns the group an entry is listed under when the list is grouped,
+// its first _groups value, or "" if it has none.
+func primaryGroup(entry app.SoftwareEntry) string {
+	if len(entry.Groups) == 0 {
+		return ""
+	}
+	return entry.Groups[0]
+}
+
+// groupHeading returns the group a row of the left list heads, if it is a heading.
+func groupHeading(row string) (string, bool) {
+	return strings.CutPrefix(row, groupHeaderPrefix)
+}
+
+// grouped reports whether the left list is shown under group headings: when it is
+// sorted by group, outside the column view.
+func (m *model) grouped() bool {
+	return m.sortMode() == sortGroup && m.sortColumn == 0 && !m.columnView
+}
+
+// insertGroupHeadings puts a heading before each group of the sorted keys and leaves
+// out the keys of collapsed groups, unless a search is active so matches always show.
+// The size of each group is kept for its heading.
+func (m *model) insertGroupHeadings(keys []string, searching bool) []string {
+	rows := make([]string, 0, len(keys))
+	m.groupSizes = make(map[string]int)
+	current := ""
+	for i, key := range keys {
+		group := primaryGroup(m.manifest[key])
+		if group == "" {
+			group = otherGroup
+		}
+		if i == 0 || !strings.EqualFold(group, current) {
+			current = group
+			rows = append(rows, groupHeaderPrefix+group)
+		}
+		m.groupSizes[current]++
+		if searching || !m.collapsedGroups[strings.ToLower(current)] {
+			rows = append(rows, key)
+		}
+	}
+	return rows
+}
+
+// arrangeVisible sorts the left list, puts it under headings when it is grouped, and
+// adds the recent group on top.
+func (m *model) arrangeVisible(searching bool) {
+	m.sortVisible(m.visible)
+	if m.grouped() {
+		m.visible = m.insertGroupHeadings(m.visible, searching)
+	}
+	m.visible = m.withRecentGroup(m.visible, searching)
+}
+
+// setGroupCollapsed collapses or expands a group and moves the cursor to its heading.
+func (m *model) setGroupCollapsed(group string, collapsed bool) {
+	if m.collapsedGroups == nil {
+		m.collapsedGroups = make(map[string]bool)
+	}
+	m.collapsedGroups[strings.ToLower(group)] = collapsed
+	m.filter()
+	for i, row := range m.visible {
+		if row == groupHeaderPrefix+group {
+			m.uiActiveListIndex = i
+			break
+		}
+	}
+}
+
+// collapseHighlightedGroup collapses the group of the highlighted row, a heading or a
+// key under it. It reports whether there was a group to collapse.
+func (m *model) collapseHighlightedGroup() bool {
+	if !m.headed() || m.uiActiveListIndex < 0 || m.uiActiveListIndex >= len(m.visible) {
+		return false
+	}
+	row := m.visible[m.uiActiveListIndex]
+	group, heading := groupHeading(row)
+	if !heading {
+		if group = primaryGroup(m.manifest[row]); group == "" {
+			group = otherGroup
+		}
+		// Headings keep the spelling of the first key of the group.
+		for i := m.uiActiveListIndex; i >= 0; i-- {
+			if g, ok := groupHeading(m.visible[i]); ok {
+				group = g
+				break
+			}
+		}
+	}
+	m.setGroupCollapsed(group, true)
+	return true
+}
+
+// expandHighlightedGroup expands the highlighted heading if its group is collapsed. It
+// reports whether it did, so → otherwise keeps switching to the right pane.
+func (m *model) expandHighlightedGroup() bool {
+	if !m.headed() || m.uiActiveListIndex < 0 || m.uiActiveListIndex >= len(m.visible) {
+		return false
+	}
+	group, heading := groupHeading(m.visible[m.uiActiveListIndex])
+	if !heading || !m.collapsedGroups[strings.ToLower(group)] {
+		return false
+	}
+	m.setGroupCollapsed(group, false)
+	return true
+}
+
+// formatHeadingLine renders a group heading of the left list with its size and a
+// marker showing whether it is collapsed.
+func (m *model) formatHeadingLine(group string, index int, focused bool, width int) string {
+	styles := core.CurrentStyles()
+	marker := "▾"
+	if m.collapsedGroups[strings.ToLower(group)] {
+		marker = "▸"
+	}
+	line := core.Glyphs(fmt.Sprintf("%s %s (%d)", marker, group, m.groupSizes[group]))
+	style := styles.SubtitleStyle
+	if focused && index == m.uiActiveListIndex {
+		style = styles.ActiveItemStyle
+		line = core.Focused(line)
+	}
+	return style.Render(runewidth.Truncate(line, max(width-2, 0), core.Glyphs("…")))
+}
+
+// groupDetails returns the details panel of a group heading.
+func (m *model) groupDetails(group string) []string {
+	styles := core.CurrentStyles()
+	state, hint := "expanded", "← collapses it"
+	if m.collapsedGroups[strings.ToLower(group)] {
+		state, hint = "collapsed", "→ expands it"
+	}
+	return []string{
+		styles.HeaderStyle.Render("Group"),
+		styles.DetailKey.Render("Name: ") + styles.DetailValueStyle.Render(group),
+		styles.DetailKey.Render("Entries: ") + styles.DetailValueStyle.Render(fmt.Sprint(m.groupSizes[group])),
+		styles.DimStyle.Render(core.Glyphs(state + ", " + hint)),
+	}
+}
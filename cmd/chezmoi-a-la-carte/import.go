@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"a-la-carte/internal/app"
+	"a-la-carte/internal/app/importer"
+	"a-la-carte/internal/app/provision"
+	"a-la-carte/internal/flags"
+)
+
+// importCommand returns the "import" subcommand.
+func importCommand() *flags.Command {
+	var profile string
+	return &flags.Command{
+		Name:    "import",
+		Args:    strings.Join(importer.Formats(), "|") + " <file>|-",
+		Summary: "Print manifest entries (or a profile) for an existing package list",
+		Flags: func(fs *flag.FlagSet, _ *flags.Options) {
+			fs.StringVar(&profile, "profile", "", "Print a profile of the matching manifest keys instead of new entries")
+		},
+		Run: func(opts *flags.Options, args []string) int {
+			return runImportCommand(opts, profile, args)
+		},
+	}
+}
+
+// runImportCommand implements the "import" subcommand and returns the exit code. It
+// prints manifest entries for the listed packages the manifest does not have yet, or
+// with --profile, a profile of the manifest keys that install them. Packages are
+// matched against the manifest given with --manifest, or the configured ones.
+//
+// # Usage
+//
+//	chezmoi-a-la-carte import brewfile|apt|winget [--manifest <file>] [--profile <name>] <file>|-
+func runImportCommand(opts *flags.Options, profile string, args []string) int {
+	if len(args) != 2 {
+		return flags.ExitUsage
+	}
+	format := args[0]
+
+	var in io.Reader = os.Stdin
+	if path := args[1]; path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		in = f
+	}
+	pkgs, err := importer.Parse(format, in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return flags.ExitUsage
+	}
+	manifest, err := loadImportManifest(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	result := importer.Match(manifest, pkgs)
+
+	if profile != "" {
+		out, err := importer.Profile(profile, result.Keys)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Print(out)
+		if len(result.Missing) > 0 {
+			fmt.Fprintf(os.Stderr, "%d of %d packages are not in the manifest; run without --profile to generate entries for them\n", len(result.Missing), len(pkgs))
+		}
+		return 0
+	}
+	out, err := importer.Entries(result.Missing)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Print(out)
+	if len(result.Keys) > 0 {
+		fmt.Fprintf(os.Stderr, "%d packages are already in the manifest: %s\n", len(pkgs)-len(result.Missing), strings.Join(result.Keys, ", "))
+	}
+	return 0
+}
+
+// loadImportManifest loads the manifest given with --manifest, or the configured ones.
+func loadImportManifest(opts *flags.Options) (app.Manifest, error) {
+	if opts.ManifestPath != "" {
+		return app.LoadManifests([]app.ManifestSource{{Path: opts.ManifestPath, Strict: opts.Strict, Render: provision.RenderTemplate}})
+	}
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	return app.LoadManifests(manifestSources(cfg))
+}
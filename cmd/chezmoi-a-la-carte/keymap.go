@@ -0,0 +1,52 @@
+package main
+
+import "a-la-carte/internal/ui/components"
+
+// keymap lists the keys of the picker and of the provisioner it hands the selection to,
+// grouped by where they apply. The help screen (h) is generated from it, so a new key
+// binding is documented by adding it here.
+var keymap = []components.KeyBinding{
+	{Context: "General", Keys: "Tab", Desc: "Toggle focus between the software lists and the details panel"},
+	{Context: "General", Keys: "/", Desc: "Start a search (when focus is on the software lists)"},
+	{Context: "General", Keys: "p", Desc: "Switch profile (replaces the current selection)"},
+	{Context: "General", Keys: "u", Desc: "Undo the last selection change"},
+	{Context: "General", Keys: "ctrl+r", Desc: "Redo the last undone selection change"},
+	{Context: "General", Keys: "ctrl+s", Desc: "Save the selection to the config file, to start with it next time"},
+	{Context: "General", Keys: "U", Desc: "Refresh the remote manifest when an update is available"},
+	{Context: "General", Keys: "i", Desc: "Compare the selection with the installed packages"},
+	{Context: "General", Keys: "!", Desc: "Show manifest diagnostics, such as entries defining the same executable or package"},
+	{Context: "General", Keys: "n", Desc: "Add a new manifest entry"},
+	{Context: "General", Keys: "h", Desc: "Toggle this help"},
+	{Context: "General", Keys: "q", Desc: "Quit, asking first if the selection has unsaved changes"},
+
+	{Context: "List", Keys: "↑/↓/j/k", Desc: "Move the highlight"},
+	{Context: "List", Keys: "Enter", Desc: "Select or deselect the highlighted entry"},
+	{Context: "List", Keys: "←/→", Desc: "Switch between the Available and Selected panes"},
+	{Context: "List", Keys: "←/→", Desc: "Collapse/expand a group heading, such as Recent on top (Available pane)"},
+	{Context: "List", Keys: "e", Desc: "Edit the highlighted manifest entry"},
+	{Context: "List", Keys: "y", Desc: "Copy the highlighted entry's install command, GitHub URL or key"},
+	{Context: "List", Keys: "o", Desc: "Open the highlighted entry's docs, homepage or GitHub page in the browser"},
+	{Context: "List", Keys: "w", Desc: "Preview the commands installing the highlighted entry (and its deps) would run"},
+	{Context: "List", Keys: "x", Desc: "Expand/collapse the members of a meta-package (🧩)"},
+	{Context: "List", Keys: "c", Desc: "Toggle the column view (name, installer, groups, status)"},
+	{Context: "List", Keys: "1-4", Desc: "Sort the column view by a column; again to reverse"},
+	{Context: "List", Keys: "s", Desc: "Cycle the sort order (alphabetical, group, installed status, recently selected)"},
+	{Context: "List", Keys: "d/Del", Desc: "Remove the highlighted entry from the selection (Selected pane)"},
+	{Context: "List", Keys: "D", Desc: "Clear the selection (Selected pane)"},
+	{Context: "List", Keys: "v", Desc: "Mark a range; d then removes every marked entry (Selected pane)"},
+
+	{Context: "Details", Keys: "↑/↓/j/k", Desc: "Scroll the details of the highlighted entry"},
+
+	{Context: "Search", Keys: "<text>", Desc: "Narrow the lists to matching names, keys and descriptions"},
+	{Context: "Search", Keys: "tag:<name>", Desc: "Only entries with this tag"},
+	{Context: "Search", Keys: "tier:<name>", Desc: "Only entries in this tier (core, extra, optional)"},
+	{Context: "Search", Keys: "recent:[<n>]", Desc: "Frequently and recently selected entries"},
+	{Context: "Search", Keys: "Enter/Tab/Esc", Desc: "Leave the search box, keeping the search"},
+
+	{Context: "Provisioning", Keys: "y/Enter", Desc: "Install the confirmed plan"},
+	{Context: "Provisioning", Keys: "n/Esc", Desc: "Cancel without installing"},
+	{Context: "Provisioning", Keys: "Space/x", Desc: "Toggle the highlighted package in the plan"},
+	{Context: "Provisioning", Keys: "↑/↓/j/k", Desc: "Scroll the plan or the log"},
+	{Context: "Provisioning", Keys: "End", Desc: "Follow the log again"},
+	{Context: "Provisioning", Keys: "q", Desc: "Stop after the running command; again to quit now"},
+}
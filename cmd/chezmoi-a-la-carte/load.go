@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"a-la-carte/internal/app"
+	"a-la-carte/internal/config"
+	"a-la-carte/internal/ui/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// manifestLoadedMsg carries the manifests loaded in the background at startup, or the
+// reason they could not be loaded.
+type manifestLoadedMsg struct {
+	manifest app.Manifest
+	err      error
+}
+
+// loadManifests fetches a remote manifest on first use and loads the manifest files in
+// the background, so the picker shows while they are read.
+func loadManifests(cfg *config.Config, sources []app.ManifestSource) tea.Cmd {
+	return func() tea.Msg {
+		if err := ensureRemoteManifest(cfg); err != nil {
+			return manifestLoadedMsg{err: fmt.Errorf("error fetching manifest from %s: %w", cfg.Software.ManifestURL, err)}
+		}
+		if err := cfg.ValidateManifestPath(); err != nil {
+			return manifestLoadedMsg{err: fmt.Errorf("manifest validation error: %w", err)}
+		}
+		manifest, err := app.LoadManifests(sources)
+		switch {
+		case err != nil && len(sources) == 1:
+			err = fmt.Errorf("error loading manifest from %s: %w", sources[0].Path, err)
+		case err != nil:
+			err = fmt.Errorf("error loading manifests: %w", err)
+		}
+		return manifestLoadedMsg{manifest: manifest, err: err}
+	}
+}
+
+// startLoading shows the loading screen and starts loading the manifests.
+func (m *model) startLoading() tea.Cmd {
+	m.loading, m.loadErr = true, nil
+	return tea.Batch(m.spinner.Tick, loadManifests(m.config, m.manifestSources))
+}
+
+// handleManifestLoaded swaps in the loaded manifests and selects the preloaded keys, or
+// keeps the error to show with a retry hint.
+func (m *model) handleManifestLoaded(msg manifestLoadedMsg) tea.Cmd {
+	m.loading = false
+	if msg.err != nil {
+		m.loadErr = msg.err
+		return nil
+	}
+	m.reloadManifest(msg.manifest)
+
+	// Add preloaded keys to selected keys if they exist in the manifest.
+	// Bare keys resolve to the highest-priority manifest that has them.
+	for _, key := range m.config.Software.PreloadKeys {
+		if resolved, exists := msg.manifest.Resolve(key, "", m.namespaces); exists && !slices.Contains(m.selectedKeys, resolved) {
+			m.selectedKeys = append(m.selectedKeys, resolved)
+		}
+	}
+	sort.Strings(m.selectedKeys)
+	m.savedKeys = slices.Clone(m.selectedKeys)
+	m.filter()
+
+	cmds := []tea.Cmd{m.planHighlighted()}
+	if (m.columnView || m.sortMode() == sortStatus) && m.installed == nil {
+		cmds = append(cmds, queryInstalled())
+	}
+	return tea.Batch(cmds...)
+}
+
+// handleLoadingKey handles key input while the manifests load or failed to load: they
+// can be loaded again after a failure, and the picker can be left.
+func (m *model) handleLoadingKey(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "r":
+		if m.loadErr != nil {
+			return m, m.startLoading()
+		}
+	}
+	return m, nil
+}
+
+// renderLoading renders the loading screen, or the load error with how to retry.
+func (m *model) renderLoading() string {
+	styles := core.CurrentStyles()
+	var body string
+	if m.loadErr != nil {
+		body = lipgloss.JoinVertical(lipgloss.Left,
+			styles.ErrorStyle.Render("Error loading manifest"),
+			"",
+			wrap(m.loadErr.Error(), max(m.width-4, 20)),
+			"",
+			styles.DimStyle.Render(core.Glyphs("r: retry · q: quit")),
+		)
+	} else {
+		body = m.spinner.View() + " Loading manifest..."
+	}
+	if m.width == 0 || m.height == 0 {
+		return body + "\n"
+	}
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, body)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+
+	"a-la-carte/internal/app/provision"
+	"a-la-carte/internal/flags"
+)
+
+// logsCommand returns the "logs" subcommand.
+func logsCommand() *flags.Command {
+	var dir, level string
+	return &flags.Command{
+		Name:    "logs",
+		Args:    "search <pattern>",
+		Summary: "Search archived provisioning run logs",
+		Flags: func(fs *flag.FlagSet, _ *flags.Options) {
+			fs.StringVar(&dir, "dir", provision.DefaultRunLogDir(), "Directory of archived run logs")
+			fs.StringVar(&level, "level", "", "Only show lines of this level (e.g. error)")
+		},
+		Run: func(_ *flags.Options, args []string) int {
+			return runLogsCommand(dir, level, args)
+		},
+	}
+}
+
+// runLogsCommand implements the "logs" subcommand and returns the exit code.
+//
+// # Usage
+//
+//	chezmoi-a-la-carte logs search [--dir <dir>] [--level <level>] <pattern>
+func runLogsCommand(dir, level string, args []string) int {
+	if len(args) == 0 || args[0] != "search" {
+		return flags.ExitUsage
+	}
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Error: logs search takes exactly one pattern")
+		return flags.ExitUsage
+	}
+	pattern, err := regexp.Compile(args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid pattern: %v\n", err)
+		return flags.ExitUsage
+	}
+	matches, err := provision.SearchRunLogs(dir, pattern)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	found := 0
+	for _, m := range matches {
+		if level != "" && m.Level != level {
+			continue
+		}
+		fmt.Println(m)
+		found++
+	}
+	if found == 0 {
+		fmt.Fprintf(os.Stderr, "No matches in %s\n", dir)
+		return 1
+	}
+	return 0
+}
@@ -19,6 +19,10 @@
 //   - q:       Quit
 //   - Enter:   Show details
 //   - esc:     Cancel search
+//   - p:       Switch profile
+//   - d/Del:   Remove the highlighted (or marked) items from the selection
+//   - D:       Clear the selection
+//   - v:       Start/stop marking a range in the selection
 //   - TAB:     Toggle focus between list and details
 //
 // # Example
@@ -29,17 +33,23 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"a-la-carte/internal/app"
+	"a-la-carte/internal/app/provision"
 	"a-la-carte/internal/config"
 	"a-la-carte/internal/flags"
 	"a-la-carte/internal/ui/components"
 	"a-la-carte/internal/ui/core"
 	"a-la-carte/internal/ui/patterns"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -74,7 +84,9 @@ const (
 // # Fields
 //
 //   - manifest:     The loaded software manifest.
-//   - loadErr:      Any error encountered during manifest loading.
+//   - index:        The lookups over manifest the search uses, built when it is loaded (see manifestIndex)
+//   - loading, spinner: Whether the manifests are being loaded in the background, and the spinner of the loading screen
+//   - loadErr:      Any error encountered during manifest loading, shown with a retry hint
 //   - entries:      All manifest keys, sorted.
 //   - visible:      Filtered keys based on search.
 //   - uiActiveListIndex:     Index of the currently selected entry.
@@ -83,11 +95,45 @@ const (
 //   - detailScroll: Scroll offset for the details panel.
 //   - selectedKeys: Keys of software selected for the right pane.
 //   - softwarePaneLeft: Track which pane is active in software focus: true=left, false=right
-//   - showHelp:     Whether to show the help overlay
+//   - marking, markAnchor: Whether a range is being marked in the right pane, and where it starts
+//   - showHelp, help: Whether to show the help overlay, and the help screen generated from the keymap
+//   - showDiff, diff, diffErr: Whether to show the diff view, and the comparison it shows (nil while computing)
+//   - showDiagnostics, duplicates: Whether to show the diagnostics view, and the executables and packages entries define under different keys
+//   - profileSwitcher: The profile switcher overlay
+//   - entryEditor:  The overlay for creating and editing manifest entries
+//   - manifestSources: The manifest files, in priority order; edited entries are written back to them
+//   - manifestStamps, added: The versions of the manifest files last loaded, watched for changes, and the keys the last change added, highlighted in the lists
+//   - watcher: Watches the manifest files for changes; started by Init
+//   - notInstallable: Keys that have no install method on this platform, with the reason
+//   - inapplicable: Keys whose _when condition is false on this system, greyed out in the lists
+//   - expandedMeta: Meta-packages whose member list is expanded in the details panel
+//   - keyPlans, installed: The plans of highlighted keys shown in the details panel, and the installed packages they were planned against
+//   - columnView, sortColumn, sortDesc: Whether the left list shows columns, and the column (1-4) and direction it is sorted by
+//   - installers:   The installer of each key on this platform, for the installer column
+//   - collapsedGroups, groupSizes: Groups (lower-cased) collapsed under their heading when the list is grouped, and the size of each heading's group
+//   - undoStack, redoStack: Selections from before each selection change, for u, and from before each undo, for ctrl+r
+//   - state, statePath: What the picker remembers between sessions (sort mode, recent selections), and where it is saved
+//   - profile:      The profile last applied, shown in the status bar
+//   - namespaces:   Names of the configured manifests in priority order (empty for a single manifest)
+//   - updateAvailable, refreshing: Whether a newer remote manifest exists, and whether it is being fetched
+//   - notice:       A one-line message shown in the footer while something runs, e.g. a manifest refresh
+//   - overlays:     The dialogs open over the picker, such as the profile switcher; the top one gets key input
+//   - confirmDialog: The dialog asking whether to save unsaved changes before quitting
+//   - savedKeys:    The selection as last saved or loaded; q asks before quitting when the selection differs
+//   - clipboard, copyTexts: Copies text to the clipboard (systemClipboard when nil), and the text of each answer of the open copy menu
+//   - osc52Output: The program's output, to copy with OSC 52 when no clipboard tool is installed (nil when the terminal does not support it)
+//   - openURL, openURLs: Opens a URL in the browser (openBrowser when nil), and the URL of each answer of the open link menu
+//   - preview, previewKey: The dialog showing the commands installing an entry would run, and the entry it shows
+//   - title:        The terminal title last set, e.g. "à la carte — 12 selected"
+//   - toasts, toastCmds: Transient notifications shown over the picker, and the timers of those queued by the message being handled
 //   - layout:       The layout for the TUI
+//   - leftPanel, rightPanel, detailsContainer: The pane containers, kept across frames so their focus state persists
 //   - width, height: The window size
 type model struct {
 	manifest          app.Manifest
+	index             *app.ManifestIndex
+	loading           bool
+	spinner           spinner.Model
 	loadErr           error
 	entries           []string // sorted keys
 	visible           []string // filtered keys (left pane, excludes selected)
@@ -99,13 +145,63 @@ type model struct {
 	selectedKeys []string // keys of selected software (right pane)
 	// track which pane is active in software focus: true=left, false=right
 	softwarePaneLeft bool
+	marking          bool // whether a range is being marked in the right pane
+	markAnchor       int  // start of the marked range in selectedKeys
 	showHelp         bool // whether to show the help overlay
+	help             *components.KeymapHelpModel
+	showDiff         bool
+	diff             *provision.PlanDiff
+	diffErr          error
+	showDiagnostics  bool
+	duplicates       []app.Duplicate
+	profileSwitcher  *components.ProfileSwitcherModel
+	entryEditor      *components.EntryEditorModel
+	manifestSources  []app.ManifestSource
+	manifestStamps   map[string]manifestStamp
+	watcher          *manifestWatcher
+	added            map[string]bool   // keys added by the last change to a manifest file
+	notInstallable   map[string]string // key -> reason it cannot be installed on this platform
+	inapplicable     map[string]bool   // keys whose _when condition is false on this system
+	expandedMeta     map[string]bool   // meta-packages whose member list is expanded
+	keyPlans         map[string]*keyPlan
+	installed        map[string]bool
+	columnView       bool // whether the left list shows columns
+	sortColumn       int  // column the left list is sorted by (0: manifest order)
+	sortDesc         bool
+	installers       map[string]string // key -> installer used on this platform
+	collapsedGroups  map[string]bool
+	groupSizes       map[string]int
+	undoStack        [][]string
+	redoStack        [][]string
+	state            *tuiState
+	statePath        string
+	profile          string
+	namespaces       []string
+	updateAvailable  bool
+	refreshing       bool
+	notice           string
+	overlays         *core.OverlayManager
+	confirmDialog    *components.ConfirmDialogModel
+	savedKeys        []string
+	clipboard        func(text string) (via string, err error)
+	copyTexts        map[string]string
+	osc52Output      io.Writer
+	openURL          func(url string) error
+	openURLs         map[string]string
+	preview          *components.TextDialogModel
+	previewKey       string
+	title            string
+	toasts           *components.ToastsModel
+	toastCmds        []tea.Cmd
 
 	// Configuration
 	config *config.Config
 
 	// Layout
 	topSplitPane      patterns.SplitPaneLayout
+	leftPanel         core.Container
+	rightPanel        core.Container
+	detailsContainer  core.Container
 	width, height     int
 	contentWidth      int
 	detailsPanelModel tea.Model
@@ -114,6 +210,15 @@ type model struct {
 // layoutMetrics is initialized in Init() to ensure all computed values are available // Changed variable name
 var layoutMetrics *core.LayoutMetrics // Changed from ui.LayoutMetrics
 
+// manifestIndex returns the index of the manifest, building it if the manifest was set
+// without reloadManifest.
+func (m *model) manifestIndex() *app.ManifestIndex {
+	if m.index == nil {
+		m.index = app.NewManifestIndex(m.manifest)
+	}
+	return m.index
+}
+
 // filterEntriesByQuery returns entries that match the given search query
 func (m *model) filterEntriesByQuery(query string) []string {
 	if query == "" {
@@ -121,13 +226,29 @@ func (m *model) filterEntriesByQuery(query string) []string {
 	}
 
 	candidateKeys := []string{}
-	lowerQuery := strings.ToLower(query)
+	q := parseFilterQuery(query)
+	lowerQuery := strings.ToLower(q.text)
+	index := m.manifestIndex()
+	var recent map[string]bool
+	if q.recent {
+		recent = make(map[string]bool)
+		for _, key := range m.rankedRecent(q.recentLimit) {
+			recent[key] = true
+		}
+	}
 
 	for _, key := range m.entries {
 		entry := m.manifest[key]
-		if strings.Contains(strings.ToLower(entry.Name), lowerQuery) ||
-			strings.Contains(strings.ToLower(key), lowerQuery) ||
-			strings.Contains(strings.ToLower(entry.Desc), lowerQuery) {
+		if len(q.tags) > 0 && !entry.HasAnyTag(q.tags) {
+			continue
+		}
+		if len(q.tiers) > 0 && !entry.InAnyTier(q.tiers) {
+			continue
+		}
+		if q.recent && !recent[key] {
+			continue
+		}
+		if index.Matches(key, lowerQuery) {
 			candidateKeys = append(candidateKeys, key)
 		}
 	}
@@ -135,6 +256,58 @@ func (m *model) filterEntriesByQuery(query string) []string {
 	return candidateKeys
 }
 
+// filterQuery is a search query split into its qualifiers and the remaining text.
+//
+// # Fields
+//   - text:        The text entries must match
+//   - tags, tiers: From "tag:<name>" and "tier:<name>"; entries must have one of the tags and be in one of the tiers
+//   - recent:      Whether "recent:" or "recent:<n>" was given; entries must be among the recently selected keys
+//   - recentLimit: The n of "recent:<n>", the number of most frequently and recently selected keys (0: all remembered)
+type filterQuery struct {
+	text        string
+	tags, tiers []string
+	recent      bool
+	recentLimit int
+}
+
+// parseFilterQuery separates the "tag:", "tier:" and "recent:" qualifiers from the
+// rest of a search query.
+//
+// # Example
+//
+//	parseFilterQuery("tag:cli tier:core recent:5 git") // text "git", tags [cli], tiers [core], the 5 top recent keys
+func parseFilterQuery(query string) filterQuery {
+	var q filterQuery
+	var words []string
+	for _, word := range strings.Fields(query) {
+		if tag, ok := strings.CutPrefix(word, "tag:"); ok {
+			if tag != "" {
+				q.tags = append(q.tags, tag)
+			}
+			continue
+		}
+		if tier, ok := strings.CutPrefix(word, "tier:"); ok {
+			if tier != "" {
+				q.tiers = append(q.tiers, tier)
+			}
+			continue
+		}
+		if limit, ok := strings.CutPrefix(word, "recent:"); ok {
+			if n, err := strconv.Atoi(limit); limit == "" || err == nil && n > 0 {
+				q.recent, q.recentLimit = true, n
+				continue
+			}
+		}
+		words = append(words, word)
+	}
+	if len(q.tags) == 0 && len(q.tiers) == 0 && !q.recent {
+		q.text = query
+	} else {
+		q.text = strings.Join(words, " ")
+	}
+	return q
+}
+
 // excludeSelectedKeys filters out keys that are already in the selected list
 func (m *model) excludeSelectedKeys(candidates []string) []string {
 	selectedSet := make(map[string]struct{})
@@ -180,6 +353,7 @@ func (m *model) filter() {
 	query := m.searchBar.GetSearch()
 	candidateKeys := m.filterEntriesByQuery(query)
 	m.visible = m.excludeSelectedKeys(candidateKeys)
+	m.arrangeVisible(query != "")
 	m.clampActiveListIndex()
 }
 
@@ -187,9 +361,12 @@ func (m *model) Init() tea.Cmd {
 	metrics := core.DefaultLayoutMetrics() // Get the value
 	layoutMetrics = &metrics               // Assign its address
 
+	m.leftPanel = patterns.Panel(core.EmptyModel(), core.WithAriaLabel("Available software"))
+	m.rightPanel = patterns.Panel(core.EmptyModel(), core.WithAriaLabel("Selected software"))
+	m.detailsContainer = patterns.Panel(core.EmptyModel(), core.WithAriaLabel("Details"))
 	m.topSplitPane = patterns.NewSplitPane(
-		patterns.WithLeftPanel(patterns.Panel(core.EmptyModel())),
-		patterns.WithRightPanel(patterns.Panel(core.EmptyModel())),
+		patterns.WithLeftPanel(m.leftPanel),
+		patterns.WithRightPanel(m.rightPanel),
 		patterns.WithRatio(core.SplitPaneRatio),
 		// No WithBottomPanel or WithVerticalRatio here
 	)
@@ -213,6 +390,14 @@ func (m *model) Init() tea.Cmd {
 	if m.detailsPanelModel != nil {
 		initCmds = append(initCmds, m.detailsPanelModel.Init())
 	}
+	if m.config != nil && m.config.Software.ManifestURL != "" {
+		initCmds = append(initCmds, checkManifestUpdate(m.config.Software.ManifestURL))
+	}
+	if m.loading {
+		initCmds = append(initCmds, m.startLoading())
+	}
+	m.watcher = newManifestWatcher(m.manifestSources)
+	initCmds = append(initCmds, m.watcher.next())
 
 	return tea.Batch(initCmds...)
 }
@@ -238,19 +423,49 @@ func (m *model) handleDetailsInput(key string) *model {
 	return m
 }
 
-// handleHelpKey handles key input when help is shown
-func (m *model) handleHelpKey(key string) (tea.Model, tea.Cmd) {
-	switch key {
+// handleHelpKey handles key input when help is shown: the filter box gets it while
+// open, and otherwise Esc or h closes the help and q quits.
+func (m *model) handleHelpKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	help := m.helpScreen()
+	if help.Filtering() {
+		help.Update(msg)
+		return m, nil
+	}
+	switch msg.String() {
 	case "esc", "h":
 		m.showHelp = false
 		return m, nil
 	case "q":
-		return m, tea.Quit
+		return m, m.requestQuit()
 	default:
+		help.Update(msg)
 		return m, nil
 	}
 }
 
+// applyProfile replaces the current selection with the keys of the named profile.
+// Keys that are not present in the manifest are ignored.
+func (m *model) applyProfile(name string) {
+	if name == "" || m.config == nil {
+		return
+	}
+	keys, err := m.config.Profile(name)
+	if err != nil {
+		return
+	}
+	m.selectedKeys = []string{}
+	m.marking = false
+	for _, key := range keys {
+		if resolved, exists := m.manifest.Resolve(key, "", m.namespaces); exists {
+			m.selectedKeys = append(m.selectedKeys, resolved)
+		}
+	}
+	sort.Strings(m.selectedKeys)
+	m.profile = name
+	m.filter()
+	m.toast(components.ToastSuccess, fmt.Sprintf("Applied profile %s (%d selected)", name, len(m.selectedKeys)))
+}
+
 // handleSearchKey handles key input when search is active
 func (m *model) handleSearchKey(msg tea.Msg) (tea.Model, tea.Cmd) {
 	updatedSearchBar, searchCmd := m.searchBar.Update(msg)
@@ -265,12 +480,69 @@ func (m *model) handleGeneralKey(key string) (tea.Model, tea.Cmd) {
 	case "ctrl+c":
 		return m, tea.Quit
 	case "q":
-		return m, tea.Quit
+		return m, m.requestQuit()
+	case "ctrl+s":
+		if path, err := m.saveSelection(); err != nil {
+			m.toast(components.ToastError, "Cannot save the selection: "+err.Error())
+		} else {
+			m.toast(components.ToastSuccess, fmt.Sprintf("Saved the selection (%d selected) to %s", len(m.selectedKeys), path))
+		}
+		return m, nil
 	case "h":
 		m.showHelp = !m.showHelp
+		m.helpScreen().Reset()
+		return m, nil
+	case "p":
+		if m.profileSwitcher != nil {
+			m.profileSwitcher.Show()
+			m.openOverlay(m.profileSwitcher)
+		}
 		return m, nil
 	case "tab":
 		return m.handleTab(), nil
+	case "u":
+		m.undo()
+		return m, nil
+	case "ctrl+r":
+		m.redo()
+		return m, nil
+	case "U":
+		return m, m.startManifestRefresh()
+	case "i":
+		return m, m.toggleDiff()
+	case "!":
+		m.showDiagnostics = !m.showDiagnostics
+		return m, nil
+	case "e":
+		if m.focus == focusSoftware {
+			if key := m.highlightedKey(); key != "" {
+				m.startEdit(key)
+			}
+		}
+		return m, nil
+	case "n":
+		m.startEdit("")
+		return m, nil
+	case "y":
+		m.startCopy()
+		return m, nil
+	case "o":
+		m.startOpen()
+		return m, nil
+	case "w":
+		return m, m.startPreview()
+	case "x":
+		m.toggleMembers()
+		return m, nil
+	case "c":
+		return m, m.toggleColumnView()
+	case "s":
+		return m, m.cycleSort()
+	case "1", "2", "3", "4":
+		if m.columnView {
+			m.sortByColumn(int(key[0] - '0'))
+		}
+		return m, nil
 	}
 
 	if m.loadErr != nil {
@@ -345,23 +617,147 @@ func (m *model) propagateUpdates(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// Update handles a message, then starts the timers of the toasts it queued and updates
+// the terminal title.
 func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if expired, ok := msg.(components.ToastExpiredMsg); ok {
+		if m.toasts != nil {
+			m.toasts.Update(expired)
+		}
+		return m, nil
+	}
+	updated, cmd := m.update(msg)
+	cmds := append([]tea.Cmd{cmd, m.titleCmd()}, m.toastCmds...)
+	m.toastCmds = nil
+	return updated, tea.Batch(cmds...)
+}
+
+// openOverlay shows a dialog over the picker, on top of any already open.
+func (m *model) openOverlay(layer core.Layer) {
+	if m.overlays == nil {
+		m.overlays = core.NewOverlayManager()
+	}
+	m.overlays.Open(layer)
+}
+
+// toast shows a transient notification over the picker, e.g. the result of an action.
+func (m *model) toast(level components.ToastLevel, text string) {
+	if m.toasts == nil {
+		m.toasts = components.NewToastsModel()
+	}
+	m.toastCmds = append(m.toastCmds, m.toasts.Push(level, text))
+}
+
+func (m *model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// Follow the terminal switching between light and dark mode
+	if core.HandleColorSchemeReport(msg) {
+		return m, nil
+	}
+
+	// Handle the manifests loading in the background
+	if loaded, ok := msg.(manifestLoadedMsg); ok {
+		return m, m.handleManifestLoaded(loaded)
+	}
+	switch msg := msg.(type) {
+	case manifestWatchMsg:
+		return m, m.handleManifestWatch(msg)
+	case manifestChangedMsg:
+		return m, m.handleManifestChanged(msg)
+	}
+	if tick, ok := msg.(spinner.TickMsg); ok {
+		if !m.loading {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(tick)
+		return m, cmd
+	}
+	if m.loading || m.loadErr != nil {
+		if keyMsg, ok := msg.(tea.KeyMsg); ok {
+			return m.handleLoadingKey(keyMsg.String())
+		}
+	}
+
 	// Handle help mode
 	if m.showHelp && !m.searchBar.IsSearching() {
 		if keyMsg, ok := msg.(tea.KeyMsg); ok {
-			return m.handleHelpKey(keyMsg.String())
+			return m.handleHelpKey(keyMsg)
 		}
 		return m, nil
 	}
 
+	// Handle diff view
+	if diff, ok := msg.(diffMsg); ok {
+		m.diff, m.diffErr = &diff.diff, diff.err
+		return m, nil
+	}
+	if plan, ok := msg.(keyPlanMsg); ok {
+		m.handleKeyPlanMsg(plan)
+		return m, nil
+	}
+	if installed, ok := msg.(installedMsg); ok {
+		m.installed = installed.installed
+		m.filter()
+		return m, nil
+	}
+	if m.showDiff && !m.searchBar.IsSearching() {
+		if keyMsg, ok := msg.(tea.KeyMsg); ok {
+			return m.handleDiffKey(keyMsg.String())
+		}
+	}
+	if m.showDiagnostics && !m.searchBar.IsSearching() {
+		if keyMsg, ok := msg.(tea.KeyMsg); ok {
+			return m.handleDiagnosticsKey(keyMsg.String())
+		}
+	}
+
+	// Route key input to the dialog on top, e.g. the profile switcher or entry editor
+	if m.overlays != nil && m.overlays.Active() {
+		if keyMsg, ok := msg.(tea.KeyMsg); ok {
+			if keyMsg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			cmd, _ := m.overlays.HandleKey(keyMsg)
+			return m, cmd
+		}
+	}
+	switch msg := msg.(type) {
+	case components.ProfileSelectedMsg:
+		before := slices.Clone(m.selectedKeys)
+		m.applyProfile(msg.Name)
+		m.trackSelection(before)
+		return m, nil
+	case components.EntryEditorSubmittedMsg, components.EntryEditorCancelledMsg:
+		return m.handleEntryEditorMsg(msg)
+	case components.ConfirmedMsg:
+		return m, m.handleConfirmed(msg)
+	case copiedMsg:
+		m.handleCopied(msg)
+		return m, nil
+	}
+
 	// Handle search mode
 	if m.searchBar.IsSearching() {
-		return m.handleSearchKey(msg)
+		updated, cmd := m.handleSearchKey(msg)
+		return updated, tea.Batch(cmd, m.planHighlighted())
 	}
 
-	// Handle key messages
+	// Handle key messages, then plan the entry that is highlighted now.
+	// Selection changes are recorded for undo, except by undo and redo themselves.
 	if keyMsg, ok := msg.(tea.KeyMsg); ok {
-		return m.handleGeneralKey(keyMsg.String())
+		key := keyMsg.String()
+		before := slices.Clone(m.selectedKeys)
+		updated, cmd := m.handleGeneralKey(key)
+		if key != "u" && key != "ctrl+r" {
+			m.trackSelection(before)
+		}
+		return updated, tea.Batch(cmd, m.planHighlighted())
+	}
+
+	// Handle the remote manifest update checker
+	switch msg.(type) {
+	case manifestUpdateMsg, manifestReloadedMsg:
+		return m.handleManifestMsg(msg)
 	}
 
 	// Handle window size changes
@@ -378,6 +774,7 @@ func (m *model) handleTab() *model {
 	if m.focus == focusSoftware {
 		m.focus = focusDetails
 		m.detailScroll = 0
+		m.marking = false
 		// Clamp uiActiveListIndex to valid range for visible or selectedKeys
 		if m.softwarePaneLeft && len(m.visible) > 0 {
 			if m.uiActiveListIndex >= len(m.visible) {
@@ -428,7 +825,12 @@ func (m *model) handleLeftPaneKey(key string) *model {
 		if m.uiActiveListIndex > 0 {
 			m.uiActiveListIndex--
 		}
+	case "left":
+		m.collapseHighlightedGroup()
 	case "right":
+		if m.expandHighlightedGroup() {
+			break
+		}
 		// switch to right pane if any selected
 		if len(m.selectedKeys) > 0 {
 			m.softwarePaneLeft = false
@@ -449,6 +851,19 @@ func (m *model) handleRightPaneKey(key string) *model {
 	switch key {
 	case "enter":
 		m.moveToDeselected()
+	case "d", "delete":
+		if m.marking {
+			m.removeMarked()
+		} else {
+			m.moveToDeselected()
+		}
+	case "D":
+		m.clearSelected()
+	case "v":
+		if len(m.selectedKeys) > 0 {
+			m.marking = !m.marking
+			m.markAnchor = m.uiActiveListIndex
+		}
 	case "down", "j":
 		if m.uiActiveListIndex < len(m.selectedKeys)-1 {
 			m.uiActiveListIndex++
@@ -461,6 +876,7 @@ func (m *model) handleRightPaneKey(key string) *model {
 		// switch to left pane if any visible
 		if len(m.visible) > 0 {
 			m.softwarePaneLeft = true
+			m.marking = false
 			// Adjust uiActiveListIndex for the new pane
 			if m.uiActiveListIndex >= len(m.visible) {
 				m.uiActiveListIndex = len(m.visible) - 1
@@ -502,6 +918,9 @@ func (m *model) detailLines(availableWidth int) []string { // Added availableWid
 		if len(m.visible) == 0 || m.uiActiveListIndex < 0 || m.uiActiveListIndex >= len(m.visible) {
 			return m.noDetails(availableWidth) // Pass availableWidth
 		}
+		if group, heading := groupHeading(m.visible[m.uiActiveListIndex]); heading {
+			return m.groupDetails(group)
+		}
 		return m.detailsForKey(m.visible[m.uiActiveListIndex], availableWidth) // Pass availableWidth
 	}
 }
@@ -522,6 +941,27 @@ func (m *model) detailsForKey(key string, availableWidth int) []string { // Adde
 		styles.DetailKey.Render("Key: ") + detailValueStyle.Render(key),
 		styles.DetailKey.Render("Desc: ") + detailValueStyle.Render(entry.Desc),
 	}
+	if len(entry.Aliases) > 0 {
+		logical = append(logical, styles.DetailKey.Render("Aliases: ")+detailValueStyle.Render(strings.Join(entry.Aliases, ", ")))
+	}
+	if reason, blocked := m.notInstallable[key]; blocked {
+		logical = append(logical, styles.DetailKey.Render("Install: ")+styles.ErrorStyle.Render("Not installable here: "+reason))
+	}
+	if entry.When != "" {
+		logical = append(logical, styles.DetailKey.Render("When: ")+detailValueStyle.Render(entry.When))
+	}
+	if len(entry.Provides) > 0 {
+		logical = append(logical, styles.DetailKey.Render("Provides: ")+detailValueStyle.Render(strings.Join(entry.Provides, ", ")))
+	}
+	for _, group := range entry.DepsAny {
+		logical = append(logical, styles.DetailKey.Render("Needs one of: ")+detailValueStyle.Render(strings.Join(group, " | ")))
+	}
+	if len(entry.Conflicts) > 0 {
+		logical = append(logical, styles.DetailKey.Render("Conflicts: ")+detailValueStyle.Render(strings.Join(entry.Conflicts, ", ")))
+	}
+	if detail := m.conflictDetail(key); detail != "" {
+		logical = append(logical, styles.DetailKey.Render("Selection: ")+styles.ErrorStyle.Render(detail))
+	}
 	if len(entry.Bin) > 0 {
 		logical = append(logical, styles.DetailKey.Render("Bin: ")+detailValueStyle.Render(strings.Join(entry.Bin, ", ")))
 	}
@@ -534,6 +974,10 @@ func (m *model) detailsForKey(key string, availableWidth int) []string { // Adde
 	if len(entry.Pacman) > 0 {
 		logical = append(logical, styles.DetailKey.Render("Pacman: ")+detailValueStyle.Render(strings.Join(entry.Pacman, ", ")))
 	}
+	// Platform-specific variants, e.g. apt:debian:x64
+	for _, installer := range slices.Sorted(maps.Keys(entry.Installers)) {
+		logical = append(logical, styles.DetailKey.Render(installer+": ")+detailValueStyle.Render(strings.Join(entry.Installers[installer], ", ")))
+	}
 	if entry.Docs != "" {
 		logical = append(logical, styles.DetailKey.Render("Docs: ")+detailValueStyle.Render(entry.Docs))
 	}
@@ -543,6 +987,13 @@ func (m *model) detailsForKey(key string, availableWidth int) []string { // Adde
 	if entry.Home != "" {
 		logical = append(logical, styles.DetailKey.Render("Home: ")+detailValueStyle.Render(entry.Home))
 	}
+	if entry.IsMeta() {
+		logical = append(logical, m.memberLines(key, detailValueStyle)...)
+	}
+	logical = append(logical, m.planLines(key, detailValueStyle)...)
+	if !entry.IsMeta() {
+		logical = append(logical, matrixLines(m.manifest, key, detailValueStyle)...)
+	}
 	// Flatten to terminal lines
 	var lines []string
 	// Use availableWidth for wrapping, adjusted by DetailsPanelWrapPadding
@@ -567,28 +1018,20 @@ func (m *model) noDetails(_ int) []string { // Added availableWidth parameter
 	}
 }
 
-// renderHelpView renders the help screen content.
+// renderHelpView renders the help screen, generated from the keymap.
 func (m *model) renderHelpView(width int) string {
 	helpStyle := lipgloss.NewStyle().Width(width).Padding(1, 2)
-	helpTitle := core.CurrentStyles().HeaderStyle.Render("Help")
-	helpBody := `
-Keyboard Controls:
-  ↑/↓/j/k:  Move selection
-  Enter:    Select/Deselect item (in software lists)
-            (No action in details panel from Enter)
-  Tab:      Toggle focus (Software Lists ↔ Details Panel)
-  /:        Start search (when focus is on Software Lists)
-  Esc:      Cancel search / Close Help
-  h:        Toggle Help
-  q:        Quit
-
-Focus Areas:
-  - Software Lists: Left (Available) and Right (Selected) panes.
-    - Use ←/→ to switch between Left and Right panes when focus is on Software Lists.
-  - Details Panel: Shows information about the currently highlighted item.
-    - Use ↑/↓/j/k to scroll content within the Details Panel.
-`
-	return helpStyle.Render(lipgloss.JoinVertical(lipgloss.Left, helpTitle, helpBody))
+	help := m.helpScreen()
+	help.SetSize(max(width-4, 0), max(m.height-cardTotalHorizontalOverhead-2, 0))
+	return helpStyle.Render(help.View())
+}
+
+// helpScreen returns the help screen, creating it on first use.
+func (m *model) helpScreen() *components.KeymapHelpModel {
+	if m.help == nil {
+		m.help = components.NewKeymapHelpModel("Help", keymap)
+	}
+	return m.help
 }
 
 func renderHeader(title string, width int) string {
@@ -608,6 +1051,14 @@ func (m *model) moveToSelected() {
 	}
 
 	keyToMove := m.visible[m.uiActiveListIndex]
+	if group, heading := groupHeading(keyToMove); heading {
+		m.setGroupCollapsed(group, !m.collapsedGroups[strings.ToLower(group)])
+		return
+	}
+	if m.state != nil {
+		m.state.touch(keyToMove)
+		m.saveState()
+	}
 
 	// Add to selectedKeys
 	m.selectedKeys = append(m.selectedKeys, keyToMove)
@@ -629,6 +1080,58 @@ func (m *model) moveToSelected() {
 	}
 }
 
+// markedRange returns the bounds (inclusive) of the marked range in selectedKeys.
+func (m *model) markedRange() (from, to int) {
+	from, to = m.markAnchor, m.uiActiveListIndex
+	if from > to {
+		from, to = to, from
+	}
+	return from, to
+}
+
+// isMarked reports whether the right-pane item at index is in the marked range.
+func (m *model) isMarked(index int) bool {
+	if !m.marking || m.softwarePaneLeft {
+		return false
+	}
+	from, to := m.markedRange()
+	return index >= from && index <= to
+}
+
+// removeMarked moves every item in the marked range back to the left pane.
+func (m *model) removeMarked() {
+	from, to := m.markedRange()
+	m.marking = false
+	if from < 0 || to >= len(m.selectedKeys) {
+		return
+	}
+	m.selectedKeys = append(m.selectedKeys[:from:from], m.selectedKeys[to+1:]...)
+	m.uiActiveListIndex = from
+	m.filter()
+	m.clampAfterRemoval()
+}
+
+// clearSelected moves every selected item back to the left pane.
+func (m *model) clearSelected() {
+	m.selectedKeys = []string{}
+	m.marking = false
+	m.filter()
+	m.clampAfterRemoval()
+}
+
+// clampAfterRemoval fixes the cursor after items were removed from the right pane,
+// switching to the left pane once the selection is empty.
+func (m *model) clampAfterRemoval() {
+	if len(m.selectedKeys) == 0 {
+		m.softwarePaneLeft = true
+		m.uiActiveListIndex = 0
+		return
+	}
+	if m.uiActiveListIndex >= len(m.selectedKeys) {
+		m.uiActiveListIndex = len(m.selectedKeys) - 1
+	}
+}
+
 func (m *model) moveToDeselected() {
 	// This function moves an item from the right pane (m.selectedKeys) to the left pane (m.visible)
 	if m.softwarePaneLeft || len(m.selectedKeys) == 0 || m.uiActiveListIndex < 0 || m.uiActiveListIndex >= len(m.selectedKeys) {
@@ -692,6 +1195,11 @@ func loadConfig(opts *flags.Options) (*config.Config, error) {
 		cfg = config.DefaultConfig()
 	}
 
+	// Override with A_LA_CARTE_* environment variables, e.g. A_LA_CARTE_UI_THEME
+	if err := cfg.ApplyEnv(os.Getenv); err != nil {
+		return nil, fmt.Errorf("invalid environment override: %w", err)
+	}
+
 	// Override with command line flags if provided
 	if opts.Debug {
 		cfg.System.DebugMode = true
@@ -701,11 +1209,28 @@ func loadConfig(opts *flags.Options) (*config.Config, error) {
 	if opts.ManifestPath != "" {
 		cfg.Software.ManifestPath = opts.ManifestPath
 	}
+	if opts.Strict {
+		cfg.Software.Strict = true
+	}
 
 	// Override emoji setting if no-emojis flag is specified
 	if opts.NoEmojis {
 		cfg.UI.EmojisEnabled = false
 	}
+	if opts.NoTitle {
+		cfg.UI.TerminalTitle = false
+	}
+
+	// A11y mode is drawn in ASCII, and ASCII mode has no emojis either
+	if opts.A11y {
+		cfg.UI.A11y = true
+	}
+	if opts.ASCII || cfg.UI.A11y {
+		cfg.UI.ASCII = true
+	}
+	if cfg.UI.ASCII {
+		cfg.UI.EmojisEnabled = false
+	}
 
 	// Validate the configuration
 	if err := cfg.Validate(); err != nil {
@@ -715,68 +1240,121 @@ func loadConfig(opts *flags.Options) (*config.Config, error) {
 	return cfg, nil
 }
 
-// initializeModel creates a new model with the given configuration
-func initializeModel(cfg *config.Config) (*model, error) {
-	// Validate the manifest path
-	if err := cfg.ValidateManifestPath(); err != nil {
-		return nil, fmt.Errorf("manifest validation error: %w", err)
+// matrixLines renders the "Matrix" details section: the installer and package the
+// entry resolves to on each simulated platform, to debug advanced installer keys.
+func matrixLines(manifest app.Manifest, key string, valueStyle lipgloss.Style) []string {
+	styles := core.CurrentStyles()
+	lines := []string{"", styles.HeaderStyle.Render("Matrix")}
+	for _, cell := range provision.NewProvisioner(nil, manifest, nil).Matrix(key) {
+		value := styles.DimStyle.Render("not installable")
+		if cell.Installer != "" {
+			value = valueStyle.Render(cell.Installer + " " + cell.Package)
+		}
+		lines = append(lines, styles.DetailKey.Render(cell.Platform+": ")+value)
+	}
+	return lines
+}
+
+// findNotInstallable returns the manifest keys that have no install method for
+// the running platform, mapped to the reason.
+func findNotInstallable(manifest app.Manifest) map[string]string {
+	prov := provision.NewProvisioner(provision.DetectSystem(), manifest, nil)
+	result := make(map[string]string)
+	for key := range manifest {
+		if ok, reason := prov.Installability(key); !ok {
+			result[key] = reason
+		}
 	}
+	return result
+}
 
-	// Resolve the manifest path to its absolute form
-	manifestPath := cfg.ResolveManifestPath()
+// findInapplicable returns the manifest keys whose _when condition is false on the
+// running platform.
+func findInapplicable(manifest app.Manifest) map[string]bool {
+	prov := provision.NewProvisioner(provision.DetectSystem(), manifest, nil)
+	result := make(map[string]bool)
+	for key, entry := range manifest {
+		if entry.When != "" && !prov.Applies(key) {
+			result[key] = true
+		}
+	}
+	return result
+}
 
-	// Load the software manifest
-	manifestData, err := app.LoadManifest(manifestPath)
-	if err != nil {
-		return nil, fmt.Errorf("error loading manifest from %s: %w", manifestPath, err)
+// manifestSources returns the manifests of cfg to load, in priority order. Templates
+// render with the built-in engine when chezmoi is not installed.
+func manifestSources(cfg *config.Config) []app.ManifestSource {
+	var sources []app.ManifestSource
+	for _, named := range cfg.ResolveManifests() {
+		sources = append(sources, app.ManifestSource{Name: named.Name, Path: named.Path, Strict: cfg.Software.Strict, Render: provision.RenderTemplate})
 	}
+	return sources
+}
 
-	// Get sorted keys from the manifest
-	var entries []string
-	for k := range manifestData {
-		entries = append(entries, k)
+// initializeModel creates a new model with the given configuration. The manifests are
+// loaded in the background once the program starts (see loadManifests).
+func initializeModel(cfg *config.Config) *model {
+	// Resolve the manifest paths to their absolute form, in priority order
+	sources := manifestSources(cfg)
+	var namespaces []string
+	for _, src := range sources {
+		if src.Name != "" {
+			namespaces = append(namespaces, src.Name)
+		}
 	}
-	sort.Strings(entries)
 
 	// Create the initial model
 	m := &model{
-		manifest:          manifestData,
-		entries:           entries,
-		visible:           append([]string{}, entries...), // Initially all entries are visible
-		selectedKeys:      []string{},                     // Initially no keys are selected
+		selectedKeys:      []string{}, // Initially no keys are selected
 		softwarePaneLeft:  true,
 		focus:             focusSoftware,
 		uiActiveListIndex: 0,
 		config:            cfg,
-	}
-
-	// Add preloaded keys to selected keys if they exist in the manifest
-	for _, key := range cfg.Software.PreloadKeys {
-		if _, exists := manifestData[key]; exists {
-			m.selectedKeys = append(m.selectedKeys, key)
-		}
-	}
+		profileSwitcher:   components.NewProfileSwitcherModel(cfg.ProfileNames()),
+		toasts:            components.NewToastsModel(),
+		overlays:          core.NewOverlayManager(),
+		confirmDialog:     components.NewConfirmDialogModel(),
+		preview:           components.NewTextDialogModel(),
+		clipboard:         systemClipboard,
+		openURL:           openBrowser,
+		entryEditor:       components.NewEntryEditorModel(),
+		manifestSources:   sources,
+		columnView:        cfg.UI.ColumnView,
+		namespaces:        namespaces,
+		statePath:         defaultStatePath(),
+		loading:           true,
+		spinner:           spinner.New(spinner.WithSpinner(spinner.Dot)),
+	}
+	m.state = loadState(m.statePath)
+	return m
+}
 
-	// Sort the selected keys for consistency
-	if len(m.selectedKeys) > 0 {
-		sort.Strings(m.selectedKeys)
-	}
+// syncPaneFocus drives the focus state of the pane containers from the model's focus:
+// the focused pane gets the active border, the others the default one.
+func (m *model) syncPaneFocus() {
+	m.leftPanel.SetFocused(m.focus == focusSoftware && m.softwarePaneLeft)
+	m.rightPanel.SetFocused(m.focus == focusSoftware && !m.softwarePaneLeft)
+	m.detailsContainer.SetFocused(m.focus == focusDetails)
+}
 
-	// Ensure valid index when entries list is empty
-	if len(entries) == 0 {
-		m.uiActiveListIndex = 0
+// View renders the picker with its toasts on top.
+func (m *model) View() string {
+	if m.toasts == nil {
+		return m.view()
 	}
-
-	return m, nil
+	return m.toasts.Overlay(m.view())
 }
 
-func (m *model) View() string {
-	if m.loadErr != nil {
-		return fmt.Sprintf("Error loading manifest: %v\n", m.loadErr)
+func (m *model) view() string {
+	if m.loading || m.loadErr != nil {
+		return m.renderLoading()
 	}
 	if m.width == 0 || m.height == 0 { // Not yet initialized
 		return "Initializing..."
 	}
+	if m.tooSmall() {
+		return m.renderTooSmall()
+	}
 
 	// Header
 	titleText := "à la carte"
@@ -802,9 +1380,10 @@ func (m *model) View() string {
 	leftPaneContent := m.renderList(m.visible, m.softwarePaneLeft && m.focus == focusSoftware, leftPaneActualContentWidth, true)
 	rightPaneContent := m.renderList(m.selectedKeys, !m.softwarePaneLeft && m.focus == focusSoftware, rightPaneActualContentWidth, false)
 
-	// Update the content of the panels within the SplitPaneLayout interface
-	m.topSplitPane.SetLeftPanel(patterns.Panel(core.StringModel(leftPaneContent)))
-	m.topSplitPane.SetRightPanel(patterns.Panel(core.StringModel(rightPaneContent)))
+	// Update the content and focus state of the persistent pane containers
+	m.leftPanel.SetContent(core.StringModel(leftPaneContent))
+	m.rightPanel.SetContent(core.StringModel(rightPaneContent))
+	m.syncPaneFocus()
 	topSplitPaneView := m.topSplitPane.View()
 
 	// Details Panel
@@ -819,12 +1398,8 @@ func (m *model) View() string {
 	detailsPanelContent := m.detailsPanelModel.View()
 
 	// Container for Details Panel
-	detailsContainer := core.NewContainer(
-		core.StringModel(detailsPanelContent),
-		core.WithBorderAll(),     // Restore the border around the details panel
-		core.WithRoundedBorder(), // Match the rounded border style used in other panels
-		core.WithPaddingAll(1),   // Match padding used in other panels
-	)
+	detailsContainer := m.detailsContainer
+	detailsContainer.SetContent(core.StringModel(detailsPanelContent))
 	detailsContainerCtx := &core.LayoutContext{
 		AvailableWidth:  m.contentWidth,
 		AvailableHeight: detailHeight, // This is the target height for the container
@@ -841,9 +1416,21 @@ func (m *model) View() string {
 	if m.showHelp {
 		footerText = "Esc/h: Close Help | q: Quit"
 	} else {
-		footerText = "h: Help | /: Search | Tab: Focus | q: Quit"
+		footerText = "h: Help | /: Search | p: Profiles | Tab: Focus | q: Quit"
+		if m.updateAvailable && !m.refreshing {
+			footerText = "Manifest update available (U: Refresh) | " + footerText
+		} else if m.notice != "" {
+			footerText = m.notice + " | " + footerText
+		}
+		if warning := m.conflictWarning(); warning != "" {
+			footerText = warning + " | " + footerText
+		}
+		if warning := m.diagnosticsWarning(); warning != "" {
+			footerText += " | " + warning
+		}
 	}
 	footer := renderFooter(footerText, m.contentWidth)
+	statusBar := m.renderStatusBar(m.contentWidth)
 
 	// Assemble all parts into a vertical layout
 	panelLayout := lipgloss.JoinVertical(
@@ -852,6 +1439,7 @@ func (m *model) View() string {
 		searchBarView,
 		mainContentRendered,
 		footer,
+		statusBar,
 	)
 
 	// Wrap the entire layout in a Card.
@@ -875,6 +1463,21 @@ func (m *model) View() string {
 		return helpCard.View()
 	}
 
+	if m.showDiff {
+		diffCard := patterns.Card(core.StringModel(m.renderDiffView(m.contentWidth)))
+		diffCard.SetSize(m.width, m.height, cardCtx)
+		return diffCard.View()
+	}
+
+	if m.showDiagnostics {
+		diagnosticsCard := patterns.Card(core.StringModel(m.renderDiagnosticsView(m.contentWidth)))
+		diagnosticsCard.SetSize(m.width, m.height, cardCtx)
+		return diagnosticsCard.View()
+	}
+
+	if m.overlays != nil {
+		return m.overlays.View(finalView)
+	}
 	return finalView
 }
 
@@ -885,6 +1488,9 @@ func (m *model) renderList(keys []string, focused bool, width int, isLeftPane bo
 	if len(keys) == 0 {
 		return m.renderEmptyList(width, isLeftPane)
 	}
+	if isLeftPane && m.columnView {
+		return m.renderColumnList(keys, focused, width)
+	}
 
 	start, end := m.calculateVisibleRange(keys, displayableItems)
 	content := m.buildListContent(keys, start, end, focused, width)
@@ -946,9 +1552,14 @@ func (m *model) buildListContent(keys []string, start, end int, focused bool, wi
 		}
 
 		k := keys[i]
+		if group, heading := groupHeading(k); heading {
+			s.WriteString(m.formatHeadingLine(group, i, focused, width))
+			s.WriteString("\n")
+			continue
+		}
 		e := m.manifest[k]
 
-		formattedLine := m.formatItemLine(&e, i, focused, width)
+		formattedLine := m.formatItemLine(k, &e, i, focused, width)
 		s.WriteString(formattedLine)
 		s.WriteString("\n")
 	}
@@ -957,25 +1568,62 @@ func (m *model) buildListContent(keys []string, start, end int, focused bool, wi
 }
 
 // formatItemLine formats a single item line with appropriate styling
-func (m *model) formatItemLine(e *app.SoftwareEntry, index int, focused bool, width int) string {
-	styles := core.CurrentStyles()
-	itemStyle := styles.ItemStyle
-	if focused && index == m.uiActiveListIndex {
-		itemStyle = styles.ActiveItemStyle
-	}
+func (m *model) formatItemLine(key string, e *app.SoftwareEntry, index int, focused bool, width int) string {
+	itemStyle := m.itemStyle(key, index, focused)
 
 	textWidth := width - 2 // Corrected from width - 1
+	active := focused && index == m.uiActiveListIndex
+	if active && core.A11y() {
+		textWidth -= len(core.FocusedPrefix)
+	}
 	if textWidth < 0 {
 		textWidth = 0
 	}
 
-	line := m.formatItemText(e, textWidth)
+	line := m.formatItemText(key, e, textWidth)
+	if active {
+		line = core.Focused(line)
+	}
 	return itemStyle.Render(line)
 }
 
+// itemStyle returns the style of the list item at index: highlighted, marked, greyed
+// out when its _when condition is false, emphasized when a manifest change added it, or
+// plain.
+func (m *model) itemStyle(key string, index int, focused bool) lipgloss.Style {
+	styles := core.CurrentStyles()
+	switch {
+	case focused && index == m.uiActiveListIndex:
+		return styles.ActiveItemStyle
+	case focused && m.isMarked(index):
+		return styles.SelectedItemStyle
+	case m.inapplicable[key]:
+		return styles.DimStyle
+	case m.added[key]:
+		return styles.HighlightStyle
+	default:
+		return styles.ItemStyle
+	}
+}
+
 // formatItemText handles text formatting with or without emoji
-func (m *model) formatItemText(e *app.SoftwareEntry, textWidth int) string {
+func (m *model) formatItemText(key string, e *app.SoftwareEntry, textWidth int) string {
 	line := e.Name
+	if ns, _ := app.SplitKey(key); ns != "" {
+		line = ns + app.NamespaceSeparator + line
+	}
+	if _, blocked := m.notInstallable[key]; blocked {
+		line += " " + core.NotInstallableBadge
+	}
+	if e.IsMeta() && !m.config.UI.EmojisEnabled {
+		line += " " + core.MetaBadge
+	}
+	if len(m.selectedConflicts(key)) > 0 {
+		line += " " + core.ConflictBadge
+	}
+	if m.added[key] {
+		line += " " + core.NewBadge
+	}
 
 	if m.config.UI.EmojisEnabled {
 		emoji := core.EmojiForEntry(e)
@@ -1018,20 +1666,15 @@ func (m *model) ensureConsistentHeight(content string, displayableItems int) str
 }
 
 func main() {
-	// Parse command line flags
-	opts := flags.Parse()
-
-	// Validate command line options
-	if err := flags.ValidateOptions(opts); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		flags.Usage()
-		os.Exit(1)
-	}
+	os.Exit(newCLI().Run(os.Args[1:]))
+}
 
-	// Handle help flag
-	if opts.Help {
-		flags.Usage()
-		return
+// runTUI implements the "tui" command, the default: it starts the picker, and returns
+// the exit code.
+func runTUI(opts *flags.Options, args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "Error: unknown command: %s\n", args[0])
+		return flags.ExitUsage
 	}
 
 	// Handle version flag
@@ -1044,15 +1687,31 @@ func main() {
 		} else {
 			fmt.Println(output)
 		}
-		return
+		return 0
 	}
 
 	// Load configuration
 	cfg, err := loadConfig(opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
+	profile, err := core.ParseColorProfile(cfg.UI.Colors, os.Getenv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		return 1
+	}
+	if cfg.UI.Theme == core.HighContrastThemeName {
+		for _, issue := range core.RegisterTheme(core.HighContrastThemeName, core.HighContrastTheme{}) {
+			fmt.Fprintf(os.Stderr, "Warning: theme %s contrasts too little: %s\n", core.HighContrastThemeName, issue)
+		}
+		core.SetThemeName(core.HighContrastThemeName)
+	}
+	core.SetColorProfile(profile)
+	core.SetASCII(cfg.UI.ASCII)
+	core.SetA11y(cfg.UI.A11y)
+	core.SetIconMode(core.IconMode(cfg.UI.Icons))
+	core.SetEmojiKeywords(cfg.UI.Emojis)
 
 	// Print configuration information
 	switch {
@@ -1064,6 +1723,7 @@ func main() {
 
 		// In debug mode, also print resolved manifest path
 		fmt.Printf("Using manifest: %s\n", cfg.ResolveManifestPath())
+		printManifestWarnings(cfg)
 	case cfg.ConfigPath != "":
 		fmt.Printf("Loaded config from: %s\n", cfg.ConfigPath)
 	default:
@@ -1071,16 +1731,38 @@ func main() {
 	}
 
 	// Initialize model
-	initialModel, err := initializeModel(cfg)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Initialization error: %v\n", err)
-		os.Exit(1)
-	}
+	initialModel := initializeModel(cfg)
 
-	// Run the application
-	p := tea.NewProgram(initialModel, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	// Run the application, following color scheme changes where the terminal reports them
+	// and saving the terminal title to restore it afterwards
+	reportScheme := isTerminal(os.Stdout)
+	saveTitle := reportScheme && cfg.UI.TerminalTitle
+	if reportScheme {
+		fmt.Print(core.EnableColorSchemeReports)
+	}
+	if saveTitle {
+		fmt.Print(core.PushTitle)
+	}
+	if supportsOSC52() {
+		initialModel.osc52Output = os.Stdout
+	}
+	p := tea.NewProgram(initialModel, tea.WithAltScreen(), tea.WithOutput(os.Stdout))
+	_, err = p.Run()
+	if saveTitle {
+		fmt.Print(core.PopTitle)
+	}
+	if reportScheme {
+		fmt.Print(core.DisableColorSchemeReports)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
+	return 0
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
@@ -1,13 +1,27 @@
 package main
 
 import (
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"a-la-carte/internal/app"
+	"a-la-carte/internal/app/provision"
+	"a-la-carte/internal/config"
+	"a-la-carte/internal/flags"
 	"a-la-carte/internal/ui/components"
+	"a-la-carte/internal/ui/core"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
 // Helper: create a minimal manifest for testing
@@ -135,3 +149,1493 @@ func SkipTuiTestEmojiAlignment(t *testing.T) {
 		}
 	}
 }
+
+// unknownCSI mimics the message Bubble Tea sends for CSI sequences it does not know.
+type unknownCSI []byte
+
+func TestColorSchemeReports(t *testing.T) {
+	defer lipgloss.SetHasDarkBackground(lipgloss.HasDarkBackground())
+	m := newTestModel()
+	m.searchBar = components.NewSearchBarModel()
+	if _, cmd := m.Update(unknownCSI("\x1b[?997;2n")); cmd != nil || lipgloss.HasDarkBackground() {
+		t.Error("expected a light scheme report to switch to light colors")
+	}
+	if _, cmd := m.Update(unknownCSI("\x1b[?997;1n")); cmd != nil || !lipgloss.HasDarkBackground() {
+		t.Error("expected a dark scheme report to switch to dark colors")
+	}
+	if core.HandleColorSchemeReport(unknownCSI("\x1b[?1004h")) {
+		t.Error("expected other CSI sequences to be ignored")
+	}
+}
+
+func TestSyncPaneFocus(t *testing.T) {
+	m := newTestModel()
+	m.Init()
+	cases := []struct {
+		name                             string
+		focus                            focusArea
+		leftPane                         bool
+		wantLeft, wantRight, wantDetails bool
+	}{
+		{"left list", focusSoftware, true, true, false, false},
+		{"selection", focusSoftware, false, false, true, false},
+		{"details", focusDetails, true, false, false, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m.focus, m.softwarePaneLeft = tc.focus, tc.leftPane
+			m.syncPaneFocus()
+			if got := m.leftPanel.GetState().Focused; got != tc.wantLeft {
+				t.Errorf("left panel focused: got %v, want %v", got, tc.wantLeft)
+			}
+			if got := m.rightPanel.GetState().Focused; got != tc.wantRight {
+				t.Errorf("right panel focused: got %v, want %v", got, tc.wantRight)
+			}
+			if got := m.detailsContainer.GetState().Focused; got != tc.wantDetails {
+				t.Errorf("details focused: got %v, want %v", got, tc.wantDetails)
+			}
+		})
+	}
+}
+
+func TestFilterEntriesByTag(t *testing.T) {
+	m := newTestModel()
+	m.manifest["foo"] = app.SoftwareEntry{Name: "Foo", Desc: "Foo desc", Tags: app.StringOrSlice{"cli"}}
+	m.manifest["bar"] = app.SoftwareEntry{Name: "Bar", Desc: "Bar desc", Tags: app.StringOrSlice{"CLI", "rust"}, Lazy: true}
+	m.entries = []string{"bar", "baz", "foo"}
+	cases := map[string][]string{
+		"tag:cli":           {"bar", "foo"},
+		"tag:rust":          {"bar"},
+		"tag:cli foo":       {"foo"},
+		"tag:rust tag:cli":  {"bar", "foo"},
+		"tag:gui":           {},
+		"tier:optional":     {"bar"},
+		"tier:core tag:cli": {"foo"},
+		"desc":              {"bar", "baz", "foo"},
+	}
+	for query, want := range cases {
+		got := m.filterEntriesByQuery(query)
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("query %q: got %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestFilterEntriesByAlias(t *testing.T) {
+	m := newTestModel()
+	m.manifest["rg"] = app.SoftwareEntry{Name: "rg", Aliases: app.StringOrSlice{"ripgrep"}}
+	m.manifest["ripgrep-all"] = app.SoftwareEntry{Name: "rga", Aliases: app.StringOrSlice{"rga"}}
+	m.entries = []string{"rg", "ripgrep-all"}
+	if got := m.filterEntriesByQuery("RipGrep"); !slices.Equal(got, []string{"rg", "ripgrep-all"}) {
+		t.Errorf("expected the entry found by its alias, got %v", got)
+	}
+	if got := m.filterEntriesByQuery("rga"); !slices.Equal(got, []string{"ripgrep-all"}) {
+		t.Errorf("expected only the entry with the alias, got %v", got)
+	}
+	if details := strings.Join(m.detailsForKey("rg", 200), "\n"); !strings.Contains(details, "Aliases: ripgrep") {
+		t.Errorf("expected the aliases in the details:\n%s", details)
+	}
+}
+
+func TestDiffView(t *testing.T) {
+	m := newTestModel()
+	m.searchBar = components.NewSearchBarModel()
+	m.selectedKeys = []string{"foo"}
+	if cmd := m.toggleDiff(); cmd == nil || !m.showDiff {
+		t.Fatal("expected the diff view to open and start comparing")
+	}
+	if view := m.renderDiffView(80); !strings.Contains(view, "Checking installed packages") {
+		t.Errorf("expected a progress message while comparing, got:\n%s", view)
+	}
+	m.Update(diffMsg{diff: provision.PlanDiff{Install: []string{"foo"}, Unselected: []string{"bar"}}})
+	view := m.renderDiffView(80)
+	for _, want := range []string{"Will install (1)", "Already installed (0)", "Not selected (1)", "foo", "bar"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("diff view missing %q:\n%s", want, view)
+		}
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.showDiff {
+		t.Error("expected esc to close the diff view")
+	}
+}
+
+func TestDiagnosticsView(t *testing.T) {
+	m := newTestModel()
+	m.searchBar = components.NewSearchBarModel()
+	manifest := app.Manifest{
+		"work/ripgrep": {Bin: app.StringOrSlice{"rg"}, Apt: app.StringOrSlice{"ripgrep"}},
+		"personal/rg":  {Bin: app.StringOrSlice{"rg"}, Brew: app.StringOrSlice{"ripgrep"}},
+	}
+	m.reloadManifest(manifest)
+	if warning := m.diagnosticsWarning(); warning != "!: 1 manifest warning" {
+		t.Errorf("expected a footer hint, got %q", warning)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("!")})
+	if !m.showDiagnostics {
+		t.Fatal("expected ! to open the diagnostics view")
+	}
+	if view := m.renderDiagnosticsView(100); !strings.Contains(view, "executable rg is defined by personal/rg and work/ripgrep") {
+		t.Errorf("expected the duplicate in the diagnostics view:\n%s", view)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.showDiagnostics {
+		t.Error("expected esc to close the diagnostics view")
+	}
+
+	m.reloadManifest(app.Manifest{"rg": {Bin: app.StringOrSlice{"rg"}}})
+	if warning := m.diagnosticsWarning(); warning != "" {
+		t.Errorf("expected no hint without warnings, got %q", warning)
+	}
+	if view := m.renderDiagnosticsView(100); !strings.Contains(view, "No entries define the same executable or package") {
+		t.Errorf("expected the empty state:\n%s", view)
+	}
+}
+
+func TestManifestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "software.yml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("foo:\n  _name: Foo\n")
+	m := newTestModel()
+	m.config = config.DefaultConfig()
+	m.searchBar = components.NewSearchBarModel()
+	m.manifestSources = []app.ManifestSource{{Path: path}}
+	m.reloadManifest(app.Manifest{"foo": {Name: "Foo"}})
+	m.selectedKeys = []string{"foo"}
+	if !maps.Equal(m.manifestStamps, stampManifests(m.manifestSources)) {
+		t.Fatalf("expected the stamps of the loaded manifest, got %v", m.manifestStamps)
+	}
+
+	// An unchanged file is not loaded again
+	m.handleManifestWatch(manifestWatchMsg{stamps: stampManifests(m.manifestSources)})
+	write("foo:\n  _name: Foo\nbar:\n  _name: Bar\n")
+	stamps := stampManifests(m.manifestSources)
+	if maps.Equal(stamps, m.manifestStamps) {
+		t.Fatal("expected the stamp to change with the file")
+	}
+	if cmd := m.handleManifestWatch(manifestWatchMsg{stamps: stamps}); cmd == nil {
+		t.Fatal("expected a change to reload the manifest")
+	}
+
+	// The selection is kept and the new entry highlighted
+	m.Update(reloadChangedManifests(m.manifestSources)())
+	if _, ok := m.manifest["bar"]; !ok || !slices.Equal(m.selectedKeys, []string{"foo"}) {
+		t.Fatalf("expected bar loaded and foo still selected, got %v and %v", m.entries, m.selectedKeys)
+	}
+	if !m.added["bar"] || m.added["foo"] {
+		t.Errorf("expected only bar to be marked new, got %v", m.added)
+	}
+	if line := m.formatItemText("bar", &app.SoftwareEntry{Name: "Bar"}, 40); !strings.Contains(line, core.NewBadge) {
+		t.Errorf("expected the new entry to be badged, got %q", line)
+	}
+	if !strings.Contains(m.toasts.View(), "Manifest reloaded: 1 new entry") {
+		t.Errorf("expected a reload toast, got %q", m.toasts.View())
+	}
+
+	// A manifest that does not parse keeps the previous one
+	write("foo: [\n")
+	m.Update(reloadChangedManifests(m.manifestSources)())
+	if _, ok := m.manifest["bar"]; !ok || !strings.Contains(m.toasts.View(), "Manifest reload failed") {
+		t.Errorf("expected the previous manifest and an error toast, got %v and %q", m.entries, m.toasts.View())
+	}
+}
+
+func TestDetailsMatrix(t *testing.T) {
+	m := newTestModel()
+	m.manifest["foo"] = app.SoftwareEntry{Name: "Foo", Apt: app.StringOrSlice{"foo-pkg"}}
+	details := strings.Join(m.detailsForKey("foo", 200), "\n")
+	for _, want := range []string{"Matrix", "ubuntu/x64: apt foo-pkg", "darwin/arm64: not installable"} {
+		if !strings.Contains(details, want) {
+			t.Errorf("details missing %q:\n%s", want, details)
+		}
+	}
+}
+
+func TestDetailsInstallerVariants(t *testing.T) {
+	m := newTestModel()
+	m.manifest["bat"] = app.SoftwareEntry{Name: "Bat", Apt: app.StringOrSlice{"bat"}, Installers: map[string]app.StringOrSlice{
+		"apt:debian:x64": {"batcat"}, "binary:linux:arm64": {"https://example.com/bat-arm64"},
+	}}
+	details := strings.Join(m.detailsForKey("bat", 200), "\n")
+	for _, want := range []string{"apt:debian:x64: batcat", "binary:linux:arm64: https://example.com/bat-arm64"} {
+		if !strings.Contains(details, want) {
+			t.Errorf("details missing %q:\n%s", want, details)
+		}
+	}
+	if strings.Index(details, "apt:debian:x64") > strings.Index(details, "binary:linux:arm64") {
+		t.Errorf("expected the variants sorted by key:\n%s", details)
+	}
+}
+
+func TestDetailsMetaMembers(t *testing.T) {
+	m := newTestModel()
+	m.manifest["stack"] = app.SoftwareEntry{Name: "Stack", Deps: app.StringOrSlice{"tools", "foo"}}
+	m.manifest["tools"] = app.SoftwareEntry{Name: "Tools", Deps: app.StringOrSlice{"bar"}}
+	m.manifest["bar"] = app.SoftwareEntry{Name: "Bar", Desc: "the bar", Apt: app.StringOrSlice{"bar"}}
+	m.visible = []string{"stack"}
+	m.softwarePaneLeft = true
+	details := strings.Join(m.detailsForKey("stack", 200), "\n")
+	if !strings.Contains(details, "Members: tools, foo") || strings.Contains(details, "Matrix") {
+		t.Errorf("expected the collapsed member list instead of the matrix:\n%s", details)
+	}
+	m.toggleMembers()
+	details = strings.Join(m.detailsForKey("stack", 200), "\n")
+	for _, want := range []string{"  tools", "    bar - the bar", "  foo"} {
+		if !strings.Contains(details, want) {
+			t.Errorf("expanded details missing %q:\n%s", want, details)
+		}
+	}
+}
+
+func TestEntryIcons(t *testing.T) {
+	m := newTestModel()
+	m.config = config.DefaultConfig()
+	t.Cleanup(func() {
+		core.SetIconMode(core.IconsEmoji)
+		core.SetEmojiKeywords(nil)
+	})
+	rg := app.SoftwareEntry{Name: "ripgrep", Desc: "Search tool written in Rust"}
+	ferris := app.SoftwareEntry{Name: "ferris", Emoji: "🦞"}
+	cases := []struct {
+		name     string
+		mode     core.IconMode
+		keywords map[string]string
+		entry    app.SoftwareEntry
+		want     string
+	}{
+		{"built-in keyword", core.IconsEmoji, nil, rg, "🧰 ripgrep"},
+		{"configured keyword", core.IconsEmoji, map[string]string{"rust": "🦀"}, rg, "🦀 ripgrep"},
+		{"entry emoji", core.IconsEmoji, map[string]string{"ferris": "🦀"}, ferris, "🦞 ferris"},
+		{"nerd font", core.IconsNerdFont, nil, rg, "\uf0ad  ripgrep"},
+	}
+	for _, tc := range cases {
+		core.SetIconMode(tc.mode)
+		core.SetEmojiKeywords(tc.keywords)
+		if got := m.formatItemText("k", &tc.entry, 40); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestASCIIMode(t *testing.T) {
+	t.Setenv(config.EnvConfigPath, "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	cfg, err := loadConfig(&flags.Options{ASCII: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.UI.ASCII || cfg.UI.EmojisEnabled {
+		t.Fatalf("expected --ascii to turn on ASCII mode without emojis, got %v %v", cfg.UI.ASCII, cfg.UI.EmojisEnabled)
+	}
+	core.SetASCII(true)
+	t.Cleanup(func() { core.SetASCII(false) })
+
+	m := newTestModel()
+	m.config = cfg
+	m.groupSizes = map[string]int{"cli": 2}
+	m.collapsedGroups = map[string]bool{"cli": true}
+	views := []string{
+		m.renderHelpView(80),
+		m.formatHeadingLine("cli", 0, false, 40),
+		core.CurrentStyles().ListPanel.Render("x"),
+	}
+	for _, view := range views {
+		for _, r := range view {
+			if r > 127 {
+				t.Errorf("expected plain ASCII, got %q in:\n%s", r, view)
+				break
+			}
+		}
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a-la-carte.yml")
+	if err := os.WriteFile(path, []byte("ui:\n  theme: dark\n  listHeight: 12\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(config.EnvConfigPath, path)
+	t.Setenv("A_LA_CARTE_UI_THEME", "light")
+	t.Setenv("A_LA_CARTE_UI_EMOJISENABLED", "true")
+	cfg, err := loadConfig(&flags.Options{NoEmojis: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.UI.Theme != "light" || cfg.UI.ListHeight != 12 || cfg.UI.EmojisEnabled {
+		t.Errorf("expected the environment over the file and flags over both, got %+v", cfg.UI)
+	}
+
+	t.Setenv("A_LA_CARTE_UI_THEME", "purple")
+	if _, err := loadConfig(&flags.Options{}); err == nil {
+		t.Error("expected an invalid override to be rejected")
+	}
+}
+
+func TestCLI(t *testing.T) {
+	cli := newCLI()
+
+	// Without a command name the picker runs, with its flags as before subcommands
+	cmd, opts, args, err := cli.Parse([]string{"--ascii", "-c", "a.yml"})
+	if err != nil || cmd.Name != "tui" || !opts.ASCII || opts.ConfigPath != "a.yml" || len(args) != 0 {
+		t.Errorf("expected tui with --ascii and --config, got %v %+v %v %v", cmd.Name, opts, args, err)
+	}
+
+	// Global flags before or after the command name, mixed with its arguments
+	cmd, opts, args, err = cli.Parse([]string{"-c", "a.yml", "config", "get", "ui.theme", "--output", "json"})
+	if err != nil || cmd.Name != "config" || opts.ConfigPath != "a.yml" || opts.OutputFormat != "json" || !slices.Equal(args, []string{"get", "ui.theme"}) {
+		t.Errorf("expected config get with global flags, got %v %+v %v %v", cmd.Name, opts, args, err)
+	}
+	cmd, _, args, err = cli.Parse([]string{"logs", "search", "--level", "error", "libfoo"})
+	if err != nil || cmd.Name != "logs" || !slices.Equal(args, []string{"search", "libfoo"}) {
+		t.Errorf("expected logs search with its own flag, got %v %v %v", cmd.Name, args, err)
+	}
+
+	// Flags belong to their command
+	if _, _, _, err := cli.Parse([]string{"config", "--ascii", "list"}); err == nil {
+		t.Error("expected --ascii to be rejected by config")
+	}
+
+	// provision hands its arguments to the provisioner unparsed
+	cmd, opts, args, err = cli.Parse([]string{"-m", "work.yml", "provision", "--dry-run", "--only", "git"})
+	if err != nil || cmd.Name != "provision" || opts.ManifestPath != "work.yml" || !slices.Equal(args, []string{"--dry-run", "--only", "git"}) {
+		t.Errorf("expected provision to pass its arguments through, got %v %+v %v %v", cmd.Name, opts, args, err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.ConfigPath = "/home/u/a-la-carte.yml"
+	cfg.Software.ManifestPath = "/home/u/software.yml"
+	if got := provisionerArgs(cfg, []string{"--dry-run"}); !slices.Equal(got, []string{"--manifest", "/home/u/software.yml", "--config", "/home/u/a-la-carte.yml", "--dry-run"}) {
+		t.Errorf("expected the configured manifest and config file, got %v", got)
+	}
+	if got := provisionerArgs(cfg, []string{"--manifest=other.yml", "--config", "c.yml"}); !slices.Equal(got, []string{"--manifest=other.yml", "--config", "c.yml"}) {
+		t.Errorf("expected explicit provisioner flags to win, got %v", got)
+	}
+	cfg.Software.Strict = true
+	if got := provisionerArgs(cfg, nil); !slices.Contains(got, "--strict") {
+		t.Errorf("expected software.strict to be passed on, got %v", got)
+	}
+	if _, opts, _, err := cli.Parse([]string{"doctor", "--strict"}); err != nil || !opts.Strict {
+		t.Errorf("expected --strict to be a global flag, got %+v %v", opts, err)
+	}
+
+	// Completion scripts list every command and their flags
+	for _, shell := range flags.CompletionShells {
+		script, err := cli.Completion(shell)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{"doctor", "new-entry", "template", "no-title"} {
+			if !strings.Contains(script, want) {
+				t.Errorf("expected the %s completion to offer %s", shell, want)
+			}
+		}
+	}
+	if _, err := cli.Completion("powershell"); err == nil {
+		t.Error("expected an unknown shell to be rejected")
+	}
+}
+
+func TestLongHelp(t *testing.T) {
+	doc := manual(newCLI())
+
+	// The commands, key bindings, settings and manifest keys all come from the code
+	text := doc.Text(manualWidth)
+	for _, want := range []string{
+		"new-entry", "--help-long", "KEY BINDINGS: LIST", "Toggle this help",
+		"ui.theme (string)", "A_LA_CARTE_UI_THEME", "managers.<name>.extraArgs (list of strings)",
+		"_bin (string or list of strings)", "_sandbox.no_network (boolean)",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in the documentation", want)
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if lipgloss.Width(line) > manualWidth {
+			t.Errorf("expected lines of at most %d columns, got %q", manualWidth, line)
+		}
+	}
+
+	man := doc.Man("1.2.3")
+	if !strings.HasPrefix(man, ".TH CHEZMOI-A-LA-CARTE 1") || !strings.Contains(man, `\fB\-\-help\-long\fR`) {
+		t.Errorf("expected a man page with escaped dashes, got:\n%s", man)
+	}
+
+	// The pager wraps for its window, scrolls and searches
+	pager := components.NewPagerModel("manual", doc.Text)
+	pager.Update(tea.WindowSizeMsg{Width: 60, Height: 10})
+	if view := pager.View(); !strings.Contains(view, "NAME") || strings.Contains(view, "FILES") {
+		t.Errorf("expected the top of the documentation, got:\n%s", view)
+	}
+	for _, key := range "/cached copy" {
+		pager.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{key}})
+	}
+	pager.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if view := pager.View(); !strings.Contains(view, "The cached copy of software.manifestURL") || strings.Contains(view, "NAME\n") {
+		t.Errorf("expected the search to scroll to the FILES section, got:\n%s", view)
+	}
+	pager.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")})
+	if view := pager.View(); !strings.Contains(view, "lines 1-8") {
+		t.Errorf("expected g to go back to the top, got:\n%s", view)
+	}
+	if _, cmd := pager.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd == nil {
+		t.Error("expected q to quit the pager")
+	}
+}
+
+func TestSchema(t *testing.T) {
+	cfg, err := fileSchema("config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ui := property(t, cfg, "ui")
+	theme := property(t, ui, "theme")
+	if theme["default"] != "dark" || !slices.Contains(theme["enum"].([]any), any("high-contrast")) {
+		t.Errorf("expected the themes with the default one, got %v", theme)
+	}
+	manifests := property(t, property(t, cfg, "software"), "manifests")["items"].(map[string]any)
+	if !slices.Equal(manifests["required"].([]string), []string{"name", "path"}) {
+		t.Errorf("expected name and path to be required, got %v", manifests["required"])
+	}
+	if options, ok := property(t, cfg, "managers")["additionalProperties"].(map[string]any); !ok || options["properties"].(map[string]any)["extraArgs"] == nil {
+		t.Errorf("expected the options of any package manager under managers, got %v", options)
+	}
+
+	manifest, err := fileSchema("manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := manifest["additionalProperties"].(map[string]any)
+	if entry["additionalProperties"] != false {
+		t.Error("expected unknown keys of entries to be flagged")
+	}
+	bin := property(t, entry, "_bin")
+	if alts, ok := bin["anyOf"].([]any); !ok || len(alts) != 2 {
+		t.Errorf("expected _bin to take a string or a list, got %v", bin)
+	}
+	if tier := property(t, entry, "_tier"); !slices.Equal(tier["enum"].([]any), []any{"core", "extra", "optional"}) {
+		t.Errorf("expected the tiers, got %v", tier)
+	}
+	service := property(t, entry, "_service")
+	if alts, ok := service["anyOf"].([]any); !ok || len(alts) != 2 || len(alts[0].(map[string]any)["anyOf"].([]any)) != 2 {
+		t.Errorf("expected _service to take a name or a map, or a list of them, got %v", service)
+	}
+	variants, ok := entry["patternProperties"].(map[string]any)
+	if !ok || len(variants) != 1 {
+		t.Fatalf("expected a pattern for the qualified installer keys, got %v", entry["patternProperties"])
+	}
+	for pattern := range variants {
+		re := regexp.MustCompile(pattern)
+		if !re.MatchString("apt:debian:x64") || !re.MatchString("binary:linux:arm64") || re.MatchString("_decs") || re.MatchString("apt") {
+			t.Errorf("expected %s to match only qualified installer keys", pattern)
+		}
+	}
+
+	// Every key is documented, so editors can show what it does
+	for name, s := range map[string]map[string]any{"config": cfg, "manifest": entry} {
+		for key, prop := range s["properties"].(map[string]any) {
+			if prop.(map[string]any)["description"] == nil {
+				t.Errorf("expected a description of %s key %s", name, key)
+			}
+		}
+	}
+
+	if _, err := fileSchema("profile"); err == nil {
+		t.Error("expected an unknown schema to be rejected")
+	}
+}
+
+// property returns the schema of key in the properties of object schema s.
+func property(t *testing.T, s map[string]any, key string) map[string]any {
+	t.Helper()
+	prop, ok := s["properties"].(map[string]any)[key].(map[string]any)
+	if !ok {
+		t.Fatalf("expected a %s property in %v", key, s)
+	}
+	return prop
+}
+
+func TestA11yMode(t *testing.T) {
+	t.Setenv(config.EnvConfigPath, "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	cfg, err := loadConfig(&flags.Options{A11y: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.UI.A11y || !cfg.UI.ASCII || cfg.UI.EmojisEnabled {
+		t.Fatalf("expected --a11y to turn on ASCII mode without emojis, got %v %v %v", cfg.UI.A11y, cfg.UI.ASCII, cfg.UI.EmojisEnabled)
+	}
+	core.SetASCII(true)
+	core.SetA11y(true)
+	t.Cleanup(func() {
+		core.SetA11y(false)
+		core.SetASCII(false)
+	})
+
+	m := newTestModel()
+	m.config = cfg
+	m.searchBar = components.NewSearchBarModel()
+	m.keyPlans = map[string]*keyPlan{"bar": {}, "baz": {}, "foo": {}} // nothing to plan in the background
+	m.entries = []string{"bar", "baz", "foo"}
+	m.focus, m.softwarePaneLeft = focusSoftware, true
+	m.Init()
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	m.filter()
+	view := m.View()
+	for _, want := range []string{"[focused] Available software", "Selected software", "Details", "[focused] Bar"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the view:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "[focused] Baz") || strings.Contains(view, "+-") || strings.ContainsAny(view, "╭│─") {
+		t.Errorf("expected only the focused item marked and no borders, got:\n%s", view)
+	}
+}
+
+func TestTerminalTitle(t *testing.T) {
+	m := newTestModel()
+	m.config = config.DefaultConfig()
+	m.selectedKeys = []string{"foo", "bar"}
+	if m.titleCmd() != nil {
+		t.Error("expected no title before the window size is known")
+	}
+	m.width = 100
+	if got := fmt.Sprint(m.titleCmd()()); got != "à la carte — 2 selected" {
+		t.Errorf("expected the selection count in the title, got %q", got)
+	}
+	if m.titleCmd() != nil {
+		t.Error("expected the title to be set again only when it changes")
+	}
+
+	t.Setenv(config.EnvConfigPath, "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	cfg, err := loadConfig(&flags.Options{NoTitle: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.config = cfg
+	m.selectedKeys = nil
+	if m.titleCmd() != nil {
+		t.Error("expected --no-title to leave the title alone")
+	}
+
+	osc52 := "\x1b]52;c;Zm9v\x07"
+	tmux := func(key string) string { return map[string]string{"TMUX": "/tmp/tmux-0/default"}[key] }
+	if got := core.Passthrough(osc52, tmux); got != osc52+"\x1bPtmux;\x1b\x1b]52;c;Zm9v\x07\x1b\\" {
+		t.Errorf("expected the sequence both as is and wrapped for tmux, got %q", got)
+	}
+	screen := func(key string) string { return map[string]string{"TERM": "screen"}[key] }
+	if got := core.Passthrough(osc52, screen); got != "\x1bP"+osc52+"\x1b\\" {
+		t.Errorf("expected the sequence wrapped for screen, got %q", got)
+	}
+	if got := core.Passthrough(osc52, func(string) string { return "" }); got != osc52 {
+		t.Errorf("expected the sequence unchanged outside a multiplexer, got %q", got)
+	}
+}
+
+func TestWindowTooSmall(t *testing.T) {
+	m := newTestModel()
+	m.config = config.DefaultConfig()
+	m.keyPlans = map[string]*keyPlan{"bar": {}, "baz": {}, "foo": {}} // nothing to plan in the background
+	m.Init()
+	m.Update(tea.WindowSizeMsg{Width: 60, Height: 20})
+	m.filter()
+	if view := m.View(); !strings.Contains(view, "terminal too small (need 80x24, have 60x20)") || strings.Contains(view, "Foo") {
+		t.Errorf("expected only the too-small screen, got:\n%s", view)
+	}
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	if view := m.View(); strings.Contains(view, "too small") || !strings.Contains(view, "Foo") {
+		t.Errorf("expected the picker again after resizing, got:\n%s", view)
+	}
+}
+
+func TestHelpFromKeymap(t *testing.T) {
+	m := newTestModel()
+	m.config = config.DefaultConfig()
+	m.keyPlans = map[string]*keyPlan{"bar": {}, "baz": {}, "foo": {}} // nothing to plan in the background
+	m.Init()
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m.filter()
+	press := func(keys ...string) {
+		for _, key := range keys {
+			m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		}
+	}
+
+	press("h")
+	view := m.View()
+	for _, want := range []string{"General", "List", "Filter: (press / to filter)", "Scroll (1-"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the help:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "Provisioning") {
+		t.Errorf("expected the help to scroll instead of overflowing the window:\n%s", view)
+	}
+	for range keymap {
+		press("j")
+	}
+	if view := m.View(); !strings.Contains(view, "Provisioning") || strings.Contains(view, "Toggle this help") {
+		t.Errorf("expected the help scrolled to its end:\n%s", view)
+	}
+
+	press("/", "u", "n", "d", "o")
+	view = m.View()
+	if !strings.Contains(view, "Filter: undo_") || !strings.Contains(view, "Undo the last selection change") ||
+		!strings.Contains(view, "Redo the last undone") || strings.Contains(view, "Switch profile") {
+		t.Errorf("expected only the undo bindings, got:\n%s", view)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if !m.showHelp || m.help.Filter() != "undo" {
+		t.Fatalf("expected Esc to close the filter box and keep the filter, got %v %q", m.showHelp, m.help.Filter())
+	}
+	press("h")
+	if m.showHelp {
+		t.Fatal("expected h to close the help")
+	}
+	press("h")
+	if m.help.Filter() != "" {
+		t.Errorf("expected the filter cleared when the help is reopened, got %q", m.help.Filter())
+	}
+}
+
+// lowContrastTheme is the default theme with muted text as dark as its background.
+type lowContrastTheme struct{ core.DefaultTheme }
+
+func (lowContrastTheme) TextMuted() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#303030"}
+}
+
+func (lowContrastTheme) Background() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#282A36"}
+}
+
+func TestContrast(t *testing.T) {
+	if ratio, err := core.ContrastRatio("#000", "#FFFFFF"); err != nil || ratio < 20.99 {
+		t.Errorf("expected black on white to contrast 21:1, got %v, %v", ratio, err)
+	}
+	if ratio, err := core.ContrastRatio("15", "0"); err != nil || ratio < 20.99 {
+		t.Errorf("expected ANSI white on black to contrast 21:1, got %v, %v", ratio, err)
+	}
+	if _, err := core.ContrastRatio("#12345", "#000"); err == nil {
+		t.Error("expected an error for a malformed color")
+	}
+	if issues := core.CheckContrast(core.HighContrastTheme{}); len(issues) != 0 {
+		t.Errorf("expected the high-contrast theme to pass, got %v", issues)
+	}
+
+	var muted []string
+	for _, issue := range core.CheckContrast(lowContrastTheme{}) {
+		if strings.HasPrefix(issue.Pair, "TextMuted") {
+			muted = append(muted, issue.String())
+		}
+	}
+	if len(muted) != 2 || !strings.Contains(muted[0], "(light): 1.0:1, needs 3.0:1") {
+		t.Errorf("expected muted text to fail in both variants, got %q", muted)
+	}
+}
+
+func TestColorProfile(t *testing.T) {
+	cases := []struct {
+		name string
+		env  map[string]string
+		want termenv.Profile
+	}{
+		{"no color", map[string]string{"NO_COLOR": "1", "COLORTERM": "truecolor"}, termenv.Ascii},
+		{"dumb terminal", map[string]string{"TERM": "dumb"}, termenv.Ascii},
+		{"truecolor", map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor"}, termenv.TrueColor},
+		{"screen", map[string]string{"TERM": "screen-256color", "COLORTERM": "truecolor"}, termenv.ANSI256},
+		{"tmux", map[string]string{"TERM": "screen-256color", "COLORTERM": "24bit", "TMUX": "/tmp/tmux"}, termenv.TrueColor},
+		{"256 colors", map[string]string{"TERM": "xterm-256color"}, termenv.ANSI256},
+		{"basic terminal", map[string]string{"TERM": "vt100"}, termenv.ANSI},
+	}
+	for _, tc := range cases {
+		getenv := func(key string) string { return tc.env[key] }
+		if got, err := core.ParseColorProfile("auto", getenv); err != nil || got != tc.want {
+			t.Errorf("%s: got %v (%v), want %v", tc.name, got, err, tc.want)
+		}
+	}
+	if _, err := core.ParseColorProfile("8", os.Getenv); err == nil {
+		t.Error("expected an error for an unknown color setting")
+	}
+
+	core.SetColorProfile(termenv.Ascii)
+	t.Cleanup(func() {
+		core.SetColorProfile(termenv.ANSI)
+		lipgloss.SetColorProfile(termenv.Ascii)
+	})
+	if !core.Monochrome() || core.CurrentTheme().Primary() != (lipgloss.AdaptiveColor{}) {
+		t.Fatal("expected no colors in monochrome mode")
+	}
+	if active := core.CurrentStyles().ActiveItemStyle.Render("x"); !strings.Contains(active, "7m") {
+		t.Errorf("expected the active item to be reversed in monochrome mode, got %q", active)
+	}
+}
+
+func TestFindInapplicable(t *testing.T) {
+	manifest := app.Manifest{
+		"never":  {Apt: app.StringOrSlice{"never"}, When: `os == "plan9"`},
+		"always": {Apt: app.StringOrSlice{"always"}, When: `os != "plan9"`},
+		"plain":  {Apt: app.StringOrSlice{"plain"}},
+	}
+	got := findInapplicable(manifest)
+	if len(got) != 1 || !got["never"] {
+		t.Errorf("expected only never to be inapplicable, got %v", got)
+	}
+	if _, blocked := findNotInstallable(manifest)["never"]; !blocked {
+		t.Error("expected an inapplicable entry to be reported as not installable")
+	}
+}
+
+func TestSelectionConflicts(t *testing.T) {
+	m := newTestModel()
+	m.manifest["neovim"] = app.SoftwareEntry{Name: "Neovim"}
+	m.manifest["neovim-nightly"] = app.SoftwareEntry{Name: "Neovim nightly", Conflicts: app.StringOrSlice{"neovim"}}
+	m.selectedKeys = []string{"neovim"}
+	if got := m.conflictWarning(); got != "" {
+		t.Errorf("expected no warning for one side of a conflict, got %q", got)
+	}
+	details := strings.Join(m.detailsForKey("neovim-nightly", 200), "\n")
+	if !strings.Contains(details, "conflicts with the selected neovim") {
+		t.Errorf("details missing the conflict with the selection:\n%s", details)
+	}
+	m.selectedKeys = []string{"neovim", "neovim-nightly"}
+	if got, want := m.conflictWarning(), "Warning: neovim-nightly conflicts with neovim"; got != want {
+		t.Errorf("conflictWarning() = %q, want %q", got, want)
+	}
+}
+
+func TestDetailsPlan(t *testing.T) {
+	m := newTestModel()
+	m.manifest["tool"] = app.SoftwareEntry{Name: "Tool", Deps: app.StringOrSlice{"lib", "foo"}, Brew: app.StringOrSlice{"tool"}, Apt: app.StringOrSlice{"tool"}}
+	m.manifest["lib"] = app.SoftwareEntry{Name: "Lib", Brew: app.StringOrSlice{"lib"}, Apt: app.StringOrSlice{"lib"}}
+	m.visible = []string{"tool"}
+	m.softwarePaneLeft = true
+	m.installed = map[string]bool{"foo": true}
+	cmd := m.planHighlighted()
+	if cmd == nil {
+		t.Fatal("expected the highlighted key to be planned")
+	}
+	if details := strings.Join(m.detailsForKey("tool", 200), "\n"); !strings.Contains(details, "Resolving...") {
+		t.Errorf("expected a pending plan:\n%s", details)
+	}
+	if m.planHighlighted() != nil {
+		t.Error("expected a pending plan not to be computed twice")
+	}
+	m.Update(cmd())
+	details := strings.Join(m.detailsForKey("tool", 200), "\n")
+	for _, want := range []string{"Plan", "lib: ", "tool: ", "foo: skipped, already installed"} {
+		if !strings.Contains(details, want) {
+			t.Errorf("details missing %q:\n%s", want, details)
+		}
+	}
+	m.searchBar = components.NewSearchBarModel()
+	m.reloadManifest(m.manifest)
+	if m.keyPlans != nil {
+		t.Error("expected reloading the manifest to drop the plans")
+	}
+}
+
+func TestColumnView(t *testing.T) {
+	m := newTestModel()
+	m.config = config.DefaultConfig()
+	m.searchBar = components.NewSearchBarModel()
+	m.manifest["foo"] = app.SoftwareEntry{Name: "Foo", Groups: app.StringOrSlice{"cli"}}
+	m.manifest["bar"] = app.SoftwareEntry{Name: "Bar", Groups: app.StringOrSlice{"gui"}}
+	m.manifest["baz"] = app.SoftwareEntry{Name: "Baz", Groups: app.StringOrSlice{"cli"}}
+	m.entries = []string{"bar", "baz", "foo"}
+	m.toggleColumnView()
+	m.Update(installedMsg{installed: map[string]bool{"baz": true}})
+	if !m.columnView || m.statusCell("baz") != "installed" || m.statusCell("foo") != "missing" {
+		t.Fatalf("expected the column view with statuses, got %v %q %q", m.columnView, m.statusCell("baz"), m.statusCell("foo"))
+	}
+	m.sortByColumn(columnGroups)
+	if want := []string{"baz", "foo", "bar"}; !slices.Equal(m.visible, want) {
+		t.Errorf("sorted by groups %v, want %v", m.visible, want)
+	}
+	m.sortByColumn(columnGroups)
+	if want := []string{"bar", "baz", "foo"}; !slices.Equal(m.visible, want) {
+		t.Errorf("sorted by groups descending %v, want %v", m.visible, want)
+	}
+	view := m.renderList(m.visible, true, 60, true)
+	for _, want := range []string{"Name", "Groups ▼", "gui", "installed"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("column view missing %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestSortCycle(t *testing.T) {
+	m := newTestModel()
+	m.searchBar = components.NewSearchBarModel()
+	m.manifest = app.Manifest{
+		"foo": {Name: "Foo", Groups: app.StringOrSlice{"gui"}},
+		"bar": {Name: "Bar"},
+		"baz": {Name: "Baz", Groups: app.StringOrSlice{"cli"}},
+	}
+	m.entries = []string{"bar", "baz", "foo"}
+	m.softwarePaneLeft = true
+	m.statePath = filepath.Join(t.TempDir(), "tui.yml")
+	m.state = loadState(m.statePath)
+	m.filter()
+	m.uiActiveListIndex = 2 // foo
+	m.moveToSelected()
+	m.selectedKeys = nil
+	m.filter()
+
+	// foo was selected before, so it is also listed under Recent on top
+	recent := []string{groupHeaderPrefix + recentGroup, "foo"}
+	m.cycleSort()
+	if want := append(recent, groupHeaderPrefix+"cli", "baz", groupHeaderPrefix+"gui", "foo", groupHeaderPrefix+otherGroup, "bar"); !slices.Equal(m.visible, want) {
+		t.Errorf("sorted by group %q, want %q", m.visible, want)
+	}
+	m.Update(installedMsg{installed: map[string]bool{"baz": true}})
+	m.cycleSort()
+	if want := append(recent, groupHeaderPrefix+allGroup, "baz", "bar", "foo"); !slices.Equal(m.visible, want) {
+		t.Errorf("sorted by status %v, want %v", m.visible, want)
+	}
+	m.cycleSort()
+	if want := append(recent, groupHeaderPrefix+allGroup, "foo", "bar", "baz"); !slices.Equal(m.visible, want) {
+		t.Errorf("sorted by recent %v, want %v", m.visible, want)
+	}
+	if state := loadState(m.statePath); state.Sort != sortRecent || !slices.Equal(state.Recent, []string{"foo"}) {
+		t.Errorf("expected the sort mode and recent keys to be saved, got %+v", state)
+	}
+	m.cycleSort()
+	if want := append(recent, groupHeaderPrefix+allGroup, "bar", "baz", "foo"); !slices.Equal(m.visible, want) {
+		t.Errorf("sorted alphabetically %v, want %v", m.visible, want)
+	}
+}
+
+func TestRecentGroup(t *testing.T) {
+	m := newTestModel()
+	m.searchBar = components.NewSearchBarModel()
+	m.manifest = app.Manifest{"a": {Name: "A"}, "b": {Name: "B"}, "c": {Name: "C"}, "d": {Name: "D"}}
+	m.entries = []string{"a", "b", "c", "d"}
+	m.softwarePaneLeft = true
+	m.state = &tuiState{Recent: []string{"a", "b", "c", "gone"}, Counts: map[string]int{"a": 1, "b": 6, "c": 2, "gone": 9}}
+	// Scores: a 1/1, b 6/2, c 2/3, gone 9/4; gone is no longer in the manifest
+	if got, want := m.rankedRecent(0), []string{"b", "a", "c"}; !slices.Equal(got, want) {
+		t.Errorf("ranked recent keys %v, want %v", got, want)
+	}
+	m.filter()
+	want := []string{groupHeaderPrefix + recentGroup, "b", "a", "c", groupHeaderPrefix + allGroup, "a", "b", "c", "d"}
+	if !slices.Equal(m.visible, want) {
+		t.Fatalf("left list %q, want %q", m.visible, want)
+	}
+	if m.visibleCount() != 4 {
+		t.Errorf("expected keys under Recent counted once, got %d", m.visibleCount())
+	}
+
+	m.uiActiveListIndex = 1
+	m.handleLeftPaneKey("left")
+	if want := []string{groupHeaderPrefix + recentGroup, groupHeaderPrefix + allGroup, "a", "b", "c", "d"}; !slices.Equal(m.visible, want) {
+		t.Errorf("collapsed Recent %q, want %q", m.visible, want)
+	}
+
+	for _, r := range "/recent:2" {
+		m.searchBar.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m.filter()
+	if want := []string{"a", "b"}; !slices.Equal(m.visible, want) {
+		t.Errorf("recent:2 matched %v, want %v", m.visible, want)
+	}
+
+	m.state.touch("d")
+	m.state.touch("d")
+	if m.state.Recent[0] != "d" || m.state.Counts["d"] != 2 {
+		t.Errorf("expected d counted twice at the front, got %+v", m.state)
+	}
+}
+
+func TestGroupHeadings(t *testing.T) {
+	m := newTestModel()
+	m.config = config.DefaultConfig()
+	m.searchBar = components.NewSearchBarModel()
+	m.manifest = app.Manifest{
+		"foo": {Name: "Foo", Groups: app.StringOrSlice{"cli"}},
+		"bar": {Name: "Bar"},
+		"baz": {Name: "Baz", Groups: app.StringOrSlice{"CLI", "dev"}},
+	}
+	m.entries = []string{"bar", "baz", "foo"}
+	m.softwarePaneLeft = true
+	m.state = &tuiState{Sort: sortGroup}
+	m.filter()
+	want := []string{groupHeaderPrefix + "CLI", "baz", "foo", groupHeaderPrefix + otherGroup, "bar"}
+	if !slices.Equal(m.visible, want) {
+		t.Fatalf("grouped list %q, want %q", m.visible, want)
+	}
+
+	m.uiActiveListIndex = 2 // foo
+	m.handleLeftPaneKey("left")
+	want = []string{groupHeaderPrefix + "CLI", groupHeaderPrefix + otherGroup, "bar"}
+	if !slices.Equal(m.visible, want) || m.uiActiveListIndex != 0 {
+		t.Fatalf("collapsed list %q at %d, want %q at 0", m.visible, m.uiActiveListIndex, want)
+	}
+	if m.highlightedKey() != "" {
+		t.Errorf("expected no key on a heading, got %q", m.highlightedKey())
+	}
+	if view := m.renderList(m.visible, true, 40, true); !strings.Contains(view, "▸ CLI (2)") {
+		t.Errorf("expected a collapsed heading with its size:\n%s", view)
+	}
+
+	m.handleLeftPaneKey("right")
+	if len(m.visible) != 5 || !m.softwarePaneLeft {
+		t.Errorf("expected → to expand the group, got %q", m.visible)
+	}
+}
+
+func TestStatusBar(t *testing.T) {
+	m := newTestModel()
+	m.config = config.DefaultConfig()
+	m.config.Profiles = map[string][]string{"work": {"foo", "missing"}}
+	m.searchBar = components.NewSearchBarModel()
+	m.manifest = app.Manifest{
+		"foo": {Name: "Foo", Tags: app.StringOrSlice{"cli"}},
+		"bar": {Name: "Bar", Tags: app.StringOrSlice{"cli"}},
+		"baz": {Name: "Baz"},
+	}
+	m.entries = []string{"bar", "baz", "foo"}
+	m.filter()
+	if text := m.statusText(); !strings.Contains(text, "3 shown · 0 selected · … installed") || !strings.Contains(text, "profile: none") {
+		t.Errorf("unexpected initial status %q", text)
+	}
+
+	m.applyProfile("work")
+	m.Update(installedMsg{installed: map[string]bool{"bar": true, "baz": true}})
+	for _, r := range "/tag:cli b" {
+		m.searchBar.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m.filter()
+	text := m.statusText()
+	for _, want := range []string{"1 shown · 1 selected (unsaved) · 2 installed", "profile: work", `filter: "b" tag:cli`, "sort: alphabetical", "theme: dark"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("status %q missing %q", text, want)
+		}
+	}
+	if bar := m.renderStatusBar(30); lipgloss.Height(bar) != 1 || lipgloss.Width(bar) > 30 {
+		t.Errorf("expected one line of at most 30 columns, got %q", bar)
+	}
+}
+
+func TestBackgroundLoading(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "software.yml")
+	if err := os.WriteFile(path, []byte("foo: [\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.Software.ManifestPath = path
+	cfg.Software.PreloadKeys = []string{"foo"}
+	m := initializeModel(cfg)
+	m.Init()
+	if !m.loading || !strings.Contains(m.View(), "Loading manifest") {
+		t.Fatalf("expected the loading screen, got %q", m.View())
+	}
+
+	m.Update(loadManifests(m.config, m.manifestSources)())
+	if m.loading || m.loadErr == nil || !strings.Contains(m.View(), "r: retry") {
+		t.Fatalf("expected the load error with a retry hint, got %q", m.View())
+	}
+
+	if err := os.WriteFile(path, []byte("foo:\n  _name: Foo\nbar:\n  _name: Bar\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")}); cmd == nil || !m.loading || m.loadErr != nil {
+		t.Fatal("expected r to load the manifest again")
+	}
+	m.Update(loadManifests(m.config, m.manifestSources)())
+	if m.loading || m.loadErr != nil {
+		t.Fatalf("expected the manifest to load, got %v", m.loadErr)
+	}
+	if !slices.Equal(m.selectedKeys, []string{"foo"}) || !slices.Equal(m.visible, []string{"bar"}) {
+		t.Errorf("expected foo preloaded and bar listed, got %v and %v", m.selectedKeys, m.visible)
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	m := newTestModel()
+	m.searchBar = components.NewSearchBarModel()
+	m.entries = []string{"bar", "baz", "foo"}
+	m.manifest["bar"] = app.SoftwareEntry{Name: "Bar"}
+	m.manifest["baz"] = app.SoftwareEntry{Name: "Baz"}
+	m.softwarePaneLeft = true
+	m.filter()
+	press := func(key string) {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "ctrl+r":
+			msg = tea.KeyMsg{Type: tea.KeyCtrlR}
+		}
+		m.Update(msg)
+	}
+
+	press("enter") // bar
+	press("enter") // baz
+	m.softwarePaneLeft = false
+	press("D")
+	if len(m.selectedKeys) != 0 {
+		t.Fatalf("expected D to clear the selection, got %v", m.selectedKeys)
+	}
+	press("u")
+	if want := []string{"bar", "baz"}; !slices.Equal(m.selectedKeys, want) {
+		t.Fatalf("expected undo to restore %v at once, got %v", want, m.selectedKeys)
+	}
+	press("u")
+	if want := []string{"bar"}; !slices.Equal(m.selectedKeys, want) || slices.Contains(m.visible, "bar") {
+		t.Fatalf("expected a second undo to restore %v, got %v (visible %v)", want, m.selectedKeys, m.visible)
+	}
+	press("ctrl+r")
+	press("ctrl+r")
+	if len(m.selectedKeys) != 0 {
+		t.Errorf("expected redo to clear the selection again, got %v", m.selectedKeys)
+	}
+	press("ctrl+r")
+	if !strings.Contains(m.toasts.View(), "Nothing to redo") {
+		t.Errorf("expected nothing left to redo, got %q", m.toasts.View())
+	}
+}
+
+func TestToasts(t *testing.T) {
+	m := newTestModel()
+	m.config = config.DefaultConfig()
+	m.config.Profiles = map[string][]string{"work": {"foo", "bar"}}
+	m.searchBar = components.NewSearchBarModel()
+	m.width, m.height = 80, 20
+	m.applyProfile("work")
+	if len(m.toastCmds) != 1 || !strings.Contains(m.toasts.View(), "Applied profile work (2 selected)") {
+		t.Fatalf("expected a toast with its timer, got %d timers and %q", len(m.toastCmds), m.toasts.View())
+	}
+	m.toastCmds = nil
+
+	background := strings.Repeat(strings.Repeat(".", 60)+"\n", 9) + strings.Repeat(".", 60)
+	view := m.toasts.Overlay(background)
+	lines := strings.Split(view, "\n")
+	if len(lines) != 10 || !strings.HasPrefix(lines[2], "....") || !strings.HasSuffix(lines[2], ".") || !strings.Contains(lines[2], "Applied profile") {
+		t.Errorf("expected the toast drawn over the top-right corner:\n%s", view)
+	}
+	for _, line := range lines {
+		if lipgloss.Width(line) != 60 {
+			t.Errorf("expected the overlay to keep the width of the view, got %d in %q", lipgloss.Width(line), line)
+		}
+	}
+
+	for i := range 4 {
+		m.toasts.Push(components.ToastError, fmt.Sprintf("error %d", i))
+	}
+	if m.toasts.Len() != 3 || strings.Contains(m.toasts.View(), "Applied profile") {
+		t.Errorf("expected the oldest toasts to be dismissed, got %d:\n%s", m.toasts.Len(), m.toasts.View())
+	}
+	m.Update(components.ToastExpiredMsg{ID: 3})
+	if m.toasts.Len() != 2 || strings.Contains(m.toasts.View(), "error 1") {
+		t.Errorf("expected the expired toast to be dismissed, got:\n%s", m.toasts.View())
+	}
+}
+
+func TestOverlayStyledBackground(t *testing.T) {
+	const red, green, bold, reset = "\x1b[31m", "\x1b[32m", "\x1b[1m", "\x1b[0m"
+	sgr := regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	cases := []struct {
+		name       string
+		background string
+		foreground string
+		x          int
+		want       string // the plain text
+		wantStyle  string // the style restored after the foreground
+	}{
+		{"style opened before x", red + "rrrrrrrrrrrrrrrrrrrr" + reset, bold + "TOAST" + reset, 5, "rrrrrTOASTrrrrrrrrrr", red},
+		{"style opened under the foreground", red + "rrr" + reset + "...." + green + "gggggggggggg" + reset, "TOAST", 5, "rrr..TOASTggggggggg", green},
+		{"foreground leaving its style open", "....................", bold + "TOAST", 5, ".....TOAST..........", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			line := core.Overlay(tc.background, tc.foreground, tc.x, 0)
+			if got := sgr.ReplaceAllString(line, ""); got != tc.want {
+				t.Errorf("text = %q, want %q", got, tc.want)
+			}
+			if lipgloss.Width(line) != lipgloss.Width(tc.background) {
+				t.Errorf("width = %d, want %d", lipgloss.Width(line), lipgloss.Width(tc.background))
+			}
+			after := line[strings.Index(line, "TOAST")+len("TOAST"):]
+			if tc.foreground != "TOAST" {
+				after = strings.TrimPrefix(after, reset)
+			}
+			if want := "\x1b[m" + tc.wantStyle; !strings.HasPrefix(after, want) {
+				t.Errorf("expected the background after the foreground to start with %q, got %q", want, after)
+			}
+		})
+	}
+}
+
+func TestOverlayWideRunes(t *testing.T) {
+	background := "ab世界cd" // 世 and 界 take two cells each
+	cases := []struct {
+		x    int
+		fg   string
+		want string
+	}{
+		{2, "X", "abX 界cd"},   // the right edge cuts 世
+		{3, "X", "ab X界cd"},   // the left edge cuts 世
+		{3, "XY", "ab XY cd"}, // both edges cut a wide rune
+		{4, "XY", "ab世XYcd"},  // no rune is cut
+	}
+	for _, tc := range cases {
+		got := core.Overlay(background, tc.fg, tc.x, 0)
+		if got != tc.want || lipgloss.Width(got) != lipgloss.Width(background) {
+			t.Errorf("Overlay(%q, %q, %d) = %q (width %d), want %q", background, tc.fg, tc.x, got, lipgloss.Width(got), tc.want)
+		}
+	}
+}
+
+func TestOverlayStack(t *testing.T) {
+	m := newTestModel()
+	m.config = config.DefaultConfig()
+	m.config.Profiles = map[string][]string{"work": {"foo"}}
+	m.searchBar = components.NewSearchBarModel()
+	m.profileSwitcher = components.NewProfileSwitcherModel([]string{"work"})
+	m.entryEditor = components.NewEntryEditorModel()
+	press := func(msg tea.KeyMsg) {
+		if _, cmd := m.Update(msg); cmd != nil {
+			m.Update(cmd())
+		}
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	m.entryEditor.Show("New entry", "", components.EntryFormData{})
+	m.openOverlay(m.entryEditor)
+	if m.overlays.Top() != m.entryEditor {
+		t.Fatalf("expected the editor on top of the profile switcher")
+	}
+	base := strings.Repeat(strings.Repeat(".", 70)+"\n", 29) + strings.Repeat(".", 70)
+	view := m.overlays.View(base)
+	lines := strings.Split(view, "\n")
+	if len(lines) != 30 || lines[0] != strings.Repeat(".", 70) || !strings.Contains(view, "New entry") {
+		t.Errorf("expected the dialogs drawn over the base view:\n%s", view)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.entryEditor.IsVisible() || m.overlays.Top() != m.profileSwitcher {
+		t.Fatalf("expected Esc to close only the editor")
+	}
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.overlays.Active() || !slices.Equal(m.selectedKeys, []string{"foo"}) {
+		t.Errorf("expected the profile applied and no dialog left, got %v", m.selectedKeys)
+	}
+	if view := m.overlays.View(base); view != base {
+		t.Errorf("expected the base view unchanged without dialogs")
+	}
+}
+
+func TestQuitConfirm(t *testing.T) {
+	m := newTestModel()
+	m.config = config.DefaultConfig()
+	m.config.ConfigPath = filepath.Join(t.TempDir(), "a-la-carte.yml")
+	m.searchBar = components.NewSearchBarModel()
+	m.confirmDialog = components.NewConfirmDialogModel()
+	m.keyPlans = map[string]*keyPlan{"foo": {}} // nothing to plan in the background
+	m.entries = []string{"foo"}
+	m.softwarePaneLeft = true
+	m.filter()
+	press := func(key string) tea.Cmd {
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		return cmd
+	}
+	isQuit := func(cmd tea.Cmd) bool {
+		if cmd == nil {
+			return false
+		}
+		_, ok := cmd().(tea.QuitMsg)
+		return ok
+	}
+
+	if !isQuit(press("q")) {
+		t.Fatal("expected q to quit without unsaved changes")
+	}
+	m.moveToSelected()
+	if cmd := press("q"); isQuit(cmd) || !m.confirmDialog.IsVisible() {
+		t.Fatal("expected q to ask before quitting with unsaved changes")
+	}
+	if cmd := press("c"); cmd == nil {
+		t.Fatal("expected the answer as a command")
+	} else if _, quit := m.Update(cmd()); isQuit(quit) || m.overlays.Active() {
+		t.Fatal("expected cancel to keep the picker open")
+	}
+
+	press("q")
+	_, quit := m.Update(press("s")())
+	if !isQuit(quit) || m.unsaved() {
+		t.Fatal("expected save-and-quit to save the selection and quit")
+	}
+	if cfg, err := config.Load(m.config.ConfigPath); err != nil || !slices.Equal(cfg.Software.PreloadKeys, []string{"foo"}) {
+		t.Errorf("expected the selection saved as preloaded keys, got %v, %v", cfg, err)
+	}
+}
+
+func TestCopyToClipboard(t *testing.T) {
+	m := newTestModel()
+	m.searchBar = components.NewSearchBarModel()
+	m.confirmDialog = components.NewConfirmDialogModel()
+	m.manifest["foo"] = app.SoftwareEntry{Name: "Foo", Github: "https://github.com/example/foo", Brew: app.StringOrSlice{"foo"}}
+	m.keyPlans = map[string]*keyPlan{"foo": {done: true, plan: []provision.InstallInstruction{{Key: "foo", Type: "brew", Package: "foo"}}, commands: []string{"brew install foo"}}}
+	m.entries = []string{"foo"}
+	m.softwarePaneLeft = true
+	m.filter()
+	var copied []string
+	m.clipboard = func(text string) (string, error) {
+		copied = append(copied, text)
+		return "test", nil
+	}
+	copyAnswer := func(answer string) {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+		if !m.confirmDialog.IsVisible() {
+			t.Fatal("expected y to open the copy menu")
+		}
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(answer)})
+		if cmd == nil {
+			t.Fatalf("expected answer %q as a command", answer)
+		}
+		if _, cmd = m.Update(cmd()); cmd == nil {
+			t.Fatalf("expected the copy of answer %q as a command", answer)
+		}
+		m.Update(cmd())
+	}
+
+	copyAnswer("c")
+	copyAnswer("g")
+	copyAnswer("k")
+	want := []string{"brew install foo", "https://github.com/example/foo", "foo"}
+	if !slices.Equal(copied, want) {
+		t.Errorf("expected %q copied, got %q", want, copied)
+	}
+	if m.toasts.Len() != 3 {
+		t.Errorf("expected a toast for each copy, got %d", m.toasts.Len())
+	}
+
+	m.clipboard = func(string) (string, error) { return "", fmt.Errorf("no clipboard") }
+	m.toasts.Dismiss()
+	copyAnswer("k")
+	if m.toasts.Len() != 1 || !strings.Contains(m.toasts.View(), "no clipboard") {
+		t.Errorf("expected the failure as a toast, got %q", m.toasts.View())
+	}
+
+	var terminal strings.Builder
+	m.clipboard = func(string) (string, error) { return "", errNoClipboardTool }
+	m.osc52Output = &terminal
+	m.toasts.Dismiss()
+	copyAnswer("k")
+	if !strings.Contains(terminal.String(), "52;c;"+base64.StdEncoding.EncodeToString([]byte("foo"))) || !strings.Contains(m.toasts.View(), "via OSC 52") {
+		t.Errorf("expected an OSC 52 copy on the program output without a clipboard tool, got %q", terminal.String())
+	}
+}
+
+func TestOpenLinks(t *testing.T) {
+	m := newTestModel()
+	m.searchBar = components.NewSearchBarModel()
+	m.confirmDialog = components.NewConfirmDialogModel()
+	m.keyPlans = map[string]*keyPlan{"foo": {}, "bar": {}} // nothing to plan in the background
+	m.manifest["foo"] = app.SoftwareEntry{Name: "Foo", Github: "https://github.com/example/foo"}
+	m.manifest["bar"] = app.SoftwareEntry{Name: "Bar", Docs: "https://bar.dev/docs", Home: "https://bar.dev"}
+	m.entries = []string{"foo", "bar"}
+	m.softwarePaneLeft = true
+	m.filter()
+	var opened []string
+	m.openURL = func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+	press := func(key string) tea.Cmd {
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		return cmd
+	}
+
+	m.uiActiveListIndex = slices.Index(m.visible, "foo")
+	press("o")
+	if m.confirmDialog.IsVisible() || !slices.Equal(opened, []string{"https://github.com/example/foo"}) {
+		t.Fatalf("expected the only link opened without a menu, got %q", opened)
+	}
+
+	m.uiActiveListIndex = slices.Index(m.visible, "bar")
+	press("o")
+	if !m.confirmDialog.IsVisible() {
+		t.Fatal("expected a menu for an entry with several links")
+	}
+	if view := m.confirmDialog.View(); !strings.Contains(view, "Docs") || !strings.Contains(view, "Homepage") || strings.Contains(view, "GitHub") {
+		t.Errorf("expected the menu to list the docs and homepage, got %q", view)
+	}
+	m.Update(press("h")())
+	if !slices.Equal(opened, []string{"https://github.com/example/foo", "https://bar.dev"}) {
+		t.Errorf("expected the homepage opened, got %q", opened)
+	}
+
+	for _, url := range []string{"file:///etc/passwd", "javascript:alert(1)", "calc.exe", "ms-settings:"} {
+		if err := openBrowser(url); err == nil {
+			t.Errorf("expected %q not to be opened", url)
+		}
+	}
+}
+
+func TestInstallPreview(t *testing.T) {
+	m := newTestModel()
+	m.searchBar = components.NewSearchBarModel()
+	m.keyPlans = map[string]*keyPlan{"foo": {}} // planned by hand below
+	m.entries = []string{"foo"}
+	m.softwarePaneLeft = true
+	m.filter()
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	if m.preview == nil || !m.preview.IsVisible() || !strings.Contains(m.preview.View(), "Resolving...") {
+		t.Fatal("expected w to open the preview while the entry is planned")
+	}
+	m.Update(keyPlanMsg{key: "foo", plan: keyPlan{
+		done:     true,
+		plan:     []provision.InstallInstruction{{Key: "foo", Type: "script", Package: "echo one\necho two"}},
+		commands: []string{"sudo apt-get update", "bash <<'A_LA_CARTE_SCRIPT'\necho one\necho two\nA_LA_CARTE_SCRIPT"},
+	}})
+	view := m.preview.View()
+	for _, want := range []string{"Install preview: foo", "sudo apt-get update", "echo two"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected the preview to show %q, got:\n%s", want, view)
+		}
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.preview.IsVisible() || m.overlays.Active() {
+		t.Error("expected esc to close the preview")
+	}
+}
+
+func TestEditEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "software.yml")
+	if err := os.WriteFile(path, []byte("# my tools\nfoo:\n  _name: Foo\n  apt: foo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := app.LoadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &model{
+		manifest:         manifest,
+		entries:          []string{"foo"},
+		visible:          []string{"foo"},
+		selectedKeys:     []string{},
+		softwarePaneLeft: true,
+		searchBar:        components.NewSearchBarModel(),
+		entryEditor:      components.NewEntryEditorModel(),
+		manifestSources:  []app.ManifestSource{{Path: path}},
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	if !m.entryEditor.IsVisible() || m.entryEditor.Data().Installers != "apt=foo" {
+		t.Fatalf("expected the editor to open with the entry, got %+v", m.entryEditor.Data())
+	}
+
+	// Move to the installers field and clear it: saving must fail and keep the editor open
+	submit := func() {
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m.Update(cmd())
+	}
+	for range 3 {
+		m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	}
+	for range len("apt=foo") {
+		m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	submit()
+	if !m.entryEditor.IsVisible() || !strings.Contains(m.entryEditor.View(), "at least one installer") {
+		t.Fatalf("expected a validation error, got:\n%s", m.entryEditor.View())
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("brew=foo")})
+	submit()
+	if m.entryEditor.IsVisible() {
+		t.Fatalf("expected the editor to close after saving:\n%s", m.entryEditor.View())
+	}
+	data, _ := os.ReadFile(path)
+	if want := "# my tools\nfoo:\n  _name: Foo\n  brew: foo\n"; string(data) != want {
+		t.Errorf("saved manifest:\n%s\nwant:\n%s", data, want)
+	}
+	if entry := m.manifest["foo"]; len(entry.Brew) != 1 || len(entry.Apt) != 0 {
+		t.Errorf("expected the manifest to be reloaded, got %+v", entry)
+	}
+}
+
+func TestManifestWatcher_ReplacedOnSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "packages.yaml")
+	if err := os.WriteFile(path, []byte("foo:\n  _name: Foo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sources := []app.ManifestSource{{Path: path}}
+	before := stampManifests(sources)
+	w := newManifestWatcher(sources)
+	if w.events == nil {
+		t.Fatal("expected change notifications rather than polling")
+	}
+	msgs := make(chan tea.Msg, 1)
+	go func() { msgs <- w.next()() }()
+
+	// Editors save by writing a temporary file and renaming it over the manifest
+	tmp := filepath.Join(dir, ".packages.yaml.swp")
+	if err := os.WriteFile(tmp, []byte("foo:\n  _name: Foo\nbar:\n  _name: Bar\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-msgs:
+		watch, ok := msg.(manifestWatchMsg)
+		if !ok || maps.Equal(watch.stamps, before) {
+			t.Errorf("expected the stamps of the replaced manifest, got %#v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the replaced manifest to be reported")
+	}
+	if (&manifestWatcher{}).next() != nil {
+		t.Error("expected no command without manifests to watch")
+	}
+}
+
+func TestRightPaneRangeRemoval(t *testing.T) {
+	cases := []struct {
+		name         string
+		keys         []string
+		wantSelected []string
+		wantCursor   int
+		wantLeft     bool
+	}{
+		{"forward range", []string{"v", "j", "d"}, []string{"baz", "qux"}, 0, false},
+		{"reversed anchor", []string{"j", "j", "j", "v", "k", "k", "d"}, []string{"foo"}, 0, false},
+		{"last item", []string{"j", "j", "j", "d"}, []string{"foo", "bar", "baz"}, 2, false},
+		{"last items marked", []string{"j", "j", "v", "j", "d"}, []string{"foo", "bar"}, 1, false},
+		{"mark toggled off", []string{"j", "v", "j", "v", "d"}, []string{"foo", "bar", "qux"}, 2, false},
+		{"everything marked", []string{"j", "j", "j", "v", "k", "k", "k", "d"}, []string{}, 0, true},
+		{"clear", []string{"j", "D"}, []string{}, 0, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newTestModel()
+			m.manifest["qux"] = app.SoftwareEntry{Name: "Qux"}
+			m.entries = append(m.entries, "qux")
+			m.searchBar = components.NewSearchBarModel()
+			m.focus = focusSoftware
+			m.selectedKeys = []string{"foo", "bar", "baz", "qux"}
+			m.filter()
+			for _, key := range tc.keys {
+				m.handleRightPaneKey(key)
+			}
+			if !slices.Equal(m.selectedKeys, tc.wantSelected) {
+				t.Errorf("selected = %v, want %v", m.selectedKeys, tc.wantSelected)
+			}
+			if m.uiActiveListIndex != tc.wantCursor || m.softwarePaneLeft != tc.wantLeft || m.marking {
+				t.Errorf("cursor = %d, left pane = %v, marking = %v; want %d, %v, false", m.uiActiveListIndex, m.softwarePaneLeft, m.marking, tc.wantCursor, tc.wantLeft)
+			}
+			if removed := 4 - len(tc.wantSelected); len(m.visible) != removed {
+				t.Errorf("expected the %d removed keys back in the left pane, got %v", removed, m.visible)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"a-la-carte/internal/app"
+	"a-la-carte/internal/config"
+	"a-la-carte/internal/flags"
+	"a-la-carte/internal/schema"
+	"a-la-carte/internal/ui/components"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// manualWidth is the width the documentation is wrapped at when it is not shown in a terminal.
+const manualWidth = 80
+
+// The default locations of the config file and of the cached remote manifest, as the
+// documentation names them whatever the environment of the machine generating it.
+const (
+	manualConfigPath   = "$XDG_CONFIG_HOME/" + config.DefaultConfigDirname + "/" + config.DefaultConfigFilename
+	manualManifestPath = "$XDG_CACHE_HOME/" + config.DefaultConfigDirname + "/manifest.yaml"
+)
+
+// manual returns the full documentation of --help-long and --man. It is generated from
+// the commands of cli, the keymap, and the doc tags of the config and manifest structs,
+// so it documents new commands, keys and settings without being edited.
+func manual(cli *flags.CLI) *flags.Doc {
+	doc := &flags.Doc{
+		Name:     cli.Name,
+		Summary:  "pick software from a manifest and install it on any system",
+		Sections: cli.Sections(),
+	}
+
+	var contexts []string
+	bindings := make(map[string][]flags.DocItem)
+	for _, b := range keymap {
+		if _, seen := bindings[b.Context]; !seen {
+			contexts = append(contexts, b.Context)
+		}
+		bindings[b.Context] = append(bindings[b.Context], flags.DocItem{Term: b.Keys, Desc: b.Desc})
+	}
+	for _, context := range contexts {
+		doc.Sections = append(doc.Sections, flags.DocSection{Title: "KEY BINDINGS: " + strings.ToUpper(context), Items: bindings[context]})
+	}
+
+	settings := flags.DocSection{
+		Title: "CONFIGURATION",
+		Text: []string{
+			fmt.Sprintf("Settings are read from the file named by --config or %s, or else %s; without a file the defaults apply. Keys are dotted paths in the file, as config get and config set take them; %s stands for a name of your choice.", config.EnvConfigPath, manualConfigPath, schema.MapKey),
+			fmt.Sprintf("Each switch, number, string or list can be overridden with an environment variable named %s and the key in upper case with dots as underscores, e.g. %s=light. Command line flags take precedence over environment variables, and those over the file.", config.EnvPrefix, config.EnvName("ui.theme")),
+		},
+	}
+	envKeys := config.EnvKeys()
+	for _, field := range schema.Fields(reflect.TypeOf(config.Config{})) {
+		desc := field.Doc
+		if slices.Contains(envKeys, field.Key) {
+			desc += ". Environment: " + config.EnvName(field.Key)
+		}
+		settings.Items = append(settings.Items, flags.DocItem{Term: field.Key + " (" + field.Type + ")", Desc: desc})
+	}
+	doc.Sections = append(doc.Sections, settings)
+
+	manifest := flags.DocSection{
+		Title: "MANIFEST",
+		Text: []string{
+			"A manifest is a YAML file mapping keys, e.g. ripgrep, to entries. Keys of an entry starting with _ describe it; the others list its packages for each installer, and the provisioner uses the first installer available on the system.",
+			"A manifest named *" + app.ManifestTemplateExt + " is a chezmoi template, rendered with chezmoi execute-template before it is parsed, so entries can vary by machine; without chezmoi, the .chezmoi.os, .chezmoi.arch, .chezmoi.hostname, .chezmoi.username, .chezmoi.homeDir and .chezmoi.osRelease data are available.",
+		},
+	}
+	for _, field := range schema.Fields(reflect.TypeOf(app.SoftwareEntry{})) {
+		manifest.Items = append(manifest.Items, flags.DocItem{Term: field.Key + " (" + field.Type + ")", Desc: field.Doc})
+	}
+	manifest.Items = append(manifest.Items, flags.DocItem{Term: "<installer>:<os or distro>[:<arch>] (string or list of strings)", Desc: installerVariantDoc})
+	doc.Sections = append(doc.Sections, manifest)
+
+	doc.Sections = append(doc.Sections,
+		flags.DocSection{Title: "ENVIRONMENT", Items: []flags.DocItem{
+			{Term: config.EnvConfigPath, Desc: "Path to the config file"},
+			{Term: config.EnvPrefix + "<SECTION>_<KEY>", Desc: "Overrides a setting of the config file (see CONFIGURATION)"},
+			{Term: "GITHUB_TOKEN", Desc: "Authenticates the GitHub API requests of new-entry"},
+			{Term: "NO_COLOR", Desc: "Draws the UI without colors"},
+			{Term: "XDG_CONFIG_HOME, XDG_CACHE_HOME", Desc: "Where the config file and the cached remote manifest are kept (default ~/.config and ~/.cache)"},
+		}},
+		flags.DocSection{Title: "FILES", Items: []flags.DocItem{
+			{Term: manualConfigPath, Desc: "The config file"},
+			{Term: manualManifestPath, Desc: "The cached copy of software.manifestURL"},
+		}},
+	)
+	return doc
+}
+
+// runLongHelp implements --help-long and --man and returns the exit code: it prints the
+// man page for --man, shows the documentation in a pager in a terminal, and prints it
+// as text otherwise, e.g. when piped to a file.
+func runLongHelp(cli *flags.CLI, opts *flags.Options) int {
+	doc := manual(cli)
+	switch {
+	case opts.Man:
+		fmt.Print(doc.Man(Version))
+	case isTerminal(os.Stdout):
+		pager := components.NewPagerModel(cli.Name+"(1)", doc.Text)
+		if _, err := tea.NewProgram(pager, tea.WithAltScreen()).Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error running pager: %v\n", err)
+			return 1
+		}
+	default:
+		fmt.Print(doc.Text(manualWidth))
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"a-la-carte/internal/app"
+	"a-la-carte/internal/ui/core"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// toggleMembers expands or collapses the member list of the highlighted meta-package in
+// the details panel. It does nothing for other entries.
+func (m *model) toggleMembers() {
+	key := m.highlightedKey()
+	entry, ok := m.manifest[key]
+	if !ok || !entry.IsMeta() {
+		return
+	}
+	if m.expandedMeta == nil {
+		m.expandedMeta = make(map[string]bool)
+	}
+	m.expandedMeta[key] = !m.expandedMeta[key]
+}
+
+// memberLines returns the details lines of a meta-package: its direct members on one
+// line, or, when expanded, every member on its own line with the members of nested
+// meta-packages indented below them.
+func (m *model) memberLines(key string, valueStyle lipgloss.Style) []string {
+	styles := core.CurrentStyles()
+	entry := m.manifest[key]
+	members := slices.Clone(entry.Deps)
+	for _, group := range entry.DepsAny {
+		members = append(members, strings.Join(group, " | "))
+	}
+	if !m.expandedMeta[key] {
+		return []string{styles.DetailKey.Render("Members: ") + valueStyle.Render(strings.Join(members, ", ")) +
+			styles.DimStyle.Render(" (x to expand)")}
+	}
+	lines := []string{styles.DetailKey.Render("Members:") + styles.DimStyle.Render(" (x to collapse)")}
+	lines = append(lines, m.memberTree(entry.Deps, 1, map[string]bool{key: true}, valueStyle)...)
+	for _, group := range entry.DepsAny {
+		lines = append(lines, "  "+valueStyle.Render("one of: "+strings.Join(group, " | ")))
+	}
+	return lines
+}
+
+// memberTree renders members at the given depth, descending into nested meta-packages.
+// seen guards against dependency cycles.
+func (m *model) memberTree(members []string, depth int, seen map[string]bool, valueStyle lipgloss.Style) []string {
+	styles := core.CurrentStyles()
+	var lines []string
+	for _, member := range members {
+		indent := strings.Repeat("  ", depth)
+		entry, ok := m.manifest[member]
+		switch {
+		case !ok:
+			lines = append(lines, indent+styles.ErrorStyle.Render(fmt.Sprintf("%s (not in the manifest)", member)))
+		case seen[member]:
+			lines = append(lines, indent+styles.DimStyle.Render(member+" (cycle)"))
+		default:
+			lines = append(lines, indent+valueStyle.Render(memberLabel(member, &entry)))
+			if entry.IsMeta() {
+				seen[member] = true
+				lines = append(lines, m.memberTree(entry.Deps, depth+1, seen, valueStyle)...)
+				delete(seen, member)
+			}
+		}
+	}
+	return lines
+}
+
+// memberLabel returns "key - description", or the key alone for entries without one.
+func memberLabel(key string, entry *app.SoftwareEntry) string {
+	if entry.Desc == "" {
+		return key
+	}
+	return key + " - " + entry.Desc
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"a-la-carte/internal/app"
+	"a-la-carte/internal/flags"
+)
+
+// newCommand returns the "new" subcommand.
+func newCommand() *flags.Command {
+	var tmpl, name string
+	return &flags.Command{
+		Name:    "new",
+		Args:    "<key>",
+		Summary: "Print a manifest entry skeleton (" + strings.Join(app.EntryTemplateNames(), ", ") + ")",
+		Flags: func(fs *flag.FlagSet, _ *flags.Options) {
+			fs.StringVar(&tmpl, "template", "", "Entry template: "+strings.Join(app.EntryTemplateNames(), ", "))
+			fs.StringVar(&name, "name", "", "Display name of the entry (defaults to the key)")
+		},
+		Run: func(_ *flags.Options, args []string) int {
+			return runNewCommand(tmpl, name, args)
+		},
+	}
+}
+
+// runNewCommand implements the "new" subcommand, which prints a manifest entry
+// skeleton for authors to fill in, and returns the exit code.
+//
+// # Usage
+//
+//	chezmoi-a-la-carte new --template cli-tool|gui-app|language-runtime [--name <name>] <key>
+func runNewCommand(tmpl, name string, args []string) int {
+	if tmpl == "" || len(args) != 1 {
+		return flags.ExitUsage
+	}
+	entry, err := app.NewEntry(tmpl, args[0], name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return flags.ExitUsage
+	}
+	fmt.Print(entry)
+	return 0
+}
+
+// newEntryCommand returns the "new-entry" subcommand.
+func newEntryCommand() *flags.Command {
+	var githubURL, key string
+	return &flags.Command{
+		Name:    "new-entry",
+		Summary: "Print a manifest entry prefilled from a GitHub repository",
+		Flags: func(fs *flag.FlagSet, _ *flags.Options) {
+			fs.StringVar(&githubURL, "github", "", "GitHub repository URL, e.g. https://github.com/sharkdp/bat")
+			fs.StringVar(&key, "key", "", "Manifest key of the entry (defaults to the repository name)")
+		},
+		Run: func(_ *flags.Options, args []string) int {
+			return runNewEntryCommand(githubURL, key, args)
+		},
+	}
+}
+
+// runNewEntryCommand implements the "new-entry" subcommand, which prints a manifest
+// entry prefilled from a GitHub repository's metadata, and returns the exit code.
+// GITHUB_TOKEN, if set, authenticates the API requests.
+//
+// # Usage
+//
+//	chezmoi-a-la-carte new-entry --github <url> [--key <key>]
+func runNewEntryCommand(githubURL, key string, args []string) int {
+	if githubURL == "" || len(args) != 0 {
+		return flags.ExitUsage
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), manifestFetchTimeout)
+	defer cancel()
+	entry, err := app.NewGitHubClient(os.Getenv("GITHUB_TOKEN")).ScaffoldEntry(ctx, githubURL, key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Print(entry)
+	return 0
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+
+	"a-la-carte/internal/app"
+	"a-la-carte/internal/app/provision"
+	"a-la-carte/internal/ui/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// keyPlan is what PlanProvision would do for one key on this system, shown in the
+// "Plan" section of the details panel.
+//
+// # Fields
+//   - done:     Whether the plan has been computed (false while it is computed)
+//   - plan:     The instructions, for the key and the deps it pulls in
+//   - commands: The shell commands installing the plan would run, for the install preview
+//   - report:   The planning decision for each key, with skip reasons
+//   - warnings: Problems planning ran into, e.g. missing deps
+//   - err:      Why planning failed, if it did
+type keyPlan struct {
+	done     bool
+	plan     []provision.InstallInstruction
+	commands []string
+	report   *provision.PlanReport
+	warnings []provision.PlanWarning
+	err      error
+}
+
+// keyPlanMsg carries the plan of a highlighted key, and the installed packages it was
+// planned against so later plans do not query them again.
+type keyPlanMsg struct {
+	key       string
+	plan      keyPlan
+	installed map[string]bool
+}
+
+// computeKeyPlan plans the key on this system in the background, with the index of the
+// loaded manifest. Installed packages are queried first if installed is nil.
+func computeKeyPlan(index *app.ManifestIndex, namespaces []string, key string, installed map[string]bool) tea.Cmd {
+	return func() tea.Msg {
+		if installed == nil {
+			installed = provision.GetInstalledPackages(queryRunner{})
+		}
+		prov := provision.NewProvisioner(provision.DetectSystem(), index.Manifest(), nil)
+		prov.Index = index
+		prov.Namespaces = namespaces
+		prov.BinOnPath = true
+		plan, err := prov.PlanProvision([]string{key}, installed)
+		return keyPlanMsg{
+			key:       key,
+			plan:      keyPlan{done: true, plan: plan, commands: prov.PreviewCommands(plan), report: prov.PlanReport, warnings: prov.Warnings, err: err},
+			installed: installed,
+		}
+	}
+}
+
+// planHighlighted starts planning the highlighted key unless its plan is known or
+// being computed. Plans are kept until the manifest is reloaded.
+func (m *model) planHighlighted() tea.Cmd {
+	key := m.highlightedKey()
+	if key == "" || m.manifest == nil {
+		return nil
+	}
+	if _, known := m.keyPlans[key]; known {
+		return nil
+	}
+	if m.keyPlans == nil {
+		m.keyPlans = make(map[string]*keyPlan)
+	}
+	m.keyPlans[key] = &keyPlan{}
+	return computeKeyPlan(m.manifestIndex(), m.namespaces, key, m.installed)
+}
+
+// handleKeyPlanMsg stores a computed plan. Plans of a manifest that has since been
+// reloaded are dropped.
+func (m *model) handleKeyPlanMsg(msg keyPlanMsg) {
+	if _, pending := m.keyPlans[msg.key]; !pending {
+		return
+	}
+	m.keyPlans[msg.key] = &msg.plan
+	if m.installed == nil {
+		m.installed = msg.installed
+	}
+	if m.preview != nil && m.preview.IsVisible() && m.previewKey == msg.key {
+		m.preview.SetLines(m.previewLines(msg.key))
+	}
+}
+
+// planLines returns the "Plan" section of the details panel for key: each instruction
+// with the key it is for, then the keys left out and why, the _deps_any choices and
+// warnings.
+func (m *model) planLines(key string, valueStyle lipgloss.Style) []string {
+	styles := core.CurrentStyles()
+	lines := []string{"", styles.HeaderStyle.Render("Plan")}
+	kp := m.keyPlans[key]
+	switch {
+	case kp == nil || !kp.done:
+		return append(lines, styles.DimStyle.Render("Resolving..."))
+	case kp.err != nil:
+		return append(lines, styles.ErrorStyle.Render("Planning failed: "+kp.err.Error()))
+	}
+	for _, inst := range kp.plan {
+		lines = append(lines, styles.DetailKey.Render(inst.Key+": ")+valueStyle.Render(inst.Type+" "+inst.Package))
+	}
+	for _, d := range kp.report.Skipped() {
+		lines = append(lines, styles.DetailKey.Render(d.Key+": ")+styles.DimStyle.Render("skipped, "+d.Detail))
+	}
+	if kp.report != nil {
+		for _, c := range kp.report.Choices {
+			lines = append(lines, styles.DetailKey.Render(c.Key+": ")+styles.DimStyle.Render(fmt.Sprintf("needs one of %d, chose %s (%s)", len(c.Group), c.Chosen, c.Why)))
+		}
+	}
+	for _, w := range kp.warnings {
+		lines = append(lines, styles.ErrorStyle.Render("Warning: "+w.Message))
+	}
+	if len(kp.plan) == 0 && len(kp.report.Skipped()) == 0 {
+		lines = append(lines, styles.DimStyle.Render("Nothing to install"))
+	}
+	return lines
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+
+	"a-la-carte/internal/ui/components"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// startPreview shows, in a dialog, the commands installing the highlighted entry and the
+// deps it pulls in would run on this machine. The dialog fills in once the entry is
+// planned.
+func (m *model) startPreview() tea.Cmd {
+	key := m.highlightedKey()
+	if key == "" {
+		return nil
+	}
+	if m.preview == nil {
+		m.preview = components.NewTextDialogModel()
+	}
+	cmd := m.planHighlighted()
+	m.previewKey = key
+	m.preview.Show("Install preview: "+key, m.previewLines(key))
+	m.openOverlay(m.preview)
+	return cmd
+}
+
+// previewLines returns the install preview of key: the commands of its plan, followed by
+// the keys left out and the planning warnings as shell comments.
+func (m *model) previewLines(key string) []string {
+	kp := m.keyPlans[key]
+	switch {
+	case kp == nil || !kp.done:
+		return []string{"Resolving..."}
+	case kp.err != nil:
+		return []string{"Planning failed: " + kp.err.Error()}
+	}
+	var lines []string
+	for _, cmd := range kp.commands {
+		lines = append(lines, strings.Split(cmd, "\n")...)
+	}
+	for _, d := range kp.report.Skipped() {
+		lines = append(lines, "# "+d.Key+" skipped, "+d.Detail)
+	}
+	for _, w := range kp.warnings {
+		lines = append(lines, "# Warning: "+w.Message)
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "# Nothing to install")
+	}
+	return lines
+}
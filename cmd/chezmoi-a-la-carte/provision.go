@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"a-la-carte/internal/app/provision"
+	"a-la-carte/internal/config"
+	"a-la-carte/internal/flags"
+)
+
+// provisionerName is the name of the provisioner executable, built from cmd/provisioner.
+const provisionerName = "provisioner"
+
+// provisionCommand returns the "provision" subcommand, which runs the provisioner with
+// the configured manifests and config file.
+func provisionCommand() *flags.Command {
+	return &flags.Command{
+		Name:        "provision",
+		Args:        "[provisioner options]",
+		Summary:     "Install the selected software with the provisioner (see provision --help)",
+		PassThrough: true,
+		Run:         runProvisioner,
+	}
+}
+
+// exportCommand returns the "export" subcommand, which runs the provisioner with
+// --export-format.
+func exportCommand() *flags.Command {
+	return &flags.Command{
+		Name:        "export",
+		Args:        strings.Join(provision.ExportFormats, "|") + " [provisioner options]",
+		Summary:     "Print the selection in another provisioning system's format instead of installing",
+		PassThrough: true,
+		Run: func(opts *flags.Options, args []string) int {
+			if len(args) == 0 || !slices.Contains(provision.ExportFormats, args[0]) {
+				return flags.ExitUsage
+			}
+			return runProvisioner(opts, append([]string{"--export-format", args[0]}, args[1:]...))
+		},
+	}
+}
+
+// runProvisioner runs the provisioner with args, passing on the manifests and config
+// file the picker uses unless args name their own, and returns its exit code.
+//
+// # Usage
+//
+//	chezmoi-a-la-carte provision [--profile <name>] [--dry-run] ...
+func runProvisioner(opts *flags.Options, args []string) int {
+	path, err := findProvisioner()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		return 1
+	}
+	cmd := exec.Command(path, provisionerArgs(cfg, args)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// provisionerArgs prepends --manifest and --config to args for the manifests and
+// config file of cfg, and --strict for software.strict, unless args already set them.
+func provisionerArgs(cfg *config.Config, args []string) []string {
+	var prefix []string
+	if !hasFlag(args, "manifest") {
+		var manifests []string
+		for _, named := range cfg.ResolveManifests() {
+			if named.Name != "" {
+				manifests = append(manifests, named.Name+"="+named.Path)
+			} else {
+				manifests = append(manifests, named.Path)
+			}
+		}
+		prefix = append(prefix, "--manifest", strings.Join(manifests, ","))
+	}
+	if cfg.ConfigPath != "" && !hasFlag(args, "config") {
+		prefix = append(prefix, "--config", cfg.ConfigPath)
+	}
+	if cfg.Software.Strict && !hasFlag(args, "strict") {
+		prefix = append(prefix, "--strict")
+	}
+	return append(prefix, args...)
+}
+
+// hasFlag reports whether args set the flag name, with one or two dashes.
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if arg, _, _ = strings.Cut(strings.TrimLeft(arg, "-"), "="); arg == name {
+			return true
+		}
+	}
+	return false
+}
+
+// findProvisioner returns the path of the provisioner executable: next to this one,
+// where the Taskfile builds it, or else on PATH.
+func findProvisioner() (string, error) {
+	name := provisionerName
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	if self, err := os.Executable(); err == nil {
+		path := filepath.Join(filepath.Dir(self), name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%s not found next to %s or on PATH; build it with task build-provisioner", name, os.Args[0])
+	}
+	return path, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"a-la-carte/internal/config"
+	"a-la-carte/internal/ui/components"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// quitQuestion identifies the quit confirmation among the answers of the confirm dialog.
+const quitQuestion = "quit"
+
+// quitChoices are the answers of the quit confirmation.
+var quitChoices = []components.ConfirmChoice{
+	{Key: "s", Label: "Save the selection and quit"},
+	{Key: "d", Label: "Discard the changes and quit"},
+	{Key: "c", Label: "Cancel"},
+}
+
+// unsaved reports whether the selection differs from the one last saved or loaded.
+func (m *model) unsaved() bool {
+	return !slices.Equal(m.selectedKeys, m.savedKeys)
+}
+
+// requestQuit quits, or asks first when the selection has unsaved changes. The help
+// and diff views are closed so the question shows over the picker.
+func (m *model) requestQuit() tea.Cmd {
+	if !m.unsaved() || m.confirmDialog == nil {
+		return tea.Quit
+	}
+	m.showHelp, m.showDiff = false, false
+	m.confirmDialog.Show(quitQuestion, "Unsaved selection",
+		fmt.Sprintf("The selection (%d selected) has changed since it was saved.", len(m.selectedKeys)),
+		quitChoices...)
+	m.openOverlay(m.confirmDialog)
+	return nil
+}
+
+// handleConfirmed acts on an answer of the confirm dialog.
+func (m *model) handleConfirmed(msg components.ConfirmedMsg) tea.Cmd {
+	switch msg.ID {
+	case copyQuestion:
+		return m.copyChoice(msg.Choice)
+	case openQuestion:
+		if url, ok := m.openURLs[msg.Choice]; ok {
+			m.openLink(url)
+		}
+		return nil
+	case quitQuestion:
+	default:
+		return nil
+	}
+	switch msg.Choice {
+	case "s":
+		if _, err := m.saveSelection(); err != nil {
+			m.toast(components.ToastError, "Cannot save the selection: "+err.Error())
+			return nil
+		}
+		return tea.Quit
+	case "d":
+		return tea.Quit
+	}
+	return nil
+}
+
+// saveSelection writes the selection to software.preloadKeys of the config file, or of
+// a new one in the default location, so the next session starts with it. It returns
+// the file written.
+func (m *model) saveSelection() (string, error) {
+	if m.config == nil {
+		return "", fmt.Errorf("no configuration")
+	}
+	path := m.config.ConfigPath
+	if path == "" {
+		var err error
+		if path, err = config.DefaultConfigPath(); err != nil {
+			return "", err
+		}
+	}
+	if err := config.SavePreloadKeys(path, m.selectedKeys); err != nil {
+		return "", err
+	}
+	m.config.ConfigPath = path
+	m.config.Software.PreloadKeys = slices.Clone(m.selectedKeys)
+	m.savedKeys = slices.Clone(m.selectedKeys)
+	return path, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import "strings"
+
+const (
+	// recentGroup is the heading of the most frequently and recently selected keys,
+	// shown at the top of the left list.
+	recentGroup = "Recent"
+	// allGroup is the heading of the rest of the left list below the recent keys, when
+	// it is not grouped.
+	allGroup = "All"
+	// recentGroupSize is how many keys the recent group shows.
+	recentGroupSize = 5
+)
+
+// rankedRecent returns the remembered keys that are still in the manifest, most
+// frequently and recently selected first, at most limit of them (0: all).
+func (m *model) rankedRecent(limit int) []string {
+	if m.state == nil {
+		return nil
+	}
+	var keys []string
+	for _, key := range m.state.ranked() {
+		if _, exists := m.manifest[key]; !exists {
+			continue
+		}
+		keys = append(keys, key)
+		if len(keys) == limit {
+			break
+		}
+	}
+	return keys
+}
+
+// withRecentGroup puts the top recent keys that are in rows under a Recent heading
+// at the top of the left list, so keys picked often are quick to find again. They
+// stay in their place below as well. The group is left out while searching, where
+// recent: finds them instead, and in the column view.
+func (m *model) withRecentGroup(rows []string, searching bool) []string {
+	if searching || m.columnView || m.state == nil {
+		return rows
+	}
+	listed := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		listed[row] = true
+	}
+	var recent []string
+	for _, key := range m.rankedRecent(0) {
+		if listed[key] {
+			recent = append(recent, key)
+			if len(recent) == recentGroupSize {
+				break
+			}
+		}
+	}
+	if len(recent) == 0 {
+		return rows
+	}
+
+	if m.groupSizes == nil {
+		m.groupSizes = make(map[string]int)
+	}
+	m.groupSizes[recentGroup] = len(recent)
+	out := []string{groupHeaderPrefix + recentGroup}
+	if !m.collapsedGroups[strings.ToLower(recentGroup)] {
+		out = append(out, recent...)
+	}
+	if m.grouped() {
+		return append(out, rows...)
+	}
+	m.groupSizes[allGroup] = len(rows)
+	out = append(out, groupHeaderPrefix+allGroup)
+	if !m.collapsedGroups[strings.ToLower(allGroup)] {
+		out = append(out, rows...)
+	}
+	return out
+}
+
+// headed reports whether the left list is shown under headings, by group or with the
+// recent group on top.
+func (m *model) headed() bool {
+	if len(m.visible) == 0 {
+		return false
+	}
+	_, heading := groupHeading(m.visible[0])
+	return heading
+}
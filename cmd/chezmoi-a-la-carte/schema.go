@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"a-la-carte/internal/app"
+	"a-la-carte/internal/config"
+	"a-la-carte/internal/flags"
+	"a-la-carte/internal/schema"
+)
+
+// installerVariantDoc describes the installer keys with OS, distro or arch qualifiers.
+const installerVariantDoc = "Packages of an installer on one OS, distribution or architecture, e.g. apt:debian:x64; the most specific key matching the system is used"
+
+// schemaNames are the files the schema command describes, in the order of its usage.
+var schemaNames = []string{"config", "manifest"}
+
+// schemaCommand returns the "schema" subcommand.
+func schemaCommand() *flags.Command {
+	return &flags.Command{
+		Name:    "schema",
+		Args:    strings.Join(schemaNames, "|"),
+		Summary: "Print the JSON Schema of the config file or of manifests, for editors to validate them",
+		Run:     runSchemaCommand,
+	}
+}
+
+// runSchemaCommand implements the "schema" subcommand and returns the exit code. The
+// schema is generated from the structs the file is decoded into, so it always matches
+// what the picker and the provisioner accept; the config schema has the built-in
+// defaults.
+//
+// # Usage
+//
+//	chezmoi-a-la-carte schema config > a-la-carte.schema.json
+//	chezmoi-a-la-carte schema manifest > software.schema.json
+func runSchemaCommand(_ *flags.Options, args []string) int {
+	if len(args) != 1 {
+		return flags.ExitUsage
+	}
+	s, err := fileSchema(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return flags.ExitUsage
+	}
+	out, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(out))
+	return 0
+}
+
+// fileSchema returns the JSON Schema of the file name stands for, one of schemaNames.
+func fileSchema(name string) (map[string]any, error) {
+	switch name {
+	case "config":
+		return schema.JSONSchema("chezmoi-a-la-carte config file", config.DefaultConfig()), nil
+	case "manifest":
+		s := schema.JSONSchema("chezmoi-a-la-carte manifest", app.Manifest{})
+		s["description"] = "Software entries by key, e.g. ripgrep"
+		// Installer keys with qualifiers have no field (see app.SoftwareEntry.Installers)
+		entry := s["additionalProperties"].(map[string]any)
+		entry["patternProperties"] = map[string]any{
+			app.InstallerKeyPattern(): map[string]any{
+				"description": installerVariantDoc,
+				"anyOf": []any{
+					map[string]any{"type": "string"},
+					map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				},
+			},
+		}
+		// A service may also be just its name (see app.Services)
+		service := entry["properties"].(map[string]any)["_service"].(map[string]any)
+		object := service["anyOf"].([]any)[0]
+		item := map[string]any{"anyOf": []any{map[string]any{"type": "string"}, object}}
+		service["anyOf"] = []any{item, map[string]any{"type": "array", "items": item}}
+		return s, nil
+	}
+	return nil, fmt.Errorf("unknown schema %q (must be one of %s)", name, strings.Join(schemaNames, ", "))
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+
+	"a-la-carte/internal/ui/core"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The smallest window the picker layout fits in; smaller windows get the
+// too-small screen instead of a garbled layout.
+const (
+	minWindowWidth  = 80
+	minWindowHeight = 24
+)
+
+// tooSmall reports whether the window is below the minimum size.
+func (m *model) tooSmall() bool {
+	return m.width < minWindowWidth || m.height < minWindowHeight
+}
+
+// renderTooSmall renders the screen shown while the window is below the minimum size,
+// e.g. "terminal too small (need 80x24, have 60x20)". The picker comes back as soon as
+// the window is resized.
+func (m *model) renderTooSmall() string {
+	styles := core.CurrentStyles()
+	body := lipgloss.JoinVertical(lipgloss.Center,
+		styles.ErrorStyle.Render(fmt.Sprintf("terminal too small (need %dx%d, have %dx%d)", minWindowWidth, minWindowHeight, m.width, m.height)),
+		styles.DimStyle.Render("Resize the window or press q to quit"),
+	)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, body)
+}
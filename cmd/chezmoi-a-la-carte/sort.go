@@ -0,0 +1,101 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"a-la-carte/internal/ui/components"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Sort modes of the left list, in the order the sort key cycles through them.
+const (
+	sortAlphabetical = "name"
+	sortGroup        = "group"
+	sortStatus       = "status"
+	sortRecent       = "recent"
+)
+
+var sortModes = []string{sortAlphabetical, sortGroup, sortStatus, sortRecent}
+
+// sortLabels describe the sort modes in toasts and the status bar.
+var sortLabels = map[string]string{
+	sortAlphabetical: "alphabetical",
+	sortGroup:        "group",
+	sortStatus:       "installed status",
+	sortRecent:       "recently selected",
+}
+
+// statusRank orders the status column when sorting by installed status: installed
+// first, then missing, then what cannot be installed here.
+var statusRank = map[string]int{"installed": 0, "missing": 1, "n/a": 2}
+
+// sortMode returns the current sort mode, alphabetical unless another one is remembered.
+func (m *model) sortMode() string {
+	if m.state == nil || !slices.Contains(sortModes, m.state.Sort) {
+		return sortAlphabetical
+	}
+	return m.state.Sort
+}
+
+// cycleSort switches the left list to the next sort mode and remembers it. A column
+// sort of the column view is dropped so the new mode shows. Sorting by installed
+// status queries the installed packages the first time.
+func (m *model) cycleSort() tea.Cmd {
+	if m.state == nil {
+		m.state = &tuiState{}
+	}
+	next := (slices.Index(sortModes, m.sortMode()) + 1) % len(sortModes)
+	m.state.Sort = sortModes[next]
+	m.sortColumn, m.sortDesc = 0, false
+	m.toast(components.ToastInfo, "Sorted by "+sortLabels[m.state.Sort])
+	m.saveState()
+	m.filter()
+	if m.state.Sort == sortStatus && m.installed == nil {
+		return queryInstalled()
+	}
+	return nil
+}
+
+// sortByMode orders keys by the sort mode, alphabetically among equals. Keys arrive
+// sorted, so alphabetical order leaves them as they are.
+func (m *model) sortByMode(keys []string) {
+	var rank func(key string) (int, string)
+	switch m.sortMode() {
+	case sortGroup:
+		// Keys without groups go last.
+		rank = func(key string) (int, string) {
+			groups := m.manifest[key].Groups
+			if len(groups) == 0 {
+				return 1, ""
+			}
+			return 0, strings.ToLower(groups[0])
+		}
+	case sortStatus:
+		rank = func(key string) (int, string) {
+			if r, known := statusRank[m.statusCell(key)]; known {
+				return r, ""
+			}
+			return 0, ""
+		}
+	case sortRecent:
+		// Keys never selected go last.
+		rank = func(key string) (int, string) {
+			if i := slices.Index(m.state.Recent, key); i >= 0 {
+				return i, ""
+			}
+			return len(m.state.Recent), ""
+		}
+	default:
+		return
+	}
+	slices.SortStableFunc(keys, func(a, b string) int {
+		ra, sa := rank(a)
+		rb, sb := rank(b)
+		if ra != rb {
+			return ra - rb
+		}
+		return strings.Compare(sa, sb)
+	})
+}
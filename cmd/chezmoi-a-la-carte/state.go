@@ -0,0 +1,114 @@
+package main
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"a-la-carte/internal/app/provision"
+	"a-la-carte/internal/ui/components"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxRecent is how many recently selected keys the picker remembers.
+const maxRecent = 100
+
+// tuiState is what the picker remembers between sessions, kept apart from the
+// configuration because the picker writes it on its own.
+//
+// # Fields
+//   - Sort:   The sort mode of the left list (see sortModes)
+//   - Recent: Keys in the order they were last selected, most recent first
+//   - Counts: How often each key in Recent was selected
+type tuiState struct {
+	Sort   string         `yaml:"sort,omitempty"`
+	Recent []string       `yaml:"recent,omitempty"`
+	Counts map[string]int `yaml:"counts,omitempty"`
+}
+
+// defaultStatePath returns where the picker state is kept, tui.yml in the state directory.
+func defaultStatePath() string {
+	return filepath.Join(provision.StateDir(), "tui.yml")
+}
+
+// loadState reads the picker state from path. A missing or unreadable file gives an
+// empty state, so a broken state file never keeps the picker from starting.
+func loadState(path string) *tuiState {
+	state := &tuiState{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state
+	}
+	if err := yaml.Unmarshal(data, state); err != nil {
+		return &tuiState{}
+	}
+	return state
+}
+
+// save writes the state to path, creating its directory if needed.
+func (s *tuiState) save(path string) error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// touch records that key was just selected, moving it to the front of Recent and
+// counting it. Keys that drop off the end of Recent are forgotten.
+func (s *tuiState) touch(key string) {
+	s.Recent = slices.DeleteFunc(s.Recent, func(k string) bool { return k == key })
+	s.Recent = slices.Insert(s.Recent, 0, key)
+	if s.Counts == nil {
+		s.Counts = make(map[string]int)
+	}
+	s.Counts[key]++
+	if len(s.Recent) > maxRecent {
+		for _, forgotten := range s.Recent[maxRecent:] {
+			delete(s.Counts, forgotten)
+		}
+		s.Recent = s.Recent[:maxRecent]
+	}
+}
+
+// ranked returns the keys of Recent by frecency: how often a key was selected,
+// discounted by how long ago it last was. Keys with the same score keep their
+// recency order.
+//
+// # Example
+//
+//	// Recent: [a b c], Counts: a=1 b=6 c=2 -> scores 1, 3, 0.67
+//	s.ranked() // [b a c]
+func (s *tuiState) ranked() []string {
+	score := func(i int) float64 {
+		return float64(max(s.Counts[s.Recent[i]], 1)) / float64(i+1)
+	}
+	order := make([]int, len(s.Recent))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(score(b), score(a))
+	})
+	keys := make([]string, len(order))
+	for i, index := range order {
+		keys[i] = s.Recent[index]
+	}
+	return keys
+}
+
+// saveState writes the picker state, reporting a failure in a toast. Models without
+// a state path, as in tests, keep their state in memory only.
+func (m *model) saveState() {
+	if m.statePath == "" {
+		return
+	}
+	if err := m.state.save(m.statePath); err != nil {
+		m.toast(components.ToastError, "Cannot save picker state: "+err.Error())
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"a-la-carte/internal/app"
+	"a-la-carte/internal/ui/core"
+	"a-la-carte/internal/ui/patterns"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// visibleCount returns how many keys the left list shows, leaving out group headings
+// and counting keys listed under Recent once.
+func (m *model) visibleCount() int {
+	keys := make(map[string]bool, len(m.visible))
+	for _, row := range m.visible {
+		if _, heading := groupHeading(row); !heading {
+			keys[row] = true
+		}
+	}
+	return len(keys)
+}
+
+// installedCount returns how many manifest entries are installed, or "…" while the
+// installed packages are being queried.
+func (m *model) installedCount() string {
+	if m.installed == nil {
+		return "…"
+	}
+	count := 0
+	for _, key := range m.entries {
+		_, bare := app.SplitKey(key)
+		if m.installed[key] || m.installed[bare] {
+			count++
+		}
+	}
+	return fmt.Sprint(count)
+}
+
+// activeFilters returns the search text and qualifiers of the search bar, or "none".
+func (m *model) activeFilters() string {
+	if m.searchBar == nil {
+		return "none"
+	}
+	q := parseFilterQuery(m.searchBar.GetSearch())
+	var filters []string
+	if q.text != "" {
+		filters = append(filters, fmt.Sprintf("%q", q.text))
+	}
+	for _, tag := range q.tags {
+		filters = append(filters, "tag:"+tag)
+	}
+	for _, tier := range q.tiers {
+		filters = append(filters, "tier:"+tier)
+	}
+	switch {
+	case q.recent && q.recentLimit > 0:
+		filters = append(filters, fmt.Sprintf("recent:%d", q.recentLimit))
+	case q.recent:
+		filters = append(filters, "recent:")
+	}
+	if len(filters) == 0 {
+		return "none"
+	}
+	return strings.Join(filters, " ")
+}
+
+// themeName returns the name of the active theme: a registered theme if one was set by
+// name, otherwise the configured one.
+func (m *model) themeName() string {
+	if name := core.CurrentThemeName(); name != "" {
+		return name
+	}
+	if m.config != nil && m.config.UI.Theme != "" {
+		return m.config.UI.Theme
+	}
+	return "default"
+}
+
+// statusText returns the text of the status bar: the entry counts, the profile last
+// applied, the active filters, the sort order and the theme.
+//
+// # Example
+//
+//	"12 shown · 3 selected · 40 installed | profile: work | filter: tag:cli | sort: name | theme: dark"
+func (m *model) statusText() string {
+	profile := m.profile
+	if profile == "" {
+		profile = "none"
+	}
+	selected := fmt.Sprintf("%d selected", len(m.selectedKeys))
+	if m.unsaved() {
+		selected += " (unsaved)"
+	}
+	return core.Glyphs(strings.Join([]string{
+		fmt.Sprintf("%d shown · %s · %s installed", m.visibleCount(), selected, m.installedCount()),
+		"profile: " + profile,
+		"filter: " + m.activeFilters(),
+		"sort: " + sortLabels[m.sortMode()],
+		"theme: " + m.themeName(),
+	}, " | "))
+}
+
+// renderStatusBar renders the status bar below the footer, cut to one line of width.
+func (m *model) renderStatusBar(width int) string {
+	text := runewidth.Truncate(m.statusText(), max(width-2, 0), core.Glyphs("…"))
+	bar := patterns.StatusBar(core.StringModel(text))
+	bar.SetSize(width, 1, &core.LayoutContext{AvailableWidth: width, AvailableHeight: 1})
+	return bar.View()
+}
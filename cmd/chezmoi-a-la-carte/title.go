@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+
+	"a-la-carte/internal/ui/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// windowTitle returns the terminal title for the picker state, e.g.
+// "à la carte — 12 selected".
+func (m *model) windowTitle() string {
+	if m.loading {
+		return core.Glyphs("à la carte — loading…")
+	}
+	return core.Glyphs(fmt.Sprintf("à la carte — %d selected", len(m.selectedKeys)))
+}
+
+// titleCmd sets the terminal title when the picker state it shows has changed, unless
+// ui.terminalTitle is off (--no-title). Nothing is set before the window size is known,
+// the first message a running picker gets.
+func (m *model) titleCmd() tea.Cmd {
+	if m.config == nil || !m.config.UI.TerminalTitle || m.width == 0 {
+		return nil
+	}
+	title := m.windowTitle()
+	if title == m.title {
+		return nil
+	}
+	m.title = title
+	return tea.SetWindowTitle(title)
+}
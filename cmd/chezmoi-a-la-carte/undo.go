@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"a-la-carte/internal/ui/components"
+)
+
+// maxUndo is how many selection changes can be undone.
+const maxUndo = 100
+
+// trackSelection records the selection as it was before a key was handled, if handling
+// the key changed it, so the change can be undone. Each key is one step, so a bulk
+// change such as clearing the selection or applying a profile undoes at once.
+func (m *model) trackSelection(before []string) {
+	if slices.Equal(before, m.selectedKeys) {
+		return
+	}
+	m.undoStack = append(m.undoStack, before)
+	if len(m.undoStack) > maxUndo {
+		m.undoStack = m.undoStack[1:]
+	}
+	m.redoStack = nil
+}
+
+// undo restores the selection from before the last change.
+func (m *model) undo() {
+	if len(m.undoStack) == 0 {
+		m.toast(components.ToastInfo, "Nothing to undo")
+		return
+	}
+	m.redoStack = append(m.redoStack, slices.Clone(m.selectedKeys))
+	m.restoreSelection(m.undoStack[len(m.undoStack)-1])
+	m.undoStack = m.undoStack[:len(m.undoStack)-1]
+	m.toast(components.ToastInfo, fmt.Sprintf("Undone (%d selected)", len(m.selectedKeys)))
+}
+
+// redo applies the last undone change again.
+func (m *model) redo() {
+	if len(m.redoStack) == 0 {
+		m.toast(components.ToastInfo, "Nothing to redo")
+		return
+	}
+	m.undoStack = append(m.undoStack, slices.Clone(m.selectedKeys))
+	m.restoreSelection(m.redoStack[len(m.redoStack)-1])
+	m.redoStack = m.redoStack[:len(m.redoStack)-1]
+	m.toast(components.ToastInfo, fmt.Sprintf("Redone (%d selected)", len(m.selectedKeys)))
+}
+
+// restoreSelection replaces the selection, dropping keys the manifest no longer has.
+func (m *model) restoreSelection(keys []string) {
+	m.selectedKeys = slices.DeleteFunc(slices.Clone(keys), func(key string) bool {
+		_, exists := m.manifest[key]
+		return !exists
+	})
+	m.marking = false
+	m.filter()
+	if !m.softwarePaneLeft {
+		m.clampAfterRemoval()
+	}
+}
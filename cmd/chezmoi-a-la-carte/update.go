@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"a-la-carte/internal/app"
+	"a-la-carte/internal/config"
+	"a-la-carte/internal/ui/components"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// manifestFetchTimeout bounds fetching or checking a remote manifest.
+const manifestFetchTimeout = 30 * time.Second
+
+// manifestUpdateMsg reports the result of the background check for a newer remote manifest.
+type manifestUpdateMsg struct {
+	available bool
+	err       error
+}
+
+// manifestReloadedMsg carries the manifest re-fetched after the user asked for a refresh.
+type manifestReloadedMsg struct {
+	manifest app.Manifest
+	err      error
+}
+
+// ensureRemoteManifest fetches a configured remote manifest into the cache if there is
+// no cached copy yet. Later updates are picked up by checkManifestUpdate.
+func ensureRemoteManifest(cfg *config.Config) error {
+	if cfg.Software.ManifestURL == "" {
+		return nil
+	}
+	cachePath := config.ManifestCachePath()
+	if _, err := os.Stat(cachePath); err == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), manifestFetchTimeout)
+	defer cancel()
+	return app.FetchManifest(ctx, cfg.Software.ManifestURL, cachePath)
+}
+
+// checkManifestUpdate checks in the background whether the remote manifest changed.
+func checkManifestUpdate(url string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), manifestFetchTimeout)
+		defer cancel()
+		available, err := app.ManifestUpdateAvailable(ctx, url, config.ManifestCachePath())
+		return manifestUpdateMsg{available: available, err: err}
+	}
+}
+
+// refreshManifest re-fetches the remote manifest into the cache and loads it, rejecting
+// unknown keys if strict is set.
+func refreshManifest(url string, strict bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), manifestFetchTimeout)
+		defer cancel()
+		cachePath := config.ManifestCachePath()
+		if err := app.FetchManifest(ctx, url, cachePath); err != nil {
+			return manifestReloadedMsg{err: err}
+		}
+		manifest, err := app.LoadManifests([]app.ManifestSource{{Path: cachePath, Strict: strict}})
+		return manifestReloadedMsg{manifest: manifest, err: err}
+	}
+}
+
+// handleManifestMsg handles the messages of the remote manifest update checker.
+// Failed background checks are silent; a failed refresh is reported in a toast.
+func (m *model) handleManifestMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case manifestUpdateMsg:
+		m.updateAvailable = msg.err == nil && msg.available
+	case manifestReloadedMsg:
+		m.refreshing, m.notice = false, ""
+		if msg.err != nil {
+			m.toast(components.ToastError, fmt.Sprintf("Manifest refresh failed: %v", msg.err))
+			return m, nil
+		}
+		m.updateAvailable = false
+		m.toast(components.ToastSuccess, "Manifest updated")
+		m.reloadManifest(msg.manifest)
+	}
+	return m, nil
+}
+
+// startManifestRefresh starts re-fetching the remote manifest if an update is available.
+func (m *model) startManifestRefresh() tea.Cmd {
+	if !m.updateAvailable || m.refreshing || m.config == nil {
+		return nil
+	}
+	m.refreshing = true
+	m.notice = "Refreshing manifest..."
+	return refreshManifest(m.config.Software.ManifestURL, m.config.Software.Strict)
+}
+
+// reloadManifest swaps in a new manifest, keeping the selected keys that still exist.
+func (m *model) reloadManifest(manifest app.Manifest) {
+	m.manifest = manifest
+	m.index = app.NewManifestIndex(manifest)
+	m.manifestStamps = stampManifests(m.manifestSources)
+	m.added = nil
+	m.keyPlans = nil
+	m.installers = nil
+	if m.columnView {
+		m.installers = findInstallers(manifest)
+	}
+	m.entries = m.entries[:0]
+	for k := range manifest {
+		m.entries = append(m.entries, k)
+	}
+	sort.Strings(m.entries)
+	kept := m.selectedKeys[:0]
+	for _, key := range m.selectedKeys {
+		if _, exists := manifest[key]; exists {
+			kept = append(kept, key)
+		}
+	}
+	m.selectedKeys = kept
+	m.marking = false
+	m.notInstallable = findNotInstallable(manifest)
+	m.inapplicable = findInapplicable(manifest)
+	m.duplicates = manifest.Duplicates()
+	m.filter()
+	if !m.softwarePaneLeft {
+		m.clampAfterRemoval()
+	}
+}
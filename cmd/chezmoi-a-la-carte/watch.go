@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+
+	"a-la-carte/internal/app"
+	"a-la-carte/internal/ui/components"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+)
+
+// manifestWatchInterval is how often the manifest files are checked for changes when
+// change notifications are not available.
+const manifestWatchInterval = time.Second
+
+// manifestDebounce is how long the manifest files must stay unchanged after a change
+// notification before they are checked, so a save that writes several times reloads once.
+const manifestDebounce = 200 * time.Millisecond
+
+// manifestStamp identifies a version of a manifest file; a missing file has the zero
+// stamp.
+type manifestStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// manifestWatchMsg carries the stamps of the manifest files, read by manifestWatcher.
+type manifestWatchMsg struct {
+	stamps map[string]manifestStamp
+}
+
+// manifestChangedMsg carries the manifests loaded again after a manifest file changed.
+type manifestChangedMsg struct {
+	manifest app.Manifest
+	err      error
+}
+
+// stampManifests returns the stamp of each manifest file of sources, by path.
+func stampManifests(sources []app.ManifestSource) map[string]manifestStamp {
+	stamps := make(map[string]manifestStamp, len(sources))
+	for _, src := range sources {
+		var stamp manifestStamp
+		if info, err := os.Stat(src.Path); err == nil {
+			stamp = manifestStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+		stamps[src.Path] = stamp
+	}
+	return stamps
+}
+
+// notifyManifests reports changes to files through fsnotify. Their directories are
+// watched rather than the files, so a file an editor replaces on save keeps being
+// watched. The channel is closed when notifications stop, e.g. a directory is removed.
+func notifyManifests(files []string) (<-chan struct{}, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	dirs, watched := make(map[string]bool), make(map[string]bool)
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+		dirs[filepath.Clean(dir)] = true
+		watched[filepath.Clean(file)] = true
+	}
+
+	events := make(chan struct{}, 1)
+	go func() {
+		defer close(events)
+		defer func() {
+			_ = watcher.Close()
+		}()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				name := filepath.Clean(event.Name)
+				if dirs[name] && event.Has(fsnotify.Remove|fsnotify.Rename) {
+					return
+				}
+				if watched[name] && event.Op != fsnotify.Chmod {
+					select {
+					case events <- struct{}{}:
+					default:
+					}
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+// manifestWatcher watches the manifest files for changes, through change notifications
+// on their directories where available and by polling otherwise.
+//
+// # Fields
+//   - sources: The manifests watched
+//   - events:  Change notifications for the manifest files; nil when polling
+type manifestWatcher struct {
+	sources []app.ManifestSource
+	events  <-chan struct{}
+}
+
+// newManifestWatcher starts watching the manifest files of sources. It falls back to
+// polling when change notifications cannot be set up, e.g. for a manifest in a directory
+// that does not exist or once the system's limit on watches is reached.
+func newManifestWatcher(sources []app.ManifestSource) *manifestWatcher {
+	w := &manifestWatcher{sources: sources}
+	if len(sources) == 0 {
+		return w
+	}
+	files := make([]string, len(sources))
+	for i, src := range sources {
+		files[i] = src.Path
+	}
+	if events, err := notifyManifests(files); err == nil {
+		w.events = events
+	}
+	return w
+}
+
+// next returns a command that delivers the stamps of the manifest files once they may
+// have changed: after a burst of change notifications has settled, or after
+// manifestWatchInterval when polling. It returns nil when there is nothing to watch.
+func (w *manifestWatcher) next() tea.Cmd {
+	if w == nil || len(w.sources) == 0 {
+		return nil
+	}
+	if w.events == nil {
+		return tea.Tick(manifestWatchInterval, func(time.Time) tea.Msg {
+			return manifestWatchMsg{stamps: stampManifests(w.sources)}
+		})
+	}
+	return func() tea.Msg {
+		if _, ok := <-w.events; !ok {
+			// Notifications stopped: keep watching by polling
+			time.Sleep(manifestWatchInterval)
+			return manifestWatchMsg{stamps: stampManifests(w.sources)}
+		}
+		settled := time.NewTimer(manifestDebounce)
+		defer settled.Stop()
+		for {
+			select {
+			case _, ok := <-w.events:
+				if !ok {
+					<-settled.C
+					return manifestWatchMsg{stamps: stampManifests(w.sources)}
+				}
+				settled.Reset(manifestDebounce)
+			case <-settled.C:
+				return manifestWatchMsg{stamps: stampManifests(w.sources)}
+			}
+		}
+	}
+}
+
+// reloadChangedManifests loads the manifests of sources again in the background.
+func reloadChangedManifests(sources []app.ManifestSource) tea.Cmd {
+	return func() tea.Msg {
+		manifest, err := app.LoadManifests(sources)
+		return manifestChangedMsg{manifest: manifest, err: err}
+	}
+}
+
+// handleManifestWatch reloads the manifests when one of their files changed since they
+// were loaded, and keeps watching. Manifests that failed to load are loaded again as at
+// startup, so fixing the file shows the picker.
+func (m *model) handleManifestWatch(msg manifestWatchMsg) tea.Cmd {
+	watch := m.watcher.next()
+	if m.loading || maps.Equal(msg.stamps, m.manifestStamps) {
+		return watch
+	}
+	m.manifestStamps = msg.stamps
+	if m.loadErr != nil {
+		return tea.Batch(watch, m.startLoading())
+	}
+	return tea.Batch(watch, reloadChangedManifests(m.manifestSources))
+}
+
+// handleManifestChanged swaps in the manifests loaded after a file changed, keeping the
+// selection and highlighting the entries the change added. A manifest that does not load,
+// e.g. while it is being edited, is reported and the picker keeps the previous one.
+func (m *model) handleManifestChanged(msg manifestChangedMsg) tea.Cmd {
+	if msg.err != nil {
+		m.toast(components.ToastError, fmt.Sprintf("Manifest reload failed: %v", msg.err))
+		return nil
+	}
+	previous := m.manifest
+	m.reloadManifest(msg.manifest)
+	for key := range msg.manifest {
+		if _, existed := previous[key]; !existed {
+			if m.added == nil {
+				m.added = make(map[string]bool)
+			}
+			m.added[key] = true
+		}
+	}
+	switch len(m.added) {
+	case 0:
+		m.toast(components.ToastInfo, "Manifest reloaded")
+	case 1:
+		m.toast(components.ToastInfo, "Manifest reloaded: 1 new entry")
+	default:
+		m.toast(components.ToastInfo, fmt.Sprintf("Manifest reloaded: %d new entries", len(m.added)))
+	}
+	return m.planHighlighted()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"a-la-carte/internal/app/provision"
+)
+
+// runAuditCommand implements the "audit" subcommand, which prints the audit log of
+// executed commands and verifies its hash chain, and returns the exit code: 1 if the
+// log cannot be read or (with --verify) has been tampered with.
+//
+// # Usage
+//
+//	provisioner audit [--file <file>] [--verify] [--tail <n>] [--json]
+func runAuditCommand(args []string) int {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	file := fs.String("file", provision.DefaultAuditPath(), "Audit log to print")
+	verify := fs.Bool("verify", false, "Only verify the hash chain of the log")
+	tail := fs.Int("tail", 0, "Only print the last n commands (0 for all)")
+	asJSON := fs.Bool("json", false, "Print the raw JSON lines of the log")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 || *tail < 0 {
+		fmt.Fprintln(os.Stderr, "Usage: provisioner audit [--file <file>] [--verify] [--tail <n>] [--json]")
+		return 2
+	}
+	if *asJSON {
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		_, _ = os.Stdout.Write(data)
+		return 0
+	}
+	records, err := provision.ReadAudit(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	verifyErr := provision.VerifyAudit(records)
+	if *verify {
+		if verifyErr != nil {
+			fmt.Fprintf(os.Stderr, "%s has been tampered with: %v\n", *file, verifyErr)
+			return 1
+		}
+		fmt.Printf("%s is intact: %d commands\n", *file, len(records))
+		return 0
+	}
+	shown := records
+	if *tail > 0 && *tail < len(shown) {
+		shown = shown[len(shown)-*tail:]
+	}
+	if err := provision.WriteAudit(os.Stdout, shown); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if verifyErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: the log has been tampered with: %v\n", verifyErr)
+		return 1
+	}
+	return 0
+}
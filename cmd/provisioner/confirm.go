@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"a-la-carte/internal/app/provision"
+	"a-la-carte/internal/ui/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// planConfirmMsg asks the user to confirm the plan before it runs. The provisioning
+// goroutine waits for the confirmed plan on reply; a nil plan cancels the run.
+type planConfirmMsg struct {
+	plan    []provision.InstallInstruction
+	skipped []provision.KeyDecision // the keys planning left out
+	reply   chan<- []provision.InstallInstruction
+}
+
+// planConfirm is the state of the plan confirmation screen. Each planned key is one
+// row that can be toggled off; the skipped keys follow and cannot be toggled.
+type planConfirm struct {
+	plan    []provision.InstallInstruction
+	keys    []string            // planned keys in plan order
+	steps   map[string][]string // "type package" of each key's instructions
+	off     map[string]bool     // keys toggled off
+	skipped []provision.KeyDecision
+	reply   chan<- []provision.InstallInstruction
+	cursor  int
+	offset  int
+}
+
+// newPlanConfirm builds the confirmation screen for a plan, with every key toggled on.
+func newPlanConfirm(msg planConfirmMsg) *planConfirm {
+	c := &planConfirm{
+		plan:    msg.plan,
+		steps:   make(map[string][]string),
+		off:     make(map[string]bool),
+		skipped: msg.skipped,
+		reply:   msg.reply,
+	}
+	for _, inst := range msg.plan {
+		if _, ok := c.steps[inst.Key]; !ok {
+			c.keys = append(c.keys, inst.Key)
+		}
+		c.steps[inst.Key] = append(c.steps[inst.Key], inst.Type+" "+inst.Package)
+	}
+	return c
+}
+
+// rows is the number of scrollable rows: the planned keys and the skipped keys.
+func (c *planConfirm) rows() int {
+	return len(c.keys) + len(c.skipped)
+}
+
+// confirmed returns the plan without the instructions of the keys toggled off.
+func (c *planConfirm) confirmed() []provision.InstallInstruction {
+	plan := []provision.InstallInstruction{}
+	for _, inst := range c.plan {
+		if !c.off[inst.Key] {
+			plan = append(plan, inst)
+		}
+	}
+	return plan
+}
+
+// answer sends the user's decision to the provisioning goroutine.
+func (c *planConfirm) answer(plan []provision.InstallInstruction) {
+	c.reply <- plan
+}
+
+// handleKey handles a key press on the confirmation screen and reports whether the
+// screen is finished.
+func (c *planConfirm) handleKey(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "up", "k":
+		if c.cursor > 0 {
+			c.cursor--
+		}
+	case "down", "j":
+		if c.cursor < c.rows()-1 {
+			c.cursor++
+		}
+	case " ", "x":
+		if c.cursor < len(c.keys) {
+			key := c.keys[c.cursor]
+			c.off[key] = !c.off[key]
+		}
+	case "y", "enter":
+		c.answer(c.confirmed())
+		return true
+	case "n", "esc", "q":
+		c.answer(nil)
+		return true
+	}
+	// Keep the cursor within the visible window
+	if c.cursor < c.offset {
+		c.offset = c.cursor
+	} else if c.cursor >= c.offset+logPanelHeight {
+		c.offset = c.cursor - logPanelHeight + 1
+	}
+	return false
+}
+
+// View renders the plan as a scrollable list with the key help below it.
+func (c *planConfirm) View() string {
+	styles := core.CurrentStyles()
+	var lines []string
+	for _, key := range c.keys {
+		box := "[x]"
+		if c.off[key] {
+			box = "[ ]"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s  %s", box, key, strings.Join(c.steps[key], ", ")))
+	}
+	for _, skipped := range c.skipped {
+		lines = append(lines, "  - skipped "+skipped.String())
+	}
+
+	var b strings.Builder
+	selected := 0
+	for _, key := range c.keys {
+		if !c.off[key] {
+			selected++
+		}
+	}
+	b.WriteString(styles.HeaderStyle.Render(fmt.Sprintf("Review the plan: %d of %d packages selected, %d skipped", selected, len(c.keys), len(c.skipped))))
+	b.WriteString("\n")
+	end := min(c.offset+logPanelHeight, len(lines))
+	for i := c.offset; i < end; i++ {
+		style := styles.ItemStyle
+		switch {
+		case i == c.cursor:
+			style = styles.ActiveItemStyle
+		case i >= len(c.keys) || c.off[c.keys[i]]:
+			style = styles.DimStyle
+		}
+		b.WriteString(style.Render(lines[i]) + "\n")
+	}
+	for i := end - c.offset; i < logPanelHeight; i++ {
+		b.WriteString("\n")
+	}
+	b.WriteString("\n" + styles.FooterStyle.Render("[y/enter] install  [n/esc] cancel  [space] toggle  [↑/↓] scroll"))
+	return b.String()
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"a-la-carte/internal/app/provision"
+)
+
+// Output formats of a dry run (--dry-run-format).
+const (
+	dryRunText  = "text"  // "[dry-run] Would run: <command>" lines
+	dryRunShell = "shell" // a bash script of the commands, for copy-pasting
+)
+
+// dryRunFormats are the valid values of --dry-run-format.
+var dryRunFormats = []string{dryRunText, dryRunShell}
+
+// scriptDelimiter ends the here-document that holds a manifest script in shell output.
+const scriptDelimiter = "A_LA_CARTE_SCRIPT"
+
+// shellSafe matches arguments that need no quoting in a POSIX shell.
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// dryRunRunner implements provision.ExecRunner and prints the commands a run would
+// execute, exactly as buildExecCmd builds them, instead of running them.
+type dryRunRunner struct {
+	format   string    // dryRunText (default) or dryRunShell
+	out      io.Writer // defaults to stdout
+	managers managerOptions
+}
+
+// writer returns where the commands are printed.
+func (r *dryRunRunner) writer() io.Writer {
+	if r.out == nil {
+		return os.Stdout
+	}
+	return r.out
+}
+
+// header writes the first lines of the output, before any command.
+func (r *dryRunRunner) header() {
+	if r.format == dryRunShell {
+		fmt.Fprintln(r.writer(), "#!/usr/bin/env bash")
+		fmt.Fprintln(r.writer(), "# Generated by provisioner --dry-run-format shell")
+	}
+}
+
+func (r *dryRunRunner) Run(cmd string, args ...string) error {
+	return r.RunContext(context.Background(), cmd, args...)
+}
+
+func (r *dryRunRunner) RunContext(ctx context.Context, cmd string, args ...string) error {
+	w := r.writer()
+	switch {
+	case cmd == "section" || cmd == "info":
+	case cmd == "script" && len(args) > 0:
+		if r.format != dryRunShell {
+			fmt.Fprintf(w, "[dry-run] Would run %s: %s\n", describeScript(args), args[0])
+			return nil
+		}
+		script, err := renderScript(ctx, args[0])
+		if err != nil {
+			fmt.Fprintf(w, "# Rendering the script template failed (%v); it is shown unrendered\n", err)
+			script = []byte(args[0])
+		}
+		argv := append(append([]string(nil), args[1:]...), "bash")
+		fmt.Fprintf(w, "%s <<'%s'\n%s\n%s\n", shellJoin(argv), scriptDelimiter, strings.TrimRight(string(script), "\n"), scriptDelimiter)
+	default:
+		c, _ := r.managers.buildExecCmd(ctx, cmd, args...)
+		if r.format == dryRunShell {
+			fmt.Fprintln(w, shellJoin(c.Args))
+		} else {
+			fmt.Fprintf(w, "[dry-run] Would run: %s\n", shellJoin(c.Args))
+		}
+	}
+	return nil
+}
+
+func (r *dryRunRunner) InstallBinary(_ context.Context, b provision.BinaryInstall) error {
+	if r.format == dryRunShell {
+		fmt.Fprintf(r.writer(), "# Download %s and install %s to %s\n", b.URL, strings.Join(b.Bins, ", "), b.Dir)
+		return nil
+	}
+	fmt.Fprintf(r.writer(), "[dry-run] Would download %s and install %s to %s\n", b.URL, strings.Join(b.Bins, ", "), b.Dir)
+	return nil
+}
+
+func (r *dryRunRunner) Output(cmd string, args ...string) ([]byte, error) {
+	out := fmt.Sprintf("[dry-run] Would output: %s %s", cmd, strings.Join(args, " "))
+	return []byte(out), nil
+}
+
+// shellJoin joins a command's arguments, quoting those a shell would split or expand.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if shellSafe.MatchString(arg) {
+			quoted[i] = arg
+		} else {
+			quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
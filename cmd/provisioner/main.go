@@ -2,23 +2,34 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"syscall"
 	"time"
 
 	"a-la-carte/internal/app"
 	"a-la-carte/internal/app/provision"
+	"a-la-carte/internal/config"
+	"a-la-carte/internal/ui/components"
 	"a-la-carte/internal/ui/core" // Changed from "a-la-carte/internal/ui"
 
 	"flag"
 
+	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
 )
 
 const logPanelHeight = 20
@@ -31,10 +42,23 @@ type logEntry struct {
 
 type logMsg logEntry
 
-type doneMsg struct{}
+// doneMsg reports that provisioning stopped, with the error that stopped it (nil when
+// it ran to the end, even if some packages failed).
+type doneMsg struct {
+	err error
+}
 
 type quitNowMsg struct{}
 
+// stepMsg carries the result of an instruction from the provisioning goroutine.
+type stepMsg provision.ReportStep
+
+// progressMsg reports plan execution progress from the provisioning goroutine.
+type progressMsg struct {
+	done, total int
+	current     provision.InstallInstruction
+}
+
 // Add spinner to model
 type model struct {
 	logs         []logEntry
@@ -44,18 +68,285 @@ type model struct {
 	ready        bool
 	userScrolled bool // track if user has scrolled up
 	spinner      spinner.Model
+	bar          progress.Model
+	progress     progressMsg        // latest execution progress
+	started      time.Time          // when execution of the plan started
+	cancel       context.CancelFunc // aborts the running provisioning; nil when not running
+	aborting     bool
+	prompt       *components.PasswordPromptModel // in-TUI sudo password prompt
+	checkingSudo bool                            // a submitted password is being validated
+	confirm      *planConfirm                    // plan confirmation screen; nil when not shown
+	toasts       *components.ToastsModel         // failures and other transient notifications
 	// For summary
 	attempted  int
 	succeeded  int
 	failed     int
 	failedPkgs []string
 	// CLI flags for provisioning
-	all      bool
-	lazy     bool
-	manifest string
-	dryRun   bool
-	groups   []string
-	only     []string
+	opts options
+}
+
+// options holds the CLI flags that control a provisioning run.
+type options struct {
+	all          bool
+	lazy         bool
+	manifestPath string // a path, or comma-separated name=path pairs
+	strict       bool   // reject manifest keys entries do not declare
+	dryRun       bool
+	dryRunFormat string // dryRunText or dryRunShell
+	groups       []string
+	only         []string
+	lockTimeout  time.Duration
+	minFreeMB    uint64
+	warnLowDisk  bool
+	skipNetwork  bool
+	maxDuration  time.Duration
+	resume       bool
+	historyPath  string
+	history      *provision.History
+	reportPath   string
+	runLogDir    string
+	logFile      string
+	logFormat    string
+	retries      int
+	refreshRepos bool
+	bootstrap    bool
+	strictDeps   bool
+	includeGUI   bool // install GUI apps even on headless systems
+	headless     bool // skip GUI apps even when there is a display
+	failFast     bool // stop at the first failed instruction
+	sandbox      string
+	noServices   bool
+	binOnPath    bool
+	exclude      []string
+	skipGroups   []string
+	tags         []string
+	tiers        []string
+	yes          bool // run the plan without asking for confirmation
+	lockfilePath string
+	lockfile     *provision.Lockfile // versions to pin installs to (--locked)
+	upgrade      bool                // upgrade the installed selection instead of installing
+	managers     managerOptions      // per-manager options from the config file
+	prefer       []string            // installers to try first (--prefer or managers.preferenceOrder)
+	without      []string            // installers never to use (--without and managers.disabled)
+	cacheMode    string              // provision.CacheDownload (--download-only) or provision.CacheOffline (--offline)
+	cacheDir     string
+	testIn       *containerTest // run the plan in a container instead of on this machine (--test-in)
+	target       string         // apply the plan to this host over ssh (--target)
+	auditPath    string         // audit log of executed commands ("" disables it)
+	noTitle      bool           // leave the terminal title alone (--no-title)
+}
+
+// retryBackoff is the delay before the first retry of a transient install failure.
+const retryBackoff = 5 * time.Second
+
+// configure applies the options to a provisioner.
+// Preflight checks are skipped in dry-run mode since nothing is installed.
+func (o *options) configure(prov *provision.Provisioner) {
+	prov.LazyOnly = o.lazy
+	prov.IncludeGUI = o.includeGUI
+	prov.ForceHeadless = o.headless
+	prov.LockTimeout = o.lockTimeout
+	prov.MaxDuration = o.maxDuration
+	prov.History = o.history
+	prov.LogFile = o.logFile
+	prov.LogFormat = o.logFormat
+	prov.RetryPolicy = provision.RetryPolicy{Attempts: o.retries + 1, Backoff: retryBackoff}
+	prov.RefreshRepos = o.refreshRepos
+	prov.BootstrapManagers = o.bootstrap
+	prov.StrictDeps = o.strictDeps
+	prov.FailFast = o.failFast
+	prov.ScriptSandbox = o.sandbox
+	prov.NoServices = o.noServices
+	prov.BinOnPath = o.binOnPath
+	prov.Exclude = o.exclude
+	prov.SkipGroups = o.skipGroups
+	prov.Namespaces = manifestNamespaces(o.manifestPath)
+	prov.Locked = o.lockfile
+	prov.CacheMode = o.cacheMode
+	prov.CacheDir = o.cacheDir
+	prov.DisabledManagers = o.without
+	if len(o.prefer) > 0 {
+		prov.InstallerOrder = provision.PreferInstallers(provision.DefaultInstallerOrder(prov.System), o.prefer)
+	}
+	if !o.dryRun {
+		prov.MinFreeSpaceMB = o.minFreeMB
+		prov.DiskSpaceWarnOnly = o.warnLowDisk
+		prov.CheckReachability = !o.skipNetwork
+	}
+	if o.cacheMode == provision.CacheOffline {
+		// Nothing may touch the network: installs come from the cache only
+		prov.RefreshRepos = false
+		prov.BootstrapManagers = false
+		prov.CheckReachability = false
+	}
+}
+
+// loadManifest loads the manifest, or merges the named manifests, given by --manifest.
+func (o *options) loadManifest() (app.Manifest, error) {
+	sources := parseManifestSources(o.manifestPath)
+	for i := range sources {
+		sources[i].Strict = o.strict
+		sources[i].Render = provision.RenderTemplate
+	}
+	return app.LoadManifests(sources)
+}
+
+// parseManifestSources parses --manifest: a single path, or comma-separated
+// name=path pairs in priority order.
+func parseManifestSources(value string) []app.ManifestSource {
+	if !strings.Contains(value, "=") {
+		return []app.ManifestSource{{Path: value}}
+	}
+	var sources []app.ManifestSource
+	for _, part := range strings.Split(value, ",") {
+		name, path, _ := strings.Cut(strings.TrimSpace(part), "=")
+		sources = append(sources, app.ManifestSource{Name: name, Path: path})
+	}
+	return sources
+}
+
+// manifestNamespaces returns the manifest names of --manifest in priority order.
+func manifestNamespaces(value string) []string {
+	var names []string
+	for _, src := range parseManifestSources(value) {
+		if src.Name != "" {
+			names = append(names, src.Name)
+		}
+	}
+	return names
+}
+
+// openRunLog creates the archive for this run's log. Dry runs are not archived.
+func (o *options) openRunLog() (*provision.RunLog, error) {
+	if o.dryRun || o.runLogDir == "" {
+		return nil, nil
+	}
+	return provision.CreateRunLog(o.runLogDir)
+}
+
+// openAuditLog opens the audit log the executed commands are recorded in. Dry runs
+// execute nothing, so they are not audited.
+func (o *options) openAuditLog() (*provision.AuditLog, error) {
+	if o.dryRun || o.auditPath == "" {
+		return nil, nil
+	}
+	return provision.OpenAuditLog(o.auditPath)
+}
+
+// auditCommand records a command that ran from start in the audit log. host is the
+// remote host for commands run over ssh.
+func auditCommand(audit *provision.AuditLog, c *exec.Cmd, host string, start time.Time, err error) {
+	rec := provision.NewAuditRecord(c.Args, c.Dir, start, err)
+	rec.Host = host
+	_ = audit.Record(rec)
+}
+
+// saveHistory persists the install durations recorded by a run and, for
+// time-boxed or resumed runs, the keys left to install later.
+func (o *options) saveHistory(prov *provision.Provisioner) error {
+	if o.dryRun || o.history == nil || o.cacheMode == provision.CacheDownload {
+		return nil
+	}
+	if o.maxDuration > 0 || o.resume {
+		o.history.Deferred = prov.Deferred
+	}
+	return o.history.Save(o.historyPath)
+}
+
+// saveReport writes the report of a run for "provisioner report".
+func (o *options) saveReport(prov *provision.Provisioner) error {
+	if o.dryRun || o.reportPath == "" || prov.Report == nil {
+		return nil
+	}
+	return provision.WriteReport(o.reportPath, prov.Report)
+}
+
+// saveLockfile records the installed versions of a successful run's packages in the
+// lockfile. Packages the run did not install keep their recorded versions.
+func (o *options) saveLockfile(prov *provision.Provisioner, plan []provision.InstallInstruction) error {
+	if o.dryRun || o.lockfilePath == "" || o.cacheMode == provision.CacheDownload {
+		return nil
+	}
+	lock, err := provision.LoadLockfile(o.lockfilePath)
+	if err != nil {
+		return err
+	}
+	if prov.RecordVersions(plan, lock) == 0 {
+		return nil
+	}
+	return lock.Save(o.lockfilePath)
+}
+
+// defaultLockfilePath returns the lockfile next to the highest-priority manifest.
+func defaultLockfilePath(manifestPath string) string {
+	return filepath.Join(filepath.Dir(parseManifestSources(manifestPath)[0].Path), provision.DefaultLockfileName)
+}
+
+// plan plans the run: the installs of the selection or, with --upgrade, the upgrades of
+// its installed packages. --download-only fetches the whole selection, installed or not,
+// since the machines it is for may lack any of it.
+func (o *options) plan(prov *provision.Provisioner, keys []string, installed map[string]bool) ([]provision.InstallInstruction, error) {
+	if o.upgrade {
+		return prov.PlanUpgrade(keys, installed)
+	}
+	if o.cacheMode == provision.CacheDownload {
+		installed = nil
+	}
+	return prov.PlanProvision(keys, installed)
+}
+
+// exitFailFast is the exit code of a run that --fail-fast stopped early.
+const exitFailFast = 3
+
+// failureExitCode returns the exit code of a failed run: exitFailFast if --fail-fast
+// stopped it early, else 1.
+func failureExitCode(err error) int {
+	if errors.Is(err, provision.ErrFailFast) {
+		return exitFailFast
+	}
+	return 1
+}
+
+// nothingToDo is the message shown when prov planned nothing.
+func (o *options) nothingToDo(prov *provision.Provisioner) string {
+	if o.upgrade {
+		return "Nothing to upgrade. None of the requested packages are installed by a package manager that can upgrade them."
+	}
+	if o.cacheMode == provision.CacheDownload {
+		return "Nothing to download. None of the requested packages can be cached for offline installs."
+	}
+	if summary := prov.PlanReport.SkipSummary(); summary != "" {
+		return fmt.Sprintf("Nothing to install. All requested packages are already installed or filtered out (%s).", summary)
+	}
+	return "Nothing to install. All requested packages are already installed or filtered out."
+}
+
+// versionsBefore records the installed versions of an --upgrade plan before it runs,
+// for upgradeSummary. It returns nil for other runs.
+func (o *options) versionsBefore(prov *provision.Provisioner, plan []provision.InstallInstruction) *provision.Lockfile {
+	if !o.upgrade || o.dryRun {
+		return nil
+	}
+	before := &provision.Lockfile{}
+	prov.RecordVersions(plan, before)
+	return before
+}
+
+// upgradeSummary returns the lines reporting which packages an --upgrade run updated,
+// given the versions recorded by versionsBefore.
+func upgradeSummary(prov *provision.Provisioner, plan []provision.InstallInstruction, before *provision.Lockfile) []string {
+	if before == nil {
+		return nil
+	}
+	after := &provision.Lockfile{}
+	prov.RecordVersions(plan, after)
+	changes := provision.ChangedVersions(before, after)
+	lines := []string{fmt.Sprintf("Updated %d of %d packages", len(changes), len(plan))}
+	for _, change := range changes {
+		lines = append(lines, "  "+change.String())
+	}
+	return lines
 }
 
 func initialModel() *model {
@@ -68,12 +359,19 @@ func initialModel() *model {
 		logChan: make(chan tea.Msg, 100),
 		ready:   false,
 		spinner: sp,
+		bar:     progress.New(progress.WithDefaultGradient(), progress.WithWidth(40)),
+		prompt:  components.NewPasswordPromptModel("Administrator access", "Enter your sudo password to install packages:"),
+		toasts:  components.NewToastsModel(),
 	}
 }
 
-// tuiExecRunner implements provision.ExecRunner and sends logs as tea.Msgs.
+// tuiExecRunner implements provision.ExecRunner and provision.OutputCapturer, and sends logs as tea.Msgs.
 type tuiExecRunner struct {
 	dispatch func(logMsg)
+	log      *provision.RunLog   // archives command output (optional)
+	audit    *provision.AuditLog // records the executed commands (optional)
+	managers managerOptions
+	provision.CapturedOutput
 }
 
 // Utility to strip ANSI codes
@@ -82,32 +380,67 @@ func stripANSI(input string) string {
 	return ansi.ReplaceAllString(input, "")
 }
 
-// Helper to construct exec.Cmd and log message for a given command
-func buildExecCmd(cmd string, args ...string) (c *exec.Cmd, logMsgStr string) {
-	switch cmd {
-	case "apt":
-		aptArgs := []string{"-o", "DPkg::Options::=--force-confdef", "install", "-y", "--no-install-recommends", "--ignore-missing"}
-		aptArgs = append(aptArgs, args...)
-		fullCmd := append([]string{"env", "DEBIAN_FRONTEND=noninteractive", "apt-get"}, aptArgs...)
-		logMsgStr = "sudo " + strings.Join(fullCmd, " ")
-		c = exec.Command("sudo", fullCmd...)
-	case "apk":
-		apkArgs := append([]string{"add", "--no-cache"}, args...)
-		logMsgStr = "sudo apk " + strings.Join(apkArgs, " ")
-		c = exec.Command("sudo", append([]string{"apk"}, apkArgs...)...)
-	case "dnf", "yum":
-		pmArgs := append([]string{"install", "-y", "--setopt=skip_if_unavailable=True", "--setopt=skip_missing_names_on_install=True"}, args...)
-		logMsgStr = "sudo " + cmd + " " + strings.Join(pmArgs, " ")
-		c = exec.Command("sudo", append([]string{cmd}, pmArgs...)...)
-	case "zypper":
-		zypperArgs := append([]string{"--non-interactive", "install", "-y"}, args...)
-		logMsgStr = "sudo zypper " + strings.Join(zypperArgs, " ")
-		c = exec.Command("sudo", append([]string{"zypper"}, zypperArgs...)...)
-	default:
-		logMsgStr = cmd + " " + strings.Join(args, " ")
-		c = exec.Command(cmd, args...)
+// commandAbortGrace is how long an interrupted command gets to exit before it is killed.
+const commandAbortGrace = 5 * time.Second
+
+// interruptOnCancel makes c receive SIGINT instead of SIGKILL when its context is
+// cancelled, so package managers can clean up (sudo forwards the signal).
+func interruptOnCancel(c *exec.Cmd) *exec.Cmd {
+	c.Cancel = func() error { return c.Process.Signal(os.Interrupt) }
+	c.WaitDelay = commandAbortGrace
+	return c
+}
+
+// installCommands are the install commands of the system package managers, which run
+// through sudo. The packages to install are appended.
+var installCommands = map[string]struct {
+	env  []string // environment variables, set with env since sudo resets the environment
+	argv []string
+}{
+	"apt":    {env: []string{"DEBIAN_FRONTEND=noninteractive"}, argv: []string{"apt-get", "-o", "DPkg::Options::=--force-confdef", "install", "-y", "--no-install-recommends", "--ignore-missing"}},
+	"apk":    {argv: []string{"apk", "add", "--no-cache"}},
+	"dnf":    {argv: []string{"dnf", "install", "-y", "--setopt=skip_if_unavailable=True", "--setopt=skip_missing_names_on_install=True"}},
+	"yum":    {argv: []string{"yum", "install", "-y", "--setopt=skip_if_unavailable=True", "--setopt=skip_missing_names_on_install=True"}},
+	"zypper": {argv: []string{"zypper", "--non-interactive", "install", "-y"}},
+}
+
+// managerOptions are the per-manager options of the config file's managers section,
+// applied by buildExecCmd.
+type managerOptions map[string]config.ManagerOptions
+
+// envCommand returns the "env K=V ..." prefix that sets the default and configured
+// environment variables, or nil if there are none.
+func envCommand(defaults []string, configured map[string]string) []string {
+	vars := append([]string(nil), defaults...)
+	for _, name := range slices.Sorted(maps.Keys(configured)) {
+		vars = append(vars, name+"="+configured[name])
 	}
-	return c, logMsgStr
+	if len(vars) == 0 {
+		return nil
+	}
+	return append([]string{"env"}, vars...)
+}
+
+// buildExecCmd constructs the exec.Cmd and log message for a command of the provisioner.
+// The managers' configured extra args are added before the package (the last argument)
+// and their environment variables are set with env, so both show up in dry runs.
+func (m managerOptions) buildExecCmd(ctx context.Context, cmd string, args ...string) (c *exec.Cmd, logMsgStr string) {
+	opts := m[cmd]
+	if install, ok := installCommands[cmd]; ok {
+		fullCmd := append(envCommand(install.env, opts.Env), install.argv...)
+		fullCmd = append(append(fullCmd, opts.ExtraArgs...), args...)
+		return interruptOnCancel(sudoCommand(ctx, fullCmd...)), "sudo " + strings.Join(fullCmd, " ")
+	}
+	if cmd == "sudo" {
+		return interruptOnCancel(sudoCommand(ctx, args...)), "sudo " + strings.Join(args, " ")
+	}
+	if len(opts.ExtraArgs) > 0 && len(args) > 0 {
+		last := len(args) - 1
+		args = append(append(append([]string(nil), args[:last]...), opts.ExtraArgs...), args[last])
+	}
+	fullCmd := append(envCommand(nil, opts.Env), cmd)
+	fullCmd = append(fullCmd, args...)
+	return interruptOnCancel(exec.CommandContext(ctx, fullCmd[0], fullCmd[1:]...)), strings.Join(fullCmd, " ")
 }
 
 // Helper to stream output from stdout/stderr and dispatch log messages
@@ -138,6 +471,10 @@ func streamOutput(stdout, stderr io.ReadCloser, dispatch func(logMsg)) {
 }
 
 func (r *tuiExecRunner) Run(cmd string, args ...string) error {
+	return r.RunContext(context.Background(), cmd, args...)
+}
+
+func (r *tuiExecRunner) RunContext(ctx context.Context, cmd string, args ...string) error {
 	if cmd == "section" && len(args) > 0 {
 		r.dispatch(logMsg{Level: "section", Text: args[0]})
 		return nil
@@ -147,7 +484,20 @@ func (r *tuiExecRunner) Run(cmd string, args ...string) error {
 		return nil
 	}
 
-	c, logMsgStr := buildExecCmd(cmd, args...)
+	var c *exec.Cmd
+	var logMsgStr string
+	if cmd == "script" && len(args) > 0 {
+		var cleanup func()
+		var err error
+		logMsgStr = describeScript(args)
+		if c, cleanup, err = scriptCommand(ctx, args); err != nil {
+			r.dispatch(logMsg{Level: "error", Text: fmt.Sprintf("Error: %s: %v", logMsgStr, err)})
+			return err
+		}
+		defer cleanup()
+	} else {
+		c, logMsgStr = r.managers.buildExecCmd(ctx, cmd, args...)
+	}
 	r.dispatch(logMsg{Level: "info", Text: logMsgStr})
 
 	stdout, err := c.StdoutPipe()
@@ -160,12 +510,19 @@ func (r *tuiExecRunner) Run(cmd string, args ...string) error {
 		r.dispatch(logMsg{Level: "error", Text: "Failed to get stderr: " + err.Error()})
 		return err
 	}
+	start := time.Now()
 	if startErr := c.Start(); startErr != nil {
+		auditCommand(r.audit, c, "", start, startErr)
 		r.dispatch(logMsg{Level: "error", Text: "Failed to start command: " + startErr.Error()})
 		return startErr
 	}
-	streamOutput(stdout, stderr, r.dispatch)
+	outCopy, errCopy := r.Writers()
+	streamOutput(io.NopCloser(io.TeeReader(stdout, outCopy)), io.NopCloser(io.TeeReader(stderr, errCopy)), func(msg logMsg) {
+		r.log.Log("output", msg.Text)
+		r.dispatch(msg)
+	})
 	err = c.Wait()
+	auditCommand(r.audit, c, "", start, err)
 	if err != nil {
 		r.dispatch(logMsg{Level: "error", Text: fmt.Sprintf("Error: %s: %v", logMsgStr, err)})
 		return err
@@ -175,70 +532,64 @@ func (r *tuiExecRunner) Run(cmd string, args ...string) error {
 }
 
 func (r *tuiExecRunner) Output(cmd string, args ...string) ([]byte, error) {
-	msg := fmt.Sprintf("Output: %s %s", cmd, strings.Join(args, " "))
-	r.dispatch(logMsg{Level: "info", Text: msg})
-	return []byte("output"), nil
+	c := exec.Command(cmd, args...)
+	start := time.Now()
+	out, err := c.Output()
+	auditCommand(r.audit, c, "", start, err)
+	return out, err
+}
+
+// realSystemRunner implements provision.ExecRunner and provision.OutputCapturer using os/exec (no logging, real output)
+type realSystemRunner struct {
+	log      *provision.RunLog   // archives command output (optional)
+	audit    *provision.AuditLog // records the executed commands (optional)
+	managers managerOptions
+	provision.CapturedOutput
 }
 
-// realSystemRunner implements provision.ExecRunner using os/exec (no logging, real output)
-type realSystemRunner struct{}
+// run runs c, recording it in the audit log.
+func (r *realSystemRunner) run(c *exec.Cmd) error {
+	start := time.Now()
+	err := c.Run()
+	auditCommand(r.audit, c, "", start, err)
+	return err
+}
 
 func (r *realSystemRunner) Run(cmd string, args ...string) error {
+	return r.RunContext(context.Background(), cmd, args...)
+}
+
+func (r *realSystemRunner) RunContext(ctx context.Context, cmd string, args ...string) error {
+	if cmd == "info" && len(args) > 0 {
+		fmt.Println(args[0])
+		return nil
+	}
 	if cmd == "section" || cmd == "info" {
 		return nil
 	}
 	if cmd == "script" && len(args) > 0 {
-		script := args[0]
-		tmpRaw, err := os.CreateTemp("", "provision-script-raw-*.sh")
-		if err != nil {
-			return err
-		}
-		defer func() {
-			_ = os.Remove(tmpRaw.Name())
-		}()
-		if _, err2 := tmpRaw.WriteString(script); err2 != nil {
-			_ = tmpRaw.Close()
-			return err2
-		}
-		if err2 := tmpRaw.Close(); err2 != nil {
-			return err2
-		}
-
-		tmpTmpl, err := os.CreateTemp("", "provision-script-tmpl-*.sh")
-		if err != nil {
-			return err
-		}
-		defer func() {
-			_ = os.Remove(tmpTmpl.Name())
-		}()
-
-		// Process through chezmoi execute-template
-		chezCmd := exec.Command("chezmoi", "execute-template", tmpRaw.Name())
-		out, err := chezCmd.Output()
+		c, cleanup, err := scriptCommand(ctx, args)
 		if err != nil {
 			return err
 		}
-		if _, err2 := tmpTmpl.Write(out); err2 != nil {
-			_ = tmpTmpl.Close()
-			return err2
-		}
-		if err2 := tmpTmpl.Close(); err2 != nil {
-			return err2
-		}
-
-		bashCmd := exec.Command("bash", tmpTmpl.Name())
-		bashCmd.Stdout = os.Stdout
-		bashCmd.Stderr = os.Stderr
-		return bashCmd.Run()
+		defer cleanup()
+		outCopy, errCopy := r.Writers()
+		c.Stdout = io.MultiWriter(os.Stdout, r.log, outCopy)
+		c.Stderr = io.MultiWriter(os.Stderr, r.log, errCopy)
+		return r.run(c)
 	}
-	c := exec.Command(cmd, args...)
-	c.Stdout = os.Stdout
-	c.Stderr = os.Stderr
-	return c.Run()
+	c, _ := r.managers.buildExecCmd(ctx, cmd, args...)
+	outCopy, errCopy := r.Writers()
+	c.Stdout = io.MultiWriter(os.Stdout, r.log, outCopy)
+	c.Stderr = io.MultiWriter(os.Stderr, r.log, errCopy)
+	return r.run(c)
 }
 func (r *realSystemRunner) Output(cmd string, args ...string) ([]byte, error) {
 	c := exec.Command(cmd, args...)
-	return c.Output()
+	start := time.Now()
+	out, err := c.Output()
+	auditCommand(r.audit, c, "", start, err)
+	return out, err
 }
 
 // getInstalledPackages returns a map of installed package keys. For now, returns an empty map (stub).
@@ -247,89 +598,200 @@ func (r *realSystemRunner) Output(cmd string, args ...string) ([]byte, error) {
 // 	return map[string]bool{}
 // }
 
-func initialModelWithFlags(all, lazy bool, manifestPath string, dryRun bool, groups, only []string) *model {
+func initialModelWithFlags(opts *options) *model {
 	m := initialModel()
-	m.all = all
-	m.lazy = lazy
-	m.manifest = manifestPath
-	m.dryRun = dryRun
-	m.groups = groups
-	m.only = only
+	m.opts = *opts
 	return m
 }
 
-type tickMsg time.Time
+// waitForLog returns a command that blocks until the provisioning goroutine sends
+// the next message. It is re-issued after every message, so log lines reach the
+// TUI as soon as they are written without polling.
+func waitForLog(ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-ch
+	}
+}
 
 func (m *model) Init() tea.Cmd {
-	// Start the provisioning goroutine
+	if !m.opts.dryRun && m.opts.testIn == nil && !useAskpass() && !sudoCached() {
+		// Ask for the sudo password inside the TUI before anything runs
+		m.prompt.Show()
+	} else {
+		m.startProvisioning()
+	}
+	return tea.Batch(m.spinner.Tick, waitForLog(m.logChan))
+}
+
+// startProvisioning runs planning and installation in a background goroutine,
+// keeping sudo credentials fresh until it finishes.
+func (m *model) startProvisioning() {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	if m.opts.testIn != nil {
+		go func() {
+			defer cancel()
+			m.runContainerTest(ctx)
+		}()
+		return
+	}
+	stop := make(chan struct{})
+	if !m.opts.dryRun {
+		go keepSudoAlive(stop, func() {
+			select {
+			case m.logChan <- sudoExpiredMsg{}:
+			case <-stop:
+			}
+		})
+	}
 	go func() {
-		manifest, err := app.LoadManifest(m.manifest)
+		defer close(stop)
+		defer cancel()
+		manifest, err := m.opts.loadManifest()
 		if err != nil {
 			m.logChan <- logMsg{Level: "error", Text: fmt.Sprintf("Failed to load manifest: %v", err)}
-			m.logChan <- doneMsg{}
+			m.logChan <- doneMsg{err: err}
 			return
 		}
-		var keys []string
-		switch {
-		case len(m.only) > 0:
-			keys = m.only
-		case len(m.groups) > 0:
-			for k := range manifest {
-				entry := manifest[k]
-				entryPtr := &entry
-				for _, g := range entryPtr.Groups {
-					for _, want := range m.groups {
-						if g == want {
-							keys = append(keys, k)
-							break
-						}
-					}
-				}
-			}
-		default:
-			for k := range manifest {
-				keys = append(keys, k)
-			}
-		}
+		keys := selectKeys(manifest, m.opts.groups, m.opts.only, m.opts.tags, m.opts.tiers)
 		var runner provision.ExecRunner
-		if m.dryRun {
+		if m.opts.dryRun {
 			runner = &dryRunRunner{}
 		} else {
 			runner = &realSystemRunner{}
 		}
 		installed := provision.GetInstalledPackages(runner)
 		dispatch := func(msg logMsg) { m.logChan <- msg }
-		prov := provision.NewProvisioner(nil, manifest, &tuiExecRunner{dispatch: dispatch})
-		prov.LazyOnly = m.lazy
+		runLog, err := m.opts.openRunLog()
+		if err != nil {
+			dispatch(logMsg{Level: "error", Text: fmt.Sprintf("Not archiving this run: %v", err)})
+		}
+		defer func() {
+			_ = runLog.Close()
+		}()
+		audit, err := m.opts.openAuditLog()
+		if err != nil {
+			dispatch(logMsg{Level: "error", Text: fmt.Sprintf("Not auditing this run: %v", err)})
+		}
+		tuiRunner := &tuiExecRunner{dispatch: dispatch, log: runLog, audit: audit, managers: m.opts.managers}
+		prov := provision.NewProvisioner(provision.DetectSystem(), manifest, tuiRunner)
+		m.opts.configure(prov)
+		prov.RunLog = runLog
+		m.follow(prov)
 		dispatch(logMsg{Level: "info", Text: "Starting provisioning..."})
 		dispatch(logMsg{Level: "info", Text: "Planning..."})
-		plan, err := prov.PlanProvision(keys, installed)
+		plan, err := m.opts.plan(prov, keys, installed)
 		if err != nil {
 			dispatch(logMsg{Level: "error", Text: fmt.Sprintf("Failed to plan provision: %v", err)})
-			m.logChan <- doneMsg{}
+			m.logChan <- doneMsg{err: err}
 			return
 		}
 		if len(plan) == 0 {
-			dispatch(logMsg{Level: "info", Text: "Nothing to install. All requested packages are already installed or filtered out."})
+			dispatch(logMsg{Level: "info", Text: m.opts.nothingToDo(prov)})
+		} else if !m.opts.yes {
+			reply := make(chan []provision.InstallInstruction)
+			m.logChan <- planConfirmMsg{plan: plan, skipped: prov.PlanReport.Skipped(), reply: reply}
+			if plan = <-reply; plan == nil {
+				dispatch(logMsg{Level: "info", Text: "Cancelled; nothing was installed."})
+				m.logChan <- doneMsg{}
+				return
+			}
 		}
 		dispatch(logMsg{Level: "info", Text: "Installing..."})
-		err = prov.ExecutePlan(plan)
+		before := m.opts.versionsBefore(prov, plan)
+		err = prov.ExecutePlanContext(ctx, plan)
+		for _, line := range upgradeSummary(prov, plan, before) {
+			dispatch(logMsg{Level: "info", Text: line})
+		}
+		if saveErr := m.opts.saveHistory(prov); saveErr != nil {
+			dispatch(logMsg{Level: "error", Text: fmt.Sprintf("Failed to save install history: %v", saveErr)})
+		}
+		if saveErr := m.opts.saveReport(prov); saveErr != nil {
+			dispatch(logMsg{Level: "error", Text: fmt.Sprintf("Failed to save run report: %v", saveErr)})
+		}
 		if err != nil {
+			runLog.Log("error", fmt.Sprintf("Provisioning failed: %v", err))
 			dispatch(logMsg{Level: "error", Text: fmt.Sprintf("Provisioning failed: %v", err)})
 		} else {
+			if saveErr := m.opts.saveLockfile(prov, plan); saveErr != nil {
+				dispatch(logMsg{Level: "error", Text: fmt.Sprintf("Failed to update the lockfile: %v", saveErr)})
+			}
+			runLog.Log("success", "Provisioning complete")
 			dispatch(logMsg{Level: "success", Text: "Provisioning complete"})
 		}
-		m.logChan <- doneMsg{}
+		m.logChan <- doneMsg{err: err}
 	}()
-	// Start the ticker for live updates
-	return tea.Tick(50*time.Millisecond, func(t time.Time) tea.Msg {
-		return tickMsg(t)
-	})
+}
+
+// follow sends the progress of prov and the result of each instruction it runs to the
+// TUI.
+func (m *model) follow(prov *provision.Provisioner) {
+	prov.Progress = func(done, total int, current provision.InstallInstruction) {
+		m.logChan <- progressMsg{done: done, total: total, current: current}
+	}
+	prov.StepDone = func(step provision.ReportStep) {
+		m.logChan <- stepMsg(step)
+	}
+}
+
+// sudoValidatedMsg reports whether the password submitted in the prompt was accepted.
+type sudoValidatedMsg struct {
+	err error
+}
+
+// sudoExpiredMsg reports that the sudo credentials expired during the run and could not
+// be refreshed without the password.
+type sudoExpiredMsg struct{}
+
+// checkSudoPassword validates password with sudo in the background, so the TUI keeps
+// drawing while sudo answers.
+func checkSudoPassword(password string) tea.Cmd {
+	return func() tea.Msg {
+		return sudoValidatedMsg{err: validateSudo(password)}
+	}
+}
+
+// handlePasswordSubmitted starts validating the sudo password entered in the prompt.
+func (m *model) handlePasswordSubmitted(password string) tea.Cmd {
+	m.checkingSudo = true
+	return checkSudoPassword(password)
+}
+
+// handleSudoValidated starts provisioning once the sudo password is accepted, or asks
+// for it again. A password asked for again during the run only refreshes the
+// credentials.
+func (m *model) handleSudoValidated(msg sudoValidatedMsg) {
+	m.checkingSudo = false
+	if msg.err != nil {
+		m.prompt.SetError("Sorry, try again.")
+		return
+	}
+	m.prompt.Hide()
+	if m.cancel == nil {
+		m.startProvisioning()
+	}
+}
+
+// handleSudoExpired asks for the sudo password again when the credentials expired
+// during the run.
+func (m *model) handleSudoExpired() {
+	if m.cancel == nil || m.prompt.IsVisible() {
+		return
+	}
+	m.prompt.Show()
+	m.prompt.SetError("The sudo credentials expired; enter the password again.")
 }
 
 func (m *model) handleKeyMsg(msg tea.KeyMsg) (*model, tea.Cmd) {
 	switch msg.String() {
 	case "ctrl+c", "q":
+		if m.cancel != nil && !m.aborting {
+			// First press aborts the running command; the TUI exits once provisioning stops
+			m.aborting = true
+			m.status = "Aborting..."
+			m.cancel()
+			return m, m.toasts.Push(components.ToastInfo, "Stopping after the running command; press q again to quit now")
+		}
 		return m, tea.Quit
 	case "up", "k":
 		if m.cursor > 0 {
@@ -353,30 +815,26 @@ func (m *model) handleKeyMsg(msg tea.KeyMsg) (*model, tea.Cmd) {
 	return m, nil
 }
 
+// handleConfirmKey passes a key press to the plan confirmation screen. ctrl+c cancels
+// the run and quits right away.
+func (m *model) handleConfirmKey(msg tea.KeyMsg) (*model, tea.Cmd) {
+	if msg.String() == "ctrl+c" {
+		m.confirm.answer(nil)
+		m.confirm = nil
+		return m, tea.Quit
+	}
+	if m.confirm.handleKey(msg) {
+		m.confirm = nil
+		m.status = "Installing..."
+	}
+	return m, nil
+}
+
 func (m *model) handleLogMsg(msg logMsg) *model {
 	m.logs = append(m.logs, logEntry(msg))
 	if msg.Text == "Planning..." || msg.Text == "Installing..." {
 		m.status = msg.Text
 	}
-	switch msg.Level {
-	case "section":
-		// No-op for summary
-	case "success":
-		if strings.Contains(msg.Text, "Installed") {
-			m.succeeded++
-			m.attempted++
-		}
-	case "error":
-		if strings.Contains(msg.Text, "Failed to install") {
-			m.failed++
-			m.attempted++
-			// Extract package name from msg.Text if possible
-			parts := strings.Fields(msg.Text)
-			if len(parts) > 3 {
-				m.failedPkgs = append(m.failedPkgs, parts[3])
-			}
-		}
-	}
 	if !m.userScrolled {
 		m.cursor = len(m.logs) - logPanelHeight
 		if m.cursor < 0 {
@@ -386,35 +844,79 @@ func (m *model) handleLogMsg(msg logMsg) *model {
 	return m
 }
 
+// handleStepMsg counts the result of an instruction in the summary, and shows an error
+// toast when it failed.
+func (m *model) handleStepMsg(msg stepMsg) tea.Cmd {
+	switch msg.Status {
+	case provision.StepInstalled:
+		m.succeeded++
+		m.attempted++
+	case provision.StepFailed:
+		m.failed++
+		m.attempted++
+		m.failedPkgs = append(m.failedPkgs, msg.Package)
+		return m.toasts.Push(components.ToastError, fmt.Sprintf("Failed to install %s: %s", msg.Key, msg.Error))
+	}
+	return nil
+}
+
 func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.prompt.IsVisible() {
+		if keyMsg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		if m.checkingSudo {
+			return m, nil
+		}
+		_, cmd := m.prompt.Update(keyMsg)
+		return m, cmd
+	}
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.confirm != nil {
+		return m.handleConfirmKey(keyMsg)
+	}
 	switch msg := msg.(type) {
+	case planConfirmMsg:
+		m.confirm = newPlanConfirm(msg)
+		m.status = "Waiting for confirmation..."
+		return m, waitForLog(m.logChan)
+	case components.PasswordSubmittedMsg:
+		return m, m.handlePasswordSubmitted(msg.Password)
+	case sudoValidatedMsg:
+		m.handleSudoValidated(msg)
+		return m, nil
+	case sudoExpiredMsg:
+		m.handleSudoExpired()
+		return m, waitForLog(m.logChan)
+	case components.PasswordCancelledMsg:
+		if m.cancel != nil {
+			// Asked again during the run: carry on, commands that need sudo will fail
+			m.prompt.Hide()
+			return m, nil
+		}
+		return m, tea.Quit
 	case tea.KeyMsg:
-		newModel, _ := m.handleKeyMsg(msg)
-		return newModel, nil
+		return m.handleKeyMsg(msg)
 	case logMsg:
-		newModel := m.handleLogMsg(msg)
-		return newModel, nil
-	case tickMsg:
-		cmds := []tea.Cmd{}
-		var spinnerCmd tea.Cmd
-		m.spinner, spinnerCmd = m.spinner.Update(nil)
-		cmds = append(cmds, spinnerCmd)
-		select {
-		case lm := <-m.logChan:
-			switch lm := lm.(type) {
-			case logMsg:
-				newModel := m.handleLogMsg(lm)
-				return newModel, tea.Batch(append(cmds, tea.Tick(50*time.Millisecond, func(t time.Time) tea.Msg { return tickMsg(t) }))...)
-			case doneMsg:
-				return m, tea.Batch(append(cmds, tea.Tick(2*time.Second, func(time.Time) tea.Msg { return quitNowMsg{} }))...)
-			default:
-				return m, tea.Batch(append(cmds, tea.Tick(50*time.Millisecond, func(t time.Time) tea.Msg { return tickMsg(t) }))...)
-			}
-		default:
-			return m, tea.Batch(append(cmds, tea.Tick(50*time.Millisecond, func(t time.Time) tea.Msg { return tickMsg(t) }))...)
+		return m.handleLogMsg(msg), waitForLog(m.logChan)
+	case stepMsg:
+		return m, tea.Batch(waitForLog(m.logChan), m.handleStepMsg(msg))
+	case components.ToastExpiredMsg:
+		m.toasts.Update(msg)
+		return m, nil
+	case progressMsg:
+		if m.started.IsZero() {
+			m.started = time.Now()
 		}
+		m.progress = msg
+		return m, tea.Batch(waitForLog(m.logChan), m.titleCmd())
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
 	case doneMsg:
-		return m, tea.Tick(2*time.Second, func(time.Time) tea.Msg { return quitNowMsg{} })
+		m.cancel = nil
+		m.finish(msg.err)
+		return m, tea.Batch(m.titleCmd(), tea.Tick(2*time.Second, func(time.Time) tea.Msg { return quitNowMsg{} }))
 	case quitNowMsg:
 		return m, tea.Quit
 	default:
@@ -422,6 +924,31 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 }
 
+// finish sets the final status once provisioning stopped: "Done", or "Failed" with the
+// reason when packages failed to install or an error stopped it.
+func (m *model) finish(err error) {
+	switch {
+	case m.failed > 0:
+		m.status = fmt.Sprintf("Failed: %d of %d packages failed to install", m.failed, m.attempted)
+	case err != nil:
+		m.status = fmt.Sprintf("Failed: %v", err)
+	default:
+		m.status = "Done"
+	}
+}
+
+// titleCmd sets the terminal title to the provisioning progress, e.g.
+// "provisioning 3/17", or to the summary once done. It does nothing with --no-title.
+func (m *model) titleCmd() tea.Cmd {
+	if m.opts.noTitle {
+		return nil
+	}
+	if m.status == "Done" || strings.HasPrefix(m.status, "Failed") {
+		return tea.SetWindowTitle(fmt.Sprintf("provisioned: %d succeeded, %d failed", m.succeeded, m.failed))
+	}
+	return tea.SetWindowTitle(fmt.Sprintf("provisioning %d/%d", m.progress.done, m.progress.total))
+}
+
 // Helper to render log lines
 func renderLogLines(logs []logEntry, start, end int) string {
 	var b strings.Builder
@@ -437,7 +964,7 @@ func renderLogLines(logs []logEntry, start, end int) string {
 			if currentTheme.ShowSectionHeaders() && entry.Text != "Complete" { // Changed ui.CurrentTheme() to currentTheme
 				style = currentStyles.HeaderStyle.Bold(true).Underline(true).Align(lipgloss.Left) // Changed ui.CurrentStyles() to currentStyles
 				prefix = ""
-				b.WriteString(style.Render(entry.Text) + "\\n")
+				b.WriteString(style.Render(entry.Text) + "\n")
 			}
 			continue
 		case "error":
@@ -456,7 +983,7 @@ func renderLogLines(logs []logEntry, start, end int) string {
 			style = currentStyles.DimStyle // Changed ui.MutedTextStyle() to currentStyles.DimStyle
 			prefix = "  "
 		}
-		b.WriteString(style.Render(prefix+entry.Text) + "\\n")
+		b.WriteString(style.Render(prefix+entry.Text) + "\n")
 	}
 	return b.String()
 }
@@ -470,32 +997,62 @@ func renderStatusBar(m *model) string {
 	switch {
 	case m.status == "Done":
 		statusBar.WriteString(currentStyles.FooterStyle.Foreground(currentTheme.Accent()).Render("✔ Provisioning complete!")) // Changed
-		statusBar.WriteString("\\n")
+		statusBar.WriteString("\n")
 		statusBar.WriteString(currentStyles.FooterStyle.Render( // Changed
 			fmt.Sprintf("Attempted: %d  Succeeded: %d  Failed: %d", m.attempted, m.succeeded, m.failed)))
 		if m.failed > 0 {
-			statusBar.WriteString("\\n" + currentStyles.FooterStyle.Foreground(currentTheme.Secondary()).Render("Failed packages: ")) // Changed
+			statusBar.WriteString("\n" + currentStyles.FooterStyle.Foreground(currentTheme.Secondary()).Render("Failed packages: ")) // Changed
 			statusBar.WriteString(strings.Join(m.failedPkgs, ", "))
 		}
 	case strings.Contains(m.status, "Failed") || strings.Contains(m.status, "error"):
 		statusBar.WriteString(currentStyles.FooterStyle.Foreground(currentTheme.Secondary()).Render("✖ Provisioning failed!")) // Changed
-		statusBar.WriteString("\\n" + currentStyles.FooterStyle.Render(m.status))                                              // Changed
+		statusBar.WriteString("\n" + currentStyles.FooterStyle.Render(m.status))                                               // Changed
 		if m.failed > 0 {
-			statusBar.WriteString("\\n" + currentStyles.FooterStyle.Foreground(currentTheme.Secondary()).Render("Failed packages: ")) // Changed
+			statusBar.WriteString("\n" + currentStyles.FooterStyle.Foreground(currentTheme.Secondary()).Render("Failed packages: ")) // Changed
 			statusBar.WriteString(strings.Join(m.failedPkgs, ", "))
 		}
 	default:
+		if m.progress.total > 0 {
+			statusBar.WriteString(renderProgress(m) + "\n")
+		}
 		// Animated spinner during provisioning
 		statusBar.WriteString(currentStyles.FooterStyle.Render(m.spinner.View() + " " + m.status)) // Changed
 	}
 	// Keyboard shortcut help (only show when not done)
 	if m.status != "Done" && !strings.Contains(m.status, "Failed") && !strings.Contains(m.status, "error") {
-		statusBar.WriteString("\\n[q] quit  [↑/↓] scroll")
+		statusBar.WriteString("\n[q] quit  [↑/↓] scroll")
 	}
 	return statusBar.String()
 }
 
+// renderProgress renders the progress bar with the current package, elapsed time and a rough ETA.
+// The ETA assumes the remaining instructions take as long on average as the finished ones.
+func renderProgress(m *model) string {
+	p := m.progress
+	percent := float64(p.done) / float64(p.total)
+	elapsed := time.Since(m.started)
+	line := fmt.Sprintf("%s %d/%d", m.bar.ViewAs(percent), p.done, p.total)
+	if p.current.Package != "" {
+		line += "  Installing " + p.current.Package
+	}
+	line += "  Elapsed " + elapsed.Round(time.Second).String()
+	if p.done > 0 && p.done < p.total {
+		eta := elapsed / time.Duration(p.done) * time.Duration(p.total-p.done)
+		line += "  ETA ~" + eta.Round(time.Second).String()
+	}
+	return core.CurrentStyles().FooterStyle.Render(line)
+}
+
 func (m *model) View() string {
+	if m.prompt.IsVisible() {
+		if m.checkingSudo {
+			return m.prompt.View() + "\n" + core.CurrentStyles().FooterStyle.Render(m.spinner.View()+" Checking password...")
+		}
+		return m.prompt.View()
+	}
+	if m.confirm != nil {
+		return m.confirm.View()
+	}
 	var b strings.Builder
 	maxLines := logPanelHeight
 	start := m.cursor
@@ -515,12 +1072,13 @@ func (m *model) View() string {
 		b.WriteString("\n")
 	}
 	b.WriteString("\n" + renderStatusBar(m))
-	return b.String()
+	return m.toasts.Overlay(b.String())
 }
 
 // ensureSudo prompts for sudo password up front and caches credentials.
+// With an askpass helper configured, sudo asks through it instead of stdin.
 func ensureSudo() {
-	cmd := exec.Command("sudo", "-v")
+	cmd := sudoCommand(context.Background(), "-v")
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -529,127 +1087,585 @@ func ensureSudo() {
 
 func main() {
 	core.RegisterTheme("default", core.DefaultTheme{}) // Changed ui.RegisterTheme and ui.DefaultTheme
-	ensureSudo()
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		os.Exit(runReportCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		os.Exit(runAuditCommand(os.Args[2:]))
+	}
 	// CLI flag parsing
 	allFlag := flag.Bool("all", false, "Install all packages (ignores selection)")
 	allFlagShort := flag.Bool("a", false, "Alias for --all")
-	lazyFlag := flag.Bool("lazy", false, "Only install packages with lazy=true")
+	lazyFlag := flag.Bool("lazy", false, "Only install packages with lazy=true or in the optional tier")
 	lazyFlagShort := flag.Bool("l", false, "Alias for --lazy")
 	noTUIFlag := flag.Bool("no-tui", false, "Run in headless mode (no TUI, just logs to stdout)")
-	manifestFlag := flag.String("manifest", "data/package_manifest.yaml", "Path to the manifest YAML file")
+	yesFlag := flag.Bool("yes", false, "Install without showing the plan for confirmation first")
+	manifestFlag := flag.String("manifest", "data/package_manifest.yaml", "Path to the manifest YAML file, or several named manifests in priority order (e.g. work=work.yml,personal=personal.yml)")
 	dryRunFlag := flag.Bool("dry-run", false, "Print commands instead of running them (safe for tests)")
+	dryRunFormatFlag := flag.String("dry-run-format", dryRunText, "How --dry-run prints the commands: text, or shell for a copy-pasteable bash script (shell implies --dry-run --no-tui)")
 	groupFlag := flag.String("group", "", "Only install packages in this group (comma-separated, e.g. dev,ops)")
 	onlyFlag := flag.String("only", "", "Only install the specified packages (comma-separated, e.g. foo,bar)")
+	tierFlag := flag.String("tier", "", "Only install packages in these tiers (comma-separated: "+strings.Join(app.Tiers, ", ")+"); entries without _tier are core, or optional if lazy")
+	tagsFlag := flag.String("tags", "", "Only install packages with one of these tags (comma-separated, e.g. cli,rust); combines with --group and --only")
+	excludeFlag := flag.String("exclude", "", "Never install these packages, even as dependencies (comma-separated, e.g. docker,vscode)")
+	skipGroupFlag := flag.String("skip-group", "", "Never install packages in these groups, even as dependencies (comma-separated, e.g. gui)")
+	profileFlag := flag.String("profile", "", "Install the packages of a named profile from the config file (not with --group or --only)")
+	configFlag := flag.String("config", "", "Path to configuration file (profiles and per-manager options)")
+	exportFormatFlag := flag.String("export-format", "", "Print the selection in another provisioning system's format instead of installing: "+strings.Join(provision.ExportFormats, ", "))
+	exportChezmoiFlag := flag.String("export-chezmoi", "", "Write the plan as a chezmoi run_onchange script template to this file, e.g. "+provision.DefaultChezmoiScriptName+" (- for stdout), instead of installing")
+	lockTimeoutFlag := flag.Duration("lock-timeout", 2*time.Minute, "How long to wait for a package-manager lock held by another process (0 to fail immediately)")
+	minFreeFlag := flag.Uint64("min-free-space", 1024, "Free disk space in MB that must remain after installing (0 disables the check)")
+	warnLowDiskFlag := flag.Bool("warn-low-disk", false, "Only warn instead of aborting when disk space is low")
+	askpassFlag := flag.String("askpass", "", "Program sudo runs to ask for the password (sets SUDO_ASKPASS)")
+	maxDurationFlag := flag.Duration("max-duration", 0, "Time budget for installing, e.g. 10m; quick packages run first and the rest are deferred (0 for no limit)")
+	resumeDeferredFlag := flag.Bool("resume-deferred", false, "Install the packages deferred by a previous --max-duration run")
+	logFileFlag := flag.String("log-file", "", "Append a record of every executed instruction (output, exit code, duration) to this file")
+	logFormatFlag := flag.String("log-format", provision.LogFormatJSON, "Format of --log-file: json (one object per line) or text")
+	scriptSandboxFlag := flag.String("script-sandbox", provision.SandboxNone, "How manifest scripts run: "+strings.Join(provision.SandboxModes, ", ")+"; entries restrict sandboxed scripts with _sandbox (no_network, readonly_home)")
+	binDetectionFlag := flag.Bool("bin-detection", true, "Treat packages whose _bin executables are already on PATH as installed (--bin-detection=false to only ask the package managers)")
+	noServicesFlag := flag.Bool("no-services", false, "Do not enable and start the services (_service) of installed packages")
+	noTitleFlag := flag.Bool("no-title", false, "Do not set the terminal title to the provisioning progress (e.g. provisioning 3/17)")
+	preferFlag := flag.String("prefer", "", "Installers to try first for each package, in order (comma-separated, e.g. brew,apt); overrides managers.preferenceOrder in the config file")
+	withoutFlag := flag.String("without", "", "Never use these installers, falling back to the next one for each package (comma-separated, e.g. snap,flatpak); adds to managers.disabled in the config file")
+	includeGUIFlag := flag.Bool("include-gui", false, "Install GUI apps (entries with _app) even on headless systems such as servers")
+	headlessFlag := flag.Bool("headless", false, "Skip GUI apps (entries with _app) as on a server, even when there is a display")
+	strictFlag := flag.Bool("strict", false, "Reject manifests with keys entries do not declare, e.g. _decs for _desc, reporting each with its file and line")
+	strictDepsFlag := flag.Bool("strict-deps", false, "Fail planning when a package depends on a key that is not in the manifest, instead of skipping the dependency")
+	failFastFlag := flag.Bool("fail-fast", false, "Stop at the first failed instruction instead of continuing with the rest of the plan (exits with code 3 when it stops early)")
+	bootstrapFlag := flag.Bool("bootstrap-managers", false, "Install package managers the plan needs (brew, flatpak, pipx, cargo) when they are missing")
+	refreshReposFlag := flag.Bool("refresh-repos", false, "Refresh package indexes (apt-get update, dnf makecache, ...) once per package manager before installing")
+	retriesFlag := flag.Int("retries", 2, "How often to retry installs that fail on a network error, a timeout or a held lock")
+	skipNetworkFlag := flag.Bool("skip-network-check", false, "Do not check that package repositories are reachable before installing")
+	diffFlag := flag.Bool("diff", false, "Compare the selection with the installed packages instead of installing: will install, already installed, and installed but not selected")
+	lockedFlag := flag.Bool("locked", false, "Install the package versions recorded in the lockfile by an earlier run, for reproducible machines")
+	lockfileFlag := flag.String("lockfile", "", "Lockfile of installed versions, updated after every successful run (default "+provision.DefaultLockfileName+" next to the manifest)")
+	outdatedFlag := flag.Bool("outdated", false, "List the selected packages with newer versions available (current → available) instead of installing")
+	upgradeFlag := flag.Bool("upgrade", false, "Upgrade the selected packages that are already installed (apt install --only-upgrade, brew upgrade, flatpak update, ...) instead of installing missing ones")
+	downloadOnlyFlag := flag.Bool("download-only", false, "Download the selected packages (apt, pacman, brew, cask, pipx, binaries) into the cache directory instead of installing them, for --offline installs")
+	offlineFlag := flag.Bool("offline", false, "Install the selected packages only from the cache directory filled by --download-only, without network access")
+	cacheDirFlag := flag.String("cache-dir", "", "Package cache directory of --download-only and --offline (default "+provision.DefaultCacheDir()+")")
+	targetFlag := flag.String("target", "", "Apply the plan to a remote host over ssh ([user@]host) instead of this machine; implies --no-tui")
+	testInFlag := flag.String("test-in", "", "Run the plan in a throwaway container instead of on this machine, e.g. docker:ubuntu:24.04 or podman:fedora:40, to validate the manifest")
+	auditLogFlag := flag.String("audit-log", provision.DefaultAuditPath(), "Append-only, hash-chained log of every executed command (view it with \"provisioner audit\"; empty to disable)")
+	porcelainFlag := flag.String("porcelain", "", "Print the selection as stable tab-separated lines for scripts instead of installing: "+strings.Join(provision.PorcelainViews, ", "))
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s [--all|-a] [--lazy|-l] [--no-tui] [--manifest <file>] [--dry-run] [--group <name>[,<name2>...]] [--only <pkg1>[,<pkg2>...]]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %[1]s [options]\n       %[1]s report [--file <file>] [--json]\n       %[1]s audit [--file <file>] [--verify] [--tail <n>] [--json]\n\nOptions:\n", os.Args[0])
 		flag.PrintDefaults()
 	}
 	flag.Parse()
 
-	all := *allFlag || *allFlagShort
-	lazy := *lazyFlag || *lazyFlagShort
 	noTUI := *noTUIFlag
-	manifestPath := *manifestFlag
-	dryRun := *dryRunFlag
-
-	// Parse group/only flags
-	var groups []string
-	if *groupFlag != "" {
-		for _, g := range strings.Split(*groupFlag, ",") {
-			g = strings.TrimSpace(g)
-			if g != "" {
-				groups = append(groups, g)
-			}
+	opts := options{
+		all:          *allFlag || *allFlagShort,
+		lazy:         *lazyFlag || *lazyFlagShort,
+		manifestPath: *manifestFlag,
+		strict:       *strictFlag,
+		dryRun:       *dryRunFlag,
+		dryRunFormat: *dryRunFormatFlag,
+		lockTimeout:  *lockTimeoutFlag,
+		minFreeMB:    *minFreeFlag,
+		warnLowDisk:  *warnLowDiskFlag,
+		skipNetwork:  *skipNetworkFlag,
+		maxDuration:  *maxDurationFlag,
+		resume:       *resumeDeferredFlag,
+		historyPath:  provision.DefaultHistoryPath(),
+		reportPath:   provision.DefaultReportPath(),
+		runLogDir:    provision.DefaultRunLogDir(),
+		logFile:      *logFileFlag,
+		logFormat:    *logFormatFlag,
+		retries:      *retriesFlag,
+		refreshRepos: *refreshReposFlag,
+		bootstrap:    *bootstrapFlag,
+		strictDeps:   *strictDepsFlag,
+		includeGUI:   *includeGUIFlag,
+		headless:     *headlessFlag,
+		failFast:     *failFastFlag,
+		sandbox:      *scriptSandboxFlag,
+		noServices:   *noServicesFlag,
+		binOnPath:    *binDetectionFlag,
+		yes:          *yesFlag,
+		lockfilePath: *lockfileFlag,
+		upgrade:      *upgradeFlag,
+		cacheDir:     *cacheDirFlag,
+		target:       *targetFlag,
+		auditPath:    *auditLogFlag,
+		noTitle:      *noTitleFlag,
+	}
+	if opts.logFormat != provision.LogFormatJSON && opts.logFormat != provision.LogFormatText {
+		fmt.Fprintf(os.Stderr, "Invalid --log-format %q: must be json or text\n", opts.logFormat)
+		os.Exit(2)
+	}
+	if !slices.Contains(dryRunFormats, opts.dryRunFormat) {
+		fmt.Fprintf(os.Stderr, "Invalid --dry-run-format %q: must be one of %s\n", opts.dryRunFormat, strings.Join(dryRunFormats, ", "))
+		os.Exit(2)
+	}
+	if opts.dryRunFormat == dryRunShell {
+		opts.dryRun = true
+		noTUI = true
+	}
+	if opts.includeGUI && opts.headless {
+		fmt.Fprintln(os.Stderr, "--include-gui and --headless cannot be combined")
+		os.Exit(2)
+	}
+	if opts.upgrade && *lockedFlag {
+		fmt.Fprintln(os.Stderr, "--upgrade cannot be combined with --locked: upgrades install the latest versions")
+		os.Exit(2)
+	}
+	switch {
+	case *downloadOnlyFlag && *offlineFlag:
+		fmt.Fprintln(os.Stderr, "--download-only and --offline cannot be combined: download on a connected machine, then install offline")
+		os.Exit(2)
+	case (*downloadOnlyFlag || *offlineFlag) && opts.upgrade:
+		fmt.Fprintln(os.Stderr, "--upgrade cannot be combined with --download-only or --offline")
+		os.Exit(2)
+	case *downloadOnlyFlag:
+		opts.cacheMode = provision.CacheDownload
+	case *offlineFlag:
+		opts.cacheMode = provision.CacheOffline
+	}
+	if opts.target != "" {
+		if *testInFlag != "" {
+			fmt.Fprintln(os.Stderr, "--target cannot be combined with --test-in")
+			os.Exit(2)
 		}
+		noTUI = true
 	}
-	var only []string
-	if *onlyFlag != "" {
-		for _, o := range strings.Split(*onlyFlag, ",") {
-			o = strings.TrimSpace(o)
-			if o != "" {
-				only = append(only, o)
-			}
+	if *testInFlag != "" {
+		if opts.dryRun {
+			fmt.Fprintln(os.Stderr, "--test-in cannot be combined with --dry-run: the container is already disposable")
+			os.Exit(2)
+		}
+		testIn, err := parseTestIn(*testInFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		opts.testIn = testIn
+	}
+	if !slices.Contains(provision.SandboxModes, opts.sandbox) {
+		fmt.Fprintf(os.Stderr, "Invalid --script-sandbox %q: must be one of %s\n", opts.sandbox, strings.Join(provision.SandboxModes, ", "))
+		os.Exit(2)
+	}
+
+	history, err := provision.LoadHistory(opts.historyPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring install history: %v\n", err)
+		history = &provision.History{}
+	}
+	opts.history = history
+
+	if opts.lockfilePath == "" {
+		opts.lockfilePath = defaultLockfilePath(opts.manifestPath)
+	}
+	if *lockedFlag {
+		lock, err := provision.LoadLockfile(opts.lockfilePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load lockfile: %v\n", err)
+			os.Exit(1)
+		}
+		if len(lock.Packages) == 0 {
+			fmt.Fprintf(os.Stderr, "No locked versions in %s; run the provisioner once without --locked to record them\n", opts.lockfilePath)
+			os.Exit(1)
+		}
+		opts.lockfile = lock
+	}
+
+	// Parse group/only/exclude flags
+	opts.groups = splitList(*groupFlag)
+	opts.only = splitList(*onlyFlag)
+	opts.exclude = splitList(*excludeFlag)
+	opts.skipGroups = splitList(*skipGroupFlag)
+	opts.tags = splitList(*tagsFlag)
+	opts.tiers = splitList(*tierFlag)
+	for _, tier := range opts.tiers {
+		if !slices.Contains(app.Tiers, strings.ToLower(tier)) {
+			fmt.Fprintf(os.Stderr, "Invalid --tier %q: must be one of %s\n", tier, strings.Join(app.Tiers, ", "))
+			os.Exit(2)
+		}
+	}
+
+	managers, err := loadManagers(*configFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	opts.managers = managers.Options
+	opts.prefer = managers.PreferenceOrder
+	if *preferFlag != "" {
+		opts.prefer = splitList(*preferFlag)
+	}
+	if err := provision.CheckInstallers(opts.prefer); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid installer preference: %v\n", err)
+		os.Exit(2)
+	}
+	opts.without = slices.Concat(managers.Disabled, splitList(*withoutFlag))
+	if err := provision.CheckInstallers(opts.without); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid disabled installer: %v\n", err)
+		os.Exit(2)
+	}
+
+	if *profileFlag != "" {
+		if len(opts.groups) > 0 || len(opts.only) > 0 {
+			fmt.Fprintln(os.Stderr, "--profile cannot be combined with --group or --only: the profile already names the packages")
+			os.Exit(2)
+		}
+		profileKeys, err := loadProfileKeys(*configFlag, *profileFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load profile: %v\n", err)
+			os.Exit(1)
 		}
+		opts.only = profileKeys
+	}
+
+	if opts.resume {
+		if len(history.Deferred) == 0 {
+			fmt.Println("No deferred packages to install.")
+			return
+		}
+		opts.only = append(opts.only, history.Deferred...)
+	}
+
+	if *porcelainFlag != "" {
+		porcelainMain(&opts, *porcelainFlag)
+		return
+	}
+
+	if *diffFlag {
+		diffMain(&opts)
+		return
+	}
+
+	if *outdatedFlag {
+		outdatedMain(&opts)
+		return
+	}
+
+	if *exportChezmoiFlag != "" {
+		exportMain(&opts, "chezmoi", *exportChezmoiFlag)
+		return
+	}
+
+	if *exportFormatFlag != "" {
+		exportMain(&opts, *exportFormatFlag, "-")
+		return
+	}
+
+	if *askpassFlag != "" {
+		if err := os.Setenv(askpassEnv, *askpassFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to set %s: %v\n", askpassEnv, err)
+			os.Exit(1)
+		}
+	}
+
+	if opts.testIn != nil && noTUI {
+		testInMain(&opts)
+		return
 	}
 
 	if noTUI {
-		headlessMain(lazy, manifestPath, dryRun, groups, only)
+		if !opts.dryRun && opts.target == "" {
+			ensureSudo()
+		}
+		headlessMain(&opts)
 		return
 	}
 
-	p := tea.NewProgram(initialModelWithFlags(all, lazy, manifestPath, dryRun, groups, only))
-	if _, err := p.Run(); err != nil {
+	// Save the terminal title to restore it once the progress no longer shows in it
+	saveTitle := !opts.noTitle && term.IsTerminal(os.Stdout.Fd())
+	if saveTitle {
+		fmt.Print(core.PushTitle)
+	}
+	p := tea.NewProgram(initialModelWithFlags(&opts))
+	_, err = p.Run()
+	if saveTitle {
+		fmt.Print(core.PopTitle)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running provision TUI: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-// dryRunRunner implements provision.ExecRunner and just prints/logs commands.
-type dryRunRunner struct{}
+// exportMain translates the selected keys into an export format (see
+// provision.Provisioner.Export) and writes the result to path ("-" for stdout).
+func exportMain(opts *options, format, path string) {
+	if !slices.Contains(provision.ExportFormats, format) {
+		fmt.Fprintf(os.Stderr, "Invalid --export-format %q: must be one of %s\n", format, strings.Join(provision.ExportFormats, ", "))
+		os.Exit(2)
+	}
+	manifest, err := opts.loadManifest()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load manifest: %v\n", err)
+		os.Exit(1)
+	}
+	prov := provision.NewProvisioner(nil, manifest, nil)
+	opts.configure(prov)
+	script, err := prov.Export(format, selectKeys(manifest, opts.groups, opts.only, opts.tags, opts.tiers))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to export plan: %v\n", err)
+		os.Exit(1)
+	}
+	if path == "-" {
+		fmt.Print(script)
+		return
+	}
+	if err := os.WriteFile(path, []byte(script), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %s\n", path)
+}
 
-func (r *dryRunRunner) Run(cmd string, args ...string) error {
-	if cmd == "section" || cmd == "info" {
-		return nil
+// porcelainMain prints the given porcelain view of the selected keys to stdout.
+// Only read-only queries are run on the system.
+func porcelainMain(opts *options, view string) {
+	if !slices.Contains(provision.PorcelainViews, view) {
+		fmt.Fprintf(os.Stderr, "Invalid --porcelain %q: must be one of %s\n", view, strings.Join(provision.PorcelainViews, ", "))
+		os.Exit(2)
+	}
+	manifest, err := opts.loadManifest()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load manifest: %v\n", err)
+		os.Exit(1)
+	}
+	var installed map[string]bool
+	if view != provision.PorcelainList {
+		installed = provision.GetInstalledPackages(&realSystemRunner{})
+	}
+	prov := provision.NewProvisioner(provision.DetectSystem(), manifest, nil)
+	opts.configure(prov)
+	if err := prov.WritePorcelain(os.Stdout, view, selectKeys(manifest, opts.groups, opts.only, opts.tags, opts.tiers), installed); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", view, err)
+		os.Exit(1)
 	}
-	fmt.Printf("[dry-run] Would run: %s %s\n", cmd, strings.Join(args, " "))
-	return nil
 }
-func (r *dryRunRunner) Output(cmd string, args ...string) ([]byte, error) {
-	out := fmt.Sprintf("[dry-run] Would output: %s %s", cmd, strings.Join(args, " "))
-	return []byte(out), nil
+
+// diffMain prints which selected packages would be installed, which are already
+// installed, and which installed packages are not selected.
+func diffMain(opts *options) {
+	manifest, err := opts.loadManifest()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load manifest: %v\n", err)
+		os.Exit(1)
+	}
+	prov := provision.NewProvisioner(provision.DetectSystem(), manifest, nil)
+	opts.configure(prov)
+	diff, err := prov.Diff(selectKeys(manifest, opts.groups, opts.only, opts.tags, opts.tiers), provision.GetInstalledPackages(&realSystemRunner{}))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to compare the selection: %v\n", err)
+		os.Exit(1)
+	}
+	if err := provision.WriteDiff(os.Stdout, diff); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write the diff: %v\n", err)
+		os.Exit(1)
+	}
 }
 
-// headlessMain runs the provisioner logic without the TUI, printing logs to stdout.
-func headlessMain(lazy bool, manifestPath string, dryRun bool, groups, only []string) {
-	manifest, err := app.LoadManifest(manifestPath)
+// outdatedMain prints the selected packages that have newer versions available.
+// Only read-only queries are run on the system.
+func outdatedMain(opts *options) {
+	manifest, err := opts.loadManifest()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load manifest: %v\n", err)
 		os.Exit(1)
 	}
+	prov := provision.NewProvisioner(provision.DetectSystem(), manifest, &realSystemRunner{})
+	opts.configure(prov)
+	outdated, err := prov.Outdated(selectKeys(manifest, opts.groups, opts.only, opts.tags, opts.tiers))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to check for newer versions: %v\n", err)
+		os.Exit(1)
+	}
+	if err := provision.WriteOutdated(os.Stdout, outdated); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write the outdated packages: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// loadProfileKeys returns the manifest keys of the named profile.
+// The config is read from configPath, or from the standard locations if empty.
+func loadProfileKeys(configPath, name string) ([]string, error) {
+	if configPath == "" {
+		configPath = config.FindConfigFile()
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Profile(name)
+}
+
+// loadManagers returns the managers section of the config file, with the overrides of
+// A_LA_CARTE_MANAGERS_* environment variables. Without a config file, only those are
+// configured.
+func loadManagers(configPath string) (config.Managers, error) {
+	if configPath == "" {
+		configPath = config.FindConfigFile()
+	}
+	cfg := config.DefaultConfig()
+	if configPath != "" {
+		var err error
+		if cfg, err = config.Load(configPath); err != nil {
+			return config.Managers{}, err
+		}
+	}
+	if err := cfg.ApplyEnv(os.Getenv); err != nil {
+		return config.Managers{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return config.Managers{}, err
+	}
+	return cfg.Managers, nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// selectKeys returns the keys to install: only if given, else the keys in groups, else
+// the whole manifest, narrowed down to the keys with one of tags and in one of tiers if
+// any are given.
+func selectKeys(manifest app.Manifest, groups, only, tags, tiers []string) []string {
+	index := app.NewManifestIndex(manifest)
 	var keys []string
 	switch {
 	case len(only) > 0:
 		keys = only
 	case len(groups) > 0:
-		for k := range manifest {
-			entry := manifest[k]
-			entryPtr := &entry
-			for _, g := range entryPtr.Groups {
-				for _, want := range groups {
-					if g == want {
-						keys = append(keys, k)
-						break
-					}
+		seen := make(map[string]bool)
+		for _, group := range groups {
+			for _, k := range index.Group(group) {
+				if !seen[k] {
+					seen[k] = true
+					keys = append(keys, k)
 				}
 			}
 		}
 	default:
-		for k := range manifest {
-			keys = append(keys, k)
+		keys = index.Keys()
+	}
+	if len(tags) == 0 && len(tiers) == 0 {
+		return keys
+	}
+	var filtered []string
+	for _, k := range keys {
+		// Unknown keys are kept so that planning reports them.
+		resolved, ok := index.Resolve(k, "", nil)
+		entry := manifest[resolved]
+		if !ok || (len(tags) == 0 || entry.HasAnyTag(tags)) && (len(tiers) == 0 || entry.InAnyTier(tiers)) {
+			filtered = append(filtered, k)
 		}
 	}
+	return filtered
+}
+
+// headlessMain runs the provisioner logic without the TUI, printing logs to stdout.
+// With --dry-run-format shell, stdout only gets the script and progress goes to stderr.
+func headlessMain(opts *options) {
+	if code := runHeadless(opts); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// runHeadless runs headlessMain and returns the exit code, so deferred cleanup runs
+// before the process exits.
+func runHeadless(opts *options) int {
+	manifest, err := opts.loadManifest()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load manifest: %v\n", err)
+		return 1
+	}
+	keys := selectKeys(manifest, opts.groups, opts.only, opts.tags, opts.tiers)
 	var runner provision.ExecRunner
-	if dryRun {
-		runner = &dryRunRunner{}
+	status := io.Writer(os.Stdout)
+	if opts.dryRun {
+		dryRun := &dryRunRunner{format: opts.dryRunFormat, managers: opts.managers}
+		if opts.dryRunFormat == dryRunShell {
+			status = os.Stderr
+		}
+		dryRun.header()
+		runner = dryRun
 	} else {
 		runner = &realSystemRunner{}
 	}
-	installed := provision.GetInstalledPackages(runner)
-	prov := provision.NewProvisioner(nil, manifest, runner)
-	prov.LazyOnly = lazy
-	fmt.Println("Starting provisioning...")
-	plan, err := prov.PlanProvision(keys, installed)
+	system := provision.DetectSystem()
+	var remote *sshRunner
+	var installed map[string]bool
+	if opts.target == "" {
+		installed = provision.GetInstalledPackages(runner)
+	} else {
+		if remote, err = connectTarget(opts); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer remote.close()
+		fmt.Fprintf(status, "Provisioning %s (%s %s)\n", opts.target, remote.system.ID(), remote.system.Arch())
+		system = remote.system
+		installed = provision.GetInstalledPackagesWith(remote, provision.RunnerLookPath(remote))
+	}
+	runLog, err := opts.openRunLog()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Not archiving this run: %v\n", err)
+	}
+	defer func() {
+		if err := runLog.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save the run log: %v\n", err)
+		}
+	}()
+	audit, err := opts.openAuditLog()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Not auditing this run: %v\n", err)
+	}
+	if remote != nil && !opts.dryRun {
+		remote.log, remote.audit = runLog, audit
+		runner = remote
+	} else if !opts.dryRun {
+		runner = &realSystemRunner{log: runLog, audit: audit, managers: opts.managers}
+	}
+	prov := provision.NewProvisioner(system, manifest, runner)
+	opts.configure(prov)
+	if remote != nil {
+		remote.configure(prov)
+	}
+	prov.RunLog = runLog
+	fmt.Fprintln(status, "Starting provisioning...")
+	plan, err := opts.plan(prov, keys, installed)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to plan provision: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
 	if len(plan) == 0 {
-		fmt.Println("Nothing to install. All requested packages are already installed or filtered out.")
+		fmt.Fprintln(status, opts.nothingToDo(prov))
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	before := opts.versionsBefore(prov, plan)
+	err = prov.ExecutePlanContext(ctx, plan)
+	for _, line := range upgradeSummary(prov, plan, before) {
+		fmt.Println(line)
+	}
+	if saveErr := opts.saveHistory(prov); saveErr != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save install history: %v\n", saveErr)
+	}
+	if saveErr := opts.saveReport(prov); saveErr != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save run report: %v\n", saveErr)
 	}
-	err = prov.ExecutePlan(plan)
 	if err != nil {
+		runLog.Log("error", fmt.Sprintf("Provisioning failed: %v", err))
 		fmt.Fprintf(os.Stderr, "Provisioning failed: %v\n", err)
-		os.Exit(1)
+		return failureExitCode(err)
+	}
+	if saveErr := opts.saveLockfile(prov, plan); saveErr != nil {
+		fmt.Fprintf(os.Stderr, "Failed to update the lockfile: %v\n", saveErr)
 	}
-	fmt.Println("Provisioning complete")
+	runLog.Log("success", "Provisioning complete")
+	fmt.Fprintln(status, "Provisioning complete")
+	return 0
 }
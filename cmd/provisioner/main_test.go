@@ -11,6 +11,20 @@
 // # Tests
 //   - TestProvisioner_AllFlag: --all installs all packages
 //   - TestProvisioner_LazyFlag: --lazy only installs lazy packages
+//   - TestProvisioner_ProfileFlag: --profile only installs the profile's packages and rejects --group and --only
+//   - TestParseManifestSources: --manifest accepts a path or named manifests
+//   - TestSelectKeys_Tags: --tags and --tier narrow the selection to tagged packages and tiers
+//   - TestPlanConfirm: the confirmation screen returns the plan without toggled-off keys
+//   - TestDryRunShell: --dry-run-format shell prints a copy-pasteable script
+//   - TestBuildExecCmd_ManagerOptions: configured manager args and env are applied
+//   - TestContainerTestCommand: --test-in runs the selection headless in a container
+//   - TestSSHRunner_RemoteArgv: --target runs sudo non-interactively on the remote host
+//   - TestFailureExitCode: a run stopped by --fail-fast exits with its own code
+//   - TestFailureToast: a failed install shows an error toast over the log until it expires
+//   - TestTerminalTitle: the terminal title follows the progress unless --no-title is set
+//   - TestPasswordPrompt_ChecksInBackground: the sudo password is validated by a command behind a spinner
+//   - TestPasswordPrompt_AskedAgainWhenExpired: sudo credentials that expire during the run bring the prompt back
+//   - TestRenderProgress: the progress line shows the bar, the count, the current package and an ETA
 //
 // # Example
 //     go test ./cmd/provisioner -v
@@ -18,10 +32,21 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
+	"time"
+
+	"a-la-carte/internal/app"
+	"a-la-carte/internal/app/provision"
+	"a-la-carte/internal/ui/components"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -57,6 +82,11 @@ func writeTempManifest(t *testing.T) string {
 	return tmp.Name()
 }
 
+// aptDryRun returns the dry-run line of an apt install of pkg.
+func aptDryRun(pkg string) string {
+	return "[dry-run] Would run: sudo env DEBIAN_FRONTEND=noninteractive apt-get -o DPkg::Options::=--force-confdef install -y --no-install-recommends --ignore-missing " + pkg
+}
+
 // TestProvisioner_AllFlag verifies that --all installs all packages.
 func TestProvisioner_AllFlag(t *testing.T) {
 	manifestPath := writeTempManifest(t)
@@ -74,13 +104,13 @@ func TestProvisioner_AllFlag(t *testing.T) {
 	if !strings.Contains(output, "foo") || !strings.Contains(output, "bar") || !strings.Contains(output, "baz") {
 		t.Errorf("expected all packages in output, got: %s", output)
 	}
-	if !strings.Contains(output, "[dry-run] Would run: apt foo") {
+	if !strings.Contains(output, aptDryRun("foo")) {
 		t.Errorf("expected dry-run for foo, got: %s", output)
 	}
-	if !strings.Contains(output, "[dry-run] Would run: apt bar") {
+	if !strings.Contains(output, aptDryRun("bar")) {
 		t.Errorf("expected dry-run for bar, got: %s", output)
 	}
-	if !strings.Contains(output, "[dry-run] Would run: apt baz") {
+	if !strings.Contains(output, aptDryRun("baz")) {
 		t.Errorf("expected dry-run for baz, got: %s", output)
 	}
 	if !strings.Contains(output, "Provisioning complete") {
@@ -108,13 +138,13 @@ func TestProvisioner_LazyFlag(t *testing.T) {
 	if strings.Contains(output, "bar") {
 		t.Errorf("did not expect non-lazy package 'bar' in output, got: %s", output)
 	}
-	if !strings.Contains(output, "[dry-run] Would run: apt foo") {
+	if !strings.Contains(output, aptDryRun("foo")) {
 		t.Errorf("expected dry-run for foo, got: %s", output)
 	}
-	if !strings.Contains(output, "[dry-run] Would run: apt baz") {
+	if !strings.Contains(output, aptDryRun("baz")) {
 		t.Errorf("expected dry-run for baz, got: %s", output)
 	}
-	if strings.Contains(output, "[dry-run] Would run: apt bar") {
+	if strings.Contains(output, aptDryRun("bar")) {
 		t.Errorf("did not expect dry-run for bar, got: %s", output)
 	}
 	if !strings.Contains(output, "Provisioning complete") {
@@ -122,6 +152,54 @@ func TestProvisioner_LazyFlag(t *testing.T) {
 	}
 }
 
+// TestDryRunShell verifies that --dry-run-format shell prints the commands as a script.
+func TestDryRunShell(t *testing.T) {
+	t.Setenv(askpassEnv, "")
+	var b strings.Builder
+	r := &dryRunRunner{format: dryRunShell, out: &b}
+	r.header()
+	_ = r.Run("section", "Installing")
+	_ = r.Run("dnf", "fd-find")
+	_ = r.Run("sudo", "sh", "-c", "echo 'deb https://example.com stable main' > /etc/apt/sources.list.d/x.list")
+	_ = r.Run("script", "echo hi", "firejail", "--net=none")
+	want := `#!/usr/bin/env bash
+# Generated by provisioner --dry-run-format shell
+sudo dnf install -y --setopt=skip_if_unavailable=True --setopt=skip_missing_names_on_install=True fd-find
+sudo sh -c 'echo '\''deb https://example.com stable main'\'' > /etc/apt/sources.list.d/x.list'
+firejail --net=none bash <<'A_LA_CARTE_SCRIPT'
+echo hi
+A_LA_CARTE_SCRIPT
+`
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+// TestBuildExecCmd_ManagerOptions verifies that configured extra args and environment
+// variables are added to the install commands.
+func TestBuildExecCmd_ManagerOptions(t *testing.T) {
+	t.Setenv(askpassEnv, "")
+	managers := managerOptions{
+		"apt":  {ExtraArgs: []string{"-t", "bookworm-backports"}, Env: map[string]string{"NEEDRESTART_MODE": "a"}},
+		"brew": {Env: map[string]string{"HOMEBREW_NO_AUTO_UPDATE": "1"}, ExtraArgs: []string{"--quiet"}},
+	}
+	tests := []struct {
+		cmd  string
+		args []string
+		want string
+	}{
+		{"apt", []string{"foo"}, "sudo env DEBIAN_FRONTEND=noninteractive NEEDRESTART_MODE=a apt-get -o DPkg::Options::=--force-confdef install -y --no-install-recommends --ignore-missing -t bookworm-backports foo"},
+		{"brew", []string{"install", "bat"}, "env HOMEBREW_NO_AUTO_UPDATE=1 brew install --quiet bat"},
+		{"dnf", []string{"fd-find"}, "sudo dnf install -y --setopt=skip_if_unavailable=True --setopt=skip_missing_names_on_install=True fd-find"},
+	}
+	for _, tt := range tests {
+		c, logMsg := managers.buildExecCmd(context.Background(), tt.cmd, tt.args...)
+		if got := strings.Join(c.Args, " "); got != tt.want || logMsg != tt.want {
+			t.Errorf("%s: got %q (log %q), want %q", tt.cmd, got, logMsg, tt.want)
+		}
+	}
+}
+
 func TestModel_handleKeyMsg(t *testing.T) {
 	m := initialModel()
 	m.logs = make([]logEntry, 30)
@@ -199,3 +277,352 @@ func SkipTestModel_handleLogMsg(t *testing.T) {
 		})
 	}
 }
+
+// TestProvisioner_ProfileFlag verifies that --profile only installs the profile's packages.
+func TestProvisioner_ProfileFlag(t *testing.T) {
+	manifestPath := writeTempManifest(t)
+	defer func() {
+		if err := os.Remove(manifestPath); err != nil {
+			t.Errorf("os.Remove failed: %v", err)
+		}
+	}()
+	configPath := filepath.Join(t.TempDir(), "a-la-carte.yml")
+	if err := os.WriteFile(configPath, []byte("profiles:\n  server:\n    - bar\n"), 0o644); err != nil {
+		t.Fatalf("failed to write temp config: %v", err)
+	}
+	cmd := exec.Command("go", "run", ".", "--profile", "server", "--config", configPath, "--no-tui", "--manifest", manifestPath, "--dry-run")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("provisioner --profile failed: %v\nOutput: %s", err, string(out))
+	}
+	output := string(out)
+	if !strings.Contains(output, aptDryRun("bar")) {
+		t.Errorf("expected dry-run for bar, got: %s", output)
+	}
+	if strings.Contains(output, aptDryRun("foo")) || strings.Contains(output, aptDryRun("baz")) {
+		t.Errorf("did not expect packages outside the profile, got: %s", output)
+	}
+
+	cmd = exec.Command("go", "run", ".", "--profile", "server", "--only", "foo", "--config", configPath, "--no-tui", "--manifest", manifestPath, "--dry-run")
+	out, err = cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(out), "--profile cannot be combined with --group or --only") {
+		t.Errorf("expected --profile with --only to be rejected, got %v: %s", err, out)
+	}
+}
+
+func TestParseManifestSources(t *testing.T) {
+	single := parseManifestSources("data/package_manifest.yaml")
+	if len(single) != 1 || !reflect.DeepEqual(single[0], app.ManifestSource{Path: "data/package_manifest.yaml"}) {
+		t.Errorf("expected a single unnamed manifest, got %+v", single)
+	}
+	named := parseManifestSources("work=work.yml, personal=personal.yml")
+	want := []app.ManifestSource{{Name: "work", Path: "work.yml"}, {Name: "personal", Path: "personal.yml"}}
+	if !reflect.DeepEqual(named, want) {
+		t.Errorf("expected %+v, got %+v", want, named)
+	}
+	if got := manifestNamespaces("work=work.yml,personal=personal.yml"); len(got) != 2 || got[0] != "work" || got[1] != "personal" {
+		t.Errorf("expected namespaces [work personal], got %v", got)
+	}
+}
+
+// TestSelectKeys_Tags verifies that --tags and --tier narrow --group and --only to tagged
+// packages and packages in the tiers.
+func TestSelectKeys_Tags(t *testing.T) {
+	manifest := app.Manifest{
+		"rg":     {Groups: app.StringOrSlice{"dev"}, Tags: app.StringOrSlice{"cli", "rust"}, Aliases: app.StringOrSlice{"ripgrep"}},
+		"code":   {Groups: app.StringOrSlice{"dev"}, Tags: app.StringOrSlice{"gui"}},
+		"ffmpeg": {Tags: app.StringOrSlice{"cli"}, Tier: "extra"},
+		"gimp":   {Lazy: true},
+	}
+	cases := []struct {
+		name         string
+		groups, only []string
+		tags, tiers  []string
+		want         []string
+	}{
+		{"tags only", nil, nil, []string{"cli"}, nil, []string{"ffmpeg", "rg"}},
+		{"group and tags", []string{"dev"}, nil, []string{"Rust"}, nil, []string{"rg"}},
+		{"only and tags", nil, []string{"code", "rg", "missing"}, []string{"cli"}, nil, []string{"missing", "rg"}},
+		{"aliases and tags", nil, []string{"ripgrep"}, []string{"rust"}, nil, []string{"ripgrep"}},
+		{"no tags", []string{"dev"}, nil, nil, nil, []string{"code", "rg"}},
+		{"tiers", nil, nil, nil, []string{"extra", "optional"}, []string{"ffmpeg", "gimp"}},
+		{"tags and tiers", nil, nil, []string{"cli"}, []string{"core"}, []string{"rg"}},
+	}
+	for _, tc := range cases {
+		got := selectKeys(manifest, tc.groups, tc.only, tc.tags, tc.tiers)
+		slices.Sort(got)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestPlanConfirm verifies that the confirmation screen returns the plan without the
+// keys toggled off, and nil when cancelled.
+func TestPlanConfirm(t *testing.T) {
+	plan := []provision.InstallInstruction{
+		{Key: "docker", Type: "preinstall", Package: "add-repo"},
+		{Key: "docker", Type: "apt", Package: "docker-ce"},
+		{Key: "jq", Type: "apt", Package: "jq"},
+	}
+	press := func(m *model, keys ...string) {
+		for _, key := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+			switch key {
+			case "down":
+				msg = tea.KeyMsg{Type: tea.KeyDown}
+			case "enter":
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			}
+			m.Update(msg)
+		}
+	}
+
+	reply := make(chan []provision.InstallInstruction, 1)
+	m := initialModel()
+	m.Update(planConfirmMsg{plan: plan, skipped: []provision.KeyDecision{{Key: "bat", Reason: provision.SkipInstalled, Detail: "already installed"}}, reply: reply})
+	if m.confirm == nil {
+		t.Fatal("expected the confirmation screen")
+	}
+	if view := m.View(); !strings.Contains(view, "2 of 2 packages selected, 1 skipped") || !strings.Contains(view, "bat: already installed") {
+		t.Errorf("unexpected confirmation view:\n%s", view)
+	}
+	press(m, " ", "enter")
+	got := <-reply
+	if len(got) != 1 || got[0].Key != "jq" || m.confirm != nil {
+		t.Errorf("expected only jq after toggling docker off, got %+v", got)
+	}
+
+	m = initialModel()
+	m.Update(planConfirmMsg{plan: plan, reply: reply})
+	press(m, "down", "n")
+	if got := <-reply; got != nil {
+		t.Errorf("expected nil after cancelling, got %+v", got)
+	}
+}
+
+// TestContainerTestCommand verifies that --test-in mounts the binary and manifests and
+// forwards the selection to a headless run inside the container.
+func TestContainerTestCommand(t *testing.T) {
+	for _, bad := range []string{"docker", "docker:", "lxc:ubuntu:24.04"} {
+		if _, err := parseTestIn(bad); err == nil {
+			t.Errorf("expected an error for --test-in %q", bad)
+		}
+	}
+	test, err := parseTestIn("podman:ubuntu:24.04")
+	if err != nil || test.runtime != "podman" || test.image != "ubuntu:24.04" {
+		t.Fatalf("parseTestIn: got %+v, %v", test, err)
+	}
+	opts := &options{manifestPath: "work=/m/work.yaml,personal=/m/personal.yaml", retries: 2, lazy: true, exclude: []string{"docker"}}
+	argv, err := test.command(opts, []string{"bat", "jq"}, "/usr/bin/provisioner")
+	if err != nil {
+		t.Fatalf("command error: %v", err)
+	}
+	got := strings.Join(argv, " ")
+	for _, want := range []string{
+		"podman run --rm -v /usr/bin/provisioner:/usr/local/bin/provisioner:ro -v /m/work.yaml:/a-la-carte/0/work.yaml:ro -v /m/personal.yaml:/a-la-carte/1/personal.yaml:ro ubuntu:24.04 sh -c ",
+		" provisioner --no-tui --yes --refresh-repos --no-services --manifest work=/a-la-carte/0/work.yaml,personal=/a-la-carte/1/personal.yaml --only bat,jq --retries 2 --lazy --exclude docker",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in %q", want, got)
+		}
+	}
+	if strings.Contains(argv[slices.Index(argv, "-c")+1], "\n") {
+		t.Error("expected the container setup on one line")
+	}
+}
+
+// TestSSHRunner_RemoteArgv verifies that --target runs sudo non-interactively on the
+// remote host and maps the local home directory to the remote one.
+func TestSSHRunner_RemoteArgv(t *testing.T) {
+	t.Setenv(askpassEnv, "/usr/bin/ssh-askpass")
+	r := newSSHRunner("me@box", nil)
+	if got := shellJoin(r.remoteArgv(context.Background(), "apk", "git")); got != "sudo -n apk add --no-cache git" {
+		t.Errorf("without a password: got %q", got)
+	}
+	r.askSudo = true
+	if got := shellJoin(r.remoteArgv(context.Background(), "apk", "git")); got != "sudo -S -p '' apk add --no-cache git" {
+		t.Errorf("with a password: got %q", got)
+	}
+	if got := shellJoin(r.remoteArgv(context.Background(), "brew", "install", "bat")); got != "brew install bat" {
+		t.Errorf("without sudo: got %q", got)
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if got := remoteDir(filepath.Join(home, ".local", "bin")); got != `"$HOME"/.local/bin` {
+		t.Errorf("remoteDir in home: got %q", got)
+	}
+	if got := remoteDir("/opt/my tools"); got != `'/opt/my tools'` {
+		t.Errorf("remoteDir outside home: got %q", got)
+	}
+}
+
+func TestFailureExitCode(t *testing.T) {
+	failed := &provision.InstructionError{Instruction: provision.InstallInstruction{Type: "apt", Package: "bat", Key: "bat"}, Err: errors.New("exit status 100")}
+	if got := failureExitCode(failed); got != 1 {
+		t.Errorf("continued run: got exit code %d, want 1", got)
+	}
+	stopped := errors.Join(failed, fmt.Errorf("%w: 2 instructions not run", provision.ErrFailFast))
+	if got := failureExitCode(stopped); got != exitFailFast {
+		t.Errorf("fail-fast run: got exit code %d, want %d", got, exitFailFast)
+	}
+}
+
+// failingRunner fails the installs of the packages in fail, and runs the others.
+type failingRunner struct {
+	fail map[string]bool
+}
+
+func (r *failingRunner) Run(cmd string, args ...string) error {
+	return r.RunContext(context.Background(), cmd, args...)
+}
+
+func (r *failingRunner) RunContext(_ context.Context, cmd string, args ...string) error {
+	if len(args) > 1 && r.fail[args[len(args)-1]] {
+		return errors.New("exit status 1")
+	}
+	return nil
+}
+
+func (r *failingRunner) Output(string, ...string) ([]byte, error) { return nil, nil }
+
+// runPlan executes plan with runner the way the TUI does, feeding what the run reports
+// to m until it is done.
+func runPlan(t *testing.T, m *model, runner provision.ExecRunner, plan []provision.InstallInstruction) {
+	t.Helper()
+	prov := provision.NewProvisioner(nil, app.Manifest{}, runner)
+	m.follow(prov)
+	go func() {
+		m.logChan <- doneMsg{err: prov.ExecutePlan(plan)}
+	}()
+	for {
+		select {
+		case msg := <-m.logChan:
+			m.Update(msg)
+			if _, done := msg.(doneMsg); done {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("the run did not finish")
+		}
+	}
+}
+
+func TestFailureToast(t *testing.T) {
+	m := initialModel()
+	m.Update(logMsg{Level: "info", Text: "Installing..."})
+	plan := []provision.InstallInstruction{
+		{Type: "brew", Package: "bat", Key: "bat"},
+		{Type: "go", Package: "example.com/tool@latest", Key: "tool"},
+	}
+	runPlan(t, m, &failingRunner{fail: map[string]bool{"bat": true}}, plan)
+	if m.toasts.Len() != 1 {
+		t.Fatalf("expected an error toast for the failed install, got %d toasts", m.toasts.Len())
+	}
+	view := m.View()
+	if !strings.Contains(view, "Failed to install bat") {
+		t.Errorf("expected the toast over the log:\n%s", view)
+	}
+	m.Update(components.ToastExpiredMsg{ID: 1})
+	if m.toasts.Len() != 0 {
+		t.Errorf("expected the toast to expire, got %d toasts", m.toasts.Len())
+	}
+}
+
+func TestTerminalTitle(t *testing.T) {
+	m := initialModel()
+	_, cmd := m.Update(progressMsg{done: 3, total: 17})
+	if cmd == nil {
+		t.Fatal("expected commands after a progress update")
+	}
+	if got := fmt.Sprint(m.titleCmd()()); got != "provisioning 3/17" {
+		t.Errorf("expected the title %q, got %q", "provisioning 3/17", got)
+	}
+	plan := []provision.InstallInstruction{
+		{Type: "brew", Package: "bat", Key: "bat"},
+		{Type: "brew", Package: "jq", Key: "jq"},
+	}
+	runPlan(t, m, &failingRunner{}, plan)
+	if m.status != "Done" {
+		t.Errorf("expected the status Done once provisioning stopped, got %q", m.status)
+	}
+	if got := fmt.Sprint(m.titleCmd()()); got != "provisioned: 2 succeeded, 0 failed" {
+		t.Errorf("expected the summary as the title, got %q", got)
+	}
+
+	m = initialModel()
+	runPlan(t, m, &failingRunner{fail: map[string]bool{"jq": true}}, plan)
+	if m.status != "Failed: 1 of 2 packages failed to install" || !strings.Contains(m.View(), "Provisioning failed") {
+		t.Errorf("expected a failed run to end Failed, got %q", m.status)
+	}
+	if got := fmt.Sprint(m.titleCmd()()); got != "provisioned: 1 succeeded, 1 failed" {
+		t.Errorf("expected the summary as the title of a failed run, got %q", got)
+	}
+	if len(m.failedPkgs) != 1 || m.failedPkgs[0] != "jq" {
+		t.Errorf("expected jq listed as failed, got %v", m.failedPkgs)
+	}
+	m.opts.noTitle = true
+	if m.titleCmd() != nil {
+		t.Error("expected --no-title to leave the title alone")
+	}
+}
+
+func TestPasswordPrompt_ChecksInBackground(t *testing.T) {
+	m := initialModel()
+	m.prompt.Show()
+	_, cmd := m.Update(components.PasswordSubmittedMsg{Password: "wrong"})
+	if cmd == nil || !m.checkingSudo {
+		t.Fatal("expected the password to be checked by a command")
+	}
+	if view := m.View(); !strings.Contains(view, "Checking password...") {
+		t.Errorf("expected a spinner while the password is checked:\n%s", view)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m.Update(sudoValidatedMsg{err: errors.New("exit status 1")})
+	if m.checkingSudo || !m.prompt.IsVisible() {
+		t.Fatal("expected a rejected password to show the prompt again")
+	}
+	if view := m.View(); !strings.Contains(view, "Sorry, try again.") || strings.Contains(view, "•") {
+		t.Errorf("expected the error and no input typed while checking:\n%s", view)
+	}
+}
+
+func TestPasswordPrompt_AskedAgainWhenExpired(t *testing.T) {
+	m := initialModel()
+	stopped := false
+	m.cancel = func() { stopped = true } // provisioning is running
+	m.Update(sudoExpiredMsg{})
+	if !m.prompt.IsVisible() || !strings.Contains(m.View(), "expired") {
+		t.Fatal("expected expired sudo credentials to show the prompt again")
+	}
+	m.Update(sudoValidatedMsg{})
+	if m.prompt.IsVisible() {
+		t.Error("expected the accepted password to hide the prompt")
+	}
+	if m.cancel(); !stopped {
+		t.Error("expected the running provisioning to carry on rather than start again")
+	}
+
+	m.Update(sudoExpiredMsg{})
+	if _, cmd := m.Update(components.PasswordCancelledMsg{}); cmd != nil || m.prompt.IsVisible() {
+		t.Error("expected cancelling the prompt during the run to carry on without sudo")
+	}
+}
+
+func TestRenderProgress(t *testing.T) {
+	m := initialModel()
+	m.started = time.Now().Add(-40 * time.Second)
+	m.progress = progressMsg{done: 4, total: 10, current: provision.InstallInstruction{Type: "apt", Package: "jq"}}
+	line := stripANSI(renderProgress(m))
+	for _, want := range []string{"█", "░", "40%", "4/10", "Installing jq", "Elapsed 40s", "ETA ~1m0s"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("expected %q in the progress line, got %q", want, line)
+		}
+	}
+
+	m.progress = progressMsg{done: 10, total: 10}
+	if line := stripANSI(renderProgress(m)); strings.Contains(line, "ETA") || strings.Contains(line, "Installing") || !strings.Contains(line, "10/10") {
+		t.Errorf("expected no ETA and no current package once finished, got %q", line)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"a-la-carte/internal/app/provision"
+)
+
+// runReportCommand implements the "report" subcommand, which prints the report of the
+// last provisioning run, and returns the exit code.
+//
+// # Usage
+//
+//	provisioner report [--file <file>] [--json]
+func runReportCommand(args []string) int {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	file := fs.String("file", provision.DefaultReportPath(), "Report to print")
+	asJSON := fs.Bool("json", false, "Print the raw JSON report")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: provisioner report [--file <file>] [--json]")
+		return 2
+	}
+	if *asJSON {
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		_, _ = os.Stdout.Write(data)
+		return 0
+	}
+	report, err := provision.LoadReport(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := provision.PrintReport(os.Stdout, report); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
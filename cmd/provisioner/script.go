@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	"a-la-carte/internal/app/provision"
+)
+
+// scriptCommand prepares a manifest script for running: the script is rendered into a
+// temp file, which bash runs under the sandbox command given after the script (see
+// provision.ScriptWrapper). cleanup removes the temp file.
+func scriptCommand(ctx context.Context, args []string) (c *exec.Cmd, cleanup func(), err error) {
+	script, wrapper := args[0], args[1:]
+	out, err := renderScript(ctx, script)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpTmpl, err := os.CreateTemp("", "provision-script-tmpl-*.sh")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup = func() {
+		_ = os.Remove(tmpTmpl.Name())
+	}
+	if _, err := tmpTmpl.Write(out); err != nil {
+		_ = tmpTmpl.Close()
+		cleanup()
+		return nil, nil, err
+	}
+	if err := tmpTmpl.Close(); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	argv := append(append([]string(nil), wrapper...), "bash", tmpTmpl.Name())
+	return interruptOnCancel(exec.CommandContext(ctx, argv[0], argv[1:]...)), cleanup, nil
+}
+
+// renderScript renders a script with chezmoi execute-template, or with the built-in
+// text/template renderer when chezmoi is not installed.
+func renderScript(ctx context.Context, script string) ([]byte, error) {
+	if _, err := exec.LookPath("chezmoi"); err != nil {
+		out, err := provision.RenderTemplate(script)
+		return []byte(out), err
+	}
+	tmpRaw, err := os.CreateTemp("", "provision-script-raw-*.sh")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.Remove(tmpRaw.Name())
+	}()
+	if _, err := tmpRaw.WriteString(script); err != nil {
+		_ = tmpRaw.Close()
+		return nil, err
+	}
+	if err := tmpRaw.Close(); err != nil {
+		return nil, err
+	}
+	return exec.CommandContext(ctx, "chezmoi", "execute-template", tmpRaw.Name()).Output()
+}
+
+// describeScript returns how a script runs, for logs: "script" or "script (sandbox: bwrap ...)".
+func describeScript(args []string) string {
+	if len(args) < 2 {
+		return "script"
+	}
+	return "script (sandbox: " + strings.Join(args[1:], " ") + ")"
+}
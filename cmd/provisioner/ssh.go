@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"a-la-carte/internal/app/provision"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// sshControlPersist is how long the shared ssh connection stays open after a run.
+const sshControlPersist = "60"
+
+// sshRunner implements provision.ExecRunner, provision.BinaryInstaller and
+// provision.OutputCapturer on a remote host over ssh (--target), so a plan computed here
+// is applied to a fresh box. It uses the system ssh client, so ~/.ssh/config, agents
+// and known hosts apply, and shares one connection between commands.
+//
+// Commands that need root run with the remote sudo: passwordless, or with the password
+// sent to sudo -S on stdin. The password is not kept: it is asked for again whenever the
+// remote sudo has no cached credentials. Scripts are rendered for the remote system and
+// copied over stdin; sudo inside scripts needs passwordless sudo.
+//
+// # Fields
+//   - target:   The host, as ssh accepts it: [user@]host or a Host from ~/.ssh/config
+//   - control:  The ControlPath of the shared connection
+//   - askSudo:  The remote sudo needs a password (false runs sudo with -n)
+//   - system:   The remote system, detected by connect
+//   - log:      Archives command output (optional)
+//   - audit:    Records the executed commands (optional)
+//   - managers: Per-manager options from the config file
+//   - CapturedOutput: Where the provisioner wants command output copied
+type sshRunner struct {
+	target   string
+	control  string
+	askSudo  bool
+	system   *provision.RealSystemInfo
+	log      *provision.RunLog
+	audit    *provision.AuditLog
+	managers managerOptions
+	provision.CapturedOutput
+}
+
+// run runs c, recording it in the audit log.
+func (r *sshRunner) run(c *exec.Cmd) error {
+	start := time.Now()
+	err := c.Run()
+	auditCommand(r.audit, c, r.target, start, err)
+	return err
+}
+
+// newSSHRunner returns a runner for target. Call connect before running anything.
+func newSSHRunner(target string, managers managerOptions) *sshRunner {
+	return &sshRunner{
+		target:   target,
+		control:  filepath.Join(os.TempDir(), "a-la-carte-ssh-%C"),
+		managers: managers,
+	}
+}
+
+// sshCommand builds the local ssh command that runs the shell command line remote.
+func (r *sshRunner) sshCommand(ctx context.Context, remote string) *exec.Cmd {
+	return interruptOnCancel(exec.CommandContext(ctx, "ssh",
+		"-o", "ControlMaster=auto",
+		"-o", "ControlPath="+r.control,
+		"-o", "ControlPersist="+sshControlPersist,
+		r.target, "--", remote))
+}
+
+// connect opens the shared connection, letting ssh ask for passwords or host key
+// confirmation on the terminal, and detects the remote system.
+func (r *sshRunner) connect() error {
+	c := r.sshCommand(context.Background(), "true")
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("connecting to %s: %w", r.target, err)
+	}
+	system, err := provision.DetectRemoteSystem(r)
+	if err != nil {
+		return err
+	}
+	r.system = system
+	return nil
+}
+
+// close closes the shared connection.
+func (r *sshRunner) close() {
+	_ = exec.Command("ssh", "-o", "ControlPath="+r.control, "-O", "exit", r.target).Run()
+}
+
+// prepareSudo makes sure the remote sudo can run: unless it is passwordless, it asks for
+// the password and validates it, which caches the remote credentials where sudo shares
+// them between sessions.
+func (r *sshRunner) prepareSudo() error {
+	if _, err := r.Output("sudo", "-n", "true"); err == nil {
+		return nil
+	}
+	password, err := r.readSudoPassword()
+	if err != nil {
+		return err
+	}
+	c := r.sshCommand(context.Background(), shellJoin([]string{"sudo", "-S", "-p", "", "-v"}))
+	c.Stdin = strings.NewReader(string(password) + "\n")
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("sudo on %s: wrong password", r.target)
+	}
+	r.askSudo = true
+	return nil
+}
+
+// readSudoPassword asks for the remote sudo password, through the askpass program if one
+// is configured and otherwise on the terminal.
+func (r *sshRunner) readSudoPassword() ([]byte, error) {
+	prompt := fmt.Sprintf("[sudo] password on %s: ", r.target)
+	var password []byte
+	var err error
+	if useAskpass() {
+		password, err = exec.Command(os.Getenv(askpassEnv), prompt).Output()
+		password = []byte(strings.TrimRight(string(password), "\r\n"))
+	} else if term.IsTerminal(os.Stdin.Fd()) {
+		fmt.Fprint(os.Stderr, prompt)
+		password, err = term.ReadPassword(os.Stdin.Fd())
+		fmt.Fprintln(os.Stderr)
+	} else {
+		return nil, fmt.Errorf("sudo on %s needs a password: run from a terminal, pass --askpass, or allow passwordless sudo", r.target)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading the sudo password: %w", err)
+	}
+	return password, nil
+}
+
+// sudoStdin returns the stdin of a remote sudo -S command: nothing while the remote
+// credentials are cached, and otherwise the password, asked for again.
+func (r *sshRunner) sudoStdin() (io.Reader, error) {
+	if _, err := r.Output("sudo", "-n", "true"); err == nil {
+		return strings.NewReader(""), nil
+	}
+	password, err := r.readSudoPassword()
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(string(password) + "\n"), nil
+}
+
+// configure points the provisioner's checks of the machine at the remote host. Free
+// disk space and repository reachability can only be checked locally, so they are off.
+func (r *sshRunner) configure(prov *provision.Provisioner) {
+	prov.LookPath = provision.RunnerLookPath(r)
+	prov.InitSystem = provision.RunnerInitSystem(r)
+	prov.MinFreeSpaceMB = 0
+	prov.CheckReachability = false
+}
+
+// remoteArgv returns the command line to run remotely: the command as buildExecCmd
+// builds it, with sudo switched to reading the password from stdin (-S) or, without a
+// password, to failing instead of prompting (-n).
+func (r *sshRunner) remoteArgv(ctx context.Context, cmd string, args ...string) []string {
+	c, _ := r.managers.buildExecCmd(ctx, cmd, args...)
+	argv := c.Args
+	if argv[0] != "sudo" {
+		return argv
+	}
+	rest := argv[1:]
+	if len(rest) > 0 && rest[0] == "-A" {
+		rest = rest[1:] // the askpass program is local
+	}
+	if r.askSudo {
+		return append([]string{"sudo", "-S", "-p", ""}, rest...)
+	}
+	return append([]string{"sudo", "-n"}, rest...)
+}
+
+func (r *sshRunner) Run(cmd string, args ...string) error {
+	return r.RunContext(context.Background(), cmd, args...)
+}
+
+func (r *sshRunner) RunContext(ctx context.Context, cmd string, args ...string) error {
+	if cmd == "info" && len(args) > 0 {
+		fmt.Println(args[0])
+		return nil
+	}
+	if cmd == "section" || cmd == "info" {
+		return nil
+	}
+	var c *exec.Cmd
+	if cmd == "script" && len(args) > 0 {
+		script, err := provision.ExecuteTemplate(args[0], provision.ChezmoiTemplateData(r.system))
+		if err != nil {
+			return err
+		}
+		run := shellJoin(append(append([]string(nil), args[1:]...), "bash")) + ` "$f" </dev/null`
+		c = r.sshCommand(ctx, `f=$(mktemp) && cat >"$f" && { `+run+`; rc=$?; rm -f "$f"; exit $rc; }`)
+		c.Stdin = strings.NewReader(script)
+	} else {
+		argv := r.remoteArgv(ctx, cmd, args...)
+		c = r.sshCommand(ctx, shellJoin(argv))
+		if argv[0] == "sudo" && r.askSudo {
+			stdin, err := r.sudoStdin()
+			if err != nil {
+				return err
+			}
+			c.Stdin = stdin
+		}
+	}
+	outCopy, errCopy := r.Writers()
+	c.Stdout = io.MultiWriter(os.Stdout, r.log, outCopy)
+	c.Stderr = io.MultiWriter(os.Stderr, r.log, errCopy)
+	return r.run(c)
+}
+
+func (r *sshRunner) Output(cmd string, args ...string) ([]byte, error) {
+	c := r.sshCommand(context.Background(), shellJoin(append([]string{cmd}, args...)))
+	start := time.Now()
+	out, err := c.Output()
+	auditCommand(r.audit, c, r.target, start, err)
+	return out, err
+}
+
+// InstallBinary downloads and extracts the binary here, then copies the executables to
+// the remote b.Dir. A b.Dir in the local home directory maps to the remote home.
+func (r *sshRunner) InstallBinary(ctx context.Context, b provision.BinaryInstall) error {
+	tmp, err := os.MkdirTemp("", "a-la-carte-binary-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmp)
+	}()
+	fmt.Printf("Downloading %s\n", b.URL)
+	local := b
+	local.Dir = tmp
+	if err := provision.InstallBinary(ctx, local); err != nil {
+		return err
+	}
+	dir := remoteDir(b.Dir)
+	for _, bin := range b.Bins {
+		f, err := os.Open(filepath.Join(tmp, bin))
+		if err != nil {
+			return err
+		}
+		dest := dir + "/" + shellJoin([]string{bin})
+		c := r.sshCommand(ctx, fmt.Sprintf("mkdir -p %s && cat >%s && chmod 755 %s", dir, dest, dest))
+		c.Stdin = f
+		c.Stderr = os.Stderr
+		err = r.run(c)
+		_ = f.Close()
+		if err != nil {
+			return fmt.Errorf("copying %s to %s: %w", bin, r.target, err)
+		}
+	}
+	fmt.Printf("Installed %s to %s on %s\n", strings.Join(b.Bins, ", "), b.Dir, r.target)
+	return nil
+}
+
+// remoteDir returns a local directory as a remote shell word: paths in the local home
+// directory are made relative to the remote $HOME, others are used as they are.
+func remoteDir(dir string) string {
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			if rel == "." {
+				return `"$HOME"`
+			}
+			return `"$HOME"/` + shellJoin([]string{filepath.ToSlash(rel)})
+		}
+	}
+	return shellJoin([]string{filepath.ToSlash(dir)})
+}
+
+// connectTarget connects to --target, and prepares the remote sudo unless nothing will
+// be installed.
+func connectTarget(opts *options) (*sshRunner, error) {
+	r := newSSHRunner(opts.target, opts.managers)
+	if err := r.connect(); err != nil {
+		return nil, err
+	}
+	if !opts.dryRun {
+		if err := r.prepareSudo(); err != nil {
+			r.close()
+			return nil, err
+		}
+	}
+	return r, nil
+}
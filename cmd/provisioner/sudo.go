@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// askpassEnv is the environment variable sudo reads the askpass helper from.
+const askpassEnv = "SUDO_ASKPASS"
+
+// sudoKeepAliveInterval is how often cached sudo credentials are refreshed during a run.
+const sudoKeepAliveInterval = time.Minute
+
+// useAskpass reports whether an askpass helper is configured.
+func useAskpass() bool {
+	return os.Getenv(askpassEnv) != ""
+}
+
+// sudoCommand builds a sudo command, passing -A when an askpass helper is configured
+// so sudo never reads the password from the terminal.
+func sudoCommand(ctx context.Context, args ...string) *exec.Cmd {
+	if useAskpass() {
+		args = append([]string{"-A"}, args...)
+	}
+	return exec.CommandContext(ctx, "sudo", args...)
+}
+
+// sudoCached reports whether sudo can run without asking for a password.
+func sudoCached() bool {
+	return exec.Command("sudo", "-n", "true").Run() == nil
+}
+
+// validateSudo validates the password with sudo and caches the credentials.
+func validateSudo(password string) error {
+	cmd := exec.Command("sudo", "-S", "-p", "", "-v")
+	cmd.Stdin = strings.NewReader(password + "\n")
+	return cmd.Run()
+}
+
+// keepSudoAlive refreshes sudo credentials until stop is closed, so long runs
+// do not fail when the sudo timestamp expires. The password is not kept: once the
+// credentials have expired, the askpass program is asked for it if one is configured,
+// and expired is called otherwise so the password can be asked for again.
+func keepSudoAlive(stop <-chan struct{}, expired func()) {
+	ticker := time.NewTicker(sudoKeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if sudoCommand(context.Background(), "-n", "-v").Run() == nil {
+				continue
+			}
+			if useAskpass() && sudoCommand(context.Background(), "-v").Run() == nil {
+				continue
+			}
+			expired()
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// containerRuntimes are the container engines --test-in can run a plan with.
+var containerRuntimes = []string{"docker", "podman"}
+
+// containerBinary is where the provisioner binary is mounted inside the container.
+const containerBinary = "/usr/local/bin/provisioner"
+
+// containerManifestDir is where the manifests are mounted inside the container.
+const containerManifestDir = "/a-la-carte"
+
+// sudoShim stands in for sudo in images that lack it. Containers run as root, so it
+// drops sudo's options and runs the command as is. It is written with printf, so it
+// must not contain single quotes, backslashes or percent signs.
+const sudoShim = `#!/bin/sh
+while [ $# -gt 0 ]; do case "$1" in -*) shift ;; *) break ;; esac; done
+[ $# -eq 0 ] || exec "$@"
+`
+
+// containerTest runs the plan inside a throwaway container (--test-in), so manifests can
+// be validated without touching the host.
+//
+// # Fields
+//   - runtime: The container engine, docker or podman
+//   - image:   The image to provision, e.g. ubuntu:24.04
+type containerTest struct {
+	runtime string
+	image   string
+}
+
+// parseTestIn parses --test-in, a container engine and image such as docker:ubuntu:24.04.
+func parseTestIn(value string) (*containerTest, error) {
+	engine, image, ok := strings.Cut(value, ":")
+	if !ok || image == "" {
+		return nil, fmt.Errorf("invalid --test-in %q: want <engine>:<image>, e.g. docker:ubuntu:24.04", value)
+	}
+	if !slices.Contains(containerRuntimes, engine) {
+		return nil, fmt.Errorf("invalid --test-in %q: the engine must be one of %s", value, strings.Join(containerRuntimes, ", "))
+	}
+	return &containerTest{runtime: engine, image: image}, nil
+}
+
+// String returns the engine and image, e.g. "ubuntu:24.04 (docker)".
+func (t *containerTest) String() string {
+	return fmt.Sprintf("%s (%s)", t.image, t.runtime)
+}
+
+// command returns the container run that installs the keys in a fresh container with
+// this binary and the manifests mounted read-only. The run inside is headless, refreshes
+// the package indexes (images ship without them) and skips services, as containers have
+// no init system. Output streams back through the engine's stdout and stderr.
+//
+// # Parameters
+//   - opts:   The options of this run; the filters that shape the plan are forwarded
+//   - keys:   The selected keys, passed on with --only
+//   - binary: The provisioner executable to mount, which must be a Linux build
+func (t *containerTest) command(opts *options, keys []string, binary string) ([]string, error) {
+	argv := []string{t.runtime, "run", "--rm", "-v", binary + ":" + containerBinary + ":ro"}
+	var manifests []string
+	for i, src := range parseManifestSources(opts.manifestPath) {
+		path, err := filepath.Abs(src.Path)
+		if err != nil {
+			return nil, err
+		}
+		mounted := filepath.ToSlash(filepath.Join(containerManifestDir, strconv.Itoa(i), filepath.Base(path)))
+		argv = append(argv, "-v", path+":"+mounted+":ro")
+		if src.Name != "" {
+			mounted = src.Name + "=" + mounted
+		}
+		manifests = append(manifests, mounted)
+	}
+	// The setup stays on one line so the command logs as one line
+	setup := fmt.Sprintf(`command -v sudo >/dev/null 2>&1 || { printf '%s' >/usr/local/bin/sudo && chmod 755 /usr/local/bin/sudo; }; exec provisioner "$@"`, strings.ReplaceAll(sudoShim, "\n", `\n`))
+	argv = append(argv, t.image, "sh", "-c", setup, "provisioner",
+		"--no-tui", "--yes", "--refresh-repos", "--no-services",
+		"--manifest", strings.Join(manifests, ","),
+		"--only", strings.Join(keys, ","),
+		"--retries", strconv.Itoa(opts.retries))
+	if opts.lazy {
+		argv = append(argv, "--lazy")
+	}
+	if opts.strictDeps {
+		argv = append(argv, "--strict-deps")
+	}
+	if opts.failFast {
+		argv = append(argv, "--fail-fast")
+	}
+	if opts.includeGUI {
+		argv = append(argv, "--include-gui")
+	}
+	if len(opts.prefer) > 0 {
+		argv = append(argv, "--prefer", strings.Join(opts.prefer, ","))
+	}
+	if len(opts.without) > 0 {
+		argv = append(argv, "--without", strings.Join(opts.without, ","))
+	}
+	if len(opts.exclude) > 0 {
+		argv = append(argv, "--exclude", strings.Join(opts.exclude, ","))
+	}
+	if len(opts.skipGroups) > 0 {
+		argv = append(argv, "--skip-group", strings.Join(opts.skipGroups, ","))
+	}
+	return argv, nil
+}
+
+// containerKeys loads the manifest and returns the sorted keys a --test-in run installs.
+func containerKeys(opts *options) ([]string, error) {
+	manifest, err := opts.loadManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+	keys := selectKeys(manifest, opts.groups, opts.only, opts.tags, opts.tiers)
+	if len(keys) == 0 {
+		return nil, errors.New("no packages selected")
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// containerCommand returns the command of a --test-in run of the selected keys.
+func containerCommand(opts *options) ([]string, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("--test-in runs this provisioner inside the container, so it needs a Linux build (GOOS=linux); this one is for %s", runtime.GOOS)
+	}
+	keys, err := containerKeys(opts)
+	if err != nil {
+		return nil, err
+	}
+	binary, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the provisioner binary: %w", err)
+	}
+	return opts.testIn.command(opts, keys, binary)
+}
+
+// testInMain runs the selection in a container, streaming its output, and exits with the
+// container run's exit code.
+func testInMain(opts *options) {
+	argv, err := containerCommand(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Testing the plan in %s...\n", opts.testIn)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	audit, err := opts.openAuditLog()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Not auditing this run: %v\n", err)
+	}
+	c := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	start := time.Now()
+	err = c.Run()
+	auditCommand(audit, c, "", start, err)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			fmt.Fprintf(os.Stderr, "The plan failed in %s\n", opts.testIn)
+			os.Exit(exitErr.ExitCode())
+		}
+		fmt.Fprintf(os.Stderr, "Failed to run %s: %v\n", opts.testIn.runtime, err)
+		os.Exit(1)
+	}
+	fmt.Printf("The plan succeeded in %s\n", opts.testIn)
+}
+
+// runContainerTest runs the selection in a container from the TUI, streaming the
+// container's output into the log view.
+func (m *model) runContainerTest(ctx context.Context) {
+	dispatch := func(msg logMsg) { m.logChan <- msg }
+	var failure error
+	defer func() {
+		m.logChan <- doneMsg{err: failure}
+	}()
+	argv, err := containerCommand(&m.opts)
+	if err != nil {
+		dispatch(logMsg{Level: "error", Text: err.Error()})
+		failure = err
+		return
+	}
+	audit, err := m.opts.openAuditLog()
+	if err != nil {
+		dispatch(logMsg{Level: "error", Text: fmt.Sprintf("Not auditing this run: %v", err)})
+	}
+	dispatch(logMsg{Level: "section", Text: "Testing in " + m.opts.testIn.String()})
+	runner := &tuiExecRunner{dispatch: dispatch, audit: audit}
+	if err := runner.RunContext(ctx, argv[0], argv[1:]...); err != nil {
+		dispatch(logMsg{Level: "error", Text: fmt.Sprintf("The plan failed in %s", m.opts.testIn)})
+		failure = err
+		return
+	}
+	dispatch(logMsg{Level: "success", Text: fmt.Sprintf("The plan succeeded in %s", m.opts.testIn)})
+}
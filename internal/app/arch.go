@@ -0,0 +1,38 @@
+package app
+
+import "strings"
+
+// archAliases maps every known architecture name, from Go (GOARCH), uname -m and
+// package managers, to the canonical identifier used in manifest keys.
+var archAliases = map[string]string{
+	"x64":     "x64",
+	"amd64":   "x64",
+	"x86_64":  "x64",
+	"arm64":   "arm64",
+	"aarch64": "arm64",
+	"x86":     "x86",
+	"386":     "x86",
+	"i386":    "x86",
+	"i686":    "x86",
+	"arm":     "arm",
+	"armv7":   "arm",
+	"armv7l":  "arm",
+	"armhf":   "arm",
+	"riscv64": "riscv64",
+	"ppc64le": "ppc64le",
+	"s390x":   "s390x",
+}
+
+// CanonicalArch returns the manifest identifier for an architecture name, e.g. "x64"
+// for "amd64" or "x86_64". Unknown names are returned lower-cased.
+//
+// # Example
+//
+//	CanonicalArch("aarch64") // "arm64"
+func CanonicalArch(arch string) string {
+	arch = strings.ToLower(arch)
+	if canonical, ok := archAliases[arch]; ok {
+		return canonical
+	}
+	return arch
+}
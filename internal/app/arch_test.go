@@ -0,0 +1,21 @@
+package app
+
+import "testing"
+
+func TestCanonicalArch(t *testing.T) {
+	tests := map[string]string{
+		"amd64":   "x64",
+		"x86_64":  "x64",
+		"X64":     "x64",
+		"aarch64": "arm64",
+		"arm64":   "arm64",
+		"i686":    "x86",
+		"armv7l":  "arm",
+		"mips":    "mips",
+	}
+	for in, want := range tests {
+		if got := CanonicalArch(in); got != want {
+			t.Errorf("CanonicalArch(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Conflict is a pair of entries that cannot be installed together, declared with
+// _conflicts on one of them.
+//
+// # Fields
+//   - Key:  The entry that declares the conflict
+//   - With: The entry it conflicts with
+//   - Via:  The _provides name of With that Key conflicts with ("" if Key names With itself)
+type Conflict struct {
+	Key  string
+	With string
+	Via  string
+}
+
+// String describes the conflict, e.g. "neovim-nightly conflicts with neovim".
+func (c Conflict) String() string {
+	if c.Via != "" {
+		return fmt.Sprintf("%s conflicts with %s (which provides %s)", c.Key, c.With, c.Via)
+	}
+	return fmt.Sprintf("%s conflicts with %s", c.Key, c.With)
+}
+
+// Conflict reports whether the entries a and b conflict: either lists the other's key,
+// bare key or one of its _provides names in _conflicts.
+//
+// # Example
+//
+//	m := Manifest{"neovim": {Provides: StringOrSlice{"vi"}}, "vim": {Conflicts: StringOrSlice{"vi"}}}
+//	m.Conflict("neovim", "vim") // {Key: vim, With: neovim, Via: vi}, true
+func (m Manifest) Conflict(a, b string) (Conflict, bool) {
+	if a == b {
+		return Conflict{}, false
+	}
+	if via, ok := m.declaresConflict(a, b); ok {
+		return Conflict{Key: a, With: b, Via: via}, true
+	}
+	if via, ok := m.declaresConflict(b, a); ok {
+		return Conflict{Key: b, With: a, Via: via}, true
+	}
+	return Conflict{}, false
+}
+
+// declaresConflict reports whether key's _conflicts names other, and through which of
+// other's _provides names ("" if it names other's key).
+func (m Manifest) declaresConflict(key, other string) (string, bool) {
+	_, bare := SplitKey(other)
+	provides := m[other].Provides
+	for _, name := range m[key].Conflicts {
+		if name == other || name == bare {
+			return "", true
+		}
+		if slices.Contains(provides, name) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Conflicts returns the conflicts among keys, each pair once, in the order of keys.
+func (m Manifest) Conflicts(keys []string) []Conflict {
+	var result []Conflict
+	for i, a := range keys {
+		for _, b := range keys[i+1:] {
+			if c, ok := m.Conflict(a, b); ok {
+				result = append(result, c)
+			}
+		}
+	}
+	return result
+}
+
+// Providers returns the sorted keys of the entries that list name in _provides.
+func (m Manifest) Providers(name string) []string {
+	var keys []string
+	for key, entry := range m {
+		if slices.Contains(entry.Provides, name) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
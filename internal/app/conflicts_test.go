@@ -0,0 +1,32 @@
+package app
+
+import "testing"
+
+func TestConflicts(t *testing.T) {
+	m := Manifest{
+		"neovim":         {Provides: StringOrSlice{"vi", "editor"}},
+		"neovim-nightly": {Conflicts: StringOrSlice{"neovim"}, Provides: StringOrSlice{"editor"}},
+		"vim":            {Conflicts: StringOrSlice{"vi"}},
+		"work/helix":     {Provides: StringOrSlice{"editor"}},
+		"emacs":          {},
+	}
+	got := m.Conflicts([]string{"neovim", "emacs", "neovim-nightly", "vim"})
+	want := []Conflict{
+		{Key: "neovim-nightly", With: "neovim"},
+		{Key: "vim", With: "neovim", Via: "vi"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got conflicts %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("conflict %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if s := got[1].String(); s != "vim conflicts with neovim (which provides vi)" {
+		t.Errorf("String() = %q", s)
+	}
+	if providers := m.Providers("editor"); len(providers) != 3 || providers[0] != "neovim" || providers[2] != "work/helix" {
+		t.Errorf("Providers(editor) = %v", providers)
+	}
+}
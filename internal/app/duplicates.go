@@ -0,0 +1,101 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Duplicate is an executable or package that entries under different keys define, e.g.
+// ripgrep in a work manifest and rg in a personal one, so merged manifests would
+// install it twice. Entries that declare a conflict with each other are expected to
+// overlap, and the same key in several manifests overrides by priority; neither counts.
+//
+// # Fields
+//   - Field: What the entries define: "_bin", or an install method such as "apt" or
+//     "apt:debian"
+//   - Value: The executable or package, e.g. "rg"
+//   - Keys:  The keys of the entries defining it, sorted
+type Duplicate struct {
+	Field string
+	Value string
+	Keys  []string
+}
+
+// String describes the duplicate, e.g. "executable rg is defined by personal/rg and
+// work/ripgrep".
+func (d Duplicate) String() string {
+	what := d.Field + " package " + d.Value
+	if d.Field == "_bin" {
+		what = "executable " + d.Value
+	}
+	keys := strings.Join(d.Keys[:len(d.Keys)-1], ", ") + " and " + d.Keys[len(d.Keys)-1]
+	return fmt.Sprintf("%s is defined by %s", what, keys)
+}
+
+// Duplicates returns the executables (_bin) and packages that entries under different
+// keys define, sorted by field and value. Use it after LoadManifests to find entries
+// that merged manifests or includes define twice.
+//
+// # Example
+//
+//	m := Manifest{"work/ripgrep": {Apt: StringOrSlice{"ripgrep"}}, "personal/rg": {Apt: StringOrSlice{"ripgrep"}}}
+//	m.Duplicates() // [{Field: apt, Value: ripgrep, Keys: [personal/rg work/ripgrep]}]
+func (m Manifest) Duplicates() []Duplicate {
+	type definition struct{ field, value string }
+	defined := make(map[definition][]string)
+	for key, entry := range m {
+		seen := make(map[definition]bool)
+		for field, values := range entry.definitions() {
+			for _, value := range values {
+				if d := (definition{field, value}); !seen[d] {
+					seen[d] = true
+					defined[d] = append(defined[d], key)
+				}
+			}
+		}
+	}
+	var result []Duplicate
+	for d, keys := range defined {
+		if m.distinctEntries(keys) {
+			sort.Strings(keys)
+			result = append(result, Duplicate{Field: d.field, Value: d.value, Keys: keys})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Field != result[j].Field {
+			return result[i].Field < result[j].Field
+		}
+		return result[i].Value < result[j].Value
+	})
+	return result
+}
+
+// definitions returns the executables and packages the entry defines, by field.
+func (e *SoftwareEntry) definitions() map[string][]string {
+	defs := map[string][]string{"_bin": e.Bin}
+	for _, installer := range installerKeys {
+		defs[installer] = e.Packages(installer)
+	}
+	for installer, packages := range e.Installers {
+		defs[installer] = packages
+	}
+	return defs
+}
+
+// distinctEntries reports whether two of keys are different entries: their bare keys
+// differ and they do not declare a conflict with each other.
+func (m Manifest) distinctEntries(keys []string) bool {
+	for i, a := range keys {
+		_, bareA := SplitKey(a)
+		for _, b := range keys[i+1:] {
+			if _, bareB := SplitKey(b); bareA == bareB {
+				continue
+			}
+			if _, conflict := m.Conflict(a, b); !conflict {
+				return true
+			}
+		}
+	}
+	return false
+}
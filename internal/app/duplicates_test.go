@@ -0,0 +1,40 @@
+package app
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDuplicates(t *testing.T) {
+	m := Manifest{
+		"work/ripgrep":     {Bin: StringOrSlice{"rg"}, Apt: StringOrSlice{"ripgrep"}},
+		"personal/ripgrep": {Bin: StringOrSlice{"rg"}, Apt: StringOrSlice{"ripgrep"}},
+		"personal/rg":      {Bin: StringOrSlice{"rg"}, Brew: StringOrSlice{"ripgrep"}, Installers: map[string]StringOrSlice{"apt:debian": {"ripgrep"}}},
+		"work/nvim":        {Bin: StringOrSlice{"nvim"}, Provides: StringOrSlice{"editor"}},
+		"work/nvim-head":   {Bin: StringOrSlice{"nvim"}, Conflicts: StringOrSlice{"editor"}},
+		"work/fd":          {Apt: StringOrSlice{"fd-find", "fd-find"}},
+	}
+	got := m.Duplicates()
+	want := []Duplicate{
+		{Field: "_bin", Value: "rg", Keys: []string{"personal/rg", "personal/ripgrep", "work/ripgrep"}},
+	}
+	if len(got) != len(want) || got[0].Field != want[0].Field || got[0].Value != want[0].Value || !slices.Equal(got[0].Keys, want[0].Keys) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	if s := got[0].String(); s != "executable rg is defined by personal/rg, personal/ripgrep and work/ripgrep" {
+		t.Errorf("unexpected description %q", s)
+	}
+
+	// The same package under different keys, in a field or a qualified installer key
+	m["work/rg-bin"] = SoftwareEntry{Apt: StringOrSlice{"ripgrep"}, Installers: map[string]StringOrSlice{"apt:debian": {"ripgrep"}}}
+	var fields []string
+	for _, d := range m.Duplicates() {
+		fields = append(fields, d.Field+" "+d.Value)
+		if d.Field == "apt" && d.String() != "apt package ripgrep is defined by personal/ripgrep, work/rg-bin and work/ripgrep" {
+			t.Errorf("unexpected description %q", d)
+		}
+	}
+	if !slices.Equal(fields, []string{"_bin rg", "apt ripgrep", "apt:debian ripgrep"}) {
+		t.Errorf("expected the duplicates sorted by field, got %v", fields)
+	}
+}
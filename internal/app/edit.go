@@ -0,0 +1,348 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EntryEdit holds the fields of a manifest entry that can be edited in the TUI.
+//
+// # Fields
+//   - Key:        The entry's key in its manifest file (without a namespace)
+//   - Name, Desc: The _name and _desc metadata
+//   - Groups:     The _groups
+//   - Installers: Install methods and their packages in order, e.g. apt=ripgrep or apt:debian=rg
+type EntryEdit struct {
+	Key        string
+	Name       string
+	Desc       string
+	Groups     []string
+	Installers []InstallerValue
+}
+
+// InstallerValue is one package of an install method. An installer listed several
+// times is written as a list.
+type InstallerValue struct {
+	Installer string
+	Package   string
+}
+
+// installerKeys are the install methods of SoftwareEntry, taken from its YAML tags.
+var installerKeys = func() []string {
+	var keys []string
+	t := reflect.TypeOf(SoftwareEntry{})
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("yaml")
+		if tag != "" && tag != "-" && !strings.HasPrefix(tag, "_") && tag != "deps" && tag != "lazy" && tag != "script" {
+			keys = append(keys, tag)
+		}
+	}
+	return keys
+}()
+
+// IsInstallerKey reports whether key is an install method, optionally with OS, distro
+// or arch suffixes such as apt:debian or binary:linux:arm64.
+func IsInstallerKey(key string) bool {
+	for _, installer := range installerKeys {
+		if key == installer || strings.HasPrefix(key, installer+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// InstallerKeyPattern returns a regular expression matching the installer keys with OS,
+// distro or arch qualifiers, e.g. apt:debian or binary:linux:arm64, which go to
+// SoftwareEntry.Installers.
+func InstallerKeyPattern() string {
+	quoted := make([]string, len(installerKeys))
+	for i, key := range installerKeys {
+		quoted[i] = regexp.QuoteMeta(key)
+	}
+	return "^(" + strings.Join(quoted, "|") + "):"
+}
+
+// ParseInstallers parses comma-separated installer=package pairs.
+//
+// # Example
+//
+//	ParseInstallers("apt=ripgrep, brew=ripgrep") // [{apt ripgrep} {brew ripgrep}]
+func ParseInstallers(s string) ([]InstallerValue, error) {
+	var values []InstallerValue
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		installer, pkg, ok := strings.Cut(pair, "=")
+		installer, pkg = strings.TrimSpace(installer), strings.TrimSpace(pkg)
+		if !ok || pkg == "" {
+			return nil, fmt.Errorf("invalid installer %q: want installer=package", pair)
+		}
+		if !IsInstallerKey(installer) {
+			return nil, fmt.Errorf("unknown installer %q", installer)
+		}
+		values = append(values, InstallerValue{Installer: installer, Package: pkg})
+	}
+	return values, nil
+}
+
+// FormatInstallers formats installers the way ParseInstallers reads them.
+func FormatInstallers(values []InstallerValue) string {
+	pairs := make([]string, len(values))
+	for i, v := range values {
+		pairs[i] = v.Installer + "=" + v.Package
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// Validate checks the fields of an edited entry.
+//
+// # Returns
+//   - error: if the key is empty or malformed, or there is no install method
+func (e *EntryEdit) Validate() error {
+	switch {
+	case e.Key == "":
+		return errors.New("the key is required")
+	case strings.ContainsAny(e.Key, " \t:"+NamespaceSeparator) || strings.HasPrefix(e.Key, "_"):
+		return fmt.Errorf("invalid key %q: keys must not start with _ or contain spaces, : or %s", e.Key, NamespaceSeparator)
+	case len(e.Installers) == 0:
+		return errors.New("at least one installer is required, e.g. apt=" + e.Key)
+	}
+	return nil
+}
+
+// LoadEntryEdit reads the editable fields of an entry from a manifest file, including
+// installer keys with suffixes that SoftwareEntry does not keep.
+func LoadEntryEdit(path, key string) (EntryEdit, error) {
+	doc, err := readManifestNode(path)
+	if err != nil {
+		return EntryEdit{}, err
+	}
+	entry := mappingValue(doc.Content[0], key)
+	if entry == nil || entry.Kind != yaml.MappingNode {
+		return EntryEdit{}, fmt.Errorf("%s has no entry %q", path, key)
+	}
+	edit := EntryEdit{Key: key}
+	for i := 0; i+1 < len(entry.Content); i += 2 {
+		field, value := entry.Content[i].Value, entry.Content[i+1]
+		var values StringOrSlice
+		if err := value.Decode(&values); err != nil {
+			continue
+		}
+		switch {
+		case field == "_name":
+			edit.Name = strings.Join(values, " ")
+		case field == "_desc":
+			edit.Desc = strings.Join(values, " ")
+		case field == "_groups":
+			edit.Groups = values
+		case IsInstallerKey(field):
+			for _, pkg := range values {
+				edit.Installers = append(edit.Installers, InstallerValue{Installer: field, Package: pkg})
+			}
+		}
+	}
+	return edit, nil
+}
+
+// SaveEntry writes an edited entry to a manifest file. The file is round-tripped
+// through yaml.Node, so comments, key order and the entry's other fields are kept.
+// Installer keys that are no longer listed are removed.
+//
+// # Parameters
+//   - path:     The manifest file; it is created if it does not exist
+//   - original: The key being edited, or "" for a new entry
+//   - edit:     The new fields; edit.Key differs from original when renaming
+//
+// # Returns
+//   - error: if the entry is invalid, the key is taken, or the file cannot be read or written
+func SaveEntry(path, original string, edit EntryEdit) error {
+	if err := edit.Validate(); err != nil {
+		return err
+	}
+	doc, err := readManifestNode(path)
+	if errors.Is(err, os.ErrNotExist) {
+		doc, err = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}, nil
+	}
+	if err != nil {
+		return err
+	}
+	root := doc.Content[0]
+	if edit.Key != original && mappingValue(root, edit.Key) != nil {
+		return fmt.Errorf("%s already has an entry %q", path, edit.Key)
+	}
+	entry := mappingValue(root, original)
+	switch {
+	case original == "":
+		entry = &yaml.Node{Kind: yaml.MappingNode}
+		root.Content = append(root.Content, scalarNode(edit.Key), entry)
+	case entry == nil || entry.Kind != yaml.MappingNode:
+		return fmt.Errorf("%s has no entry %q", path, original)
+	}
+	// Rename the entry in place
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i+1] == entry {
+			root.Content[i].Value = edit.Key
+		}
+	}
+
+	setField(entry, "_name", listNode(splitNonEmpty(edit.Name)))
+	setField(entry, "_desc", listNode(splitNonEmpty(edit.Desc)))
+	setField(entry, "_groups", listNode(edit.Groups))
+	var installers []string
+	packages := make(map[string][]string)
+	for _, v := range edit.Installers {
+		if !slices.Contains(installers, v.Installer) {
+			installers = append(installers, v.Installer)
+		}
+		packages[v.Installer] = append(packages[v.Installer], v.Package)
+	}
+	for i := 0; i+1 < len(entry.Content); {
+		if field := entry.Content[i].Value; IsInstallerKey(field) && packages[field] == nil {
+			entry.Content = slices.Delete(entry.Content, i, i+2)
+			continue
+		}
+		i += 2
+	}
+	for _, installer := range installers {
+		setField(entry, installer, listNode(packages[installer]))
+	}
+	return writeManifestNode(path, doc)
+}
+
+// splitNonEmpty returns s as a one-element list, or nil if it is empty.
+func splitNonEmpty(s string) []string {
+	if s = strings.TrimSpace(s); s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+// readManifestNode parses a manifest file and returns its document, whose content is
+// the top-level mapping; the document is kept so comments before the first entry, such
+// as a yaml-language-server directive, are written back. Templates are refused, as
+// writing the rendered entries back would lose their directives.
+func readManifestNode(path string) (*yaml.Node, error) {
+	if IsManifestTemplate(path) {
+		return nil, fmt.Errorf("%s is a template; edit it in your editor", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s is not a manifest: the top level must be a mapping", path)
+	}
+	return &doc, nil
+}
+
+// writeManifestNode writes a manifest document to path, replacing the file only once
+// the new contents are complete.
+func writeManifestNode(path string, doc *yaml.Node) error {
+	data, err := encodeManifestNode(doc)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".manifest-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		_ = os.Chmod(tmp.Name(), info.Mode().Perm())
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// encodeManifestNode encodes a manifest document, or a mapping of entries, with the
+// two-space indent manifests use.
+func encodeManifestNode(node *yaml.Node) ([]byte, error) {
+	if node.Kind != yaml.DocumentNode {
+		node = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{node}}
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setField sets key in a mapping node to value, keeping the key's position and
+// comments; a nil value removes the key.
+func setField(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value != key {
+			continue
+		}
+		if value == nil {
+			m.Content = slices.Delete(m.Content, i, i+2)
+			return
+		}
+		value.HeadComment, value.LineComment, value.FootComment = m.Content[i+1].HeadComment, m.Content[i+1].LineComment, m.Content[i+1].FootComment
+		m.Content[i+1] = value
+		return
+	}
+	if value != nil {
+		m.Content = append(m.Content, scalarNode(key), value)
+	}
+}
+
+// listNode returns a scalar for a single value, a sequence for several, or nil for none.
+func listNode(values []string) *yaml.Node {
+	switch len(values) {
+	case 0:
+		return nil
+	case 1:
+		return scalarNode(values[0])
+	}
+	seq := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, v := range values {
+		seq.Content = append(seq.Content, scalarNode(v))
+	}
+	return seq
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
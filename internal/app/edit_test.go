@@ -0,0 +1,119 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const editYAML = `# Tools for the terminal
+ripgrep:
+  _bin: rg
+  _name: ripgrep # display name
+  apt: ripgrep
+  apt:debian: rg-old
+  brew: ripgrep
+fd:
+  _name: fd
+  apt: fd-find
+`
+
+func TestParseInstallers(t *testing.T) {
+	got, err := ParseInstallers("apt=ripgrep, apt:debian=rg , binary:linux=https://example.com/rg.tgz,")
+	if err != nil {
+		t.Fatalf("ParseInstallers error: %v", err)
+	}
+	want := []InstallerValue{{"apt", "ripgrep"}, {"apt:debian", "rg"}, {"binary:linux", "https://example.com/rg.tgz"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if FormatInstallers(got) != "apt=ripgrep, apt:debian=rg, binary:linux=https://example.com/rg.tgz" {
+		t.Errorf("unexpected FormatInstallers output %q", FormatInstallers(got))
+	}
+	for _, bad := range []string{"apt", "apt=", "nope=pkg", "_bin=rg", "deps=fd"} {
+		if _, err := ParseInstallers(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestSaveEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "software.yml")
+	if err := os.WriteFile(path, []byte(editYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	edit, err := LoadEntryEdit(path, "ripgrep")
+	if err != nil {
+		t.Fatalf("LoadEntryEdit error: %v", err)
+	}
+	if edit.Name != "ripgrep" || FormatInstallers(edit.Installers) != "apt=ripgrep, apt:debian=rg-old, brew=ripgrep" {
+		t.Errorf("unexpected loaded entry %+v", edit)
+	}
+
+	edit.Key = "rg"
+	edit.Desc = "Fast grep"
+	edit.Groups = []string{"cli", "search"}
+	edit.Installers = []InstallerValue{{"apt", "ripgrep"}, {"cargo", "ripgrep"}}
+	if err := SaveEntry(path, "ripgrep", edit); err != nil {
+		t.Fatalf("SaveEntry error: %v", err)
+	}
+	if err := SaveEntry(path, "", EntryEdit{Key: "jq", Installers: []InstallerValue{{"apt", "jq"}}}); err != nil {
+		t.Fatalf("SaveEntry of a new entry error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	got := string(data)
+	want := `# Tools for the terminal
+rg:
+  _bin: rg
+  _name: ripgrep # display name
+  apt: ripgrep
+  _desc: Fast grep
+  _groups:
+    - cli
+    - search
+  cargo: ripgrep
+fd:
+  _name: fd
+  apt: fd-find
+jq:
+  apt: jq
+`
+	if got != want {
+		t.Errorf("saved manifest:\n%s\nwant:\n%s", got, want)
+	}
+	if _, err := LoadManifest(path); err != nil {
+		t.Errorf("saved manifest does not load: %v", err)
+	}
+
+	for name, tc := range map[string]struct {
+		original string
+		edit     EntryEdit
+		want     string
+	}{
+		"taken key":    {"fd", EntryEdit{Key: "jq", Installers: []InstallerValue{{"apt", "fd"}}}, "already has"},
+		"missing":      {"gone", EntryEdit{Key: "gone", Installers: []InstallerValue{{"apt", "x"}}}, "no entry"},
+		"no installer": {"", EntryEdit{Key: "new"}, "at least one installer"},
+		"bad key":      {"", EntryEdit{Key: "a b", Installers: []InstallerValue{{"apt", "x"}}}, "invalid key"},
+	} {
+		if err := SaveEntry(path, tc.original, tc.edit); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got error %v, want %q", name, err, tc.want)
+		}
+	}
+}
+
+func TestSaveEntryHeadComment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "software.yml")
+	head := "# yaml-language-server: $schema=x.json\n\n# Shared tools\n# maintained by hand\n\n"
+	if err := os.WriteFile(path, []byte(head+"fd:\n  apt: fd-find\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveEntry(path, "", EntryEdit{Key: "jq", Installers: []InstallerValue{{"apt", "jq"}}}); err != nil {
+		t.Fatalf("SaveEntry error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if want := head + "fd:\n  apt: fd-find\njq:\n  apt: jq\n"; string(data) != want {
+		t.Errorf("saved manifest:\n%s\nwant:\n%s", data, want)
+	}
+}
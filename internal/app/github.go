@@ -0,0 +1,244 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GitHubClient queries the GitHub API and the Homebrew formula API for ScaffoldEntry.
+//
+// # Fields
+//   - APIURL:  The GitHub API base URL (DefaultGitHubAPIURL unless testing)
+//   - BrewURL: The Homebrew formula API base URL (DefaultBrewAPIURL unless testing)
+//   - Token:   An optional GitHub token, which raises the API rate limit
+//   - HTTP:    The HTTP client (http.DefaultClient if nil)
+type GitHubClient struct {
+	APIURL  string
+	BrewURL string
+	Token   string
+	HTTP    *http.Client
+}
+
+// Default endpoints of GitHubClient.
+const (
+	DefaultGitHubAPIURL = "https://api.github.com"
+	DefaultBrewAPIURL   = "https://formulae.brew.sh/api"
+)
+
+// maxAPIResponseSize bounds the size of an API response.
+const maxAPIResponseSize = 4 << 20
+
+// errNotFound is returned by getJSON for a 404 response.
+var errNotFound = errors.New("not found")
+
+// NewGitHubClient returns a client for the public GitHub and Homebrew APIs.
+func NewGitHubClient(token string) *GitHubClient {
+	return &GitHubClient{APIURL: DefaultGitHubAPIURL, BrewURL: DefaultBrewAPIURL, Token: token}
+}
+
+// githubRepo is the part of the GitHub repository API response ScaffoldEntry uses.
+type githubRepo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Homepage    string `json:"homepage"`
+	HTMLURL     string `json:"html_url"`
+}
+
+// githubRelease is the part of the GitHub latest release API response ScaffoldEntry uses.
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// ParseGitHubURL returns the owner and repository of a GitHub URL. It accepts
+// https://github.com/owner/repo, github.com/owner/repo.git and owner/repo.
+func ParseGitHubURL(raw string) (owner, repo string, err error) {
+	s := strings.TrimSpace(raw)
+	if !strings.Contains(s, "://") && strings.HasPrefix(s, "github.com/") {
+		s = "https://" + s
+	}
+	path := s
+	if strings.Contains(s, "://") {
+		u, err := url.Parse(s)
+		if err != nil || u.Host != "github.com" && u.Host != "www.github.com" {
+			return "", "", fmt.Errorf("not a GitHub repository URL: %q", raw)
+		}
+		path = u.Path
+	}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("not a GitHub repository URL: %q", raw)
+	}
+	return parts[0], strings.TrimSuffix(parts[1], ".git"), nil
+}
+
+// ScaffoldEntry builds a manifest entry snippet for a GitHub repository from its
+// metadata: the description and homepage, a brew formula if Homebrew has one named
+// after the repository, and binary:* downloads from the latest release's assets.
+// The key and _bin default to the lowercased repository name.
+//
+// # Parameters
+//   - ctx:       Bounds the API requests
+//   - githubURL: The repository, see ParseGitHubURL
+//   - key:       The manifest key ("" for the repository name)
+//
+// # Returns
+//   - string: The YAML entry, ready to append to a manifest
+//   - error:  If the URL is invalid or the repository cannot be fetched
+//
+// # Example
+//
+//	snippet, err := app.NewGitHubClient("").ScaffoldEntry(ctx, "https://github.com/sharkdp/bat", "")
+func (c *GitHubClient) ScaffoldEntry(ctx context.Context, githubURL, key string) (string, error) {
+	owner, name, err := ParseGitHubURL(githubURL)
+	if err != nil {
+		return "", err
+	}
+	var repo githubRepo
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/repos/%s/%s", c.APIURL, owner, name), &repo); err != nil {
+		return "", fmt.Errorf("fetching %s/%s: %w", owner, name, err)
+	}
+	if repo.Name == "" {
+		repo.Name = name
+	}
+	if key == "" {
+		key = strings.ToLower(repo.Name)
+	}
+	// Releases and formulae are optional: a repository may have neither
+	var release githubRelease
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/repos/%s/%s/releases/latest", c.APIURL, owner, name), &release); err != nil && !errors.Is(err, errNotFound) {
+		return "", fmt.Errorf("fetching the latest release of %s/%s: %w", owner, name, err)
+	}
+	hasFormula := true
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/formula/%s.json", c.BrewURL, key), &struct{}{}); errors.Is(err, errNotFound) {
+		hasFormula = false
+	} else if err != nil {
+		return "", fmt.Errorf("checking for a brew formula %s: %w", key, err)
+	}
+
+	entry := &yaml.Node{Kind: yaml.MappingNode}
+	setField(entry, "_name", scalarNode(repo.Name))
+	setField(entry, "_desc", listNode(splitNonEmpty(repo.Description)))
+	setField(entry, "_github", scalarNode(repo.HTMLURL))
+	setField(entry, "_home", listNode(splitNonEmpty(repo.Homepage)))
+	setField(entry, "_bin", scalarNode(key))
+	installers := 0
+	if hasFormula {
+		setField(entry, "brew", scalarNode(key))
+		installers++
+	}
+	// Download URLs refer to the release through {version}, so bumping _version updates them
+	version := strings.TrimPrefix(release.TagName, "v")
+	if version != "" {
+		setField(entry, "_version", scalarNode(version))
+	}
+	assets := make([]string, len(release.Assets))
+	urls := make(map[string]string, len(release.Assets))
+	for i, asset := range release.Assets {
+		assets[i] = asset.Name
+		urls[asset.Name] = asset.URL
+	}
+	for _, target := range releaseTargets {
+		if asset := pickReleaseAsset(assets, target.os, target.arch); asset != "" {
+			downloadURL := urls[asset]
+			if version != "" {
+				downloadURL = strings.ReplaceAll(downloadURL, version, "{version}")
+			}
+			setField(entry, target.installer, scalarNode(downloadURL))
+			installers++
+		}
+	}
+	if installers == 0 {
+		entry.Content[len(entry.Content)-1].LineComment = "TODO add an installer, e.g. apt: " + key
+	}
+	data, err := encodeManifestNode(&yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{scalarNode(key), entry}})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// releaseTargets are the binary:* installers filled from release assets, with the
+// name fragments an asset for them contains.
+var releaseTargets = []struct {
+	installer string
+	os, arch  []string
+}{
+	{"binary:linux", []string{"linux"}, []string{"x86_64", "amd64", "x64"}},
+	{"binary:darwin", []string{"darwin", "macos", "apple"}, []string{"arm64", "aarch64", "universal"}},
+	{"binary:windows", []string{"windows", "win64"}, []string{"x86_64", "amd64", "x64", "win64"}},
+}
+
+// releaseArchives are the asset suffixes worth installing; checksums, signatures and
+// distro packages are skipped.
+var releaseArchives = []string{".tar.gz", ".tgz", ".tar.xz", ".tar.bz2", ".zip", ".exe"}
+
+// pickReleaseAsset returns the first asset for the OS and one of the architectures,
+// falling back to the first asset for the OS alone, or "" if there is none.
+func pickReleaseAsset(assets, osNames, archNames []string) string {
+	var fallback string
+	for _, asset := range assets {
+		name := strings.ToLower(asset)
+		if !containsAny(name, releaseArchives, strings.HasSuffix) || !containsAny(name, osNames, strings.Contains) {
+			continue
+		}
+		if containsAny(name, archNames, strings.Contains) {
+			return asset
+		}
+		if fallback == "" && !containsAny(name, []string{"arm", "aarch64", "386", "i686"}, strings.Contains) {
+			fallback = asset
+		}
+	}
+	return fallback
+}
+
+// containsAny reports whether match(s, sub) holds for any of subs.
+func containsAny(s string, subs []string, match func(s, sub string) bool) bool {
+	for _, sub := range subs {
+		if match(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// getJSON fetches url and decodes the JSON response into v. A 404 returns errNotFound.
+func (c *GitHubClient) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" && strings.HasPrefix(url, c.APIURL) {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return errNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxAPIResponseSize)).Decode(v)
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseGitHubURL(t *testing.T) {
+	for _, raw := range []string{"https://github.com/sharkdp/bat", "github.com/sharkdp/bat.git", "sharkdp/bat", "https://github.com/sharkdp/bat/releases"} {
+		if owner, repo, err := ParseGitHubURL(raw); err != nil || owner != "sharkdp" || repo != "bat" {
+			t.Errorf("ParseGitHubURL(%q) = %q, %q, %v", raw, owner, repo, err)
+		}
+	}
+	for _, raw := range []string{"https://gitlab.com/a/b", "bat", ""} {
+		if _, _, err := ParseGitHubURL(raw); err == nil {
+			t.Errorf("expected an error for %q", raw)
+		}
+	}
+}
+
+func TestScaffoldEntry(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/sharkdp/bat", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"name": "bat", "description": "A cat(1) clone with wings.", "homepage": "", "html_url": "https://github.com/sharkdp/bat"}`))
+	})
+	mux.HandleFunc("/repos/sharkdp/bat/releases/latest", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"tag_name": "v0.24.0", "assets": [
+			{"name": "bat-v0.24.0-x86_64-unknown-linux-gnu.tar.gz.sha256", "browser_download_url": "https://dl/sha"},
+			{"name": "bat_0.24.0_amd64.deb", "browser_download_url": "https://dl/deb"},
+			{"name": "bat-v0.24.0-aarch64-unknown-linux-gnu.tar.gz", "browser_download_url": "https://dl/v0.24.0/bat-v0.24.0-aarch64-unknown-linux-gnu.tar.gz"},
+			{"name": "bat-v0.24.0-x86_64-unknown-linux-gnu.tar.gz", "browser_download_url": "https://dl/v0.24.0/bat-v0.24.0-x86_64-unknown-linux-gnu.tar.gz"},
+			{"name": "bat-v0.24.0-x86_64-apple-darwin.tar.gz", "browser_download_url": "https://dl/v0.24.0/bat-v0.24.0-x86_64-apple-darwin.tar.gz"},
+			{"name": "bat-v0.24.0-x86_64-pc-windows-msvc.zip", "browser_download_url": "https://dl/v0.24.0/bat-v0.24.0-x86_64-pc-windows-msvc.zip"}
+		]}`))
+	})
+	mux.HandleFunc("/formula/bat.json", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"name": "bat"}`))
+	})
+	mux.HandleFunc("/repos/someone/tool", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"name": "Tool", "description": "", "homepage": "https://tool.dev", "html_url": "https://github.com/someone/tool"}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	client := &GitHubClient{APIURL: srv.URL, BrewURL: srv.URL}
+
+	got, err := client.ScaffoldEntry(context.Background(), "https://github.com/sharkdp/bat", "")
+	if err != nil {
+		t.Fatalf("ScaffoldEntry error: %v", err)
+	}
+	want := `bat:
+  _name: bat
+  _desc: A cat(1) clone with wings.
+  _github: https://github.com/sharkdp/bat
+  _bin: bat
+  brew: bat
+  _version: 0.24.0
+  binary:linux: https://dl/v{version}/bat-v{version}-x86_64-unknown-linux-gnu.tar.gz
+  binary:darwin: https://dl/v{version}/bat-v{version}-x86_64-apple-darwin.tar.gz
+  binary:windows: https://dl/v{version}/bat-v{version}-x86_64-pc-windows-msvc.zip
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	var m Manifest
+	if err := yaml.Unmarshal([]byte(got), &m); err != nil || len(m["bat"].BinaryLinux) != 1 {
+		t.Errorf("snippet does not parse as a manifest: %v", err)
+	}
+
+	// Without releases or a formula, the snippet asks for an installer
+	got, err = client.ScaffoldEntry(context.Background(), "someone/tool", "")
+	if err != nil {
+		t.Fatalf("ScaffoldEntry error: %v", err)
+	}
+	if !strings.HasPrefix(got, "tool:\n  _name: Tool\n") || !strings.Contains(got, "_home: https://tool.dev") || !strings.Contains(got, "_bin: tool # TODO add an installer") {
+		t.Errorf("unexpected snippet:\n%s", got)
+	}
+
+	if _, err := client.ScaffoldEntry(context.Background(), "someone/missing", ""); err == nil {
+		t.Error("expected an error for a missing repository")
+	}
+}
@@ -0,0 +1,202 @@
+// Package importer converts existing dependency lists, such as a Brewfile or the output
+// of dpkg --get-selections, into manifest entries or a selection set of manifest keys.
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"a-la-carte/internal/app"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Package is one package of an imported dependency list.
+//
+// # Fields
+//   - Installer: The install method it came from, e.g. brew, cask, apt or winget
+//   - Name:      The package as the installer knows it, e.g. ripgrep or 497799835 for mas
+//   - Title:     A display name if the list has one, e.g. Xcode for a mas app
+type Package struct {
+	Installer string
+	Name      string
+	Title     string
+}
+
+// Parser reads the packages of a dependency list.
+type Parser func(r io.Reader) ([]Package, error)
+
+// parsers are the supported list formats, keyed by the name used on the command line.
+var parsers = map[string]Parser{
+	"brewfile": ParseBrewfile,
+	"apt":      ParseAptList,
+	"winget":   ParseWingetExport,
+}
+
+// Formats returns the supported list formats, sorted.
+func Formats() []string {
+	names := make([]string, 0, len(parsers))
+	for name := range parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse reads a dependency list in the given format.
+//
+// # Returns
+//   - []Package: The packages in list order, without duplicates
+//   - error:     If the format is unknown or the list cannot be parsed
+func Parse(format string, r io.Reader) ([]Package, error) {
+	parse, ok := parsers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown import format %q (available: %s)", format, strings.Join(Formats(), ", "))
+	}
+	pkgs, err := parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s list: %w", format, err)
+	}
+	var unique []Package
+	for _, pkg := range pkgs {
+		if !slices.Contains(unique, pkg) {
+			unique = append(unique, pkg)
+		}
+	}
+	return unique, nil
+}
+
+// Result is the outcome of matching imported packages against a manifest.
+//
+// # Fields
+//   - Keys:    Manifest keys that install one of the packages, sorted (a selection set)
+//   - Missing: Packages no manifest entry installs, in list order
+type Result struct {
+	Keys    []string
+	Missing []Package
+}
+
+// Match finds the manifest entries that install the imported packages. A package
+// matches an entry that lists it for the same installer; packages of installers the
+// manifest has no key for (e.g. winget) match an entry by key instead.
+func Match(manifest app.Manifest, pkgs []Package) Result {
+	var result Result
+	index := app.NewManifestIndex(manifest)
+	for _, pkg := range pkgs {
+		key, ok := findEntry(index, pkg)
+		if !ok {
+			result.Missing = append(result.Missing, pkg)
+			continue
+		}
+		if !slices.Contains(result.Keys, key) {
+			result.Keys = append(result.Keys, key)
+		}
+	}
+	sort.Strings(result.Keys)
+	return result
+}
+
+// findEntry returns the manifest key that installs pkg, preferring keys in sorted order
+// so the result does not depend on map iteration.
+func findEntry(index *app.ManifestIndex, pkg Package) (string, bool) {
+	keys := index.Package(pkg.Installer, pkg.Name)
+	if !app.IsInstallerKey(pkg.Installer) {
+		keys = index.Bare(EntryKey(pkg))
+	}
+	if len(keys) == 0 {
+		return "", false
+	}
+	return keys[0], true
+}
+
+// EntryKey returns the manifest key suggested for a package: its title or name,
+// lowercased, without a tap prefix (brew) or publisher (winget).
+//
+// # Example
+//
+//	EntryKey(Package{Installer: "brew", Name: "hashicorp/tap/terraform"}) // "terraform"
+//	EntryKey(Package{Installer: "winget", Name: "BurntSushi.ripgrep.MSVC"}) // "ripgrep"
+func EntryKey(pkg Package) string {
+	name := pkg.Name
+	switch {
+	case pkg.Title != "":
+		name = pkg.Title
+	case pkg.Installer == "winget":
+		if parts := strings.Split(name, "."); len(parts) > 1 {
+			name = parts[1]
+		}
+	default:
+		name = name[strings.LastIndex(name, "/")+1:]
+	}
+	return strings.ToLower(strings.Join(strings.Fields(name), "-"))
+}
+
+// Entries renders manifest entries for packages, one per suggested key; packages that
+// share a key (e.g. a brew formula and an apt package) become one entry. Packages of
+// installers the manifest has no key for are left as TODO comments.
+//
+// # Returns
+//   - string: The YAML entries, ready to append to a manifest
+//   - error:  If the entries cannot be encoded
+func Entries(pkgs []Package) (string, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	entries := make(map[string]*yaml.Node)
+	for _, pkg := range pkgs {
+		key := EntryKey(pkg)
+		entry, ok := entries[key]
+		if !ok {
+			title := pkg.Title
+			if title == "" {
+				title = pkg.Name[strings.LastIndex(pkg.Name, "/")+1:]
+			}
+			entry = &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{scalar("_name"), scalar(title)}}
+			entries[key] = entry
+			root.Content = append(root.Content, scalar(key), entry)
+		}
+		if !app.IsInstallerKey(pkg.Installer) {
+			entry.Content[len(entry.Content)-1].LineComment = fmt.Sprintf("TODO add an installer for %s package %s", pkg.Installer, pkg.Name)
+			continue
+		}
+		entry.Content = append(entry.Content, scalar(pkg.Installer), scalar(pkg.Name))
+	}
+	if len(root.Content) == 0 {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Profile renders keys as a profiles section of the config file, so an imported list
+// can be used as a named selection set.
+//
+// # Example
+//
+//	Profile("laptop", []string{"bat", "fd"}) // "profiles:\n  laptop:\n    - bat\n    - fd\n"
+func Profile(name string, keys []string) (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string]map[string][]string{"profiles": {name: keys}}); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
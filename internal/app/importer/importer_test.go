@@ -0,0 +1,86 @@
+package importer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		format string
+		input  string
+		want   []Package
+	}{
+		{"brewfile", `tap "homebrew/bundle"
+brew "ripgrep"
+brew "hashicorp/tap/terraform", args: ["HEAD"]
+cask "firefox"
+mas "Xcode", id: 497799835
+vscode "golang.go"
+brew "ripgrep"
+`, []Package{{"brew", "ripgrep", ""}, {"brew", "hashicorp/tap/terraform", ""}, {"cask", "firefox", ""}, {"mas", "497799835", "Xcode"}}},
+		{"apt", "git\t\t\tinstall\nlibc6:amd64\t\tinstall\nold-pkg\t\t\tdeinstall\n", []Package{{"apt", "git", ""}, {"apt", "libc6", ""}}},
+		{"apt", "Listing... Done\nripgrep/jammy,now 13.0.0-2 amd64 [installed]\n", []Package{{"apt", "ripgrep", ""}}},
+		{"winget", `{"Sources": [{"Packages": [{"PackageIdentifier": "BurntSushi.ripgrep.MSVC"}, {"PackageIdentifier": "Git.Git"}]}]}`, []Package{{"winget", "BurntSushi.ripgrep.MSVC", ""}, {"winget", "Git.Git", ""}}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.format, strings.NewReader(tt.input))
+		if err != nil {
+			t.Fatalf("%s: Parse error: %v", tt.format, err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.format, got, tt.want)
+		}
+	}
+	if _, err := Parse("gemfile", strings.NewReader("")); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if _, err := Parse("winget", strings.NewReader("not json")); err == nil {
+		t.Error("expected an error for an invalid winget export")
+	}
+}
+
+func TestMatchAndEntries(t *testing.T) {
+	manifest := app.Manifest{
+		"ripgrep": {Brew: app.StringOrSlice{"ripgrep"}, Apt: app.StringOrSlice{"ripgrep"}},
+		"git":     {Apt: app.StringOrSlice{"git"}},
+	}
+	pkgs := []Package{
+		{"brew", "ripgrep", ""},
+		{"winget", "Git.Git", ""},
+		{"brew", "hashicorp/tap/terraform", ""},
+		{"cask", "firefox", ""},
+		{"apt", "firefox", ""},
+		{"winget", "Microsoft.PowerToys", ""},
+	}
+	result := Match(manifest, pkgs)
+	if !slices.Equal(result.Keys, []string{"git", "ripgrep"}) || len(result.Missing) != 4 {
+		t.Fatalf("unexpected match %+v", result)
+	}
+
+	got, err := Entries(result.Missing)
+	if err != nil {
+		t.Fatalf("Entries error: %v", err)
+	}
+	want := `terraform:
+  _name: terraform
+  brew: hashicorp/tap/terraform
+firefox:
+  _name: firefox
+  cask: firefox
+  apt: firefox
+powertoys:
+  _name: Microsoft.PowerToys # TODO add an installer for winget package Microsoft.PowerToys
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	profile, err := Profile("laptop", result.Keys)
+	if err != nil || profile != "profiles:\n  laptop:\n    - git\n    - ripgrep\n" {
+		t.Errorf("unexpected profile %q, %v", profile, err)
+	}
+}
@@ -0,0 +1,88 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// brewfileLine matches a Brewfile entry: the type, its quoted name, and the options,
+// e.g. `mas "Xcode", id: 497799835`.
+var brewfileLine = regexp.MustCompile(`^(brew|cask|mas)\s+["']([^"']+)["']\s*(.*)$`)
+
+// masID matches the App Store id option of a mas entry.
+var masID = regexp.MustCompile(`\bid:\s*(\d+)`)
+
+// ParseBrewfile reads the formulae, casks and Mac App Store apps of a Brewfile, as
+// written by `brew bundle dump`. Taps and other entry types are skipped.
+func ParseBrewfile(r io.Reader) ([]Package, error) {
+	var pkgs []Package
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m := brewfileLine.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m == nil {
+			continue
+		}
+		pkg := Package{Installer: m[1], Name: m[2]}
+		if pkg.Installer == "mas" {
+			id := masID.FindStringSubmatch(m[3])
+			if id == nil {
+				continue
+			}
+			pkg.Name, pkg.Title = id[1], m[2]
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, scanner.Err()
+}
+
+// ParseAptList reads apt packages from the output of `dpkg --get-selections`, `apt list
+// --installed` or `apt-mark showmanual`. Architecture suffixes such as :amd64 are
+// dropped, and packages not selected for install are skipped.
+func ParseAptList(r io.Reader) ([]Package, error) {
+	var pkgs []Package
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || strings.HasSuffix(fields[0], "...") {
+			continue
+		}
+		// dpkg --get-selections: "<package> <state>"
+		if len(fields) == 2 && fields[1] != "install" {
+			continue
+		}
+		// apt list --installed: "<package>/<suites> <version> <arch> [installed]"
+		name, _, _ := strings.Cut(fields[0], "/")
+		name, _, _ = strings.Cut(name, ":")
+		pkgs = append(pkgs, Package{Installer: "apt", Name: name})
+	}
+	return pkgs, scanner.Err()
+}
+
+// wingetExport is the part of a `winget export` file ParseWingetExport uses.
+type wingetExport struct {
+	Sources []struct {
+		Packages []struct {
+			PackageIdentifier string `json:"PackageIdentifier"`
+		} `json:"Packages"`
+	} `json:"Sources"`
+}
+
+// ParseWingetExport reads the package identifiers of a `winget export` JSON file.
+func ParseWingetExport(r io.Reader) ([]Package, error) {
+	var export wingetExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, err
+	}
+	var pkgs []Package
+	for _, source := range export.Sources {
+		for _, p := range source.Packages {
+			if p.PackageIdentifier != "" {
+				pkgs = append(pkgs, Package{Installer: "winget", Name: p.PackageIdentifier})
+			}
+		}
+	}
+	return pkgs, nil
+}
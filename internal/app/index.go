@@ -0,0 +1,150 @@
+package app
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ManifestIndex answers the lookups the picker and the provisioner make over and over
+// (keys by group, executable, install method, alias and _provides name, and search
+// matches) from maps built once, instead of scanning the manifest each time. An index
+// is a snapshot: build a new one when the manifest changes.
+//
+// # Example
+//
+//	ix := NewManifestIndex(m)
+//	ix.Group("dev")                  // sorted keys of the entries in group dev
+//	ix.Resolve("ripgrep", "", nil)   // "rg", true when rg lists ripgrep in _aliases
+//	ix.Matches("rg", "grep")         // whether "grep" is in rg's key, name, description or aliases
+type ManifestIndex struct {
+	manifest   Manifest
+	keys       []string
+	bare       map[string][]string
+	groups     map[string][]string
+	bins       map[string][]string
+	installers map[string][]string
+	packages   map[string]map[string][]string
+	aliases    map[string][]string
+	provides   map[string][]string
+	corpus     map[string]string
+}
+
+// NewManifestIndex indexes manifest m. The lists it returns are sorted by key.
+func NewManifestIndex(m Manifest) *ManifestIndex {
+	ix := &ManifestIndex{
+		manifest:   m,
+		keys:       make([]string, 0, len(m)),
+		bare:       make(map[string][]string),
+		groups:     make(map[string][]string),
+		bins:       make(map[string][]string),
+		installers: make(map[string][]string),
+		packages:   make(map[string]map[string][]string),
+		aliases:    make(map[string][]string),
+		provides:   make(map[string][]string),
+		corpus:     make(map[string]string, len(m)),
+	}
+	for key := range m {
+		ix.keys = append(ix.keys, key)
+	}
+	sort.Strings(ix.keys)
+	for _, key := range ix.keys {
+		entry := m[key]
+		_, bare := SplitKey(key)
+		ix.bare[bare] = append(ix.bare[bare], key)
+		indexKey(ix.groups, entry.Groups, key)
+		indexKey(ix.bins, entry.Bin, key)
+		indexKey(ix.aliases, entry.Aliases, key)
+		indexKey(ix.provides, entry.Provides, key)
+		for field, packages := range entry.definitions() {
+			if field == "_bin" || len(packages) == 0 {
+				continue
+			}
+			installer, _, _ := strings.Cut(field, ":")
+			indexKey(ix.installers, []string{installer}, key)
+			if ix.packages[field] == nil {
+				ix.packages[field] = make(map[string][]string)
+			}
+			indexKey(ix.packages[field], packages, key)
+		}
+		ix.corpus[key] = strings.ToLower(strings.Join(append([]string{key, entry.Name, entry.Desc}, entry.Aliases...), "\n"))
+	}
+	return ix
+}
+
+// indexKey appends key to the lists of names in index, once per name.
+func indexKey(index map[string][]string, names []string, key string) {
+	for _, name := range names {
+		if list := index[name]; len(list) == 0 || list[len(list)-1] != key {
+			index[name] = append(list, key)
+		}
+	}
+}
+
+// Manifest returns the indexed manifest.
+func (ix *ManifestIndex) Manifest() Manifest {
+	return ix.manifest
+}
+
+// Keys returns every key of the manifest, sorted.
+func (ix *ManifestIndex) Keys() []string {
+	return ix.keys
+}
+
+// Bare returns the keys whose bare key is name, e.g. work/rg and personal/rg for rg.
+func (ix *ManifestIndex) Bare(name string) []string {
+	return ix.bare[name]
+}
+
+// Group returns the keys of the entries in group name.
+func (ix *ManifestIndex) Group(name string) []string {
+	return ix.groups[name]
+}
+
+// Bin returns the keys of the entries that install executable name (_bin).
+func (ix *ManifestIndex) Bin(name string) []string {
+	return ix.bins[name]
+}
+
+// Installer returns the keys of the entries with packages for install method name, e.g.
+// "apt", including those that only list them under qualified keys such as apt:debian.
+// The binary:* keys count as the method "binary".
+func (ix *ManifestIndex) Installer(name string) []string {
+	return ix.installers[name]
+}
+
+// Package returns the keys of the entries that list package pkg under installer key
+// field, e.g. "brew" or "apt:debian".
+func (ix *ManifestIndex) Package(field, pkg string) []string {
+	return ix.packages[field][pkg]
+}
+
+// Providers returns the keys of the entries that list name in _provides, like
+// Manifest.Providers.
+func (ix *ManifestIndex) Providers(name string) []string {
+	return ix.provides[name]
+}
+
+// Resolve finds the manifest key a reference points to, like Manifest.Resolve.
+func (ix *ManifestIndex) Resolve(key, namespace string, priority []string) (string, bool) {
+	return ix.manifest.resolve(key, namespace, priority, ix.aliased)
+}
+
+// aliased returns the first key of manifest ns that lists alias in _aliases, or "".
+func (ix *ManifestIndex) aliased(ns, alias string) string {
+	i := slices.IndexFunc(ix.aliases[alias], func(key string) bool {
+		keyNS, _ := SplitKey(key)
+		return keyNS == ns
+	})
+	if i < 0 {
+		return ""
+	}
+	return ix.aliases[alias][i]
+}
+
+// Matches reports whether the lower-case query occurs in the key, name, description or
+// one of the aliases of the entry key, ignoring case, as the picker's search does.
+func (ix *ManifestIndex) Matches(key, query string) bool {
+	corpus, ok := ix.corpus[key]
+	return ok && strings.Contains(corpus, query)
+}
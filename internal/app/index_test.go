@@ -0,0 +1,70 @@
+package app
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestManifestIndex(t *testing.T) {
+	m := Manifest{
+		"work/ripgrep":  {Name: "ripgrep", Desc: "Fast GREP", Groups: StringOrSlice{"cli", "search"}, Bin: StringOrSlice{"rg"}, Apt: StringOrSlice{"ripgrep"}},
+		"personal/rg":   {Groups: StringOrSlice{"cli"}, Bin: StringOrSlice{"rg"}, Aliases: StringOrSlice{"grep-rs"}, Installers: map[string]StringOrSlice{"apt:debian": {"ripgrep"}}},
+		"personal/nvim": {Name: "Neovim", Bin: StringOrSlice{"nvim"}, Provides: StringOrSlice{"editor"}, BinaryLinux: StringOrSlice{"https://example.com/nvim.tar.gz"}},
+		"work/vim":      {Groups: StringOrSlice{"cli", "cli"}, Provides: StringOrSlice{"editor"}, Brew: StringOrSlice{"vim"}},
+	}
+	ix := NewManifestIndex(m)
+
+	lists := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"Keys()", ix.Keys(), []string{"personal/nvim", "personal/rg", "work/ripgrep", "work/vim"}},
+		{"Bare(rg)", ix.Bare("rg"), []string{"personal/rg"}},
+		{"Group(cli)", ix.Group("cli"), []string{"personal/rg", "work/ripgrep", "work/vim"}},
+		{"Group(search)", ix.Group("search"), []string{"work/ripgrep"}},
+		{"Group(none)", ix.Group("none"), nil},
+		{"Bin(rg)", ix.Bin("rg"), []string{"personal/rg", "work/ripgrep"}},
+		{"Installer(apt)", ix.Installer("apt"), []string{"personal/rg", "work/ripgrep"}},
+		{"Installer(binary)", ix.Installer("binary"), []string{"personal/nvim"}},
+		{"Installer(brew)", ix.Installer("brew"), []string{"work/vim"}},
+		{"Package(apt, ripgrep)", ix.Package("apt", "ripgrep"), []string{"work/ripgrep"}},
+		{"Package(apt:debian, ripgrep)", ix.Package("apt:debian", "ripgrep"), []string{"personal/rg"}},
+		{"Package(dnf, ripgrep)", ix.Package("dnf", "ripgrep"), nil},
+		{"Providers(editor)", ix.Providers("editor"), []string{"personal/nvim", "work/vim"}},
+	}
+	for _, tt := range lists {
+		if !slices.Equal(tt.got, tt.want) {
+			t.Errorf("%s = %v; want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	priority := []string{"work", "personal"}
+	for _, key := range []string{"ripgrep", "rg", "grep-rs", "personal/grep-rs", "work/grep-rs", "nvim", "missing"} {
+		wantKey, wantOK := m.Resolve(key, "", priority)
+		if got, ok := ix.Resolve(key, "", priority); got != wantKey || ok != wantOK {
+			t.Errorf("Resolve(%q) = %q, %v; want %q, %v like Manifest.Resolve", key, got, ok, wantKey, wantOK)
+		}
+	}
+
+	matches := []struct {
+		key, query string
+		want       bool
+	}{
+		{"work/ripgrep", "grep", true},
+		{"work/ripgrep", "fast grep", true},
+		{"personal/rg", "grep-rs", true},
+		{"personal/nvim", "neovim", true},
+		{"personal/nvim", "personal/", true},
+		{"work/vim", "neovim", false},
+		{"missing", "", false},
+	}
+	for _, tt := range matches {
+		if got := ix.Matches(tt.key, tt.query); got != tt.want {
+			t.Errorf("Matches(%q, %q) = %v; want %v", tt.key, tt.query, got, tt.want)
+		}
+	}
+	if ix.Manifest() == nil {
+		t.Error("Manifest() = nil")
+	}
+}
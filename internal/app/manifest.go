@@ -1,8 +1,10 @@
 package app
 
 import (
-	"log"
-	"os"
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -53,55 +55,342 @@ func (s *StringOrSlice) UnmarshalYAML(value *yaml.Node) error {
 //
 // # Fields
 //   - Bin, Desc, Docs, Github, Home, Name, Short, Groups: metadata fields
+//   - Tags: free-form labels independent of groups (e.g. cli, rust), used for filtering
+//   - Size: approximate download/install size in MB (0 if unknown)
+//   - Timeout: maximum time a single install may take, as a Go duration (e.g. "15m"; empty for no limit)
+//   - Version, Sha256: version and checksum of binary:* downloads
+//   - Sandbox: restrictions on the entry's scripts when scripts run in a sandbox
+//   - AptRepo, AptKey, DnfRepo: third-party repositories (and signing key) added before installing with apt or dnf
+//   - Service: services enabled and started after installing, e.g. docker (see Services)
 //   - Brew, Apt, Pacman, etc.: installation methods for various package managers
-//   - Deps: list of dependency keys
+//   - Deps: list of dependency keys; an entry with only deps is a meta-package (see IsMeta)
+//   - DepsAny: groups of alternative dependency keys, e.g. [[docker, podman]]; one key of each group is installed
 //   - App: GUI app identifier (if present)
+//   - GUI: Overrides whether the entry is a GUI app skipped on headless systems (defaults to having an App)
 //   - Script: Script(s) to run as part of provisioning
-//   - Lazy: If true, only install with --lazy flag
+//   - PreInstall, PostInstall: commands or scripts run before and after the entry is installed (e.g. adding a repo, enabling a service)
+//   - Lazy: If true, only install with --lazy flag (the optional tier)
+//   - Tier: The tier of the entry: core (the default), extra or optional
+//   - When: A condition on the system the entry applies to, e.g. `os == "linux" && arch == "arm64"`
+//   - Conflicts: Keys, or names other entries provide, that cannot be installed together with the entry (see Manifest.Conflict)
+//   - Provides: Names the entry provides; deps and _conflicts can refer to any entry that provides a name
+//   - Emoji: The icon the picker shows for the entry, overriding the emoji matched from its name and description
+//   - Aliases: Other names of the entry, e.g. ripgrep for the entry keyed rg; references to an alias resolve to the entry (see Manifest.Resolve)
+//   - Installers: Installer keys with OS, distro or arch qualifiers, which have no field of their own, e.g.
+//     apt:debian:x64 or binary:linux:arm64, with their packages (see UnmarshalYAML)
+//   - Raw: Every key of the entry as written in the manifest, including advanced installer keys such as
+//     apt:debian:x64 that have no field; set by LoadManifest and ParseManifest, nil for entries built in code
+//
+// The doc tags describe the keys of manifest entries for the built-in documentation (see
+// schema.Fields), and the schema tags constrain them in the JSON Schema of the manifest
+// (see schema.JSONSchema).
 //
 // # Example
 //
 //	entry := SoftwareEntry{Name: "bat", Brew: StringOrSlice{"bat"}}
 type SoftwareEntry struct {
-	Bin           StringOrSlice `yaml:"_bin"`
-	Desc          string        `yaml:"_desc"`
-	Docs          string        `yaml:"_docs"`
-	Github        string        `yaml:"_github"`
-	Home          string        `yaml:"_home"`
-	Name          string        `yaml:"_name"`
-	Short         string        `yaml:"_short"`
-	Groups        StringOrSlice `yaml:"_groups"`
-	Brew          StringOrSlice `yaml:"brew"`
-	Apt           StringOrSlice `yaml:"apt"`
-	Pacman        StringOrSlice `yaml:"pacman"`
-	Choco         StringOrSlice `yaml:"choco"`
-	Go            StringOrSlice `yaml:"go"`
-	Snap          StringOrSlice `yaml:"snap"`
-	Port          StringOrSlice `yaml:"port"`
-	Scoop         StringOrSlice `yaml:"scoop"`
-	Yay           StringOrSlice `yaml:"yay"`
-	Apk           StringOrSlice `yaml:"apk"`
-	Dnf           StringOrSlice `yaml:"dnf"`
-	Pkg           StringOrSlice `yaml:"pkg"`
-	Cask          StringOrSlice `yaml:"cask"`
-	Flatpak       StringOrSlice `yaml:"flatpak"`
-	Mas           StringOrSlice `yaml:"mas"`
-	Nix           StringOrSlice `yaml:"nix"`
-	PkgTermux     StringOrSlice `yaml:"pkg-termux"`
-	Emerge        StringOrSlice `yaml:"emerge"`
-	NixEnv        StringOrSlice `yaml:"nix-env"`
-	BinaryDarwin  StringOrSlice `yaml:"binary:darwin"`
-	BinaryLinux   StringOrSlice `yaml:"binary:linux"`
-	BinaryWindows StringOrSlice `yaml:"binary:windows"`
-	Xbps          StringOrSlice `yaml:"xbps"`
-	Zypper        StringOrSlice `yaml:"zypper"`
-	Cargo         StringOrSlice `yaml:"cargo"`
-	Pipx          StringOrSlice `yaml:"pipx"`
-	Deps          StringOrSlice `yaml:"deps"`
-	App           string        `yaml:"_app"`   // GUI app identifier (if present)
-	Script        StringOrSlice `yaml:"script"` // Script(s) to run as part of provisioning
-	Lazy          bool          `yaml:"lazy"`   // If true, only install with --lazy flag
+	Bin           StringOrSlice `yaml:"_bin" doc:"Executables the entry installs; an entry whose executables are on PATH counts as installed"`
+	Desc          string        `yaml:"_desc" doc:"Description shown in the details pane"`
+	Docs          string        `yaml:"_docs" doc:"Documentation URL" schema:"format=uri"`
+	Github        string        `yaml:"_github" doc:"GitHub repository URL" schema:"format=uri"`
+	Home          string        `yaml:"_home" doc:"Homepage URL" schema:"format=uri"`
+	Name          string        `yaml:"_name" doc:"Display name"`
+	Short         string        `yaml:"_short" doc:"Short description"`
+	Groups        StringOrSlice `yaml:"_groups" doc:"Groups the entry belongs to, e.g. dev"`
+	Tags          StringOrSlice `yaml:"_tags" doc:"Free-form labels for filtering, e.g. [cli, rust]"`
+	Size          int           `yaml:"_size" doc:"Approximate download and install size in MB" schema:"minimum=0"`
+	Timeout       string        `yaml:"_timeout" doc:"Maximum duration of a single install, e.g. 15m"`
+	Version       string        `yaml:"_version" doc:"Version substituted for {version} in binary:* URLs"`
+	Sha256        string        `yaml:"_sha256" doc:"Expected SHA-256 of the binary:* download"`
+	Sandbox       ScriptSandbox `yaml:"_sandbox" doc:"Restrictions on the entry's scripts when scripts run sandboxed"`
+	AptRepo       string        `yaml:"_apt_repo" doc:"apt sources line added before installing with apt"`
+	AptKey        string        `yaml:"_apt_key" doc:"URL of the GPG key that signs _apt_repo" schema:"format=uri"`
+	DnfRepo       string        `yaml:"_dnf_repo" doc:"URL of a .repo file added before installing with dnf or yum" schema:"format=uri"`
+	Service       Services      `yaml:"_service" doc:"Services enabled and started after installing (systemd or launchd): names, or maps with name, sudo and pkg"`
+	Brew          StringOrSlice `yaml:"brew" doc:"Homebrew formulae"`
+	Apt           StringOrSlice `yaml:"apt" doc:"apt packages"`
+	Pacman        StringOrSlice `yaml:"pacman" doc:"pacman packages"`
+	Choco         StringOrSlice `yaml:"choco" doc:"Chocolatey packages"`
+	Go            StringOrSlice `yaml:"go" doc:"Go packages, installed with go install"`
+	Snap          StringOrSlice `yaml:"snap" doc:"Snap packages"`
+	Port          StringOrSlice `yaml:"port" doc:"MacPorts ports"`
+	Scoop         StringOrSlice `yaml:"scoop" doc:"Scoop apps"`
+	Yay           StringOrSlice `yaml:"yay" doc:"AUR packages, installed with yay"`
+	Apk           StringOrSlice `yaml:"apk" doc:"Alpine packages"`
+	Dnf           StringOrSlice `yaml:"dnf" doc:"dnf packages"`
+	Pkg           StringOrSlice `yaml:"pkg" doc:"FreeBSD packages"`
+	Cask          StringOrSlice `yaml:"cask" doc:"Homebrew casks"`
+	Flatpak       StringOrSlice `yaml:"flatpak" doc:"Flatpak app IDs"`
+	Mas           StringOrSlice `yaml:"mas" doc:"Mac App Store app IDs"`
+	Nix           StringOrSlice `yaml:"nix" doc:"Nix packages"`
+	PkgTermux     StringOrSlice `yaml:"pkg-termux" doc:"Termux packages"`
+	Emerge        StringOrSlice `yaml:"emerge" doc:"Gentoo packages"`
+	NixEnv        StringOrSlice `yaml:"nix-env" doc:"Nix packages, installed with nix-env"`
+	BinaryDarwin  StringOrSlice `yaml:"binary:darwin" doc:"URL of a binary or archive to install on macOS"`
+	BinaryLinux   StringOrSlice `yaml:"binary:linux" doc:"URL of a binary or archive to install on Linux"`
+	BinaryWindows StringOrSlice `yaml:"binary:windows" doc:"URL of a binary or archive to install on Windows"`
+	Xbps          StringOrSlice `yaml:"xbps" doc:"Void Linux packages"`
+	Zypper        StringOrSlice `yaml:"zypper" doc:"openSUSE packages"`
+	Cargo         StringOrSlice `yaml:"cargo" doc:"Rust crates, installed with cargo install"`
+	Pipx          StringOrSlice `yaml:"pipx" doc:"Python apps, installed with pipx"`
+	Deps          StringOrSlice `yaml:"deps" doc:"Keys of entries installed first; an entry with only deps is a meta-package"`
+	DepsAny       AnyOf         `yaml:"_deps_any" doc:"Groups of alternative dependencies, one of each installed, e.g. [[docker, podman]]"`
+	App           string        `yaml:"_app" doc:"GUI app identifier; entries with one are skipped on headless systems"`
+	GUI           *bool         `yaml:"_gui" doc:"Whether the entry is a GUI app (defaults to having an _app)"`
+	Script        StringOrSlice `yaml:"script" doc:"Scripts run to install the entry"`
+	PreInstall    StringOrSlice `yaml:"_preinstall" doc:"Commands run before the entry is installed"`
+	PostInstall   StringOrSlice `yaml:"_postinstall" doc:"Commands run after the entry is installed"`
+	Lazy          bool          `yaml:"lazy" doc:"Only install with --lazy (the optional tier)"`
+	Tier          string        `yaml:"_tier" doc:"Tier of the entry: core (the default), extra or optional" schema:"enum=core|extra|optional"`
+	When          string        `yaml:"_when" doc:"Condition the system must meet, e.g. os == \"linux\" && arch == \"arm64\""`
+	Conflicts     StringOrSlice `yaml:"_conflicts" doc:"Keys, or names other entries provide, that cannot be installed together with the entry"`
+	Provides      StringOrSlice `yaml:"_provides" doc:"Names deps and _conflicts can refer to the entry by, e.g. editor"`
+	Emoji         string        `yaml:"_emoji" doc:"Icon shown in the picker instead of one matched from the name and description"`
+	Aliases       StringOrSlice `yaml:"_aliases" doc:"Other names of the entry, e.g. ripgrep for rg; search, --only, profiles and deps find the entry by them"`
 	// Add more fields as needed
+
+	// Installers holds the qualified installer keys, e.g. apt:debian:x64 (see UnmarshalYAML)
+	Installers map[string]StringOrSlice `yaml:"-" json:"-"`
+	// Raw holds every key of the entry as written in the manifest (see ParseManifest)
+	Raw map[string]interface{} `yaml:"-" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface for SoftwareEntry. It decodes
+// the keys into their fields and collects the installer keys that have no field, such
+// as apt:debian:x64, into Installers, so the platform-specific variants of an entry are
+// not lost. Other keys without a field are ignored (see LoadManifestStrict).
+//
+// # Parameters
+//   - value: the YAML node to decode
+//
+// # Returns
+//   - error: if a key cannot be decoded into its field, or an installer key is not a
+//     string or a list of strings
+func (e *SoftwareEntry) UnmarshalYAML(value *yaml.Node) error {
+	type fields SoftwareEntry // without this method, so its fields are decoded as usual
+	if err := value.Decode((*fields)(e)); err != nil {
+		return err
+	}
+	if value.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		key := value.Content[i].Value
+		if _, ok := entryFields[key]; ok || !IsInstallerKey(key) {
+			continue
+		}
+		var packages StringOrSlice
+		if err := value.Content[i+1].Decode(&packages); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if e.Installers == nil {
+			e.Installers = make(map[string]StringOrSlice)
+		}
+		e.Installers[key] = packages
+	}
+	return nil
+}
+
+// entryFields are the types of the fields of SoftwareEntry by their YAML keys.
+var entryFields = yamlFields(reflect.TypeOf(SoftwareEntry{}))
+
+// AnyOf lists groups of alternative dependency keys; one key of each group satisfies the
+// dependency. A group may be a single key or a list.
+//
+// # Example
+//
+//	_deps_any: [[docker, podman], [curl, wget]]
+type AnyOf []StringOrSlice
+
+// Service is a service enabled and started after an entry is installed.
+//
+// # Fields
+//   - Name: The name of the service, e.g. sshd
+//   - Sudo: Whether the service manager runs with sudo; nil for its default (sudo for
+//     systemd and launchd, not for brew services)
+//   - Pkg:  The installer the entry must be installed with for the service to apply,
+//     e.g. apt when the service is named differently per distribution; empty for any
+type Service struct {
+	Name string `yaml:"name" doc:"Name of the service, e.g. sshd" schema:"required"`
+	Sudo *bool  `yaml:"sudo" doc:"Whether the service manager runs with sudo (default: sudo for systemd and launchd)"`
+	Pkg  string `yaml:"pkg" doc:"Installer the entry must be installed with for the service to apply, e.g. apt"`
+}
+
+// Services lists the services of an entry. Each may be a name or a map with the name
+// and the sudo and pkg options of Service; a single service need not be in a list.
+//
+// # Example
+//
+//	_service: docker
+//	_service: [{name: smbd, pkg: apt}, {name: smb, pkg: dnf}]
+type Services []Service
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface for Services.
+//
+// # Parameters
+//   - value: the YAML node to decode: a name, a map, or a list of either
+//
+// # Returns
+//   - error: if a service is neither a name nor a map, or has no name
+func (s *Services) UnmarshalYAML(value *yaml.Node) error {
+	items := []*yaml.Node{value}
+	if value.Kind == yaml.SequenceNode {
+		items = value.Content
+	}
+	services := make(Services, 0, len(items))
+	for _, item := range items {
+		var service Service
+		switch item.Kind {
+		case yaml.ScalarNode:
+			if err := item.Decode(&service.Name); err != nil {
+				return err
+			}
+		case yaml.MappingNode:
+			if err := item.Decode(&service); err != nil {
+				return err
+			}
+		default:
+			return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: a service must be a name or a map with name, sudo and pkg", item.Line)}}
+		}
+		if service.Name == "" {
+			return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: service without a name", item.Line)}}
+		}
+		services = append(services, service)
+	}
+	*s = services
+	return nil
+}
+
+// Names returns the names of the services.
+func (s Services) Names() []string {
+	names := make([]string, 0, len(s))
+	for _, service := range s {
+		names = append(names, service.Name)
+	}
+	return names
+}
+
+// Tiers of manifest entries, from the essentials to packages only installed on request.
+const (
+	TierCore     = "core"
+	TierExtra    = "extra"
+	TierOptional = "optional"
+)
+
+// Tiers are the known tiers, in order.
+var Tiers = []string{TierCore, TierExtra, TierOptional}
+
+// EffectiveTier returns the tier of the entry: _tier if it is set, else optional for
+// lazy entries and core for the others.
+func (e *SoftwareEntry) EffectiveTier() string {
+	switch {
+	case e.Tier != "":
+		return strings.ToLower(e.Tier)
+	case e.Lazy:
+		return TierOptional
+	default:
+		return TierCore
+	}
+}
+
+// InAnyTier reports whether the entry is in one of the tiers, ignoring case.
+//
+// # Example
+//
+//	entry := SoftwareEntry{Lazy: true}
+//	entry.InAnyTier([]string{"extra", "Optional"}) // true
+func (e *SoftwareEntry) InAnyTier(tiers []string) bool {
+	tier := e.EffectiveTier()
+	for _, want := range tiers {
+		if strings.EqualFold(tier, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsGUI reports whether the entry is a GUI app, which is skipped on headless systems:
+// _gui if it is set, else whether the entry has an _app. CLI tools that happen to
+// declare an app bundle set _gui: false.
+func (e *SoftwareEntry) IsGUI() bool {
+	if e.GUI != nil {
+		return *e.GUI
+	}
+	return e.App != ""
+}
+
+// IsMeta reports whether the entry is a meta-package: it only lists deps, with no install
+// method, script or app of its own, e.g. a "rust-dev-stack" that groups rustup, cargo-edit
+// and sccache. Installing a meta-package installs its deps.
+func (e *SoftwareEntry) IsMeta() bool {
+	if len(e.Deps) == 0 && len(e.DepsAny) == 0 || len(e.Script) > 0 || e.App != "" {
+		return false
+	}
+	v := reflect.ValueOf(e).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		tag := v.Type().Field(i).Tag.Get("yaml")
+		if strings.HasPrefix(tag, "_") || tag == "deps" {
+			continue
+		}
+		if packages, ok := v.Field(i).Interface().(StringOrSlice); ok && len(packages) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// HasAnyTag reports whether the entry has at least one of the tags, ignoring case.
+//
+// # Example
+//
+//	entry := SoftwareEntry{Tags: StringOrSlice{"cli", "rust"}}
+//	entry.HasAnyTag([]string{"Rust"}) // true
+func (e *SoftwareEntry) HasAnyTag(tags []string) bool {
+	for _, have := range e.Tags {
+		for _, want := range tags {
+			if strings.EqualFold(have, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Packages returns the packages the entry lists for an install method, e.g. "brew" or
+// "binary:linux", or nil if it has none.
+//
+// # Example
+//
+//	entry := SoftwareEntry{Brew: StringOrSlice{"bat"}}
+//	entry.Packages("brew") // ["bat"]
+func (e *SoftwareEntry) Packages(installer string) []string {
+	v := reflect.ValueOf(e).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).Tag.Get("yaml") != installer {
+			continue
+		}
+		if packages, ok := v.Field(i).Interface().(StringOrSlice); ok {
+			return packages
+		}
+	}
+	return nil
+}
+
+// ScriptSandbox declares how an entry's scripts are restricted when the provisioner
+// runs scripts in a sandbox. Without a sandbox the restrictions are not enforced.
+//
+// # Fields
+//   - NoNetwork:    Scripts run without network access
+//   - ReadOnlyHome: Scripts cannot write to the user's home directory
+//
+// # Example
+//
+//	_sandbox:
+//	  no_network: true
+//	  readonly_home: true
+type ScriptSandbox struct {
+	NoNetwork    bool `yaml:"no_network" doc:"Scripts run without network access"`
+	ReadOnlyHome bool `yaml:"readonly_home" doc:"Scripts cannot write to the home directory"`
 }
 
 // Manifest represents the full manifest mapping software names to their entries.
@@ -111,33 +400,59 @@ type SoftwareEntry struct {
 //	m := Manifest{"bat": SoftwareEntry{...}}
 type Manifest map[string]SoftwareEntry
 
-// LoadManifest loads a manifest from a YAML file at the given path.
+// LoadManifest loads a manifest from a YAML file at the given path, see ParseManifest.
+// A template (see ManifestTemplateExt) is rendered with chezmoi first; use
+// LoadManifests with ManifestSource.Render to render it without chezmoi.
 //
 // # Parameters
 //   - path: the path to the YAML manifest file
 //
 // # Returns
 //   - Manifest: the loaded manifest
-//   - error: if the file cannot be opened or decoded
+//   - error: if the file cannot be read, rendered or decoded
 //
 // # Example
 //
 //	m, err := LoadManifest("software.yml")
 func LoadManifest(path string) (Manifest, error) {
-	f, err := os.Open(path)
-	if err != nil {
+	return ManifestSource{Path: path}.load()
+}
+
+// ParseManifest decodes a manifest from YAML. The document is parsed once; each entry
+// is decoded from it into its fields and into Raw, so keys without a field, such as
+// apt:debian:x64, are kept for the provisioner.
+//
+// # Example
+//
+//	m, err := ParseManifest([]byte("bat:\n  apt:debian: bat\n"))
+//	m["bat"].Raw["apt:debian"] // "bat"
+func ParseManifest(data []byte) (Manifest, error) {
+	return decodeManifest(yaml.NewDecoder(bytes.NewReader(data)))
+}
+
+// decodeManifest decodes the next document of dec as a manifest, with the raw keys of
+// each entry.
+func decodeManifest(dec *yaml.Decoder) (Manifest, error) {
+	var root yaml.Node
+	if err := dec.Decode(&root); err != nil {
 		return nil, err
 	}
-	defer func() {
-		if err := f.Close(); err != nil {
-			log.Printf("Error closing file: %v", err)
-		}
-	}()
+	return decodeManifestNode(&root)
+}
 
+// decodeManifestNode decodes a parsed manifest document, with the raw keys of each entry.
+func decodeManifestNode(root *yaml.Node) (Manifest, error) {
 	var m Manifest
-	dec := yaml.NewDecoder(f)
-	if err := dec.Decode(&m); err != nil {
+	if err := root.Decode(&m); err != nil {
 		return nil, err
 	}
+	var raw map[string]map[string]interface{}
+	if err := root.Decode(&raw); err != nil {
+		return nil, err
+	}
+	for key, entry := range m {
+		entry.Raw = raw[key]
+		m[key] = entry
+	}
 	return m, nil
 }
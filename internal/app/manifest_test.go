@@ -2,7 +2,10 @@ package app
 
 import (
 	"os"
+	"strings"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 const sampleYAML = `testapp:
@@ -51,3 +54,145 @@ func TestLoadManifest(t *testing.T) {
 		t.Errorf("unexpected entry values: %+v", entry)
 	}
 }
+
+func TestParseManifestRaw(t *testing.T) {
+	m, err := ParseManifest([]byte("bat:\n  _name: bat\n  apt: bat\n  apt:debian:x64: batcat\n  _sandbox:\n    no_network: true\n"))
+	if err != nil {
+		t.Fatalf("ParseManifest failed: %v", err)
+	}
+	entry := m["bat"]
+	if entry.Name != "bat" || len(entry.Apt) != 1 || !entry.Sandbox.NoNetwork {
+		t.Errorf("expected the fields decoded, got %+v", entry)
+	}
+	if entry.Raw["apt:debian:x64"] != "batcat" || entry.Raw["_name"] != "bat" {
+		t.Errorf("expected every key in Raw, got %v", entry.Raw)
+	}
+	if sandbox, ok := entry.Raw["_sandbox"].(map[string]interface{}); !ok || sandbox["no_network"] != true {
+		t.Errorf("expected nested sections in Raw, got %v", entry.Raw["_sandbox"])
+	}
+
+	// Raw survives merging manifests under namespaces
+	path := writeManifest(t, t.TempDir(), "work.yml", "bat:\n  apt:debian: batcat\n")
+	merged, err := LoadManifests([]ManifestSource{{Name: "work", Path: path}})
+	if err != nil || merged["work/bat"].Raw["apt:debian"] != "batcat" {
+		t.Errorf("expected Raw in the merged manifest, got %v %v", merged, err)
+	}
+}
+
+func TestUnmarshalInstallers(t *testing.T) {
+	var entry SoftwareEntry
+	data := "apt: bat\napt:debian:x64: batcat\nbinary:linux: https://example.com/bat\nbinary:linux:arm64: [https://example.com/bat-arm64]\n_decs: typo\n"
+	if err := yaml.Unmarshal([]byte(data), &entry); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if len(entry.Apt) != 1 || len(entry.BinaryLinux) != 1 {
+		t.Errorf("expected the declared keys in their fields, got %+v", entry)
+	}
+	if len(entry.Installers) != 2 || entry.Installers["apt:debian:x64"][0] != "batcat" || entry.Installers["binary:linux:arm64"][0] != "https://example.com/bat-arm64" {
+		t.Errorf("expected only the qualified installer keys in Installers, got %v", entry.Installers)
+	}
+
+	if err := yaml.Unmarshal([]byte("apt:debian:\n  name: bat\n"), &entry); err == nil || !strings.Contains(err.Error(), "apt:debian") {
+		t.Errorf("expected an error naming the key of a malformed variant, got %v", err)
+	}
+}
+
+func TestUnmarshalServices(t *testing.T) {
+	var entry SoftwareEntry
+	data := "_service:\n  - name: smbd\n    sudo: true\n    pkg: apt\n  - smb\n"
+	if err := yaml.Unmarshal([]byte(data), &entry); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if len(entry.Service) != 2 || entry.Service[0].Pkg != "apt" || entry.Service[0].Sudo == nil || !*entry.Service[0].Sudo || entry.Service[1].Name != "smb" || entry.Service[1].Sudo != nil {
+		t.Errorf("expected a service map and a name, got %+v", entry.Service)
+	}
+	for _, single := range []string{"_service: docker\n", "_service:\n  name: docker\n"} {
+		var e SoftwareEntry
+		if err := yaml.Unmarshal([]byte(single), &e); err != nil || len(e.Service) != 1 || e.Service[0].Name != "docker" {
+			t.Errorf("expected a single service from %q, got %+v (%v)", single, e.Service, err)
+		}
+	}
+	if err := yaml.Unmarshal([]byte("_service:\n  - sudo: true\n"), &entry); err == nil || !strings.Contains(err.Error(), "without a name") {
+		t.Errorf("expected an error for a service without a name, got %v", err)
+	}
+}
+
+// TestLoadShippedManifest loads the manifest shipped in data/, which uses the map form of
+// _service.
+func TestLoadShippedManifest(t *testing.T) {
+	manifest, err := LoadManifest("../../data/package-metadata.yaml")
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if services := manifest["openssh-server"].Service; len(services) != 1 || services[0].Name != "sshd" || services[0].Sudo == nil || *services[0].Sudo {
+		t.Errorf("expected sshd without sudo, got %+v", services)
+	}
+	if services := manifest["samba"].Service; len(services) != 3 || services[1].Pkg != "dnf" {
+		t.Errorf("expected the services of samba by installer, got %+v", services)
+	}
+}
+
+func TestNewEntry(t *testing.T) {
+	for _, category := range EntryTemplateNames() {
+		text, err := NewEntry(category, "mytool", "MyTool")
+		if err != nil {
+			t.Fatalf("NewEntry(%s) failed: %v", category, err)
+		}
+		var manifest Manifest
+		if err := yaml.Unmarshal([]byte(text), &manifest); err != nil {
+			t.Fatalf("template %s is not valid YAML: %v", category, err)
+		}
+		if manifest["mytool"].Name != "MyTool" {
+			t.Errorf("template %s: expected _name MyTool, got %q", category, manifest["mytool"].Name)
+		}
+	}
+	if _, err := NewEntry("nope", "mytool", ""); err == nil {
+		t.Error("expected an error for an unknown template")
+	}
+}
+
+func TestEffectiveTier(t *testing.T) {
+	var manifest Manifest
+	data := "a: {brew: a}\nb: {brew: b, lazy: true}\nc: {brew: c, _tier: Extra}\nd: {brew: d, lazy: true, _tier: core}\n"
+	if err := yaml.Unmarshal([]byte(data), &manifest); err != nil {
+		t.Fatalf("yaml.Unmarshal failed: %v", err)
+	}
+	want := map[string]string{"a": TierCore, "b": TierOptional, "c": TierExtra, "d": TierCore}
+	for key, tier := range want {
+		entry := manifest[key]
+		if got := entry.EffectiveTier(); got != tier {
+			t.Errorf("%s: got tier %q, want %q", key, got, tier)
+		}
+		if !entry.InAnyTier([]string{tier}) {
+			t.Errorf("%s: expected to be in tier %s", key, tier)
+		}
+	}
+}
+
+func TestIsMeta(t *testing.T) {
+	var manifest Manifest
+	data := "stack: {_desc: Rust tools, deps: [rustup, sccache]}\nrustup: {brew: rustup}\ntool: {brew: tool, deps: rustup}\nsetup: {script: setup.sh, deps: rustup}\nempty: {_desc: nothing}\n"
+	if err := yaml.Unmarshal([]byte(data), &manifest); err != nil {
+		t.Fatalf("yaml.Unmarshal failed: %v", err)
+	}
+	for key, want := range map[string]bool{"stack": true, "rustup": false, "tool": false, "setup": false, "empty": false} {
+		entry := manifest[key]
+		if got := entry.IsMeta(); got != want {
+			t.Errorf("%s: IsMeta() = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestDepsAny(t *testing.T) {
+	var manifest Manifest
+	if err := yaml.Unmarshal([]byte("compose: {_deps_any: [[docker, podman], curl]}\n"), &manifest); err != nil {
+		t.Fatalf("yaml.Unmarshal failed: %v", err)
+	}
+	entry := manifest["compose"]
+	if len(entry.DepsAny) != 2 || len(entry.DepsAny[0]) != 2 || entry.DepsAny[1][0] != "curl" {
+		t.Errorf("unexpected _deps_any %v", entry.DepsAny)
+	}
+	if !entry.IsMeta() {
+		t.Error("expected an entry with only _deps_any to be a meta-package")
+	}
+}
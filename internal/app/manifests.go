@@ -0,0 +1,142 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// NamespaceSeparator separates the manifest name from the entry key in namespaced keys,
+// e.g. "work/ripgrep".
+const NamespaceSeparator = "/"
+
+// ManifestSource is a manifest file loaded under a name.
+//
+// # Fields
+//   - Name:   The namespace of the manifest's keys; empty for a single unnamed manifest
+//   - Path:   The path to the YAML manifest file
+//   - Strict: Reject keys entries do not declare (see LoadManifestStrict)
+//   - Render: Renders the file when it is a template and chezmoi is not installed;
+//     nil requires chezmoi for templates (see ManifestTemplateExt)
+type ManifestSource struct {
+	Name   string
+	Path   string
+	Strict bool
+	Render TemplateRenderer
+}
+
+// load loads the manifest file of the source, rendering it first if it is a template.
+func (src ManifestSource) load() (Manifest, error) {
+	data, err := readManifest(src.Path, src.Render)
+	if err != nil {
+		return nil, err
+	}
+	if src.Strict {
+		return parseManifestStrict(src.Path, data)
+	}
+	return ParseManifest(data)
+}
+
+// LoadManifests loads several manifests and merges them into one.
+// With a single unnamed source the keys are kept as they are; otherwise every key is
+// namespaced as "<name>/<key>", so entries with the same key do not collide.
+// Sources are listed in priority order, see Manifest.Resolve.
+//
+// # Returns
+//   - Manifest: the merged manifest
+//   - error: if a source is unnamed, named twice, or cannot be loaded
+func LoadManifests(sources []ManifestSource) (Manifest, error) {
+	if len(sources) == 1 && sources[0].Name == "" {
+		return sources[0].load()
+	}
+	merged := make(Manifest)
+	seen := make(map[string]bool)
+	for _, src := range sources {
+		if src.Name == "" || strings.Contains(src.Name, NamespaceSeparator) {
+			return nil, fmt.Errorf("invalid manifest name %q for %s: names must be non-empty and must not contain %q", src.Name, src.Path, NamespaceSeparator)
+		}
+		if seen[src.Name] {
+			return nil, fmt.Errorf("duplicate manifest name %q", src.Name)
+		}
+		seen[src.Name] = true
+		m, err := src.load()
+		if err != nil {
+			return nil, fmt.Errorf("loading manifest %s: %w", src.Name, err)
+		}
+		for key, entry := range m {
+			merged[src.Name+NamespaceSeparator+key] = entry
+		}
+	}
+	return merged, nil
+}
+
+// SplitKey splits a namespaced key into its manifest name and bare key.
+// Keys without a namespace return an empty name.
+//
+// # Example
+//
+//	SplitKey("work/ripgrep") // "work", "ripgrep"
+func SplitKey(key string) (namespace, bare string) {
+	if ns, rest, ok := strings.Cut(key, NamespaceSeparator); ok {
+		return ns, rest
+	}
+	return "", key
+}
+
+// Resolve finds the manifest key a reference points to. Keys present in the manifest
+// resolve to themselves. A bare key resolves first within namespace (the manifest of
+// the entry that refers to it, if any), then in the manifests of priority, in order.
+// A reference that is no key resolves the same way to the entry listing it in _aliases,
+// so "ripgrep" finds the entry keyed rg.
+//
+// # Returns
+//   - string: The resolved key
+//   - bool:   False if no manifest has the key or an entry with the alias
+//
+// # Example
+//
+//	m := Manifest{"rg": {Aliases: StringOrSlice{"ripgrep"}}}
+//	m.Resolve("ripgrep", "", nil) // "rg", true
+func (m Manifest) Resolve(key, namespace string, priority []string) (string, bool) {
+	return m.resolve(key, namespace, priority, m.aliased)
+}
+
+// resolve implements Resolve with aliased looking up the entry of a manifest with an
+// alias, by scanning the manifest or from a ManifestIndex.
+func (m Manifest) resolve(key, namespace string, priority []string, aliased func(ns, alias string) string) (string, bool) {
+	if _, ok := m[key]; ok {
+		return key, true
+	}
+	ns, bare := SplitKey(key)
+	candidates := []string{ns}
+	if ns == "" {
+		candidates = priority
+		if namespace != "" {
+			candidates = append([]string{namespace}, priority...)
+		}
+		for _, ns := range candidates {
+			if _, ok := m[ns+NamespaceSeparator+key]; ok {
+				return ns + NamespaceSeparator + key, true
+			}
+		}
+		candidates = append([]string{""}, candidates...)
+	}
+	for _, ns := range candidates {
+		if resolved := aliased(ns, bare); resolved != "" {
+			return resolved, true
+		}
+	}
+	return "", false
+}
+
+// aliased returns the key of the entry of manifest ns ("" for keys without a namespace)
+// that lists alias in _aliases, the first in sort order if several do, or "" if none does.
+func (m Manifest) aliased(ns, alias string) string {
+	found := ""
+	for key, entry := range m {
+		if keyNS, _ := SplitKey(key); keyNS == ns && slices.Contains(entry.Aliases, alias) && (found == "" || key < found) {
+			found = key
+		}
+	}
+	return found
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeManifest(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadManifests(t *testing.T) {
+	dir := t.TempDir()
+	work := writeManifest(t, dir, "work.yml", "ripgrep:\n  _name: ripgrep\n  apt: ripgrep\n")
+	personal := writeManifest(t, dir, "personal.yml", "ripgrep:\n  _name: ripgrep\n  brew: ripgrep\nbat:\n  brew: bat\n")
+
+	m, err := LoadManifests([]ManifestSource{{Name: "work", Path: work}, {Name: "personal", Path: personal}})
+	if err != nil {
+		t.Fatalf("LoadManifests failed: %v", err)
+	}
+	for _, key := range []string{"work/ripgrep", "personal/ripgrep", "personal/bat"} {
+		if _, ok := m[key]; !ok {
+			t.Errorf("expected key %q in merged manifest", key)
+		}
+	}
+	if len(m) != 3 {
+		t.Errorf("expected 3 entries, got %d", len(m))
+	}
+
+	single, err := LoadManifests([]ManifestSource{{Path: work}})
+	if err != nil {
+		t.Fatalf("LoadManifests failed: %v", err)
+	}
+	if _, ok := single["ripgrep"]; !ok {
+		t.Errorf("expected a single unnamed manifest to keep its keys, got %v", single)
+	}
+}
+
+func TestLoadManifests_InvalidNames(t *testing.T) {
+	dir := t.TempDir()
+	path := writeManifest(t, dir, "m.yml", "bat:\n  brew: bat\n")
+	for _, sources := range [][]ManifestSource{
+		{{Name: "", Path: path}, {Name: "b", Path: path}},
+		{{Name: "a/b", Path: path}},
+		{{Name: "a", Path: path}, {Name: "a", Path: path}},
+	} {
+		if _, err := LoadManifests(sources); err == nil {
+			t.Errorf("expected error for sources %+v", sources)
+		}
+	}
+}
+
+func TestManifestResolve(t *testing.T) {
+	m := Manifest{
+		"work/ripgrep":     {},
+		"personal/ripgrep": {},
+		"personal/bat":     {Aliases: StringOrSlice{"ripgrep", "batcat"}},
+		"work/rg":          {Aliases: StringOrSlice{"grep-rs"}},
+		"personal/grep":    {Aliases: StringOrSlice{"grep-rs"}},
+		"fd":               {Aliases: StringOrSlice{"fd-find"}},
+	}
+	priority := []string{"work", "personal"}
+	tests := []struct {
+		key, namespace string
+		want           string
+		wantOK         bool
+	}{
+		{key: "personal/ripgrep", want: "personal/ripgrep", wantOK: true},
+		{key: "ripgrep", want: "work/ripgrep", wantOK: true},
+		{key: "ripgrep", namespace: "personal", want: "personal/ripgrep", wantOK: true},
+		{key: "bat", namespace: "work", want: "personal/bat", wantOK: true},
+		{key: "work/bat"},
+		{key: "fd-find", want: "fd", wantOK: true},
+		// Aliases resolve like keys, after them
+		{key: "batcat", want: "personal/bat", wantOK: true},
+		{key: "grep-rs", want: "work/rg", wantOK: true},
+		{key: "grep-rs", namespace: "personal", want: "personal/grep", wantOK: true},
+		{key: "personal/grep-rs", want: "personal/grep", wantOK: true},
+		{key: "work/batcat"},
+	}
+	for _, tt := range tests {
+		got, ok := m.Resolve(tt.key, tt.namespace, priority)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Resolve(%q, %q) = %q, %v; want %q, %v", tt.key, tt.namespace, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package provision
+
+import (
+	"fmt"
+	"strings"
+
+	"a-la-carte/internal/app"
+)
+
+// DepChoice records which alternative of a _deps_any group planning picked.
+//
+// # Fields
+//   - Key:    The entry that declares the group
+//   - Group:  The alternatives, as written
+//   - Chosen: The key that satisfies the group
+//   - Why:    Why it was picked: "planned", "installed" or "installable"
+type DepChoice struct {
+	Key    string   `json:"key"`
+	Group  []string `json:"group"`
+	Chosen string   `json:"chosen"`
+	Why    string   `json:"why"`
+}
+
+// String describes the choice, e.g. "compose: podman of docker|podman (installed)".
+func (c DepChoice) String() string {
+	return fmt.Sprintf("%s: %s of %s (%s)", c.Key, c.Chosen, strings.Join(c.Group, "|"), c.Why)
+}
+
+// chooseAlternative picks the alternative of a _deps_any group of parent to expand: one
+// that is already part of the plan, else one that is installed, else the first that can
+// be installed here. The choice is recorded in PlanReport and logged. When no
+// alternative fits, planning fails with StrictDeps and otherwise warns and returns "".
+func (p *Provisioner) chooseAlternative(parent string, group []string, visited map[string]bool) (string, error) {
+	namespace, _ := app.SplitKey(parent)
+	var candidates []string
+	for _, ref := range group {
+		key, ok := p.manifestIndex().Resolve(ref, namespace, p.Namespaces)
+		if !ok {
+			key, ok = p.provider(ref, visited)
+		}
+		if ok {
+			candidates = append(candidates, key)
+		}
+	}
+	choose := func(key, why string) (string, error) {
+		c := DepChoice{Key: parent, Group: group, Chosen: key, Why: why}
+		p.PlanReport.record(c)
+		if p.Runner != nil {
+			_ = p.Runner.Run("info", "Choosing "+c.String())
+		}
+		return key, nil
+	}
+	var allowed []string
+	for _, key := range candidates {
+		if reason, _ := p.excludeReason(key); reason == "" {
+			allowed = append(allowed, key)
+		}
+	}
+	for _, key := range allowed {
+		if _, bare := app.SplitKey(key); visited[bare] {
+			return choose(key, "planned")
+		}
+	}
+	for _, key := range allowed {
+		if p.installed != nil && p.shouldSkipInstalled(key, p.installed) {
+			return choose(key, "installed")
+		}
+	}
+	for _, key := range allowed {
+		if ok, _ := p.Installability(key); ok {
+			return choose(key, "installable")
+		}
+	}
+	msg := fmt.Sprintf("%s depends on one of %s, none of which can be installed here", parent, strings.Join(group, ", "))
+	if p.StrictDeps {
+		return "", fmt.Errorf("%s", msg)
+	}
+	p.warn(PlanWarning{Key: parent, Dep: strings.Join(group, "|"), Message: msg + "; skipping the dependency"})
+	return "", nil
+}
@@ -0,0 +1,65 @@
+package provision
+
+import (
+	"strings"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestPlanProvisionDepsAny(t *testing.T) {
+	manifest := app.Manifest{
+		"compose": {Apt: app.StringOrSlice{"compose"}, DepsAny: app.AnyOf{{"docker", "podman"}}},
+		"docker":  {Apt: app.StringOrSlice{"docker.io"}},
+		"podman":  {Apt: app.StringOrSlice{"podman"}},
+		"mac":     {Cask: app.StringOrSlice{"mac"}},
+		"needy":   {Apt: app.StringOrSlice{"needy"}, DepsAny: app.AnyOf{{"mac", "missing"}}},
+	}
+	prov := NewProvisioner(&fakeSystemInfo{}, manifest, &fakeExecRunner{})
+	packages := func(plan []InstallInstruction) string {
+		var names []string
+		for _, inst := range plan {
+			names = append(names, inst.Package)
+		}
+		return strings.Join(names, " ")
+	}
+	tests := []struct {
+		keys      []string
+		installed map[string]bool
+		want      string
+		why       string
+	}{
+		{[]string{"compose"}, nil, "docker.io compose", "installable"},
+		{[]string{"compose"}, map[string]bool{"podman": true}, "compose", "installed"},
+		{[]string{"podman", "compose"}, nil, "podman compose", "planned"},
+	}
+	for _, tt := range tests {
+		plan, err := prov.PlanProvision(tt.keys, tt.installed)
+		if err != nil {
+			t.Fatalf("PlanProvision(%v) error: %v", tt.keys, err)
+		}
+		if got := packages(plan); got != tt.want {
+			t.Errorf("PlanProvision(%v, %v) = %q, want %q", tt.keys, tt.installed, got, tt.want)
+		}
+		if choices := prov.PlanReport.Choices; len(choices) != 1 || choices[0].Why != tt.why {
+			t.Errorf("PlanProvision(%v, %v) choices %v, want one %s", tt.keys, tt.installed, choices, tt.why)
+		}
+	}
+	if got := prov.PlanReport.Choices[0].String(); got != "compose: podman of docker|podman (planned)" {
+		t.Errorf("String() = %q", got)
+	}
+	var b strings.Builder
+	if err := PrintReport(&b, &RunReport{Choices: prov.PlanReport.Choices}); err != nil || !strings.Contains(b.String(), "podman of docker|podman (planned)") {
+		t.Errorf("PrintReport output missing the choice (%v):\n%s", err, b.String())
+	}
+
+	// No alternative can be installed on Linux
+	plan, err := prov.PlanProvision([]string{"needy"}, nil)
+	if err != nil || packages(plan) != "needy" || len(prov.Warnings) != 1 {
+		t.Errorf("expected needy with a warning, got %+v, %v, %+v", plan, err, prov.Warnings)
+	}
+	prov.StrictDeps = true
+	if _, err := prov.PlanProvision([]string{"needy"}, nil); err == nil || !strings.Contains(err.Error(), "one of mac, missing") {
+		t.Errorf("expected a StrictDeps error, got %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+package provision
+
+import "a-la-carte/internal/app"
+
+// canonicalArchAliases lists, per canonical identifier, its other names in a fixed order.
+var canonicalArchAliases = map[string][]string{
+	"x64":   {"amd64", "x86_64"},
+	"arm64": {"aarch64"},
+	"x86":   {"386", "i386", "i686"},
+	"arm":   {"armv7", "armv7l", "armhf"},
+}
+
+// archVariants returns the names a manifest key may use for arch: the canonical
+// identifier first, then its aliases.
+func archVariants(arch string) []string {
+	canonical := app.CanonicalArch(arch)
+	return append([]string{canonical}, canonicalArchAliases[canonical]...)
+}
@@ -0,0 +1,28 @@
+package provision
+
+import "testing"
+
+func TestGetFieldByPriority_ArchAliases(t *testing.T) {
+	entry := map[string]interface{}{
+		"apt:debian:x64":  "pkg-x64",
+		"apt:linux:amd64": "pkg-linux-amd64",
+		"apt:aarch64":     "pkg-arm",
+		"apt":             "pkg",
+	}
+	tests := []struct {
+		osId, osType, arch string
+		want               string
+	}{
+		{"debian", "linux", "amd64", "pkg-x64"},
+		{"debian", "linux", "x86_64", "pkg-x64"},
+		{"ubuntu", "linux", "x64", "pkg-linux-amd64"},
+		{"ubuntu", "linux", "arm64", "pkg-arm"},
+		{"ubuntu", "linux", "riscv64", "pkg"},
+	}
+	for _, tt := range tests {
+		got, ok := getFieldByPriority(entry, "apt", "", tt.osId, tt.osType, tt.arch)
+		if !ok || got != tt.want {
+			t.Errorf("getFieldByPriority(%s/%s/%s) = %q, %v; want %q", tt.osId, tt.osType, tt.arch, got, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package provision
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditRecord is an entry of the audit log: one command the provisioner executed.
+// Each record holds the hash of the previous one, so editing, inserting or deleting a
+// record breaks the chain from there on.
+//
+// # Fields
+//   - Seq:      The position in the log, starting at 1
+//   - Start:    When the command started
+//   - End:      When the command exited
+//   - Argv:     The command and its arguments, as executed
+//   - Dir:      The working directory
+//   - User:     The user that ran the command
+//   - Host:     The remote host the command ran on, for --target runs
+//   - ExitCode: The exit code (-1 if the command did not start or exit normally)
+//   - Prev:     The Hash of the previous record ("" for the first)
+//   - Hash:     SHA-256 over Prev and the other fields of this record
+type AuditRecord struct {
+	Seq      int       `json:"seq"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Argv     []string  `json:"argv"`
+	Dir      string    `json:"cwd"`
+	User     string    `json:"user"`
+	Host     string    `json:"host,omitempty"`
+	ExitCode int       `json:"exit_code"`
+	Prev     string    `json:"prev"`
+	Hash     string    `json:"hash"`
+}
+
+// NewAuditRecord returns the record of a command that ran from start until now, as this
+// process's user. dir is the command's working directory ("" for this process's).
+func NewAuditRecord(argv []string, dir string, start time.Time, err error) AuditRecord {
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	return AuditRecord{
+		Start:    start,
+		End:      time.Now(),
+		Argv:     append([]string(nil), argv...),
+		Dir:      dir,
+		User:     name,
+		ExitCode: exitCode(err),
+	}
+}
+
+// hash computes the record's Hash from its other fields.
+func (r AuditRecord) hash() string {
+	r.Hash = ""
+	data, _ := json.Marshal(r)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditLog appends AuditRecords to an append-only JSON lines file. Its methods are safe
+// for concurrent use and do nothing on a nil *AuditLog, so runners can record
+// unconditionally.
+type AuditLog struct {
+	mu   sync.Mutex
+	path string
+	seq  int
+	last string
+}
+
+// DefaultAuditPath returns the location of the audit log inside the state directory.
+func DefaultAuditPath() string {
+	return filepath.Join(StateDir(), "audit.log")
+}
+
+// OpenAuditLog opens the audit log at path, creating it if needed, and reads its last
+// record to continue the chain.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	records, err := ReadAudit(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	a := &AuditLog{path: path}
+	if len(records) > 0 {
+		last := records[len(records)-1]
+		a.seq, a.last = last.Seq, last.Hash
+	}
+	return a, nil
+}
+
+// Record appends a command to the log, filling in Seq, Prev and Hash.
+func (a *AuditLog) Record(rec AuditRecord) error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	rec.Seq, rec.Prev = a.seq+1, a.last
+	rec.Hash = rec.hash()
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	a.seq, a.last = rec.Seq, rec.Hash
+	return nil
+}
+
+// ReadAudit reads the records of the audit log at path, without verifying them.
+func ReadAudit(path string) ([]AuditRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	var records []AuditRecord
+	scan := bufio.NewScanner(f)
+	scan.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for line := 1; scan.Scan(); line++ {
+		if strings.TrimSpace(scan.Text()) == "" {
+			continue
+		}
+		var rec AuditRecord
+		if err := json.Unmarshal(scan.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, scan.Err()
+}
+
+// VerifyAudit checks the hash chain of audit records. Edits, insertions and deletions
+// are detected; records removed from the end of the log are not, as nothing follows them.
+//
+// # Returns
+//   - error: Describing the first record that breaks the chain, or nil if it is intact
+func VerifyAudit(records []AuditRecord) error {
+	prev := ""
+	for i, rec := range records {
+		switch {
+		case rec.Seq != i+1:
+			return fmt.Errorf("record %d: sequence number %d, want %d (a record was inserted or removed)", i+1, rec.Seq, i+1)
+		case rec.Prev != prev:
+			return fmt.Errorf("record %d: the chain is broken: it follows %.12s, not %.12s", rec.Seq, rec.Prev, prev)
+		case rec.hash() != rec.Hash:
+			return fmt.Errorf("record %d: the hash does not match its contents (the record was modified)", rec.Seq)
+		}
+		prev = rec.Hash
+	}
+	return nil
+}
+
+// WriteAudit writes audit records as a table of their time, user, exit code, duration
+// and command.
+func WriteAudit(w io.Writer, records []AuditRecord) error {
+	if len(records) == 0 {
+		_, err := io.WriteString(w, "The audit log is empty.\n")
+		return err
+	}
+	var b strings.Builder
+	for _, rec := range records {
+		who := rec.User
+		if rec.Host != "" {
+			who += "@" + rec.Host
+		}
+		fmt.Fprintf(&b, "%5d  %s  %-16s  exit %-3d  %8s  %s\n", rec.Seq, rec.Start.Local().Format("2006-01-02 15:04:05"), who, rec.ExitCode,
+			rec.End.Sub(rec.Start).Round(time.Millisecond), strings.Join(rec.Argv, " "))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,62 @@
+package provision
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "audit.log")
+	audit, err := OpenAuditLog(path)
+	if err != nil {
+		t.Fatalf("OpenAuditLog error: %v", err)
+	}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := audit.Record(NewAuditRecord([]string{"sudo", "apt-get", "install", "-y", "jq"}, "/tmp", start, nil)); err != nil {
+		t.Fatalf("Record error: %v", err)
+	}
+	// A reopened log continues the chain
+	audit, err = OpenAuditLog(path)
+	if err != nil {
+		t.Fatalf("OpenAuditLog error: %v", err)
+	}
+	if err := audit.Record(NewAuditRecord([]string{"brew", "install", "bat"}, "", start, errors.New("exit status 1"))); err != nil {
+		t.Fatalf("Record error: %v", err)
+	}
+	var nilLog *AuditLog
+	if err := nilLog.Record(AuditRecord{}); err != nil {
+		t.Errorf("expected a nil log to ignore records, got %v", err)
+	}
+
+	records, err := ReadAudit(path)
+	if err != nil {
+		t.Fatalf("ReadAudit error: %v", err)
+	}
+	if len(records) != 2 || records[1].Seq != 2 || records[1].Prev != records[0].Hash || records[0].Dir != "/tmp" || records[1].ExitCode != -1 {
+		t.Fatalf("unexpected records %+v", records)
+	}
+	if err := VerifyAudit(records); err != nil {
+		t.Errorf("expected an intact chain, got %v", err)
+	}
+	var b strings.Builder
+	if err := WriteAudit(&b, records); err != nil || !strings.Contains(b.String(), "sudo apt-get install -y jq") {
+		t.Errorf("unexpected WriteAudit output %q (%v)", b.String(), err)
+	}
+
+	// Tampering with the file is detected
+	data, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, []byte(strings.Replace(string(data), `"jq"`, `"xq"`, 1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	records, _ = ReadAudit(path)
+	if err := VerifyAudit(records); err == nil || !strings.Contains(err.Error(), "record 1") {
+		t.Errorf("expected record 1 to fail verification, got %v", err)
+	}
+	if err := VerifyAudit(records[1:]); err == nil {
+		t.Error("expected a removed record to fail verification")
+	}
+}
@@ -0,0 +1,303 @@
+package provision
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"a-la-carte/internal/app"
+)
+
+// BinaryInstall describes the download and installation of a prebuilt binary.
+//
+// # Fields
+//   - URL:    The download URL, with placeholders already expanded
+//   - SHA256: The expected hex SHA-256 of the download (optional)
+//   - Bins:   The executables to install, found by base name in an archive
+//   - Dir:    The directory the executables are installed to
+type BinaryInstall struct {
+	URL    string
+	SHA256 string
+	Bins   []string
+	Dir    string
+}
+
+// BinaryInstaller is implemented by ExecRunners that install binaries themselves,
+// e.g. to only print what would be downloaded in a dry run. Other runners use
+// InstallBinary.
+type BinaryInstaller interface {
+	InstallBinary(ctx context.Context, b BinaryInstall) error
+}
+
+// DefaultBinDir returns where binaries are installed by default: ~/.local/bin.
+func DefaultBinDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".local", "bin")
+	}
+	return filepath.Join(home, ".local", "bin")
+}
+
+// ExpandBinaryURL fills in the placeholders of a binary download URL:
+// {version}, {os} (e.g. linux), {arch} (the canonical manifest arch, e.g. x64)
+// and {goarch} (the Go name, e.g. amd64).
+//
+// # Example
+//
+//	ExpandBinaryURL("https://example.com/{version}/tool-{os}-{goarch}.tar.gz", "1.2.0", "linux", "x64")
+//	// "https://example.com/1.2.0/tool-linux-amd64.tar.gz"
+func ExpandBinaryURL(url, version, goos, arch string) string {
+	arch = app.CanonicalArch(arch)
+	goarch := arch
+	switch arch {
+	case "x64":
+		goarch = "amd64"
+	case "x86":
+		goarch = "386"
+	}
+	return strings.NewReplacer(
+		"{version}", version,
+		"{os}", goos,
+		"{arch}", arch,
+		"{goarch}", goarch,
+	).Replace(url)
+}
+
+// binaryInstall builds the BinaryInstall for a binary:* instruction from its manifest entry.
+// _version and _sha256 support the same OS and arch suffixes as installer keys.
+func (p *Provisioner) binaryInstall(inst InstallInstruction) BinaryInstall {
+	entry := p.Manifest[inst.Key]
+	entryMap := p.entryMap(inst.Key, &entry)
+	osId, osType, osArch := "", strings.TrimPrefix(inst.Type, "binary:"), ""
+	if p.System != nil {
+		osId, osArch = p.System.ID(), p.System.Arch()
+	}
+	version, _ := getFieldByPriority(entryMap, "_version", "", osId, osType, osArch)
+	sum, _ := getFieldByPriority(entryMap, "_sha256", "", osId, osType, osArch)
+	bins := append([]string(nil), entry.Bin...)
+	if len(bins) == 0 {
+		_, bare := app.SplitKey(inst.Key)
+		bins = []string{bare}
+	}
+	if osType == "windows" {
+		for i, bin := range bins {
+			if !strings.HasSuffix(bin, ".exe") {
+				bins[i] = bin + ".exe"
+			}
+		}
+	}
+	dir := p.BinDir
+	if dir == "" {
+		dir = DefaultBinDir()
+	}
+	return BinaryInstall{
+		URL:    ExpandBinaryURL(inst.Package, version, osType, osArch),
+		SHA256: sum,
+		Bins:   bins,
+		Dir:    dir,
+	}
+}
+
+// installBinary installs a binary:* instruction, through the runner if it is a BinaryInstaller.
+func (p *Provisioner) installBinary(ctx context.Context, inst InstallInstruction) error {
+	b := p.binaryInstall(inst)
+	if installer, ok := p.Runner.(BinaryInstaller); ok {
+		return installer.InstallBinary(ctx, b)
+	}
+	_ = p.Runner.Run("info", fmt.Sprintf("Downloading %s", b.URL))
+	if err := InstallBinary(ctx, b); err != nil {
+		return err
+	}
+	_ = p.Runner.Run("info", fmt.Sprintf("Installed %s to %s", strings.Join(b.Bins, ", "), b.Dir))
+	return nil
+}
+
+// InstallBinary downloads b.URL, verifies its checksum if one is given, and installs
+// the executables to b.Dir with mode 0755. .tar.gz, .tgz and .zip downloads are
+// extracted; anything else is installed as the single executable b.Bins[0].
+//
+// # Returns
+//   - error: if the download, verification, extraction or installation fails
+func InstallBinary(ctx context.Context, b BinaryInstall) error {
+	tmp, err := os.CreateTemp("", "a-la-carte-binary-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+	if err := download(ctx, b.URL, tmp, b.SHA256); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(b.Dir, 0o755); err != nil {
+		return err
+	}
+	name := strings.ToLower(path.Base(strings.SplitN(b.URL, "?", 2)[0]))
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return extractTarGz(tmp, b)
+	case strings.HasSuffix(name, ".zip"):
+		return extractZip(tmp, b)
+	default:
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		return installExecutable(tmp, b.Dir, b.Bins[0])
+	}
+}
+
+// download writes the body of url to w, checking its SHA-256 against sum if set.
+// file:// URLs are read from disk, e.g. downloads cached for offline installs.
+func download(ctx context.Context, url string, w io.Writer, sum string) error {
+	if name, ok := strings.CutPrefix(url, "file://"); ok {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		return copyVerified(url, w, f, sum)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+	return copyVerified(url, w, resp.Body, sum)
+}
+
+// copyVerified copies the download of url from r to w, checking its SHA-256 against sum if set.
+func copyVerified(url string, w io.Writer, r io.Reader, sum string) error {
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, hash), r); err != nil {
+		return fmt.Errorf("downloading %s: %w", url, err)
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); sum != "" && !strings.EqualFold(got, sum) {
+		return fmt.Errorf("checksum mismatch for %s: got sha256 %s, want %s", url, got, sum)
+	}
+	return nil
+}
+
+// extractTarGz installs the wanted executables from a gzipped tarball.
+func extractTarGz(f *os.File, b BinaryInstall) error {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", b.URL, err)
+	}
+	defer func() {
+		_ = gz.Close()
+	}()
+	tr := tar.NewReader(gz)
+	var installed []string
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", b.URL, err)
+		}
+		bin := path.Base(hdr.Name)
+		if hdr.Typeflag != tar.TypeReg || !slices.Contains(b.Bins, bin) || slices.Contains(installed, bin) {
+			continue
+		}
+		if err := installExecutable(tr, b.Dir, bin); err != nil {
+			return err
+		}
+		installed = append(installed, bin)
+	}
+	return checkInstalled(b, installed)
+}
+
+// extractZip installs the wanted executables from a zip archive.
+func extractZip(f *os.File, b BinaryInstall) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", b.URL, err)
+	}
+	var installed []string
+	for _, zf := range zr.File {
+		bin := path.Base(zf.Name)
+		if zf.FileInfo().IsDir() || !slices.Contains(b.Bins, bin) || slices.Contains(installed, bin) {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", b.URL, err)
+		}
+		err = installExecutable(rc, b.Dir, bin)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+		installed = append(installed, bin)
+	}
+	return checkInstalled(b, installed)
+}
+
+// checkInstalled reports the executables that were not found in an archive.
+func checkInstalled(b BinaryInstall, installed []string) error {
+	var missing []string
+	for _, bin := range b.Bins {
+		if !slices.Contains(installed, bin) {
+			missing = append(missing, bin)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s does not contain %s", b.URL, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// installExecutable writes r to dir/name with mode 0755, replacing any existing file
+// only once the new one is complete.
+func installExecutable(r io.Reader, dir, name string) error {
+	tmp, err := os.CreateTemp(dir, "."+name+"-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
@@ -0,0 +1,192 @@
+package provision
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// serveFiles serves the given bodies by URL path.
+func serveFiles(t *testing.T, files map[string][]byte) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func assertExecutable(t *testing.T, path, content string) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("expected %s to be installed: %v", path, err)
+	}
+	if info.Mode().Perm() != 0o755 {
+		t.Errorf("expected %s to have mode 0755, got %v", path, info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Errorf("expected %s to contain %q, got %q", path, content, data)
+	}
+}
+
+func TestExpandBinaryURL(t *testing.T) {
+	got := ExpandBinaryURL("https://x/{version}/tool-{os}-{goarch}-{arch}.tgz", "1.2.0", "linux", "amd64")
+	if want := "https://x/1.2.0/tool-linux-amd64-x64.tgz"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestInstallBinary_Archives(t *testing.T) {
+	srv := serveFiles(t, map[string][]byte{
+		"/tool.tar.gz": tarGz(t, map[string]string{"tool-1.0/tool": "tar-bin", "tool-1.0/README": "docs"}),
+		"/tool.zip":    zipArchive(t, map[string]string{"bin/tool": "zip-bin", "bin/helper": "helper-bin"}),
+		"/tool":        []byte("raw-bin"),
+	})
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	if err := InstallBinary(ctx, BinaryInstall{URL: srv.URL + "/tool.tar.gz", Bins: []string{"tool"}, Dir: dir}); err != nil {
+		t.Fatalf("InstallBinary(tar.gz) failed: %v", err)
+	}
+	assertExecutable(t, filepath.Join(dir, "tool"), "tar-bin")
+	if _, err := os.Stat(filepath.Join(dir, "README")); err == nil {
+		t.Error("expected only the executable to be extracted")
+	}
+
+	dir = t.TempDir()
+	if err := InstallBinary(ctx, BinaryInstall{URL: srv.URL + "/tool.zip", Bins: []string{"tool", "helper"}, Dir: dir}); err != nil {
+		t.Fatalf("InstallBinary(zip) failed: %v", err)
+	}
+	assertExecutable(t, filepath.Join(dir, "tool"), "zip-bin")
+	assertExecutable(t, filepath.Join(dir, "helper"), "helper-bin")
+
+	dir = t.TempDir()
+	if err := InstallBinary(ctx, BinaryInstall{URL: srv.URL + "/tool", Bins: []string{"tool"}, Dir: dir}); err != nil {
+		t.Fatalf("InstallBinary(raw) failed: %v", err)
+	}
+	assertExecutable(t, filepath.Join(dir, "tool"), "raw-bin")
+
+	if err := InstallBinary(ctx, BinaryInstall{URL: srv.URL + "/tool.tar.gz", Bins: []string{"other"}, Dir: t.TempDir()}); err == nil {
+		t.Error("expected an error when the archive lacks the executable")
+	}
+}
+
+func TestInstallBinary_Checksum(t *testing.T) {
+	srv := serveFiles(t, map[string][]byte{"/tool": []byte("raw-bin")})
+	sum := sha256.Sum256([]byte("raw-bin"))
+	dir := t.TempDir()
+	if err := InstallBinary(context.Background(), BinaryInstall{URL: srv.URL + "/tool", SHA256: hex.EncodeToString(sum[:]), Bins: []string{"tool"}, Dir: dir}); err != nil {
+		t.Fatalf("InstallBinary with a valid checksum failed: %v", err)
+	}
+	err := InstallBinary(context.Background(), BinaryInstall{URL: srv.URL + "/tool", SHA256: strings.Repeat("0", 64), Bins: []string{"other"}, Dir: dir})
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("expected a checksum mismatch, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other")); err == nil {
+		t.Error("expected nothing to be installed after a checksum mismatch")
+	}
+}
+
+func TestExecutePlan_Binary(t *testing.T) {
+	srv := serveFiles(t, map[string][]byte{"/v2.0/tool-linux-x64.tar.gz": tarGz(t, map[string]string{"tool": "bin"})})
+	manifest := app.Manifest{
+		"tool": {BinaryLinux: app.StringOrSlice{srv.URL + "/v{version}/tool-{os}-{arch}.tar.gz"}, Version: "2.0"},
+	}
+	prov := NewProvisioner(staticSystemInfo{os: "linux", arch: "x86_64", id: "ubuntu"}, manifest, &fakeExecRunner{})
+	prov.BinDir = t.TempDir()
+	plan, err := prov.PlanProvision([]string{"tool"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	if err := prov.ExecutePlan(plan); err != nil {
+		t.Fatalf("ExecutePlan error: %v", err)
+	}
+	assertExecutable(t, filepath.Join(prov.BinDir, "tool"), "bin")
+}
+
+// binaryRecorder is a runner that records binary installs instead of downloading.
+type binaryRecorder struct {
+	fakeExecRunner
+	installs []BinaryInstall
+}
+
+func (r *binaryRecorder) InstallBinary(_ context.Context, b BinaryInstall) error {
+	r.installs = append(r.installs, b)
+	return nil
+}
+
+func TestExecutePlan_BinaryThroughRunner(t *testing.T) {
+	manifest := app.Manifest{
+		"tool": {BinaryWindows: app.StringOrSlice{"https://x/tool.zip"}, Bin: app.StringOrSlice{"tool"}, Sha256: "abc"},
+	}
+	runner := &binaryRecorder{}
+	prov := NewProvisioner(nil, manifest, runner)
+	prov.BinDir = "/bin-dir"
+	if err := prov.ExecutePlan([]InstallInstruction{{Type: "binary:windows", Package: "https://x/tool.zip", Key: "tool"}}); err != nil {
+		t.Fatalf("ExecutePlan error: %v", err)
+	}
+	want := BinaryInstall{URL: "https://x/tool.zip", SHA256: "abc", Bins: []string{"tool.exe"}, Dir: "/bin-dir"}
+	if len(runner.installs) != 1 || runner.installs[0].URL != want.URL || runner.installs[0].SHA256 != want.SHA256 ||
+		runner.installs[0].Dir != want.Dir || strings.Join(runner.installs[0].Bins, ",") != "tool.exe" {
+		t.Errorf("got installs %+v, want %+v", runner.installs, want)
+	}
+}
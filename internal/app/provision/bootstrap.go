@@ -0,0 +1,173 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// managerBinaries maps installers that depend on a separately installed package manager
+// to the binary that must be on PATH.
+var managerBinaries = map[string]string{
+	"brew":    "brew",
+	"cask":    "brew",
+	"flatpak": "flatpak",
+	"pipx":    "pipx",
+	"cargo":   "cargo",
+}
+
+// managerScripts are the upstream install scripts for managers that are not installed
+// through the system package manager.
+var managerScripts = map[string]string{
+	"brew":  `NONINTERACTIVE=1 /bin/bash -c "$(curl -fsSL https://raw.githubusercontent.com/Homebrew/install/HEAD/install.sh)"`,
+	"cargo": `curl --proto '=https' --tlsv1.2 -sSf https://sh.rustup.rs | sh -s -- -y --no-modify-path`,
+}
+
+// managerPackages names the system package that provides a manager, per system package manager.
+var managerPackages = map[string]map[string]string{
+	"flatpak": {"apt": "flatpak", "dnf": "flatpak", "pacman": "flatpak", "zypper": "flatpak", "apk": "flatpak"},
+	"pipx":    {"apt": "pipx", "dnf": "pipx", "pacman": "python-pipx", "zypper": "python3-pipx", "apk": "pipx", "brew": "pipx"},
+}
+
+// systemManagers are the system package managers, in detection order, with their binary.
+var systemManagers = []struct{ installer, binary string }{
+	{"apt", "apt-get"},
+	{"dnf", "dnf"},
+	{"pacman", "pacman"},
+	{"zypper", "zypper"},
+	{"apk", "apk"},
+}
+
+// managerBinDirs are where bootstrapped managers put their binaries, which may not be on PATH yet.
+var managerBinDirs = map[string][]string{
+	"brew":  {"/opt/homebrew/bin", "/usr/local/bin", "/home/linuxbrew/.linuxbrew/bin"},
+	"cargo": {"~/.cargo/bin"},
+}
+
+// MissingManagers returns the package managers the plan needs whose binary is not on PATH.
+func (p *Provisioner) MissingManagers(plan []InstallInstruction) []string {
+	var missing []string
+	for _, inst := range plan {
+		binary, ok := managerBinaries[inst.Type]
+		if !ok || slices.Contains(missing, binary) {
+			continue
+		}
+		if _, err := p.lookPathFunc()(binary); err != nil {
+			missing = append(missing, binary)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// BootstrapInstruction returns the instruction that installs a package manager on this system.
+//
+// # Returns
+//   - InstallInstruction: An install script, or a package for the system package manager
+//   - error:              If there is no known way to install the manager here
+func (p *Provisioner) BootstrapInstruction(manager string) (InstallInstruction, error) {
+	if script, ok := managerScripts[manager]; ok {
+		return InstallInstruction{Type: "script", Package: script}, nil
+	}
+	system := p.systemManager()
+	if pkg, ok := managerPackages[manager][system]; ok {
+		return InstallInstruction{Type: system, Package: pkg}, nil
+	}
+	if system == "" {
+		return InstallInstruction{}, fmt.Errorf("cannot bootstrap %s: no supported system package manager found", manager)
+	}
+	return InstallInstruction{}, fmt.Errorf("cannot bootstrap %s with %s", manager, system)
+}
+
+// systemManager returns the installer of the system package manager, or "" if none is found.
+// On macOS, Homebrew is used when it is available.
+func (p *Provisioner) systemManager() string {
+	lookPath := p.lookPathFunc()
+	if p.System != nil && p.System.OS() == "darwin" {
+		if _, err := lookPath("brew"); err == nil {
+			return "brew"
+		}
+		return ""
+	}
+	for _, m := range systemManagers {
+		if _, err := lookPath(m.binary); err == nil {
+			return m.installer
+		}
+	}
+	return ""
+}
+
+// bootstrapManagers installs the package managers the plan needs but that are missing,
+// then adds their binary directories to PATH so the plan can use them right away.
+// In dry-run mode the bootstrap instructions are only logged.
+//
+// # Returns
+//   - []error: One error per manager that could not be installed
+func (p *Provisioner) bootstrapManagers(ctx context.Context, plan []InstallInstruction) []error {
+	missing := p.MissingManagers(plan)
+	if len(missing) == 0 {
+		return nil
+	}
+	if p.Runner != nil {
+		_ = p.Runner.Run("section", "Bootstrapping")
+	}
+	var errs []error
+	for _, manager := range missing {
+		inst, err := p.BootstrapInstruction(manager)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if p.DryRun {
+			p.DryRunLog = append(p.DryRunLog, "bootstrap "+manager+": "+ShellCommand(inst))
+			continue
+		}
+		if _, ok := lockFiles[inst.Type]; ok {
+			if err := p.waitForLock(inst.Type); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		p.RunLog.Log("info", "Bootstrapping "+manager+": "+ShellCommand(inst))
+		if err := p.runWithRetry(ctx, inst); err != nil {
+			errs = append(errs, fmt.Errorf("bootstrapping %s: %w", manager, err))
+			p.RunLog.Log("error", fmt.Sprintf("Failed: %v", err))
+			continue
+		}
+		addToPath(managerBinDirs[manager])
+		p.RunLog.Log("success", "Bootstrapped "+manager)
+	}
+	return errs
+}
+
+// addToPath prepends the existing directories among dirs to PATH; ~ is expanded.
+func addToPath(dirs []string) {
+	path := filepath.SplitList(os.Getenv("PATH"))
+	home, _ := os.UserHomeDir()
+	for _, dir := range dirs {
+		if rest, ok := strings.CutPrefix(dir, "~/"); ok {
+			dir = filepath.Join(home, rest)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() || slices.Contains(path, dir) {
+			continue
+		}
+		path = append([]string{dir}, path...)
+	}
+	_ = os.Setenv("PATH", strings.Join(path, string(os.PathListSeparator)))
+}
+
+// lookPathFunc returns the function used to find binaries on PATH.
+func (p *Provisioner) lookPathFunc() func(string) (string, error) {
+	if p.lookPath != nil {
+		return p.lookPath
+	}
+	if p.LookPath != nil {
+		return p.LookPath
+	}
+	return exec.LookPath
+}
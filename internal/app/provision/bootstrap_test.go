@@ -0,0 +1,123 @@
+package provision
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeLookPath finds only the given binaries.
+func fakeLookPath(found ...string) func(string) (string, error) {
+	return func(name string) (string, error) {
+		if slices.Contains(found, name) {
+			return "/usr/bin/" + name, nil
+		}
+		return "", errors.New("not found")
+	}
+}
+
+func TestMissingManagers(t *testing.T) {
+	prov := NewProvisioner(nil, nil, nil)
+	prov.lookPath = fakeLookPath("cargo")
+	plan := []InstallInstruction{
+		{Type: "cask", Package: "firefox"},
+		{Type: "brew", Package: "bat"},
+		{Type: "cargo", Package: "ripgrep"},
+		{Type: "pipx", Package: "black"},
+		{Type: "apt", Package: "git"},
+	}
+	want := []string{"brew", "pipx"}
+	if got := prov.MissingManagers(plan); !slices.Equal(got, want) {
+		t.Errorf("MissingManagers = %q, want %q", got, want)
+	}
+}
+
+func TestBootstrapInstruction(t *testing.T) {
+	tests := []struct {
+		name    string
+		sys     SystemInfo
+		found   []string
+		manager string
+		want    InstallInstruction
+		wantErr bool
+	}{
+		{name: "brew script", sys: staticSystemInfo{os: "linux"}, manager: "brew", want: InstallInstruction{Type: "script", Package: managerScripts["brew"]}},
+		{name: "pipx with apt", sys: staticSystemInfo{os: "linux"}, found: []string{"apt-get"}, manager: "pipx", want: InstallInstruction{Type: "apt", Package: "pipx"}},
+		{name: "pipx with pacman", sys: staticSystemInfo{os: "linux"}, found: []string{"pacman"}, manager: "pipx", want: InstallInstruction{Type: "pacman", Package: "python-pipx"}},
+		{name: "pipx with brew on darwin", sys: staticSystemInfo{os: "darwin"}, found: []string{"brew", "apt-get"}, manager: "pipx", want: InstallInstruction{Type: "brew", Package: "pipx"}},
+		{name: "flatpak on darwin", sys: staticSystemInfo{os: "darwin"}, found: []string{"brew"}, manager: "flatpak", wantErr: true},
+		{name: "no system manager", sys: staticSystemInfo{os: "linux"}, manager: "flatpak", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prov := NewProvisioner(tt.sys, nil, nil)
+			prov.lookPath = fakeLookPath(tt.found...)
+			got, err := prov.BootstrapInstruction(tt.manager)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BootstrapInstruction error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecutePlan_BootstrapManagers(t *testing.T) {
+	runner := &fakeExecRunner{}
+	prov := NewProvisioner(staticSystemInfo{os: "linux"}, nil, runner)
+	prov.BootstrapManagers = true
+	prov.lookPath = fakeLookPath("apk")
+	plan := []InstallInstruction{{Type: "pipx", Package: "black", Key: "black"}}
+	if err := prov.ExecutePlan(plan); err != nil {
+		t.Fatalf("ExecutePlan error: %v", err)
+	}
+	bootstrap := slices.Index(runner.Commands, "apk pipx")
+	install := slices.Index(runner.Commands, "pipx black")
+	if bootstrap < 0 || install < bootstrap {
+		t.Errorf("expected pipx to be bootstrapped before installing, got %q", runner.Commands)
+	}
+}
+
+func TestExecutePlan_BootstrapDisabled(t *testing.T) {
+	runner := &fakeExecRunner{}
+	prov := NewProvisioner(staticSystemInfo{os: "linux"}, nil, runner)
+	prov.lookPath = fakeLookPath("apk")
+	if err := prov.ExecutePlan([]InstallInstruction{{Type: "pipx", Package: "black", Key: "black"}}); err != nil {
+		t.Fatalf("ExecutePlan error: %v", err)
+	}
+	if slices.Contains(runner.Commands, "apk pipx") {
+		t.Errorf("did not expect a bootstrap without BootstrapManagers, got %q", runner.Commands)
+	}
+}
+
+func TestExecutePlan_BootstrapFailureIsReported(t *testing.T) {
+	prov := NewProvisioner(staticSystemInfo{os: "linux"}, nil, &fakeExecRunner{})
+	prov.BootstrapManagers = true
+	prov.lookPath = fakeLookPath()
+	err := prov.ExecutePlan([]InstallInstruction{{Type: "flatpak", Package: "org.gimp.GIMP", Key: "gimp"}})
+	if err == nil || !strings.Contains(err.Error(), "cannot bootstrap flatpak") {
+		t.Errorf("expected bootstrap error, got %v", err)
+	}
+}
+
+func TestExecutePlan_BootstrapDryRun(t *testing.T) {
+	prov := NewProvisioner(staticSystemInfo{os: "linux"}, nil, &fakeExecRunner{})
+	prov.BootstrapManagers = true
+	prov.DryRun = true
+	prov.lookPath = fakeLookPath("dnf")
+	if err := prov.ExecutePlan([]InstallInstruction{{Type: "flatpak", Package: "org.gimp.GIMP", Key: "gimp"}}); err != nil {
+		t.Fatalf("ExecutePlan error: %v", err)
+	}
+	want := []string{"bootstrap flatpak: sudo dnf install -y flatpak", "flatpak org.gimp.GIMP"}
+	if got := prov.DryRunCommands(); !slices.Equal(got, want) {
+		t.Errorf("got dry-run log %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,207 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"a-la-carte/internal/app"
+)
+
+// Cache modes of Provisioner.CacheMode, for provisioning machines without internet.
+const (
+	CacheDownload = "download" // fetch the packages into CacheDir without installing them
+	CacheOffline  = "offline"  // install the packages from CacheDir without network access
+)
+
+// Prefixes of the instruction types PlanProvision plans in a cache mode, followed by
+// the installer, e.g. "download:apt" or "offline:binary:linux".
+const (
+	DownloadPrefix = "download:"
+	OfflinePrefix  = "offline:"
+)
+
+// cacheCommand builds the command that downloads a package into the installer's cache
+// directory, or that installs it from there.
+type cacheCommand func(pkg, dir string) []string
+
+// cacheCommands are the installers that support the cache modes. apt and pacman
+// install from their regular package index, so an offline machine needs the same
+// index as the one that downloaded (e.g. the same image); the archives come from dir.
+var cacheCommands = map[string]struct{ download, offline cacheCommand }{
+	"apt": {
+		download: func(pkg, dir string) []string {
+			return []string{"sudo", "sh", "-c", fmt.Sprintf("mkdir -p %s && apt-get install -y --download-only --no-install-recommends -o Dir::Cache::archives=%s %s",
+				shellQuote(filepath.Join(dir, "partial")), shellQuote(dir), shellQuote(pkg))}
+		},
+		offline: func(pkg, dir string) []string {
+			return []string{"sudo", "env", "DEBIAN_FRONTEND=noninteractive", "apt-get", "install", "-y", "--no-download", "--no-install-recommends", "-o", "Dir::Cache::archives=" + dir, pkg}
+		},
+	},
+	"pacman": {
+		download: func(pkg, dir string) []string {
+			return []string{"sudo", "pacman", "-Sw", "--noconfirm", "--cachedir", dir, pkg}
+		},
+		offline: func(pkg, dir string) []string {
+			return []string{"sudo", "pacman", "-S", "--noconfirm", "--needed", "--cachedir", dir, pkg}
+		},
+	},
+	"brew": {
+		download: func(pkg, dir string) []string {
+			return []string{"env", "HOMEBREW_CACHE=" + dir, "brew", "fetch", "--deps", pkg}
+		},
+		offline: func(pkg, dir string) []string {
+			return []string{"env", "HOMEBREW_CACHE=" + dir, "HOMEBREW_NO_AUTO_UPDATE=1", "brew", "install", pkg}
+		},
+	},
+	"cask": {
+		download: func(pkg, dir string) []string {
+			return []string{"env", "HOMEBREW_CACHE=" + dir, "brew", "fetch", "--cask", pkg}
+		},
+		offline: func(pkg, dir string) []string {
+			return []string{"env", "HOMEBREW_CACHE=" + dir, "HOMEBREW_NO_AUTO_UPDATE=1", "brew", "install", "--cask", pkg}
+		},
+	},
+	"pipx": {
+		download: func(pkg, dir string) []string {
+			return []string{"python3", "-m", "pip", "download", "--dest", dir, pkg}
+		},
+		offline: func(pkg, dir string) []string {
+			return []string{"pipx", "install", "--pip-args=--no-index --find-links=" + dir, pkg}
+		},
+	},
+}
+
+// DefaultCacheDir returns where packages are cached for offline provisioning,
+// $XDG_CACHE_HOME/a-la-carte/packages or ~/.cache/a-la-carte/packages.
+func DefaultCacheDir() string {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "a-la-carte", "packages")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".a-la-carte", "packages")
+	}
+	return filepath.Join(home, ".cache", "a-la-carte", "packages")
+}
+
+// isBinaryType reports whether an instruction type downloads a prebuilt binary.
+func isBinaryType(instType string) bool {
+	return strings.HasPrefix(instType, "binary:")
+}
+
+// cachePlan rewrites a plan for CacheMode: package and binary instructions of the
+// installers that support caching become download or offline instructions. Services
+// are kept offline, as they need no network. Everything else (scripts, repositories,
+// hooks, other installers) is left out with a warning.
+func (p *Provisioner) cachePlan(plan []InstallInstruction) []InstallInstruction {
+	if p.CacheMode == "" {
+		return plan
+	}
+	prefix, action := DownloadPrefix, "downloaded"
+	if p.CacheMode == CacheOffline {
+		prefix, action = OfflinePrefix, "installed offline"
+	}
+	var cached []InstallInstruction
+	for _, inst := range plan {
+		_, supported := cacheCommands[inst.Type]
+		switch {
+		case supported || isBinaryType(inst.Type):
+			inst.Type = prefix + inst.Type
+			cached = append(cached, inst)
+		case p.CacheMode == CacheOffline && ServiceCommands(inst) != nil:
+			cached = append(cached, inst)
+		default:
+			p.warn(PlanWarning{Key: inst.Key, Message: fmt.Sprintf("%s %s of %s cannot be %s; skipping it", inst.Type, inst.Package, inst.Key, action)})
+		}
+	}
+	return cached
+}
+
+// cacheDir returns the cache directory of an installer inside CacheDir.
+func (p *Provisioner) cacheDir(installer string) string {
+	dir := p.CacheDir
+	if dir == "" {
+		dir = DefaultCacheDir()
+	}
+	if isBinaryType(installer) {
+		installer = "binary"
+	}
+	return filepath.Join(dir, installer)
+}
+
+// CacheCommand returns the command of a download or offline package instruction, or
+// nil if the instruction is neither (or is a binary, which is downloaded in-process).
+//
+// # Example
+//
+//	p.CacheCommand(InstallInstruction{Type: "download:brew", Package: "bat"})
+//	// ["env", "HOMEBREW_CACHE=<CacheDir>/brew", "brew", "fetch", "--deps", "bat"]
+func (p *Provisioner) CacheCommand(inst InstallInstruction) []string {
+	if installer, ok := strings.CutPrefix(inst.Type, DownloadPrefix); ok {
+		if cmds, ok := cacheCommands[installer]; ok {
+			return cmds.download(inst.Package, p.cacheDir(installer))
+		}
+	}
+	if installer, ok := strings.CutPrefix(inst.Type, OfflinePrefix); ok {
+		if cmds, ok := cacheCommands[installer]; ok {
+			return cmds.offline(inst.Package, p.cacheDir(installer))
+		}
+	}
+	return nil
+}
+
+// cachedBinaryPath returns where the download of a binary instruction is cached. The
+// file keeps the download's name, prefixed with the key since names like
+// linux-amd64.tar.gz are common.
+func (p *Provisioner) cachedBinaryPath(key, url string) string {
+	_, bare := app.SplitKey(key)
+	return filepath.Join(p.cacheDir("binary"), bare+"-"+path.Base(strings.SplitN(url, "?", 2)[0]))
+}
+
+// runCachedBinary downloads a binary instruction into the cache, or installs it from
+// there, through the runner if it is a BinaryInstaller (e.g. to print it in a dry run).
+func (p *Provisioner) runCachedBinary(ctx context.Context, inst InstallInstruction) error {
+	plain := inst
+	plain.Type = installerOf(inst.Type)
+	b := p.binaryInstall(plain)
+	cached := p.cachedBinaryPath(inst.Key, b.URL)
+	if strings.HasPrefix(inst.Type, OfflinePrefix) {
+		b.URL = "file://" + cached
+		if installer, ok := p.Runner.(BinaryInstaller); ok {
+			return installer.InstallBinary(ctx, b)
+		}
+		return InstallBinary(ctx, b)
+	}
+	if installer, ok := p.Runner.(BinaryInstaller); ok {
+		return installer.InstallBinary(ctx, BinaryInstall{URL: b.URL, SHA256: b.SHA256, Bins: []string{filepath.Base(cached)}, Dir: filepath.Dir(cached)})
+	}
+	_ = p.Runner.Run("info", fmt.Sprintf("Downloading %s to %s", b.URL, cached))
+	return downloadFile(ctx, b.URL, cached, b.SHA256)
+}
+
+// downloadFile downloads url to the file at dest, which is only replaced once the
+// download is complete and its checksum matches sum (if set).
+func downloadFile(ctx context.Context, url, dest, sum string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".download-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if err := download(ctx, url, tmp, sum); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
@@ -0,0 +1,103 @@
+package provision
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestPlanProvisionCacheModes(t *testing.T) {
+	manifest := app.Manifest{
+		"bat":    {Apt: app.StringOrSlice{"bat"}},
+		"fd":     {Brew: app.StringOrSlice{"fd"}},
+		"custom": {Script: app.StringOrSlice{"echo hi"}},
+	}
+	prov := NewProvisioner(nil, manifest, nil)
+	prov.InstallerOrder = []string{"apt", "brew"}
+	prov.CacheMode = CacheDownload
+	prov.CacheDir = "/cache"
+	plan, err := prov.PlanProvision([]string{"bat", "custom", "fd"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	want := []InstallInstruction{
+		{Type: "download:apt", Package: "bat", Key: "bat"},
+		{Type: "download:brew", Package: "fd", Key: "fd"},
+	}
+	if !slices.Equal(plan, want) {
+		t.Errorf("got plan %+v, want %+v", plan, want)
+	}
+	if len(prov.Warnings) != 1 || prov.Warnings[0].Key != "custom" {
+		t.Errorf("expected a warning for the script, got %+v", prov.Warnings)
+	}
+
+	runner := &fakeExecRunner{}
+	prov.Runner = runner
+	if err := prov.ExecutePlan(plan); err != nil {
+		t.Fatalf("ExecutePlan error: %v", err)
+	}
+	for _, cmd := range []string{
+		"sudo sh -c mkdir -p '/cache/apt/partial' && apt-get install -y --download-only --no-install-recommends -o Dir::Cache::archives='/cache/apt' 'bat'",
+		"env HOMEBREW_CACHE=/cache/brew brew fetch --deps fd",
+	} {
+		if !slices.Contains(runner.Commands, cmd) {
+			t.Errorf("expected command %q, got %v", cmd, runner.Commands)
+		}
+	}
+
+	prov.CacheMode = CacheOffline
+	runner.Commands = nil
+	plan, err = prov.PlanProvision([]string{"bat"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	if err := prov.ExecutePlan(plan); err != nil {
+		t.Fatalf("ExecutePlan error: %v", err)
+	}
+	want2 := "sudo env DEBIAN_FRONTEND=noninteractive apt-get install -y --no-download --no-install-recommends -o Dir::Cache::archives=/cache/apt bat"
+	if !slices.Contains(runner.Commands, want2) {
+		t.Errorf("expected command %q, got %v", want2, runner.Commands)
+	}
+}
+
+func TestCachedBinary(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("raw-bin"))
+	}))
+	defer srv.Close()
+	manifest := app.Manifest{"tool": {BinaryLinux: app.StringOrSlice{srv.URL + "/tool-{os}"}}}
+	prov := NewProvisioner(staticSystemInfo{os: "linux", arch: "x86_64", id: "ubuntu"}, manifest, &fakeExecRunner{})
+	prov.CacheDir = t.TempDir()
+	prov.BinDir = t.TempDir()
+
+	prov.CacheMode = CacheDownload
+	plan, err := prov.PlanProvision([]string{"tool"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	if err := prov.ExecutePlan(plan); err != nil {
+		t.Fatalf("ExecutePlan(download) error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(prov.CacheDir, "binary", "tool-tool-linux")); err != nil {
+		t.Fatalf("expected the download in the cache: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(prov.BinDir, "tool")); err == nil {
+		t.Error("expected nothing to be installed by a download")
+	}
+
+	srv.Close()
+	prov.CacheMode = CacheOffline
+	plan, err = prov.PlanProvision([]string{"tool"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	if err := prov.ExecutePlan(plan); err != nil {
+		t.Fatalf("ExecutePlan(offline) error: %v", err)
+	}
+	assertExecutable(t, filepath.Join(prov.BinDir, "tool"), "raw-bin")
+}
@@ -0,0 +1,63 @@
+package provision
+
+import (
+	"fmt"
+
+	"a-la-carte/internal/app"
+)
+
+// dropConflicts resolves conflicts among expanded keys (see app.Manifest.Conflict): the
+// first key of each conflicting pair is kept and the later one is skipped with a warning,
+// so the plan never installs both.
+func (p *Provisioner) dropConflicts(keys []string) []string {
+	var kept []string
+	for _, key := range keys {
+		conflict, found := app.Conflict{}, false
+		for _, other := range kept {
+			if conflict, found = p.Manifest.Conflict(key, other); found {
+				break
+			}
+		}
+		if !found {
+			kept = append(kept, key)
+			continue
+		}
+		p.warn(PlanWarning{Key: key, Message: conflict.String() + "; skipping " + key})
+		with := conflict.With
+		if with == key {
+			with = conflict.Key
+		}
+		p.skip(key, SkipConflict, fmt.Sprintf("conflicts with %s", with))
+	}
+	return kept
+}
+
+// provider returns the entry a deps reference to a _provides name resolves to: one that
+// is already part of the plan if there is one, else the first that does not conflict with
+// the plan, in key order.
+func (p *Provisioner) provider(name string, visited map[string]bool) (string, bool) {
+	providers := p.manifestIndex().Providers(name)
+	for _, key := range providers {
+		if _, bare := app.SplitKey(key); visited[bare] {
+			return key, true
+		}
+	}
+	for _, key := range providers {
+		if !p.conflictsWithAny(key, visited) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// conflictsWithAny reports whether key conflicts with any visited key.
+func (p *Provisioner) conflictsWithAny(key string, visited map[string]bool) bool {
+	for bare := range visited {
+		for _, other := range p.manifestIndex().Bare(bare) {
+			if _, found := p.Manifest.Conflict(key, other); found {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package provision
+
+import (
+	"slices"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestPlanProvisionConflicts(t *testing.T) {
+	manifest := app.Manifest{
+		"neovim":         {Apt: app.StringOrSlice{"neovim"}, Provides: app.StringOrSlice{"editor"}},
+		"neovim-nightly": {Apt: app.StringOrSlice{"neovim-nightly"}, Conflicts: app.StringOrSlice{"neovim"}, Provides: app.StringOrSlice{"editor"}},
+		"plugins":        {Apt: app.StringOrSlice{"plugins"}, Deps: app.StringOrSlice{"editor"}},
+	}
+	prov := NewProvisioner(&fakeSystemInfo{}, manifest, &fakeExecRunner{})
+	plan, err := prov.PlanProvision([]string{"neovim-nightly", "neovim"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	if len(plan) != 1 || plan[0].Package != "neovim-nightly" {
+		t.Errorf("expected only the first of the conflicting keys, got %+v", plan)
+	}
+	skipped := prov.PlanReport.Skipped()
+	if len(skipped) != 1 || skipped[0].Reason != SkipConflict || skipped[0].Detail != "conflicts with neovim-nightly" {
+		t.Errorf("expected neovim to be skipped as a conflict, got %v", skipped)
+	}
+	if len(prov.Warnings) != 1 {
+		t.Errorf("expected a conflict warning, got %+v", prov.Warnings)
+	}
+
+	// A dep on a provided name uses the provider already in the plan
+	plan, err = prov.PlanProvision([]string{"neovim-nightly", "plugins"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	var got []string
+	for _, inst := range plan {
+		got = append(got, inst.Package)
+	}
+	if want := []string{"neovim-nightly", "plugins"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	// Otherwise the first provider in key order
+	plan, err = prov.PlanProvision([]string{"plugins"}, nil)
+	if err != nil || len(plan) != 2 || plan[0].Package != "neovim" {
+		t.Errorf("expected neovim to provide the editor, got %+v, %v", plan, err)
+	}
+}
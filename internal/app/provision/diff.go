@@ -0,0 +1,96 @@
+package provision
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// PlanDiff compares a selection with what is installed, like `chezmoi diff` does for files.
+//
+// # Fields
+//   - Install:    Selected keys, and the deps they pull in, that are not installed yet
+//   - Installed:  Selected keys, and the deps they pull in, that are already installed
+//   - Unselected: Installed manifest keys that are not selected (candidates for removal)
+type PlanDiff struct {
+	Install    []string
+	Installed  []string
+	Unselected []string
+}
+
+// Diff compares the selected keys with the installed packages. Deps are expanded as
+// for PlanProvision, and Exclude and SkipGroups apply. Each list is sorted.
+//
+// # Parameters
+//   - keys:      The selected manifest keys
+//   - installed: Installed packages, as returned by GetInstalledPackages
+//
+// # Returns
+//   - PlanDiff: The three columns of the diff
+//   - error:    If a selected key or (with StrictDeps) a dep is not in the manifest
+func (p *Provisioner) Diff(keys []string, installed map[string]bool) (PlanDiff, error) {
+	if installed == nil {
+		installed = map[string]bool{}
+	}
+	p.Warnings = nil
+	p.PlanReport = &PlanReport{}
+	p.installed = installed
+	selected, err := p.expandDeps(p.preferByPriority(keys), "", make(map[string]bool))
+	if err != nil {
+		return PlanDiff{}, err
+	}
+	var diff PlanDiff
+	for _, key := range selected {
+		if p.shouldSkipInstalled(key, installed) {
+			diff.Installed = append(diff.Installed, key)
+		} else {
+			diff.Install = append(diff.Install, key)
+		}
+	}
+	for key := range p.Manifest {
+		if !slices.Contains(selected, key) && p.shouldSkipInstalled(key, installed) {
+			diff.Unselected = append(diff.Unselected, key)
+		}
+	}
+	slices.Sort(diff.Install)
+	slices.Sort(diff.Installed)
+	slices.Sort(diff.Unselected)
+	return diff, nil
+}
+
+// Columns returns the diff as rows of three cells, headed by the column titles.
+// Shorter columns are padded with empty cells.
+func (d PlanDiff) Columns() [][3]string {
+	rows := [][3]string{{"WILL INSTALL", "ALREADY INSTALLED", "NOT SELECTED"}}
+	for i := 0; i < max(len(d.Install), len(d.Installed), len(d.Unselected)); i++ {
+		var row [3]string
+		for col, keys := range [3][]string{d.Install, d.Installed, d.Unselected} {
+			if i < len(keys) {
+				row[col] = keys[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// WriteDiff writes the diff as three aligned columns followed by the counts.
+func WriteDiff(w io.Writer, d PlanDiff) error {
+	rows := d.Columns()
+	var width [2]int
+	for _, row := range rows {
+		for col := range width {
+			width[col] = max(width[col], utf8.RuneCountInString(row[col]))
+		}
+	}
+	var b strings.Builder
+	for _, row := range rows {
+		line := fmt.Sprintf("%-*s  %-*s  %s", width[0], row[0], width[1], row[1], row[2])
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	fmt.Fprintf(&b, "\n%d to install, %d already installed, %d installed but not selected\n", len(d.Install), len(d.Installed), len(d.Unselected))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
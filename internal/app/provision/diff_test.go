@@ -0,0 +1,45 @@
+package provision
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestDiff(t *testing.T) {
+	manifest := app.Manifest{
+		"app":    {Apt: app.StringOrSlice{"app"}, Deps: []string{"lib"}},
+		"lib":    {Apt: app.StringOrSlice{"lib"}},
+		"jq":     {Apt: app.StringOrSlice{"jq"}},
+		"old":    {Apt: app.StringOrSlice{"old"}},
+		"unused": {Apt: app.StringOrSlice{"unused"}},
+	}
+	prov := NewProvisioner(nil, manifest, nil)
+	diff, err := prov.Diff([]string{"jq", "app"}, map[string]bool{"lib": true, "old": true})
+	if err != nil {
+		t.Fatalf("Diff error: %v", err)
+	}
+	if !slices.Equal(diff.Install, []string{"app", "jq"}) || !slices.Equal(diff.Installed, []string{"lib"}) || !slices.Equal(diff.Unselected, []string{"old"}) {
+		t.Errorf("unexpected diff %+v", diff)
+	}
+
+	var b strings.Builder
+	if err := WriteDiff(&b, diff); err != nil {
+		t.Fatalf("WriteDiff error: %v", err)
+	}
+	want := `WILL INSTALL  ALREADY INSTALLED  NOT SELECTED
+app           lib                old
+jq
+
+2 to install, 1 already installed, 1 installed but not selected
+`
+	if b.String() != want {
+		t.Errorf("WriteDiff output:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	if _, err := prov.Diff([]string{"missing"}, nil); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+}
@@ -0,0 +1,51 @@
+package provision
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrLowDiskSpace is returned when there is not enough free disk space to execute a plan.
+var ErrLowDiskSpace = errors.New("low disk space")
+
+// EstimatePlanSizeMB returns the estimated download/install size of a plan in MB.
+// Each manifest key is counted once; entries without a known _size count as zero.
+func (p *Provisioner) EstimatePlanSizeMB(plan []InstallInstruction) uint64 {
+	var total uint64
+	seen := make(map[string]bool)
+	for _, inst := range plan {
+		if inst.Key == "" || seen[inst.Key] {
+			continue
+		}
+		seen[inst.Key] = true
+		if size := p.Manifest[inst.Key].Size; size > 0 {
+			total += uint64(size)
+		}
+	}
+	return total
+}
+
+// CheckDiskSpace verifies that at least MinFreeSpaceMB would remain free on DiskPath
+// after installing the plan.
+//
+// # Returns
+//   - error: wrapping ErrLowDiskSpace if the check fails, or the error from reading free space
+func (p *Provisioner) CheckDiskSpace(plan []InstallInstruction) error {
+	path := p.DiskPath
+	if path == "" {
+		path = defaultDiskPath()
+	}
+	freeSpace := p.freeSpace
+	if freeSpace == nil {
+		freeSpace = freeDiskSpaceMB
+	}
+	free, err := freeSpace(path)
+	if err != nil {
+		return fmt.Errorf("checking free disk space on %s: %w", path, err)
+	}
+	estimate := p.EstimatePlanSizeMB(plan)
+	if free < estimate+p.MinFreeSpaceMB {
+		return fmt.Errorf("%w: %d MB free on %s, plan needs about %d MB plus %d MB headroom", ErrLowDiskSpace, free, path, estimate, p.MinFreeSpaceMB)
+	}
+	return nil
+}
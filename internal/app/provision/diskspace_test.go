@@ -0,0 +1,74 @@
+package provision
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestEstimatePlanSizeMB(t *testing.T) {
+	manifest := app.Manifest{
+		"big":     app.SoftwareEntry{Size: 300},
+		"small":   app.SoftwareEntry{Size: 20},
+		"unknown": app.SoftwareEntry{},
+	}
+	prov := NewProvisioner(&fakeSystemInfo{}, manifest, nil)
+	plan := []InstallInstruction{
+		{Type: "script", Package: "echo", Key: "big"},
+		{Type: "apt", Package: "big", Key: "big"},
+		{Type: "apt", Package: "small", Key: "small"},
+		{Type: "apt", Package: "unknown", Key: "unknown"},
+	}
+	if got := prov.EstimatePlanSizeMB(plan); got != 320 {
+		t.Errorf("expected 320 MB, got %d", got)
+	}
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	manifest := app.Manifest{"big": app.SoftwareEntry{Size: 300}}
+	plan := []InstallInstruction{{Type: "apt", Package: "big", Key: "big"}}
+	prov := NewProvisioner(&fakeSystemInfo{}, manifest, nil)
+	prov.MinFreeSpaceMB = 100
+	prov.freeSpace = func(string) (uint64, error) { return 500, nil }
+	if err := prov.CheckDiskSpace(plan); err != nil {
+		t.Errorf("expected enough space, got: %v", err)
+	}
+	prov.freeSpace = func(string) (uint64, error) { return 350, nil }
+	if err := prov.CheckDiskSpace(plan); !errors.Is(err, ErrLowDiskSpace) {
+		t.Errorf("expected ErrLowDiskSpace, got: %v", err)
+	}
+}
+
+func TestExecutePlan_LowDiskSpace(t *testing.T) {
+	manifest := app.Manifest{"big": app.SoftwareEntry{Size: 300}}
+	plan := []InstallInstruction{{Type: "apt", Package: "big", Key: "big"}}
+	runner := &fakeExecRunner{}
+	prov := NewProvisioner(&fakeSystemInfo{}, manifest, runner)
+	prov.MinFreeSpaceMB = 100
+	prov.freeSpace = func(string) (uint64, error) { return 10, nil }
+	if err := prov.ExecutePlan(plan); !errors.Is(err, ErrLowDiskSpace) {
+		t.Fatalf("expected ExecutePlan to abort with ErrLowDiskSpace, got: %v", err)
+	}
+	if len(runner.Commands) != 0 {
+		t.Errorf("expected no commands after aborting, got: %v", runner.Commands)
+	}
+
+	prov.DiskSpaceWarnOnly = true
+	if err := prov.ExecutePlan(plan); err != nil {
+		t.Fatalf("expected warn-only mode to continue, got: %v", err)
+	}
+
+	// Free space that cannot be read is a warning, not a reason to abort
+	prov.DiskSpaceWarnOnly = false
+	runner.Commands = nil
+	prov.freeSpace = func(string) (uint64, error) { return 0, errors.New("statfs: not supported") }
+	if err := prov.ExecutePlan(plan); err != nil {
+		t.Fatalf("expected an unreadable free space to be skipped, got: %v", err)
+	}
+	if !slices.Contains(runner.Commands, "apt big") || !strings.Contains(strings.Join(runner.Commands, "\n"), "Warning: checking free disk space") {
+		t.Errorf("expected a warning and big to be installed, got: %v", runner.Commands)
+	}
+}
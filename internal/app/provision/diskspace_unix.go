@@ -0,0 +1,19 @@
+//go:build !windows
+
+package provision
+
+import "syscall"
+
+// defaultDiskPath is the root filesystem, where packages are installed.
+func defaultDiskPath() string {
+	return "/"
+}
+
+// freeDiskSpaceMB returns the space available to unprivileged users on the filesystem containing path, in MB.
+func freeDiskSpaceMB(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize) / (1024 * 1024), nil
+}
@@ -0,0 +1,33 @@
+//go:build windows
+
+package provision
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// getDiskFreeSpaceEx is the Win32 function reporting the free space of a volume.
+var getDiskFreeSpaceEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// defaultDiskPath is the root of the system drive, where packages are installed.
+func defaultDiskPath() string {
+	if drive := os.Getenv("SystemDrive"); drive != "" {
+		return drive + `\`
+	}
+	return `C:\`
+}
+
+// freeDiskSpaceMB returns the space available to the current user on the volume containing path, in MB.
+func freeDiskSpaceMB(path string) (uint64, error) {
+	dir, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var available uint64
+	if ok, _, err := getDiskFreeSpaceEx.Call(uintptr(unsafe.Pointer(dir)), uintptr(unsafe.Pointer(&available)), 0, 0); ok == 0 {
+		return 0, err
+	}
+	return available / (1024 * 1024), nil
+}
@@ -0,0 +1,140 @@
+package provision
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultChezmoiScriptName is the conventional file name for an exported install script.
+const DefaultChezmoiScriptName = "run_onchange_install-packages.sh.tmpl"
+
+// exportTarget is an OS/distro combination rendered as one guarded block of an exported script.
+type exportTarget struct {
+	os         string
+	id         string
+	installers []string // installers available on the platform, in preference order
+}
+
+// portableInstallers are language and cross-distro installers usable on any Linux target.
+var portableInstallers = []string{"flatpak", "snap", "brew", "go", "cargo", "pipx", "nix", "binary:linux"}
+
+// exportTargets are the platforms an exported chezmoi script is planned for, in guard order.
+var exportTargets = []exportTarget{
+	{os: "darwin", id: "darwin", installers: []string{"brew", "cask", "port", "mas", "go", "cargo", "pipx", "nix", "binary:darwin"}},
+	{os: "linux", id: "ubuntu", installers: append([]string{"apt"}, portableInstallers...)},
+	{os: "linux", id: "debian", installers: append([]string{"apt"}, portableInstallers...)},
+	{os: "linux", id: "fedora", installers: append([]string{"dnf"}, portableInstallers...)},
+	{os: "linux", id: "arch", installers: append([]string{"pacman", "yay"}, portableInstallers...)},
+	{os: "linux", id: "alpine", installers: append([]string{"apk"}, portableInstallers...)},
+	{os: "linux", id: "opensuse-tumbleweed", installers: append([]string{"zypper"}, portableInstallers...)},
+}
+
+// guard returns the chezmoi template condition matching the target.
+func (t exportTarget) guard() string {
+	if t.os == "darwin" {
+		return `eq .chezmoi.os "darwin"`
+	}
+	return fmt.Sprintf(`and (eq .chezmoi.os %q) (eq .chezmoi.osRelease.id %q)`, t.os, t.id)
+}
+
+// staticSystemInfo is a SystemInfo with fixed values, used to plan for platforms other than the host.
+type staticSystemInfo struct {
+	os   string
+	arch string
+	id   string
+}
+
+func (s staticSystemInfo) OS() string       { return s.os }
+func (s staticSystemInfo) Arch() string     { return s.arch }
+func (s staticSystemInfo) ID() string       { return s.id }
+func (s staticSystemInfo) IsHeadless() bool { return false }
+
+// ShellCommand returns the shell command line that installs a single instruction.
+//
+// # Example
+//
+//	ShellCommand(InstallInstruction{Type: "brew", Package: "bat"}) // "brew install bat"
+func ShellCommand(inst InstallInstruction) string {
+	switch inst.Type {
+	case "apt":
+		return "sudo env DEBIAN_FRONTEND=noninteractive apt-get install -y --no-install-recommends " + inst.Package
+	case "apk":
+		return "sudo apk add --no-cache " + inst.Package
+	case "dnf", "yum":
+		return "sudo " + inst.Type + " install -y " + inst.Package
+	case "zypper":
+		return "sudo zypper --non-interactive install -y " + inst.Package
+	case "pacman":
+		return "sudo pacman -S --noconfirm --needed " + inst.Package
+	case "yay":
+		return "yay -S --noconfirm --needed " + inst.Package
+	case "brew", "go", "cargo", "pipx", "port", "mas", "scoop", "choco", "snap", "flatpak":
+		return inst.Type + " install " + inst.Package
+	case "cask":
+		return "brew install --cask " + inst.Package
+	case AptRepo, DnfRepo:
+		return "sudo sh -c " + shellQuote(inst.Package)
+	default:
+		if IsServiceInstruction(inst.Type) {
+			var cmds []string
+			for _, cmd := range ServiceCommands(inst) {
+				cmds = append(cmds, strings.Join(cmd, " "))
+			}
+			return strings.Join(cmds, " && ")
+		}
+		if cmd := UpgradeCommand(inst); cmd != nil {
+			return strings.Join(cmd, " ")
+		}
+		return inst.Type + " " + inst.Package
+	}
+}
+
+// ExportChezmoiScript renders the plan for the given keys as a chezmoi run_onchange
+// script template. Each supported platform is planned separately and wrapped in a
+// chezmoi template guard, so the script installs the right packages wherever it runs.
+// Each platform uses its own installer order; Provisioner.InstallerOrder is ignored.
+//
+// # Parameters
+//   - keys: The manifest keys to include
+//
+// # Returns
+//   - string: The script template contents
+//   - error:  If planning fails (e.g. an unknown key)
+func (p *Provisioner) ExportChezmoiScript(keys []string) (string, error) {
+	var b strings.Builder
+	b.WriteString("#!/usr/bin/env bash\n")
+	b.WriteString("# Generated by chezmoi-a-la-carte. chezmoi re-runs this script whenever its contents change.\n")
+	b.WriteString("set -euo pipefail\n\n")
+
+	first := true
+	for _, target := range exportTargets {
+		planner := *p
+		planner.System = staticSystemInfo{os: target.os, id: target.id}
+		planner.Runner = nil
+		planner.InstallerOrder = target.installers
+		plan, err := planner.PlanProvision(keys, nil)
+		if err != nil {
+			return "", err
+		}
+		if len(plan) == 0 {
+			continue
+		}
+		keyword := "else if"
+		if first {
+			keyword = "if"
+			first = false
+		}
+		fmt.Fprintf(&b, "{{ %s %s -}}\n", keyword, target.guard())
+		for _, inst := range plan {
+			if isScriptType(inst.Type) {
+				b.WriteString(scriptCommand(inst) + "\n")
+				continue
+			}
+			b.WriteString(ShellCommand(inst) + "\n")
+		}
+	}
+	if !first {
+		b.WriteString("{{ end -}}\n")
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,93 @@
+package provision
+
+import (
+	"strings"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestExportChezmoiScript(t *testing.T) {
+	manifest := app.Manifest{
+		"bat": app.SoftwareEntry{
+			Brew:   app.StringOrSlice{"bat"},
+			Apt:    app.StringOrSlice{"bat"},
+			Pacman: app.StringOrSlice{"bat"},
+		},
+	}
+	prov := NewProvisioner(nil, manifest, &fakeExecRunner{})
+	script, err := prov.ExportChezmoiScript([]string{"bat"})
+	if err != nil {
+		t.Fatalf("ExportChezmoiScript error: %v", err)
+	}
+	wants := []string{
+		"#!/usr/bin/env bash",
+		"{{ if eq .chezmoi.os \"darwin\" -}}\nbrew install bat\n",
+		"(eq .chezmoi.osRelease.id \"ubuntu\") -}}\nsudo env DEBIAN_FRONTEND=noninteractive apt-get install -y --no-install-recommends bat\n",
+		"(eq .chezmoi.osRelease.id \"arch\") -}}\nsudo pacman -S --noconfirm --needed bat\n",
+		// Fedora has no dnf package, so it falls back to a portable installer
+		"(eq .chezmoi.osRelease.id \"fedora\") -}}\nbrew install bat\n",
+		"{{ end -}}",
+	}
+	for _, want := range wants {
+		if !strings.Contains(script, want) {
+			t.Errorf("expected script to contain %q, got:\n%s", want, script)
+		}
+	}
+}
+
+func TestExportChezmoiScript_UnknownKey(t *testing.T) {
+	prov := NewProvisioner(nil, app.Manifest{}, nil)
+	if _, err := prov.ExportChezmoiScript([]string{"missing"}); err == nil {
+		t.Error("expected error for unknown key, got nil")
+	}
+}
+
+func TestExport(t *testing.T) {
+	manifest := app.Manifest{
+		"bat":    {Brew: app.StringOrSlice{"bat"}, Apt: app.StringOrSlice{"bat"}, Nix: app.StringOrSlice{"bat"}},
+		"fd":     {Brew: app.StringOrSlice{"fd"}, Apt: app.StringOrSlice{"fd-find"}, NixEnv: app.StringOrSlice{"nixpkgs.fd"}},
+		"xcode":  {Name: "Xcode", Mas: app.StringOrSlice{"497799835"}},
+		"kitty":  {Cask: app.StringOrSlice{"kitty"}, Deps: app.StringOrSlice{"bat"}},
+		"custom": {Script: app.StringOrSlice{"echo hi"}},
+	}
+	prov := NewProvisioner(nil, manifest, nil)
+
+	brewfile, err := prov.Export("brewfile", []string{"kitty", "fd", "xcode", "custom"})
+	if err != nil {
+		t.Fatalf("Export brewfile error: %v", err)
+	}
+	for _, want := range []string{"brew \"bat\"\ncask \"kitty\"\n", "brew \"fd\"\n", "mas \"Xcode\", id: 497799835\n", "# Not available via Homebrew: custom\n"} {
+		if !strings.Contains(brewfile, want) {
+			t.Errorf("Brewfile missing %q:\n%s", want, brewfile)
+		}
+	}
+
+	nix, err := prov.Export("nix", []string{"bat", "fd", "xcode"})
+	if err != nil {
+		t.Fatalf("Export nix error: %v", err)
+	}
+	if !strings.Contains(nix, "home.packages = with pkgs; [\n  bat\n  fd\n];\n# Not available in nixpkgs: xcode\n") {
+		t.Errorf("unexpected nix fragment:\n%s", nix)
+	}
+
+	ansible, err := prov.Export("ansible", []string{"bat", "fd", "custom", "xcode"})
+	if err != nil {
+		t.Fatalf("Export ansible error: %v", err)
+	}
+	wants := []string{
+		"- name: Install apt packages\n  ansible.builtin.apt:\n    name:\n      - bat\n      - fd-find\n    state: present\n  become: true\n  when: ansible_facts['distribution'] == 'Ubuntu'\n",
+		"- name: Install brew packages\n  community.general.homebrew:\n    name:\n      - bat\n      - fd\n    state: present\n  when: ansible_facts['os_family'] == 'Darwin'\n",
+		"- name: Install custom (script)\n  ansible.builtin.shell: echo hi\n",
+		"ansible.builtin.command: mas install 497799835\n",
+	}
+	for _, want := range wants {
+		if !strings.Contains(ansible, want) {
+			t.Errorf("Ansible tasks missing %q:\n%s", want, ansible)
+		}
+	}
+
+	if _, err := prov.Export("puppet", []string{"bat"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,255 @@
+package provision
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExportFormats are the formats Export translates a selection to.
+var ExportFormats = []string{"ansible", "brewfile", "chezmoi", "nix"}
+
+// exportHeader is the first line of every exported file.
+const exportHeader = "# Generated by chezmoi-a-la-carte"
+
+// Export translates the plan for the given keys into another provisioning system's
+// format, so the curated selection can be used without a-la-carte:
+//
+//   - ansible:  A task list of package tasks per installer, guarded by distribution
+//   - brewfile: A Brewfile for `brew bundle` with the brew, cask and mas packages
+//   - chezmoi:  A chezmoi run_onchange script template (see ExportChezmoiScript)
+//   - nix:      A home-manager `home.packages` fragment with the nix packages
+//
+// Keys the format cannot install are listed in a trailing comment.
+//
+// # Returns
+//   - string: The exported file contents
+//   - error:  If the format is unknown or planning fails (e.g. an unknown key)
+func (p *Provisioner) Export(format string, keys []string) (string, error) {
+	switch format {
+	case "ansible":
+		return p.exportAnsible(keys)
+	case "brewfile":
+		return p.exportBrewfile(keys)
+	case "chezmoi":
+		return p.ExportChezmoiScript(keys)
+	case "nix":
+		return p.exportNix(keys)
+	}
+	return "", fmt.Errorf("unknown export format %q: must be one of %s", format, strings.Join(ExportFormats, ", "))
+}
+
+// planFor plans keys for a target platform and returns the plan along with the keys,
+// including pulled-in deps, that have no instruction the format exports.
+func (p *Provisioner) planFor(target exportTarget, keys []string, exported func(InstallInstruction) bool) ([]InstallInstruction, []string, error) {
+	planner := *p
+	planner.System = staticSystemInfo{os: target.os, id: target.id}
+	planner.Runner = nil
+	planner.InstallerOrder = target.installers
+	expanded, err := planner.expandDeps(planner.preferByPriority(keys), "", make(map[string]bool))
+	if err != nil {
+		return nil, nil, err
+	}
+	plan, err := planner.PlanProvision(keys, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var missing []string
+	for _, key := range expanded {
+		if !slices.ContainsFunc(plan, func(inst InstallInstruction) bool { return inst.Key == key && exported(inst) }) {
+			missing = append(missing, key)
+		}
+	}
+	return plan, missing, nil
+}
+
+// exports reports whether an instruction uses one of the target's installers.
+func (t exportTarget) exports(inst InstallInstruction) bool {
+	return slices.Contains(t.installers, inst.Type)
+}
+
+// writeMissing writes the trailing comment listing the keys an export leaves out.
+func writeMissing(b *strings.Builder, what string, missing []string) {
+	if len(missing) > 0 {
+		fmt.Fprintf(b, "# Not available %s: %s\n", what, strings.Join(missing, ", "))
+	}
+}
+
+// exportBrewfile renders the brew, cask and mas packages of the keys as a Brewfile.
+func (p *Provisioner) exportBrewfile(keys []string) (string, error) {
+	target := exportTarget{os: "darwin", id: "darwin", installers: []string{"brew", "cask", "mas"}}
+	plan, missing, err := p.planFor(target, keys, target.exports)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(exportHeader + ". Install with: brew bundle --file <this file>\n")
+	for _, inst := range plan {
+		switch inst.Type {
+		case "brew", "cask":
+			fmt.Fprintf(&b, "%s %q\n", inst.Type, inst.Package)
+		case "mas":
+			name := p.Manifest[inst.Key].Name
+			if name == "" {
+				name = inst.Key
+			}
+			fmt.Fprintf(&b, "mas %q, id: %s\n", name, inst.Package)
+		}
+	}
+	writeMissing(&b, "via Homebrew", missing)
+	return b.String(), nil
+}
+
+// exportNix renders the nix packages of the keys as a home-manager home.packages fragment.
+func (p *Provisioner) exportNix(keys []string) (string, error) {
+	target := exportTarget{os: "linux", id: "nixos", installers: []string{"nix", "nix-env"}}
+	plan, missing, err := p.planFor(target, keys, target.exports)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(exportHeader + ". Add to your home-manager configuration.\n")
+	b.WriteString("home.packages = with pkgs; [\n")
+	for _, inst := range plan {
+		if target.exports(inst) {
+			b.WriteString("  " + strings.TrimPrefix(inst.Package, "nixpkgs.") + "\n")
+		}
+	}
+	b.WriteString("];\n")
+	writeMissing(&b, "in nixpkgs", missing)
+	return b.String(), nil
+}
+
+// ansibleModules are the Ansible modules that install a list of packages of an
+// installer, and whether they need root. Other instructions run as commands.
+var ansibleModules = map[string]struct {
+	module string
+	become bool
+}{
+	"apt":     {"ansible.builtin.apt", true},
+	"dnf":     {"ansible.builtin.dnf", true},
+	"pacman":  {"community.general.pacman", true},
+	"apk":     {"community.general.apk", true},
+	"zypper":  {"community.general.zypper", true},
+	"snap":    {"community.general.snap", true},
+	"flatpak": {"community.general.flatpak", false},
+	"brew":    {"community.general.homebrew", false},
+	"cask":    {"community.general.homebrew_cask", false},
+	"cargo":   {"community.general.cargo", false},
+}
+
+// ansibleDistributions are the ansible_facts distribution values of the export targets.
+var ansibleDistributions = map[string]string{
+	"ubuntu":              "Ubuntu",
+	"debian":              "Debian",
+	"fedora":              "Fedora",
+	"arch":                "Archlinux",
+	"alpine":              "Alpine",
+	"opensuse-tumbleweed": "openSUSE Tumbleweed",
+}
+
+// ansibleTask is one task of an exported Ansible task list.
+type ansibleTask struct {
+	Name   string
+	Module string
+	Args   any
+	Become bool
+	When   string
+}
+
+// MarshalYAML writes the task's keys in the order Ansible tasks are usually written:
+// name, the module, become and when.
+func (t ansibleTask) MarshalYAML() (any, error) {
+	args := &yaml.Node{}
+	if err := args.Encode(t.Args); err != nil {
+		return nil, err
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	add := func(key string, value *yaml.Node) {
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	}
+	add("name", &yaml.Node{Kind: yaml.ScalarNode, Value: t.Name})
+	add(t.Module, args)
+	if t.Become {
+		add("become", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+	}
+	add("when", &yaml.Node{Kind: yaml.ScalarNode, Value: t.When})
+	return node, nil
+}
+
+// ansiblePackages are the arguments of a package module task. Name is shared with the
+// task being built, so later packages can be appended to it.
+type ansiblePackages struct {
+	Name  *[]string `yaml:"name"`
+	State string    `yaml:"state"`
+}
+
+// exportAnsible renders the plan of every export target as Ansible tasks: package
+// module tasks for the installers Ansible has modules for, and a command or shell task
+// for everything else.
+func (p *Provisioner) exportAnsible(keys []string) (string, error) {
+	var tasks []ansibleTask
+	var missing []string
+	missingCount := make(map[string]int)
+	for _, target := range exportTargets {
+		plan, targetMissing, err := p.planFor(target, keys, func(InstallInstruction) bool { return true })
+		if err != nil {
+			return "", err
+		}
+		for _, key := range targetMissing {
+			if missingCount[key]++; missingCount[key] == len(exportTargets) {
+				missing = append(missing, key)
+			}
+		}
+		when := fmt.Sprintf("ansible_facts['distribution'] == '%s'", ansibleDistributions[target.id])
+		if target.os == "darwin" {
+			when = "ansible_facts['os_family'] == 'Darwin'"
+		}
+		// Consecutive packages of an installer share a task; the plan order is kept so
+		// repositories are still added before the packages that need them
+		var packages *[]string
+		lastType := ""
+		for _, inst := range plan {
+			if mod, ok := ansibleModules[inst.Type]; ok {
+				if inst.Type == lastType {
+					*packages = append(*packages, inst.Package)
+					continue
+				}
+				packages, lastType = &[]string{inst.Package}, inst.Type
+				tasks = append(tasks, ansibleTask{
+					Name:   "Install " + inst.Type + " packages",
+					Module: mod.module,
+					Args:   ansiblePackages{Name: packages, State: "present"},
+					Become: mod.become,
+					When:   when,
+				})
+				continue
+			}
+			lastType = ""
+			task := ansibleTask{Name: fmt.Sprintf("Install %s (%s)", inst.Key, inst.Type), Module: "ansible.builtin.command", Args: ShellCommand(inst), When: when}
+			if isScriptType(inst.Type) {
+				task.Module, task.Args = "ansible.builtin.shell", inst.Package
+			}
+			tasks = append(tasks, task)
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteString(exportHeader + ". Include with ansible.builtin.include_tasks.\n")
+	if len(tasks) > 0 {
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(tasks); err != nil {
+			return "", err
+		}
+		if err := enc.Close(); err != nil {
+			return "", err
+		}
+	}
+	var b strings.Builder
+	b.Write(buf.Bytes())
+	writeMissing(&b, "on any platform", missing)
+	return b.String(), nil
+}
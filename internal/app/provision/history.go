@@ -0,0 +1,141 @@
+package provision
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultExpectedDuration is assumed for keys that have never been installed.
+const defaultExpectedDuration = 30 * time.Second
+
+// History records how long each manifest key took to install, so later runs can
+// estimate how long a plan will take.
+//
+// # Fields
+//   - Durations: Last observed install duration per manifest key
+//   - Deferred:  Keys left over by a time-boxed run, installable with --resume-deferred
+type History struct {
+	Durations map[string]time.Duration `yaml:"durations"`
+	Deferred  []string                 `yaml:"deferred,omitempty"`
+}
+
+// StateDir returns the directory for provisioning and picker state,
+// $XDG_STATE_HOME/a-la-carte or ~/.local/state/a-la-carte.
+func StateDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "a-la-carte")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".a-la-carte"
+	}
+	return filepath.Join(home, ".local", "state", "a-la-carte")
+}
+
+// DefaultHistoryPath returns the location of the history file inside the state directory.
+func DefaultHistoryPath() string {
+	return filepath.Join(StateDir(), "history.yml")
+}
+
+// LoadHistory reads the history file. A missing file yields an empty history.
+func LoadHistory(path string) (*History, error) {
+	h := &History{Durations: map[string]time.Duration{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("failed to parse history file: %w", err)
+	}
+	if h.Durations == nil {
+		h.Durations = map[string]time.Duration{}
+	}
+	return h, nil
+}
+
+// Save writes the history file, creating its directory if needed.
+func (h *History) Save(path string) error {
+	sort.Strings(h.Deferred)
+	data, err := yaml.Marshal(h)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Expected returns the expected install duration of a key.
+func (h *History) Expected(key string) time.Duration {
+	if h != nil {
+		if d, ok := h.Durations[key]; ok {
+			return d
+		}
+	}
+	return defaultExpectedDuration
+}
+
+// Record stores the observed install duration of a key.
+func (h *History) Record(key string, d time.Duration) {
+	if h == nil {
+		return
+	}
+	if h.Durations == nil {
+		h.Durations = map[string]time.Duration{}
+	}
+	h.Durations[key] = d
+}
+
+// orderByDuration reorders the plan so the quickest keys run first, using the
+// history for estimates. Instructions of one key stay together, and a key never
+// runs before a dependency that is also in the plan.
+func (p *Provisioner) orderByDuration(plan []InstallInstruction) []InstallInstruction {
+	var keys []string
+	groups := make(map[string][]InstallInstruction)
+	for _, inst := range plan {
+		if _, ok := groups[inst.Key]; !ok {
+			keys = append(keys, inst.Key)
+		}
+		groups[inst.Key] = append(groups[inst.Key], inst)
+	}
+	done := make(map[string]bool)
+	ready := func(key string) bool {
+		for _, dep := range p.Manifest[key].Deps {
+			if _, planned := groups[dep]; planned && !done[dep] {
+				return false
+			}
+		}
+		return true
+	}
+	ordered := make([]InstallInstruction, 0, len(plan))
+	for len(keys) > 0 {
+		best := -1
+		for i, key := range keys {
+			if !ready(key) {
+				continue
+			}
+			if best < 0 || p.History.Expected(key) < p.History.Expected(keys[best]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			// Dependency cycle; keep the remaining keys in plan order
+			best = 0
+		}
+		key := keys[best]
+		ordered = append(ordered, groups[key]...)
+		done[key] = true
+		keys = append(keys[:best], keys[best+1:]...)
+	}
+	return ordered
+}
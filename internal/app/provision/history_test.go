@@ -0,0 +1,83 @@
+package provision
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"a-la-carte/internal/app"
+)
+
+func TestHistory_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "history.yml")
+	h, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("expected missing history to load, got: %v", err)
+	}
+	if got := h.Expected("foo"); got != defaultExpectedDuration {
+		t.Errorf("expected default duration for unknown key, got %s", got)
+	}
+	h.Record("foo", 2*time.Minute)
+	h.Deferred = []string{"zed", "bar"}
+	if err := h.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	if got := loaded.Expected("foo"); got != 2*time.Minute {
+		t.Errorf("expected 2m for foo, got %s", got)
+	}
+	if !reflect.DeepEqual(loaded.Deferred, []string{"bar", "zed"}) {
+		t.Errorf("unexpected deferred keys: %v", loaded.Deferred)
+	}
+}
+
+func TestExecutePlan_MaxDuration(t *testing.T) {
+	manifest := app.Manifest{
+		"slow":  app.SoftwareEntry{},
+		"quick": app.SoftwareEntry{},
+		"dep":   app.SoftwareEntry{},
+		"needs": app.SoftwareEntry{Deps: []string{"slow"}},
+	}
+	plan := []InstallInstruction{
+		{Type: "apt", Package: "slow", Key: "slow"},
+		{Type: "apt", Package: "needs", Key: "needs"},
+		{Type: "apt", Package: "dep", Key: "dep"},
+		{Type: "apt", Package: "quick", Key: "quick"},
+	}
+	runner := &fakeExecRunner{}
+	prov := NewProvisioner(&fakeSystemInfo{}, manifest, runner)
+	prov.MaxDuration = 10 * time.Minute
+	prov.History = &History{Durations: map[string]time.Duration{
+		"slow":  time.Hour,
+		"needs": time.Second,
+		"dep":   2 * time.Minute,
+		"quick": time.Minute,
+	}}
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	prov.now = func() time.Time {
+		clock = clock.Add(30 * time.Second)
+		return clock
+	}
+	if err := prov.ExecutePlan(plan); err != nil {
+		t.Fatalf("ExecutePlan failed: %v", err)
+	}
+	var installed []string
+	for _, c := range runner.Commands {
+		if len(c) > 4 && c[:4] == "apt " {
+			installed = append(installed, c[4:])
+		}
+	}
+	if !reflect.DeepEqual(installed, []string{"quick", "dep"}) {
+		t.Errorf("expected quickest keys first, got: %v", installed)
+	}
+	if !reflect.DeepEqual(prov.Deferred, []string{"slow", "needs"}) {
+		t.Errorf("expected slow and its dependent to be deferred, got: %v", prov.Deferred)
+	}
+	if got := prov.History.Expected("quick"); got != 30*time.Second {
+		t.Errorf("expected recorded duration of 30s for quick, got %s", got)
+	}
+}
@@ -0,0 +1,48 @@
+package provision
+
+import (
+	"fmt"
+
+	"a-la-carte/internal/app"
+)
+
+// Instruction types of the _preinstall and _postinstall hooks of a manifest entry.
+// Hooks are shell snippets and run like scripts.
+const (
+	HookPreInstall  = "preinstall"
+	HookPostInstall = "postinstall"
+)
+
+// isScriptType reports whether instructions of the type run as shell scripts.
+func isScriptType(instType string) bool {
+	return instType == "script" || instType == HookPreInstall || instType == HookPostInstall
+}
+
+// addHookInstructions wraps the instructions planned for an entry since start in its
+// _preinstall and _postinstall hooks. Entries with nothing to install get no hooks.
+func (p *Provisioner) addHookInstructions(entry *app.SoftwareEntry, start int, plan *[]InstallInstruction) {
+	if len(*plan) == start {
+		return
+	}
+	var pre []InstallInstruction
+	for _, hook := range entry.PreInstall {
+		pre = append(pre, InstallInstruction{Type: HookPreInstall, Package: hook})
+	}
+	*plan = append((*plan)[:start], append(pre, (*plan)[start:]...)...)
+	for _, hook := range entry.PostInstall {
+		*plan = append(*plan, InstallInstruction{Type: HookPostInstall, Package: hook})
+	}
+}
+
+// skipAfterFailure reports whether an instruction is skipped because an earlier step of
+// its key failed: nothing runs after a failed _preinstall hook, and _postinstall hooks
+// do not run after any failure.
+func (p *Provisioner) skipAfterFailure(inst InstallInstruction, failed, blocked map[string]bool) bool {
+	if !blocked[inst.Key] && !(inst.Type == HookPostInstall && failed[inst.Key]) {
+		return false
+	}
+	if p.Runner != nil {
+		_ = p.Runner.Run("info", fmt.Sprintf("Skipping %s %s for %s: an earlier step failed", inst.Type, inst.Package, inst.Key))
+	}
+	return true
+}
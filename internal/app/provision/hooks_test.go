@@ -0,0 +1,94 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+// failingRunner fails every command that starts with one of the given prefixes.
+type failingRunner struct {
+	fakeExecRunner
+	fail []string
+}
+
+func (f *failingRunner) RunContext(_ context.Context, cmd string, args ...string) error {
+	_ = f.Run(cmd, args...)
+	full := f.Commands[len(f.Commands)-1]
+	for _, prefix := range f.fail {
+		if strings.HasPrefix(full, prefix) {
+			return errors.New("failed: " + full)
+		}
+	}
+	return nil
+}
+
+func hookManifest() app.Manifest {
+	return app.Manifest{
+		"docker": {
+			Apt:         app.StringOrSlice{"docker-ce"},
+			PreInstall:  app.StringOrSlice{"add-repo"},
+			PostInstall: app.StringOrSlice{"enable-service", "add-group"},
+		},
+		"nothing": {PreInstall: app.StringOrSlice{"never"}},
+	}
+}
+
+func TestPlanProvision_Hooks(t *testing.T) {
+	prov := NewProvisioner(staticSystemInfo{os: "linux", id: "ubuntu"}, hookManifest(), &fakeExecRunner{})
+	plan, err := prov.PlanProvision([]string{"docker", "nothing"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	var got []string
+	for _, inst := range plan {
+		got = append(got, inst.Key+":"+inst.Type+" "+inst.Package)
+	}
+	want := []string{"docker:preinstall add-repo", "docker:apt docker-ce", "docker:postinstall enable-service", "docker:postinstall add-group"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got plan %v, want %v", got, want)
+	}
+}
+
+func TestExecutePlan_HookFailures(t *testing.T) {
+	cases := []struct {
+		name    string
+		fail    string
+		wantRan []string
+	}{
+		{"success", "", []string{"script add-repo", "apt docker-ce", "script enable-service", "script add-group"}},
+		{"preinstall fails", "script add-repo", []string{"script add-repo"}},
+		{"install fails", "apt", []string{"script add-repo", "apt docker-ce"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			runner := &failingRunner{}
+			if tc.fail != "" {
+				runner.fail = []string{tc.fail}
+			}
+			prov := NewProvisioner(staticSystemInfo{os: "linux", id: "ubuntu"}, hookManifest(), runner)
+			plan, err := prov.PlanProvision([]string{"docker"}, nil)
+			if err != nil {
+				t.Fatalf("PlanProvision error: %v", err)
+			}
+			runner.Commands = nil
+			err = prov.ExecutePlan(plan)
+			if (err != nil) != (tc.fail != "") {
+				t.Errorf("ExecutePlan error = %v, want failure %v", err, tc.fail != "")
+			}
+			var ran []string
+			for _, cmd := range runner.Commands {
+				if !strings.HasPrefix(cmd, "section") && !strings.HasPrefix(cmd, "info") && cmd != "fuser" {
+					ran = append(ran, cmd)
+				}
+			}
+			if !slices.Equal(ran, tc.wantRan) {
+				t.Errorf("ran %v, want %v", ran, tc.wantRan)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package provision
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// installerPlatforms restricts installers to the operating systems they exist on.
+// Installers that are not listed (brew, go, cargo, pipx, nix, ...) run anywhere.
+var installerPlatforms = map[string][]string{
+	"apt":            {"linux"},
+	"dnf":            {"linux"},
+	"yum":            {"linux"},
+	"pacman":         {"linux"},
+	"yay":            {"linux"},
+	"apk":            {"linux"},
+	"zypper":         {"linux"},
+	"emerge":         {"linux"},
+	"xbps":           {"linux"},
+	"flatpak":        {"linux"},
+	"snap":           {"linux"},
+	"cask":           {"darwin"},
+	"mas":            {"darwin"},
+	"port":           {"darwin"},
+	"scoop":          {"windows"},
+	"choco":          {"windows"},
+	"binary:darwin":  {"darwin"},
+	"binary:linux":   {"linux"},
+	"binary:windows": {"windows"},
+}
+
+// nonInstallerKeys are manifest keys without a leading underscore that are not install methods.
+var nonInstallerKeys = map[string]bool{"deps": true, "lazy": true, "script": true}
+
+// installerApplies reports whether an installer exists on the provisioner's OS.
+// Without system information every installer applies.
+func (p *Provisioner) installerApplies(installer string) bool {
+	if p.System == nil || p.System.OS() == "" {
+		return true
+	}
+	oses, restricted := installerPlatforms[installer]
+	return !restricted || slices.Contains(oses, p.System.OS())
+}
+
+// Installability reports whether planning the key on this system produces an install
+// instruction, and if not, why.
+//
+// # Returns
+//   - bool:   True if the key can be installed here
+//   - string: The reason it cannot (empty if it can)
+func (p *Provisioner) Installability(key string) (bool, string) {
+	entry, ok := p.Manifest[key]
+	if !ok {
+		return false, "not in the manifest"
+	}
+	if applies, _ := p.conditionMet(&entry); !applies {
+		return false, fmt.Sprintf("_when %s is false here", entry.When)
+	}
+	if len(entry.Script) > 0 || entry.IsMeta() {
+		return true, ""
+	}
+	var plan []InstallInstruction
+	p.addInstallerInstruction(key, &entry, &plan)
+	if len(plan) > 0 {
+		return true, ""
+	}
+	var methods []string
+	for k, v := range p.entryMap(key, &entry) {
+		if !strings.HasPrefix(k, "_") && !nonInstallerKeys[k] && !isEmptyValue(v) {
+			methods = append(methods, k)
+		}
+	}
+	if len(methods) == 0 {
+		return false, "no install methods defined"
+	}
+	sort.Strings(methods)
+	platform := "this platform"
+	if p.System != nil {
+		platform = p.System.OS() + "/" + p.System.Arch()
+	}
+	var disabled []string
+	for _, method := range methods {
+		if slices.Contains(p.DisabledManagers, method) {
+			disabled = append(disabled, method)
+		}
+	}
+	if len(disabled) > 0 {
+		return false, fmt.Sprintf("no install method for %s (only %s; disabled: %s)", platform, strings.Join(methods, ", "), strings.Join(disabled, ", "))
+	}
+	return false, fmt.Sprintf("no install method for %s (only %s)", platform, strings.Join(methods, ", "))
+}
+
+// Installer returns the installer and package planning picks for the key on this
+// system, "script" and the first script for entries installed by scripts only, or
+// empty strings if the key has no install method here.
+//
+// # Example
+//
+//	prov.Installer("ripgrep") // "apt", "ripgrep" on Ubuntu
+func (p *Provisioner) Installer(key string) (string, string) {
+	entry, ok := p.Manifest[key]
+	if !ok {
+		return "", ""
+	}
+	if applies, _ := p.conditionMet(&entry); !applies {
+		return "", ""
+	}
+	var plan []InstallInstruction
+	p.addInstallerInstruction(key, &entry, &plan)
+	if len(plan) > 0 {
+		return plan[0].Type, plan[0].Package
+	}
+	if len(entry.Script) > 0 {
+		return "script", entry.Script[0]
+	}
+	return "", ""
+}
+
+// isEmptyValue reports whether a raw manifest value is unset, e.g. an empty string or list.
+func isEmptyValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package provision
+
+import (
+	"strings"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestInstallability(t *testing.T) {
+	manifest := app.Manifest{
+		"cli":     app.SoftwareEntry{Apt: app.StringOrSlice{"cli"}},
+		"macapp":  app.SoftwareEntry{Cask: app.StringOrSlice{"macapp"}, Mas: app.StringOrSlice{"123"}},
+		"scripty": app.SoftwareEntry{Script: app.StringOrSlice{"echo hi"}},
+		"empty":   app.SoftwareEntry{Name: "Empty"},
+	}
+	runner := &fakeExecRunner{}
+	prov := NewProvisioner(&fakeSystemInfo{}, manifest, runner)
+
+	for _, key := range []string{"cli", "scripty"} {
+		if ok, reason := prov.Installability(key); !ok {
+			t.Errorf("expected %s to be installable, got reason %q", key, reason)
+		}
+	}
+	ok, reason := prov.Installability("macapp")
+	if ok || reason != "no install method for linux/x64 (only cask, mas)" {
+		t.Errorf("unexpected installability for macapp: %v %q", ok, reason)
+	}
+	if ok, reason := prov.Installability("empty"); ok || reason != "no install methods defined" {
+		t.Errorf("unexpected installability for empty: %v %q", ok, reason)
+	}
+	for key, want := range map[string]string{"cli": "apt cli", "scripty": "script echo hi", "macapp": " ", "missing": " "} {
+		if installer, pkg := prov.Installer(key); installer+" "+pkg != want {
+			t.Errorf("Installer(%s) = %q %q, want %q", key, installer, pkg, want)
+		}
+	}
+
+	plan, err := prov.PlanProvision([]string{"cli", "macapp"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	if len(plan) != 1 || plan[0].Key != "cli" {
+		t.Errorf("expected only cli in the plan, got %+v", plan)
+	}
+	warned := false
+	for _, c := range runner.Commands {
+		if strings.Contains(c, "Warning: macapp is not installable here") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Errorf("expected a plan warning for macapp, got: %v", runner.Commands)
+	}
+}
@@ -2,31 +2,93 @@ package provision
 
 import (
 	"bufio"
+	"os/exec"
 	"regexp"
 	"strings"
+
+	"golang.org/x/sync/errgroup"
 )
 
+// maxInstalledQueries bounds how many package managers are queried at once.
+const maxInstalledQueries = 4
+
+// installedDetector lists the installed packages of one package manager.
+//
+// # Fields
+//   - Bin:    The executable that must be on PATH for the manager to be queried
+//   - Detect: Queries the manager through the runner
+type installedDetector struct {
+	Bin    string
+	Detect func(ExecRunner) map[string]bool
+}
+
+// installedDetectors are the package managers GetInstalledPackages queries.
+var installedDetectors = []installedDetector{
+	{Bin: "dpkg", Detect: getAptInstalled},
+	{Bin: "brew", Detect: getBrewInstalled},
+	{Bin: "pipx", Detect: getPipxInstalled},
+	{Bin: "cargo", Detect: getCargoInstalled},
+	{Bin: "npm", Detect: getNpmInstalled},
+	{Bin: "flatpak", Detect: getFlatpakInstalled},
+	{Bin: "snap", Detect: getSnapInstalled},
+	{Bin: "pacman", Detect: getPacmanInstalled},
+	{Bin: "rpm", Detect: getDnfInstalled},
+}
+
 // GetInstalledPackages queries the system for installed packages for supported managers.
 // It returns a map of package names (keys) that are installed.
 // Uses the provided ExecRunner for testability.
+//
+// Only managers found on PATH are queried, concurrently, so runner.Output must be
+// safe for concurrent use.
 func GetInstalledPackages(runner ExecRunner) map[string]bool {
-	installed := make(map[string]bool)
+	return GetInstalledPackagesWith(runner, exec.LookPath)
+}
+
+// GetInstalledPackagesWith is GetInstalledPackages with the managers found by lookPath
+// instead of on the local PATH. Pass RunnerLookPath(runner) when runner runs commands on
+// another machine.
+func GetInstalledPackagesWith(runner ExecRunner, lookPath func(string) (string, error)) map[string]bool {
+	results := make([]map[string]bool, len(installedDetectors))
+	var g errgroup.Group
+	g.SetLimit(maxInstalledQueries)
+	for i, detector := range installedDetectors {
+		if _, err := lookPath(detector.Bin); err != nil {
+			continue
+		}
+		g.Go(func() error {
+			results[i] = detector.Detect(runner)
+			return nil
+		})
+	}
+	_ = g.Wait()
 
-	merge := func(pkgs map[string]bool) {
+	installed := make(map[string]bool)
+	for _, pkgs := range results {
 		for k := range pkgs {
 			installed[k] = true
 		}
 	}
-
-	merge(getAptInstalled(runner))
-	merge(getBrewInstalled(runner))
-	merge(getPipxInstalled(runner))
-	merge(getCargoInstalled(runner))
-	merge(getNpmInstalled(runner))
-
 	return installed
 }
 
+// binsOnPath reports whether BinOnPath is set and all _bin executables of key are on
+// PATH. This is much cheaper than asking the package managers and also covers tools
+// installed by hand. Entries without _bin are never found this way.
+func (p *Provisioner) binsOnPath(key string) bool {
+	bins := p.Manifest[key].Bin
+	if !p.BinOnPath || len(bins) == 0 {
+		return false
+	}
+	lookPath := p.lookPathFunc()
+	for _, bin := range bins {
+		if _, err := lookPath(bin); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
 func getAptInstalled(runner ExecRunner) map[string]bool {
 	pkgs := make(map[string]bool)
 	out, err := runner.Output("dpkg", "-l")
@@ -119,3 +181,91 @@ func getNpmInstalled(runner ExecRunner) map[string]bool {
 	}
 	return pkgs
 }
+
+// getFlatpakInstalled lists installed flatpak applications by ID, e.g. org.mozilla.firefox.
+func getFlatpakInstalled(runner ExecRunner) map[string]bool {
+	pkgs := make(map[string]bool)
+	out, err := runner.Output("flatpak", "list", "--app", "--columns=application")
+	if err != nil {
+		return pkgs
+	}
+	scan := bufio.NewScanner(strings.NewReader(string(out)))
+	for scan.Scan() {
+		name := strings.TrimSpace(scan.Text())
+		if name != "" && name != "Application ID" {
+			pkgs[name] = true
+		}
+	}
+	return pkgs
+}
+
+func getSnapInstalled(runner ExecRunner) map[string]bool {
+	pkgs := make(map[string]bool)
+	out, err := runner.Output("snap", "list")
+	if err != nil {
+		return pkgs
+	}
+	scan := bufio.NewScanner(strings.NewReader(string(out)))
+	for scan.Scan() {
+		fields := strings.Fields(scan.Text())
+		if len(fields) > 0 && fields[0] != "Name" {
+			pkgs[fields[0]] = true
+		}
+	}
+	return pkgs
+}
+
+func getPacmanInstalled(runner ExecRunner) map[string]bool {
+	pkgs := make(map[string]bool)
+	out, err := runner.Output("pacman", "-Q")
+	if err != nil {
+		return pkgs
+	}
+	scan := bufio.NewScanner(strings.NewReader(string(out)))
+	for scan.Scan() {
+		fields := strings.Fields(scan.Text())
+		if len(fields) > 0 {
+			pkgs[fields[0]] = true
+		}
+	}
+	return pkgs
+}
+
+// getDnfInstalled lists installed RPM packages with dnf, whose lines read
+// "name.arch version repo", and falls back to rpm where dnf is missing or fails.
+func getDnfInstalled(runner ExecRunner) map[string]bool {
+	pkgs := make(map[string]bool)
+	out, err := runner.Output("dnf", "list", "installed")
+	if err != nil || len(out) == 0 {
+		return getRpmInstalled(runner)
+	}
+	scan := bufio.NewScanner(strings.NewReader(string(out)))
+	for scan.Scan() {
+		line := scan.Text()
+		fields := strings.Fields(line)
+		// Skip headers and the version lines of wrapped entries.
+		if len(fields) == 0 || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "Last metadata") {
+			continue
+		}
+		if dot := strings.LastIndex(fields[0], "."); dot > 0 {
+			pkgs[fields[0][:dot]] = true
+		}
+	}
+	return pkgs
+}
+
+func getRpmInstalled(runner ExecRunner) map[string]bool {
+	pkgs := make(map[string]bool)
+	out, err := runner.Output("rpm", "-qa", "--queryformat", "%{NAME}\\n")
+	if err != nil {
+		return pkgs
+	}
+	scan := bufio.NewScanner(strings.NewReader(string(out)))
+	for scan.Scan() {
+		name := strings.TrimSpace(scan.Text())
+		if name != "" {
+			pkgs[name] = true
+		}
+	}
+	return pkgs
+}
@@ -1,8 +1,13 @@
 package provision
 
 import (
+	"context"
+	"errors"
+	"slices"
 	"strings"
 	"testing"
+
+	"a-la-carte/internal/app"
 )
 
 type fakeOutputRunner struct {
@@ -10,6 +15,9 @@ type fakeOutputRunner struct {
 }
 
 func (f *fakeOutputRunner) Run(cmd string, args ...string) error { return nil }
+func (f *fakeOutputRunner) RunContext(_ context.Context, cmd string, args ...string) error {
+	return nil
+}
 func (f *fakeOutputRunner) Output(cmd string, args ...string) ([]byte, error) {
 	key := cmd
 	if len(args) > 0 {
@@ -48,7 +56,7 @@ fd-find v8.2.1:
 └── cowsay@1.5.0
 `),
 	}}
-	got := GetInstalledPackages(runner)
+	got := GetInstalledPackagesWith(runner, func(bin string) (string, error) { return "/usr/bin/" + bin, nil })
 	want := map[string]bool{
 		"foo":     true,
 		"bat":     true,
@@ -70,3 +78,97 @@ fd-find v8.2.1:
 		t.Errorf("did not expect 'bar' to be detected as installed")
 	}
 }
+
+func TestGetInstalledPackages_OnlyOnPath(t *testing.T) {
+	runner := &fakeOutputRunner{outputs: map[string][]byte{
+		"dpkg -l":      []byte("ii  foo    1.0 all some package\n"),
+		"brew list -1": []byte("bat\n"),
+	}}
+	got := GetInstalledPackagesWith(runner, func(bin string) (string, error) {
+		if bin == "brew" {
+			return "/opt/homebrew/bin/brew", nil
+		}
+		return "", errors.New("not found")
+	})
+	if !got["bat"] || got["foo"] {
+		t.Errorf("expected only brew to be queried, got %v", got)
+	}
+}
+
+func TestGetInstalledPackages_OtherManagers(t *testing.T) {
+	runner := &fakeOutputRunner{outputs: map[string][]byte{
+		"flatpak list --app --columns=application": []byte("org.mozilla.firefox\ncom.spotify.Client\n"),
+		"snap list": []byte(`Name    Version   Rev    Tracking       Publisher   Notes
+core22  20240111  1122   latest/stable  canonical✓  base
+code    1.86.2    151    latest/stable  vscode✓     classic
+`),
+		"pacman -Q": []byte("ripgrep 14.1.0-1\nzsh 5.9-5\n"),
+		"dnf list installed": []byte(`Last metadata expiration check: 0:12:01 ago on Mon 01 Jan 2024.
+Installed Packages
+bash.x86_64                         5.2.15-3.fc38         @anaconda
+java-17-openjdk-headless.x86_64
+                                    1:17.0.9.0.9-3.fc38   @updates
+`),
+	}}
+	got := GetInstalledPackagesWith(runner, func(bin string) (string, error) { return "/usr/bin/" + bin, nil })
+	for _, k := range []string{"org.mozilla.firefox", "com.spotify.Client", "core22", "code", "ripgrep", "zsh", "bash", "java-17-openjdk-headless"} {
+		if !got[k] {
+			t.Errorf("expected %s to be detected as installed", k)
+		}
+	}
+	for _, k := range []string{"Name", "Installed", "Last", "1:17.0.9.0.9-3"} {
+		if got[k] {
+			t.Errorf("did not expect %q to be detected as installed", k)
+		}
+	}
+}
+
+func TestGetInstalledPackages_RpmFallback(t *testing.T) {
+	runner := &fakeOutputRunner{outputs: map[string][]byte{
+		`rpm -qa --queryformat %{NAME}\n`: []byte("git\nvim-enhanced\n"),
+	}}
+	got := GetInstalledPackagesWith(runner, func(bin string) (string, error) {
+		if bin == "rpm" {
+			return "/usr/bin/rpm", nil
+		}
+		return "", errors.New("not found")
+	})
+	if !got["git"] || !got["vim-enhanced"] {
+		t.Errorf("expected rpm packages when dnf is missing, got %v", got)
+	}
+}
+
+func TestPlanProvision_BinOnPath(t *testing.T) {
+	manifest := app.Manifest{
+		"ripgrep": {Apt: app.StringOrSlice{"ripgrep"}, Bin: app.StringOrSlice{"rg"}},
+		"fd":      {Apt: app.StringOrSlice{"fd-find"}, Bin: app.StringOrSlice{"fd", "fdfind"}},
+		"jq":      {Apt: app.StringOrSlice{"jq"}},
+	}
+	onPath := map[string]bool{"rg": true, "fd": true, "jq": true}
+	for _, enabled := range []bool{true, false} {
+		prov := NewProvisioner(staticSystemInfo{os: "linux", id: "ubuntu"}, manifest, &fakeExecRunner{})
+		prov.BinOnPath = enabled
+		prov.lookPath = func(bin string) (string, error) {
+			if onPath[bin] {
+				return "/usr/bin/" + bin, nil
+			}
+			return "", errors.New("not found")
+		}
+		plan, err := prov.PlanProvision([]string{"fd", "jq", "ripgrep"}, map[string]bool{})
+		if err != nil {
+			t.Fatalf("PlanProvision error: %v", err)
+		}
+		var got []string
+		for _, inst := range plan {
+			got = append(got, inst.Key)
+		}
+		want := []string{"fd", "jq", "ripgrep"}
+		if enabled {
+			// ripgrep's only executable is on PATH; fd lacks fdfind and jq has no _bin.
+			want = []string{"fd", "jq"}
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("BinOnPath=%v: planned %v, want %v", enabled, got, want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package provision
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockPollInterval is how often a held package-manager lock is re-checked.
+const lockPollInterval = time.Second
+
+// lockReportEvery controls how many polls pass between countdown log lines.
+const lockReportEvery = 5
+
+// lockFiles lists the lock files used by each package manager that takes a system-wide lock.
+var lockFiles = map[string][]string{
+	"apt": {
+		"/var/lib/dpkg/lock-frontend",
+		"/var/lib/dpkg/lock",
+		"/var/lib/apt/lists/lock",
+		"/var/cache/apt/archives/lock",
+	},
+	"dnf": {
+		"/var/lib/dnf/rpmdb_lock.pid",
+		"/var/lib/rpm/.rpm.lock",
+	},
+	"yum": {
+		"/var/run/yum.pid",
+		"/var/lib/rpm/.rpm.lock",
+	},
+}
+
+// LockInfo describes a package-manager lock held by another process.
+//
+// # Fields
+//   - Path:    The lock file that is held
+//   - PID:     The process ID holding the lock
+//   - Process: The process name, if it could be determined
+type LockInfo struct {
+	Path    string
+	PID     int
+	Process string
+}
+
+// String returns a human-readable description of the lock holder.
+func (l LockInfo) String() string {
+	if l.Process != "" {
+		return fmt.Sprintf("%s held by %s (PID %d)", l.Path, l.Process, l.PID)
+	}
+	return fmt.Sprintf("%s held by PID %d", l.Path, l.PID)
+}
+
+// DetectLock reports whether the lock of the given installer is held by another process.
+// It uses fuser to find the holding PID and ps to resolve the process name.
+//
+// # Returns
+//   - LockInfo: The lock holder (zero value if not locked)
+//   - bool:     True if the lock is held
+func DetectLock(runner ExecRunner, installer string) (LockInfo, bool) {
+	if runner == nil {
+		return LockInfo{}, false
+	}
+	for _, path := range lockFiles[installer] {
+		out, err := runner.Output("fuser", path)
+		if err != nil {
+			continue
+		}
+		for _, field := range strings.Fields(string(out)) {
+			// fuser may append access-mode letters to the PID (e.g. "1234F")
+			pid, convErr := strconv.Atoi(strings.TrimRight(field, "cefFmr"))
+			if convErr != nil || pid <= 0 {
+				continue
+			}
+			info := LockInfo{Path: path, PID: pid}
+			if name, psErr := runner.Output("ps", "-o", "comm=", "-p", strconv.Itoa(pid)); psErr == nil {
+				info.Process = strings.TrimSpace(string(name))
+			}
+			return info, true
+		}
+	}
+	return LockInfo{}, false
+}
+
+// waitForLock blocks until the installer's lock is released or LockTimeout elapses,
+// logging a countdown through the runner while it waits.
+func (p *Provisioner) waitForLock(installer string) error {
+	info, locked := DetectLock(p.Runner, installer)
+	if !locked {
+		return nil
+	}
+	sleep := p.sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+	remaining := p.LockTimeout
+	for polls := 0; locked; polls++ {
+		if remaining <= 0 {
+			return fmt.Errorf("%s is locked: %s", installer, info)
+		}
+		if polls%lockReportEvery == 0 {
+			_ = p.Runner.Run("info", fmt.Sprintf("Waiting for %s lock, %s: %s remaining", installer, info, remaining.Round(time.Second)))
+		}
+		sleep(lockPollInterval)
+		remaining -= lockPollInterval
+		info, locked = DetectLock(p.Runner, installer)
+	}
+	_ = p.Runner.Run("info", fmt.Sprintf("%s lock released", installer))
+	return nil
+}
@@ -0,0 +1,82 @@
+package provision
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// lockRunner reports the apt lock as held for the first `heldPolls` fuser calls.
+type lockRunner struct {
+	fakeExecRunner
+	heldPolls int
+	polls     int
+}
+
+func (l *lockRunner) Output(cmd string, args ...string) ([]byte, error) {
+	switch cmd {
+	case "fuser":
+		if len(args) > 0 && args[0] == "/var/lib/dpkg/lock-frontend" {
+			l.polls++
+			if l.polls <= l.heldPolls {
+				return []byte(" 4242"), nil
+			}
+		}
+		return nil, nil
+	case "ps":
+		return []byte("unattended-upgr\n"), nil
+	}
+	return nil, nil
+}
+
+func TestDetectLock(t *testing.T) {
+	info, locked := DetectLock(&lockRunner{heldPolls: 1}, "apt")
+	if !locked {
+		t.Fatal("expected apt lock to be detected")
+	}
+	if info.PID != 4242 || info.Process != "unattended-upgr" || info.Path != "/var/lib/dpkg/lock-frontend" {
+		t.Errorf("unexpected lock info: %+v", info)
+	}
+	if _, locked := DetectLock(&lockRunner{}, "apt"); locked {
+		t.Error("did not expect a lock when fuser reports no holders")
+	}
+	if _, locked := DetectLock(&lockRunner{heldPolls: 1}, "brew"); locked {
+		t.Error("did not expect a lock for an installer without lock files")
+	}
+}
+
+func TestWaitForLock(t *testing.T) {
+	runner := &lockRunner{heldPolls: 3}
+	prov := NewProvisioner(&fakeSystemInfo{}, nil, runner)
+	prov.LockTimeout = time.Minute
+	var slept time.Duration
+	prov.sleep = func(d time.Duration) { slept += d }
+	if err := prov.waitForLock("apt"); err != nil {
+		t.Fatalf("expected lock to be released, got: %v", err)
+	}
+	if slept != 3*lockPollInterval {
+		t.Errorf("expected to wait %s, waited %s", 3*lockPollInterval, slept)
+	}
+	found := false
+	for _, c := range runner.Commands {
+		if strings.Contains(c, "Waiting for apt lock") && strings.Contains(c, "unattended-upgr (PID 4242)") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected countdown naming the lock holder, got: %v", runner.Commands)
+	}
+}
+
+func TestWaitForLock_Timeout(t *testing.T) {
+	prov := NewProvisioner(&fakeSystemInfo{}, nil, &lockRunner{heldPolls: 100})
+	prov.LockTimeout = 2 * time.Second
+	prov.sleep = func(time.Duration) {}
+	err := prov.waitForLock("apt")
+	if err == nil {
+		t.Fatal("expected an error when the lock is never released")
+	}
+	if !strings.Contains(err.Error(), "PID 4242") {
+		t.Errorf("expected error to name the lock holder, got: %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+package provision
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultLockfileName is the file name of the lockfile, kept next to the manifest.
+const DefaultLockfileName = "a-la-carte.lock.yml"
+
+// Lockfile records the version of each package a run installed, so other machines
+// can install the same versions with Provisioner.Locked.
+//
+// # Fields
+//   - Packages: The locked package of each manifest key
+type Lockfile struct {
+	Packages map[string]LockedPackage `yaml:"packages"`
+}
+
+// LockedPackage is the installed version of a manifest key's package.
+//
+// # Fields
+//   - Installer: The installer that installed it, e.g. apt
+//   - Package:   The package name
+//   - Version:   The installed version as the package manager reports it
+type LockedPackage struct {
+	Installer string `yaml:"installer"`
+	Package   string `yaml:"package"`
+	Version   string `yaml:"version"`
+}
+
+// LoadLockfile reads a lockfile. A missing file yields an empty lockfile.
+func LoadLockfile(path string) (*Lockfile, error) {
+	l := &Lockfile{Packages: map[string]LockedPackage{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+	if err := yaml.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile %s: %w", path, err)
+	}
+	if l.Packages == nil {
+		l.Packages = map[string]LockedPackage{}
+	}
+	return l, nil
+}
+
+// Save writes the lockfile, replacing the file only once the new contents are complete.
+func (l *Lockfile) Save(path string) error {
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("failed to marshal lockfile: %w", err)
+	}
+	data = append([]byte("# Generated by chezmoi-a-la-carte after provisioning. Install these versions with --locked.\n"), data...)
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".lockfile-*.yml")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// lookup returns the locked package of key. It is safe to call on a nil lockfile.
+func (l *Lockfile) lookup(key string) (LockedPackage, bool) {
+	if l == nil {
+		return LockedPackage{}, false
+	}
+	locked, ok := l.Packages[key]
+	return locked, ok
+}
+
+// versionQueries ask a package manager for the installed version of a package.
+// Each returns the command to run and extracts the version from its output.
+var versionQueries = map[string]func(pkg string) ([]string, func(out string) string){
+	"apt": func(pkg string) ([]string, func(string) string) {
+		return []string{"dpkg-query", "-W", "-f=${Version}", pkg}, strings.TrimSpace
+	},
+	"dnf":    rpmVersionQuery,
+	"yum":    rpmVersionQuery,
+	"zypper": rpmVersionQuery,
+	"apk": func(pkg string) ([]string, func(string) string) {
+		// "<pkg>-<version> <arch> {<origin>} (<license>) [installed]"
+		return []string{"apk", "list", "--installed", pkg}, func(out string) string {
+			for _, line := range strings.Split(out, "\n") {
+				if fields := strings.Fields(line); len(fields) > 0 && strings.HasPrefix(fields[0], pkg+"-") {
+					return strings.TrimPrefix(fields[0], pkg+"-")
+				}
+			}
+			return ""
+		}
+	},
+	"brew": func(pkg string) ([]string, func(string) string) {
+		return []string{"brew", "list", "--versions", pkg}, lastField
+	},
+	"cask": func(pkg string) ([]string, func(string) string) {
+		return []string{"brew", "list", "--cask", "--versions", pkg}, lastField
+	},
+	"pacman": func(pkg string) ([]string, func(string) string) {
+		return []string{"pacman", "-Q", pkg}, lastField
+	},
+	"snap": func(pkg string) ([]string, func(string) string) {
+		// A header line, then "<name> <version> <rev> ..."
+		return []string{"snap", "list", pkg}, func(out string) string {
+			return listedVersion(out, pkg, 1)
+		}
+	},
+	"pipx": func(pkg string) ([]string, func(string) string) {
+		return []string{"pipx", "list", "--short"}, func(out string) string {
+			return listedVersion(out, pkg, 1)
+		}
+	},
+	"cargo": func(pkg string) ([]string, func(string) string) {
+		// "<name> v<version>:" lines, each followed by its indented binaries
+		return []string{"cargo", "install", "--list"}, func(out string) string {
+			return strings.TrimSuffix(strings.TrimPrefix(listedVersion(out, pkg, 1), "v"), ":")
+		}
+	},
+}
+
+// rpmVersionQuery asks rpm for the version and release of a package.
+func rpmVersionQuery(pkg string) ([]string, func(string) string) {
+	return []string{"rpm", "-q", "--queryformat", "%{VERSION}-%{RELEASE}", pkg}, strings.TrimSpace
+}
+
+// lastField returns the last whitespace-separated field of the first output line.
+func lastField(out string) string {
+	first, _, _ := strings.Cut(strings.TrimSpace(out), "\n")
+	fields := strings.Fields(first)
+	if len(fields) < 2 {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
+
+// listedVersion returns field n of the output line whose first field is pkg.
+func listedVersion(out, pkg string, n int) string {
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > n && fields[0] == pkg {
+			return fields[n]
+		}
+	}
+	return ""
+}
+
+// RecordVersions asks the package managers for the installed version of each package
+// instruction of the plan and stores them in lock. Packages pinned by Locked are
+// recorded under their plain name, and upgraded packages with Installer set to the
+// installer of their upgrade:* type (installerOf), e.g. apt for upgrade:apt. Packages
+// whose version cannot be determined (e.g. scripts, binary downloads, failed queries) are
+// left unchanged.
+//
+// # Returns
+//   - int: The number of packages recorded
+func (p *Provisioner) RecordVersions(plan []InstallInstruction, lock *Lockfile) int {
+	if p.Runner == nil {
+		return 0
+	}
+	if lock.Packages == nil {
+		lock.Packages = map[string]LockedPackage{}
+	}
+	recorded := 0
+	for _, inst := range plan {
+		installer := installerOf(inst.Type)
+		query, ok := versionQueries[installer]
+		if !ok {
+			continue
+		}
+		pkg := inst.Package
+		if locked, ok := p.Locked.lookup(inst.Key); ok {
+			if pinned, _ := pinnedPackage(installer, locked.Package, locked.Version); pinned == pkg {
+				pkg = locked.Package
+			}
+		}
+		args, parse := query(pkg)
+		out, err := p.Runner.Output(args[0], args[1:]...)
+		if err != nil {
+			continue
+		}
+		if version := parse(string(out)); version != "" {
+			lock.Packages[inst.Key] = LockedPackage{Installer: installer, Package: pkg, Version: version}
+			recorded++
+		}
+	}
+	return recorded
+}
+
+// pinnedPackage returns the package argument that installs a specific version with
+// the installer, or false if the installer cannot pin versions.
+func pinnedPackage(installer, pkg, version string) (string, bool) {
+	switch installer {
+	case "apt", "apk", "zypper":
+		return pkg + "=" + version, true
+	case "dnf", "yum":
+		return pkg + "-" + version, true
+	}
+	return "", false
+}
+
+// pinToLock rewrites the package instructions of a plan to install the versions in
+// p.Locked. Keys that are not locked, are locked for another installer or package, or
+// use an installer that cannot pin versions install the latest version with a warning.
+func (p *Provisioner) pinToLock(plan []InstallInstruction) []InstallInstruction {
+	if p.Locked == nil {
+		return plan
+	}
+	for i, inst := range plan {
+		if _, ok := versionQueries[inst.Type]; !ok {
+			continue
+		}
+		locked, ok := p.Locked.lookup(inst.Key)
+		switch {
+		case !ok:
+			p.warn(PlanWarning{Key: inst.Key, Message: fmt.Sprintf("%s is not in the lockfile; installing the latest version", inst.Key)})
+		case locked.Installer != inst.Type || locked.Package != inst.Package:
+			p.warn(PlanWarning{Key: inst.Key, Message: fmt.Sprintf("%s is locked for %s %s but planned with %s %s; installing the latest version", inst.Key, locked.Installer, locked.Package, inst.Type, inst.Package)})
+		default:
+			if pinned, ok := pinnedPackage(inst.Type, inst.Package, locked.Version); ok {
+				plan[i].Package = pinned
+			} else {
+				p.warn(PlanWarning{Key: inst.Key, Message: fmt.Sprintf("%s cannot install a specific version of %s; installing the latest instead of %s", inst.Type, inst.Key, locked.Version)})
+			}
+		}
+	}
+	return plan
+}
@@ -0,0 +1,95 @@
+package provision
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestRecordVersionsAndSave(t *testing.T) {
+	runner := &fakeOutputRunner{outputs: map[string][]byte{
+		"dpkg-query -W -f=${Version} ripgrep": []byte("13.0.0-2"),
+		"brew list --versions bat":            []byte("bat 0.24.0\n"),
+		"cargo install --list":                []byte("du-dust v0.8.6:\n    dust\nzoxide v0.9.4:\n    zoxide\n"),
+	}}
+	prov := NewProvisioner(nil, app.Manifest{}, runner)
+	plan := []InstallInstruction{
+		{Type: "apt", Package: "ripgrep", Key: "ripgrep"},
+		{Type: "brew", Package: "bat", Key: "bat"},
+		{Type: "cargo", Package: "zoxide", Key: "zoxide"},
+		{Type: "script", Package: "echo hi", Key: "custom"},
+		{Type: "apt", Package: "gone", Key: "gone"}, // query output is empty
+	}
+	path := filepath.Join(t.TempDir(), DefaultLockfileName)
+	lock, err := LoadLockfile(path)
+	if err != nil {
+		t.Fatalf("LoadLockfile of a missing file: %v", err)
+	}
+	lock.Packages["old"] = LockedPackage{Installer: "apt", Package: "old", Version: "1"}
+	if n := prov.RecordVersions(plan, lock); n != 3 {
+		t.Errorf("expected 3 recorded versions, got %d", n)
+	}
+	if err := lock.Save(path); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+	loaded, err := LoadLockfile(path)
+	if err != nil {
+		t.Fatalf("LoadLockfile error: %v", err)
+	}
+	want := map[string]LockedPackage{
+		"ripgrep": {"apt", "ripgrep", "13.0.0-2"},
+		"bat":     {"brew", "bat", "0.24.0"},
+		"zoxide":  {"cargo", "zoxide", "0.9.4"},
+		"old":     {"apt", "old", "1"},
+	}
+	if len(loaded.Packages) != len(want) {
+		t.Errorf("expected %d locked packages, got %+v", len(want), loaded.Packages)
+	}
+	for key, w := range want {
+		if loaded.Packages[key] != w {
+			t.Errorf("%s: got %+v, want %+v", key, loaded.Packages[key], w)
+		}
+	}
+}
+
+func TestPlanProvisionLocked(t *testing.T) {
+	manifest := app.Manifest{
+		"ripgrep": {Apt: app.StringOrSlice{"ripgrep"}},
+		"fd":      {Dnf: app.StringOrSlice{"fd-find"}},
+		"bat":     {Brew: app.StringOrSlice{"bat"}},
+		"jq":      {Apt: app.StringOrSlice{"jq"}},
+	}
+	prov := NewProvisioner(nil, manifest, nil)
+	prov.InstallerOrder = []string{"apt", "dnf", "brew"}
+	prov.Locked = &Lockfile{Packages: map[string]LockedPackage{
+		"ripgrep": {"apt", "ripgrep", "13.0.0-2"},
+		"fd":      {"dnf", "fd-find", "8.7.0-1.fc39"},
+		"bat":     {"brew", "bat", "0.24.0"},
+	}}
+	plan, err := prov.PlanProvision([]string{"bat", "fd", "jq", "ripgrep"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	var got []string
+	for _, inst := range plan {
+		got = append(got, inst.Type+" "+inst.Package)
+	}
+	want := "brew bat,dnf fd-find-8.7.0-1.fc39,apt jq,apt ripgrep=13.0.0-2"
+	if strings.Join(got, ",") != want {
+		t.Errorf("got plan %v, want %s", got, want)
+	}
+	if len(prov.Warnings) != 2 || prov.Warnings[0].Key != "bat" || prov.Warnings[1].Key != "jq" {
+		t.Errorf("expected warnings for bat (not pinnable) and jq (not locked), got %+v", prov.Warnings)
+	}
+
+	// Versions of pinned packages are recorded under their plain name
+	runner := &fakeOutputRunner{outputs: map[string][]byte{"dpkg-query -W -f=${Version} ripgrep": []byte("13.0.0-2")}}
+	prov.Runner = runner
+	lock := &Lockfile{}
+	prov.RecordVersions(plan, lock)
+	if lock.Packages["ripgrep"] != (LockedPackage{"apt", "ripgrep", "13.0.0-2"}) {
+		t.Errorf("unexpected locked ripgrep %+v", lock.Packages["ripgrep"])
+	}
+}
@@ -0,0 +1,171 @@
+package provision
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Log file formats supported by Provisioner.LogFormat.
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+// InstructionRecord is the log file entry written for each executed instruction.
+//
+// # Fields
+//   - Time:     When the instruction started
+//   - Key:      The manifest key the instruction was planned for
+//   - Type:     The installer type
+//   - Package:  The package (or script) installed
+//   - Command:  The equivalent shell command
+//   - ExitCode: The exit code (-1 if the command did not exit normally)
+//   - Duration: How long the instruction took, in milliseconds
+//   - Stdout, Stderr: The command output, if the runner captures it
+//   - Error:    The error, if the instruction failed
+type InstructionRecord struct {
+	Time     time.Time `json:"time"`
+	Key      string    `json:"key"`
+	Type     string    `json:"type"`
+	Package  string    `json:"package"`
+	Command  string    `json:"command"`
+	ExitCode int       `json:"exit_code"`
+	Duration int64     `json:"duration_ms"`
+	Stdout   string    `json:"stdout,omitempty"`
+	Stderr   string    `json:"stderr,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// OutputCapturer is implemented by ExecRunners that can copy the output of the commands
+// they run to other writers. ExecutePlan uses it to keep the output of each instruction
+// for LogFile and to tell transient failures from permanent ones.
+type OutputCapturer interface {
+	// CaptureOutput sets the writers the output of the following commands is copied
+	// to; nil writers stop the copying.
+	CaptureOutput(stdout, stderr io.Writer)
+}
+
+// CapturedOutput implements OutputCapturer for embedding in an ExecRunner.
+//
+// # Example
+//
+//	type runner struct{ provision.CapturedOutput }
+//	...
+//	stdout, stderr := r.Writers()
+//	c.Stdout = io.MultiWriter(os.Stdout, stdout)
+type CapturedOutput struct {
+	stdout, stderr io.Writer
+}
+
+// CaptureOutput sets the writers returned by Writers.
+func (o *CapturedOutput) CaptureOutput(stdout, stderr io.Writer) {
+	o.stdout, o.stderr = stdout, stderr
+}
+
+// Writers returns the writers the runner should copy command output to. Each is
+// io.Discard when the output is not captured.
+func (o *CapturedOutput) Writers() (stdout, stderr io.Writer) {
+	stdout, stderr = o.stdout, o.stderr
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+	return stdout, stderr
+}
+
+// runCapturing runs an instruction, copying the output of its commands to stdout and
+// stderr if the runner is an OutputCapturer.
+func (p *Provisioner) runCapturing(ctx context.Context, inst InstallInstruction, stdout, stderr io.Writer) error {
+	if capturer, ok := p.Runner.(OutputCapturer); ok {
+		capturer.CaptureOutput(stdout, stderr)
+		defer capturer.CaptureOutput(nil, nil)
+	}
+	return p.runInstruction(ctx, inst)
+}
+
+// exitCoder is implemented by errors carrying a process exit code, such as *exec.ExitError.
+type exitCoder interface {
+	ExitCode() int
+}
+
+// exitCode returns the exit code corresponding to the error of a command.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr exitCoder
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// runLogged runs an instruction, appending a record of it to LogFile. The standard
+// error of the command is also written to stderr.
+func (p *Provisioner) runLogged(ctx context.Context, inst InstallInstruction, stderr *bytes.Buffer) error {
+	var stdout bytes.Buffer
+	start := time.Now()
+	err := p.runCapturing(ctx, inst, &stdout, stderr)
+	rec := InstructionRecord{
+		Time:     start,
+		Key:      inst.Key,
+		Type:     inst.Type,
+		Package:  inst.Package,
+		Command:  ShellCommand(inst),
+		ExitCode: exitCode(err),
+		Duration: time.Since(start).Milliseconds(),
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	if logErr := p.appendLog(rec); logErr != nil && p.Runner != nil {
+		_ = p.Runner.Run("info", fmt.Sprintf("Warning: cannot write log file: %v", logErr))
+	}
+	return err
+}
+
+// appendLog appends a record to LogFile in LogFormat.
+func (p *Provisioner) appendLog(rec InstructionRecord) error {
+	f, err := os.OpenFile(p.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if p.LogFormat == LogFormatText {
+		_, err = io.WriteString(f, formatTextRecord(rec))
+		return err
+	}
+	return json.NewEncoder(f).Encode(rec)
+}
+
+// formatTextRecord renders a record as a summary line followed by its indented output.
+func formatTextRecord(rec InstructionRecord) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s %s exit=%d duration=%dms", rec.Time.Format(time.RFC3339), rec.Key, rec.Type, rec.Package, rec.ExitCode, rec.Duration)
+	if rec.Error != "" {
+		fmt.Fprintf(&b, " error=%q", rec.Error)
+	}
+	b.WriteString("\n")
+	for _, out := range []struct{ name, text string }{{"stdout", rec.Stdout}, {"stderr", rec.Stderr}} {
+		if out.text == "" {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimRight(out.text, "\n"), "\n") {
+			fmt.Fprintf(&b, "  %s: %s\n", out.name, line)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package provision
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+// outputRunner writes to the captured command output and fails "bad".
+type outputRunner struct {
+	fakeExecRunner
+	CapturedOutput
+}
+
+func (o *outputRunner) RunContext(_ context.Context, cmd string, args ...string) error {
+	if cmd == "section" || cmd == "info" {
+		return nil
+	}
+	stdout, stderr := o.Writers()
+	fmt.Fprintf(stdout, "installing %s\n", strings.Join(args, " "))
+	if len(args) > 0 && args[0] == "bad" {
+		fmt.Fprintln(stderr, "E: Unable to locate package bad")
+		return fmt.Errorf("exit status 100")
+	}
+	return nil
+}
+
+func TestExecutePlan_LogFile(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "provision.jsonl")
+	prov := NewProvisioner(&fakeSystemInfo{}, app.Manifest{}, &outputRunner{})
+	prov.LogFile = logFile
+	plan := []InstallInstruction{
+		{Type: "apt", Package: "good", Key: "good"},
+		{Type: "apt", Package: "bad", Key: "bad"},
+	}
+	if err := prov.ExecutePlan(plan); err == nil {
+		t.Fatal("expected ExecutePlan to fail")
+	}
+	f, err := os.Open(logFile)
+	if err != nil {
+		t.Fatalf("expected log file: %v", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	var records []InstructionRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec InstructionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Key != "good" || records[0].ExitCode != 0 || records[0].Stdout != "installing good\n" {
+		t.Errorf("unexpected record for good: %+v", records[0])
+	}
+	if records[1].Error != "exit status 100" || !strings.Contains(records[1].Stderr, "Unable to locate package") {
+		t.Errorf("unexpected record for bad: %+v", records[1])
+	}
+}
+
+func TestFormatTextRecord(t *testing.T) {
+	got := formatTextRecord(InstructionRecord{Key: "bat", Type: "apt", Package: "bat", ExitCode: 100, Duration: 12, Stderr: "E: oops\n", Error: "exit status 100"})
+	if !strings.Contains(got, "bat apt bat exit=100 duration=12ms error=\"exit status 100\"\n") || !strings.Contains(got, "  stderr: E: oops\n") {
+		t.Errorf("unexpected text record: %q", got)
+	}
+}
@@ -0,0 +1,58 @@
+package provision
+
+// MatrixPlatform is a simulated platform an entry's installer is resolved for.
+//
+// # Fields
+//   - Name:       The label shown for the platform, e.g. "ubuntu/x64"
+//   - OS, ID, Arch: The SystemInfo values simulated
+//   - Installers: The installer order used on the platform
+type MatrixPlatform struct {
+	Name       string
+	OS         string
+	ID         string
+	Arch       string
+	Installers []string
+}
+
+// MatrixPlatforms are the platforms Matrix resolves entries for.
+var MatrixPlatforms = []MatrixPlatform{
+	{Name: "ubuntu/x64", OS: "linux", ID: "ubuntu", Arch: "x64", Installers: append([]string{"apt"}, portableInstallers...)},
+	{Name: "fedora/x64", OS: "linux", ID: "fedora", Arch: "x64", Installers: append([]string{"dnf"}, portableInstallers...)},
+	{Name: "darwin/arm64", OS: "darwin", ID: "darwin", Arch: "arm64", Installers: exportTargets[0].installers},
+	{Name: "windows/x64", OS: "windows", ID: "windows", Arch: "x64", Installers: []string{"scoop", "choco", "go", "cargo", "pipx", "binary:windows"}},
+}
+
+// MatrixCell is the installer and package an entry resolves to on one platform.
+// Installer and Package are empty if the entry cannot be installed there.
+type MatrixCell struct {
+	Platform  string
+	Installer string
+	Package   string
+}
+
+// Matrix resolves the installer and package of key on each of MatrixPlatforms, the
+// way planning would on that platform, including OS, distro and arch specific keys.
+// It helps to check advanced keys such as apt:ubuntu or binary:linux:arm64.
+//
+// # Example
+//
+//	for _, cell := range prov.Matrix("ripgrep") {
+//		fmt.Println(cell.Platform, cell.Installer, cell.Package) // e.g. "ubuntu/x64 apt ripgrep"
+//	}
+func (p *Provisioner) Matrix(key string) []MatrixCell {
+	entry := p.Manifest[key]
+	cells := make([]MatrixCell, 0, len(MatrixPlatforms))
+	for _, platform := range MatrixPlatforms {
+		planner := *p
+		planner.System = staticSystemInfo{os: platform.OS, id: platform.ID, arch: platform.Arch}
+		planner.InstallerOrder = platform.Installers
+		cell := MatrixCell{Platform: platform.Name}
+		var plan []InstallInstruction
+		planner.addInstallerInstruction(key, &entry, &plan)
+		if len(plan) > 0 {
+			cell.Installer, cell.Package = plan[0].Type, plan[0].Package
+		}
+		cells = append(cells, cell)
+	}
+	return cells
+}
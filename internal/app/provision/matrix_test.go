@@ -0,0 +1,44 @@
+package provision
+
+import (
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestMatrix(t *testing.T) {
+	manifest := app.Manifest{
+		"ripgrep": {
+			Apt:   app.StringOrSlice{"ripgrep"},
+			Dnf:   app.StringOrSlice{"ripgrep"},
+			Brew:  app.StringOrSlice{"ripgrep"},
+			Scoop: app.StringOrSlice{"ripgrep"},
+		},
+	}
+	raw := map[string]map[string]interface{}{
+		"ripgrep": {"apt": "ripgrep", "apt:ubuntu": "rg-ubuntu", "dnf": "ripgrep", "brew": "ripgrep", "brew:darwin:arm64": "rg-arm", "scoop": "ripgrep"},
+	}
+	prov := NewProvisioner(nil, manifest, nil)
+	prov.ManifestRaw = raw
+	want := map[string]string{
+		"ubuntu/x64":   "apt rg-ubuntu",
+		"fedora/x64":   "dnf ripgrep",
+		"darwin/arm64": "brew rg-arm",
+		"windows/x64":  "scoop ripgrep",
+	}
+	cells := prov.Matrix("ripgrep")
+	if len(cells) != len(MatrixPlatforms) {
+		t.Fatalf("got %d cells, want %d", len(cells), len(MatrixPlatforms))
+	}
+	for _, cell := range cells {
+		if got := cell.Installer + " " + cell.Package; got != want[cell.Platform] {
+			t.Errorf("%s: got %q, want %q", cell.Platform, got, want[cell.Platform])
+		}
+	}
+
+	for _, cell := range NewProvisioner(nil, app.Manifest{"mac-only": {Cask: app.StringOrSlice{"app"}}}, nil).Matrix("mac-only") {
+		if (cell.Installer != "") != (cell.Platform == "darwin/arm64") {
+			t.Errorf("%s: unexpected installer %q for a cask-only entry", cell.Platform, cell.Installer)
+		}
+	}
+}
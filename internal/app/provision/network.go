@@ -0,0 +1,178 @@
+package provision
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// ErrUnreachable is returned when repositories needed by a plan cannot be reached.
+var ErrUnreachable = errors.New("repositories unreachable")
+
+// networkDialTimeout bounds each reachability probe.
+const networkDialTimeout = 5 * time.Second
+
+// installerEndpoints lists the host:port pairs each installer downloads from.
+// apt endpoints are discovered from the system's sources lists when possible.
+var installerEndpoints = map[string][]string{
+	"apt":            {"deb.debian.org:80"},
+	"brew":           {"formulae.brew.sh:443", "ghcr.io:443"},
+	"cask":           {"formulae.brew.sh:443", "ghcr.io:443"},
+	"go":             {"proxy.golang.org:443"},
+	"cargo":          {"index.crates.io:443"},
+	"pipx":           {"pypi.org:443"},
+	"flatpak":        {"dl.flathub.org:443"},
+	"snap":           {"api.snapcraft.io:443"},
+	"dnf":            {"mirrors.fedoraproject.org:443"},
+	"yum":            {"mirrors.fedoraproject.org:443"},
+	"pacman":         {"geo.mirror.pkgbuild.com:443"},
+	"yay":            {"aur.archlinux.org:443"},
+	"apk":            {"dl-cdn.alpinelinux.org:443"},
+	"zypper":         {"download.opensuse.org:443"},
+	"nix":            {"cache.nixos.org:443"},
+	"port":           {"packages.macports.org:443"},
+	"choco":          {"community.chocolatey.org:443"},
+	"scoop":          {"github.com:443"},
+	"script":         {"github.com:443"},
+	"binary:darwin":  {"github.com:443"},
+	"binary:linux":   {"github.com:443"},
+	"binary:windows": {"github.com:443"},
+}
+
+// aptSourcePaths are the files scanned for apt mirror hosts.
+var aptSourcePaths = []string{"/etc/apt/sources.list", "/etc/apt/sources.list.d/*.list", "/etc/apt/sources.list.d/*.sources"}
+
+var aptURLPattern = regexp.MustCompile(`(https?)://([^/\s]+)`)
+
+// ReachabilityFailure describes a repository endpoint that could not be reached.
+//
+// # Fields
+//   - Installer: The installer that needs the endpoint
+//   - Endpoint:  The host:port that was probed
+//   - Err:       The dial error
+type ReachabilityFailure struct {
+	Installer string
+	Endpoint  string
+	Err       error
+}
+
+// Diagnosis returns an actionable hint for the failure.
+func (f ReachabilityFailure) Diagnosis() string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.As(f.Err, &dnsErr):
+		return "DNS lookup failed; check your resolver, VPN or /etc/resolv.conf"
+	case errors.Is(f.Err, syscall.ECONNREFUSED):
+		return "connection refused; check proxy settings (HTTP_PROXY/HTTPS_PROXY)"
+	case errors.As(f.Err, &netErr) && netErr.Timeout():
+		return "connection timed out; a firewall, proxy or captive portal may be blocking traffic"
+	default:
+		return "check your network connection"
+	}
+}
+
+// String returns a human-readable description of the failure.
+func (f ReachabilityFailure) String() string {
+	return fmt.Sprintf("%s: cannot reach %s (%v): %s", f.Installer, f.Endpoint, f.Err, f.Diagnosis())
+}
+
+// aptEndpoints returns the mirror hosts configured in the apt sources lists.
+func aptEndpoints() []string {
+	seen := make(map[string]bool)
+	var endpoints []string
+	for _, pattern := range aptSourcePaths {
+		files, _ := filepath.Glob(pattern)
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			for _, m := range aptURLPattern.FindAllStringSubmatch(string(data), -1) {
+				port := "80"
+				if m[1] == "https" {
+					port = "443"
+				}
+				endpoint := net.JoinHostPort(m[2], port)
+				if !seen[endpoint] {
+					seen[endpoint] = true
+					endpoints = append(endpoints, endpoint)
+				}
+			}
+		}
+	}
+	return endpoints
+}
+
+// planEndpoints returns the endpoints needed by a plan, keyed by endpoint with the installer that needs it.
+// The apt sources lists are read once, at the first apt instruction.
+func planEndpoints(plan []InstallInstruction) map[string]string {
+	endpoints := make(map[string]string)
+	var aptHosts []string
+	for _, inst := range plan {
+		installer := installerOf(inst.Type)
+		hosts := installerEndpoints[installer]
+		if installer == "apt" {
+			if aptHosts == nil {
+				aptHosts = aptEndpoints()
+				if len(aptHosts) == 0 {
+					aptHosts = installerEndpoints["apt"]
+				}
+			}
+			hosts = aptHosts
+		}
+		for _, h := range hosts {
+			if _, ok := endpoints[h]; !ok {
+				endpoints[h] = installer
+			}
+		}
+	}
+	return endpoints
+}
+
+// CheckNetwork probes every repository endpoint the plan will hit.
+//
+// # Returns
+//   - []ReachabilityFailure: The endpoints that could not be reached, sorted by endpoint
+func (p *Provisioner) CheckNetwork(plan []InstallInstruction) []ReachabilityFailure {
+	dial := p.dial
+	if dial == nil {
+		dial = func(address string) error {
+			conn, err := net.DialTimeout("tcp", address, networkDialTimeout)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		}
+	}
+	var failures []ReachabilityFailure
+	for endpoint, installer := range planEndpoints(plan) {
+		if err := dial(endpoint); err != nil {
+			failures = append(failures, ReachabilityFailure{Installer: installer, Endpoint: endpoint, Err: err})
+		}
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Endpoint < failures[j].Endpoint })
+	return failures
+}
+
+// networkPreflight runs CheckNetwork, logs each failure, and returns an error wrapping ErrUnreachable if any failed.
+func (p *Provisioner) networkPreflight(plan []InstallInstruction) error {
+	failures := p.CheckNetwork(plan)
+	if len(failures) == 0 {
+		return nil
+	}
+	errs := make([]error, 0, len(failures))
+	for _, f := range failures {
+		if p.Runner != nil {
+			_ = p.Runner.Run("info", f.String())
+		}
+		errs = append(errs, errors.New(f.String()))
+	}
+	return fmt.Errorf("%w: %w", ErrUnreachable, errors.Join(errs...))
+}
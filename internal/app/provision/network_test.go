@@ -0,0 +1,95 @@
+package provision
+
+import (
+	"errors"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestCheckNetwork(t *testing.T) {
+	prov := NewProvisioner(&fakeSystemInfo{}, nil, &fakeExecRunner{})
+	var dialed []string
+	prov.dial = func(address string) error {
+		dialed = append(dialed, address)
+		if address == "proxy.golang.org:443" {
+			return &net.DNSError{Err: "no such host", Name: "proxy.golang.org", IsNotFound: true}
+		}
+		return nil
+	}
+	plan := []InstallInstruction{
+		{Type: "brew", Package: "bat"},
+		{Type: "brew", Package: "fd"},
+		{Type: "go", Package: "github.com/example/tool@latest"},
+	}
+	failures := prov.CheckNetwork(plan)
+	if len(dialed) != 3 {
+		t.Errorf("expected each endpoint to be probed once, got: %v", dialed)
+	}
+	if len(failures) != 1 || failures[0].Installer != "go" {
+		t.Fatalf("expected one go failure, got: %+v", failures)
+	}
+	if !strings.Contains(failures[0].Diagnosis(), "DNS") {
+		t.Errorf("expected a DNS diagnosis, got: %s", failures[0].Diagnosis())
+	}
+}
+
+func TestPlanEndpoints_AptSources(t *testing.T) {
+	dir := t.TempDir()
+	sources := filepath.Join(dir, "sources.list")
+	content := "deb http://mirror.example.org/debian bookworm main\ndeb https://security.example.org/debian-security bookworm-security main\n"
+	if err := os.WriteFile(sources, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	saved := aptSourcePaths
+	aptSourcePaths = []string{sources}
+	defer func() { aptSourcePaths = saved }()
+
+	plan := []InstallInstruction{{Type: "apt", Package: "bat"}, {Type: "brew", Package: "fd"}, {Type: "apt", Package: "jq"}}
+	got := planEndpoints(plan)
+	want := map[string]string{
+		"mirror.example.org:80":    "apt",
+		"security.example.org:443": "apt",
+		"formulae.brew.sh:443":     "brew",
+		"ghcr.io:443":              "brew",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("planEndpoints = %v, want %v", got, want)
+	}
+
+	aptSourcePaths = []string{filepath.Join(dir, "missing.list")}
+	if got := planEndpoints(plan); got["deb.debian.org:80"] != "apt" || len(got) != 3 {
+		t.Errorf("expected the default apt endpoint without sources lists, got %v", got)
+	}
+}
+
+func TestReachabilityFailure_Diagnosis(t *testing.T) {
+	refused := ReachabilityFailure{Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}
+	if !strings.Contains(refused.Diagnosis(), "proxy") {
+		t.Errorf("expected a proxy hint for refused connections, got: %s", refused.Diagnosis())
+	}
+	other := ReachabilityFailure{Err: errors.New("boom")}
+	if other.Diagnosis() == "" {
+		t.Error("expected a fallback diagnosis")
+	}
+}
+
+func TestExecutePlan_Unreachable(t *testing.T) {
+	runner := &fakeExecRunner{}
+	prov := NewProvisioner(&fakeSystemInfo{}, nil, runner)
+	prov.CheckReachability = true
+	prov.dial = func(string) error { return errors.New("network is unreachable") }
+	err := prov.ExecutePlan([]InstallInstruction{{Type: "brew", Package: "bat"}})
+	if !errors.Is(err, ErrUnreachable) {
+		t.Fatalf("expected ErrUnreachable, got: %v", err)
+	}
+	for _, c := range runner.Commands {
+		if strings.HasPrefix(c, "brew") {
+			t.Errorf("did not expect any install after a failed preflight, got: %v", runner.Commands)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package provision
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// OutdatedPackage is a selected package with a newer version available.
+//
+// # Fields
+//   - Key:       The manifest key
+//   - Installer: The installer the key is installed with, e.g. apt
+//   - Package:   The package name
+//   - Current:   The installed version ("" if the manager does not report it)
+//   - Available: The newer version the manager would upgrade to
+type OutdatedPackage struct {
+	Key       string
+	Installer string
+	Package   string
+	Current   string
+	Available string
+}
+
+// outdatedDetector lists the packages of one installer that have newer versions.
+//
+// # Fields
+//   - Bin:    The executable that must be on PATH for the manager to be queried
+//   - Detect: Queries the manager through the runner; results are keyed by package
+type outdatedDetector struct {
+	Bin    string
+	Detect func(ExecRunner) map[string]OutdatedPackage
+}
+
+// outdatedDetectors are the installers Outdated can query, keyed by installer.
+var outdatedDetectors = map[string]outdatedDetector{
+	"apt":     {Bin: "apt", Detect: getAptOutdated},
+	"brew":    {Bin: "brew", Detect: getBrewOutdated("--formula")},
+	"cask":    {Bin: "brew", Detect: getBrewOutdated("--cask")},
+	"dnf":     {Bin: "dnf", Detect: getDnfOutdated},
+	"pacman":  {Bin: "pacman", Detect: getPacmanOutdated},
+	"zypper":  {Bin: "zypper", Detect: getZypperOutdated},
+	"flatpak": {Bin: "flatpak", Detect: getFlatpakOutdated},
+	"snap":    {Bin: "snap", Detect: getSnapOutdated},
+}
+
+// Outdated asks the package managers which of the selected keys, and the deps they
+// pull in, have newer versions available. Each key is checked with the installer
+// PlanProvision would install it with; keys of other installers are not checked.
+// Managers that are not on PATH are skipped, and the others are queried concurrently,
+// so p.Runner.Output must be safe for concurrent use.
+//
+// # Returns
+//   - []OutdatedPackage: The outdated packages, sorted by key
+//   - error:             If a selected key or (with StrictDeps) a dep is not in the manifest
+func (p *Provisioner) Outdated(keys []string) ([]OutdatedPackage, error) {
+	if p.Runner == nil {
+		return nil, nil
+	}
+	expanded, err := p.expandDeps(p.preferByPriority(keys), "", make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+	var insts []InstallInstruction
+	var installers []string
+	for _, key := range expanded {
+		inst, ok := p.packageInstruction(key)
+		if _, checked := outdatedDetectors[inst.Type]; !ok || !checked {
+			continue
+		}
+		insts = append(insts, inst)
+		if !slices.Contains(installers, inst.Type) {
+			installers = append(installers, inst.Type)
+		}
+	}
+
+	results := make([]map[string]OutdatedPackage, len(installers))
+	lookPath := p.lookPathFunc()
+	var g errgroup.Group
+	g.SetLimit(maxInstalledQueries)
+	for i, installer := range installers {
+		detector := outdatedDetectors[installer]
+		if _, err := lookPath(detector.Bin); err != nil {
+			continue
+		}
+		g.Go(func() error {
+			results[i] = detector.Detect(p.Runner)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	var outdated []OutdatedPackage
+	for _, inst := range insts {
+		found, ok := results[slices.Index(installers, inst.Type)][inst.Package]
+		if !ok {
+			continue
+		}
+		found.Key, found.Installer, found.Package = inst.Key, inst.Type, inst.Package
+		if found.Current == "" {
+			found.Current = p.installedVersion(inst)
+		}
+		outdated = append(outdated, found)
+	}
+	sort.Slice(outdated, func(i, j int) bool { return outdated[i].Key < outdated[j].Key })
+	return outdated, nil
+}
+
+// installedVersion asks the package manager for the installed version of an
+// instruction's package, or returns "" if it cannot be determined.
+func (p *Provisioner) installedVersion(inst InstallInstruction) string {
+	lock := &Lockfile{}
+	p.RecordVersions([]InstallInstruction{inst}, lock)
+	return lock.Packages[inst.Key].Version
+}
+
+// WriteOutdated writes the outdated packages as an aligned table of the current and
+// available versions, followed by a count.
+func WriteOutdated(w io.Writer, outdated []OutdatedPackage) error {
+	if len(outdated) == 0 {
+		_, err := io.WriteString(w, "All selected packages are up to date.\n")
+		return err
+	}
+	rows := [][4]string{{"KEY", "INSTALLER", "CURRENT", "AVAILABLE"}}
+	for _, pkg := range outdated {
+		current := pkg.Current
+		if current == "" {
+			current = "?"
+		}
+		rows = append(rows, [4]string{pkg.Key, pkg.Installer, current, pkg.Available})
+	}
+	var width [3]int
+	for _, row := range rows {
+		for col := range width {
+			width[col] = max(width[col], utf8.RuneCountInString(row[col]))
+		}
+	}
+	var b strings.Builder
+	for i, row := range rows {
+		arrow := "→"
+		if i == 0 {
+			arrow = " "
+		}
+		fmt.Fprintf(&b, "%-*s  %-*s  %-*s %s %s\n", width[0], row[0], width[1], row[1], width[2], row[2], arrow, row[3])
+	}
+	fmt.Fprintf(&b, "\n%d outdated; upgrade them with --upgrade\n", len(outdated))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// getAptOutdated parses `apt list --upgradable`, whose lines read
+// "bat/jammy-updates 0.24.0-1 amd64 [upgradable from: 0.23.0-1]".
+func getAptOutdated(runner ExecRunner) map[string]OutdatedPackage {
+	pkgs := make(map[string]OutdatedPackage)
+	out, err := runner.Output("apt", "list", "--upgradable")
+	if err != nil {
+		return pkgs
+	}
+	scan := bufio.NewScanner(strings.NewReader(string(out)))
+	for scan.Scan() {
+		line := scan.Text()
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		name, _, ok := strings.Cut(fields[0], "/")
+		if !ok {
+			continue
+		}
+		pkg := OutdatedPackage{Available: fields[1]}
+		if _, from, ok := strings.Cut(line, "upgradable from: "); ok {
+			pkg.Current = strings.TrimSuffix(strings.TrimSpace(from), "]")
+		}
+		pkgs[name] = pkg
+	}
+	return pkgs
+}
+
+// getBrewOutdated returns a detector that parses `brew outdated --verbose` for formulae
+// or casks, whose lines read "bat (0.23.0) < 0.24.0" or "firefox (120.0) != 121.0".
+func getBrewOutdated(kind string) func(ExecRunner) map[string]OutdatedPackage {
+	return func(runner ExecRunner) map[string]OutdatedPackage {
+		pkgs := make(map[string]OutdatedPackage)
+		out, err := runner.Output("brew", "outdated", kind, "--verbose")
+		if err != nil {
+			return pkgs
+		}
+		scan := bufio.NewScanner(strings.NewReader(string(out)))
+		for scan.Scan() {
+			name, rest, ok := strings.Cut(strings.TrimSpace(scan.Text()), " (")
+			if !ok {
+				continue
+			}
+			current, rest, ok := strings.Cut(rest, ")")
+			fields := strings.Fields(rest)
+			if !ok || len(fields) < 2 {
+				continue
+			}
+			// Several installed versions are listed comma-separated; the newest is last
+			versions := strings.Split(current, ", ")
+			pkgs[name] = OutdatedPackage{Current: versions[len(versions)-1], Available: fields[len(fields)-1]}
+		}
+		return pkgs
+	}
+}
+
+// getDnfOutdated parses `dnf list --upgrades`, whose lines read "name.arch version repo".
+// It does not report installed versions.
+func getDnfOutdated(runner ExecRunner) map[string]OutdatedPackage {
+	pkgs := make(map[string]OutdatedPackage)
+	out, err := runner.Output("dnf", "list", "--upgrades")
+	if err != nil {
+		return pkgs
+	}
+	scan := bufio.NewScanner(strings.NewReader(string(out)))
+	for scan.Scan() {
+		fields := strings.Fields(scan.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		if dot := strings.LastIndex(fields[0], "."); dot > 0 {
+			pkgs[fields[0][:dot]] = OutdatedPackage{Available: fields[1]}
+		}
+	}
+	return pkgs
+}
+
+// getPacmanOutdated parses `pacman -Qu`, whose lines read "bat 0.23.0-1 -> 0.24.0-1".
+func getPacmanOutdated(runner ExecRunner) map[string]OutdatedPackage {
+	pkgs := make(map[string]OutdatedPackage)
+	out, err := runner.Output("pacman", "-Qu")
+	if err != nil {
+		return pkgs
+	}
+	scan := bufio.NewScanner(strings.NewReader(string(out)))
+	for scan.Scan() {
+		fields := strings.Fields(scan.Text())
+		if len(fields) >= 4 && fields[2] == "->" {
+			pkgs[fields[0]] = OutdatedPackage{Current: fields[1], Available: fields[3]}
+		}
+	}
+	return pkgs
+}
+
+// getZypperOutdated parses the table of `zypper list-updates`, whose rows read
+// "v | repo | name | current | available | arch".
+func getZypperOutdated(runner ExecRunner) map[string]OutdatedPackage {
+	pkgs := make(map[string]OutdatedPackage)
+	out, err := runner.Output("zypper", "--non-interactive", "list-updates")
+	if err != nil {
+		return pkgs
+	}
+	scan := bufio.NewScanner(strings.NewReader(string(out)))
+	for scan.Scan() {
+		cols := strings.Split(scan.Text(), "|")
+		if len(cols) < 5 || strings.TrimSpace(cols[0]) != "v" {
+			continue
+		}
+		pkgs[strings.TrimSpace(cols[2])] = OutdatedPackage{Current: strings.TrimSpace(cols[3]), Available: strings.TrimSpace(cols[4])}
+	}
+	return pkgs
+}
+
+// getFlatpakOutdated lists the applications with updates by ID and new version. It
+// does not report installed versions.
+func getFlatpakOutdated(runner ExecRunner) map[string]OutdatedPackage {
+	pkgs := make(map[string]OutdatedPackage)
+	out, err := runner.Output("flatpak", "remote-ls", "--updates", "--app", "--columns=application,version")
+	if err != nil {
+		return pkgs
+	}
+	scan := bufio.NewScanner(strings.NewReader(string(out)))
+	for scan.Scan() {
+		fields := strings.Fields(scan.Text())
+		if len(fields) > 0 && fields[0] != "Application" {
+			pkgs[fields[0]] = OutdatedPackage{Available: strings.Join(fields[1:], " ")}
+		}
+	}
+	return pkgs
+}
+
+// getSnapOutdated parses `snap refresh --list`, a table headed by "Name Version Rev ..."
+// that lists the new versions. It does not report installed versions.
+func getSnapOutdated(runner ExecRunner) map[string]OutdatedPackage {
+	pkgs := make(map[string]OutdatedPackage)
+	out, err := runner.Output("snap", "refresh", "--list")
+	if err != nil {
+		return pkgs
+	}
+	scan := bufio.NewScanner(strings.NewReader(string(out)))
+	for scan.Scan() {
+		fields := strings.Fields(scan.Text())
+		if len(fields) >= 3 && fields[0] != "Name" {
+			pkgs[fields[0]] = OutdatedPackage{Available: fields[1]}
+		}
+	}
+	return pkgs
+}
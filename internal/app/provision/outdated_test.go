@@ -0,0 +1,94 @@
+package provision
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestOutdated(t *testing.T) {
+	manifest := app.Manifest{
+		"bat":       {Apt: app.StringOrSlice{"bat"}},
+		"jq":        {Apt: app.StringOrSlice{"jq"}, Deps: []string{"oniguruma"}},
+		"ripgrep":   {Brew: app.StringOrSlice{"ripgrep"}},
+		"gimp":      {Flatpak: app.StringOrSlice{"org.gimp.GIMP"}},
+		"tool":      {Pacman: app.StringOrSlice{"tool"}},
+		"custom":    {Script: app.StringOrSlice{"echo hi"}},
+		"oniguruma": {Apt: app.StringOrSlice{"libonig5"}},
+	}
+	runner := &fakeOutputRunner{outputs: map[string][]byte{
+		"apt list --upgradable": []byte(`Listing... Done
+bat/jammy-updates 0.24.0-1 amd64 [upgradable from: 0.23.0-1]
+libonig5/jammy 6.9.8-1 amd64 [upgradable from: 6.9.7-1]
+unrelated/jammy 2.0 amd64 [upgradable from: 1.0]
+`),
+		"brew outdated --formula --verbose":                               []byte("ripgrep (13.0.0, 14.0.3) < 14.1.0\n"),
+		"flatpak remote-ls --updates --app --columns=application,version": []byte("org.gimp.GIMP\t2.10.38\n"),
+		"pacman -Qu": []byte("tool 1.0-1 -> 1.1-1\n"),
+	}}
+	prov := NewProvisioner(nil, manifest, runner)
+	prov.InstallerOrder = []string{"apt", "brew", "flatpak", "pacman"}
+	prov.lookPath = func(bin string) (string, error) {
+		if bin == "pacman" {
+			return "", errors.New("not found")
+		}
+		return "/usr/bin/" + bin, nil
+	}
+	outdated, err := prov.Outdated([]string{"bat", "custom", "gimp", "jq", "ripgrep", "tool"})
+	if err != nil {
+		t.Fatalf("Outdated error: %v", err)
+	}
+	want := []OutdatedPackage{
+		{Key: "bat", Installer: "apt", Package: "bat", Current: "0.23.0-1", Available: "0.24.0-1"},
+		{Key: "gimp", Installer: "flatpak", Package: "org.gimp.GIMP", Current: "", Available: "2.10.38"},
+		{Key: "oniguruma", Installer: "apt", Package: "libonig5", Current: "6.9.7-1", Available: "6.9.8-1"},
+		{Key: "ripgrep", Installer: "brew", Package: "ripgrep", Current: "14.0.3", Available: "14.1.0"},
+	}
+	if len(outdated) != len(want) {
+		t.Fatalf("got %+v, want %+v", outdated, want)
+	}
+	for i := range want {
+		if outdated[i] != want[i] {
+			t.Errorf("got %+v, want %+v", outdated[i], want[i])
+		}
+	}
+
+	var b strings.Builder
+	if err := WriteOutdated(&b, outdated[:2]); err != nil {
+		t.Fatalf("WriteOutdated error: %v", err)
+	}
+	wantTable := `KEY   INSTALLER  CURRENT    AVAILABLE
+bat   apt        0.23.0-1 → 0.24.0-1
+gimp  flatpak    ?        → 2.10.38
+
+2 outdated; upgrade them with --upgrade
+`
+	if b.String() != wantTable {
+		t.Errorf("WriteOutdated output:\n%s\nwant:\n%s", b.String(), wantTable)
+	}
+}
+
+func TestOutdatedParsers(t *testing.T) {
+	runner := &fakeOutputRunner{outputs: map[string][]byte{
+		"dnf list --upgrades": []byte(`Last metadata expiration check: 0:10:00 ago.
+Available Upgrades
+bat.x86_64    0.24.0-1.fc39    updates
+`),
+		"zypper --non-interactive list-updates": []byte(`S | Repository | Name | Current Version | Available Version | Arch
+--+------------+------+-----------------+-------------------+-------
+v | repo-oss   | bat  | 0.23.0-1.1      | 0.24.0-1.1        | x86_64
+`),
+		"snap refresh --list": []byte("Name     Version  Rev   Size  Publisher  Notes\nfirefox  121.0    3600  80MB  mozilla**  -\n"),
+	}}
+	if got := getDnfOutdated(runner)["bat"]; got.Available != "0.24.0-1.fc39" {
+		t.Errorf("dnf: unexpected %+v", got)
+	}
+	if got := getZypperOutdated(runner)["bat"]; got.Current != "0.23.0-1.1" || got.Available != "0.24.0-1.1" {
+		t.Errorf("zypper: unexpected %+v", got)
+	}
+	if got := getSnapOutdated(runner); len(got) != 1 || got["firefox"].Available != "121.0" {
+		t.Errorf("snap: unexpected %+v", got)
+	}
+}
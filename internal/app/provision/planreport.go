@@ -0,0 +1,135 @@
+package provision
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SkipReason is why planning left a key out of the plan.
+type SkipReason string
+
+// Skip reasons of a KeyDecision.
+const (
+	SkipInstalled      SkipReason = "installed"       // The key is already installed
+	SkipHeadless       SkipReason = "headless"        // The key is a GUI app on a headless system
+	SkipNotLazy        SkipReason = "not-lazy"        // LazyOnly is set and the key is not lazy
+	SkipExcluded       SkipReason = "excluded"        // The key is in Exclude
+	SkipGroup          SkipReason = "skipped-group"   // The key is in one of SkipGroups
+	SkipNotInstallable SkipReason = "not-installable" // No installer of the key works on this system
+	SkipNotUpgradable  SkipReason = "not-upgradable"  // Upgrade: no package manager can upgrade the key
+	SkipCondition      SkipReason = "condition"       // The key's _when condition is false on this system
+	SkipConflict       SkipReason = "conflict"        // The key conflicts with a key planned before it
+)
+
+// KeyDecision is what planning decided for one key.
+//
+// # Fields
+//   - Key:     The manifest key
+//   - Planned: Whether instructions were planned for the key (for a meta-package, its deps)
+//   - Reason:  Why the key was skipped ("" if it was planned)
+//   - Detail:  A human-readable description of the reason, e.g. "group gui is skipped"
+//     (for a meta-package, its members)
+type KeyDecision struct {
+	Key     string     `json:"key"`
+	Planned bool       `json:"planned"`
+	Reason  SkipReason `json:"reason,omitempty"`
+	Detail  string     `json:"detail,omitempty"`
+}
+
+// String returns the key and, for a skipped key, why, e.g. "bat: already installed".
+func (d KeyDecision) String() string {
+	if d.Planned {
+		return d.Key
+	}
+	return fmt.Sprintf("%s: %s", d.Key, d.Detail)
+}
+
+// PlanReport holds the decision for each key PlanProvision or PlanUpgrade considered,
+// in planning order, so callers can summarize the plan without parsing log lines.
+//
+// # Fields
+//   - Decisions: The decision for each key
+//   - Choices:   The alternative picked for each _deps_any group
+type PlanReport struct {
+	Decisions []KeyDecision `json:"decisions"`
+	Choices   []DepChoice   `json:"choices,omitempty"`
+}
+
+// Planned returns the keys instructions were planned for.
+func (r *PlanReport) Planned() []string {
+	var keys []string
+	for _, d := range r.decisions() {
+		if d.Planned {
+			keys = append(keys, d.Key)
+		}
+	}
+	return keys
+}
+
+// Skipped returns the decisions of the keys that were left out of the plan.
+func (r *PlanReport) Skipped() []KeyDecision {
+	var skipped []KeyDecision
+	for _, d := range r.decisions() {
+		if !d.Planned {
+			skipped = append(skipped, d)
+		}
+	}
+	return skipped
+}
+
+// SkipSummary counts the skipped keys by reason, in the order the reasons first occur,
+// e.g. "2 installed, 1 excluded" ("" if nothing was skipped).
+func (r *PlanReport) SkipSummary() string {
+	counts := make(map[SkipReason]int)
+	var order []SkipReason
+	for _, d := range r.Skipped() {
+		if counts[d.Reason] == 0 {
+			order = append(order, d.Reason)
+		}
+		counts[d.Reason]++
+	}
+	parts := make([]string, len(order))
+	for i, reason := range order {
+		parts[i] = fmt.Sprintf("%d %s", counts[reason], reason)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// choices returns the _deps_any choices, or nil for a nil report.
+func (r *PlanReport) choices() []DepChoice {
+	if r == nil {
+		return nil
+	}
+	return r.Choices
+}
+
+// decisions returns the decisions, or nil for a nil report.
+func (r *PlanReport) decisions() []KeyDecision {
+	if r == nil {
+		return nil
+	}
+	return r.Decisions
+}
+
+// decide records the decision for a key in PlanReport, starting a report if needed.
+func (p *Provisioner) decide(d KeyDecision) {
+	if p.PlanReport == nil {
+		p.PlanReport = &PlanReport{}
+	}
+	p.PlanReport.Decisions = append(p.PlanReport.Decisions, d)
+}
+
+// record adds the choice of a _deps_any alternative to the report, if there is one.
+func (r *PlanReport) record(c DepChoice) {
+	if r != nil {
+		r.Choices = append(r.Choices, c)
+	}
+}
+
+// skip records that key was left out of the plan and logs why.
+func (p *Provisioner) skip(key string, reason SkipReason, detail string) {
+	p.decide(KeyDecision{Key: key, Reason: reason, Detail: detail})
+	if p.Runner != nil {
+		_ = p.Runner.Run("info", fmt.Sprintf("Skipping %s: %s", key, detail))
+	}
+}
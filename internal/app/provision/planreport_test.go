@@ -0,0 +1,59 @@
+package provision
+
+import (
+	"slices"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestPlanReport(t *testing.T) {
+	manifest := app.Manifest{
+		"bat":    {Apt: app.StringOrSlice{"bat"}},
+		"jq":     {Apt: app.StringOrSlice{"jq"}},
+		"docker": {Apt: app.StringOrSlice{"docker.io"}},
+		"editor": {Apt: app.StringOrSlice{"editor"}, Groups: []string{"gui"}},
+		"macapp": {Cask: app.StringOrSlice{"macapp"}},
+	}
+	prov := NewProvisioner(nil, manifest, &fakeExecRunner{})
+	prov.InstallerOrder = []string{"apt"}
+	prov.Exclude = []string{"docker"}
+	prov.SkipGroups = []string{"gui"}
+	keys := []string{"bat", "docker", "editor", "jq", "macapp"}
+	if _, err := prov.PlanProvision(keys, map[string]bool{"bat": true}); err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	if got := prov.PlanReport.Planned(); !slices.Equal(got, []string{"jq"}) {
+		t.Errorf("planned %v, want [jq]", got)
+	}
+	var reasons []SkipReason
+	for _, d := range prov.PlanReport.Skipped() {
+		reasons = append(reasons, d.Reason)
+	}
+	want := []SkipReason{SkipExcluded, SkipGroup, SkipInstalled, SkipNotInstallable}
+	if !slices.Equal(reasons, want) {
+		t.Errorf("got skip reasons %v, want %v", reasons, want)
+	}
+	if got := prov.PlanReport.Skipped()[0].String(); got != "docker: excluded" {
+		t.Errorf("String() = %q, want %q", got, "docker: excluded")
+	}
+	summary := "1 excluded, 1 skipped-group, 1 installed, 1 not-installable"
+	if got := prov.PlanReport.SkipSummary(); got != summary {
+		t.Errorf("SkipSummary() = %q, want %q", got, summary)
+	}
+
+	// A new plan starts a new report
+	if _, err := prov.PlanProvision([]string{"jq"}, nil); err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	if len(prov.PlanReport.Decisions) != 1 || prov.PlanReport.SkipSummary() != "" {
+		t.Errorf("expected only jq in the new report, got %+v", prov.PlanReport.Decisions)
+	}
+}
+
+func TestPlanReportNil(t *testing.T) {
+	var r *PlanReport
+	if r.Planned() != nil || r.Skipped() != nil || r.SkipSummary() != "" {
+		t.Error("expected a nil report to be empty")
+	}
+}
@@ -0,0 +1,132 @@
+package provision
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Porcelain views select what WritePorcelain prints.
+//
+// Porcelain output is meant for scripts: one record per line, fields separated by a
+// single tab, no header and no color. The columns of each view are stable and new
+// columns are only ever appended, so consumers can rely on field positions.
+const (
+	// PorcelainList prints the selected manifest entries:
+	//	<key> TAB <name> TAB <groups, comma-separated> TAB <description>
+	PorcelainList = "list"
+	// PorcelainPlan prints the planned install instructions in execution order:
+	//	<key> TAB <installer> TAB <package> TAB <shell command>
+	PorcelainPlan = "plan"
+	// PorcelainStatus prints the install state of the selected keys:
+	//	<key> TAB <installed|missing|deferred|unavailable> TAB <reason>
+	PorcelainStatus = "status"
+)
+
+// PorcelainViews lists the supported porcelain views.
+var PorcelainViews = []string{PorcelainList, PorcelainPlan, PorcelainStatus}
+
+// Status values reported by the porcelain status view.
+const (
+	StatusInstalled   = "installed"
+	StatusMissing     = "missing"
+	StatusDeferred    = "deferred"
+	StatusUnavailable = "unavailable"
+)
+
+// porcelainField makes a value safe for a tab-separated record.
+// Tabs and line breaks become spaces and empty values are written as "-".
+func porcelainField(s string) string {
+	s = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(strings.TrimSpace(s))
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// writeRecord writes one porcelain record.
+func writeRecord(w io.Writer, fields ...string) error {
+	for i := range fields {
+		fields[i] = porcelainField(fields[i])
+	}
+	_, err := fmt.Fprintln(w, strings.Join(fields, "\t"))
+	return err
+}
+
+// WritePorcelain writes the given view for keys to w.
+//
+// # Parameters
+//   - w:         Destination of the records
+//   - view:      One of PorcelainList, PorcelainPlan or PorcelainStatus
+//   - keys:      The selected manifest keys
+//   - installed: Installed packages, as returned by GetInstalledPackages
+//
+// # Returns
+//   - error: If the view is unknown, planning fails or writing fails
+func (p *Provisioner) WritePorcelain(w io.Writer, view string, keys []string, installed map[string]bool) error {
+	switch view {
+	case PorcelainList:
+		return p.writePorcelainList(w, keys)
+	case PorcelainPlan:
+		plan, err := p.PlanProvision(keys, installed)
+		if err != nil {
+			return err
+		}
+		return writePorcelainPlan(w, plan)
+	case PorcelainStatus:
+		return p.writePorcelainStatus(w, keys, installed)
+	}
+	return fmt.Errorf("unknown porcelain view %q (want one of %s)", view, strings.Join(PorcelainViews, ", "))
+}
+
+// writePorcelainList writes one record per selected manifest entry, sorted by key.
+func (p *Provisioner) writePorcelainList(w io.Writer, keys []string) error {
+	for _, key := range sortedKeys(p.preferByPriority(keys)) {
+		entry, ok := p.Manifest[key]
+		if !ok {
+			continue
+		}
+		if err := writeRecord(w, key, entry.Name, strings.Join(entry.Groups, ","), entry.Desc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePorcelainPlan writes one record per instruction, in plan order.
+func writePorcelainPlan(w io.Writer, plan []InstallInstruction) error {
+	for _, inst := range plan {
+		if err := writeRecord(w, inst.Key, inst.Type, inst.Package, ShellCommand(inst)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePorcelainStatus writes the install state of each selected key, sorted by key.
+func (p *Provisioner) writePorcelainStatus(w io.Writer, keys []string, installed map[string]bool) error {
+	for _, key := range sortedKeys(p.preferByPriority(keys)) {
+		status, reason := StatusMissing, ""
+		switch ok, why := p.Installability(key); {
+		case installed[key], installed != nil && p.binsOnPath(key):
+			status = StatusInstalled
+		case !ok:
+			status, reason = StatusUnavailable, why
+		case p.History != nil && slices.Contains(p.History.Deferred, key):
+			status, reason = StatusDeferred, "deferred by a time-boxed run"
+		}
+		if err := writeRecord(w, key, status, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns a sorted, de-duplicated copy of keys.
+func sortedKeys(keys []string) []string {
+	sorted := slices.Clone(keys)
+	sort.Strings(sorted)
+	return slices.Compact(sorted)
+}
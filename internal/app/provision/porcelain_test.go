@@ -0,0 +1,76 @@
+package provision
+
+import (
+	"bytes"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func porcelainManifest() app.Manifest {
+	return app.Manifest{
+		"bat": app.SoftwareEntry{
+			Name:   "bat",
+			Desc:   "A cat clone\twith wings",
+			Groups: app.StringOrSlice{"cli", "dev"},
+			Brew:   app.StringOrSlice{"bat"},
+		},
+		"fd": app.SoftwareEntry{
+			Name: "fd",
+			Brew: app.StringOrSlice{"fd"},
+		},
+		"win-only": app.SoftwareEntry{
+			Name:  "win-only",
+			Scoop: app.StringOrSlice{"win-only"},
+		},
+	}
+}
+
+func TestWritePorcelain_List(t *testing.T) {
+	prov := NewProvisioner(nil, porcelainManifest(), nil)
+	var buf bytes.Buffer
+	if err := prov.WritePorcelain(&buf, PorcelainList, []string{"fd", "bat", "fd"}, nil); err != nil {
+		t.Fatalf("WritePorcelain error: %v", err)
+	}
+	want := "bat\tbat\tcli,dev\tA cat clone with wings\n" +
+		"fd\tfd\t-\t-\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%q\nwant:\n%q", buf.String(), want)
+	}
+}
+
+func TestWritePorcelain_Plan(t *testing.T) {
+	prov := NewProvisioner(nil, porcelainManifest(), nil)
+	var buf bytes.Buffer
+	if err := prov.WritePorcelain(&buf, PorcelainPlan, []string{"bat", "fd"}, map[string]bool{"fd": true}); err != nil {
+		t.Fatalf("WritePorcelain error: %v", err)
+	}
+	want := "bat\tbrew\tbat\tbrew install bat\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%q\nwant:\n%q", buf.String(), want)
+	}
+}
+
+func TestWritePorcelain_Status(t *testing.T) {
+	sys := staticSystemInfo{os: "linux", arch: "amd64", id: "ubuntu"}
+	prov := NewProvisioner(sys, porcelainManifest(), nil)
+	prov.History = &History{Deferred: []string{"fd"}}
+	var buf bytes.Buffer
+	keys := []string{"win-only", "fd", "bat"}
+	if err := prov.WritePorcelain(&buf, PorcelainStatus, keys, map[string]bool{"bat": true}); err != nil {
+		t.Fatalf("WritePorcelain error: %v", err)
+	}
+	want := "bat\tinstalled\t-\n" +
+		"fd\tdeferred\tdeferred by a time-boxed run\n" +
+		"win-only\tunavailable\tno install method for linux/amd64 (only scoop)\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%q\nwant:\n%q", buf.String(), want)
+	}
+}
+
+func TestWritePorcelain_UnknownView(t *testing.T) {
+	prov := NewProvisioner(nil, porcelainManifest(), nil)
+	if err := prov.WritePorcelain(&bytes.Buffer{}, "json", []string{"bat"}, nil); err == nil {
+		t.Error("expected error for unknown view, got nil")
+	}
+}
@@ -0,0 +1,102 @@
+package provision
+
+import (
+	"fmt"
+	"slices"
+)
+
+// defaultInstallerOrder is the order installers are tried in for each entry when
+// InstallerOrder is not set, before the native managers of the system are moved first.
+var defaultInstallerOrder = []string{
+	"apt", "brew", "pacman", "apk", "dnf", "zypper", "scoop", "choco", "go", "cargo", "pipx", "cask", "flatpak", "snap", "port", "yay", "pkg", "emerge", "nix", "mas", "xbps", "binary:darwin", "binary:linux", "binary:windows",
+}
+
+// nativeInstallers are the package managers of an OS (GOOS) or distribution (os-release
+// ID), which are preferred over the others there.
+var nativeInstallers = map[string][]string{
+	"darwin":   {"brew", "cask", "mas", "port"},
+	"windows":  {"scoop", "choco"},
+	"debian":   {"apt"},
+	"ubuntu":   {"apt"},
+	"fedora":   {"dnf"},
+	"rhel":     {"dnf"},
+	"centos":   {"dnf"},
+	"arch":     {"pacman", "yay"},
+	"alpine":   {"apk"},
+	"suse":     {"zypper"},
+	"opensuse": {"zypper"},
+	"gentoo":   {"emerge"},
+	"void":     {"xbps"},
+}
+
+// DefaultInstallerOrder returns the order installers are tried in on a system: the
+// native package managers of its OS and distribution (brew on macOS, apt on Debian and
+// its derivatives, ...) first, then the others in the built-in order.
+//
+// # Parameters
+//   - sys: The system to order the installers for (nil for the built-in order)
+func DefaultInstallerOrder(sys SystemInfo) []string {
+	if sys == nil {
+		return slices.Clone(defaultInstallerOrder)
+	}
+	var native []string
+	for _, name := range append([]string{sys.OS(), sys.ID()}, distroLike(sys)...) {
+		native = append(native, nativeInstallers[name]...)
+	}
+	return PreferInstallers(defaultInstallerOrder, native)
+}
+
+// distroLike returns the distributions a system's distribution is derived from
+// (os-release ID_LIKE), if the system reports them.
+func distroLike(sys SystemInfo) []string {
+	if info, ok := sys.(*RealSystemInfo); ok {
+		return info.IDLike
+	}
+	return nil
+}
+
+// PreferInstallers returns order with the preferred installers moved to the front, in
+// the order they are preferred in. Installers that are not in order are added.
+//
+// # Example
+//
+//	PreferInstallers([]string{"apt", "brew", "snap"}, []string{"brew"}) // [brew apt snap]
+func PreferInstallers(order, prefer []string) []string {
+	var result []string
+	for _, name := range prefer {
+		if !slices.Contains(result, name) {
+			result = append(result, name)
+		}
+	}
+	for _, name := range order {
+		if !slices.Contains(result, name) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// CheckInstallers returns an error naming the first of names that is not an installer.
+func CheckInstallers(names []string) error {
+	for _, name := range names {
+		if _, ok := installerPlatforms[name]; !ok && !slices.Contains(defaultInstallerOrder, name) {
+			return fmt.Errorf("unknown installer %q", name)
+		}
+	}
+	return nil
+}
+
+// installerOrder returns InstallerOrder, or the default order for the system if it is not
+// set, without the DisabledManagers.
+func (p *Provisioner) installerOrder() []string {
+	order := p.InstallerOrder
+	if len(order) == 0 {
+		order = DefaultInstallerOrder(p.System)
+	}
+	if len(p.DisabledManagers) == 0 {
+		return order
+	}
+	return slices.DeleteFunc(slices.Clone(order), func(name string) bool {
+		return slices.Contains(p.DisabledManagers, name)
+	})
+}
@@ -0,0 +1,83 @@
+package provision
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestDefaultInstallerOrder(t *testing.T) {
+	tests := []struct {
+		sys   SystemInfo
+		first []string
+	}{
+		{nil, []string{"apt", "brew"}},
+		{staticSystemInfo{os: "darwin", arch: "arm64"}, []string{"brew", "cask", "mas", "port", "apt"}},
+		{staticSystemInfo{os: "linux", arch: "x86_64", id: "ubuntu"}, []string{"apt", "brew"}},
+		{staticSystemInfo{os: "linux", arch: "x86_64", id: "arch"}, []string{"pacman", "yay", "apt"}},
+		{&RealSystemInfo{GOOS: "linux", DistroID: "linuxmint", IDLike: []string{"ubuntu", "debian"}}, []string{"apt", "brew"}},
+	}
+	for _, tt := range tests {
+		got := DefaultInstallerOrder(tt.sys)
+		if !slices.Equal(got[:len(tt.first)], tt.first) {
+			t.Errorf("DefaultInstallerOrder(%v) starts with %v, want %v", tt.sys, got[:len(tt.first)], tt.first)
+		}
+		if len(got) != len(defaultInstallerOrder) {
+			t.Errorf("DefaultInstallerOrder(%v) has %d installers, want %d", tt.sys, len(got), len(defaultInstallerOrder))
+		}
+	}
+}
+
+func TestPreferInstallers(t *testing.T) {
+	got := PreferInstallers([]string{"apt", "brew", "snap"}, []string{"snap", "yum", "snap"})
+	if want := []string{"snap", "yum", "apt", "brew"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if err := CheckInstallers([]string{"brew", "yum", "binary:linux"}); err != nil {
+		t.Errorf("CheckInstallers error: %v", err)
+	}
+	if err := CheckInstallers([]string{"brew", "brwe"}); err == nil {
+		t.Error("expected an error for an unknown installer")
+	}
+}
+
+func TestPlanProvisionPreferredInstaller(t *testing.T) {
+	manifest := app.Manifest{"bat": {Apt: app.StringOrSlice{"bat"}, Pacman: app.StringOrSlice{"bat"}, Brew: app.StringOrSlice{"bat"}}}
+	prov := NewProvisioner(staticSystemInfo{os: "linux", arch: "x86_64", id: "arch"}, manifest, nil)
+	plan, err := prov.PlanProvision([]string{"bat"}, nil)
+	if err != nil || len(plan) != 1 || plan[0].Type != "pacman" {
+		t.Errorf("expected pacman by default on Arch, got %+v, %v", plan, err)
+	}
+	prov.InstallerOrder = PreferInstallers(DefaultInstallerOrder(prov.System), []string{"brew"})
+	plan, err = prov.PlanProvision([]string{"bat"}, nil)
+	if err != nil || len(plan) != 1 || plan[0].Type != "brew" {
+		t.Errorf("expected the preferred brew, got %+v, %v", plan, err)
+	}
+}
+
+func TestPlanProvisionDisabledManagers(t *testing.T) {
+	manifest := app.Manifest{
+		"vlc":   {Snap: app.StringOrSlice{"vlc"}, Flatpak: app.StringOrSlice{"org.videolan.VLC"}, Apt: app.StringOrSlice{"vlc"}},
+		"spot":  {Snap: app.StringOrSlice{"spotify"}},
+		"other": {Apt: app.StringOrSlice{"other"}},
+	}
+	prov := NewProvisioner(staticSystemInfo{os: "linux", arch: "x86_64", id: "ubuntu"}, manifest, nil)
+	prov.InstallerOrder = []string{"snap", "flatpak", "apt"}
+	prov.DisabledManagers = []string{"snap", "flatpak"}
+	plan, err := prov.PlanProvision([]string{"vlc", "spot", "other"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	want := []InstallInstruction{
+		{Type: "apt", Package: "vlc", Key: "vlc"},
+		{Type: "apt", Package: "other", Key: "other"},
+	}
+	if !slices.Equal(plan, want) {
+		t.Errorf("got plan %+v, want %+v", plan, want)
+	}
+	if ok, reason := prov.Installability("spot"); ok || !strings.Contains(reason, "disabled: snap") {
+		t.Errorf("expected spot to be uninstallable with snap disabled, got %v %q", ok, reason)
+	}
+}
@@ -0,0 +1,48 @@
+package provision
+
+import "strings"
+
+// scriptDelimiter ends the here-document that holds a script in generated shell commands.
+const scriptDelimiter = "A_LA_CARTE_SCRIPT"
+
+// scriptCommand returns the shell command that runs a script instruction: the script
+// fed to bash as a here-document.
+func scriptCommand(inst InstallInstruction) string {
+	return "bash <<'" + scriptDelimiter + "'\n" + strings.TrimRight(inst.Package, "\n") + "\n" + scriptDelimiter
+}
+
+// PreviewCommands returns the shell commands executing a plan would run on this system,
+// in order: the package managers bootstrapped first (with BootstrapManagers), each
+// repository refresh before its installer's first install (with RefreshRepos), and the
+// command of every instruction. Nothing is run. Managers that cannot be bootstrapped
+// are left out; executing the plan reports them.
+//
+// # Example
+//
+//	plan, _ := prov.PlanProvision([]string{"bat"}, installed)
+//	prov.PreviewCommands(plan) // ["sudo env DEBIAN_FRONTEND=noninteractive apt-get install -y --no-install-recommends bat"]
+func (p *Provisioner) PreviewCommands(plan []InstallInstruction) []string {
+	var cmds []string
+	if len(plan) == 0 {
+		return cmds
+	}
+	if p.BootstrapManagers {
+		for _, manager := range p.MissingManagers(plan) {
+			if inst, err := p.BootstrapInstruction(manager); err == nil {
+				cmds = append(cmds, ShellCommand(inst))
+			}
+		}
+	}
+	refreshed := make(map[string]bool)
+	for _, inst := range plan {
+		if cmd := p.pendingRefresh(installerOf(inst.Type), refreshed); cmd != nil {
+			cmds = append(cmds, strings.Join(cmd, " "))
+		}
+		if isScriptType(inst.Type) {
+			cmds = append(cmds, scriptCommand(inst))
+			continue
+		}
+		cmds = append(cmds, ShellCommand(inst))
+	}
+	return cmds
+}
@@ -0,0 +1,42 @@
+package provision
+
+import (
+	"slices"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestPreviewCommands(t *testing.T) {
+	manifest := app.Manifest{
+		"black": {Pipx: app.StringOrSlice{"black"}, PreInstall: app.StringOrSlice{"echo before\n"}},
+		"jq":    {Apk: app.StringOrSlice{"jq"}},
+	}
+	runner := &fakeExecRunner{}
+	prov := NewProvisioner(staticSystemInfo{os: "linux"}, manifest, runner)
+	prov.BootstrapManagers = true
+	prov.RefreshRepos = true
+	prov.lookPath = fakeLookPath("apk")
+	plan := []InstallInstruction{
+		{Type: HookPreInstall, Package: "echo before\n", Key: "black"},
+		{Type: "pipx", Package: "black", Key: "black"},
+		{Type: "apk", Package: "jq", Key: "jq"},
+	}
+
+	want := []string{
+		"sudo apk add --no-cache pipx",
+		"bash <<'A_LA_CARTE_SCRIPT'\necho before\nA_LA_CARTE_SCRIPT",
+		"pipx install black",
+		"sudo apk update",
+		"sudo apk add --no-cache jq",
+	}
+	if got := prov.PreviewCommands(plan); !slices.Equal(got, want) {
+		t.Errorf("got commands %q, want %q", got, want)
+	}
+	if len(runner.Commands) != 0 {
+		t.Errorf("expected nothing to run, got %q", runner.Commands)
+	}
+	if got := prov.PreviewCommands(nil); len(got) != 0 {
+		t.Errorf("expected no commands for an empty plan, got %q", got)
+	}
+}
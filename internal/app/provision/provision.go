@@ -1,10 +1,13 @@
 package provision
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"errors"
 
@@ -17,7 +20,7 @@ import (
 //
 // # Usage
 //
-//	sys := &RealSystemInfo{}
+//	sys := DetectSystem()
 //	os := sys.OS()
 type SystemInfo interface {
 	OS() string
@@ -32,8 +35,13 @@ type SystemInfo interface {
 //
 //	runner := &RealExecRunner{}
 //	err := runner.Run("echo", "hello")
+//	err = runner.RunContext(ctx, "echo", "hello") // stops the command when ctx is done
+//
+// The pseudo-command "script" runs args[0] as a script; any further args are the
+// sandbox command to run the shell under (see ScriptSandbox).
 type ExecRunner interface {
 	Run(cmd string, args ...string) error
+	RunContext(ctx context.Context, cmd string, args ...string) error
 	Output(cmd string, args ...string) ([]byte, error)
 }
 
@@ -42,35 +50,141 @@ type ExecRunner interface {
 // # Fields
 //   - System:   Provides system/OS info
 //   - Manifest: The loaded software manifest
-//   - ManifestRaw: The raw manifest map for advanced key matching (optional)
+//   - ManifestRaw: Raw entries overriding the Raw of the manifest's entries for advanced key matching (optional)
 //   - Runner:   Executes system commands
-//   - InstallerOrder: Preferred order of installer types (overrides default)
-//   - LazyOnly: If true, only install packages with Lazy=true
+//   - InstallerOrder: Preferred order of installer types (overrides DefaultInstallerOrder)
+//   - DisabledManagers: Installer types that are never planned; entries fall back to their next installer
+//   - LazyOnly: If true, only install packages in the optional tier (Lazy=true)
+//   - IncludeGUI: If true, GUI apps are installed even on headless systems
+//   - ForceHeadless: If true, GUI apps are skipped as on a headless system, even when there is a display
 //   - DryRun:   If true, do not actually run commands, just log them
 //   - DryRunLog: Stores dry run log entries
-//   - Errors:   Aggregated errors from last ExecutePlan
+//   - Errors:   The errors of the last ExecutePlan; failed instructions are *InstructionError
+//   - FailFast: If true, ExecutePlan stops at the first failed instruction instead of continuing with the rest
 //   - LogFile:  If set, logs all command attempts and errors to this file
+//   - LogFormat: Format of LogFile, LogFormatJSON (default, one object per line) or LogFormatText
+//   - LockTimeout: How long to wait for a package-manager lock held by another process
+//   - MinFreeSpaceMB: Free disk space (MB) that must remain after the estimated install size; 0 disables the check
+//   - DiskSpaceWarnOnly: If true, too little free space only logs a warning (free space that cannot be read always does)
+//   - DiskPath: The filesystem path checked for free space (defaults to "/", or the system drive on Windows)
+//   - CheckReachability: If true, repositories used by the plan are probed before installing
+//   - MaxDuration: Time budget for ExecutePlan; quick keys run first and keys that do not fit are deferred (0 disables)
+//   - History: Install durations from previous runs; updated by ExecutePlan (optional)
+//   - Deferred: Keys deferred by the last ExecutePlan because they did not fit in MaxDuration
+//   - RunLog: Archive of the run; ExecutePlan records each instruction and its result (optional)
+//   - Progress: Called by ExecutePlan as instructions start and when the plan finishes (optional)
+//   - StepDone: Called by ExecutePlan with the result of each instruction, as recorded in Report (optional)
+//   - RetryPolicy: How often transient failures (network errors, timeouts, held locks) are retried
+//   - RefreshRepos: If true, package indexes are refreshed once per manager before its first install
+//   - BinDir: Where binary:* installers put executables (defaults to ~/.local/bin)
+//   - ScriptSandbox: How scripts run: SandboxNone (default), SandboxBwrap, SandboxFirejail or SandboxEnv
+//   - Report: Summary of the last ExecutePlan: each instruction's result, durations and the environment
+//   - Exclude: Keys left out of the plan, even when other keys depend on them
+//   - SkipGroups: Groups whose keys are left out of the plan, even when other keys depend on them
+//   - BinOnPath: If true, entries whose _bin executables are all on PATH count as installed
+//   - NoServices: If true, the _service of installed entries is not enabled and started
+//   - StrictDeps: If true, a deps reference to a missing key fails planning instead of being skipped with a warning
+//   - Warnings: Problems found by the last PlanProvision that did not stop planning
+//   - PlanReport: What the last PlanProvision or PlanUpgrade decided for each key: planned, or skipped and why
+//   - Locked: If set, PlanProvision pins package installs to the versions in this lockfile
+//   - Namespaces: Names of merged manifests in priority order, used to resolve bare and duplicate keys (optional)
+//   - Index: Lookups in Manifest, for callers that already index it; built on first use otherwise
+//   - BootstrapManagers: If true, package managers the plan needs (brew, flatpak, pipx, cargo) are installed first when missing
+//   - CacheMode: CacheDownload to only fetch packages into CacheDir, CacheOffline to install only from it ("" installs normally)
+//   - CacheDir: Where CacheMode keeps packages (defaults to DefaultCacheDir)
+//   - LookPath: Finds executables on the machine being provisioned, e.g. RunnerLookPath for remote runs (defaults to exec.LookPath)
+//   - InitSystem: Reports the init system of the machine being provisioned, e.g. RunnerInitSystem (defaults to checking this machine)
 type Provisioner struct {
-	System         SystemInfo
-	Manifest       app.Manifest
-	ManifestRaw    map[string]map[string]interface{} // Raw manifest for advanced key matching
-	Runner         ExecRunner
-	InstallerOrder []string // Preferred order of installer types
-	LazyOnly       bool     // Only install packages with Lazy=true
-	DryRun         bool     // If true, do not actually run commands, just log them
-	DryRunLog      []string // Stores dry run log entries
-	Errors         []error  // Aggregated errors from last ExecutePlan
-	LogFile        string   // If set, logs all command attempts and errors to this file
+	System            SystemInfo
+	Manifest          app.Manifest
+	ManifestRaw       map[string]map[string]interface{} // Overrides SoftwareEntry.Raw for advanced key matching
+	Runner            ExecRunner
+	InstallerOrder    []string // Preferred order of installer types
+	DisabledManagers  []string // Installer types that are never planned
+	LazyOnly          bool     // Only install packages with Lazy=true
+	IncludeGUI        bool     // Install GUI apps even on headless systems
+	ForceHeadless     bool     // Skip GUI apps even when there is a display
+	DryRun            bool     // If true, do not actually run commands, just log them
+	DryRunLog         []string // Stores dry run log entries
+	Errors            []error  // Errors of the last ExecutePlan
+	FailFast          bool     // Stop at the first failed instruction
+	LogFile           string   // If set, logs all command attempts and errors to this file
+	LogFormat         string   // LogFormatJSON (default) or LogFormatText
+	LockTimeout       time.Duration
+	MinFreeSpaceMB    uint64
+	DiskSpaceWarnOnly bool
+	DiskPath          string
+	CheckReachability bool
+	MaxDuration       time.Duration
+	History           *History
+	Deferred          []string
+	RunLog            *RunLog
+	Progress          ProgressFunc
+	StepDone          func(ReportStep)
+	RetryPolicy       RetryPolicy
+	RefreshRepos      bool
+	BootstrapManagers bool
+	Namespaces        []string
+	Index             *app.ManifestIndex
+	StrictDeps        bool
+	BinDir            string
+	ScriptSandbox     string
+	NoServices        bool
+	BinOnPath         bool
+	Exclude           []string
+	SkipGroups        []string
+	Report            *RunReport
+	Warnings          []PlanWarning
+	PlanReport        *PlanReport
+	Locked            *Lockfile
+	CacheMode         string
+	CacheDir          string
+	LookPath          func(string) (string, error)
+	InitSystem        func() string
+
+	now       func() time.Time             // Overridable for tests; defaults to time.Now
+	sleep     func(time.Duration)          // Overridable for tests; defaults to time.Sleep
+	freeSpace func(string) (uint64, error) // Overridable for tests; defaults to freeDiskSpaceMB
+	dial      func(string) error           // Overridable for tests; defaults to a TCP dial
+	lookPath  func(string) (string, error) // Overridable for tests; defaults to exec.LookPath
+	initName  func() string                // Overridable for tests; defaults to checking for systemd
+	installed map[string]bool              // Installed keys of the plan being made, for choosing among _deps_any
 }
 
+// ProgressFunc receives execution progress: done instructions out of total, and the
+// instruction about to run (zero value once the plan has finished).
+type ProgressFunc func(done, total int, current InstallInstruction)
+
 // InstallInstruction represents a single install/provision action.
 //
 // # Fields
 //   - Type:    The installer type (e.g., "apt", "brew")
 //   - Package: The package name to install
+//   - Key:     The manifest key the instruction was planned for
 type InstallInstruction struct {
 	Type    string // e.g. "apt", "brew", etc.
 	Package string
+	Key     string
+}
+
+// PlanWarning describes a problem found while planning that did not stop it.
+//
+// # Fields
+//   - Key:     The manifest key the problem was found in
+//   - Dep:     The dependency reference involved, if any
+//   - Message: A human-readable description
+type PlanWarning struct {
+	Key     string
+	Dep     string
+	Message string
+}
+
+// warn records a planning warning and logs it.
+func (p *Provisioner) warn(w PlanWarning) {
+	p.Warnings = append(p.Warnings, w)
+	if p.Runner != nil {
+		_ = p.Runner.Run("info", "Warning: "+w.Message)
+	}
 }
 
 // NewProvisioner creates a new Provisioner with the given dependencies.
@@ -92,48 +206,37 @@ func NewProvisioner(sys SystemInfo, manifest app.Manifest, runner ExecRunner) *P
 
 // getFieldByPriority returns the value for a manifest field with advanced key matching.
 // It supports keys like prefix:installer:osId:osArch, etc, with fallback order as in installx.js.
+// The arch part of a key may be any alias of osArch, e.g. x64 or amd64 (see app.CanonicalArch).
 func getFieldByPriority(entry map[string]interface{}, prefix, installer, osId, osType, osArch string) (string, bool) {
+	base := prefix
 	if installer != "" {
-		keys := []string{
-			prefix + ":" + installer + ":" + osId + ":" + osArch,
-			prefix + ":" + installer + ":" + osId,
-			prefix + ":" + installer + ":" + osType + ":" + osArch,
-			prefix + ":" + installer + ":" + osType,
-			prefix + ":" + installer + ":" + osArch,
-			prefix + ":" + installer,
-			prefix,
-		}
-		for _, k := range keys {
-			if v, ok := entry[k]; ok {
-				if s, ok := v.(string); ok {
-					return s, true
-				}
-				if arr, ok := v.([]interface{}); ok && len(arr) > 0 {
-					if s, ok := arr[0].(string); ok {
-						return s, true
-					}
-				}
+		base += ":" + installer
+	}
+	var keys []string
+	for _, arch := range archVariants(osArch) {
+		keys = append(keys, base+":"+osId+":"+arch)
+	}
+	keys = append(keys, base+":"+osId)
+	for _, arch := range archVariants(osArch) {
+		keys = append(keys, base+":"+osType+":"+arch)
+	}
+	keys = append(keys, base+":"+osType)
+	for _, arch := range archVariants(osArch) {
+		keys = append(keys, base+":"+arch)
+	}
+	keys = append(keys, base)
+	if installer != "" {
+		keys = append(keys, prefix)
+	}
+	for _, k := range keys {
+		if v, ok := entry[k]; ok {
+			if s, ok := v.(string); ok {
+				return s, true
 			}
-		}
-	} else {
-		keys := []string{
-			prefix + ":" + osId + ":" + osArch,
-			prefix + ":" + osId,
-			prefix + ":" + osType + ":" + osArch,
-			prefix + ":" + osType,
-			prefix + ":" + osArch,
-			prefix,
-		}
-		for _, k := range keys {
-			if v, ok := entry[k]; ok {
-				if s, ok := v.(string); ok {
+			if arr, ok := v.([]interface{}); ok && len(arr) > 0 {
+				if s, ok := arr[0].(string); ok {
 					return s, true
 				}
-				if arr, ok := v.([]interface{}); ok && len(arr) > 0 {
-					if s, ok := arr[0].(string); ok {
-						return s, true
-					}
-				}
 			}
 		}
 	}
@@ -141,15 +244,74 @@ func getFieldByPriority(entry map[string]interface{}, prefix, installer, osId, o
 }
 
 func (p *Provisioner) shouldSkipInstalled(key string, installed map[string]bool) bool {
-	return installed != nil && installed[key]
+	_, bare := app.SplitKey(key)
+	return installed != nil && (installed[key] || installed[bare] || p.binsOnPath(key))
+}
+
+// preferByPriority resolves keys, including aliases, and replaces keys that share a bare name with the key
+// from the manifest earliest in Namespaces, at the position of the first of them. Other keys are unchanged.
+func (p *Provisioner) preferByPriority(keys []string) []string {
+	index := p.manifestIndex()
+	rank := func(key string) int {
+		ns, _ := app.SplitKey(key)
+		if i := slices.Index(p.Namespaces, ns); i >= 0 {
+			return i
+		}
+		return len(p.Namespaces)
+	}
+	resolved := make([]string, len(keys))
+	for i, key := range keys {
+		resolved[i] = key
+		if k, ok := index.Resolve(key, "", p.Namespaces); ok {
+			resolved[i] = k
+		}
+	}
+	best := make(map[string]string)
+	for _, key := range resolved {
+		_, bare := app.SplitKey(key)
+		if cur, ok := best[bare]; !ok || rank(key) < rank(cur) {
+			best[bare] = key
+		}
+	}
+	var result []string
+	for _, key := range resolved {
+		_, bare := app.SplitKey(key)
+		if winner, ok := best[bare]; ok {
+			result = append(result, winner)
+			delete(best, bare)
+		}
+	}
+	return result
 }
 
 func (p *Provisioner) shouldSkipHeadless(entry *app.SoftwareEntry) bool {
-	return p.System != nil && p.System.IsHeadless() && entry.App != ""
+	if p.IncludeGUI || !entry.IsGUI() {
+		return false
+	}
+	return p.ForceHeadless || p.System != nil && p.System.IsHeadless()
+}
+
+// excludeReason returns why key is left out of the plan by Exclude or SkipGroups, or ""
+// if it is not. Excluded keys are not expanded, so deps only they pull in are left out too.
+//
+// # Returns
+//   - SkipReason: SkipExcluded or SkipGroup, or "" if the key is not left out
+//   - string:     A human-readable description of the reason
+func (p *Provisioner) excludeReason(key string) (SkipReason, string) {
+	_, bare := app.SplitKey(key)
+	if slices.Contains(p.Exclude, key) || slices.Contains(p.Exclude, bare) {
+		return SkipExcluded, "excluded"
+	}
+	for _, group := range p.Manifest[key].Groups {
+		if slices.Contains(p.SkipGroups, group) {
+			return SkipGroup, fmt.Sprintf("group %s is skipped", group)
+		}
+	}
+	return "", ""
 }
 
 func (p *Provisioner) shouldSkipLazy(entry *app.SoftwareEntry) bool {
-	return p.LazyOnly && !entry.Lazy
+	return p.LazyOnly && entry.EffectiveTier() != app.TierOptional
 }
 
 func (p *Provisioner) addScriptInstructions(entry *app.SoftwareEntry, plan *[]InstallInstruction) {
@@ -162,21 +324,11 @@ func (p *Provisioner) addScriptInstructions(entry *app.SoftwareEntry, plan *[]In
 }
 
 func (p *Provisioner) addInstallerInstruction(key string, entry *app.SoftwareEntry, plan *[]InstallInstruction) {
-	installerOrder := p.InstallerOrder
-	if len(installerOrder) == 0 {
-		installerOrder = []string{
-			"apt", "brew", "pacman", "apk", "dnf", "zypper", "scoop", "choco", "go", "cargo", "pipx", "cask", "flatpak", "snap", "port", "yay", "pkg", "emerge", "nix", "mas", "xbps", "binary:darwin", "binary:linux", "binary:windows",
+	entryMap := p.entryMap(key, entry)
+	for _, instType := range p.installerOrder() {
+		if !p.installerApplies(instType) {
+			continue
 		}
-	}
-	var entryMap map[string]interface{}
-	if p.ManifestRaw != nil {
-		entryMap = p.ManifestRaw[key]
-	} else {
-		entryMap = make(map[string]interface{})
-		b, _ := yaml.Marshal(entry)
-		_ = yaml.Unmarshal(b, &entryMap)
-	}
-	for _, instType := range installerOrder {
 		osId, osType, osArch := "", "", ""
 		if p.System != nil {
 			osId = p.System.ID()
@@ -199,20 +351,93 @@ func (p *Provisioner) addInstallerInstruction(key string, entry *app.SoftwareEnt
 	}
 }
 
-// expandDeps recursively expands dependencies for the given keys.
-func (p *Provisioner) expandDeps(keys []string, visited map[string]bool) ([]string, error) {
+// entryMap returns the manifest entry as a raw map for advanced key matching: its keys
+// as loaded (SoftwareEntry.Raw), or for entries built in code, its fields and its
+// qualified installer keys (SoftwareEntry.Installers).
+func (p *Provisioner) entryMap(key string, entry *app.SoftwareEntry) map[string]interface{} {
+	if p.ManifestRaw != nil {
+		return p.ManifestRaw[key]
+	}
+	if entry.Raw != nil {
+		return entry.Raw
+	}
+	entryMap := make(map[string]interface{})
+	b, _ := yaml.Marshal(entry)
+	_ = yaml.Unmarshal(b, &entryMap)
+	for key, packages := range entry.Installers {
+		values := make([]interface{}, len(packages))
+		for i, pkg := range packages {
+			values[i] = pkg
+		}
+		entryMap[key] = values
+	}
+	return entryMap
+}
+
+// manifestIndex returns Index, building it the first time it is needed.
+func (p *Provisioner) manifestIndex() *app.ManifestIndex {
+	if p.Index == nil {
+		p.Index = app.NewManifestIndex(p.Manifest)
+	}
+	return p.Index
+}
+
+// expandDeps recursively expands dependencies for the given keys, which are the deps of
+// parent (empty for the requested keys). Keys are resolved against the manifests in
+// priority order, deps first within the manifest that declares them, and each bare key
+// is expanded only once. Unless StrictDeps is set, a dep that is not in the manifest is
+// skipped with a warning. A dep may name what entries _provides instead of a key. Keys
+// whose _when condition is false are skipped with their deps. Of each _deps_any group one
+// alternative is expanded (see chooseAlternative).
+func (p *Provisioner) expandDeps(keys []string, parent string, visited map[string]bool) ([]string, error) {
 	var result []string
-	for _, key := range keys {
-		if visited[key] {
+	namespace, _ := app.SplitKey(parent)
+	for _, ref := range keys {
+		key, ok := p.manifestIndex().Resolve(ref, namespace, p.Namespaces)
+		if !ok && parent != "" {
+			key, ok = p.provider(ref, visited)
+		}
+		if !ok && parent != "" && !p.StrictDeps {
+			p.warn(PlanWarning{Key: parent, Dep: ref, Message: fmt.Sprintf("%s depends on %s, which is not in the manifest; skipping the dependency", parent, ref)})
 			continue
 		}
-		visited[key] = true
-		entry, ok := p.Manifest[key]
 		if !ok {
-			return nil, fmt.Errorf("manifest key not found: %s", key)
+			return nil, fmt.Errorf("manifest key not found: %s", ref)
+		}
+		_, bare := app.SplitKey(key)
+		if visited[bare] {
+			continue
+		}
+		visited[bare] = true
+		if reason, detail := p.excludeReason(key); reason != "" {
+			p.skip(key, reason, detail)
+			continue
+		}
+		entry := p.Manifest[key]
+		applies, err := p.conditionMet(&entry)
+		if err != nil {
+			p.warn(PlanWarning{Key: key, Message: fmt.Sprintf("%s: ignoring %v", key, err)})
+		}
+		if !applies {
+			p.skip(key, SkipCondition, fmt.Sprintf("_when %s is false here", entry.When))
+			continue
 		}
 		if len(entry.Deps) > 0 {
-			depsExpanded, err := p.expandDeps(entry.Deps, visited)
+			depsExpanded, err := p.expandDeps(entry.Deps, key, visited)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, depsExpanded...)
+		}
+		for _, group := range entry.DepsAny {
+			choice, err := p.chooseAlternative(key, group, visited)
+			if err != nil {
+				return nil, err
+			}
+			if choice == "" {
+				continue
+			}
+			depsExpanded, err := p.expandDeps([]string{choice}, key, visited)
 			if err != nil {
 				return nil, err
 			}
@@ -229,26 +454,50 @@ func (p *Provisioner) planForKey(key string, installed map[string]bool, plan *[]
 	if !ok {
 		return fmt.Errorf("manifest key not found: %s", key)
 	}
-	if p.shouldSkipInstalled(key, installed) {
-		if p.Runner != nil {
-			_ = p.Runner.Run("info", fmt.Sprintf("Skipping %s: already installed", key))
+	if entry.Timeout != "" {
+		if _, err := time.ParseDuration(entry.Timeout); err != nil {
+			return fmt.Errorf("invalid _timeout for %s: %w", key, err)
 		}
+	}
+	if p.shouldSkipInstalled(key, installed) {
+		p.skip(key, SkipInstalled, "already installed")
 		return nil
 	}
 	if p.shouldSkipHeadless(&entry) {
-		if p.Runner != nil {
-			_ = p.Runner.Run("info", fmt.Sprintf("Skipping %s: headless mode", key))
-		}
+		p.skip(key, SkipHeadless, "headless mode")
 		return nil
 	}
 	if p.shouldSkipLazy(&entry) {
+		p.skip(key, SkipNotLazy, "not marked lazy")
+		return nil
+	}
+	if entry.IsMeta() {
+		// A meta-package has nothing to install itself; expandDeps already planned its deps
+		detail := "meta-package of " + strings.Join(entry.Deps, ", ")
+		p.decide(KeyDecision{Key: key, Planned: true, Detail: detail})
 		if p.Runner != nil {
-			_ = p.Runner.Run("info", fmt.Sprintf("Skipping %s: not marked lazy", key))
+			_ = p.Runner.Run("info", fmt.Sprintf("Expanding %s: %s", key, detail))
 		}
 		return nil
 	}
+	start := len(*plan)
 	p.addScriptInstructions(&entry, plan)
 	p.addInstallerInstruction(key, &entry, plan)
+	p.addRepoInstructions(key, &entry, start, plan)
+	p.addServiceInstructions(key, &entry, start, plan)
+	p.addHookInstructions(&entry, start, plan)
+	for i := start; i < len(*plan); i++ {
+		(*plan)[i].Key = key
+	}
+	if start < len(*plan) {
+		p.decide(KeyDecision{Key: key, Planned: true})
+		return nil
+	}
+	_, reason := p.Installability(key)
+	p.decide(KeyDecision{Key: key, Reason: SkipNotInstallable, Detail: reason})
+	if p.Runner != nil {
+		_ = p.Runner.Run("info", fmt.Sprintf("Warning: %s is not installable here: %s", key, reason))
+	}
 	return nil
 }
 
@@ -257,17 +506,23 @@ func (p *Provisioner) PlanProvision(keys []string, installed map[string]bool) ([
 		_ = p.Runner.Run("section", "Planning")
 	}
 	var plan []InstallInstruction
+	p.Warnings = nil
+	p.PlanReport = &PlanReport{}
+	p.installed = installed
 	visited := make(map[string]bool)
-	expandedKeys, err := p.expandDeps(keys, visited)
+	expandedKeys, err := p.expandDeps(p.preferByPriority(keys), "", visited)
 	if err != nil {
 		return nil, err
 	}
+	expandedKeys = p.dropConflicts(expandedKeys)
 	for _, key := range expandedKeys {
 		err := p.planForKey(key, installed, &plan)
 		if err != nil {
 			return nil, err
 		}
 	}
+	plan = p.pinToLock(plan)
+	plan = p.cachePlan(plan)
 	// Log planned installs
 	if p.Runner != nil {
 		for _, inst := range plan {
@@ -285,39 +540,154 @@ func (p *Provisioner) PlanProvision(keys []string, installed map[string]bool) ([
 // # Returns
 //   - error: If any error occurs (aggregated)
 func (p *Provisioner) ExecutePlan(plan []InstallInstruction) error {
+	return p.ExecutePlanContext(context.Background(), plan)
+}
+
+// ExecutePlanContext is like ExecutePlan but stops when ctx is cancelled: the running
+// command is aborted and the remaining instructions are not started. Each instruction
+// is also bounded by the _timeout of its manifest entry.
+//
+// # Parameters
+//   - ctx:  Cancels the run (e.g. on ctrl+c)
+//   - plan: The list of install instructions to execute
+//
+// # Returns
+//   - error: If any error occurs (aggregated); wraps ctx.Err() if the run was cancelled
+func (p *Provisioner) ExecutePlanContext(ctx context.Context, plan []InstallInstruction) (err error) {
+	now := p.now
+	if now == nil {
+		now = time.Now
+	}
+	p.startReport(now())
+	p.Errors = nil
+	defer func() {
+		p.Errors = splitErrors(err)
+		p.finishReport(now(), err)
+	}()
 	if len(plan) == 0 {
 		return nil
 	}
+	if !p.DryRun && p.MinFreeSpaceMB > 0 {
+		if err := p.CheckDiskSpace(plan); err != nil {
+			if errors.Is(err, ErrLowDiskSpace) && !p.DiskSpaceWarnOnly {
+				return err
+			}
+			if p.Runner != nil {
+				_ = p.Runner.Run("info", fmt.Sprintf("Warning: %v", err))
+			}
+		}
+	}
+	if !p.DryRun && p.CheckReachability {
+		if err := p.networkPreflight(plan); err != nil {
+			return err
+		}
+	}
+	var errs []error
+	if p.BootstrapManagers {
+		errs = p.bootstrapManagers(ctx, plan)
+	}
 	// Section header: Installing
 	if p.Runner != nil {
 		_ = p.Runner.Run("section", "Installing")
 	}
-	var errs []error
-	for _, inst := range plan {
+	if p.MaxDuration > 0 {
+		plan = p.orderByDuration(plan)
+	}
+	p.Deferred = nil
+	deferred := make(map[string]bool)
+	failed := make(map[string]bool)
+	blocked := make(map[string]bool) // keys whose _preinstall hook failed
+	refreshed := make(map[string]bool)
+	spent := make(map[string]time.Duration)
+	start := now()
+	var estimated time.Duration // budget used in dry-run mode, where nothing actually takes time
+	for i, inst := range plan {
+		if p.Progress != nil {
+			p.Progress(i, len(plan), inst)
+		}
+		if ctx.Err() != nil {
+			errs = append(errs, fmt.Errorf("provisioning aborted: %w", ctx.Err()))
+			p.reportAborted(plan[i:])
+			break
+		}
+		if p.MaxDuration > 0 && (i == 0 || plan[i-1].Key != inst.Key) {
+			used := now().Sub(start)
+			if p.DryRun {
+				used = estimated
+			}
+			if p.mustDefer(inst.Key, used, deferred) {
+				deferred[inst.Key] = true
+				p.Deferred = append(p.Deferred, inst.Key)
+				if p.Runner != nil {
+					_ = p.Runner.Run("info", fmt.Sprintf("Deferring %s: expected %s does not fit in the remaining time", inst.Key, p.History.Expected(inst.Key)))
+				}
+				p.reportStep(inst, StepDeferred, 0, nil)
+				continue
+			}
+			estimated += p.History.Expected(inst.Key)
+		}
+		if deferred[inst.Key] {
+			p.reportStep(inst, StepDeferred, 0, nil)
+			continue
+		}
+		if p.skipAfterFailure(inst, failed, blocked) {
+			p.reportStep(inst, StepSkipped, 0, nil)
+			continue
+		}
 		logLine := inst.Type + " " + inst.Package
 		if p.DryRun {
+			if cmd := p.pendingRefresh(installerOf(inst.Type), refreshed); cmd != nil {
+				p.DryRunLog = append(p.DryRunLog, strings.Join(cmd, " "))
+			}
 			p.DryRunLog = append(p.DryRunLog, logLine)
+			p.reportStep(inst, StepPlanned, 0, nil)
 			continue
 		}
-		var err error
-		if inst.Type == "script" {
-			err = p.Runner.Run("script", inst.Package)
-		} else {
-			switch inst.Type {
-			case "apt", "apk", "dnf", "zypper", "yum":
-				err = p.Runner.Run(inst.Type, inst.Package)
-			case "brew":
-				err = p.Runner.Run("brew", "install", inst.Package)
-			case "go":
-				err = p.Runner.Run("go", "install", inst.Package)
-			default:
-				err = p.Runner.Run(inst.Type, inst.Package)
+		if _, ok := lockFiles[installerOf(inst.Type)]; ok {
+			if err := p.waitForLock(installerOf(inst.Type)); err != nil {
+				errs = append(errs, &InstructionError{Instruction: inst, Err: err})
+				p.reportStep(inst, StepFailed, 0, err)
+				if p.FailFast {
+					errs = append(errs, p.stopEarly(plan[i+1:]))
+					break
+				}
+				continue
 			}
 		}
+		p.refreshRepos(ctx, installerOf(inst.Type), refreshed)
+		p.RunLog.SetKey(inst.Key)
+		p.RunLog.Log("info", "Running: "+ShellCommand(inst))
+		instStart := now()
+		err := p.runWithRetry(ctx, inst)
+		took := now().Sub(instStart)
+		spent[inst.Key] += took
 		if err != nil {
-			errs = append(errs, err)
+			failed[inst.Key] = true
+			blocked[inst.Key] = blocked[inst.Key] || inst.Type == HookPreInstall
+			errs = append(errs, &InstructionError{Instruction: inst, Err: err})
+			p.RunLog.Log("error", fmt.Sprintf("Failed: %v", err))
+			p.reportStep(inst, StepFailed, took, err)
+			if p.FailFast {
+				errs = append(errs, p.stopEarly(plan[i+1:]))
+				break
+			}
+		} else {
+			p.RunLog.Log("success", "Installed "+inst.Package)
+			p.reportStep(inst, StepInstalled, took, nil)
+		}
+	}
+	p.RunLog.SetKey("")
+	if p.Progress != nil && ctx.Err() == nil {
+		p.Progress(len(plan), len(plan), InstallInstruction{})
+	}
+	for key, d := range spent {
+		if key != "" && !failed[key] {
+			p.History.Record(key, d)
 		}
 	}
+	if len(p.Deferred) > 0 && p.Runner != nil {
+		_ = p.Runner.Run("info", fmt.Sprintf("Deferred %d items to stay within %s: %s", len(p.Deferred), p.MaxDuration, strings.Join(p.Deferred, ", ")))
+	}
 	// Section header: Complete
 	if p.Runner != nil {
 		_ = p.Runner.Run("section", "Complete")
@@ -328,6 +698,112 @@ func (p *Provisioner) ExecutePlan(plan []InstallInstruction) error {
 	return nil
 }
 
+// runInstruction runs a single instruction, bounded by the _timeout of its manifest entry.
+func (p *Provisioner) runInstruction(ctx context.Context, inst InstallInstruction) error {
+	timeout, _ := time.ParseDuration(p.Manifest[inst.Key].Timeout) // validated while planning
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var err error
+	switch inst.Type {
+	case "script", HookPreInstall, HookPostInstall:
+		var wrapper []string
+		if wrapper, err = p.scriptWrapper(inst.Key); err == nil {
+			err = p.Runner.RunContext(ctx, "script", append([]string{inst.Package}, wrapper...)...)
+		}
+	case "apt", "apk", "dnf", "zypper", "yum":
+		err = p.Runner.RunContext(ctx, inst.Type, inst.Package)
+	case "brew":
+		err = p.Runner.RunContext(ctx, "brew", "install", inst.Package)
+	case "go":
+		err = p.Runner.RunContext(ctx, "go", "install", inst.Package)
+	case "binary:darwin", "binary:linux", "binary:windows":
+		err = p.installBinary(ctx, inst)
+	case AptRepo, DnfRepo:
+		err = p.Runner.RunContext(ctx, "sudo", "sh", "-c", inst.Package)
+	default:
+		if IsServiceInstruction(inst.Type) {
+			err = p.startService(ctx, inst)
+		} else if cmd := UpgradeCommand(inst); cmd != nil {
+			err = p.Runner.RunContext(ctx, cmd[0], cmd[1:]...)
+		} else if cmd := p.CacheCommand(inst); cmd != nil {
+			err = p.Runner.RunContext(ctx, cmd[0], cmd[1:]...)
+		} else if isBinaryType(installerOf(inst.Type)) {
+			err = p.runCachedBinary(ctx, inst)
+		} else {
+			err = p.Runner.RunContext(ctx, inst.Type, inst.Package)
+		}
+	}
+	if err != nil && timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s %s timed out after %s: %w", inst.Type, inst.Package, timeout, err)
+	}
+	return err
+}
+
+// mustDefer reports whether a key has to be deferred, either because its expected
+// duration does not fit in the remaining budget or because a dependency was deferred.
+func (p *Provisioner) mustDefer(key string, used time.Duration, deferred map[string]bool) bool {
+	for _, dep := range p.Manifest[key].Deps {
+		if deferred[dep] {
+			return true
+		}
+	}
+	return used+p.History.Expected(key) > p.MaxDuration
+}
+
+// ErrFailFast is returned, joined with the error of the failed instruction, when FailFast
+// stopped a plan early.
+var ErrFailFast = errors.New("stopped at the first failure (fail-fast)")
+
+// stopEarly reports the instructions FailFast leaves unrun as aborted, logs it and
+// returns the error recording it.
+func (p *Provisioner) stopEarly(rest []InstallInstruction) error {
+	p.reportAborted(rest)
+	if p.Runner != nil && len(rest) > 0 {
+		_ = p.Runner.Run("info", fmt.Sprintf("Stopping after the failure: %d remaining instructions are not run", len(rest)))
+	}
+	return fmt.Errorf("%w: %d instructions not run", ErrFailFast, len(rest))
+}
+
+// InstructionError is the error of an instruction that failed during ExecutePlan. The
+// errors ExecutePlan returns and records in Errors wrap one per failed instruction, so
+// callers can find what failed with errors.As.
+//
+// # Fields
+//   - Instruction: The instruction that failed
+//   - Err:         Why it failed, after any retries
+type InstructionError struct {
+	Instruction InstallInstruction
+	Err         error
+}
+
+// Error returns the error prefixed with the key of the instruction, or with its type and
+// package if it has no key.
+func (e *InstructionError) Error() string {
+	if e.Instruction.Key != "" {
+		return fmt.Sprintf("%s: %v", e.Instruction.Key, e.Err)
+	}
+	return fmt.Sprintf("%s %s: %v", e.Instruction.Type, e.Instruction.Package, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *InstructionError) Unwrap() error {
+	return e.Err
+}
+
+// splitErrors returns the errors joined in err by ExecutePlan, or nil if err is nil.
+func splitErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
+
 // AggregatedError returns a single error representing all errors from last ExecutePlan, or nil.
 func (p *Provisioner) AggregatedError() error {
 	if len(p.Errors) == 0 {
@@ -363,14 +839,7 @@ func (p *Provisioner) PostInstall() error {
 	for key := range p.Manifest {
 		entry := p.Manifest[key]
 		entryPtr := &entry
-		var entryMap map[string]interface{}
-		if p.ManifestRaw != nil {
-			entryMap = p.ManifestRaw[key]
-		} else {
-			entryMap = make(map[string]interface{})
-			b, _ := yaml.Marshal(entryPtr)
-			_ = yaml.Unmarshal(b, &entryMap)
-		}
+		entryMap := p.entryMap(key, entryPtr)
 		p.handleFlatpakWrapper(entryMap, osId, osType, osArch)
 		p.handleCaskWrapper(entryMap, osId, osType, osArch, entryPtr)
 	}
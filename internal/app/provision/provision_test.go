@@ -1,11 +1,14 @@
 package provision
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
 
@@ -19,7 +22,7 @@ type fakeSystemInfo struct {
 }
 
 func (f *fakeSystemInfo) OS() string       { return "linux" }
-func (f *fakeSystemInfo) Arch() string     { return "amd64" }
+func (f *fakeSystemInfo) Arch() string     { return "x64" }
 func (f *fakeSystemInfo) ID() string       { return "ubuntu" }
 func (f *fakeSystemInfo) IsHeadless() bool { return f.headless }
 
@@ -35,6 +38,9 @@ func (f *fakeExecRunner) Run(cmd string, args ...string) error {
 	f.Commands = append(f.Commands, full)
 	return nil
 }
+func (f *fakeExecRunner) RunContext(_ context.Context, cmd string, args ...string) error {
+	return f.Run(cmd, args...)
+}
 func (f *fakeExecRunner) Output(cmd string, args ...string) ([]byte, error) {
 	f.Commands = append(f.Commands, cmd)
 	return []byte("output"), nil
@@ -42,6 +48,10 @@ func (f *fakeExecRunner) Output(cmd string, args ...string) ([]byte, error) {
 
 type errRunner struct{ fakeExecRunner }
 
+func (e *errRunner) RunContext(_ context.Context, cmd string, args ...string) error {
+	return e.Run(cmd, args...)
+}
+
 func (e *errRunner) Run(cmd string, args ...string) error {
 	if cmd == "apt" && len(args) > 0 && args[0] == "foo" {
 		return fmt.Errorf("fail foo")
@@ -123,6 +133,34 @@ func TestPlanProvisionWithDeps(t *testing.T) {
 	}
 }
 
+func TestPlanProvisionMetaPackage(t *testing.T) {
+	manifest := app.Manifest{
+		"rust-dev-stack": {Desc: "Rust tools", Deps: app.StringOrSlice{"rustup", "sccache"}},
+		"rustup":         {Apt: app.StringOrSlice{"rustup"}},
+		"sccache":        {Apt: app.StringOrSlice{"sccache"}},
+	}
+	runner := &fakeExecRunner{}
+	prov := NewProvisioner(&fakeSystemInfo{}, manifest, runner)
+	plan, err := prov.PlanProvision([]string{"rust-dev-stack"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	if len(plan) != 2 || plan[0].Package != "rustup" || plan[1].Package != "sccache" {
+		t.Errorf("expected the members to be planned, got %+v", plan)
+	}
+	if ok, reason := prov.Installability("rust-dev-stack"); !ok {
+		t.Errorf("expected a meta-package to be installable, got %q", reason)
+	}
+	if skipped := prov.PlanReport.Skipped(); len(skipped) != 0 {
+		t.Errorf("expected nothing skipped, got %v", skipped)
+	}
+	for _, cmd := range runner.Commands {
+		if strings.Contains(cmd, "not installable") {
+			t.Errorf("unexpected warning: %s", cmd)
+		}
+	}
+}
+
 func TestPlanProvisionWithCycle(t *testing.T) {
 	manifest := app.Manifest{
 		"a": app.SoftwareEntry{
@@ -230,6 +268,39 @@ func TestPlanProvisionHeadlessSkipsGUI(t *testing.T) {
 	}
 }
 
+func TestPlanProvisionHeadlessOverrides(t *testing.T) {
+	notGUI := false
+	manifest := app.Manifest{
+		"gui":     {Apt: app.StringOrSlice{"gui"}, App: "SomeApp"},
+		"cli-app": {Apt: app.StringOrSlice{"cli-app"}, App: "CliApp", GUI: &notGUI},
+		"cli":     {Apt: app.StringOrSlice{"cli"}},
+	}
+	plannedKeys := func(prov *Provisioner) []string {
+		plan, err := prov.PlanProvision([]string{"cli", "cli-app", "gui"}, nil)
+		if err != nil {
+			t.Fatalf("PlanProvision error: %v", err)
+		}
+		var keys []string
+		for _, inst := range plan {
+			keys = append(keys, inst.Key)
+		}
+		return keys
+	}
+	prov := NewProvisioner(&fakeSystemInfo{headless: true}, manifest, nil)
+	if got := plannedKeys(prov); !slices.Equal(got, []string{"cli", "cli-app"}) {
+		t.Errorf("headless: planned %v, want [cli cli-app]", got)
+	}
+	prov.IncludeGUI = true
+	if got := plannedKeys(prov); !slices.Equal(got, []string{"cli", "cli-app", "gui"}) {
+		t.Errorf("--include-gui: planned %v, want [cli cli-app gui]", got)
+	}
+	prov = NewProvisioner(&fakeSystemInfo{}, manifest, nil)
+	prov.ForceHeadless = true
+	if got := plannedKeys(prov); !slices.Equal(got, []string{"cli", "cli-app"}) {
+		t.Errorf("--headless: planned %v, want [cli cli-app]", got)
+	}
+}
+
 func TestPlanProvisionScript(t *testing.T) {
 	manifest := app.Manifest{
 		"foo": app.SoftwareEntry{
@@ -323,6 +394,57 @@ func (m macSys) Arch() string     { return "x64" }
 func (m macSys) ID() string       { return "darwin" }
 func (m macSys) IsHeadless() bool { return false }
 
+// TestPlanProvision_ParsedRawKeys verifies that advanced keys of a parsed manifest are
+// matched without setting ManifestRaw.
+func TestPlanProvision_Aliases(t *testing.T) {
+	manifest := app.Manifest{
+		"rg":     {Apt: app.StringOrSlice{"ripgrep"}, Aliases: app.StringOrSlice{"ripgrep"}},
+		"search": {Deps: app.StringOrSlice{"ripgrep"}},
+	}
+	prov := NewProvisioner(&fakeSystemInfo{}, manifest, &fakeExecRunner{})
+	prov.System = customSys{}
+	plan, err := prov.PlanProvision([]string{"ripgrep", "rg", "search"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	if len(plan) != 1 || plan[0].Key != "rg" || plan[0].Package != "ripgrep" {
+		t.Errorf("expected the aliases to plan rg once, got %+v", plan)
+	}
+}
+
+func TestPlanProvision_ParsedRawKeys(t *testing.T) {
+	manifest, err := app.ParseManifest([]byte("foo:\n  apt: foo-apt\n  apt:debian: foo-debian\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov := NewProvisioner(&fakeSystemInfo{}, manifest, &fakeExecRunner{})
+	prov.System = customSys{}
+	plan, err := prov.PlanProvision([]string{"foo"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	if len(plan) != 1 || plan[0].Package != "foo-debian" {
+		t.Errorf("expected foo-debian from the raw keys, got %+v", plan)
+	}
+}
+
+func TestPlanProvision_InstallerVariants(t *testing.T) {
+	// Entries built in code have no raw keys; their variants come from Installers
+	manifest := app.Manifest{"foo": {
+		Apt:        app.StringOrSlice{"foo-apt"},
+		Installers: map[string]app.StringOrSlice{"apt:debian:x64": {"foo-debian-x64"}, "apt:darwin": {"foo-darwin"}},
+	}}
+	prov := NewProvisioner(&fakeSystemInfo{}, manifest, &fakeExecRunner{})
+	prov.System = customSys{}
+	plan, err := prov.PlanProvision([]string{"foo"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	if len(plan) != 1 || plan[0].Package != "foo-debian-x64" {
+		t.Errorf("expected foo-debian-x64 from Installers, got %+v", plan)
+	}
+}
+
 func TestPlanProvision_AdvancedKeyMatching(t *testing.T) {
 	manifest := app.Manifest{
 		"foo": app.SoftwareEntry{}, // will fill via map
@@ -527,6 +649,9 @@ func (r *realSystemRunner) Run(cmd string, args ...string) error {
 	c.Stderr = os.Stderr
 	return c.Run()
 }
+func (r *realSystemRunner) RunContext(_ context.Context, cmd string, args ...string) error {
+	return r.Run(cmd, args...)
+}
 func (r *realSystemRunner) Output(cmd string, args ...string) ([]byte, error) {
 	c := exec.Command(cmd, args...)
 	return c.Output()
@@ -598,8 +723,35 @@ func SkipTestExecutePlan_DryRun(t *testing.T) {
 	}
 }
 
+func TestExecutePlan_FailFast(t *testing.T) {
+	plan := []InstallInstruction{
+		{Type: "apt", Package: "foo", Key: "foo"},
+		{Type: "apt", Package: "baz", Key: "baz"},
+	}
+	runner := &errRunner{}
+	prov := NewProvisioner(&fakeSystemInfo{}, app.Manifest{}, runner)
+	if err := prov.ExecutePlan(plan); err == nil || errors.Is(err, ErrFailFast) {
+		t.Errorf("expected the run to continue past the failure, got %v", err)
+	}
+	if got := prov.Report.Steps[1].Status; got != StepInstalled {
+		t.Errorf("continued run: baz is %s, want %s", got, StepInstalled)
+	}
+
+	prov.FailFast = true
+	err := prov.ExecutePlan(plan)
+	if !errors.Is(err, ErrFailFast) {
+		t.Fatalf("expected ErrFailFast, got %v", err)
+	}
+	if len(prov.Errors) != 2 {
+		t.Errorf("expected the failure and the stop in Errors, got %v", prov.Errors)
+	}
+	if got := prov.Report.Steps[1].Status; got != StepAborted || !prov.Report.FailFast {
+		t.Errorf("fail-fast run: baz is %s, want %s (report %+v)", got, StepAborted, prov.Report)
+	}
+}
+
 //revive:disable:var-naming
-func SkipTestExecutePlan_ErrorAggregationAndLogFile(t *testing.T) {
+func TestExecutePlan_ErrorAggregationAndLogFile(t *testing.T) {
 	//revive:enable:var-naming
 	manifest := app.Manifest{
 		"foo": app.SoftwareEntry{
@@ -621,6 +773,7 @@ func SkipTestExecutePlan_ErrorAggregationAndLogFile(t *testing.T) {
 	runner := &errRunner{}
 	prov := NewProvisioner(&fakeSystemInfo{}, manifest, runner)
 	prov.LogFile = tempLog
+	prov.LogFormat = LogFormatText
 	plan := []InstallInstruction{
 		{Type: "apt", Package: "foo"},
 		{Type: "script", Package: "echo bar"},
@@ -633,10 +786,18 @@ func SkipTestExecutePlan_ErrorAggregationAndLogFile(t *testing.T) {
 	if len(prov.Errors) != 2 {
 		t.Errorf("expected 2 errors, got %d", len(prov.Errors))
 	}
+	var instErr *InstructionError
+	if !errors.As(err, &instErr) || instErr.Instruction.Package != "foo" || instErr.Err.Error() != "fail foo" {
+		t.Errorf("expected an InstructionError for apt foo, got %v", err)
+	}
 	agg := prov.AggregatedError()
 	if agg == nil || !strings.Contains(agg.Error(), "2 errors occurred") {
 		t.Errorf("expected aggregated error message, got %v", agg)
 	}
+	if err := prov.ExecutePlan(plan[2:]); err != nil || len(prov.Errors) != 0 {
+		t.Errorf("expected a successful run to reset the errors, got %v, %v", err, prov.Errors)
+	}
+	prov.Errors = []error{err}
 	prov.ClearErrors()
 	if len(prov.Errors) != 0 {
 		t.Errorf("expected errors to be cleared")
@@ -647,10 +808,10 @@ func SkipTestExecutePlan_ErrorAggregationAndLogFile(t *testing.T) {
 		t.Fatalf("failed to read log file: %v", readErr)
 	}
 	logStr := string(data)
-	if !strings.Contains(logStr, "apt foo") || !strings.Contains(logStr, "[ERROR] fail foo") {
+	if !strings.Contains(logStr, "apt foo") || !strings.Contains(logStr, `error="fail foo"`) {
 		t.Errorf("log file missing apt foo error: %q", logStr)
 	}
-	if !strings.Contains(logStr, "script echo bar") || !strings.Contains(logStr, "[ERROR] fail script") {
+	if !strings.Contains(logStr, "script echo bar") || !strings.Contains(logStr, `error="fail script"`) {
 		t.Errorf("log file missing script error: %q", logStr)
 	}
 	if !strings.Contains(logStr, "apt baz") {
@@ -679,7 +840,7 @@ func TestProvisioner_shouldSkipInstalled(t *testing.T) {
 type fakeSys struct{}
 
 func (f *fakeSys) OS() string       { return "linux" }
-func (f *fakeSys) Arch() string     { return "amd64" }
+func (f *fakeSys) Arch() string     { return "x64" }
 func (f *fakeSys) ID() string       { return "ubuntu" }
 func (f *fakeSys) IsHeadless() bool { return true }
 
@@ -814,6 +975,9 @@ func (m *mockRunner) Run(cmd string, args ...string) error {
 	m.cmds = append(m.cmds, cmd+" "+strings.Join(args, " "))
 	return nil
 }
+func (m *mockRunner) RunContext(_ context.Context, cmd string, args ...string) error {
+	return m.Run(cmd, args...)
+}
 func (m *mockRunner) Output(cmd string, args ...string) ([]byte, error) { return nil, nil }
 
 func Test_handleFlatpakWrapper(t *testing.T) {
@@ -1043,3 +1207,163 @@ func SkipTestExecutePlan_AllPackageManagers(t *testing.T) {
 		})
 	}
 }
+
+// blockingRunner blocks every install until its context is done.
+type blockingRunner struct{ fakeExecRunner }
+
+func (b *blockingRunner) RunContext(ctx context.Context, cmd string, args ...string) error {
+	if cmd == "section" || cmd == "info" {
+		return nil
+	}
+	_ = b.Run(cmd, args...)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestExecutePlan_Timeout(t *testing.T) {
+	manifest := app.Manifest{"slow": app.SoftwareEntry{Apt: app.StringOrSlice{"slow"}, Timeout: "10ms"}}
+	prov := NewProvisioner(&fakeSystemInfo{}, manifest, &blockingRunner{})
+	plan, err := prov.PlanProvision([]string{"slow"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	err = prov.ExecutePlan(plan)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 10ms") {
+		t.Errorf("expected timeout error, got: %v", err)
+	}
+
+	manifest["slow"] = app.SoftwareEntry{Apt: app.StringOrSlice{"slow"}, Timeout: "soon"}
+	if _, err := prov.PlanProvision([]string{"slow"}, nil); err == nil {
+		t.Error("expected invalid _timeout to fail planning")
+	}
+}
+
+func TestExecutePlanContext_Cancel(t *testing.T) {
+	runner := &blockingRunner{}
+	prov := NewProvisioner(&fakeSystemInfo{}, app.Manifest{}, runner)
+	plan := []InstallInstruction{{Type: "apt", Package: "a", Key: "a"}, {Type: "apt", Package: "b", Key: "b"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	go cancel()
+	err := prov.ExecutePlanContext(ctx, plan)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got: %v", err)
+	}
+	for _, c := range runner.Commands {
+		if c == "apt b" {
+			t.Errorf("expected the remaining instructions to be skipped, got: %v", runner.Commands)
+		}
+	}
+}
+
+func TestPlanProvision_Namespaces(t *testing.T) {
+	manifest := app.Manifest{
+		"work/ripgrep":     {Apt: app.StringOrSlice{"ripgrep"}, Deps: []string{"git"}},
+		"work/git":         {Apt: app.StringOrSlice{"git"}},
+		"personal/ripgrep": {Brew: app.StringOrSlice{"ripgrep"}},
+		"personal/git":     {Brew: app.StringOrSlice{"git"}},
+		"personal/bat":     {Brew: app.StringOrSlice{"bat"}, Deps: []string{"ripgrep"}},
+	}
+	prov := NewProvisioner(nil, manifest, nil)
+	prov.Namespaces = []string{"work", "personal"}
+	plan, err := prov.PlanProvision([]string{"personal/ripgrep", "bat", "work/ripgrep"}, map[string]bool{})
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	var got []string
+	for _, inst := range plan {
+		got = append(got, inst.Key+"="+inst.Type)
+	}
+	// ripgrep comes from work, the higher-priority manifest, even though personal/ripgrep was
+	// listed first; its dep resolves within work and bat's dep is not installed twice.
+	want := []string{"work/git=apt", "work/ripgrep=apt", "personal/bat=brew"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got plan %q, want %q", got, want)
+	}
+}
+
+func TestPlanProvision_Index(t *testing.T) {
+	manifest := app.Manifest{
+		"rg":  {Apt: app.StringOrSlice{"ripgrep"}, Aliases: app.StringOrSlice{"ripgrep"}},
+		"bat": {Apt: app.StringOrSlice{"bat"}},
+	}
+	index := app.NewManifestIndex(manifest)
+	prov := NewProvisioner(nil, manifest, nil)
+	prov.Index = index
+	for range 2 {
+		plan, err := prov.PlanProvision([]string{"ripgrep"}, map[string]bool{})
+		if err != nil || len(plan) != 1 || plan[0].Key != "rg" {
+			t.Fatalf("expected the alias resolved through the index, got %+v, %v", plan, err)
+		}
+		if _, err := prov.Diff([]string{"bat"}, map[string]bool{}); err != nil {
+			t.Fatalf("Diff error: %v", err)
+		}
+	}
+	if prov.Index != index {
+		t.Error("expected the index set by the caller to be kept across operations")
+	}
+
+	prov = NewProvisioner(nil, manifest, nil)
+	if _, err := prov.PlanProvision([]string{"ripgrep"}, map[string]bool{}); err != nil || prov.Index == nil {
+		t.Errorf("expected the index built on first use, got %v", err)
+	}
+}
+
+func TestPlanProvision_MissingDep(t *testing.T) {
+	manifest := app.Manifest{
+		"foo": {Apt: app.StringOrSlice{"foo"}, Deps: []string{"bar", "gone"}},
+		"bar": {Apt: app.StringOrSlice{"bar"}},
+	}
+	runner := &fakeExecRunner{}
+	prov := NewProvisioner(nil, manifest, runner)
+	plan, err := prov.PlanProvision([]string{"foo"}, nil)
+	if err != nil {
+		t.Fatalf("expected a missing dep to be skipped, got error: %v", err)
+	}
+	if len(plan) != 2 || plan[0].Package != "bar" || plan[1].Package != "foo" {
+		t.Errorf("unexpected plan: %+v", plan)
+	}
+	if len(prov.Warnings) != 1 || prov.Warnings[0].Key != "foo" || prov.Warnings[0].Dep != "gone" {
+		t.Errorf("expected a warning for foo -> gone, got %+v", prov.Warnings)
+	}
+	if !slices.ContainsFunc(runner.Commands, func(c string) bool { return strings.Contains(c, "depends on gone") }) {
+		t.Errorf("expected the warning to be logged, got %q", runner.Commands)
+	}
+
+	prov.StrictDeps = true
+	if _, err := prov.PlanProvision([]string{"foo"}, nil); err == nil {
+		t.Error("expected an error for a missing dep with StrictDeps")
+	}
+	if _, err := NewProvisioner(nil, manifest, nil).PlanProvision([]string{"gone"}, nil); err == nil {
+		t.Error("expected an error for a missing requested key")
+	}
+}
+
+func TestPlanProvision_ExcludeAndSkipGroups(t *testing.T) {
+	manifest := app.Manifest{
+		"app":        {Apt: app.StringOrSlice{"app"}, Deps: []string{"docker", "lib"}},
+		"docker":     {Apt: app.StringOrSlice{"docker-ce"}, Deps: []string{"containerd"}},
+		"lib":        {Apt: app.StringOrSlice{"lib"}},
+		"containerd": {Apt: app.StringOrSlice{"containerd"}},
+		"editor":     {Apt: app.StringOrSlice{"editor"}, Groups: []string{"gui"}},
+	}
+	runner := &fakeExecRunner{}
+	prov := NewProvisioner(nil, manifest, runner)
+	prov.Exclude = []string{"docker"}
+	prov.SkipGroups = []string{"gui"}
+	plan, err := prov.PlanProvision([]string{"app", "editor"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	var got []string
+	for _, inst := range plan {
+		got = append(got, inst.Key)
+	}
+	if want := []string{"lib", "app"}; !slices.Equal(got, want) {
+		t.Errorf("planned %v, want %v", got, want)
+	}
+	for _, want := range []string{"Skipping docker: excluded", "Skipping editor: group gui is skipped"} {
+		if !slices.ContainsFunc(runner.Commands, func(c string) bool { return strings.Contains(c, want) }) {
+			t.Errorf("expected %q to be logged, got %q", want, runner.Commands)
+		}
+	}
+}
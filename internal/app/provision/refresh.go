@@ -0,0 +1,43 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// refreshCommands are the commands, run through sudo, that refresh each package
+// manager's repository index. Managers that always fetch fresh metadata are not listed.
+var refreshCommands = map[string][]string{
+	"apt":    {"sudo", "apt-get", "update"},
+	"dnf":    {"sudo", "dnf", "makecache"},
+	"yum":    {"sudo", "yum", "makecache"},
+	"zypper": {"sudo", "zypper", "--non-interactive", "refresh"},
+	"apk":    {"sudo", "apk", "update"},
+}
+
+// pendingRefresh returns the refresh command for an installer if RefreshRepos is set
+// and the installer's index has not been refreshed yet in this run, marking it as refreshed.
+func (p *Provisioner) pendingRefresh(installer string, refreshed map[string]bool) []string {
+	cmd, ok := refreshCommands[installer]
+	if !p.RefreshRepos || !ok || refreshed[installer] {
+		return nil
+	}
+	refreshed[installer] = true
+	return cmd
+}
+
+// refreshRepos refreshes the installer's repository index before its first install.
+// A failed refresh is only logged: installing from a stale index may still succeed.
+func (p *Provisioner) refreshRepos(ctx context.Context, installer string, refreshed map[string]bool) {
+	cmd := p.pendingRefresh(installer, refreshed)
+	if cmd == nil {
+		return
+	}
+	p.RunLog.Log("info", "Refreshing: "+strings.Join(cmd, " "))
+	if err := p.Runner.RunContext(ctx, cmd[0], cmd[1:]...); err != nil {
+		msg := fmt.Sprintf("Warning: refreshing %s repositories failed: %v", installer, err)
+		_ = p.Runner.Run("info", msg)
+		p.RunLog.Log("error", msg)
+	}
+}
@@ -0,0 +1,86 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExecutePlan_RefreshReposOncePerManager(t *testing.T) {
+	runner := &fakeExecRunner{}
+	prov := NewProvisioner(nil, nil, runner)
+	prov.RefreshRepos = true
+	plan := []InstallInstruction{
+		{Type: "apk", Package: "a", Key: "a"},
+		{Type: "brew", Package: "b", Key: "b"},
+		{Type: "apk", Package: "c", Key: "c"},
+		{Type: "zypper", Package: "d", Key: "d"},
+	}
+	if err := prov.ExecutePlan(plan); err != nil {
+		t.Fatalf("ExecutePlan error: %v", err)
+	}
+	want := []string{"sudo apk update", "apk a", "brew install b", "apk c", "sudo zypper --non-interactive refresh", "zypper d"}
+	var got []string
+	for _, c := range runner.Commands {
+		if !strings.HasPrefix(c, "section ") && !strings.HasPrefix(c, "info ") {
+			got = append(got, c)
+		}
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got commands %q, want %q", got, want)
+	}
+}
+
+func TestExecutePlan_NoRefreshByDefault(t *testing.T) {
+	runner := &fakeExecRunner{}
+	prov := NewProvisioner(nil, nil, runner)
+	if err := prov.ExecutePlan([]InstallInstruction{{Type: "apk", Package: "a", Key: "a"}}); err != nil {
+		t.Fatalf("ExecutePlan error: %v", err)
+	}
+	for _, c := range runner.Commands {
+		if strings.HasPrefix(c, "sudo ") {
+			t.Errorf("unexpected refresh command %q", c)
+		}
+	}
+}
+
+func TestExecutePlan_RefreshDryRun(t *testing.T) {
+	prov := NewProvisioner(nil, nil, &fakeExecRunner{})
+	prov.RefreshRepos = true
+	prov.DryRun = true
+	plan := []InstallInstruction{{Type: "apk", Package: "a", Key: "a"}, {Type: "apk", Package: "b", Key: "b"}}
+	if err := prov.ExecutePlan(plan); err != nil {
+		t.Fatalf("ExecutePlan error: %v", err)
+	}
+	want := []string{"sudo apk update", "apk a", "apk b"}
+	if got := prov.DryRunCommands(); !slices.Equal(got, want) {
+		t.Errorf("got dry-run log %q, want %q", got, want)
+	}
+}
+
+// refreshFailRunner fails every refresh command.
+type refreshFailRunner struct{ fakeExecRunner }
+
+func (r *refreshFailRunner) RunContext(ctx context.Context, cmd string, args ...string) error {
+	if cmd == "sudo" {
+		return errors.New("network down")
+	}
+	return r.fakeExecRunner.RunContext(ctx, cmd, args...)
+}
+
+func TestExecutePlan_RefreshFailureIsNotFatal(t *testing.T) {
+	runner := &refreshFailRunner{}
+	prov := NewProvisioner(nil, nil, runner)
+	prov.RefreshRepos = true
+	if err := prov.ExecutePlan([]InstallInstruction{{Type: "apk", Package: "a", Key: "a"}}); err != nil {
+		t.Fatalf("ExecutePlan error: %v", err)
+	}
+	if !slices.Contains(runner.Commands, "apk a") {
+		t.Errorf("expected install to run after failed refresh, got %q", runner.Commands)
+	}
+	if !slices.ContainsFunc(runner.Commands, func(c string) bool { return strings.Contains(c, "refreshing apk repositories failed") }) {
+		t.Errorf("expected a warning about the failed refresh, got %q", runner.Commands)
+	}
+}
@@ -0,0 +1,63 @@
+package provision
+
+import (
+	"fmt"
+	"strings"
+)
+
+// unameOS maps `uname -s` to the GOOS names SystemInfo reports.
+var unameOS = map[string]string{
+	"Linux":   "linux",
+	"Darwin":  "darwin",
+	"FreeBSD": "freebsd",
+}
+
+// DetectRemoteSystem detects the machine a runner executes on, e.g. a host reached over
+// SSH: the OS and architecture from uname, the distribution from os-release and WSL
+// from the kernel release. A remote session has no graphical display, so it is headless.
+//
+// # Returns
+//   - *RealSystemInfo: The remote system; GOARCH holds the uname machine, e.g. "x86_64"
+//   - error:           If uname fails or reports an unsupported OS
+func DetectRemoteSystem(runner ExecRunner) (*RealSystemInfo, error) {
+	out, err := runner.Output("uname", "-s", "-m")
+	if err != nil {
+		return nil, fmt.Errorf("detecting the remote system: %w", err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 || unameOS[fields[0]] == "" {
+		return nil, fmt.Errorf("unsupported remote system %q", strings.TrimSpace(string(out)))
+	}
+	readFile := func(path string) ([]byte, error) {
+		return runner.Output("cat", path)
+	}
+	noEnv := func(string) string { return "" }
+	sys := detectSystem(unameOS[fields[0]], fields[1], noEnv, readFile)
+	sys.Headless = true
+	return sys, nil
+}
+
+// RunnerLookPath returns a LookPath that finds executables on the machine a runner
+// executes on, with the shell's command -v.
+func RunnerLookPath(runner ExecRunner) func(string) (string, error) {
+	return func(bin string) (string, error) {
+		out, err := runner.Output("sh", "-c", "command -v "+shellQuote(bin))
+		path := strings.TrimSpace(string(out))
+		if err != nil || path == "" {
+			return "", fmt.Errorf("%s: executable file not found in remote $PATH", bin)
+		}
+		return path, nil
+	}
+}
+
+// RunnerInitSystem returns an InitSystem that reports the init system of the machine a
+// runner executes on: "systemd" if it is running and systemctl is installed, else "".
+func RunnerInitSystem(runner ExecRunner) func() string {
+	return func() string {
+		check := fmt.Sprintf("test -d %s && command -v systemctl", systemdRuntimeDir)
+		if _, err := runner.Output("sh", "-c", check); err != nil {
+			return ""
+		}
+		return "systemd"
+	}
+}
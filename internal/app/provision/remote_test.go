@@ -0,0 +1,30 @@
+package provision
+
+import "testing"
+
+func TestDetectRemoteSystem(t *testing.T) {
+	runner := &fakeOutputRunner{outputs: map[string][]byte{
+		"uname -s -m":                    []byte("Linux x86_64\n"),
+		"cat /etc/os-release":            []byte("ID=ubuntu\nID_LIKE=debian\n"),
+		"sh -c command -v 'systemctl'":   []byte("/usr/bin/systemctl\n"),
+		"cat /proc/sys/kernel/osrelease": []byte("6.8.0-generic\n"),
+	}}
+	sys, err := DetectRemoteSystem(runner)
+	if err != nil {
+		t.Fatalf("DetectRemoteSystem error: %v", err)
+	}
+	if sys.OS() != "linux" || sys.Arch() != "x64" || sys.ID() != "ubuntu" || !sys.IsHeadless() || sys.IsWSL() {
+		t.Errorf("unexpected remote system %+v", sys)
+	}
+	if path, err := RunnerLookPath(runner)("systemctl"); err != nil || path != "/usr/bin/systemctl" {
+		t.Errorf("RunnerLookPath(systemctl) = %q, %v", path, err)
+	}
+	if _, err := RunnerLookPath(runner)("brew"); err == nil {
+		t.Error("expected brew not to be found")
+	}
+
+	runner.outputs["uname -s -m"] = []byte("Plan9 386\n")
+	if _, err := DetectRemoteSystem(runner); err == nil {
+		t.Error("expected an error for an unsupported OS")
+	}
+}
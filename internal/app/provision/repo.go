@@ -0,0 +1,117 @@
+package provision
+
+import (
+	"regexp"
+	"strings"
+
+	"a-la-carte/internal/app"
+)
+
+// Instruction types that add a package repository before an entry's apt or dnf install.
+// Their Package is the idempotent shell script that adds the repository, run as root.
+const (
+	AptRepo = "apt-repo"
+	DnfRepo = "dnf-repo"
+)
+
+// Where repositories and their keys are written.
+const (
+	aptKeyringDir = "/etc/apt/keyrings"
+	aptSourcesDir = "/etc/apt/sources.list.d"
+	dnfReposDir   = "/etc/yum.repos.d"
+)
+
+// repoNameChars are the characters not allowed in repository file names.
+var repoNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// aptPlaceholders are expanded by the shell when the repository is added.
+var aptPlaceholders = strings.NewReplacer(
+	"{arch}", "$(dpkg --print-architecture)",
+	"{codename}", `$(. /etc/os-release && echo "$VERSION_CODENAME")`,
+)
+
+// repoFileName returns the file name used for a key's repository and keyring.
+func repoFileName(key string) string {
+	_, bare := app.SplitKey(key)
+	return "a-la-carte-" + strings.Trim(repoNameChars.ReplaceAllString(strings.ToLower(bare), "-"), "-")
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// AptRepoScript returns the shell script that adds an apt repository and its signing
+// key. The key is only downloaded if the keyring is missing, and the sources list is
+// only rewritten, followed by apt-get update, when it changed.
+//
+// # Parameters
+//   - key:    The manifest key, used to name the sources list and keyring
+//   - repo:   The sources line ("deb [options] url suite components"); {arch} and
+//     {codename} are replaced with the dpkg architecture and the release codename
+//   - keyURL: The URL of the repository's GPG key (optional)
+//
+// # Example
+//
+//	AptRepoScript("docker", "deb [arch={arch}] https://download.docker.com/linux/ubuntu {codename} stable",
+//		"https://download.docker.com/linux/ubuntu/gpg")
+func AptRepoScript(key, repo, keyURL string) string {
+	name := repoFileName(key)
+	line := strings.TrimSpace(repo)
+	if !strings.HasPrefix(line, "deb ") && !strings.HasPrefix(line, "deb-src ") {
+		line = "deb " + line
+	}
+	var steps []string
+	if keyURL != "" {
+		keyring := aptKeyringDir + "/" + name + ".gpg"
+		steps = append(steps,
+			"install -d -m 0755 "+aptKeyringDir,
+			"{ [ -s "+keyring+" ] || curl -fsSL "+shellQuote(keyURL)+" | gpg --dearmor -o "+keyring+"; }",
+		)
+		kind, rest, _ := strings.Cut(line, " ")
+		if strings.HasPrefix(rest, "[") {
+			rest = "[signed-by=" + keyring + " " + strings.TrimPrefix(rest, "[")
+		} else {
+			rest = "[signed-by=" + keyring + "] " + rest
+		}
+		line = kind + " " + rest
+	}
+	line = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`").Replace(line)
+	list := aptSourcesDir + "/" + name + ".list"
+	steps = append(steps,
+		`line="`+aptPlaceholders.Replace(line)+`"`,
+		`{ [ "$(cat `+list+` 2>/dev/null)" = "$line" ] || { echo "$line" > `+list+` && apt-get update; }; }`,
+	)
+	return strings.Join(steps, " && ")
+}
+
+// DnfRepoScript returns the shell script that installs a dnf/yum .repo file from url
+// unless it is already present.
+func DnfRepoScript(key, url string) string {
+	file := dnfReposDir + "/" + repoFileName(key) + ".repo"
+	return "[ -f " + file + " ] || curl -fsSL " + shellQuote(url) + " -o " + file
+}
+
+// addRepoInstructions adds the repository declared by an entry (_apt_repo/_apt_key or
+// _dnf_repo) before the first instruction planned since start that installs from it.
+func (p *Provisioner) addRepoInstructions(key string, entry *app.SoftwareEntry, start int, plan *[]InstallInstruction) {
+	for i := start; i < len(*plan); i++ {
+		var repo InstallInstruction
+		switch (*plan)[i].Type {
+		case "apt":
+			if entry.AptRepo == "" {
+				continue
+			}
+			repo = InstallInstruction{Type: AptRepo, Package: AptRepoScript(key, entry.AptRepo, entry.AptKey)}
+		case "dnf", "yum":
+			if entry.DnfRepo == "" {
+				continue
+			}
+			repo = InstallInstruction{Type: DnfRepo, Package: DnfRepoScript(key, entry.DnfRepo)}
+		default:
+			continue
+		}
+		*plan = append((*plan)[:i], append([]InstallInstruction{repo}, (*plan)[i:]...)...)
+		return
+	}
+}
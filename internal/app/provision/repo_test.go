@@ -0,0 +1,99 @@
+package provision
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestPlanProvision_Repos(t *testing.T) {
+	manifest := app.Manifest{
+		"docker": {
+			Apt:     app.StringOrSlice{"docker-ce"},
+			Dnf:     app.StringOrSlice{"docker-ce"},
+			AptRepo: "deb [arch={arch}] https://download.docker.com/linux/ubuntu {codename} stable",
+			AptKey:  "https://download.docker.com/linux/ubuntu/gpg",
+			DnfRepo: "https://download.docker.com/linux/fedora/docker-ce.repo",
+		},
+	}
+	for _, tc := range []struct{ id, wantType string }{{"ubuntu", AptRepo}, {"fedora", DnfRepo}} {
+		prov := NewProvisioner(staticSystemInfo{os: "linux", id: tc.id}, manifest, nil)
+		prov.InstallerOrder = []string{"apt", "dnf"}
+		if tc.id == "fedora" {
+			prov.InstallerOrder = []string{"dnf"}
+		}
+		plan, err := prov.PlanProvision([]string{"docker"}, nil)
+		if err != nil {
+			t.Fatalf("PlanProvision error: %v", err)
+		}
+		if len(plan) != 2 || plan[0].Type != tc.wantType || plan[0].Key != "docker" {
+			t.Errorf("%s: expected the %s instruction before the install, got %+v", tc.id, tc.wantType, plan)
+		}
+	}
+}
+
+func TestAptRepoScript(t *testing.T) {
+	script := AptRepoScript("docker", "deb [arch={arch}] https://example.com/apt {codename} stable", "https://example.com/gpg")
+	for _, want := range []string{
+		"deb [signed-by=/etc/apt/keyrings/a-la-carte-docker.gpg arch=$(dpkg --print-architecture)] https://example.com/apt",
+		"curl -fsSL 'https://example.com/gpg'",
+		"/etc/apt/sources.list.d/a-la-carte-docker.list",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("expected %q in %s", want, script)
+		}
+	}
+	if got := AptRepoScript("x", "https://example.com/apt stable main", ""); !strings.Contains(got, `line="deb https://example.com/apt stable main"`) || strings.Contains(got, "signed-by") {
+		t.Errorf("expected an unsigned deb line, got %s", got)
+	}
+}
+
+// TestAptRepoScript_Idempotent runs the script against a temp root with stubbed tools
+// and checks that a second run neither refetches the key nor updates apt again.
+func TestAptRepoScript_Idempotent(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	root := t.TempDir()
+	bin := filepath.Join(root, "bin")
+	calls := filepath.Join(root, "calls")
+	if err := os.MkdirAll(bin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, tool := range []string{"curl", "apt-get", "dpkg"} {
+		stub := "#!/bin/sh\necho " + tool + " >> " + calls + "\necho amd64\n"
+		if err := os.WriteFile(filepath.Join(bin, tool), []byte(stub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gpg := "#!/bin/sh\ncat > \"$3\"\n"
+	if err := os.WriteFile(filepath.Join(bin, "gpg"), []byte(gpg), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	script := AptRepoScript("tool", "deb [arch={arch}] https://example.com/apt stable main", "https://example.com/gpg")
+	script = strings.ReplaceAll(script, "/etc/apt", filepath.Join(root, "etc/apt"))
+	if err := os.MkdirAll(filepath.Join(root, "etc/apt/sources.list.d"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		cmd := exec.Command("sh", "-c", script)
+		cmd.Env = append(os.Environ(), "PATH="+bin+":"+os.Getenv("PATH"))
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("run %d failed: %v\n%s", i+1, err, out)
+		}
+	}
+	data, _ := os.ReadFile(calls)
+	got := strings.Fields(string(data))
+	if want := []string{"curl", "dpkg", "apt-get", "dpkg"}; !slices.Equal(got, want) {
+		t.Errorf("got calls %v, want %v", got, want)
+	}
+	list, _ := os.ReadFile(filepath.Join(root, "etc/apt/sources.list.d/a-la-carte-tool.list"))
+	if !strings.Contains(string(list), "arch=amd64] https://example.com/apt stable main") {
+		t.Errorf("unexpected sources list %q", list)
+	}
+}
@@ -0,0 +1,196 @@
+package provision
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Step statuses of a RunReport.
+const (
+	StepInstalled = "installed" // The instruction succeeded
+	StepFailed    = "failed"    // The instruction failed
+	StepSkipped   = "skipped"   // An earlier step of the same key failed
+	StepDeferred  = "deferred"  // The key did not fit in MaxDuration
+	StepPlanned   = "planned"   // Dry run: the instruction would have run
+	StepAborted   = "aborted"   // The run was cancelled, or stopped by FailFast, before the instruction started
+)
+
+// RunReport is the machine-readable summary of an ExecutePlan run, written to
+// DefaultReportPath and shown by "provisioner report".
+//
+// # Fields
+//   - Started, Finished: When the run started and ended
+//   - Duration:    How long the run took, in milliseconds
+//   - DryRun:      Whether commands were only logged
+//   - FailFast:    Whether the run stopped at the first failure instead of continuing
+//   - Environment: The system the run happened on
+//   - Steps:       Each instruction with its result, in execution order
+//   - Skipped:     Keys planning left out of the plan, and why
+//   - Choices:     The alternative planning picked for each _deps_any group
+//   - Deferred:    Keys deferred to stay within MaxDuration
+//   - Errors:      The errors of the run
+type RunReport struct {
+	Started     time.Time         `json:"started"`
+	Finished    time.Time         `json:"finished"`
+	Duration    int64             `json:"duration_ms"`
+	DryRun      bool              `json:"dry_run"`
+	FailFast    bool              `json:"fail_fast"`
+	Environment ReportEnvironment `json:"environment"`
+	Steps       []ReportStep      `json:"steps"`
+	Skipped     []KeyDecision     `json:"skipped,omitempty"`
+	Choices     []DepChoice       `json:"choices,omitempty"`
+	Deferred    []string          `json:"deferred,omitempty"`
+	Errors      []string          `json:"errors,omitempty"`
+}
+
+// ReportEnvironment describes the system a run happened on.
+type ReportEnvironment struct {
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	Distro   string `json:"distro"`
+	Headless bool   `json:"headless"`
+	Hostname string `json:"hostname"`
+}
+
+// ReportStep is the result of one instruction of a run.
+type ReportStep struct {
+	Key      string `json:"key"`
+	Type     string `json:"type"`
+	Package  string `json:"package"`
+	Command  string `json:"command"`
+	Status   string `json:"status"`
+	Duration int64  `json:"duration_ms"`
+	Error    string `json:"error,omitempty"`
+}
+
+// DefaultReportPath returns the location of the last run's report inside the state directory.
+func DefaultReportPath() string {
+	return filepath.Join(StateDir(), "last-run.json")
+}
+
+// startReport starts the report of a run.
+func (p *Provisioner) startReport(start time.Time) {
+	p.Report = &RunReport{Started: start, DryRun: p.DryRun, FailFast: p.FailFast, Steps: []ReportStep{}, Skipped: p.PlanReport.Skipped(), Choices: p.PlanReport.choices()}
+	if p.System != nil {
+		p.Report.Environment = ReportEnvironment{OS: p.System.OS(), Arch: p.System.Arch(), Distro: p.System.ID(), Headless: p.System.IsHeadless()}
+	}
+	p.Report.Environment.Hostname, _ = os.Hostname()
+}
+
+// reportStep records the result of an instruction.
+func (p *Provisioner) reportStep(inst InstallInstruction, status string, d time.Duration, err error) {
+	step := ReportStep{Key: inst.Key, Type: inst.Type, Package: inst.Package, Command: ShellCommand(inst), Status: status, Duration: d.Milliseconds()}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	p.Report.Steps = append(p.Report.Steps, step)
+	if p.StepDone != nil {
+		p.StepDone(step)
+	}
+}
+
+// reportAborted records instructions that were not started because the run stopped.
+func (p *Provisioner) reportAborted(plan []InstallInstruction) {
+	for _, inst := range plan {
+		p.reportStep(inst, StepAborted, 0, nil)
+	}
+}
+
+// finishReport completes the report of a run with the error ExecutePlan returns.
+func (p *Provisioner) finishReport(end time.Time, err error) {
+	p.Report.Finished = end
+	p.Report.Duration = end.Sub(p.Report.Started).Milliseconds()
+	p.Report.Deferred = p.Deferred
+	for _, err := range splitErrors(err) {
+		p.Report.Errors = append(p.Report.Errors, err.Error())
+	}
+}
+
+// WriteReport writes a report as indented JSON, creating the directory if needed.
+func WriteReport(path string, report *RunReport) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// LoadReport reads a report written by WriteReport.
+func LoadReport(path string) (*RunReport, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no run report at %s", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var report RunReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse run report %s: %w", path, err)
+	}
+	return &report, nil
+}
+
+// PrintReport writes a human-readable summary of a report: the environment, a line
+// per step, and the totals.
+func PrintReport(w io.Writer, report *RunReport) error {
+	var b strings.Builder
+	env := report.Environment
+	fmt.Fprintf(&b, "Run of %s (%s)", report.Started.Local().Format("2006-01-02 15:04:05"), time.Duration(report.Duration)*time.Millisecond)
+	if report.DryRun {
+		b.WriteString(" [dry run]")
+	}
+	if report.FailFast {
+		b.WriteString(" [fail-fast]")
+	}
+	fmt.Fprintf(&b, "\nSystem: %s/%s %s on %s", env.OS, env.Arch, env.Distro, env.Hostname)
+	if env.Headless {
+		b.WriteString(" (headless)")
+	}
+	b.WriteString("\n\n")
+	counts := make(map[string]int)
+	for _, step := range report.Steps {
+		counts[step.Status]++
+		fmt.Fprintf(&b, "  %-9s %-20s %s", step.Status, step.Key, step.Command)
+		if step.Duration > 0 {
+			fmt.Fprintf(&b, " (%s)", time.Duration(step.Duration)*time.Millisecond)
+		}
+		b.WriteString("\n")
+		if step.Error != "" {
+			fmt.Fprintf(&b, "            %s\n", step.Error)
+		}
+	}
+	for _, d := range report.Skipped {
+		fmt.Fprintf(&b, "  %-9s %-20s %s\n", "unplanned", d.Key, d.Detail)
+	}
+	for _, c := range report.Choices {
+		fmt.Fprintf(&b, "  %-9s %-20s %s of %s (%s)\n", "chose", c.Key, c.Chosen, strings.Join(c.Group, "|"), c.Why)
+	}
+	var totals []string
+	for _, status := range []string{StepInstalled, StepFailed, StepSkipped, StepDeferred, StepPlanned, StepAborted} {
+		if counts[status] > 0 {
+			totals = append(totals, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	if len(report.Skipped) > 0 {
+		totals = append(totals, fmt.Sprintf("%d unplanned", len(report.Skipped)))
+	}
+	if len(totals) == 0 {
+		totals = append(totals, "nothing to do")
+	}
+	fmt.Fprintf(&b, "\n%s\n", strings.Join(totals, ", "))
+	for _, err := range report.Errors {
+		fmt.Fprintf(&b, "Error: %s\n", err)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,73 @@
+package provision
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExecutePlan_Report(t *testing.T) {
+	runner := &failingRunner{fail: []string{"apt"}}
+	prov := NewProvisioner(staticSystemInfo{os: "linux", id: "ubuntu"}, hookManifest(), runner)
+	plan, err := prov.PlanProvision([]string{"docker"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	if err := prov.ExecutePlan(plan); err == nil {
+		t.Fatal("expected ExecutePlan to fail")
+	}
+	report := prov.Report
+	if report == nil {
+		t.Fatal("expected a report")
+	}
+	var got []string
+	for _, step := range report.Steps {
+		got = append(got, step.Type+" "+step.Status)
+	}
+	want := []string{"preinstall installed", "apt failed", "postinstall skipped", "postinstall skipped"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got steps %v, want %v", got, want)
+	}
+	if report.Steps[1].Error == "" || len(report.Errors) != 1 {
+		t.Errorf("expected the apt failure in the report, got step error %q and errors %v", report.Steps[1].Error, report.Errors)
+	}
+	if report.Environment.OS != "linux" || report.Environment.Distro != "ubuntu" {
+		t.Errorf("unexpected environment %+v", report.Environment)
+	}
+}
+
+func TestReport_RoundTrip(t *testing.T) {
+	prov := NewProvisioner(staticSystemInfo{os: "linux", id: "ubuntu"}, hookManifest(), &fakeExecRunner{})
+	prov.DryRun = true
+	plan, err := prov.PlanProvision([]string{"docker"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	if err := prov.ExecutePlan(plan); err != nil {
+		t.Fatalf("ExecutePlan error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "state", "last-run.json")
+	if err := WriteReport(path, prov.Report); err != nil {
+		t.Fatalf("WriteReport error: %v", err)
+	}
+	report, err := LoadReport(path)
+	if err != nil {
+		t.Fatalf("LoadReport error: %v", err)
+	}
+	if !report.DryRun || len(report.Steps) != 4 || !strings.Contains(report.Steps[1].Command, "apt-get install") {
+		t.Errorf("unexpected report after round trip: %+v", report)
+	}
+	var b strings.Builder
+	if err := PrintReport(&b, report); err != nil {
+		t.Fatalf("PrintReport error: %v", err)
+	}
+	for _, want := range []string{"[dry run]", "linux/", "docker", "4 planned"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("PrintReport output missing %q:\n%s", want, b.String())
+		}
+	}
+	if _, err := LoadReport(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected an error for a missing report")
+	}
+}
@@ -0,0 +1,99 @@
+package provision
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how transient install failures are retried.
+//
+// # Fields
+//   - Attempts: Total attempts per instruction; 0 or 1 disables retries
+//   - Backoff:  Delay before the first retry, doubled after each further attempt
+type RetryPolicy struct {
+	Attempts int
+	Backoff  time.Duration
+}
+
+// transientFailures are phrases in the standard error of a failed install that point
+// at a flaky network, a timeout or a package manager lock held by another process.
+// Failures without one of them, e.g. a package that does not exist, are permanent.
+var transientFailures = []string{
+	"could not resolve host",
+	"temporary failure in name resolution",
+	"name or service not known",
+	"network is unreachable",
+	"connection refused",
+	"connection reset",
+	"connection timed out",
+	"operation timed out",
+	"i/o timeout",
+	"tls handshake timeout",
+	"unexpected eof",
+	"502 bad gateway",
+	"503 service unavailable",
+	"504 gateway timeout",
+	"could not get lock",
+	"is locked by another process",
+	"another process is using",
+	"waiting for cache lock",
+	"blocking waiting for file lock",
+	"has 'install' change in progress",
+}
+
+// IsRetryable reports whether a failed install may be transient, given the standard
+// error of the command. Only commands that ran and exited with an error are retried;
+// failures that are not an exit status (e.g. a missing binary) are permanent.
+func IsRetryable(err error, stderr string) bool {
+	if err == nil || exitCode(err) <= 0 {
+		return false
+	}
+	stderr = strings.ToLower(stderr)
+	return slices.ContainsFunc(transientFailures, func(phrase string) bool {
+		return strings.Contains(stderr, phrase)
+	})
+}
+
+// runWithRetry runs an instruction, retrying transient failures according to RetryPolicy.
+func (p *Provisioner) runWithRetry(ctx context.Context, inst InstallInstruction) error {
+	backoff := p.RetryPolicy.Backoff
+	for attempt := 1; ; attempt++ {
+		var stderr bytes.Buffer
+		var err error
+		if p.LogFile != "" {
+			err = p.runLogged(ctx, inst, &stderr)
+		} else {
+			err = p.runCapturing(ctx, inst, io.Discard, &stderr)
+		}
+		if err == nil || attempt >= p.RetryPolicy.Attempts || ctx.Err() != nil || !IsRetryable(err, stderr.String()) {
+			return err
+		}
+		if p.Runner != nil {
+			_ = p.Runner.Run("info", fmt.Sprintf("Retrying %s %s in %s (attempt %d/%d): %v", inst.Type, inst.Package, backoff, attempt+1, p.RetryPolicy.Attempts, err))
+		}
+		p.RunLog.Log("info", fmt.Sprintf("Retrying after: %v", err))
+		if err := p.wait(ctx, backoff); err != nil {
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// wait pauses for d, returning early with the context error if ctx is cancelled.
+func (p *Provisioner) wait(ctx context.Context, d time.Duration) error {
+	if p.sleep != nil {
+		p.sleep(d)
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,110 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"a-la-carte/internal/app"
+)
+
+// exitError is a test error carrying an exit code, like *exec.ExitError.
+type exitError int
+
+func (e exitError) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+func (e exitError) ExitCode() int { return int(e) }
+
+// flakyRunner fails installs with err and stderr for the first `failures` attempts.
+type flakyRunner struct {
+	fakeExecRunner
+	CapturedOutput
+	failures int
+	err      error
+	stderr   string
+	attempts int
+}
+
+func (f *flakyRunner) RunContext(_ context.Context, cmd string, args ...string) error {
+	if cmd == "section" || cmd == "info" {
+		return nil
+	}
+	f.attempts++
+	if f.attempts <= f.failures {
+		_, stderr := f.Writers()
+		_, _ = io.WriteString(stderr, f.stderr)
+		return f.err
+	}
+	return nil
+}
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		err    error
+		stderr string
+		want   bool
+	}{
+		{exitError(1), "curl: (6) Could not resolve host: github.com", true},
+		{fmt.Errorf("wrapped: %w", exitError(1)), "dial tcp: i/o timeout", true},
+		{exitError(101), "error: failed to download: Connection reset by peer", true},
+		{exitError(100), "E: Could not get lock /var/lib/dpkg/lock-frontend", true},
+		{exitError(1), "Error: No available formula with the name \"bta\"", false},
+		{exitError(1), "", false},
+		{errors.New("executable file not found"), "connection refused", false},
+		{nil, "", false},
+	}
+	for _, tc := range cases {
+		if got := IsRetryable(tc.err, tc.stderr); got != tc.want {
+			t.Errorf("IsRetryable(%v, %q) = %v, want %v", tc.err, tc.stderr, got, tc.want)
+		}
+	}
+}
+
+func TestExecutePlan_Retry(t *testing.T) {
+	plan := []InstallInstruction{{Type: "brew", Package: "bat", Key: "bat"}}
+	var slept []time.Duration
+	newProv := func(runner ExecRunner) *Provisioner {
+		prov := NewProvisioner(&fakeSystemInfo{}, app.Manifest{}, runner)
+		prov.RetryPolicy = RetryPolicy{Attempts: 3, Backoff: time.Second}
+		prov.sleep = func(d time.Duration) { slept = append(slept, d) }
+		return prov
+	}
+
+	const offline = "curl: (7) Failed to connect: Connection refused"
+	runner := &flakyRunner{failures: 2, err: exitError(1), stderr: offline}
+	if err := newProv(runner).ExecutePlan(plan); err != nil {
+		t.Fatalf("expected the third attempt to succeed, got: %v", err)
+	}
+	if runner.attempts != 3 || len(slept) != 2 || slept[1] != 2*time.Second {
+		t.Errorf("expected 3 attempts with doubling backoff, got %d attempts, slept %v", runner.attempts, slept)
+	}
+
+	runner = &flakyRunner{failures: 5, err: exitError(1), stderr: offline}
+	if err := newProv(runner).ExecutePlan(plan); err == nil || runner.attempts != 3 {
+		t.Errorf("expected failure after 3 attempts, got %d attempts, err %v", runner.attempts, err)
+	}
+
+	runner = &flakyRunner{failures: 5, err: exitError(1), stderr: "Error: No available formula"}
+	if err := newProv(runner).ExecutePlan(plan); err == nil || runner.attempts != 1 {
+		t.Errorf("expected a permanent failure not to be retried, got %d attempts", runner.attempts)
+	}
+}
+
+func TestExecutePlan_RetryCancelled(t *testing.T) {
+	plan := []InstallInstruction{{Type: "brew", Package: "bat", Key: "bat"}}
+	runner := &flakyRunner{failures: 5, err: exitError(1), stderr: "connection timed out"}
+	prov := NewProvisioner(&fakeSystemInfo{}, app.Manifest{}, runner)
+	prov.RetryPolicy = RetryPolicy{Attempts: 3, Backoff: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	start := time.Now()
+	err := prov.ExecutePlanContext(ctx, plan)
+	if !errors.Is(err, context.Canceled) || runner.attempts != 1 {
+		t.Errorf("expected cancelling to stop the backoff, got %d attempts, err %v", runner.attempts, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Minute {
+		t.Errorf("expected the backoff to end with the context, waited %s", elapsed)
+	}
+}
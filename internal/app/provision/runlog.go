@@ -0,0 +1,149 @@
+package provision
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// runLogTimeFormat names archived run logs, so sorting names sorts runs by start time.
+const runLogTimeFormat = "20060102-150405"
+
+// RunLog archives the log of one provisioning run as tab-separated lines of
+// time, manifest key, level and text. The key is the instruction being executed
+// when the line was written, so output can be traced back to its package.
+// All methods are safe on a nil *RunLog, which discards everything.
+type RunLog struct {
+	mu   sync.Mutex
+	file *os.File
+	key  string
+}
+
+// DefaultRunLogDir returns the directory run logs are archived in.
+func DefaultRunLogDir() string {
+	return filepath.Join(StateDir(), "logs")
+}
+
+// CreateRunLog creates a new run log in dir, named after the current time.
+func CreateRunLog(dir string) (*RunLog, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	name := filepath.Join(dir, time.Now().Format(runLogTimeFormat)+".log")
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create run log: %w", err)
+	}
+	return &RunLog{file: f}, nil
+}
+
+// SetKey sets the manifest key attributed to the following lines.
+func (l *RunLog) SetKey(key string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.key = key
+}
+
+// Log appends a line with the given level.
+func (l *RunLog) Log(level, text string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		fmt.Fprintf(l.file, "%s\t%s\t%s\t%s\n", time.Now().Format(time.TimeOnly), l.key, level, line)
+	}
+}
+
+// Write implements io.Writer, logging command output line by line at the "output" level.
+func (l *RunLog) Write(p []byte) (int, error) {
+	l.Log("output", string(p))
+	return len(p), nil
+}
+
+// Close closes the log file.
+func (l *RunLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// RunLogMatch is a line of an archived run log that matched a search.
+//
+// # Fields
+//   - Run:   The run the line belongs to (its start time, e.g. "20240102-150405")
+//   - Time:  The time the line was written
+//   - Key:   The manifest key being installed, if any
+//   - Level: The log level (e.g. "info", "error", "output")
+//   - Text:  The log text
+type RunLogMatch struct {
+	Run   string
+	Time  string
+	Key   string
+	Level string
+	Text  string
+}
+
+// String returns the match with its run and package context.
+func (m RunLogMatch) String() string {
+	key := m.Key
+	if key == "" {
+		key = "-"
+	}
+	return fmt.Sprintf("%s %s [%s] %s: %s", m.Run, m.Time, key, m.Level, m.Text)
+}
+
+// SearchRunLogs returns the lines of the run logs in dir whose text or key match
+// pattern, newest run first.
+func SearchRunLogs(dir string, pattern *regexp.Regexp) ([]RunLogMatch, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+	var matches []RunLogMatch
+	for _, file := range files {
+		fileMatches, err := searchRunLog(file, pattern)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, fileMatches...)
+	}
+	return matches, nil
+}
+
+// searchRunLog returns the matching lines of a single run log.
+func searchRunLog(path string, pattern *regexp.Regexp) ([]RunLogMatch, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open run log: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	run := strings.TrimSuffix(filepath.Base(path), ".log")
+	var matches []RunLogMatch
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		m := RunLogMatch{Run: run, Time: fields[0], Key: fields[1], Level: fields[2], Text: fields[3]}
+		if pattern.MatchString(m.Text) || pattern.MatchString(m.Key) {
+			matches = append(matches, m)
+		}
+	}
+	return matches, scanner.Err()
+}
@@ -0,0 +1,46 @@
+package provision
+
+import (
+	"regexp"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestRunLog_Search(t *testing.T) {
+	dir := t.TempDir()
+	runLog, err := CreateRunLog(dir)
+	if err != nil {
+		t.Fatalf("CreateRunLog failed: %v", err)
+	}
+	manifest := app.Manifest{"foo": app.SoftwareEntry{}, "libfoo": app.SoftwareEntry{}}
+	prov := NewProvisioner(&fakeSystemInfo{}, manifest, &errRunner{})
+	prov.RunLog = runLog
+	plan := []InstallInstruction{
+		{Type: "apt", Package: "foo", Key: "libfoo"},
+		{Type: "apt", Package: "bar", Key: "foo"},
+	}
+	if err := prov.ExecutePlan(plan); err == nil {
+		t.Fatal("expected ExecutePlan to fail")
+	}
+	if err := runLog.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	matches, err := SearchRunLogs(dir, regexp.MustCompile("libfoo"))
+	if err != nil {
+		t.Fatalf("SearchRunLogs failed: %v", err)
+	}
+	var failure *RunLogMatch
+	for i, m := range matches {
+		if m.Key != "libfoo" {
+			t.Errorf("expected only libfoo lines, got: %s", m)
+		}
+		if m.Level == "error" {
+			failure = &matches[i]
+		}
+	}
+	if failure == nil || failure.Text != "Failed: fail foo" {
+		t.Fatalf("expected the libfoo failure with its reason, got: %v", matches)
+	}
+}
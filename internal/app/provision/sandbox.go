@@ -0,0 +1,86 @@
+package provision
+
+import (
+	"fmt"
+	"os"
+
+	"a-la-carte/internal/app"
+)
+
+// Script sandbox modes for Provisioner.ScriptSandbox.
+const (
+	SandboxNone     = "none"     // Run scripts with plain bash
+	SandboxBwrap    = "bwrap"    // Run scripts inside bubblewrap
+	SandboxFirejail = "firejail" // Run scripts inside firejail
+	SandboxEnv      = "env"      // Run scripts with a minimal environment
+)
+
+// SandboxModes lists the supported script sandbox modes.
+var SandboxModes = []string{SandboxNone, SandboxBwrap, SandboxFirejail, SandboxEnv}
+
+// sandboxEnv are the variables kept by the env sandbox; everything else is cleared.
+var sandboxEnv = []string{"HOME", "PATH", "USER", "LANG", "TERM"}
+
+// scriptWrapper returns the sandbox command the key's scripts run under, or nil to run
+// them with plain bash.
+func (p *Provisioner) scriptWrapper(key string) ([]string, error) {
+	return ScriptWrapper(p.ScriptSandbox, p.Manifest[key].Sandbox, os.Getenv)
+}
+
+// ScriptWrapper builds the command that runs a script's shell in the given sandbox mode,
+// applying the entry's restrictions. The runner appends the shell and script path.
+//
+// # Parameters
+//   - mode:    One of SandboxModes (empty means SandboxNone)
+//   - sandbox: The entry's restrictions
+//   - getenv:  Looks up HOME and the variables kept by the env sandbox
+//
+// # Returns
+//   - []string: The command prefix, nil for SandboxNone
+//   - error:    If the mode is unknown or cannot enforce a restriction
+//
+// # Example
+//
+//	ScriptWrapper(SandboxBwrap, app.ScriptSandbox{NoNetwork: true}, os.Getenv)
+//	// ["bwrap", "--dev-bind", "/", "/", "--die-with-parent", "--unshare-net"]
+func ScriptWrapper(mode string, sandbox app.ScriptSandbox, getenv func(string) string) ([]string, error) {
+	home := getenv("HOME")
+	switch mode {
+	case "", SandboxNone:
+		return nil, nil
+	case SandboxBwrap:
+		cmd := []string{"bwrap", "--dev-bind", "/", "/", "--die-with-parent"}
+		if sandbox.NoNetwork {
+			cmd = append(cmd, "--unshare-net")
+		}
+		if sandbox.ReadOnlyHome && home != "" {
+			cmd = append(cmd, "--ro-bind", home, home)
+		}
+		return cmd, nil
+	case SandboxFirejail:
+		cmd := []string{"firejail", "--quiet", "--noprofile"}
+		if sandbox.NoNetwork {
+			cmd = append(cmd, "--net=none")
+		}
+		if sandbox.ReadOnlyHome && home != "" {
+			cmd = append(cmd, "--read-only="+home)
+		}
+		return cmd, nil
+	case SandboxEnv:
+		if sandbox.ReadOnlyHome {
+			return nil, fmt.Errorf("a read-only home needs the %s or %s script sandbox", SandboxBwrap, SandboxFirejail)
+		}
+		cmd := []string{"env", "-i"}
+		for _, name := range sandboxEnv {
+			if value := getenv(name); value != "" {
+				cmd = append(cmd, name+"="+value)
+			}
+		}
+		if sandbox.NoNetwork {
+			cmd = append(cmd, "unshare", "--net", "--map-root-user")
+		}
+		return cmd, nil
+	default:
+		return nil, fmt.Errorf("unknown script sandbox %q", mode)
+	}
+}
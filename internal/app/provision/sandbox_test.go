@@ -0,0 +1,59 @@
+package provision
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestScriptWrapper(t *testing.T) {
+	env := map[string]string{"HOME": "/home/me", "PATH": "/usr/bin"}
+	getenv := func(name string) string { return env[name] }
+	restricted := app.ScriptSandbox{NoNetwork: true, ReadOnlyHome: true}
+	cases := []struct {
+		mode    string
+		sandbox app.ScriptSandbox
+		want    string
+		wantErr bool
+	}{
+		{"", restricted, "", false},
+		{SandboxNone, restricted, "", false},
+		{SandboxBwrap, app.ScriptSandbox{}, "bwrap --dev-bind / / --die-with-parent", false},
+		{SandboxBwrap, restricted, "bwrap --dev-bind / / --die-with-parent --unshare-net --ro-bind /home/me /home/me", false},
+		{SandboxFirejail, restricted, "firejail --quiet --noprofile --net=none --read-only=/home/me", false},
+		{SandboxEnv, app.ScriptSandbox{NoNetwork: true}, "env -i HOME=/home/me PATH=/usr/bin unshare --net --map-root-user", false},
+		{SandboxEnv, restricted, "", true},
+		{"docker", app.ScriptSandbox{}, "", true},
+	}
+	for _, tc := range cases {
+		got, err := ScriptWrapper(tc.mode, tc.sandbox, getenv)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ScriptWrapper(%q, %+v) error = %v, wantErr %v", tc.mode, tc.sandbox, err, tc.wantErr)
+			continue
+		}
+		if strings.Join(got, " ") != tc.want {
+			t.Errorf("ScriptWrapper(%q, %+v) = %q, want %q", tc.mode, tc.sandbox, got, tc.want)
+		}
+	}
+}
+
+func TestExecutePlan_SandboxedScript(t *testing.T) {
+	manifest := app.Manifest{
+		"tool": {Script: app.StringOrSlice{"echo hi"}, Sandbox: app.ScriptSandbox{NoNetwork: true}},
+	}
+	runner := &fakeExecRunner{}
+	prov := NewProvisioner(nil, manifest, runner)
+	prov.ScriptSandbox = SandboxFirejail
+	plan, err := prov.PlanProvision([]string{"tool"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	if err := prov.ExecutePlan(plan); err != nil {
+		t.Fatalf("ExecutePlan error: %v", err)
+	}
+	if want := "script echo hi firejail --quiet --noprofile --net=none"; !slices.Contains(runner.Commands, want) {
+		t.Errorf("expected %q in %v", want, runner.Commands)
+	}
+}
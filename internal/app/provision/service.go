@@ -0,0 +1,178 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"a-la-carte/internal/app"
+)
+
+// Instruction types that enable and start an entry's _service after it is installed,
+// one per service manager. Their Package is the service name.
+const (
+	ServiceSystemd = "service:systemd" // sudo systemctl enable --now
+	ServiceLaunchd = "service:launchd" // launchctl, for system daemons on macOS
+	ServiceBrew    = "service:brew"    // brew services, for services installed with brew on macOS
+)
+
+// Suffixes of the type of a service instruction whose _service sets sudo, overriding
+// the service manager's default, e.g. service:systemd:nosudo.
+const (
+	serviceSudo   = ":sudo"
+	serviceNoSudo = ":nosudo"
+)
+
+// IsServiceInstruction reports whether t is the type of a service instruction.
+func IsServiceInstruction(t string) bool {
+	return strings.HasPrefix(t, "service:")
+}
+
+// serviceManager returns the service instruction type t without its sudo suffix, and
+// whether its commands run with sudo.
+func serviceManager(t string) (string, bool) {
+	if manager, ok := strings.CutSuffix(t, serviceNoSudo); ok {
+		return manager, false
+	}
+	if manager, ok := strings.CutSuffix(t, serviceSudo); ok {
+		return manager, true
+	}
+	return t, t != ServiceBrew
+}
+
+// systemdRuntimeDir exists only when systemd is the running init system.
+const systemdRuntimeDir = "/run/systemd/system"
+
+// ServiceCommands returns the commands that enable and start the service of an
+// instruction, or nil if the instruction is not a service instruction.
+//
+// # Example
+//
+//	ServiceCommands(InstallInstruction{Type: ServiceSystemd, Package: "docker"})
+//	// [["sudo", "systemctl", "enable", "--now", "docker"]]
+//	ServiceCommands(InstallInstruction{Type: ServiceSystemd + ":nosudo", Package: "sshd"})
+//	// [["systemctl", "enable", "--now", "sshd"]]
+func ServiceCommands(inst InstallInstruction) [][]string {
+	manager, sudo := serviceManager(inst.Type)
+	var cmds [][]string
+	switch manager {
+	case ServiceSystemd:
+		cmds = [][]string{{"systemctl", "enable", "--now", inst.Package}}
+	case ServiceLaunchd:
+		cmds = [][]string{
+			{"launchctl", "enable", "system/" + inst.Package},
+			{"launchctl", "kickstart", "-k", "system/" + inst.Package},
+		}
+	case ServiceBrew:
+		cmds = [][]string{{"brew", "services", "start", inst.Package}}
+	default:
+		return nil
+	}
+	if sudo {
+		for i, cmd := range cmds {
+			cmds[i] = append([]string{"sudo"}, cmd...)
+		}
+	}
+	return cmds
+}
+
+// serviceType returns the service instruction type for this system, or "" with the
+// reason services cannot be managed here (e.g. a container without systemd).
+func (p *Provisioner) serviceType(installers []string) (string, string) {
+	osType := ""
+	if p.System != nil {
+		osType = p.System.OS()
+	}
+	switch osType {
+	case "linux":
+		if p.initSystem() != "systemd" {
+			return "", "systemd is not running"
+		}
+		return ServiceSystemd, ""
+	case "darwin":
+		for _, installer := range installers {
+			if installer == "brew" || installer == "cask" {
+				return ServiceBrew, ""
+			}
+		}
+		return ServiceLaunchd, ""
+	default:
+		return "", "services are not managed on " + osType
+	}
+}
+
+// initSystem returns the name of the running init system ("systemd" or "").
+func (p *Provisioner) initSystem() string {
+	if p.initName != nil {
+		return p.initName()
+	}
+	if p.InitSystem != nil {
+		return p.InitSystem()
+	}
+	if info, err := os.Stat(systemdRuntimeDir); err != nil || !info.IsDir() {
+		return ""
+	}
+	if _, err := p.lookPathFunc()("systemctl"); err != nil {
+		return ""
+	}
+	return "systemd"
+}
+
+// addServiceInstructions adds the steps that enable and start an entry's services after
+// the instructions planned for it since start. Entries with nothing to install, and all
+// entries when NoServices is set, get no service steps; services with a pkg only apply
+// when the entry is installed with that installer.
+func (p *Provisioner) addServiceInstructions(key string, entry *app.SoftwareEntry, start int, plan *[]InstallInstruction) {
+	if len(entry.Service) == 0 || len(*plan) == start {
+		return
+	}
+	var installers []string
+	for _, inst := range (*plan)[start:] {
+		installers = append(installers, inst.Type)
+	}
+	var services app.Services
+	for _, service := range entry.Service {
+		if service.Pkg == "" || slices.Contains(installers, service.Pkg) {
+			services = append(services, service)
+		}
+	}
+	if len(services) == 0 {
+		return
+	}
+	if p.NoServices {
+		if p.Runner != nil {
+			_ = p.Runner.Run("info", fmt.Sprintf("Not enabling %s for %s: --no-services", strings.Join(services.Names(), ", "), key))
+		}
+		return
+	}
+	serviceType, reason := p.serviceType(installers)
+	if serviceType == "" {
+		if p.Runner != nil {
+			_ = p.Runner.Run("info", fmt.Sprintf("Not enabling %s for %s: %s", strings.Join(services.Names(), ", "), key, reason))
+		}
+		return
+	}
+	for _, service := range services {
+		t := serviceType
+		switch {
+		case service.Sudo == nil:
+		case *service.Sudo:
+			t += serviceSudo
+		default:
+			t += serviceNoSudo
+		}
+		*plan = append(*plan, InstallInstruction{Type: t, Package: service.Name})
+	}
+}
+
+// startService runs the commands that enable and start a service.
+func (p *Provisioner) startService(ctx context.Context, inst InstallInstruction) error {
+	for _, cmd := range ServiceCommands(inst) {
+		if err := p.Runner.RunContext(ctx, cmd[0], cmd[1:]...); err != nil {
+			return fmt.Errorf("enabling service %s: %w", inst.Package, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package provision
+
+import (
+	"slices"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestPlanProvision_Services(t *testing.T) {
+	manifest := app.Manifest{
+		"docker": {Apt: app.StringOrSlice{"docker.io"}, Brew: app.StringOrSlice{"colima"}, Service: app.Services{{Name: "docker"}}, PostInstall: app.StringOrSlice{"usermod -aG docker me"}},
+	}
+	cases := []struct {
+		name       string
+		sys        SystemInfo
+		init       string
+		noServices bool
+		want       []string
+	}{
+		{"systemd", staticSystemInfo{os: "linux", id: "ubuntu"}, "systemd", false, []string{"apt docker.io", "service:systemd docker", "postinstall usermod -aG docker me"}},
+		{"no systemd", staticSystemInfo{os: "linux", id: "ubuntu"}, "", false, []string{"apt docker.io", "postinstall usermod -aG docker me"}},
+		{"no-services", staticSystemInfo{os: "linux", id: "ubuntu"}, "systemd", true, []string{"apt docker.io", "postinstall usermod -aG docker me"}},
+		{"brew", staticSystemInfo{os: "darwin", id: "darwin"}, "", false, []string{"brew colima", "service:brew docker", "postinstall usermod -aG docker me"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			prov := NewProvisioner(tc.sys, manifest, &fakeExecRunner{})
+			prov.InstallerOrder = []string{"apt", "brew"}
+			prov.NoServices = tc.noServices
+			prov.initName = func() string { return tc.init }
+			plan, err := prov.PlanProvision([]string{"docker"}, nil)
+			if err != nil {
+				t.Fatalf("PlanProvision error: %v", err)
+			}
+			var got []string
+			for _, inst := range plan {
+				got = append(got, inst.Type+" "+inst.Package)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got plan %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExecutePlan_Service(t *testing.T) {
+	runner := &fakeExecRunner{}
+	prov := NewProvisioner(nil, app.Manifest{}, runner)
+	plan := []InstallInstruction{{Type: ServiceLaunchd, Package: "com.example.agent"}}
+	if err := prov.ExecutePlan(plan); err != nil {
+		t.Fatalf("ExecutePlan error: %v", err)
+	}
+	for _, want := range []string{"sudo launchctl enable system/com.example.agent", "sudo launchctl kickstart -k system/com.example.agent"} {
+		if !slices.Contains(runner.Commands, want) {
+			t.Errorf("expected %q in %v", want, runner.Commands)
+		}
+	}
+	if got, want := ShellCommand(InstallInstruction{Type: ServiceSystemd, Package: "docker"}), "sudo systemctl enable --now docker"; got != want {
+		t.Errorf("ShellCommand = %q, want %q", got, want)
+	}
+}
+
+func TestPlanProvision_ServiceOptions(t *testing.T) {
+	yes, no := true, false
+	manifest := app.Manifest{
+		"samba": {Apt: app.StringOrSlice{"samba"}, Dnf: app.StringOrSlice{"samba"}, Service: app.Services{
+			{Name: "smbd", Sudo: &yes, Pkg: "apt"},
+			{Name: "smb", Sudo: &yes, Pkg: "dnf"},
+		}},
+		"openssh-server": {Apt: app.StringOrSlice{"openssh-server"}, Service: app.Services{{Name: "sshd", Sudo: &no}}},
+		"colima":         {Brew: app.StringOrSlice{"colima"}, Service: app.Services{{Name: "colima", Sudo: &yes}}},
+	}
+	cases := []struct {
+		name  string
+		sys   SystemInfo
+		order []string
+		key   string
+		want  []string
+	}{
+		{"pkg apt", staticSystemInfo{os: "linux", id: "ubuntu"}, []string{"apt", "dnf"}, "samba", []string{"apt samba", "sudo systemctl enable --now smbd"}},
+		{"pkg dnf", staticSystemInfo{os: "linux", id: "fedora"}, []string{"dnf", "apt"}, "samba", []string{"dnf samba", "sudo systemctl enable --now smb"}},
+		{"no sudo", staticSystemInfo{os: "linux", id: "ubuntu"}, []string{"apt"}, "openssh-server", []string{"apt openssh-server", "systemctl enable --now sshd"}},
+		{"brew with sudo", staticSystemInfo{os: "darwin", id: "darwin"}, []string{"brew"}, "colima", []string{"brew colima", "sudo brew services start colima"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			prov := NewProvisioner(tc.sys, manifest, &fakeExecRunner{})
+			prov.InstallerOrder = tc.order
+			prov.initName = func() string { return "systemd" }
+			plan, err := prov.PlanProvision([]string{tc.key}, nil)
+			if err != nil {
+				t.Fatalf("PlanProvision error: %v", err)
+			}
+			var got []string
+			for _, inst := range plan {
+				if IsServiceInstruction(inst.Type) {
+					got = append(got, ShellCommand(inst))
+				} else {
+					got = append(got, inst.Type+" "+inst.Package)
+				}
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got plan %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package provision
+
+import (
+	"bufio"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"a-la-carte/internal/app"
+)
+
+// osReleasePaths are the os-release files read for the distribution, in order.
+var osReleasePaths = []string{"/etc/os-release", "/usr/lib/os-release"}
+
+// wslReleasePath holds the kernel release, which mentions Microsoft under WSL.
+const wslReleasePath = "/proc/sys/kernel/osrelease"
+
+// RealSystemInfo is the SystemInfo of the running host, as returned by DetectSystem.
+//
+// # Fields
+//   - GOOS:     The operating system, e.g. "linux" or "darwin"
+//   - GOARCH:   The architecture, e.g. "amd64" or "arm64"
+//   - DistroID: The ID from os-release (e.g. "ubuntu"), or GOOS if there is none
+//   - IDLike:   The ID_LIKE entries from os-release, e.g. ["debian"]
+//   - WSL:      Whether the host is Windows Subsystem for Linux
+//   - Headless: Whether there is no graphical session
+//
+// Arch reports the canonical manifest identifier of GOARCH, e.g. "x64" for amd64.
+type RealSystemInfo struct {
+	GOOS     string
+	GOARCH   string
+	DistroID string
+	IDLike   []string
+	WSL      bool
+	Headless bool
+}
+
+func (s *RealSystemInfo) OS() string       { return s.GOOS }
+func (s *RealSystemInfo) Arch() string     { return app.CanonicalArch(s.GOARCH) }
+func (s *RealSystemInfo) ID() string       { return s.DistroID }
+func (s *RealSystemInfo) IsHeadless() bool { return s.Headless }
+
+// IsWSL reports whether the host is Windows Subsystem for Linux.
+func (s *RealSystemInfo) IsWSL() bool { return s.WSL }
+
+// DetectSystem detects the running host: the OS and architecture from the Go runtime,
+// the distribution from /etc/os-release, WSL from the kernel release, and headless
+// sessions from the display and SSH environment variables.
+//
+// # Example
+//
+//	prov := NewProvisioner(DetectSystem(), manifest, runner)
+func DetectSystem() *RealSystemInfo {
+	return detectSystem(runtime.GOOS, runtime.GOARCH, os.Getenv, os.ReadFile)
+}
+
+// detectSystem implements DetectSystem with injectable sources for tests.
+func detectSystem(goos, goarch string, getenv func(string) string, readFile func(string) ([]byte, error)) *RealSystemInfo {
+	sys := &RealSystemInfo{GOOS: goos, GOARCH: goarch, DistroID: goos}
+	if goos == "linux" {
+		for _, path := range osReleasePaths {
+			data, err := readFile(path)
+			if err != nil {
+				continue
+			}
+			release := parseOSRelease(string(data))
+			if id := release["ID"]; id != "" {
+				sys.DistroID = id
+			}
+			sys.IDLike = strings.Fields(release["ID_LIKE"])
+			break
+		}
+		if getenv("WSL_DISTRO_NAME") != "" || getenv("WSL_INTEROP") != "" {
+			sys.WSL = true
+		} else if data, err := readFile(wslReleasePath); err == nil {
+			sys.WSL = strings.Contains(strings.ToLower(string(data)), "microsoft")
+		}
+	}
+	sys.Headless = isHeadless(goos, getenv)
+	return sys
+}
+
+// isHeadless reports whether there is no graphical session to install GUI apps for.
+// On Linux that means no X11 or Wayland display; elsewhere, a remote SSH session.
+func isHeadless(goos string, getenv func(string) string) bool {
+	switch goos {
+	case "windows":
+		return false
+	case "darwin":
+		return getenv("SSH_CONNECTION") != "" || getenv("SSH_TTY") != ""
+	default:
+		return getenv("DISPLAY") == "" && getenv("WAYLAND_DISPLAY") == ""
+	}
+}
+
+// parseOSRelease parses the KEY=value lines of an os-release file, unquoting values.
+func parseOSRelease(data string) map[string]string {
+	values := make(map[string]string)
+	scan := bufio.NewScanner(strings.NewReader(data))
+	for scan.Scan() {
+		line := strings.TrimSpace(scan.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, `'"`)
+		}
+		values[key] = value
+	}
+	return values
+}
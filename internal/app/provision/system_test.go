@@ -0,0 +1,67 @@
+package provision
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func fakeEnv(vars map[string]string) func(string) string {
+	return func(k string) string { return vars[k] }
+}
+
+func fakeFiles(files map[string]string) func(string) ([]byte, error) {
+	return func(path string) ([]byte, error) {
+		if data, ok := files[path]; ok {
+			return []byte(data), nil
+		}
+		return nil, errors.New("no such file")
+	}
+}
+
+func TestDetectSystem_Linux(t *testing.T) {
+	files := fakeFiles(map[string]string{
+		"/etc/os-release":            "NAME=\"Ubuntu\"\nID=ubuntu\nID_LIKE=debian\n# comment\nVERSION_ID=\"24.04\"\n",
+		"/proc/sys/kernel/osrelease": "6.8.0-45-generic\n",
+	})
+	sys := detectSystem("linux", "amd64", fakeEnv(map[string]string{"DISPLAY": ":0"}), files)
+	if sys.OS() != "linux" || sys.Arch() != "x64" || sys.ID() != "ubuntu" {
+		t.Errorf("unexpected system %+v", sys)
+	}
+	if !slices.Equal(sys.IDLike, []string{"debian"}) {
+		t.Errorf("expected ID_LIKE [debian], got %v", sys.IDLike)
+	}
+	if sys.IsWSL() || sys.IsHeadless() {
+		t.Errorf("expected a desktop, non-WSL system, got %+v", sys)
+	}
+}
+
+func TestDetectSystem_WSLAndHeadless(t *testing.T) {
+	files := fakeFiles(map[string]string{
+		"/usr/lib/os-release":        "ID='debian'\n",
+		"/proc/sys/kernel/osrelease": "5.15.153.1-microsoft-standard-WSL2\n",
+	})
+	sys := detectSystem("linux", "arm64", fakeEnv(nil), files)
+	if sys.ID() != "debian" {
+		t.Errorf("expected ID from /usr/lib/os-release, got %q", sys.ID())
+	}
+	if !sys.IsWSL() {
+		t.Error("expected WSL to be detected from the kernel release")
+	}
+	if !sys.IsHeadless() {
+		t.Error("expected a system without a display to be headless")
+	}
+	if sys := detectSystem("linux", "amd64", fakeEnv(map[string]string{"WSL_DISTRO_NAME": "Ubuntu"}), fakeFiles(nil)); !sys.IsWSL() {
+		t.Error("expected WSL to be detected from WSL_DISTRO_NAME")
+	}
+}
+
+func TestDetectSystem_Darwin(t *testing.T) {
+	sys := detectSystem("darwin", "arm64", fakeEnv(nil), fakeFiles(nil))
+	if sys.ID() != "darwin" || sys.IsHeadless() || sys.IsWSL() {
+		t.Errorf("unexpected darwin system %+v", sys)
+	}
+	if sys := detectSystem("darwin", "arm64", fakeEnv(map[string]string{"SSH_CONNECTION": "10.0.0.1 22 10.0.0.2 22"}), fakeFiles(nil)); !sys.IsHeadless() {
+		t.Error("expected an SSH session on darwin to be headless")
+	}
+}
@@ -0,0 +1,79 @@
+package provision
+
+import (
+	"os"
+	"os/exec"
+	"os/user"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the chezmoi template functions available to the built-in renderer.
+var templateFuncs = template.FuncMap{
+	"env": os.Getenv,
+	"lookPath": func(file string) (string, error) {
+		path, err := exec.LookPath(file)
+		if err != nil {
+			return "", nil // chezmoi returns an empty string for missing executables
+		}
+		return path, nil
+	},
+	"contains":  func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix": func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix": func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"lower":     strings.ToLower,
+	"upper":     strings.ToUpper,
+	"trim":      strings.TrimSpace,
+}
+
+// ChezmoiTemplateData returns the template data tree the built-in renderer exposes,
+// a subset of chezmoi's: .chezmoi.os, .chezmoi.arch, .chezmoi.hostname,
+// .chezmoi.username, .chezmoi.homeDir and .chezmoi.osRelease.id/idLike.
+func ChezmoiTemplateData(sys *RealSystemInfo) map[string]any {
+	hostname, _ := os.Hostname()
+	hostname, _, _ = strings.Cut(hostname, ".")
+	homeDir, _ := os.UserHomeDir()
+	username := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+	chezmoi := map[string]any{
+		"os":       sys.GOOS,
+		"arch":     sys.GOARCH,
+		"hostname": hostname,
+		"username": username,
+		"homeDir":  homeDir,
+	}
+	if sys.GOOS == "linux" {
+		chezmoi["osRelease"] = map[string]any{
+			"id":     sys.DistroID,
+			"idLike": strings.Join(sys.IDLike, " "),
+		}
+	}
+	return map[string]any{"chezmoi": chezmoi}
+}
+
+// RenderTemplate renders text with ExecuteTemplate and the data of the system it runs
+// on. It is the app.TemplateRenderer of manifest templates when chezmoi is not installed.
+func RenderTemplate(text string) (string, error) {
+	return ExecuteTemplate(text, ChezmoiTemplateData(DetectSystem()))
+}
+
+// ExecuteTemplate renders a manifest script with Go's text/template, as a fallback for
+// chezmoi execute-template on machines without chezmoi. Scripts that only use the data
+// of ChezmoiTemplateData and the functions above render the same as with chezmoi.
+//
+// # Returns
+//   - string: The rendered script
+//   - error:  If the template does not parse or fails to render
+func ExecuteTemplate(text string, data map[string]any) (string, error) {
+	tmpl, err := template.New("script").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
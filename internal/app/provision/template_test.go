@@ -0,0 +1,35 @@
+package provision
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestExecuteTemplate(t *testing.T) {
+	data := ChezmoiTemplateData(&RealSystemInfo{GOOS: "linux", GOARCH: "arm64", DistroID: "ubuntu", IDLike: []string{"debian"}})
+	script := `{{ if eq .chezmoi.os "linux" }}curl -o tool-{{ .chezmoi.arch }} {{ .chezmoi.osRelease.id }}{{ end }}` +
+		`{{ if hasPrefix "arm" .chezmoi.arch }} arm{{ end }}`
+	got, err := ExecuteTemplate(script, data)
+	if err != nil {
+		t.Fatalf("ExecuteTemplate error: %v", err)
+	}
+	if want := "curl -o tool-arm64 ubuntu arm"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := ExecuteTemplate("{{ .chezmoi.missing.key }}", data); err == nil {
+		t.Error("expected an error for a key that is not in the data")
+	}
+	if _, err := ExecuteTemplate("{{ if }}", data); err == nil {
+		t.Error("expected a parse error")
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	got, err := RenderTemplate("{{ .chezmoi.os }}/{{ .chezmoi.arch }}")
+	if err != nil {
+		t.Fatalf("RenderTemplate error: %v", err)
+	}
+	if want := runtime.GOOS + "/" + runtime.GOARCH; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,149 @@
+package provision
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// UpgradePrefix starts the type of an instruction that upgrades an installed package,
+// followed by the installer, e.g. "upgrade:apt". Its Package is the installed package.
+const UpgradePrefix = "upgrade:"
+
+// upgradeCommands return the command that upgrades an installed package, per installer.
+// Installers that are not listed (scripts, binary downloads, go, ...) cannot upgrade.
+var upgradeCommands = map[string]func(pkg string) []string{
+	"apt": func(pkg string) []string {
+		return []string{"sudo", "env", "DEBIAN_FRONTEND=noninteractive", "apt-get", "install", "--only-upgrade", "-y", pkg}
+	},
+	"apk":     func(pkg string) []string { return []string{"sudo", "apk", "upgrade", "--no-cache", pkg} },
+	"dnf":     func(pkg string) []string { return []string{"sudo", "dnf", "upgrade", "-y", pkg} },
+	"yum":     func(pkg string) []string { return []string{"sudo", "yum", "update", "-y", pkg} },
+	"zypper":  func(pkg string) []string { return []string{"sudo", "zypper", "--non-interactive", "update", pkg} },
+	"pacman":  func(pkg string) []string { return []string{"sudo", "pacman", "-S", "--noconfirm", pkg} },
+	"brew":    func(pkg string) []string { return []string{"brew", "upgrade", pkg} },
+	"cask":    func(pkg string) []string { return []string{"brew", "upgrade", "--cask", pkg} },
+	"flatpak": func(pkg string) []string { return []string{"flatpak", "update", "-y", pkg} },
+	"snap":    func(pkg string) []string { return []string{"sudo", "snap", "refresh", pkg} },
+	"pipx":    func(pkg string) []string { return []string{"pipx", "upgrade", pkg} },
+	"cargo":   func(pkg string) []string { return []string{"cargo", "install", pkg} }, // reinstalls only if newer
+	"mas":     func(pkg string) []string { return []string{"mas", "upgrade", pkg} },
+	"scoop":   func(pkg string) []string { return []string{"scoop", "update", pkg} },
+	"choco":   func(pkg string) []string { return []string{"choco", "upgrade", "-y", pkg} },
+}
+
+// installerOf returns the installer of an instruction type, without UpgradePrefix,
+// DownloadPrefix or OfflinePrefix.
+func installerOf(instType string) string {
+	for _, prefix := range []string{UpgradePrefix, DownloadPrefix, OfflinePrefix} {
+		if installer, ok := strings.CutPrefix(instType, prefix); ok {
+			return installer
+		}
+	}
+	return instType
+}
+
+// UpgradeCommand returns the command of an upgrade instruction, or nil if the
+// instruction is not an upgrade.
+//
+// # Example
+//
+//	UpgradeCommand(InstallInstruction{Type: "upgrade:brew", Package: "bat"}) // ["brew", "upgrade", "bat"]
+func UpgradeCommand(inst InstallInstruction) []string {
+	installer, ok := strings.CutPrefix(inst.Type, UpgradePrefix)
+	if !ok {
+		return nil
+	}
+	if cmd, ok := upgradeCommands[installer]; ok {
+		return cmd(inst.Package)
+	}
+	return nil
+}
+
+// packageInstruction returns the package manager instruction PlanProvision would plan
+// for key, without scripts, repositories, services and hooks.
+func (p *Provisioner) packageInstruction(key string) (InstallInstruction, bool) {
+	entry := p.Manifest[key]
+	var insts []InstallInstruction
+	p.addInstallerInstruction(key, &entry, &insts)
+	if len(insts) == 0 {
+		return InstallInstruction{}, false
+	}
+	insts[0].Key = key
+	return insts[0], true
+}
+
+// PlanUpgrade plans upgrades of the selected keys, and the deps they pull in, that are
+// already installed. Each key is upgraded with the installer PlanProvision would install
+// it with; keys whose installer cannot upgrade (e.g. scripts) are skipped with a log
+// line, and keys that are not installed are left alone.
+//
+// # Parameters
+//   - keys:      The selected manifest keys
+//   - installed: Installed packages, as returned by GetInstalledPackages
+//
+// # Returns
+//   - []InstallInstruction: One upgrade instruction per installed key
+//   - error:                If a selected key or (with StrictDeps) a dep is not in the manifest
+func (p *Provisioner) PlanUpgrade(keys []string, installed map[string]bool) ([]InstallInstruction, error) {
+	if p.Runner != nil {
+		_ = p.Runner.Run("section", "Planning")
+	}
+	p.Warnings = nil
+	p.PlanReport = &PlanReport{}
+	p.installed = installed
+	expanded, err := p.expandDeps(p.preferByPriority(keys), "", make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+	var plan []InstallInstruction
+	for _, key := range expanded {
+		if !p.shouldSkipInstalled(key, installed) {
+			continue
+		}
+		inst, ok := p.packageInstruction(key)
+		if !ok || upgradeCommands[inst.Type] == nil {
+			p.skip(key, SkipNotUpgradable, "it cannot be upgraded by a package manager")
+			continue
+		}
+		p.decide(KeyDecision{Key: key, Planned: true})
+		plan = append(plan, InstallInstruction{Type: UpgradePrefix + inst.Type, Package: inst.Package, Key: key})
+	}
+	if p.Runner != nil {
+		for _, inst := range plan {
+			_ = p.Runner.Run("info", fmt.Sprintf("Will upgrade: %s %s", installerOf(inst.Type), inst.Package))
+		}
+	}
+	return plan, nil
+}
+
+// VersionChange is a package whose installed version changed during a run.
+//
+// # Fields
+//   - Key:      The manifest key
+//   - Package:  The package name
+//   - From, To: The installed version before and after the run
+type VersionChange struct {
+	Key     string
+	Package string
+	From    string
+	To      string
+}
+
+// String returns the change as "key: from → to".
+func (c VersionChange) String() string {
+	return fmt.Sprintf("%s: %s → %s", c.Key, c.From, c.To)
+}
+
+// ChangedVersions compares the versions recorded with RecordVersions before and after
+// a run, and returns the packages whose version changed, sorted by key.
+func ChangedVersions(before, after *Lockfile) []VersionChange {
+	var changes []VersionChange
+	for key, now := range after.Packages {
+		if was, ok := before.lookup(key); ok && was.Version != now.Version {
+			changes = append(changes, VersionChange{Key: key, Package: now.Package, From: was.Version, To: now.Version})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
@@ -0,0 +1,71 @@
+package provision
+
+import (
+	"slices"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestPlanUpgrade(t *testing.T) {
+	manifest := app.Manifest{
+		"app":     {Apt: app.StringOrSlice{"app"}, Deps: []string{"lib"}},
+		"lib":     {Apt: app.StringOrSlice{"libfoo"}},
+		"bat":     {Brew: app.StringOrSlice{"bat"}},
+		"custom":  {Script: app.StringOrSlice{"curl example.com | sh"}},
+		"missing": {Apt: app.StringOrSlice{"missing"}},
+	}
+	prov := NewProvisioner(nil, manifest, nil)
+	prov.InstallerOrder = []string{"apt", "brew"}
+	installed := map[string]bool{"app": true, "lib": true, "bat": true, "custom": true}
+	plan, err := prov.PlanUpgrade([]string{"app", "bat", "custom", "missing"}, installed)
+	if err != nil {
+		t.Fatalf("PlanUpgrade error: %v", err)
+	}
+	want := []InstallInstruction{
+		{Type: "upgrade:apt", Package: "libfoo", Key: "lib"},
+		{Type: "upgrade:apt", Package: "app", Key: "app"},
+		{Type: "upgrade:brew", Package: "bat", Key: "bat"},
+	}
+	if !slices.Equal(plan, want) {
+		t.Errorf("got plan %+v, want %+v", plan, want)
+	}
+
+	runner := &fakeExecRunner{}
+	prov.Runner = runner
+	if err := prov.ExecutePlan(plan); err != nil {
+		t.Fatalf("ExecutePlan error: %v", err)
+	}
+	for _, cmd := range []string{
+		"sudo env DEBIAN_FRONTEND=noninteractive apt-get install --only-upgrade -y libfoo",
+		"brew upgrade bat",
+	} {
+		if !slices.Contains(runner.Commands, cmd) {
+			t.Errorf("expected command %q, got %v", cmd, runner.Commands)
+		}
+	}
+	if got := ShellCommand(InstallInstruction{Type: "upgrade:flatpak", Package: "org.gimp.GIMP"}); got != "flatpak update -y org.gimp.GIMP" {
+		t.Errorf("unexpected shell command %q", got)
+	}
+}
+
+func TestChangedVersions(t *testing.T) {
+	before := &Lockfile{Packages: map[string]LockedPackage{
+		"bat": {"brew", "bat", "0.23.0"},
+		"jq":  {"apt", "jq", "1.6"},
+	}}
+	runner := &fakeOutputRunner{outputs: map[string][]byte{
+		"brew list --versions bat":       []byte("bat 0.24.0"),
+		"dpkg-query -W -f=${Version} jq": []byte("1.6"),
+	}}
+	prov := NewProvisioner(nil, app.Manifest{}, runner)
+	after := &Lockfile{}
+	prov.RecordVersions([]InstallInstruction{
+		{Type: "upgrade:brew", Package: "bat", Key: "bat"},
+		{Type: "upgrade:apt", Package: "jq", Key: "jq"},
+	}, after)
+	changes := ChangedVersions(before, after)
+	if len(changes) != 1 || changes[0].String() != "bat: 0.23.0 → 0.24.0" {
+		t.Errorf("unexpected changes %v", changes)
+	}
+}
@@ -0,0 +1,49 @@
+package provision
+
+import (
+	"strconv"
+
+	"a-la-carte/internal/app"
+)
+
+// whenVars returns the values of the _when variables (app.WhenVariables) on the
+// provisioner's system. Without system information only headless is set.
+func (p *Provisioner) whenVars() map[string]string {
+	headless := p.ForceHeadless
+	vars := map[string]string{}
+	if p.System != nil {
+		vars["os"] = p.System.OS()
+		vars["arch"] = p.System.Arch()
+		vars["id"] = p.System.ID()
+		headless = headless || p.System.IsHeadless()
+	}
+	vars["headless"] = strconv.FormatBool(headless)
+	return vars
+}
+
+// conditionMet reports whether the entry's _when condition holds on this system.
+// Entries without a condition always apply, and so do entries whose _when does not
+// parse, such as the shell tests (test -f /proc/version) of older manifests: the
+// condition is ignored, and the error says why.
+//
+// # Returns
+//   - bool:  True if the entry applies here
+//   - error: If the condition is malformed and was ignored
+func (p *Provisioner) conditionMet(entry *app.SoftwareEntry) (bool, error) {
+	if entry.When == "" {
+		return true, nil
+	}
+	when, err := app.ParseWhen(entry.When)
+	if err != nil {
+		return true, err
+	}
+	return when.Eval(p.whenVars()), nil
+}
+
+// Applies reports whether the key's _when condition holds on this system. Keys without
+// a condition, or with a malformed one, apply.
+func (p *Provisioner) Applies(key string) bool {
+	entry := p.Manifest[key]
+	applies, _ := p.conditionMet(&entry)
+	return applies
+}
@@ -0,0 +1,73 @@
+package provision
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"a-la-carte/internal/app"
+)
+
+func TestPlanProvisionWhen(t *testing.T) {
+	manifest := app.Manifest{
+		"tool":     {Apt: app.StringOrSlice{"tool"}, When: `os == "linux" && arch == "amd64"`},
+		"mac-only": {Apt: app.StringOrSlice{"mac-only"}, Deps: app.StringOrSlice{"helper"}, When: `os == "darwin"`},
+		"helper":   {Apt: app.StringOrSlice{"helper"}},
+		"desktop":  {Apt: app.StringOrSlice{"desktop"}, When: "headless == false"},
+	}
+	prov := NewProvisioner(&fakeSystemInfo{headless: true}, manifest, &fakeExecRunner{})
+	plan, err := prov.PlanProvision([]string{"tool", "mac-only", "desktop"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	if len(plan) != 1 || plan[0].Package != "tool" {
+		t.Errorf("expected only tool to be planned, got %+v", plan)
+	}
+	var skipped []string
+	for _, d := range prov.PlanReport.Skipped() {
+		if d.Reason == SkipCondition {
+			skipped = append(skipped, d.Key)
+		}
+	}
+	if !slices.Equal(skipped, []string{"mac-only", "desktop"}) {
+		t.Errorf("got keys skipped by _when %v, want [mac-only desktop]", skipped)
+	}
+	if ok, reason := prov.Installability("mac-only"); ok || !strings.Contains(reason, `_when os == "darwin"`) {
+		t.Errorf("expected mac-only to be uninstallable by its condition, got %v %q", ok, reason)
+	}
+
+	// Conditions that do not parse, e.g. the shell tests of older manifests, are ignored
+	// with a warning instead of failing the plan
+	manifest["shell-test"] = app.SoftwareEntry{Apt: app.StringOrSlice{"shell-test"}, When: "test -f /proc/version && ! test -d /Applications"}
+	plan, err = prov.PlanProvision([]string{"shell-test"}, nil)
+	if err != nil || len(plan) != 1 || plan[0].Package != "shell-test" {
+		t.Fatalf("expected shell-test to be planned, got %+v, %v", plan, err)
+	}
+	if len(prov.Warnings) != 1 || !strings.Contains(prov.Warnings[0].Message, "shell-test: ignoring invalid _when") {
+		t.Errorf("expected a warning about the ignored condition, got %+v", prov.Warnings)
+	}
+	if !prov.Applies("shell-test") {
+		t.Error("expected an entry with an ignored condition to apply")
+	}
+}
+
+// TestPlanProvisionWhen_ShippedManifest plans entries of the manifest shipped in data/,
+// whose _when conditions are shell tests.
+func TestPlanProvisionWhen_ShippedManifest(t *testing.T) {
+	manifest, err := app.LoadManifest("../../../data/package-metadata.yaml")
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	prov := NewProvisioner(&fakeSystemInfo{}, manifest, &fakeExecRunner{})
+	prov.InstallerOrder = []string{"apt"}
+	plan, err := prov.PlanProvision([]string{"openssh-server"}, nil)
+	if err != nil {
+		t.Fatalf("PlanProvision error: %v", err)
+	}
+	if len(plan) == 0 || plan[0].Package != "openssh-server" {
+		t.Errorf("expected openssh-server to be planned, got %+v", plan)
+	}
+	if len(prov.Warnings) != 1 || prov.Warnings[0].Key != "openssh-server" {
+		t.Errorf("expected a warning about the shell test, got %+v", prov.Warnings)
+	}
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxManifestSize bounds the size of a downloaded manifest.
+const maxManifestSize = 16 << 20
+
+// downloadManifest fetches a manifest from url and checks that it parses.
+func downloadManifest(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", url, err)
+	}
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest at %s: %w", url, err)
+	}
+	return data, nil
+}
+
+// FetchManifest downloads the manifest at url into cachePath, replacing the cached copy
+// only once the download is complete and parses as a manifest.
+//
+// # Parameters
+//   - ctx:       Bounds the download
+//   - url:       The remote manifest URL
+//   - cachePath: Where the manifest is cached
+//
+// # Returns
+//   - error: if the download fails, the manifest is invalid or the cache cannot be written
+func FetchManifest(ctx context.Context, url, cachePath string) error {
+	data, err := downloadManifest(ctx, url)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(cachePath), ".manifest-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), cachePath)
+}
+
+// ManifestUpdateAvailable reports whether the manifest at url differs from the cached copy.
+// A missing cache counts as an available update.
+func ManifestUpdateAvailable(ctx context.Context, url, cachePath string) (bool, error) {
+	remote, err := downloadManifest(ctx, url)
+	if err != nil {
+		return false, err
+	}
+	cached, err := os.ReadFile(cachePath)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(remote, cached), nil
+}
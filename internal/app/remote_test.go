@@ -0,0 +1,65 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFetchManifestAndUpdateCheck(t *testing.T) {
+	body := "bat:\n  brew: bat\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	cachePath := filepath.Join(t.TempDir(), "cache", "manifest.yaml")
+	ctx := context.Background()
+
+	if available, err := ManifestUpdateAvailable(ctx, srv.URL, cachePath); err != nil || !available {
+		t.Fatalf("expected an update without a cache, got %v, %v", available, err)
+	}
+	if err := FetchManifest(ctx, srv.URL, cachePath); err != nil {
+		t.Fatalf("FetchManifest failed: %v", err)
+	}
+	m, err := LoadManifest(cachePath)
+	if err != nil || len(m["bat"].Brew) != 1 {
+		t.Fatalf("expected cached manifest with bat, got %v, %v", m, err)
+	}
+	if available, err := ManifestUpdateAvailable(ctx, srv.URL, cachePath); err != nil || available {
+		t.Errorf("expected no update for an unchanged manifest, got %v, %v", available, err)
+	}
+
+	body = "bat:\n  brew: bat\nfd:\n  brew: fd\n"
+	if available, err := ManifestUpdateAvailable(ctx, srv.URL, cachePath); err != nil || !available {
+		t.Errorf("expected an update after the manifest changed, got %v, %v", available, err)
+	}
+}
+
+func TestFetchManifest_KeepsCacheOnInvalidManifest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("not: [valid"))
+	}))
+	defer srv.Close()
+	cachePath := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(cachePath, []byte("bat: {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := FetchManifest(context.Background(), srv.URL, cachePath); err == nil {
+		t.Fatal("expected error for an invalid manifest")
+	}
+	data, err := os.ReadFile(cachePath)
+	if err != nil || string(data) != "bat: {}\n" {
+		t.Errorf("expected the cache to be untouched, got %q, %v", data, err)
+	}
+}
+
+func TestFetchManifest_HTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	if err := FetchManifest(context.Background(), srv.URL, filepath.Join(t.TempDir(), "m.yaml")); err == nil {
+		t.Error("expected error for a 404 response")
+	}
+}
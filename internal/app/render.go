@@ -0,0 +1,55 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ManifestTemplateExt is the extension of manifests that are chezmoi templates, e.g.
+// software.yml.tmpl. They are rendered before they are parsed, so entries can vary by
+// machine, e.g. with {{ if eq .chezmoi.hostname "work" }}.
+const ManifestTemplateExt = ".tmpl"
+
+// IsManifestTemplate reports whether the manifest file at path is a chezmoi template.
+func IsManifestTemplate(path string) bool {
+	return strings.HasSuffix(path, ManifestTemplateExt)
+}
+
+// TemplateRenderer renders the text of a template; it renders manifest templates when
+// chezmoi is not installed (see provision.RenderTemplate).
+type TemplateRenderer func(text string) (string, error)
+
+// readManifest returns the contents of the manifest file at path, rendered if it is a
+// template: with chezmoi execute-template, which knows the data of the chezmoi config,
+// or with render when chezmoi is not installed.
+func readManifest(path string, render TemplateRenderer) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !IsManifestTemplate(path) {
+		return data, err
+	}
+	if _, err := exec.LookPath("chezmoi"); err != nil {
+		if render == nil {
+			return nil, fmt.Errorf("rendering %s: chezmoi is not installed", path)
+		}
+		out, err := render(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("rendering %s: %w", path, err)
+		}
+		return []byte(out), nil
+	}
+	cmd := exec.Command("chezmoi", "execute-template")
+	cmd.Stdin = bytes.NewReader(data)
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			err = errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("rendering %s with chezmoi: %w", path, err)
+	}
+	return out, nil
+}
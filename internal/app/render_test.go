@@ -0,0 +1,76 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const templateManifest = "bat:\n  _name: bat\n{{ if eq .chezmoi.hostname \"work\" }}  apt: batcat\n{{ else }}  brew: bat\n{{ end }}"
+
+func TestLoadManifestTemplate(t *testing.T) {
+	dir := t.TempDir()
+	path := writeManifest(t, dir, "software.yml.tmpl", templateManifest)
+	t.Setenv("PATH", t.TempDir()) // no chezmoi
+
+	// Without chezmoi the source's renderer renders the template
+	render := func(text string) (string, error) {
+		if text != templateManifest {
+			return "", errors.New("unexpected template")
+		}
+		return "bat:\n  _name: bat\n  apt: batcat\n", nil
+	}
+	m, err := LoadManifests([]ManifestSource{{Path: path, Render: render}})
+	if err != nil || len(m["bat"].Apt) != 1 || len(m["bat"].Brew) != 0 {
+		t.Fatalf("expected the rendered entry, got %+v %v", m, err)
+	}
+	if _, err := LoadManifest(path); err == nil || !strings.Contains(err.Error(), "chezmoi is not installed") {
+		t.Errorf("expected a template to need chezmoi without a renderer, got %v", err)
+	}
+	failing := func(string) (string, error) { return "", errors.New("bad template") }
+	if _, err := LoadManifests([]ManifestSource{{Path: path, Render: failing}}); err == nil || !strings.Contains(err.Error(), "rendering "+path+": bad template") {
+		t.Errorf("expected the render error with the path, got %v", err)
+	}
+
+	// Other manifests are parsed as they are
+	plain := writeManifest(t, dir, "software.yml", "bat:\n  brew: bat\n")
+	if m, err := LoadManifests([]ManifestSource{{Path: plain, Render: failing}}); err != nil || len(m["bat"].Brew) != 1 {
+		t.Errorf("expected a plain manifest not to be rendered, got %+v %v", m, err)
+	}
+
+	// Templates are not edited in place, as their directives would be lost
+	if err := SaveEntry(path, "bat", EntryEdit{Key: "bat", Installers: []InstallerValue{{Installer: "apt", Package: "bat"}}}); err == nil || !strings.Contains(err.Error(), "is a template") {
+		t.Errorf("expected saving into a template to be refused, got %v", err)
+	}
+}
+
+func TestLoadManifestTemplateChezmoi(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake chezmoi is a shell script")
+	}
+	bin := t.TempDir()
+	// The fake chezmoi renders the template as if the hostname were "work", and fails
+	// like chezmoi on a broken one
+	script := "#!/bin/sh\n[ \"$1\" = execute-template ] || exit 2\n" +
+		"case \"$(cat)\" in *broken*) echo 'template: stdin:1: unexpected EOF' >&2; exit 1;; esac\n" +
+		"printf 'bat:\\n  apt: batcat\\n'\n"
+	if err := os.WriteFile(filepath.Join(bin, "chezmoi"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := t.TempDir()
+	path := writeManifest(t, dir, "software.yml.tmpl", templateManifest)
+	m, err := LoadManifestStrict(path)
+	if err != nil || len(m["bat"].Apt) != 1 || m["bat"].Apt[0] != "batcat" {
+		t.Fatalf("expected the manifest rendered by chezmoi, got %+v %v", m, err)
+	}
+
+	broken := writeManifest(t, dir, "broken.yml.tmpl", "{{ broken")
+	if _, err := LoadManifest(broken); err == nil || !strings.Contains(err.Error(), "with chezmoi: template: stdin:1: unexpected EOF") {
+		t.Errorf("expected chezmoi's error, got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"a-la-carte/internal/schema"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnknownField is a key of a manifest entry that SoftwareEntry does not declare, e.g.
+// the misspelled _decs, which LoadManifest silently ignores.
+//
+// # Fields
+//   - Path:         The manifest file
+//   - Line, Column: Where the key is in the file
+//   - Entry:        The manifest key of the entry, with the sections the key is in,
+//     e.g. "bat" or "bat._sandbox"
+//   - Key:          The unknown key
+//   - Suggestion:   A declared key it is likely a misspelling of, or empty
+type UnknownField struct {
+	Path       string
+	Line       int
+	Column     int
+	Entry      string
+	Key        string
+	Suggestion string
+}
+
+// String returns the field as "path:line:column: unknown key <key> in <entry>", like
+// compiler errors, so editors can jump to it.
+func (f UnknownField) String() string {
+	s := fmt.Sprintf("%s:%d:%d: unknown key %s in %s", f.Path, f.Line, f.Column, f.Key, f.Entry)
+	if f.Suggestion != "" {
+		s += " (did you mean " + f.Suggestion + "?)"
+	}
+	return s
+}
+
+// UnknownFieldsError is the error of LoadManifestStrict for a manifest with keys
+// SoftwareEntry does not declare.
+type UnknownFieldsError struct {
+	Fields []UnknownField
+}
+
+// Error lists the unknown keys, one per line.
+func (e *UnknownFieldsError) Error() string {
+	lines := []string{fmt.Sprintf("%d unknown keys in manifest entries:", len(e.Fields))}
+	if len(e.Fields) == 1 {
+		lines[0] = "unknown key in a manifest entry:"
+	}
+	for _, f := range e.Fields {
+		lines = append(lines, "  "+f.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// LoadManifestStrict loads a manifest like LoadManifest, but rejects keys of entries
+// that SoftwareEntry does not declare instead of ignoring them, so typos such as _decs
+// for _desc are caught. Installer keys with qualifiers, such as apt:debian:x64, are
+// declared (see SoftwareEntry.Installers).
+//
+// # Parameters
+//   - path: the path to the YAML manifest file
+//
+// # Returns
+//   - Manifest: the loaded manifest
+//   - error: an *UnknownFieldsError listing every unknown key with its location (in
+//     the rendered manifest for a template), or the error of LoadManifest
+//
+// # Example
+//
+//	m, err := LoadManifestStrict("software.yml")
+//	var unknown *UnknownFieldsError
+//	if errors.As(err, &unknown) {
+//		for _, f := range unknown.Fields {
+//			fmt.Println(f) // software.yml:12:3: unknown key _decs in bat (did you mean _desc?)
+//		}
+//	}
+func LoadManifestStrict(path string) (Manifest, error) {
+	return ManifestSource{Path: path, Strict: true}.load()
+}
+
+// parseManifestStrict decodes the manifest data read from path like ParseManifest and
+// returns an *UnknownFieldsError for the keys SoftwareEntry does not declare.
+func parseManifestStrict(path string, data []byte) (Manifest, error) {
+	// The decoder's KnownFields does not reach into SoftwareEntry.UnmarshalYAML, so the
+	// keys are checked on the parsed document, which also gives their columns
+	var root yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&root); err != nil {
+		return nil, err
+	}
+	m, err := decodeManifestNode(&root)
+	if err != nil {
+		return nil, err
+	}
+	var fields []UnknownField
+	if len(root.Content) == 1 && root.Content[0].Kind == yaml.MappingNode {
+		entries := root.Content[0].Content
+		for i := 0; i+1 < len(entries); i += 2 {
+			unknownFields(path, entries[i].Value, entries[i+1], reflect.TypeOf(SoftwareEntry{}), &fields)
+		}
+	}
+	if len(fields) > 0 {
+		return nil, &UnknownFieldsError{Fields: fields}
+	}
+	return m, nil
+}
+
+// unknownFields appends the keys of mapping node that struct type t does not declare
+// to fields, and those of the sections (nested structs) it declares
+func unknownFields(path, entry string, node *yaml.Node, t reflect.Type, fields *[]UnknownField) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	known := yamlFields(t)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		ft, ok := known[key.Value]
+		if !ok && t == reflect.TypeOf(SoftwareEntry{}) && IsInstallerKey(key.Value) {
+			continue
+		}
+		if !ok {
+			*fields = append(*fields, UnknownField{
+				Path: path, Line: key.Line, Column: key.Column, Entry: entry, Key: key.Value,
+				Suggestion: closestKey(key.Value, known),
+			})
+			continue
+		}
+		if ft.Kind() == reflect.Struct {
+			unknownFields(path, entry+"."+key.Value, value, ft, fields)
+		}
+	}
+}
+
+// yamlFields returns the types of the fields of struct type t by their YAML names
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	known := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name, _ := schema.Name(field); field.IsExported() && name != "-" {
+			known[name] = field.Type
+		}
+	}
+	return known
+}
+
+// closestKey returns the known key key is most likely a misspelling of: the nearest
+// one within an edit per three characters (at least one, at most two), or empty
+func closestKey(key string, known map[string]reflect.Type) string {
+	best, bestDist := "", min(2, max(1, len(key)/3))+1
+	for name := range known {
+		if d := editDistance(key, name); d < bestDist || d == bestDist && name < best {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Damerau-Levenshtein distance of a and b (with adjacent
+// transpositions, so _decs is one edit from _desc)
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
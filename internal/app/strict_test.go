@@ -0,0 +1,55 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadManifestStrict(t *testing.T) {
+	dir := t.TempDir()
+	valid := writeManifest(t, dir, "valid.yml", "bat:\n  _desc: cat with wings\n  brew: bat\n  apt:debian:x64: batcat\n  _sandbox:\n    no_network: true\n")
+	if _, err := LoadManifestStrict(valid); err != nil {
+		t.Fatalf("expected a manifest of declared keys to load, got %v", err)
+	}
+
+	typos := writeManifest(t, dir, "typos.yml", "bat:\n  _decs: cat with wings\n  brew: bat\n  _sandbox:\n    no_netwrok: true\nfd:\n  apt: fd-find\n  frobnicate: yes\n")
+	if m, err := LoadManifest(typos); err != nil || m["bat"].Desc != "" {
+		t.Fatalf("expected LoadManifest to ignore unknown keys, got %v %v", m, err)
+	}
+	_, err := LoadManifestStrict(typos)
+	var unknown *UnknownFieldsError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("expected an UnknownFieldsError, got %v", err)
+	}
+	want := []UnknownField{
+		{Path: typos, Line: 2, Column: 3, Entry: "bat", Key: "_decs", Suggestion: "_desc"},
+		{Path: typos, Line: 5, Column: 5, Entry: "bat._sandbox", Key: "no_netwrok", Suggestion: "no_network"},
+		{Path: typos, Line: 8, Column: 3, Entry: "fd", Key: "frobnicate"},
+	}
+	if len(unknown.Fields) != len(want) {
+		t.Fatalf("expected %d unknown keys, got %v", len(want), unknown.Fields)
+	}
+	for i, f := range unknown.Fields {
+		if f != want[i] {
+			t.Errorf("expected %+v, got %+v", want[i], f)
+		}
+	}
+	if msg := err.Error(); !strings.Contains(msg, typos+":2:3: unknown key _decs in bat (did you mean _desc?)") {
+		t.Errorf("expected the location and a suggestion in the error, got %q", msg)
+	}
+
+	// Sources opt in per manifest, and the error names the manifest
+	if _, err := LoadManifests([]ManifestSource{{Name: "work", Path: typos}}); err != nil {
+		t.Errorf("expected a lenient source to load, got %v", err)
+	}
+	if _, err := LoadManifests([]ManifestSource{{Name: "work", Path: typos, Strict: true}}); !errors.As(err, &unknown) || !strings.Contains(err.Error(), "manifest work") {
+		t.Errorf("expected a strict source to fail with the manifest name, got %v", err)
+	}
+
+	// Other errors are reported as they are
+	broken := writeManifest(t, dir, "broken.yml", "bat:\n  _size: big\n")
+	if _, err := LoadManifestStrict(broken); err == nil || errors.As(err, &unknown) {
+		t.Errorf("expected the type error, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"text/template"
+)
+
+// entryTemplates are the manifest entry skeletons offered to authors, keyed by category.
+// Each lists the fields relevant to its category, with per-OS keys where installs differ.
+var entryTemplates = map[string]string{
+	"cli-tool": `{{.Key}}:
+  _bin: {{.Key}}
+  _desc: TODO describe {{.Key}}
+  _docs: https://TODO
+  _github: https://github.com/TODO/{{.Key}}
+  _home: https://TODO
+  _name: {{.Name}}
+  _short: TODO one-line summary
+  _groups: cli
+  apt: {{.Key}}
+  brew: {{.Key}}
+  dnf: {{.Key}}
+  pacman: {{.Key}}
+  zypper: {{.Key}}
+  scoop: {{.Key}}
+  binary:linux: https://github.com/TODO/{{.Key}}/releases/latest/download/{{.Key}}-linux-amd64.tar.gz
+`,
+	"gui-app": `{{.Key}}:
+  _app: {{.Name}}.app
+  _bin: {{.Key}}
+  _desc: TODO describe {{.Name}}
+  _docs: https://TODO
+  _github: https://github.com/TODO/{{.Key}}
+  _home: https://TODO
+  _name: {{.Name}}
+  _short: TODO one-line summary
+  _groups: gui
+  cask: {{.Key}}
+  flatpak: org.TODO.{{.Name}}
+  snap: {{.Key}}
+  choco: {{.Key}}
+`,
+	"language-runtime": `{{.Key}}:
+  _bin: {{.Key}}
+  _desc: TODO describe the {{.Name}} runtime
+  _docs: https://TODO
+  _github: https://github.com/TODO/{{.Key}}
+  _home: https://TODO
+  _name: {{.Name}}
+  _short: TODO one-line summary
+  _groups: language
+  apt: {{.Key}}
+  apt:debian: {{.Key}}
+  brew: {{.Key}}
+  dnf: {{.Key}}
+  pacman: {{.Key}}
+  zypper: {{.Key}}
+  apk: {{.Key}}
+  port: {{.Key}}
+  scoop: {{.Key}}
+  choco: {{.Key}}
+`,
+}
+
+// EntryTemplateNames returns the available entry template categories, sorted.
+func EntryTemplateNames() []string {
+	names := make([]string, 0, len(entryTemplates))
+	for name := range entryTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewEntry renders the manifest entry skeleton of a category for a new key.
+//
+// # Parameters
+//   - category: One of EntryTemplateNames()
+//   - key:      The manifest key of the new entry
+//   - name:     The display name (defaults to key)
+//
+// # Returns
+//   - string: The YAML entry
+//   - error:  If the category is unknown
+func NewEntry(category, key, name string) (string, error) {
+	text, ok := entryTemplates[category]
+	if !ok {
+		return "", fmt.Errorf("unknown template %q (available: %v)", category, EntryTemplateNames())
+	}
+	if name == "" {
+		name = key
+	}
+	tmpl := template.Must(template.New(category).Parse(text))
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, struct{ Key, Name string }{key, name}); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
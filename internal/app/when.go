@@ -0,0 +1,223 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// WhenVariables are the facts a _when condition can test:
+//   - os:       The operating system (GOOS), e.g. linux or darwin
+//   - arch:     The architecture, e.g. x86_64 or arm64; any name of it matches (see
+//     CanonicalArch), so arch == "amd64" holds on x86_64
+//   - id:       The distribution ID from os-release, e.g. ubuntu (the OS elsewhere)
+//   - headless: Whether the system has no display, true or false
+var WhenVariables = []string{"os", "arch", "id", "headless"}
+
+// When is a parsed _when condition, which decides whether a manifest entry applies to
+// a system. Conditions compare variables (see WhenVariables) with quoted strings or
+// true/false using == and !=, and combine comparisons with &&, ||, ! and parentheses.
+// A variable on its own is true if its value is "true".
+//
+// # Example
+//
+//	w, _ := ParseWhen(`os == "linux" && arch == "arm64"`)
+//	w.Eval(map[string]string{"os": "linux", "arch": "arm64"}) // true
+type When struct {
+	expr string
+	eval func(vars map[string]string) bool
+}
+
+// ParseWhen parses a _when condition.
+//
+// # Returns
+//   - *When: The condition, ready to evaluate
+//   - error: If the condition is malformed or uses an unknown variable
+func ParseWhen(expr string) (*When, error) {
+	tokens, err := lexWhen(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid _when %q: %w", expr, err)
+	}
+	p := &whenParser{tokens: tokens}
+	eval, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %s", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid _when %q: %w", expr, err)
+	}
+	return &When{expr: expr, eval: eval}, nil
+}
+
+// Eval reports whether the condition holds for the variables. Missing variables are "".
+func (w *When) Eval(vars map[string]string) bool {
+	return w.eval(vars)
+}
+
+// String returns the condition as written.
+func (w *When) String() string {
+	return w.expr
+}
+
+// whenToken is a token of a _when condition.
+//
+// # Fields
+//   - kind: "ident", "string" or the operator itself, e.g. "&&" or "("
+//   - text: The identifier, the unquoted string or the operator
+type whenToken struct {
+	kind string
+	text string
+}
+
+// whenOperators are the operators of _when conditions, longest first.
+var whenOperators = []string{"==", "!=", "&&", "||", "!", "(", ")"}
+
+// lexWhen splits a _when condition into tokens.
+func lexWhen(expr string) ([]whenToken, error) {
+	var tokens []whenToken
+	for i := 0; i < len(expr); {
+		c := rune(expr[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexRune(expr[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, whenToken{kind: "string", text: expr[i+1 : i+1+end]})
+			i += end + 2
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(expr) && (unicode.IsLetter(rune(expr[i])) || unicode.IsDigit(rune(expr[i])) || expr[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, whenToken{kind: "ident", text: expr[start:i]})
+		default:
+			op := ""
+			for _, candidate := range whenOperators {
+				if strings.HasPrefix(expr[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+			}
+			tokens = append(tokens, whenToken{kind: op, text: op})
+			i += len(op)
+		}
+	}
+	return tokens, nil
+}
+
+// whenParser is a recursive-descent parser of _when conditions, from the lowest
+// precedence (||) to the highest (comparisons).
+type whenParser struct {
+	tokens []whenToken
+	pos    int
+}
+
+// accept consumes the next token if it is of the given kind.
+func (p *whenParser) accept(kind string) bool {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == kind {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// atArch reports whether the next token is the arch variable, whose values compare
+// by CanonicalArch.
+func (p *whenParser) atArch() bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == "ident" && p.tokens[p.pos].text == "arch"
+}
+
+func (p *whenParser) parseOr() (func(map[string]string) bool, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.accept("||") {
+		var right func(map[string]string) bool
+		if right, err = p.parseAnd(); err == nil {
+			l := left
+			left = func(vars map[string]string) bool { return l(vars) || right(vars) }
+		}
+	}
+	return left, err
+}
+
+func (p *whenParser) parseAnd() (func(map[string]string) bool, error) {
+	left, err := p.parseUnary()
+	for err == nil && p.accept("&&") {
+		var right func(map[string]string) bool
+		if right, err = p.parseUnary(); err == nil {
+			l := left
+			left = func(vars map[string]string) bool { return l(vars) && right(vars) }
+		}
+	}
+	return left, err
+}
+
+func (p *whenParser) parseUnary() (func(map[string]string) bool, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(vars map[string]string) bool { return !operand(vars) }, nil
+	}
+	if p.accept("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		return inner, nil
+	}
+	arch := p.atArch()
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!="} {
+		if !p.accept(op) {
+			continue
+		}
+		arch = arch || p.atArch()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		equal := op == "=="
+		if arch {
+			return func(vars map[string]string) bool {
+				return (CanonicalArch(left(vars)) == CanonicalArch(right(vars))) == equal
+			}, nil
+		}
+		return func(vars map[string]string) bool {
+			return strings.EqualFold(left(vars), right(vars)) == equal
+		}, nil
+	}
+	return func(vars map[string]string) bool { return strings.EqualFold(left(vars), "true") }, nil
+}
+
+// parseOperand parses a variable, a quoted string or true/false into its value.
+func (p *whenParser) parseOperand() (func(map[string]string) string, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of condition")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	switch {
+	case tok.kind == "string", tok.kind == "ident" && (tok.text == "true" || tok.text == "false"):
+		return func(map[string]string) string { return tok.text }, nil
+	case tok.kind == "ident" && slices.Contains(WhenVariables, tok.text):
+		return func(vars map[string]string) string { return vars[tok.text] }, nil
+	case tok.kind == "ident":
+		return nil, fmt.Errorf("unknown variable %s (want one of %s)", tok.text, strings.Join(WhenVariables, ", "))
+	default:
+		return nil, fmt.Errorf("unexpected %s", tok.text)
+	}
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseWhen(t *testing.T) {
+	vars := map[string]string{"os": "linux", "arch": "arm64", "id": "ubuntu", "headless": "true"}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`os == "linux"`, true},
+		{`os == "linux" && arch == "arm64"`, true},
+		{`os == "darwin" || arch == 'arm64'`, true},
+		{`headless == false`, false},
+		{`headless`, true},
+		{`!headless`, false},
+		{`os != "Linux"`, false},
+		{`!(os == "darwin" || id == "fedora") && arch != "x86_64"`, true},
+		{`os == "linux" && (id == "arch" || id == "fedora")`, false},
+	}
+	for _, tt := range tests {
+		w, err := ParseWhen(tt.expr)
+		if err != nil {
+			t.Errorf("ParseWhen(%s) error: %v", tt.expr, err)
+			continue
+		}
+		if got := w.Eval(vars); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseWhenArch(t *testing.T) {
+	tests := []struct {
+		expr, arch string
+		want       bool
+	}{
+		{`arch == "amd64"`, "x64", true},
+		{`arch == "x86_64"`, "x64", true},
+		{`arch != "AMD64"`, "x64", false},
+		{`arch == "arm64"`, "x64", false},
+		{`"aarch64" == arch`, "arm64", true},
+		{`arch == "mips"`, "mips", true},
+	}
+	for _, tt := range tests {
+		w, err := ParseWhen(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseWhen(%s) error: %v", tt.expr, err)
+		}
+		if got := w.Eval(map[string]string{"arch": tt.arch}); got != tt.want {
+			t.Errorf("%s with arch %s = %v, want %v", tt.expr, tt.arch, got, tt.want)
+		}
+	}
+}
+
+func TestParseWhenErrors(t *testing.T) {
+	tests := map[string]string{
+		`distro == "ubuntu"`: "unknown variable distro",
+		`os == "linux`:       "unterminated string",
+		`os == "linux" &&`:   "unexpected end",
+		`(os == "linux"`:     "missing )",
+		`os == "linux" os`:   "unexpected os",
+		`os = "linux"`:       "unexpected '='",
+	}
+	for expr, want := range tests {
+		if _, err := ParseWhen(expr); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseWhen(%s) error = %v, want %q", expr, err, want)
+		}
+	}
+}
@@ -1,9 +1,10 @@
 // Package config provides configuration management for the a-la-carte application.
 //
 // The configuration is loaded with the following precedence (highest to lowest):
-// 1. Environment variable (A_LA_CARTE_CONFIG)
-// 2. Command line flags (--config)
-// 3. XDG config file ($HOME/.config/a-la-carte/a-la-carte.yml)
+// 1. Command line flags overriding settings (--debug, --manifest, ...)
+// 2. Environment variables overriding settings (A_LA_CARTE_UI_THEME, ...; see ApplyEnv)
+// 3. The config file: A_LA_CARTE_CONFIG, --config, or the XDG config file
+// ($HOME/.config/a-la-carte/a-la-carte.yml)
 // 4. Built-in defaults
 package config
 
@@ -33,33 +34,95 @@ var (
 	ErrNoConfig = errors.New("no configuration file found")
 )
 
-// Config represents the application configuration
+// NamedManifest is a manifest file loaded under a name
+type NamedManifest struct {
+	// Name namespaces the manifest's keys, e.g. "work" in "work/ripgrep"
+	Name string `yaml:"name" doc:"Name namespacing the manifest's keys, e.g. work in work/ripgrep" schema:"required,pattern=^[^/]+$"`
+	// Path is the path to the manifest file, relative to the config file
+	Path string `yaml:"path" doc:"Path to the manifest file, relative to the config file" schema:"required"`
+}
+
+// ManagerOptions customizes the install commands of one package manager
+type ManagerOptions struct {
+	// ExtraArgs are added to the install command before the package, e.g. ["-t", "bookworm-backports"]
+	ExtraArgs []string `yaml:"extraArgs,omitempty" doc:"Arguments added to the install command before the package, e.g. [-t, bookworm-backports]"`
+	// Env are environment variables set for the install command, e.g. HOMEBREW_NO_AUTO_UPDATE: "1"
+	Env map[string]string `yaml:"env,omitempty" doc:"Environment variables set for the install command, e.g. HOMEBREW_NO_AUTO_UPDATE: \"1\""`
+}
+
+// Managers configures the package managers
+type Managers struct {
+	// PreferenceOrder lists installers to try before the others for each entry, e.g. [brew, apt];
+	// the rest follow in the default order for the system
+	PreferenceOrder []string `yaml:"preferenceOrder,omitempty" doc:"Installers tried before the others for each entry, e.g. [brew, apt]"`
+	// Disabled lists installers that are never used, e.g. [snap, flatpak]; entries fall back
+	// to their next installer
+	Disabled []string `yaml:"disabled,omitempty" doc:"Installers never used, e.g. [snap, flatpak]; entries fall back to their next installer"`
+	// Options maps a package manager (e.g. apt, brew) to options for its install commands
+	Options map[string]ManagerOptions `yaml:",inline" doc:"Options of the install commands of one package manager, e.g. apt or brew"`
+}
+
+// Config represents the application configuration. The doc tags describe the keys of the
+// config file for the built-in documentation (see schema.Fields), and the schema tags
+// constrain them in its JSON Schema (see schema.JSONSchema)
 type Config struct {
 	// UI configuration settings
 	UI struct {
-		// Theme controls the color scheme (light, dark, system)
-		Theme string `yaml:"theme,omitempty"`
+		// Theme controls the color scheme (light, dark, system), or high-contrast
+		Theme string `yaml:"theme,omitempty" doc:"Color scheme: light, dark, system or high-contrast" schema:"enum=dark|light|system|high-contrast"`
 		// DetailHeight is the height of the detail pane
-		DetailHeight int `yaml:"detailHeight,omitempty"`
+		DetailHeight int `yaml:"detailHeight,omitempty" doc:"Height of the details pane in lines" schema:"minimum=1"`
 		// ListHeight is the height of the list pane
-		ListHeight int `yaml:"listHeight,omitempty"`
+		ListHeight int `yaml:"listHeight,omitempty" doc:"Height of the software lists in lines" schema:"minimum=1"`
 		// EmojisEnabled controls whether emojis are displayed in the UI
-		EmojisEnabled bool `yaml:"emojisEnabled,omitempty"`
-	} `yaml:"ui,omitempty"`
+		EmojisEnabled bool `yaml:"emojisEnabled,omitempty" doc:"Show emojis next to entries"`
+		// ColumnView makes the picker list start in the column view
+		ColumnView bool `yaml:"columnView,omitempty" doc:"Start the list in the column view"`
+		// Colors caps the colors of the UI: auto (detected from the terminal, the
+		// default), truecolor, 256, 16 or none; NO_COLOR also means none
+		Colors string `yaml:"colors,omitempty" doc:"Colors of the UI: auto (detected, the default), truecolor, 256, 16 or none" schema:"enum=auto|truecolor|256|16|none"`
+		// ASCII replaces emojis, box-drawing borders and arrows with plain ASCII
+		ASCII bool `yaml:"ascii,omitempty" doc:"Draw the UI in plain ASCII instead of emojis, box drawing and arrows"`
+		// A11y renders the UI for screen readers: no decorative borders or emojis, pane
+		// labels in the output, and the focused item marked with [focused]; implies ASCII
+		A11y bool `yaml:"a11y,omitempty" doc:"Screen-reader-friendly output with labelled panes; implies ascii"`
+		// TerminalTitle sets the terminal title to the picker state, e.g.
+		// "à la carte — 12 selected"; on by default
+		TerminalTitle bool `yaml:"terminalTitle,omitempty" doc:"Set the terminal title to the picker state (on by default)"`
+		// Icons selects the icons next to entries: emoji (the default) or nerdfont
+		Icons string `yaml:"icons,omitempty" doc:"Icons next to entries: emoji (the default) or nerdfont" schema:"enum=emoji|nerdfont"`
+		// Emojis maps keywords matched in entry names and descriptions to emojis, e.g.
+		// rust: 🦀; they take precedence over the built-in keywords
+		Emojis map[string]string `yaml:"emojis,omitempty" doc:"Emojis for keywords matched in entry names and descriptions, e.g. rust: 🦀"`
+	} `yaml:"ui,omitempty" doc:"Appearance of the picker"`
 
 	// Software configuration
 	Software struct {
 		// ManifestPath is the path to the software manifest
-		ManifestPath string `yaml:"manifestPath,omitempty"`
+		ManifestPath string `yaml:"manifestPath,omitempty" doc:"Path to the manifest, relative to the config file"`
 		// PreloadKeys are software keys to preload
-		PreloadKeys []string `yaml:"preloadKeys,omitempty"`
-	} `yaml:"software,omitempty"`
+		PreloadKeys []string `yaml:"preloadKeys,omitempty" doc:"Manifest keys selected when the picker starts"`
+		// ManifestURL is a remote manifest. It is cached locally and used instead of ManifestPath
+		ManifestURL string `yaml:"manifestURL,omitempty" doc:"URL of a remote manifest, cached locally and used instead of manifestPath" schema:"format=uri"`
+		// Manifests are several named manifests loaded together, in priority order.
+		// When set, ManifestPath is ignored and keys are namespaced by manifest name.
+		Manifests []NamedManifest `yaml:"manifests,omitempty" doc:"Named manifests loaded together in priority order, instead of manifestPath; keys are namespaced by name"`
+		// Strict rejects manifests with keys entries do not declare, e.g. misspelled ones
+		Strict bool `yaml:"strict,omitempty" doc:"Reject manifests with keys entries do not declare, e.g. _decs for _desc, instead of ignoring them"`
+	} `yaml:"software,omitempty" doc:"Where the manifests come from"`
+
+	// Profiles maps a profile name (e.g. work, personal, server) to a named
+	// selection set of manifest keys
+	Profiles map[string][]string `yaml:"profiles,omitempty" doc:"Named selections of manifest keys, e.g. work: [docker, kubectl]"`
+
+	// Managers configures the package managers: their preference order and options per manager
+	Managers Managers `yaml:"managers,omitempty" doc:"Preferences and options of the package managers"`
 
 	// System settings
 	System struct {
 		// DebugMode enables debug logging
-		DebugMode bool `yaml:"debugMode,omitempty"`
-	} `yaml:"system,omitempty"`
+		DebugMode bool `yaml:"debugMode,omitempty" doc:"Print the configuration and the manifest in use at startup"`
+	} `yaml:"system,omitempty" doc:"Diagnostics"`
 
 	// ConfigPath stores the path where the config was loaded from
 	ConfigPath string `yaml:"-"`
@@ -74,11 +137,15 @@ func DefaultConfig() *Config {
 	c.UI.DetailHeight = 10
 	c.UI.ListHeight = 10
 	c.UI.EmojisEnabled = true
+	c.UI.TerminalTitle = true
 
 	// Software defaults
 	c.Software.ManifestPath = "software.yml"
 	c.Software.PreloadKeys = []string{}
 
+	// Profile defaults
+	c.Profiles = map[string][]string{}
+
 	// System defaults
 	c.System.DebugMode = false
 
@@ -90,12 +157,25 @@ func DefaultConfig() *Config {
 func (c *Config) Validate() error {
 	// Validate UI theme
 	validThemes := map[string]bool{
-		"dark":   true,
-		"light":  true,
-		"system": true,
+		"dark":          true,
+		"light":         true,
+		"system":        true,
+		"high-contrast": true,
 	}
 	if !validThemes[c.UI.Theme] {
-		return fmt.Errorf("invalid UI theme: %s (must be 'dark', 'light', or 'system')", c.UI.Theme)
+		return fmt.Errorf("invalid UI theme: %s (must be 'dark', 'light', 'system' or 'high-contrast')", c.UI.Theme)
+	}
+
+	// Validate UI colors
+	switch c.UI.Colors {
+	case "", "auto", "truecolor", "256", "16", "none":
+	default:
+		return fmt.Errorf("invalid UI colors: %s (must be 'auto', 'truecolor', '256', '16' or 'none')", c.UI.Colors)
+	}
+
+	// Validate UI icons
+	if c.UI.Icons != "" && c.UI.Icons != "emoji" && c.UI.Icons != "nerdfont" {
+		return fmt.Errorf("invalid UI icons: %s (must be 'emoji' or 'nerdfont')", c.UI.Icons)
 	}
 
 	// Validate UI dimensions
@@ -112,6 +192,38 @@ func (c *Config) Validate() error {
 		return errors.New("software manifest path cannot be empty")
 	}
 
+	// Validate profile names
+	seen := make(map[string]bool)
+	for _, m := range c.Software.Manifests {
+		if strings.TrimSpace(m.Name) == "" || strings.Contains(m.Name, "/") {
+			return fmt.Errorf("invalid manifest name: %q (must be non-empty and must not contain '/')", m.Name)
+		}
+		if seen[m.Name] {
+			return fmt.Errorf("duplicate manifest name: %s", m.Name)
+		}
+		seen[m.Name] = true
+		if m.Path == "" {
+			return fmt.Errorf("manifest %s has no path", m.Name)
+		}
+	}
+
+	for name := range c.Profiles {
+		if strings.TrimSpace(name) == "" {
+			return errors.New("profile name cannot be empty")
+		}
+	}
+
+	for name, opts := range c.Managers.Options {
+		if strings.TrimSpace(name) == "" {
+			return errors.New("manager name cannot be empty")
+		}
+		for key := range opts.Env {
+			if key == "" || strings.ContainsAny(key, "= ") {
+				return fmt.Errorf("invalid environment variable %q for manager %s", key, name)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -201,20 +313,26 @@ func (c *Config) Save(path string) error {
 	return nil
 }
 
-// SaveToDefaultLocation saves the configuration to the default XDG config location
-func (c *Config) SaveToDefaultLocation() error {
-	// Get XDG config home
+// DefaultConfigPath returns the config file in the default XDG config location,
+// whether or not it exists
+func DefaultConfigPath() (string, error) {
 	xdgConfigHome := os.Getenv("XDG_CONFIG_HOME")
 	if xdgConfigHome == "" {
 		home, err := os.UserHomeDir()
 		if err != nil {
-			return fmt.Errorf("error getting user home directory: %w", err)
+			return "", fmt.Errorf("error getting user home directory: %w", err)
 		}
 		xdgConfigHome = filepath.Join(home, ".config")
 	}
+	return filepath.Join(xdgConfigHome, DefaultConfigDirname, DefaultConfigFilename), nil
+}
 
-	// Create the path
-	path := filepath.Join(xdgConfigHome, DefaultConfigDirname, DefaultConfigFilename)
+// SaveToDefaultLocation saves the configuration to the default XDG config location
+func (c *Config) SaveToDefaultLocation() error {
+	path, err := DefaultConfigPath()
+	if err != nil {
+		return err
+	}
 	return c.Save(path)
 }
 
@@ -235,17 +353,7 @@ func CreateDefault() (string, error) {
 	}
 
 	// Return the path
-	xdgConfigHome := os.Getenv("XDG_CONFIG_HOME")
-	if xdgConfigHome == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return "", fmt.Errorf("error getting user home directory: %w", err)
-		}
-		xdgConfigHome = filepath.Join(home, ".config")
-	}
-
-	path := filepath.Join(xdgConfigHome, DefaultConfigDirname, DefaultConfigFilename)
-	return path, nil
+	return DefaultConfigPath()
 }
 
 // String returns a string representation of the configuration for debugging
@@ -258,6 +366,21 @@ func (c *Config) String() string {
 	b.WriteString(fmt.Sprintf("  UI Detail Height: %d\n", c.UI.DetailHeight))
 	b.WriteString(fmt.Sprintf("  UI List Height: %d\n", c.UI.ListHeight))
 	b.WriteString(fmt.Sprintf("  UI Emojis Enabled: %v\n", c.UI.EmojisEnabled))
+	if c.UI.Colors != "" {
+		b.WriteString(fmt.Sprintf("  UI Colors: %s\n", c.UI.Colors))
+	}
+	if c.UI.ASCII {
+		b.WriteString("  UI ASCII: true\n")
+	}
+	if c.UI.A11y {
+		b.WriteString("  UI A11y: true\n")
+	}
+	if !c.UI.TerminalTitle {
+		b.WriteString("  UI Terminal Title: false\n")
+	}
+	if c.UI.Icons != "" {
+		b.WriteString(fmt.Sprintf("  UI Icons: %s\n", c.UI.Icons))
+	}
 	b.WriteString(fmt.Sprintf("  Software Manifest Path: %s\n", c.Software.ManifestPath))
 	b.WriteString(fmt.Sprintf("  System Debug Mode: %v\n", c.System.DebugMode))
 
@@ -268,5 +391,12 @@ func (c *Config) String() string {
 		}
 	}
 
+	if len(c.Profiles) > 0 {
+		b.WriteString("  Profiles:\n")
+		for _, name := range c.ProfileNames() {
+			b.WriteString(fmt.Sprintf("    %s: %s\n", name, strings.Join(c.Profiles[name], ", ")))
+		}
+	}
+
 	return b.String()
 }
@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -156,12 +158,32 @@ func TestValidate(t *testing.T) {
 		t.Errorf("expected no validation error for default config, got %v", err)
 	}
 
+	// Test the high-contrast theme
+	cfg.UI.Theme = "high-contrast"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no validation error for the high-contrast theme, got %v", err)
+	}
+
 	// Test invalid theme
 	cfg.UI.Theme = "invalid"
 	if err := cfg.Validate(); err == nil {
 		t.Error("expected validation error for invalid theme, got nil")
 	}
 
+	// Reset and test invalid colors
+	cfg = DefaultConfig()
+	cfg.UI.Colors = "8"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation error for invalid colors, got nil")
+	}
+
+	// Reset and test invalid icons
+	cfg = DefaultConfig()
+	cfg.UI.Icons = "ascii-art"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation error for invalid icons, got nil")
+	}
+
 	// Reset and test invalid detail height
 	cfg = DefaultConfig()
 	cfg.UI.DetailHeight = 0
@@ -182,6 +204,42 @@ func TestValidate(t *testing.T) {
 	if err := cfg.Validate(); err == nil {
 		t.Error("expected validation error for empty manifest path, got nil")
 	}
+
+	// Reset and test duplicate manifest names
+	cfg = DefaultConfig()
+	cfg.Software.Manifests = []NamedManifest{{Name: "work", Path: "a.yml"}, {Name: "work", Path: "b.yml"}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation error for duplicate manifest names, got nil")
+	}
+
+	// Reset and test a manifest name with a slash
+	cfg = DefaultConfig()
+	cfg.Software.Manifests = []NamedManifest{{Name: "a/b", Path: "a.yml"}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation error for manifest name with a slash, got nil")
+	}
+
+	// Reset and test an invalid manager environment variable
+	cfg = DefaultConfig()
+	cfg.Managers.Options = map[string]ManagerOptions{"brew": {Env: map[string]string{"A=B": "1"}}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation error for invalid environment variable, got nil")
+	}
+}
+
+func TestResolveManifests(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ConfigPath = "/etc/a-la-carte/a-la-carte.yml"
+	if got := cfg.ResolveManifests(); len(got) != 1 || got[0].Name != "" || got[0].Path != "/etc/a-la-carte/software.yml" {
+		t.Errorf("expected the single manifest path, got %v", got)
+	}
+
+	cfg.Software.Manifests = []NamedManifest{{Name: "work", Path: "work.yml"}, {Name: "personal", Path: "/home/me/personal.yml"}}
+	got := cfg.ResolveManifests()
+	want := []NamedManifest{{Name: "work", Path: "/etc/a-la-carte/work.yml"}, {Name: "personal", Path: "/home/me/personal.yml"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("expected %v, got %v", want, got)
+	}
 }
 
 func TestSave(t *testing.T) {
@@ -226,3 +284,242 @@ func TestSave(t *testing.T) {
 		t.Errorf("expected preload keys ['test1', 'test2'], got %v", loadedCfg.Software.PreloadKeys)
 	}
 }
+
+func TestSavePreloadKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a-la-carte.yml")
+	original := "# my settings\nui:\n  theme: light # keep me\nsoftware:\n  preloadKeys: [old]\n"
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SavePreloadKeys(path, []string{"git", "vim"}); err != nil {
+		t.Fatalf("SavePreloadKeys: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{"# my settings", "theme: light # keep me", "- git", "- vim"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("saved config missing %q:\n%s", want, data)
+		}
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("failed to load saved config: %v", err)
+	}
+	if len(cfg.Software.PreloadKeys) != 2 || cfg.Software.PreloadKeys[0] != "git" || cfg.UI.Theme != "light" {
+		t.Errorf("unexpected config after saving: %+v", cfg)
+	}
+
+	created := filepath.Join(t.TempDir(), "new", "a-la-carte.yml")
+	if err := SavePreloadKeys(created, []string{"git"}); err != nil {
+		t.Fatalf("SavePreloadKeys on a new file: %v", err)
+	}
+	if cfg, err := Load(created); err != nil || len(cfg.Software.PreloadKeys) != 1 {
+		t.Errorf("expected a new config with the keys, got %+v, %v", cfg, err)
+	}
+}
+
+func TestSettings(t *testing.T) {
+	c := DefaultConfig()
+	for key, value := range map[string]string{
+		"ui.theme":              "light",
+		"UI.DetailHeight":       "12",
+		"ui.emojisEnabled":      "false",
+		"software.preloadKeys":  "git, vim",
+		"profiles.work":         "docker,kubectl",
+		"managers.disabled":     "snap",
+		"software.manifestPath": "manifests/software.yml",
+	} {
+		if err := c.Set(key, value); err != nil {
+			t.Fatalf("Set(%s): %v", key, err)
+		}
+	}
+	if c.UI.Theme != "light" || c.UI.DetailHeight != 12 || c.UI.EmojisEnabled ||
+		strings.Join(c.Software.PreloadKeys, ",") != "git,vim" || strings.Join(c.Profiles["work"], ",") != "docker,kubectl" ||
+		strings.Join(c.Managers.Disabled, ",") != "snap" || c.Software.ManifestPath != "manifests/software.yml" {
+		t.Errorf("unexpected config after Set: %+v", c)
+	}
+	if value, err := c.Get("ui.detailheight"); err != nil || FormatSetting(value) != "12" {
+		t.Errorf("expected Get to ignore case, got %v, %v", value, err)
+	}
+
+	for key, value := range map[string]string{
+		"ui.nope":          "x",
+		"ui.detailHeight":  "tall",
+		"ui.columnView":    "maybe",
+		"software":         "x",
+		"managers.apt.env": "x",
+	} {
+		if err := c.Set(key, value); err == nil {
+			t.Errorf("expected Set(%s, %s) to fail", key, value)
+		}
+	}
+
+	var keys []string
+	for _, setting := range c.Settings() {
+		keys = append(keys, setting.Key)
+	}
+	for _, want := range []string{"profiles.work", "software.manifestPath", "system.debugMode", "ui.theme"} {
+		if !slices.Contains(keys, want) {
+			t.Errorf("expected %s among the settings, got %v", want, keys)
+		}
+	}
+	if !slices.IsSorted(keys) || slices.Contains(keys, "configPath") {
+		t.Errorf("expected the file settings sorted by key, got %v", keys)
+	}
+}
+
+func TestSaveSetting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a-la-carte.yml")
+	original := "# my settings\nui:\n  theme: light # keep me\n"
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveSetting(path, "SOFTWARE.MANIFESTPATH", "/home/me/manifests/software.yml"); err != nil {
+		t.Fatalf("SaveSetting: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{"# my settings", "theme: light # keep me", "manifestPath: /home/me/manifests/software.yml"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("saved config missing %q:\n%s", want, data)
+		}
+	}
+
+	if err := SaveSetting(path, "ui.theme", "purple"); err == nil || !strings.Contains(err.Error(), "invalid UI theme") {
+		t.Errorf("expected an invalid theme to be rejected, got %v", err)
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(data) {
+		t.Errorf("expected a rejected setting to leave the file alone:\n%s", after)
+	}
+
+	created := filepath.Join(t.TempDir(), "new", "a-la-carte.yml")
+	if err := SaveSetting(created, "ui.listHeight", "20"); err != nil {
+		t.Fatalf("SaveSetting on a new file: %v", err)
+	}
+	if cfg, err := Load(created); err != nil || cfg.UI.ListHeight != 20 || cfg.UI.Theme != "dark" {
+		t.Errorf("expected a new config with the setting, got %+v, %v", cfg, err)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	if got := EnvName("software.manifestPath"); got != "A_LA_CARTE_SOFTWARE_MANIFESTPATH" {
+		t.Errorf("unexpected variable name %s", got)
+	}
+	keys := EnvKeys()
+	for _, want := range []string{"ui.theme", "ui.detailHeight", "software.preloadKeys", "managers.disabled", "system.debugMode"} {
+		if !slices.Contains(keys, want) {
+			t.Errorf("expected %s to be overridable, got %v", want, keys)
+		}
+	}
+	for _, unwanted := range []string{"profiles", "software.manifests", "ui.emojis", "configPath"} {
+		if slices.Contains(keys, unwanted) {
+			t.Errorf("expected %s not to be overridable", unwanted)
+		}
+	}
+
+	env := map[string]string{
+		"A_LA_CARTE_UI_THEME":              "light",
+		"A_LA_CARTE_UI_LISTHEIGHT":         "25",
+		"A_LA_CARTE_SOFTWARE_PRELOADKEYS":  "git,vim",
+		"A_LA_CARTE_SYSTEM_DEBUGMODE":      "true",
+		"A_LA_CARTE_SOFTWARE_MANIFESTPATH": "",
+	}
+	c := DefaultConfig()
+	if err := c.ApplyEnv(func(name string) string { return env[name] }); err != nil {
+		t.Fatalf("ApplyEnv: %v", err)
+	}
+	if c.UI.Theme != "light" || c.UI.ListHeight != 25 || len(c.Software.PreloadKeys) != 2 ||
+		!c.System.DebugMode || c.Software.ManifestPath != "software.yml" {
+		t.Errorf("unexpected config after ApplyEnv: %+v", c)
+	}
+
+	env["A_LA_CARTE_UI_DETAILHEIGHT"] = "tall"
+	if err := DefaultConfig().ApplyEnv(func(name string) string { return env[name] }); err == nil ||
+		!strings.Contains(err.Error(), "A_LA_CARTE_UI_DETAILHEIGHT") {
+		t.Errorf("expected an error naming the variable, got %v", err)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "a-la-carte-profiles-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err = os.RemoveAll(tempDir); err != nil {
+			t.Errorf("failed to remove temp dir: %v", err)
+		}
+	}()
+
+	configPath := filepath.Join(tempDir, "a-la-carte.yml")
+	configContent := `
+profiles:
+  work:
+    - git
+    - docker
+  server:
+    - htop
+`
+	if err = os.WriteFile(configPath, []byte(configContent), 0o644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	names := cfg.ProfileNames()
+	if len(names) != 2 || names[0] != "server" || names[1] != "work" {
+		t.Errorf("expected profile names ['server', 'work'], got %v", names)
+	}
+
+	keys, err := cfg.Profile("work")
+	if err != nil {
+		t.Fatalf("expected profile 'work', got error: %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "git" || keys[1] != "docker" {
+		t.Errorf("expected work keys ['git', 'docker'], got %v", keys)
+	}
+
+	if _, err := cfg.Profile("missing"); err == nil {
+		t.Error("expected error for missing profile, got nil")
+	}
+}
+
+func TestRemoteManifestPath(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/tmp/cache")
+	cfg := DefaultConfig()
+	cfg.Software.ManifestURL = "https://example.com/software.yml"
+	want := filepath.Join("/tmp/cache", DefaultConfigDirname, "manifest.yaml")
+	if got := cfg.ResolveManifestPath(); got != want {
+		t.Errorf("expected cached manifest path %s, got %s", want, got)
+	}
+	if err := cfg.ValidateManifestPath(); err != nil {
+		t.Errorf("expected a remote manifest to skip file validation, got %v", err)
+	}
+}
+
+func TestLoadManagers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a-la-carte.yml")
+	data := `managers:
+  preferenceOrder: [brew, apt]
+  disabled: [snap]
+  apt:
+    extraArgs: ["-t", "bookworm-backports"]
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if got := cfg.Managers.PreferenceOrder; len(got) != 2 || got[0] != "brew" || got[1] != "apt" {
+		t.Errorf("expected preference order [brew apt], got %v", got)
+	}
+	if got := cfg.Managers.Disabled; len(got) != 1 || got[0] != "snap" {
+		t.Errorf("expected snap to be disabled, got %v", got)
+	}
+	if len(cfg.Managers.Options) != 1 || len(cfg.Managers.Options["apt"].ExtraArgs) != 2 {
+		t.Errorf("expected the apt options only, got %v", cfg.Managers.Options)
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SavePreloadKeys sets software.preloadKeys in a config file, so the keys are selected
+// the next time the picker starts. Only that setting is changed: the file is edited
+// through yaml.Node, keeping comments and every other setting as written
+// Creates the file and its directory if they do not exist
+func SavePreloadKeys(path string, keys []string) error {
+	return editConfigFile(path, func(root *yaml.Node) error {
+		software := mappingEntry(root, "software")
+		if software.Kind != yaml.MappingNode {
+			*software = yaml.Node{Kind: yaml.MappingNode}
+		}
+		list := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, key := range keys {
+			list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key})
+		}
+		preload := mappingEntry(software, "preloadKeys")
+		list.HeadComment, list.LineComment = preload.HeadComment, preload.LineComment
+		*preload = *list
+		return nil
+	})
+}
+
+// editConfigFile applies edit to the top-level mapping of a config file and writes the
+// file back, keeping comments and the settings edit leaves alone
+// Creates the file and its directory if they do not exist
+func editConfigFile(path string, edit func(root *yaml.Node) error) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("error reading config file: %w", err)
+	default:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("error parsing config file: %w", err)
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("config file %s is not a mapping", path)
+	}
+	if err := edit(root); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating config directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating config file: %w", err)
+	}
+	encoder := yaml.NewEncoder(f)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("error encoding config: %w", err)
+	}
+	return f.Close()
+}
+
+// mappingEntry returns the value of key in a mapping node, adding the key with an
+// empty value if it is missing
+func mappingEntry(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	return value
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ProfileNames returns the names of all configured profiles, sorted
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile returns the manifest keys of the named profile
+// Returns an error if no profile with that name is configured
+func (c *Config) Profile(name string) ([]string, error) {
+	keys, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile not found: %s", name)
+	}
+	return append([]string(nil), keys...), nil
+}
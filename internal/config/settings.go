@@ -0,0 +1,337 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Setting is one configuration key and its value, as "config list" shows it
+type Setting struct {
+	// Key is the dotted path of the setting in the config file, e.g. "ui.theme"
+	Key string `json:"key"`
+	// Value is the value of the setting, e.g. "dark"
+	Value any `json:"value"`
+}
+
+// Settings returns every setting of the configuration with its value, sorted by key.
+// Keys are the dotted paths of the config file, e.g. "ui.theme" or "profiles.work";
+// entries of maps are listed one by one
+func (c *Config) Settings() []Setting {
+	var settings []Setting
+	collectSettings(reflect.ValueOf(c).Elem(), "", &settings)
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+// collectSettings appends the leaves of v under prefix to settings
+func collectSettings(v reflect.Value, prefix string, settings *[]Setting) {
+	switch {
+	case v.Kind() == reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			name, inline := yamlName(v.Type().Field(i))
+			switch {
+			case name == "-":
+			case inline:
+				collectSettings(v.Field(i), prefix, settings)
+			default:
+				collectSettings(v.Field(i), joinKey(prefix, name), settings)
+			}
+		}
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		for _, key := range v.MapKeys() {
+			collectSettings(v.MapIndex(key), joinKey(prefix, key.String()), settings)
+		}
+	default:
+		*settings = append(*settings, Setting{Key: prefix, Value: v.Interface()})
+	}
+}
+
+// Get returns the value of a setting by its dotted key, e.g. "ui.theme". Keys are
+// matched ignoring case; a key of a section returns the whole section
+func (c *Config) Get(key string) (any, error) {
+	v, err := lookupSetting(reflect.ValueOf(c).Elem(), key)
+	if err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+// Set changes a setting by its dotted key, e.g. "software.manifestPath", parsing value
+// for the type of the setting: true or false for switches, a number for sizes, and a
+// comma-separated list for lists. An empty value clears the setting. Only strings,
+// switches, numbers and lists of strings can be set this way; Set does not validate
+// the configuration as a whole, see Validate
+func (c *Config) Set(key, value string) error {
+	parts := strings.Split(key, ".")
+	parent := reflect.ValueOf(c).Elem()
+	if len(parts) > 1 {
+		var err error
+		if parent, err = lookupSetting(parent, strings.Join(parts[:len(parts)-1], ".")); err != nil {
+			return err
+		}
+	}
+	last := parts[len(parts)-1]
+	if parent.Kind() == reflect.Map && parent.Type().Key().Kind() == reflect.String {
+		// A map entry, e.g. profiles.work, is replaced as a whole
+		parsed, err := parseSetting(key, parent.Type().Elem(), value)
+		if err != nil {
+			return err
+		}
+		if parent.IsNil() {
+			parent.Set(reflect.MakeMap(parent.Type()))
+		}
+		parent.SetMapIndex(reflect.ValueOf(last).Convert(parent.Type().Key()), parsed)
+		return nil
+	}
+	field, err := lookupSetting(parent, last)
+	if err != nil {
+		return fmt.Errorf("unknown setting: %s", key)
+	}
+	if !field.CanSet() {
+		return fmt.Errorf("%s cannot be set from the command line; edit the config file instead", key)
+	}
+	parsed, err := parseSetting(key, field.Type(), value)
+	if err != nil {
+		return err
+	}
+	field.Set(parsed)
+	return nil
+}
+
+// lookupSetting follows a dotted key through structs (by yaml name) and maps
+func lookupSetting(v reflect.Value, key string) (reflect.Value, error) {
+	for _, part := range strings.Split(key, ".") {
+		next, ok := settingChild(v, part)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("unknown setting: %s", key)
+		}
+		v = next
+	}
+	return v, nil
+}
+
+// settingChild returns the field or map entry of v named name
+func settingChild(v reflect.Value, name string) (reflect.Value, bool) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			fieldName, inline := yamlName(v.Type().Field(i))
+			switch {
+			case fieldName == "-":
+			case inline:
+				if child, ok := settingChild(v.Field(i), name); ok {
+					return child, true
+				}
+			case strings.EqualFold(fieldName, name):
+				return v.Field(i), true
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+		for _, key := range v.MapKeys() {
+			if key.String() == name {
+				return v.MapIndex(key), true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// parseSetting parses value as a setting of type t
+func parseSetting(key string, t reflect.Type, value string) (reflect.Value, error) {
+	v := reflect.New(t).Elem()
+	switch {
+	case t.Kind() == reflect.String:
+		v.SetString(value)
+	case t.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return v, fmt.Errorf("invalid value for %s: %q (must be true or false)", key, value)
+		}
+		v.SetBool(b)
+	case t.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return v, fmt.Errorf("invalid value for %s: %q (must be a number)", key, value)
+		}
+		v.SetInt(int64(n))
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String:
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				v.Set(reflect.Append(v, reflect.ValueOf(item)))
+			}
+		}
+	default:
+		return v, fmt.Errorf("%s cannot be set from the command line; edit the config file instead", key)
+	}
+	return v, nil
+}
+
+// yamlName returns the name of a struct field in YAML, and whether it is inlined
+func yamlName(field reflect.StructField) (string, bool) {
+	name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name, strings.Contains(opts, "inline")
+}
+
+// joinKey appends name to a dotted key
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// FormatSetting formats the value of a setting the way Set parses it: lists as
+// comma-separated items, sections as YAML
+func FormatSetting(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ",")
+	case bool, int:
+		return fmt.Sprint(v)
+	}
+	var b strings.Builder
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// SaveSetting changes one setting in a config file and validates the result first, so
+// an invalid value leaves the file alone. Only that setting is changed: the file is
+// edited through yaml.Node, keeping comments and every other setting as written
+// Creates the file and its directory if they do not exist
+//
+// # Example
+//
+//	config.SaveSetting(path, "software.manifestPath", "~/manifests/software.yml")
+func SaveSetting(path, key, value string) error {
+	c, err := Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		c, err = DefaultConfig(), nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := c.Set(key, value); err != nil {
+		return err
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	newValue, err := c.Get(key)
+	if err != nil {
+		return err
+	}
+	return editConfigFile(path, func(root *yaml.Node) error {
+		node := root
+		for _, part := range strings.Split(canonicalKey(c, key), ".") {
+			if node.Kind != yaml.MappingNode {
+				*node = yaml.Node{Kind: yaml.MappingNode}
+			}
+			node = mappingEntry(node, part)
+		}
+		var encoded yaml.Node
+		if err := encoded.Encode(newValue); err != nil {
+			return fmt.Errorf("error encoding %s: %w", key, err)
+		}
+		encoded.HeadComment, encoded.LineComment = node.HeadComment, node.LineComment
+		*node = encoded
+		return nil
+	})
+}
+
+// canonicalKey returns key with the names of struct fields spelled as in YAML, e.g.
+// "software.manifestPath" for "SOFTWARE.MANIFESTPATH"; map keys are kept
+func canonicalKey(c *Config, key string) string {
+	v := reflect.ValueOf(c).Elem()
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		if v.Kind() != reflect.Struct {
+			break
+		}
+		for j := 0; j < v.NumField(); j++ {
+			name, inline := yamlName(v.Type().Field(j))
+			if !inline && strings.EqualFold(name, part) {
+				parts[i] = name
+				v = v.Field(j)
+				break
+			}
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// EnvPrefix starts the names of the environment variables that override settings
+const EnvPrefix = "A_LA_CARTE_"
+
+// EnvName returns the environment variable that overrides a setting: EnvPrefix followed
+// by the dotted key in upper case with dots as underscores
+//
+// # Example
+//
+//	config.EnvName("software.manifestPath") // "A_LA_CARTE_SOFTWARE_MANIFESTPATH"
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// EnvKeys returns the keys of the settings that environment variables can override:
+// the strings, switches, numbers and lists of strings of the config file, in file order.
+// Map entries such as profiles are not covered
+func EnvKeys() []string {
+	var keys []string
+	collectEnvKeys(reflect.TypeOf(Config{}), "", &keys)
+	return keys
+}
+
+// collectEnvKeys appends the keys of the settable fields of struct type t to keys
+func collectEnvKeys(t reflect.Type, prefix string, keys *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, inline := yamlName(field)
+		if name == "-" || inline {
+			continue
+		}
+		key := joinKey(prefix, name)
+		switch kind := field.Type.Kind(); {
+		case kind == reflect.Struct:
+			collectEnvKeys(field.Type, key, keys)
+		case kind == reflect.String, kind == reflect.Bool, kind == reflect.Int,
+			kind == reflect.Slice && field.Type.Elem().Kind() == reflect.String:
+			*keys = append(*keys, key)
+		}
+	}
+}
+
+// ApplyEnv overrides settings with the environment variables named after them (see
+// EnvName), e.g. A_LA_CARTE_UI_THEME=light. Values are parsed as by Set; empty
+// variables are ignored. Environment variables take precedence over the config file,
+// and command line flags over both
+func (c *Config) ApplyEnv(getenv func(string) string) error {
+	for _, key := range EnvKeys() {
+		value := getenv(EnvName(key))
+		if value == "" {
+			continue
+		}
+		if err := c.Set(key, value); err != nil {
+			return fmt.Errorf("%s: %w", EnvName(key), err)
+		}
+	}
+	return nil
+}
@@ -6,10 +6,27 @@ import (
 	"path/filepath"
 )
 
-// ValidateManifestPath checks if the manifest path exists and is readable
+// ValidateManifestPath checks if the manifest path exists and is readable.
+// With several manifests configured, each of them is checked.
+// A remote manifest is not checked, since it is fetched into the cache on startup.
 func (c *Config) ValidateManifestPath() error {
+	if c.Software.ManifestURL != "" {
+		return nil
+	}
+	if len(c.Software.Manifests) > 0 {
+		for _, m := range c.Software.Manifests {
+			if err := c.validateManifestFile(m.Path); err != nil {
+				return fmt.Errorf("manifest %s: %w", m.Name, err)
+			}
+		}
+		return nil
+	}
+	return c.validateManifestFile(c.Software.ManifestPath)
+}
+
+// validateManifestFile checks if a manifest file exists and is readable
+func (c *Config) validateManifestFile(manifestPath string) error {
 	// If the manifest path is relative, prepend the config directory
-	manifestPath := c.Software.ManifestPath
 	if !filepath.IsAbs(manifestPath) && c.ConfigPath != "" {
 		configDir := filepath.Dir(c.ConfigPath)
 		manifestPath = filepath.Join(configDir, manifestPath)
@@ -43,10 +60,44 @@ func (c *Config) ValidateManifestPath() error {
 	return nil
 }
 
-// ResolveManifestPath returns the absolute path to the manifest file
+// ResolveManifestPath returns the absolute path to the manifest file.
+// For a remote manifest, this is the path of the cached copy.
 func (c *Config) ResolveManifestPath() string {
-	manifestPath := c.Software.ManifestPath
+	if c.Software.ManifestURL != "" {
+		return ManifestCachePath()
+	}
+	return c.resolvePath(c.Software.ManifestPath)
+}
+
+// ManifestCachePath returns where a remote manifest is cached,
+// under $XDG_CACHE_HOME/a-la-carte (default ~/.cache/a-la-carte)
+func ManifestCachePath() string {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(os.TempDir(), DefaultConfigDirname, "manifest.yaml")
+		}
+		cacheHome = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(cacheHome, DefaultConfigDirname, "manifest.yaml")
+}
+
+// ResolveManifests returns the configured manifests in priority order with absolute paths.
+// Without named manifests, it returns the single manifest with an empty name.
+func (c *Config) ResolveManifests() []NamedManifest {
+	if len(c.Software.Manifests) == 0 || c.Software.ManifestURL != "" {
+		return []NamedManifest{{Path: c.ResolveManifestPath()}}
+	}
+	resolved := make([]NamedManifest, 0, len(c.Software.Manifests))
+	for _, m := range c.Software.Manifests {
+		resolved = append(resolved, NamedManifest{Name: m.Name, Path: c.resolvePath(m.Path)})
+	}
+	return resolved
+}
 
+// resolvePath makes a path from the config absolute
+func (c *Config) resolvePath(manifestPath string) string {
 	// If it's already absolute, return it
 	if filepath.IsAbs(manifestPath) {
 		return manifestPath
@@ -0,0 +1,267 @@
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ExitUsage is the exit code of a command used wrongly. When a command's Run returns it,
+// the CLI prints the command's usage after whatever error the command printed
+const ExitUsage = 2
+
+// Command is one subcommand of the CLI, e.g. "config"
+//
+// # Fields
+//   - Name:        The word selecting the command, e.g. "config"
+//   - Args:        A synopsis of the positional arguments, e.g. "get <key> | list"
+//   - Summary:     One line describing the command, for the command list
+//   - Flags:       Registers the command's own flags; the global flags are registered for every command
+//   - PassThrough: Hands every argument after the command name to Run unparsed, for commands
+//     that forward them to another program
+//   - Run:         Runs the command with the options and the positional arguments and returns the exit code
+type Command struct {
+	Name        string
+	Args        string
+	Summary     string
+	Flags       func(fs *flag.FlagSet, opts *Options)
+	PassThrough bool
+	Run         func(opts *Options, args []string) int
+}
+
+// CLI is a command line of subcommands with shared global flags. Global flags may come
+// before or after the command name; the command's own flags and positional arguments
+// may be mixed in any order. Without a command name, the default command runs, so
+// "chezmoi-a-la-carte --ascii" still starts the picker
+//
+// # Fields
+//   - Name:     The program name, for usage messages
+//   - Default:  The name of the command run when none is given
+//   - Commands: The commands, in the order the usage lists them
+//   - Footer:   Printed after the command and flag lists of the full usage
+//   - LongHelp: Shows the full documentation for --help-long and --man and returns the exit code
+type CLI struct {
+	Name     string
+	Default  string
+	Commands []*Command
+	Footer   string
+	LongHelp func(opts *Options) int
+}
+
+// New creates the CLI of the application, with tui as the default command and the
+// configuration, keyboard and example notes of Usage
+//
+// # Example
+//
+//	cli := flags.New(tuiCommand(), configCommand())
+//	os.Exit(cli.Run(os.Args[1:]))
+func New(commands ...*Command) *CLI {
+	return &CLI{Name: "chezmoi-a-la-carte", Default: "tui", Commands: commands, Footer: usageFooter}
+}
+
+// Lookup returns the command named name, or nil
+func (c *CLI) Lookup(name string) *Command {
+	for _, cmd := range c.Commands {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// Parse finds the command in args and parses the global flags and the command's flags
+//
+// # Returns
+//   - The command to run (the default command when args name none)
+//   - The parsed options
+//   - The positional arguments of the command, or every argument after the command
+//     name for a PassThrough command
+//   - An error for unknown flags or invalid flag values
+func (c *CLI) Parse(args []string) (*Command, *Options, []string, error) {
+	cmd, opts, positional, _, err := c.parse(args)
+	return cmd, opts, positional, err
+}
+
+// parse is Parse, also reporting whether args name the command
+func (c *CLI) parse(args []string) (*Command, *Options, []string, bool, error) {
+	// Global flags may come before the command name
+	opts := &Options{OutputFormat: "text"}
+	globals := flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	globals.SetOutput(io.Discard)
+	registerGlobals(globals, opts)
+	if err := globals.Parse(args); err == nil && globals.NArg() > 0 {
+		if cmd := c.Lookup(globals.Arg(0)); cmd != nil {
+			rest := globals.Args()[1:]
+			if cmd.PassThrough {
+				return cmd, opts, rest, true, nil
+			}
+			positional, err := c.parseCommand(cmd, opts, rest)
+			return cmd, opts, positional, true, err
+		}
+	}
+
+	cmd := c.Lookup(c.Default)
+	if cmd == nil {
+		return nil, nil, nil, false, fmt.Errorf("no default command %q", c.Default)
+	}
+	opts = &Options{OutputFormat: "text"}
+	positional, err := c.parseCommand(cmd, opts, args)
+	return cmd, opts, positional, false, err
+}
+
+// parseCommand parses the global and own flags of cmd from args into opts, keeping the
+// global flags already set, and returns the positional arguments
+func (c *CLI) parseCommand(cmd *Command, opts *Options, args []string) ([]string, error) {
+	fs := c.flagSet(cmd, opts)
+	fs.SetOutput(io.Discard)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// flagSet returns a flag set with the global flags and the flags of cmd bound to opts
+func (c *CLI) flagSet(cmd *Command, opts *Options) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.Name+" "+cmd.Name, flag.ContinueOnError)
+	registerGlobals(fs, opts)
+	if cmd.Flags != nil {
+		cmd.Flags(fs, opts)
+	}
+	return fs
+}
+
+// registerGlobals registers the flags every command accepts, keeping the values already
+// in opts as defaults
+func registerGlobals(fs *flag.FlagSet, opts *Options) {
+	fs.StringVar(&opts.ConfigPath, "config", opts.ConfigPath, "Path to configuration file")
+	fs.StringVar(&opts.ConfigPath, "c", opts.ConfigPath, "Path to configuration file (shorthand)")
+	fs.StringVar(&opts.ManifestPath, "manifest", opts.ManifestPath, "Path to software manifest file")
+	fs.StringVar(&opts.ManifestPath, "m", opts.ManifestPath, "Path to software manifest file (shorthand)")
+	fs.BoolVar(&opts.Debug, "debug", opts.Debug, "Enable debug mode")
+	fs.BoolVar(&opts.Debug, "d", opts.Debug, "Enable debug mode (shorthand)")
+	fs.StringVar(&opts.OutputFormat, "output", opts.OutputFormat, "Output format (text, json)")
+	fs.StringVar(&opts.OutputFormat, "o", opts.OutputFormat, "Output format (shorthand)")
+	fs.BoolVar(&opts.Quiet, "quiet", opts.Quiet, "Suppress non-essential output")
+	fs.BoolVar(&opts.Quiet, "q", opts.Quiet, "Suppress non-essential output (shorthand)")
+	fs.BoolVar(&opts.Strict, "strict", opts.Strict, "Reject manifests with keys entries do not declare, e.g. misspelled ones")
+	fs.BoolVar(&opts.Help, "help", opts.Help, "Show help message")
+	fs.BoolVar(&opts.Help, "h", opts.Help, "Show help message (shorthand)")
+	fs.BoolVar(&opts.HelpLong, "help-long", opts.HelpLong, "Show the full documentation (commands, key bindings, config and manifest formats) in a pager")
+	fs.BoolVar(&opts.Man, "man", opts.Man, "Print the full documentation as a man page")
+}
+
+// Run parses args, validates the options and runs the command, printing the usage for
+// --help and for wrong usage and the documentation for --help-long, and returns the
+// exit code
+//
+// # Example
+//
+//	os.Exit(flags.New(commands...).Run(os.Args[1:]))
+func (c *CLI) Run(args []string) int {
+	cmd, opts, positional, named, err := c.parse(args)
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	usage := func(w io.Writer) {
+		if named {
+			c.CommandUsage(w, cmd)
+		} else {
+			c.Usage(w)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		usage(os.Stderr)
+		return ExitUsage
+	}
+	if (opts.HelpLong || opts.Man) && c.LongHelp != nil {
+		return c.LongHelp(opts)
+	}
+	if opts.Help {
+		usage(os.Stdout)
+		return 0
+	}
+	if err := ValidateOptions(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return ExitUsage
+	}
+	code := cmd.Run(opts, positional)
+	if code == ExitUsage {
+		usage(os.Stderr)
+	}
+	return code
+}
+
+// Usage writes the full usage: the commands, the global flags, the flags of the default
+// command and the Footer
+func (c *CLI) Usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s [command] [options]\n", c.Name)
+	fmt.Fprintln(w, "\nA terminal user interface (TUI) for browsing and managing software manifests.")
+
+	fmt.Fprintln(w, "\nCommands:")
+	width := 0
+	for _, cmd := range c.Commands {
+		width = max(width, len(cmd.Name))
+	}
+	for _, cmd := range c.Commands {
+		summary := cmd.Summary
+		if cmd.Name == c.Default {
+			summary += " (default)"
+		}
+		fmt.Fprintf(w, "  %-*s  %s\n", width, cmd.Name, summary)
+	}
+	fmt.Fprintf(w, "\nRun '%s <command> --help' for the arguments and options of a command.\n", c.Name)
+
+	fmt.Fprintln(w, "\nGlobal options:")
+	globals := flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	registerGlobals(globals, &Options{OutputFormat: "text"})
+	globals.SetOutput(w)
+	globals.PrintDefaults()
+
+	if cmd := c.Lookup(c.Default); cmd != nil && cmd.Flags != nil {
+		fmt.Fprintf(w, "\nOptions of %s:\n", cmd.Name)
+		own := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+		cmd.Flags(own, &Options{})
+		own.SetOutput(w)
+		own.PrintDefaults()
+	}
+
+	if c.Footer != "" {
+		fmt.Fprint(w, c.Footer)
+	}
+}
+
+// CommandUsage writes the usage of one command: its synopsis, its own flags and the
+// global flags
+func (c *CLI) CommandUsage(w io.Writer, cmd *Command) {
+	synopsis := c.Name + " " + cmd.Name
+	if cmd.Flags != nil {
+		synopsis += " [options]"
+	}
+	if cmd.Args != "" {
+		synopsis += " " + cmd.Args
+	}
+	fmt.Fprintf(w, "Usage: %s\n\n%s\n", synopsis, cmd.Summary)
+	if cmd.Flags != nil {
+		fmt.Fprintln(w, "\nOptions:")
+		own := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+		cmd.Flags(own, &Options{})
+		own.SetOutput(w)
+		own.PrintDefaults()
+	}
+	fmt.Fprintln(w, "\nGlobal options:")
+	globals := flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	registerGlobals(globals, &Options{OutputFormat: "text"})
+	globals.SetOutput(w)
+	globals.PrintDefaults()
+}
@@ -0,0 +1,180 @@
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CompletionShells are the shells Completion writes scripts for
+var CompletionShells = []string{"bash", "zsh", "fish"}
+
+// Completion returns a tab-completion script for shell that completes the command
+// names and the flags of the command being typed, generated from the commands so new
+// commands and flags complete without touching the scripts
+//
+// # Example
+//
+//	script, err := cli.Completion("bash") // source <(chezmoi-a-la-carte completion bash)
+func (c *CLI) Completion(shell string) (string, error) {
+	switch shell {
+	case "bash":
+		return c.bashCompletion(), nil
+	case "zsh":
+		return c.zshCompletion(), nil
+	case "fish":
+		return c.fishCompletion(), nil
+	}
+	return "", fmt.Errorf("unknown shell %q: must be one of %s", shell, strings.Join(CompletionShells, ", "))
+}
+
+// completionFlag is one flag as completion scripts offer it
+type completionFlag struct {
+	name  string
+	usage string
+}
+
+// flagNames returns the flags of a flag set as typed ("--config", "-c"), long ones first
+func flagNames(fs *flag.FlagSet) []completionFlag {
+	var long, short []completionFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		if len(f.Name) == 1 {
+			short = append(short, completionFlag{"-" + f.Name, f.Usage})
+		} else {
+			long = append(long, completionFlag{"--" + f.Name, f.Usage})
+		}
+	})
+	return append(long, short...)
+}
+
+// globalFlags returns the global flags
+func (c *CLI) globalFlags() []completionFlag {
+	fs := flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	registerGlobals(fs, &Options{})
+	return flagNames(fs)
+}
+
+// commandFlags returns the own flags of cmd
+func commandFlags(cmd *Command) []completionFlag {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	if cmd.Flags != nil {
+		cmd.Flags(fs, &Options{})
+	}
+	return flagNames(fs)
+}
+
+// words joins the names of flags with spaces
+func words(flags []completionFlag) string {
+	names := make([]string, len(flags))
+	for i, f := range flags {
+		names[i] = f.name
+	}
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
+
+// commandNames returns the names of the commands
+func (c *CLI) commandNames() []string {
+	names := make([]string, len(c.Commands))
+	for i, cmd := range c.Commands {
+		names[i] = cmd.Name
+	}
+	return names
+}
+
+// funcName returns the name of the completion function of the scripts
+func (c *CLI) funcName() string {
+	return "_" + strings.ReplaceAll(c.Name, "-", "_")
+}
+
+// bashCompletion returns the bash completion script
+func (c *CLI) bashCompletion() string {
+	var b strings.Builder
+	globals := words(c.globalFlags())
+	fmt.Fprintf(&b, "# bash completion for %s\n", c.Name)
+	fmt.Fprintf(&b, "%s() {\n", c.funcName())
+	b.WriteString("\tlocal cur=${COMP_WORDS[COMP_CWORD]} cmd=\"\" i words\n")
+	b.WriteString("\tfor ((i = 1; i < COMP_CWORD; i++)); do\n")
+	b.WriteString("\t\tcase ${COMP_WORDS[i]} in\n")
+	fmt.Fprintf(&b, "\t\t%s)\n\t\t\tcmd=${COMP_WORDS[i]}\n\t\t\tbreak\n\t\t\t;;\n", strings.Join(c.commandNames(), "|"))
+	b.WriteString("\t\tesac\n\tdone\n")
+	b.WriteString("\tcase $cmd in\n")
+	for _, cmd := range c.Commands {
+		fmt.Fprintf(&b, "\t%s) words=%q ;;\n", cmd.Name, strings.TrimSpace(words(commandFlags(cmd))+" "+globals))
+	}
+	defaultFlags := ""
+	if cmd := c.Lookup(c.Default); cmd != nil {
+		defaultFlags = words(commandFlags(cmd)) + " "
+	}
+	fmt.Fprintf(&b, "\t*) words=%q ;;\n", strings.Join(c.commandNames(), " ")+" "+defaultFlags+globals)
+	b.WriteString("\tesac\n")
+	b.WriteString("\tCOMPREPLY=($(compgen -W \"$words\" -- \"$cur\"))\n")
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "complete -o default -F %s %s\n", c.funcName(), c.Name)
+	return b.String()
+}
+
+// zshCompletion returns the zsh completion script
+func (c *CLI) zshCompletion() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#compdef %s\n\n", c.Name)
+	fmt.Fprintf(&b, "%s() {\n", c.funcName())
+	b.WriteString("\tlocal -a commands flags\n\tcommands=(\n")
+	for _, cmd := range c.Commands {
+		fmt.Fprintf(&b, "\t\t%s\n", shellQuote(cmd.Name+":"+cmd.Summary))
+	}
+	b.WriteString("\t)\n")
+	fmt.Fprintf(&b, "\tflags=(%s)\n", words(c.globalFlags()))
+	fmt.Fprintf(&b, "\tlocal i=${words[1,CURRENT-1][(I)(%s)]}\n", strings.Join(c.commandNames(), "|"))
+	b.WriteString("\tif (( i == 0 )); then\n\t\t_describe command commands\n")
+	if cmd := c.Lookup(c.Default); cmd != nil && cmd.Flags != nil {
+		fmt.Fprintf(&b, "\t\tflags+=(%s)\n", words(commandFlags(cmd)))
+	}
+	b.WriteString("\telse\n\t\tcase ${words[i]} in\n")
+	for _, cmd := range c.Commands {
+		if own := words(commandFlags(cmd)); own != "" {
+			fmt.Fprintf(&b, "\t\t%s) flags+=(%s) ;;\n", cmd.Name, own)
+		}
+	}
+	b.WriteString("\t\tesac\n\tfi\n")
+	b.WriteString("\tcompadd -- $flags\n\t_files\n}\n\n")
+	fmt.Fprintf(&b, "compdef %s %s\n", c.funcName(), c.Name)
+	return b.String()
+}
+
+// shellQuote quotes s in single quotes for zsh and fish
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishCompletion returns the fish completion script
+func (c *CLI) fishCompletion() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# fish completion for %s\n", c.Name)
+	for _, cmd := range c.Commands {
+		fmt.Fprintf(&b, "complete -c %s -n __fish_use_subcommand -f -a %s -d %s\n", c.Name, cmd.Name, shellQuote(cmd.Summary))
+	}
+	for _, f := range c.globalFlags() {
+		fmt.Fprintf(&b, "complete -c %s %s -d %s\n", c.Name, fishFlag(f.name), shellQuote(f.usage))
+	}
+	for _, cmd := range c.Commands {
+		condition := "__fish_seen_subcommand_from " + cmd.Name
+		if cmd.Name == c.Default {
+			condition = "__fish_use_subcommand; or " + condition
+		}
+		for _, f := range commandFlags(cmd) {
+			fmt.Fprintf(&b, "complete -c %s -n %s %s -d %s\n", c.Name, shellQuote(condition), fishFlag(f.name), shellQuote(f.usage))
+		}
+	}
+	return b.String()
+}
+
+// fishFlag returns the option of fish's complete describing a flag: -l for long
+// flags, -s for single-letter ones
+func fishFlag(name string) string {
+	if strings.HasPrefix(name, "--") {
+		return "-l " + strings.TrimPrefix(name, "--")
+	}
+	return "-s " + strings.TrimPrefix(name, "-")
+}
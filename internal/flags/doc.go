@@ -0,0 +1,189 @@
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Doc is the built-in documentation of --help-long: sections of paragraphs and items,
+// built from the commands, the keymap and the structs of the config file and the
+// manifest, and rendered as text for the pager or as a man page
+//
+// # Fields
+//   - Name:     The program name
+//   - Summary:  What the program does, in one line
+//   - Sections: The sections, e.g. COMMANDS
+type Doc struct {
+	Name     string
+	Summary  string
+	Sections []DocSection
+}
+
+// DocSection is one section of a Doc
+//
+// # Fields
+//   - Title: The heading, e.g. "KEY BINDINGS"
+//   - Text:  Paragraphs before the items
+//   - Items: Terms and their descriptions, e.g. a flag and what it does
+type DocSection struct {
+	Title string
+	Text  []string
+	Items []DocItem
+}
+
+// DocItem is a term and its description in a DocSection
+type DocItem struct {
+	Term string
+	Desc string
+}
+
+// Sections returns the sections of the documentation the CLI knows: the synopsis, the
+// commands with their arguments and flags, and the global flags
+func (c *CLI) Sections() []DocSection {
+	synopsis := DocSection{Title: "SYNOPSIS", Text: []string{c.Name + " [command] [options] [arguments]"}}
+
+	commands := DocSection{
+		Title: "COMMANDS",
+		Text:  []string{fmt.Sprintf("Without a command, %s runs. Global options may come before or after the command name.", c.Default)},
+	}
+	for _, cmd := range c.Commands {
+		term := cmd.Name
+		if cmd.Args != "" {
+			term += " " + cmd.Args
+		}
+		desc := cmd.Summary
+		if cmd.Flags != nil {
+			fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+			cmd.Flags(fs, &Options{})
+			var names []string
+			for _, f := range flagNames(fs) {
+				names = append(names, f.name)
+			}
+			desc += ". Options: " + strings.Join(names, ", ")
+		}
+		commands.Items = append(commands.Items, DocItem{Term: term, Desc: desc})
+	}
+
+	globals := DocSection{Title: "GLOBAL OPTIONS"}
+	fs := flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	registerGlobals(fs, &Options{})
+	globals.Items = flagItems(fs)
+
+	sections := []DocSection{synopsis, commands, globals}
+	if cmd := c.Lookup(c.Default); cmd != nil && cmd.Flags != nil {
+		own := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+		cmd.Flags(own, &Options{})
+		sections = append(sections, DocSection{Title: strings.ToUpper(cmd.Name) + " OPTIONS", Items: flagItems(own)})
+	}
+	return sections
+}
+
+// flagItems returns the long flags of fs as items, with the single-letter aliases that
+// set the same variable
+func flagItems(fs *flag.FlagSet) []DocItem {
+	aliases := make(map[uintptr]string)
+	fs.VisitAll(func(f *flag.Flag) {
+		if len(f.Name) == 1 {
+			aliases[reflect.ValueOf(f.Value).Pointer()] = "-" + f.Name
+		}
+	})
+	var items []DocItem
+	fs.VisitAll(func(f *flag.Flag) {
+		if len(f.Name) == 1 {
+			return
+		}
+		term := "--" + f.Name
+		if alias, ok := aliases[reflect.ValueOf(f.Value).Pointer()]; ok {
+			term = alias + ", " + term
+		}
+		items = append(items, DocItem{Term: term, Desc: f.Usage})
+	})
+	return items
+}
+
+// Text renders the documentation as plain text wrapped at width columns, like a man page
+// in a terminal
+func (d *Doc) Text(width int) string {
+	width = max(width, 40)
+	var b strings.Builder
+	b.WriteString("NAME\n")
+	b.WriteString(indent(d.Name+" - "+d.Summary, "    ", width))
+	for _, section := range d.Sections {
+		b.WriteString("\n" + section.Title + "\n")
+		for i, text := range section.Text {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(indent(text, "    ", width))
+		}
+		for i, item := range section.Items {
+			if i > 0 || len(section.Text) > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(indent(item.Term, "    ", width))
+			if item.Desc != "" {
+				b.WriteString(indent(item.Desc, "        ", width))
+			}
+		}
+	}
+	return b.String()
+}
+
+// indent wraps text at spaces to fit width columns, with every line starting with
+// prefix. Unlike ansi.Wordwrap it never breaks at hyphens, which would split flags
+func indent(text, prefix string, width int) string {
+	var b strings.Builder
+	line := prefix
+	for _, word := range strings.Fields(text) {
+		if line != prefix && ansi.StringWidth(line)+1+ansi.StringWidth(word) > width {
+			b.WriteString(line + "\n")
+			line = prefix
+		}
+		if line != prefix {
+			line += " "
+		}
+		line += word
+	}
+	b.WriteString(line + "\n")
+	return b.String()
+}
+
+// Man renders the documentation as a man page in roff, for man -l or a manpath
+// directory
+//
+// # Example
+//
+//	chezmoi-a-la-carte --man > chezmoi-a-la-carte.1 && man -l chezmoi-a-la-carte.1
+func (d *Doc) Man(version string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH %s 1 \"\" \"%s %s\"\n", strings.ToUpper(d.Name), d.Name, version)
+	b.WriteString(".SH NAME\n")
+	fmt.Fprintf(&b, "%s \\- %s\n", roff(d.Name), roff(d.Summary))
+	for _, section := range d.Sections {
+		fmt.Fprintf(&b, ".SH %s\n", roff(section.Title))
+		for _, text := range section.Text {
+			fmt.Fprintf(&b, ".PP\n%s\n", roff(text))
+		}
+		for _, item := range section.Items {
+			fmt.Fprintf(&b, ".TP\n\\fB%s\\fR\n%s\n", roff(item.Term), roff(item.Desc))
+		}
+	}
+	return b.String()
+}
+
+// roff escapes text for roff: backslashes, dashes, and dots or quotes starting a line
+func roff(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\e`)
+	text = strings.ReplaceAll(text, "-", `\-`)
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,12 +1,12 @@
-// Package flags provides command line flag handling for the a-la-carte application
+// Package flags provides the command line of the a-la-carte application: subcommands
+// such as tui, provision, config and doctor, each with its own flags, and the global
+// flags they share
 package flags
 
-import (
-	"flag"
-	"fmt"
-)
+import "flag"
 
-// Options defines the command line options for the application
+// Options defines the command line options for the application: the global flags, and
+// the flags of the tui command
 type Options struct {
 	// ConfigPath is the path to the configuration file
 	ConfigPath string
@@ -23,79 +23,121 @@ type Options struct {
 	// Help shows the help message and exits
 	Help bool
 
+	// HelpLong shows the full documentation in a pager and exits
+	HelpLong bool
+
+	// Man prints the full documentation as a man page and exits
+	Man bool
+
 	// OutputFormat defines the output format for non-interactive commands
 	OutputFormat string
 
 	// Quiet suppresses non-essential output
 	Quiet bool
 
+	// Strict rejects manifests with keys entries do not declare
+	Strict bool
+
 	// NoEmojis disables emoji display in the UI
 	NoEmojis bool
-}
 
-// Parse parses command line flags and returns the options
-func Parse() *Options {
-	opts := &Options{}
-
-	// Define long-form flags
-	flag.StringVar(&opts.ConfigPath, "config", "", "Path to configuration file")
-	flag.StringVar(&opts.ManifestPath, "manifest", "", "Path to software manifest file")
-	flag.BoolVar(&opts.Debug, "debug", false, "Enable debug mode")
-	flag.BoolVar(&opts.Version, "version", false, "Show version and exit")
-	flag.BoolVar(&opts.Help, "help", false, "Show help message")
-	flag.StringVar(&opts.OutputFormat, "output", "text", "Output format (text, json)")
-	flag.BoolVar(&opts.Quiet, "quiet", false, "Suppress non-essential output")
-	flag.BoolVar(&opts.NoEmojis, "no-emojis", false, "Disable emojis in the UI")
-
-	// Define short aliases
-	flag.StringVar(&opts.ConfigPath, "c", "", "Path to configuration file (shorthand)")
-	flag.StringVar(&opts.ManifestPath, "m", "", "Path to software manifest file (shorthand)")
-	flag.BoolVar(&opts.Debug, "d", false, "Enable debug mode (shorthand)")
-	flag.BoolVar(&opts.Version, "v", false, "Show version and exit (shorthand)")
-	flag.BoolVar(&opts.Help, "h", false, "Show help message (shorthand)")
-	flag.StringVar(&opts.OutputFormat, "o", "text", "Output format (shorthand)")
-	flag.BoolVar(&opts.Quiet, "q", false, "Suppress non-essential output (shorthand)")
-	flag.BoolVar(&opts.NoEmojis, "E", false, "Disable emojis in the UI (shorthand)")
-
-	flag.Parse()
-	return opts
+	// ASCII replaces emojis, box-drawing borders and arrows with plain ASCII
+	ASCII bool
+
+	// A11y renders the UI for screen readers (implies ASCII)
+	A11y bool
+
+	// NoTitle leaves the terminal title alone
+	NoTitle bool
 }
 
-// Usage prints usage information
-func Usage() {
-	fmt.Println("Usage: chezmoi-a-la-carte [options]")
-	fmt.Println("\nA terminal user interface (TUI) for browsing and managing software manifests.")
-	fmt.Println("\nOptions:")
-	flag.PrintDefaults()
-
-	fmt.Println("\nConfiguration:")
-	fmt.Println("  Configuration is loaded from the following sources in order of precedence:")
-	fmt.Println("  1. Environment variable: A_LA_CARTE_CONFIG=/path/to/config.yml")
-	fmt.Println("  2. Command line flag: --config /path/to/config.yml")
-	fmt.Println("  3. Default location: $HOME/.config/a-la-carte/a-la-carte.yml")
-	fmt.Println("  4. Built-in defaults")
-
-	fmt.Println("\nKeyboard Controls:")
-	fmt.Println("  ↑/↓/j/k:  Move selection")
-	fmt.Println("  /:        Start search")
-	fmt.Println("  q:        Quit")
-	fmt.Println("  Enter:    Show details")
-	fmt.Println("  esc:      Cancel search")
-	fmt.Println("  TAB:      Toggle focus between list and details")
-
-	fmt.Println("\nExamples:")
-	fmt.Println("  # Run with a custom config file")
-	fmt.Println("  chezmoi-a-la-carte --config /path/to/config.yml")
-	fmt.Println()
-	fmt.Println("  # Run with a specific manifest file")
-	fmt.Println("  chezmoi-a-la-carte --manifest /path/to/software.yml")
-	fmt.Println()
-	fmt.Println("  # Run in debug mode")
-	fmt.Println("  chezmoi-a-la-carte --debug")
-	fmt.Println()
-	fmt.Println("  # Disable emoji display in the UI")
-	fmt.Println("  chezmoi-a-la-carte --no-emojis")
-	fmt.Println()
-	fmt.Println("  # Output in JSON format (for scripting)")
-	fmt.Println("  chezmoi-a-la-carte --output json --quiet")
+// TUIFlags registers the flags of the tui command, which starts the picker
+func TUIFlags(fs *flag.FlagSet, opts *Options) {
+	fs.BoolVar(&opts.Version, "version", false, "Show version and exit")
+	fs.BoolVar(&opts.Version, "v", false, "Show version and exit (shorthand)")
+	fs.BoolVar(&opts.NoEmojis, "no-emojis", false, "Disable emojis in the UI")
+	fs.BoolVar(&opts.NoEmojis, "E", false, "Disable emojis in the UI (shorthand)")
+	fs.BoolVar(&opts.ASCII, "ascii", false, "Use plain ASCII instead of emojis, box drawing and arrows")
+	fs.BoolVar(&opts.A11y, "a11y", false, "Screen-reader-friendly output: no decorative borders or emojis, labelled panes, [focused] on the focused item")
+	fs.BoolVar(&opts.NoTitle, "no-title", false, "Do not set the terminal title to the picker state")
 }
+
+// usageFooter follows the command and flag lists in the full usage
+const usageFooter = `
+Configuration:
+  Configuration is loaded from the following sources in order of precedence:
+  1. Environment variable: A_LA_CARTE_CONFIG=/path/to/config.yml
+  2. Command line flag: --config /path/to/config.yml
+  3. Default location: $HOME/.config/a-la-carte/a-la-carte.yml
+  4. Built-in defaults
+
+Keyboard Controls:
+  ↑/↓/j/k:  Move selection
+  /:        Start search
+  q:        Quit
+  Enter:    Show details
+  esc:      Cancel search
+  p:        Switch profile
+  u:        Undo the last selection change (ctrl+r: redo)
+  U:        Refresh the remote manifest when an update is available
+  d/Del:    Remove from selection (selected pane)
+  D:        Clear selection (selected pane)
+  v:        Mark a range in the selected pane
+  TAB:      Toggle focus between list and details
+
+Examples:
+  # Run with a custom config file
+  chezmoi-a-la-carte --config /path/to/config.yml
+
+  # Run with a specific manifest file
+  chezmoi-a-la-carte --manifest /path/to/software.yml
+
+  # Run in debug mode
+  chezmoi-a-la-carte --debug
+
+  # Disable emoji display in the UI
+  chezmoi-a-la-carte --no-emojis
+
+  # Draw the UI in plain ASCII, e.g. on a serial console
+  chezmoi-a-la-carte --ascii
+
+  # Use the picker with a screen reader
+  chezmoi-a-la-carte tui --a11y
+
+  # Install the selection without the confirmation step
+  chezmoi-a-la-carte provision --yes
+
+  # Print the selection as a Brewfile
+  chezmoi-a-la-carte export brewfile
+
+  # Check the config file, the manifests and the provisioner
+  chezmoi-a-la-carte doctor
+
+  # Read the full documentation, or install it as a man page
+  chezmoi-a-la-carte --help-long
+  chezmoi-a-la-carte --man > ~/.local/share/man/man1/chezmoi-a-la-carte.1
+
+  # Enable tab completion in bash
+  source <(chezmoi-a-la-carte completion bash)
+
+  # Switch to the light theme without editing the config file
+  chezmoi-a-la-carte config set ui.theme light
+
+  # Find out when libfoo last failed and why
+  chezmoi-a-la-carte logs search libfoo
+
+  # Start a manifest entry for a new command-line tool
+  chezmoi-a-la-carte new --template cli-tool ripgrep >> software.yml
+
+  # Start a manifest entry from a GitHub repository
+  chezmoi-a-la-carte new-entry --github https://github.com/sharkdp/bat >> software.yml
+
+  # Add entries for the packages of a Brewfile the manifest does not have yet
+  chezmoi-a-la-carte import brewfile ./Brewfile >> software.yml
+
+  # Turn the manually installed apt packages into a profile
+  apt-mark showmanual | chezmoi-a-la-carte import apt --profile server -
+
+  # Print a setting as JSON (for scripting)
+  chezmoi-a-la-carte config get ui.theme --output json
+`
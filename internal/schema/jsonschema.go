@@ -0,0 +1,190 @@
+package schema
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Draft is the JSON Schema version of the schemas JSONSchema returns; it is the newest
+// one editors such as VS Code and yaml-language-server fully support.
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchema returns a JSON Schema of the YAML form of v, e.g. of the config file for a
+// *config.Config, for editors to validate and complete the file with. Keys come from
+// the yaml tags of the structs, descriptions from their doc tags, and the non-empty
+// values of v become defaults, so a value holding the built-in defaults documents them.
+//
+// A schema tag adds constraints to a field, separated by commas; for a list they apply
+// to its items:
+//   - enum=a|b:     The value is one of a or b
+//   - format=uri:   The value is a URI (any JSON Schema format)
+//   - pattern=re:   The value matches the regular expression re
+//   - minimum=n:    The value is at least n
+//   - maximum=n:    The value is at most n
+//   - required:     The key must be present
+//
+// Structs are closed: editors flag keys they do not declare, e.g. misspelled ones.
+//
+// # Example
+//
+//	type Settings struct {
+//		Theme string `yaml:"theme" doc:"Color scheme" schema:"enum=light|dark"`
+//	}
+//	s := schema.JSONSchema("Settings", &Settings{Theme: "dark"})
+//	// {"$schema": ..., "title": "Settings", "type": "object", "additionalProperties": false,
+//	//  "properties": {"theme": {"type": "string", "description": "Color scheme",
+//	//  "enum": ["light", "dark"], "default": "dark"}}}
+func JSONSchema(title string, v any) map[string]any {
+	s := build(reflect.TypeOf(v), reflect.ValueOf(v))
+	s["$schema"] = Draft
+	s["title"] = title
+	return s
+}
+
+// build returns the schema of type t; v is a value of t to take defaults from, or the
+// zero Value for none
+func build(t reflect.Type, v reflect.Value) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		if v.IsValid() {
+			v = v.Elem()
+		}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		s := map[string]any{"type": "object", "additionalProperties": false}
+		props := make(map[string]any)
+		properties(t, v, s, props)
+		s["properties"] = props
+		return s
+	case reflect.Slice:
+		items := build(t.Elem(), reflect.Value{})
+		list := map[string]any{"type": "array", "items": items}
+		if UnmarshalsScalar(t) {
+			return map[string]any{"anyOf": []any{items, list}}
+		}
+		return list
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": build(t.Elem(), reflect.Value{})}
+	}
+	return map[string]any{"type": jsonType(t)}
+}
+
+// properties adds the fields of struct type t to props, the properties of schema s,
+// with inlined structs merged in and an inlined map as the schema of s's other keys
+func properties(t reflect.Type, v reflect.Value, s, props map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, inline := Name(field)
+		if name == "-" {
+			continue
+		}
+		var fv reflect.Value
+		if v.IsValid() {
+			fv = v.Field(i)
+		}
+		ft := deref(field.Type)
+		switch {
+		case inline && ft.Kind() == reflect.Struct:
+			if fv.IsValid() && fv.Kind() == reflect.Pointer {
+				fv = fv.Elem()
+			}
+			properties(ft, fv, s, props)
+		case inline && ft.Kind() == reflect.Map:
+			s["additionalProperties"] = annotate(build(ft.Elem(), reflect.Value{}), field, reflect.Value{})
+		default:
+			props[name] = annotate(build(field.Type, fv), field, fv)
+			if slices.Contains(tagOptions(field), "required") {
+				required, _ := s["required"].([]string)
+				s["required"] = append(required, name)
+			}
+		}
+	}
+}
+
+// annotate adds the description, constraints and default of a struct field to its
+// schema s and returns s
+func annotate(s map[string]any, field reflect.StructField, v reflect.Value) map[string]any {
+	if doc := field.Tag.Get("doc"); doc != "" {
+		s["description"] = doc
+	}
+	for _, opt := range tagOptions(field) {
+		key, value, _ := strings.Cut(opt, "=")
+		switch key {
+		case "enum":
+			var values []any
+			for _, item := range strings.Split(value, "|") {
+				values = append(values, item)
+			}
+			constrain(s, key, values)
+		case "minimum", "maximum":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				constrain(s, key, n)
+			}
+		case "format", "pattern":
+			constrain(s, key, value)
+		}
+	}
+	if isDefault(v) {
+		s["default"] = v.Interface()
+	}
+	return s
+}
+
+// constrain sets a constraint on the values schema s accepts: on s itself, on the items
+// of a list, or on every alternative
+func constrain(s map[string]any, key string, value any) {
+	switch {
+	case s["anyOf"] != nil:
+		for _, alt := range s["anyOf"].([]any) {
+			constrain(alt.(map[string]any), key, value)
+		}
+	case s["type"] == "array":
+		constrain(s["items"].(map[string]any), key, value)
+	default:
+		s[key] = value
+	}
+}
+
+// isDefault reports whether v is a value worth documenting as a default: set, not
+// empty, and not a section, whose keys have defaults of their own
+func isDefault(v reflect.Value) bool {
+	if !v.IsValid() || v.IsZero() {
+		return false
+	}
+	switch deref(v.Type()).Kind() {
+	case reflect.Struct:
+		return false
+	case reflect.Slice, reflect.Map:
+		return v.Len() > 0
+	}
+	return true
+}
+
+// tagOptions returns the comma-separated options of the schema tag of a struct field
+func tagOptions(field reflect.StructField) []string {
+	tag := field.Tag.Get("schema")
+	if tag == "" {
+		return nil
+	}
+	return strings.Split(tag, ",")
+}
+
+// jsonType returns the JSON Schema type of scalar type t
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	}
+	return "string"
+}
@@ -0,0 +1,161 @@
+// Package schema describes the YAML files of the application, the config file and the
+// manifest, from the Go structs they are decoded into: yaml tags name the keys and doc
+// tags describe them, so the built-in documentation follows the structs.
+//
+// # Example
+//
+//	type Settings struct {
+//		Theme string `yaml:"theme" doc:"Color scheme: light or dark"`
+//	}
+//	schema.Fields(reflect.TypeOf(Settings{})) // [{theme string Color scheme: light or dark}]
+package schema
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MapKey stands for the keys of a map in the Key of a Field, e.g. "profiles.<name>".
+const MapKey = "<name>"
+
+// Field describes one key of a YAML file.
+//
+// # Fields
+//   - Key:  The dotted path of the key, e.g. "ui.theme"; MapKey stands for map keys
+//     and [] for the items of a list, e.g. "software.manifests[].path"
+//   - Type: The type of the value as users write it, e.g. "list of strings"
+//   - Doc:  The doc tag of the struct field
+type Field struct {
+	Key  string
+	Type string
+	Doc  string
+}
+
+// Fields returns the keys of the YAML form of struct type t, in struct order, with the
+// sections (nested structs) before their keys. Fields tagged yaml:"-" are skipped;
+// inlined structs and maps are listed under their parent
+func Fields(t reflect.Type) []Field {
+	var fields []Field
+	collect(t, "", &fields)
+	return fields
+}
+
+// collect appends the fields of struct type t under prefix to fields
+func collect(t reflect.Type, prefix string, fields *[]Field) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, inline := Name(field)
+		if name == "-" {
+			continue
+		}
+		key := prefix
+		if !inline {
+			key = join(prefix, name)
+		}
+		doc := field.Tag.Get("doc")
+		ft := deref(field.Type)
+		switch {
+		case inline && ft.Kind() == reflect.Struct:
+			collect(ft, key, fields)
+		case inline && ft.Kind() == reflect.Map:
+			*fields = append(*fields, Field{Key: join(key, MapKey), Type: TypeName(ft.Elem()), Doc: doc})
+			collectNested(ft.Elem(), join(key, MapKey), fields)
+		default:
+			*fields = append(*fields, Field{Key: key, Type: TypeName(ft), Doc: doc})
+			switch ft.Kind() {
+			case reflect.Struct:
+				collect(ft, key, fields)
+			case reflect.Map:
+				collectNested(ft.Elem(), join(key, MapKey), fields)
+			case reflect.Slice:
+				collectNested(ft.Elem(), key+"[]", fields)
+			}
+		}
+	}
+}
+
+// collectNested appends the fields of the values of a map or the items of a list when
+// they are structs
+func collectNested(t reflect.Type, prefix string, fields *[]Field) {
+	if t = deref(t); t.Kind() == reflect.Struct {
+		collect(t, prefix, fields)
+	}
+}
+
+// Name returns the name of a struct field in YAML (the lower-cased field name without
+// a yaml tag), and whether it is inlined
+func Name(field reflect.StructField) (string, bool) {
+	name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name, strings.Contains(opts, "inline")
+}
+
+// TypeName returns the type of YAML values decoded into t, as users write them, e.g.
+// "string", "list of strings" or "string or list of strings" for a slice type that
+// also decodes a single string
+func TypeName(t reflect.Type) string {
+	t = deref(t)
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Struct:
+		return "section"
+	case reflect.Slice:
+		if UnmarshalsScalar(t) {
+			return TypeName(t.Elem()) + " or list of " + plural(TypeName(t.Elem()))
+		}
+		return "list of " + plural(TypeName(t.Elem()))
+	case reflect.Map:
+		return "map of " + plural(TypeName(t.Elem()))
+	}
+	return t.Kind().String()
+}
+
+// UnmarshalsScalar reports whether slice type t decodes its own YAML, taken as also
+// accepting a single item, as app.StringOrSlice does
+func UnmarshalsScalar(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && reflect.PointerTo(t).Implements(reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem())
+}
+
+// plural returns the plural of a type name, bracketing alternatives
+func plural(name string) string {
+	switch {
+	case strings.Contains(name, " or "):
+		return "(" + name + ")"
+	case strings.HasPrefix(name, "list of "), strings.HasPrefix(name, "map of "):
+		return name[:strings.Index(name, " of ")] + "s" + name[strings.Index(name, " of "):]
+	case name == "boolean", name == "integer", name == "number", name == "string", name == "section":
+		return name + "s"
+	}
+	return name
+}
+
+// deref returns the type pointers of t point to
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// join appends name to a dotted key
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
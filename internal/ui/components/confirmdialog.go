@@ -0,0 +1,132 @@
+// confirmdialog.go provides a dialog asking a question with a few answers.
+package components
+
+import (
+	"strings"
+
+	"a-la-carte/internal/ui/core"
+	"a-la-carte/internal/ui/patterns"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ConfirmChoice is an answer offered by a confirm dialog.
+//
+// # Fields
+//   - Key:   The key that picks the answer, e.g. "s"
+//   - Label: What the answer does, e.g. "Save and quit"
+type ConfirmChoice struct {
+	Key   string
+	Label string
+}
+
+// ConfirmedMsg is returned as a command result when the user picks an answer. Esc
+// closes the dialog without one.
+type ConfirmedMsg struct {
+	ID     string // the question, as passed to Show
+	Choice string // the Key of the answer
+}
+
+// ConfirmDialogModel represents a dialog asking a question. An answer is picked with
+// its key, or highlighted with the arrow keys or Tab and picked with Enter.
+type ConfirmDialogModel struct {
+	id      string
+	title   string
+	message string
+	choices []ConfirmChoice
+	cursor  int
+	visible bool
+}
+
+// NewConfirmDialogModel creates a hidden confirm dialog.
+func NewConfirmDialogModel() *ConfirmDialogModel {
+	return &ConfirmDialogModel{}
+}
+
+// Init does nothing for this model.
+func (m *ConfirmDialogModel) Init() tea.Cmd { return nil }
+
+// Update handles key input while the dialog is visible.
+func (m *ConfirmDialogModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || !m.visible {
+		return m, nil
+	}
+	switch key := keyMsg.String(); key {
+	case "esc":
+		m.Hide()
+	case "up", "left", "shift+tab":
+		m.cursor = (m.cursor + len(m.choices) - 1) % len(m.choices)
+	case "down", "right", "tab":
+		m.cursor = (m.cursor + 1) % len(m.choices)
+	case "enter":
+		return m, m.choose(m.choices[m.cursor].Key)
+	default:
+		for _, choice := range m.choices {
+			if choice.Key == key {
+				return m, m.choose(key)
+			}
+		}
+	}
+	return m, nil
+}
+
+// choose closes the dialog and returns the command reporting the answer.
+func (m *ConfirmDialogModel) choose(key string) tea.Cmd {
+	m.Hide()
+	id := m.id
+	return func() tea.Msg { return ConfirmedMsg{ID: id, Choice: key} }
+}
+
+// Show asks a question, highlighting the first answer.
+//
+// # Parameters
+//   - id:      Identifies the question in the ConfirmedMsg, e.g. "quit"
+//   - title:   The dialog title
+//   - message: The question
+//   - choices: The answers, at least one
+func (m *ConfirmDialogModel) Show(id, title, message string, choices ...ConfirmChoice) {
+	m.id, m.title, m.message, m.choices = id, title, message, choices
+	m.cursor = 0
+	m.visible = true
+}
+
+// Hide hides the dialog.
+func (m *ConfirmDialogModel) Hide() {
+	m.visible = false
+}
+
+// IsVisible returns whether the dialog is visible.
+func (m *ConfirmDialogModel) IsVisible() bool {
+	return m.visible
+}
+
+// View renders the dialog with the highlighted answer marked.
+func (m *ConfirmDialogModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	styles := core.CurrentStyles()
+
+	answers := make([]string, len(m.choices))
+	for i, choice := range m.choices {
+		label := choice.Key + ": " + choice.Label
+		if i == m.cursor {
+			answers[i] = styles.ActiveItemStyle.Render("> " + label)
+		} else {
+			answers[i] = styles.ItemStyle.Render("  " + label)
+		}
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		styles.TitleHeaderStyle.Render(m.title),
+		styles.ItemStyle.Render(m.message),
+		"",
+		strings.Join(answers, "\n"),
+		styles.FooterStyle.Render(core.Glyphs("↑/↓: Move | Enter: Choose | Esc: Cancel")),
+	)
+	return patterns.Dialog(core.StringModel(content)).View()
+}
@@ -94,12 +94,12 @@ func getIndicator(scroll, maxScroll int, focused bool) string {
 	indicatorStyle := core.IndicatorStyle(focused) // Updated to use core.IndicatorStyle(focused)
 
 	if scroll == 0 {
-		return indicatorStyle.Render("↓")
+		return indicatorStyle.Render(core.Glyphs("↓"))
 	}
 	if scroll == maxScroll {
-		return indicatorStyle.Render("↑")
+		return indicatorStyle.Render(core.Glyphs("↑"))
 	}
-	return indicatorStyle.Render("↑↓")
+	return indicatorStyle.Render(core.Glyphs("↑↓"))
 }
 
 // Helper: prepareVisibleLines prepares the visible lines for the focused panel
@@ -148,13 +148,13 @@ func renderNotFocusedPanel(data *DetailsPanelData, width, height int) string {
 	if maxScroll > 0 {
 		// Use muted style for not-focused indicators
 		indicatorStyle := core.IndicatorStyle(false) // Updated to use core.IndicatorStyle(false)
-		indicatorLine = "  " + indicatorStyle.Render("▼") + " more..."
+		indicatorLine = "  " + indicatorStyle.Render(core.Glyphs("▼")) + " more..."
 		if runewidth.StringWidth(indicatorLine) > width {
-			maxLen := width - runewidth.StringWidth("  ") - runewidth.StringWidth(indicatorStyle.Render("▼"))
+			maxLen := width - runewidth.StringWidth("  ") - runewidth.StringWidth(indicatorStyle.Render(core.Glyphs("▼")))
 			if maxLen > 0 {
-				indicatorLine = "  " + indicatorStyle.Render("▼") + " " + truncateString("more...", maxLen)
+				indicatorLine = "  " + indicatorStyle.Render(core.Glyphs("▼")) + " " + truncateString("more...", maxLen)
 			} else {
-				indicatorLine = "  " + indicatorStyle.Render("▼")
+				indicatorLine = "  " + indicatorStyle.Render(core.Glyphs("▼"))
 			}
 		}
 	}
@@ -0,0 +1,160 @@
+// entryeditor.go provides a form dialog for creating and editing manifest entries.
+package components
+
+import (
+	"a-la-carte/internal/ui/core"
+	"a-la-carte/internal/ui/patterns"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// EntryFormData holds the text of the entry editor's fields.
+//
+// # Fields
+//   - Key:        The manifest key
+//   - Name, Desc: The display name and description
+//   - Installers: Comma-separated installer=package pairs, e.g. "apt=ripgrep, brew=ripgrep"
+//   - Groups:     Comma-separated group names
+type EntryFormData struct {
+	Key        string
+	Name       string
+	Desc       string
+	Installers string
+	Groups     string
+}
+
+// EntryEditorSubmittedMsg is returned as a command result when the user presses Enter.
+// Original is the key being edited, or "" for a new entry.
+type EntryEditorSubmittedMsg struct {
+	Original string
+	Data     EntryFormData
+}
+
+// EntryEditorCancelledMsg is returned as a command result when the user presses Esc.
+type EntryEditorCancelledMsg struct{}
+
+// entryFieldLabels are the labels of the editor's fields, in the order they are shown.
+var entryFieldLabels = []string{"Key", "Name", "Description", "Installers", "Groups"}
+
+// EntryEditorModel represents a form dialog with one text field per entry field.
+type EntryEditorModel struct {
+	title    string
+	original string
+	fields   [][]rune
+	focus    int
+	errMsg   string
+	visible  bool
+}
+
+// NewEntryEditorModel creates a new, hidden entry editor.
+func NewEntryEditorModel() *EntryEditorModel {
+	return &EntryEditorModel{fields: make([][]rune, len(entryFieldLabels))}
+}
+
+// Init does nothing for this model.
+func (m *EntryEditorModel) Init() tea.Cmd { return nil }
+
+// Update handles key input while the editor is visible. Tab and the arrow keys move
+// between fields.
+func (m *EntryEditorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || !m.visible {
+		return m, nil
+	}
+	switch keyMsg.Type {
+	case tea.KeyEnter:
+		original, data := m.original, m.Data()
+		return m, func() tea.Msg { return EntryEditorSubmittedMsg{Original: original, Data: data} }
+	case tea.KeyEsc:
+		return m, func() tea.Msg { return EntryEditorCancelledMsg{} }
+	case tea.KeyTab, tea.KeyDown:
+		m.focus = (m.focus + 1) % len(m.fields)
+	case tea.KeyShiftTab, tea.KeyUp:
+		m.focus = (m.focus + len(m.fields) - 1) % len(m.fields)
+	case tea.KeyBackspace:
+		if field := m.fields[m.focus]; len(field) > 0 {
+			m.fields[m.focus] = field[:len(field)-1]
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.fields[m.focus] = append(m.fields[m.focus], keyMsg.Runes...)
+	}
+	return m, nil
+}
+
+// Show makes the editor visible with the given fields.
+//
+// # Parameters
+//   - title:    The dialog title, e.g. "Edit ripgrep"
+//   - original: The key being edited, or "" for a new entry
+//   - data:     The initial text of the fields
+func (m *EntryEditorModel) Show(title, original string, data EntryFormData) {
+	m.title = title
+	m.original = original
+	m.fields = [][]rune{
+		[]rune(data.Key),
+		[]rune(data.Name),
+		[]rune(data.Desc),
+		[]rune(data.Installers),
+		[]rune(data.Groups),
+	}
+	m.focus = 0
+	m.errMsg = ""
+	m.visible = true
+}
+
+// Hide hides the editor.
+func (m *EntryEditorModel) Hide() {
+	m.visible = false
+	m.errMsg = ""
+}
+
+// IsVisible returns whether the editor is visible.
+func (m *EntryEditorModel) IsVisible() bool {
+	return m.visible
+}
+
+// SetError sets an error message shown below the fields, e.g. after a failed validation.
+// The input is kept so the user can correct it.
+func (m *EntryEditorModel) SetError(msg string) {
+	m.errMsg = msg
+}
+
+// Data returns the current text of the fields.
+func (m *EntryEditorModel) Data() EntryFormData {
+	return EntryFormData{
+		Key:        string(m.fields[0]),
+		Name:       string(m.fields[1]),
+		Desc:       string(m.fields[2]),
+		Installers: string(m.fields[3]),
+		Groups:     string(m.fields[4]),
+	}
+}
+
+// View renders the form with the focused field highlighted.
+func (m *EntryEditorModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	styles := core.CurrentStyles()
+
+	parts := []string{styles.TitleHeaderStyle.Render(m.title)}
+	for i, label := range entryFieldLabels {
+		line := lipgloss.JoinHorizontal(lipgloss.Top,
+			styles.DetailKey.Width(13).Render(label+":"),
+			string(m.fields[i]))
+		if i == m.focus {
+			parts = append(parts, styles.ActiveItemStyle.Render("> "+line+"_"))
+		} else {
+			parts = append(parts, styles.ItemStyle.Render("  "+line))
+		}
+	}
+	parts = append(parts, styles.DescriptionStyle.Render("Installers: installer=package, ... (e.g. apt=ripgrep, brew=ripgrep)"))
+	if m.errMsg != "" {
+		parts = append(parts, styles.ErrorStyle.Render(m.errMsg))
+	}
+	parts = append(parts, styles.FooterStyle.Render(core.Glyphs("Tab/↑/↓: Field | Enter: Save | Esc: Cancel")))
+
+	return patterns.Dialog(core.StringModel(lipgloss.JoinVertical(lipgloss.Left, parts...))).View()
+}
@@ -77,7 +77,7 @@ func (m *HelpDialogModel) View() string {
 	styles := core.CurrentStyles() // Updated from ui.CurrentStyles()
 
 	title := styles.TitleHeaderStyle.Render(m.title)
-	content := styles.ItemStyle.Render(m.content)
+	content := styles.ItemStyle.Render(core.Glyphs(m.content))
 	footer := styles.FooterStyle.Render(m.footer)
 
 	// Combine all parts of the dialog
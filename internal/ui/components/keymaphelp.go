@@ -0,0 +1,214 @@
+// keymaphelp.go provides a scrollable help screen generated from a keymap, with a filter box.
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"a-la-carte/internal/ui/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// KeyBinding describes what a key does in one context of the UI, for the help screen.
+//
+// # Fields
+//   - Context: Where the key applies, e.g. "List"; the help groups bindings by it
+//   - Keys:    The keys, e.g. "d/Del"
+//   - Desc:    What they do
+type KeyBinding struct {
+	Context string
+	Keys    string
+	Desc    string
+}
+
+// KeymapHelpModel represents a help screen listing a keymap grouped by context, in the
+// order the contexts first appear. Typing / opens the filter box, which narrows the
+// list to bindings whose keys, description or context contain the filter; the arrow
+// keys scroll.
+//
+// # Fields
+//   - title:     The screen title
+//   - bindings:  The keymap
+//   - filter:    The text in the filter box
+//   - filtering: Whether key input goes to the filter box
+//   - offset:    The first line shown
+//   - width, height: The size of the screen; 0 height shows every line
+//
+// # Example
+//
+//	help := components.NewKeymapHelpModel("Help", []components.KeyBinding{
+//		{Context: "List", Keys: "enter", Desc: "Select the highlighted item"},
+//	})
+//	help.SetSize(80, 20)
+type KeymapHelpModel struct {
+	title         string
+	bindings      []KeyBinding
+	filter        string
+	filtering     bool
+	offset        int
+	width, height int
+}
+
+// keymapHelpChrome is how many lines the title, filter box and footer take.
+const keymapHelpChrome = 3
+
+// NewKeymapHelpModel creates a help screen for a keymap.
+func NewKeymapHelpModel(title string, bindings []KeyBinding) *KeymapHelpModel {
+	return &KeymapHelpModel{title: title, bindings: bindings}
+}
+
+// Init does nothing for this model.
+func (m *KeymapHelpModel) Init() tea.Cmd { return nil }
+
+// Update handles key input: the filter box while it is open, and scrolling otherwise.
+// Esc or Enter closes the filter box, keeping the filter.
+func (m *KeymapHelpModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	key := keyMsg.String()
+	if m.filtering {
+		switch key {
+		case "esc", "enter":
+			m.filtering = false
+		case "backspace":
+			if m.filter != "" {
+				m.SetFilter(m.filter[:len(m.filter)-1])
+			}
+		default:
+			if len(key) == 1 && key >= " " && key <= "~" {
+				m.SetFilter(m.filter + key)
+			}
+		}
+		return m, nil
+	}
+	switch key {
+	case "/":
+		m.filtering = true
+	case "up", "k":
+		m.scroll(-1)
+	case "down", "j":
+		m.scroll(1)
+	case "pgup":
+		m.scroll(-m.pageSize())
+	case "pgdown", " ":
+		m.scroll(m.pageSize())
+	}
+	return m, nil
+}
+
+// Filtering reports whether key input goes to the filter box.
+func (m *KeymapHelpModel) Filtering() bool {
+	return m.filtering
+}
+
+// Filter returns the text in the filter box.
+func (m *KeymapHelpModel) Filter() string {
+	return m.filter
+}
+
+// SetFilter narrows the help to bindings containing filter (ignoring case) and scrolls
+// back to the top.
+func (m *KeymapHelpModel) SetFilter(filter string) {
+	m.filter = filter
+	m.offset = 0
+}
+
+// Reset clears the filter and scrolls back to the top, e.g. when the help is reopened.
+func (m *KeymapHelpModel) Reset() {
+	m.filtering = false
+	m.SetFilter("")
+}
+
+// SetSize sets the size of the screen.
+func (m *KeymapHelpModel) SetSize(width, height int) {
+	m.width, m.height = width, height
+	m.scroll(0)
+}
+
+// pageSize returns how many lines of bindings fit on the screen.
+func (m *KeymapHelpModel) pageSize() int {
+	if m.height <= 0 {
+		return len(m.lines())
+	}
+	return max(1, m.height-keymapHelpChrome)
+}
+
+// scroll moves the bindings by delta lines, keeping the last line at the bottom at most.
+func (m *KeymapHelpModel) scroll(delta int) {
+	m.offset = max(0, min(m.offset+delta, len(m.lines())-m.pageSize()))
+}
+
+// lines returns the bindings matching the filter, one per line under a heading per context.
+func (m *KeymapHelpModel) lines() []string {
+	styles := core.CurrentStyles()
+	filter := strings.ToLower(m.filter)
+	keysWidth := 0
+	for _, b := range m.bindings {
+		keysWidth = max(keysWidth, ansi.StringWidth(core.Glyphs(b.Keys)))
+	}
+
+	var contexts []string
+	groups := make(map[string][]string)
+	for _, b := range m.bindings {
+		if filter != "" && !strings.Contains(strings.ToLower(b.Context+" "+b.Keys+" "+b.Desc), filter) {
+			continue
+		}
+		if _, seen := groups[b.Context]; !seen {
+			contexts = append(contexts, b.Context)
+		}
+		keys := core.Glyphs(b.Keys)
+		keys += strings.Repeat(" ", keysWidth-ansi.StringWidth(keys))
+		line := "  " + styles.HighlightStyle.Render(keys) + "  " + core.Glyphs(b.Desc)
+		if m.width > 0 {
+			line = ansi.Truncate(line, m.width, core.Glyphs("…"))
+		}
+		groups[b.Context] = append(groups[b.Context], line)
+	}
+
+	var lines []string
+	for i, context := range contexts {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, styles.HeaderStyle.Render(context))
+		lines = append(lines, groups[context]...)
+	}
+	return lines
+}
+
+// View renders the help screen with the visible part of the keymap.
+func (m *KeymapHelpModel) View() string {
+	styles := core.CurrentStyles()
+
+	filterLine := "Filter: " + styles.DimStyle.Render("(press / to filter)")
+	switch {
+	case m.filtering:
+		filterLine = "Filter: " + m.filter + "_"
+	case m.filter != "":
+		filterLine = "Filter: " + m.filter
+	}
+
+	lines := m.lines()
+	end := min(m.offset+m.pageSize(), len(lines))
+	shown := lines[m.offset:end]
+	if len(lines) == 0 {
+		shown = []string{styles.DimStyle.Render("No keys match " + m.filter)}
+	}
+	footer := "/: Filter | Esc: Close"
+	if len(lines) > m.pageSize() {
+		footer = fmt.Sprintf("↑/↓: Scroll (%d-%d of %d) | %s", m.offset+1, end, len(lines), footer)
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		styles.HeaderStyle.Render(m.title),
+		filterLine,
+		strings.Join(shown, "\n"),
+		styles.FooterStyle.Render(core.Glyphs(footer)),
+	)
+}
@@ -0,0 +1,175 @@
+// pager.go provides a full-screen, less-like view of a long text, such as the built-in documentation.
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"a-la-carte/internal/ui/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// pagerChrome is how many lines the title and footer take.
+const pagerChrome = 2
+
+// PagerModel represents a full-screen view of a long text, run as its own program. The
+// text is rendered for the width of the window, so it rewraps on resize. Keys work as in
+// less: the arrows, j/k, space/b and g/G scroll, / searches and n jumps to the next
+// match, q quits.
+//
+// # Fields
+//   - title:         The title above the text
+//   - render:        Renders the text for a width
+//   - lines:         The rendered text, one line each
+//   - offset:        The first line shown
+//   - search:        The last search
+//   - searching:     Whether key input goes to the search prompt
+//   - status:        A message shown in the footer, e.g. that the search has no match
+//   - width, height: The size of the window
+//
+// # Example
+//
+//	pager := components.NewPagerModel("chezmoi-a-la-carte(1)", doc.Text)
+//	_, err := tea.NewProgram(pager, tea.WithAltScreen()).Run()
+type PagerModel struct {
+	title         string
+	render        func(width int) string
+	lines         []string
+	offset        int
+	search        string
+	searching     bool
+	status        string
+	width, height int
+}
+
+// NewPagerModel creates a pager for the text render returns for a width.
+func NewPagerModel(title string, render func(width int) string) *PagerModel {
+	return &PagerModel{title: title, render: render}
+}
+
+// Init does nothing for this model; the text is rendered once the window size is known.
+func (m *PagerModel) Init() tea.Cmd { return nil }
+
+// Update handles the window size and key input.
+func (m *PagerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.SetSize(msg.Width, msg.Height)
+	case tea.KeyMsg:
+		if m.searching {
+			m.updateSearch(msg.String())
+			return m, nil
+		}
+		m.status = ""
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			m.scroll(-1)
+		case "down", "j", "enter":
+			m.scroll(1)
+		case "pgup", "b":
+			m.scroll(-m.pageSize())
+		case "pgdown", " ", "f":
+			m.scroll(m.pageSize())
+		case "home", "g":
+			m.offset = 0
+		case "end", "G":
+			m.scroll(len(m.lines))
+		case "/":
+			m.searching, m.search = true, ""
+		case "n":
+			m.findNext(m.offset + 1)
+		}
+	}
+	return m, nil
+}
+
+// updateSearch handles key input at the search prompt; Enter searches from the top of
+// the screen, Esc cancels.
+func (m *PagerModel) updateSearch(key string) {
+	switch key {
+	case "enter":
+		m.searching = false
+		m.findNext(m.offset)
+	case "esc":
+		m.searching = false
+	case "backspace":
+		if m.search != "" {
+			m.search = m.search[:len(m.search)-1]
+		}
+	default:
+		if len([]rune(key)) == 1 {
+			m.search += key
+		}
+	}
+}
+
+// findNext scrolls to the first line from line from that contains the search, ignoring case.
+func (m *PagerModel) findNext(from int) {
+	if m.search == "" {
+		return
+	}
+	search := strings.ToLower(m.search)
+	for i := from; i < len(m.lines); i++ {
+		if strings.Contains(strings.ToLower(m.lines[i]), search) {
+			m.offset = i
+			m.scroll(0)
+			return
+		}
+	}
+	m.status = "Pattern not found: " + m.search
+}
+
+// SetSize sets the size of the window and rewraps the text for its width.
+func (m *PagerModel) SetSize(width, height int) {
+	m.width, m.height = width, height
+	m.lines = strings.Split(strings.TrimSuffix(m.render(width), "\n"), "\n")
+	m.scroll(0)
+}
+
+// pageSize returns how many lines of text fit in the window.
+func (m *PagerModel) pageSize() int {
+	return max(1, m.height-pagerChrome)
+}
+
+// scroll moves the text by delta lines, keeping the last line at the bottom at most.
+func (m *PagerModel) scroll(delta int) {
+	m.offset = max(0, min(m.offset+delta, len(m.lines)-m.pageSize()))
+}
+
+// View renders the visible part of the text between the title and a footer with the
+// position, the search prompt or the status.
+func (m *PagerModel) View() string {
+	if m.width == 0 {
+		return ""
+	}
+	styles := core.CurrentStyles()
+
+	end := min(m.offset+m.pageSize(), len(m.lines))
+	shown := m.lines[m.offset:end]
+	if pad := m.pageSize() - len(shown); pad > 0 {
+		shown = append(shown, make([]string, pad)...)
+	}
+
+	footer := fmt.Sprintf("lines %d-%d of %d", m.offset+1, end, len(m.lines))
+	if len(m.lines) > 0 {
+		footer += fmt.Sprintf(" (%d%%)", end*100/len(m.lines))
+	}
+	footer += " | ↑/↓/Space/b: Scroll | /: Search | q: Quit"
+	switch {
+	case m.searching:
+		footer = "/" + m.search + "_"
+	case m.status != "":
+		footer = m.status
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		styles.HeaderStyle.Render(m.title),
+		strings.Join(shown, "\n"),
+		styles.FooterStyle.Render(core.Glyphs(footer)),
+	)
+}
@@ -0,0 +1,106 @@
+// passwordprompt.go provides a masked password input dialog.
+package components
+
+import (
+	"strings"
+
+	"a-la-carte/internal/ui/core"
+	"a-la-carte/internal/ui/patterns"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PasswordSubmittedMsg is returned as a command result when the user presses Enter.
+type PasswordSubmittedMsg struct {
+	Password string
+}
+
+// PasswordCancelledMsg is returned as a command result when the user presses Esc.
+type PasswordCancelledMsg struct{}
+
+// PasswordPromptModel represents a masked password input dialog.
+type PasswordPromptModel struct {
+	title   string
+	prompt  string
+	value   []rune
+	errMsg  string
+	visible bool
+}
+
+// NewPasswordPromptModel creates a new password prompt with the given title and prompt text.
+func NewPasswordPromptModel(title, prompt string) *PasswordPromptModel {
+	return &PasswordPromptModel{
+		title:  title,
+		prompt: prompt,
+	}
+}
+
+// Init does nothing for this model.
+func (m *PasswordPromptModel) Init() tea.Cmd { return nil }
+
+// Update handles key input while the prompt is visible.
+func (m *PasswordPromptModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || !m.visible {
+		return m, nil
+	}
+	switch keyMsg.Type {
+	case tea.KeyEnter:
+		password := string(m.value)
+		return m, func() tea.Msg { return PasswordSubmittedMsg{Password: password} }
+	case tea.KeyEsc:
+		return m, func() tea.Msg { return PasswordCancelledMsg{} }
+	case tea.KeyBackspace:
+		if len(m.value) > 0 {
+			m.value = m.value[:len(m.value)-1]
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.value = append(m.value, keyMsg.Runes...)
+	}
+	return m, nil
+}
+
+// Show makes the prompt visible and clears any previous input.
+func (m *PasswordPromptModel) Show() {
+	m.visible = true
+	m.value = nil
+}
+
+// Hide hides the prompt and clears its input.
+func (m *PasswordPromptModel) Hide() {
+	m.visible = false
+	m.value = nil
+}
+
+// IsVisible returns whether the prompt is visible.
+func (m *PasswordPromptModel) IsVisible() bool {
+	return m.visible
+}
+
+// SetError sets an error message shown below the input, e.g. after a wrong password.
+func (m *PasswordPromptModel) SetError(msg string) {
+	m.errMsg = msg
+	m.value = nil
+}
+
+// View renders the prompt with the input masked.
+func (m *PasswordPromptModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	styles := core.CurrentStyles()
+
+	parts := []string{
+		styles.TitleHeaderStyle.Render(m.title),
+		styles.ItemStyle.Render(m.prompt),
+		styles.ActiveItemStyle.Render("> " + strings.Repeat(core.Glyphs("•"), len(m.value))),
+	}
+	if m.errMsg != "" {
+		parts = append(parts, styles.ErrorStyle.Render(m.errMsg))
+	}
+	parts = append(parts, styles.FooterStyle.Render("Enter: Submit | Esc: Cancel"))
+
+	return patterns.Dialog(core.StringModel(lipgloss.JoinVertical(lipgloss.Left, parts...))).View()
+}
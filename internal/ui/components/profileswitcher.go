@@ -0,0 +1,124 @@
+// profileswitcher.go provides an overlay for choosing a named selection profile.
+package components
+
+import (
+	"strings"
+
+	"a-la-carte/internal/ui/core"
+	"a-la-carte/internal/ui/patterns"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ProfileSelectedMsg is returned as a command result when the user applies a profile.
+type ProfileSelectedMsg struct {
+	Name string
+}
+
+// ProfileSwitcherModel represents the profile switcher overlay.
+type ProfileSwitcherModel struct {
+	names   []string
+	cursor  int
+	visible bool
+}
+
+// NewProfileSwitcherModel creates a new profile switcher for the given profile names.
+func NewProfileSwitcherModel(names []string) *ProfileSwitcherModel {
+	return &ProfileSwitcherModel{
+		names: names,
+	}
+}
+
+// Init does nothing for this model.
+func (m *ProfileSwitcherModel) Init() tea.Cmd { return nil }
+
+// Update handles key input while the switcher is visible. Enter applies the highlighted
+// profile and closes the switcher; Esc or p closes it.
+func (m *ProfileSwitcherModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || !m.visible {
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "esc", "p":
+		m.Hide()
+	case "up", "k":
+		m.MoveUp()
+	case "down", "j":
+		m.MoveDown()
+	case "enter":
+		m.Hide()
+		if name := m.Current(); name != "" {
+			return m, func() tea.Msg { return ProfileSelectedMsg{Name: name} }
+		}
+	}
+	return m, nil
+}
+
+// Show makes the profile switcher visible and resets the cursor.
+func (m *ProfileSwitcherModel) Show() {
+	m.visible = true
+	m.cursor = 0
+}
+
+// Hide hides the profile switcher.
+func (m *ProfileSwitcherModel) Hide() {
+	m.visible = false
+}
+
+// IsVisible returns whether the profile switcher is visible.
+func (m *ProfileSwitcherModel) IsVisible() bool {
+	return m.visible
+}
+
+// MoveUp moves the cursor to the previous profile.
+func (m *ProfileSwitcherModel) MoveUp() {
+	if m.cursor > 0 {
+		m.cursor--
+	}
+}
+
+// MoveDown moves the cursor to the next profile.
+func (m *ProfileSwitcherModel) MoveDown() {
+	if m.cursor < len(m.names)-1 {
+		m.cursor++
+	}
+}
+
+// Current returns the highlighted profile name, or "" if there are no profiles.
+func (m *ProfileSwitcherModel) Current() string {
+	if m.cursor < 0 || m.cursor >= len(m.names) {
+		return ""
+	}
+	return m.names[m.cursor]
+}
+
+// View renders the profile switcher.
+func (m *ProfileSwitcherModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	styles := core.CurrentStyles()
+
+	var lines []string
+	if len(m.names) == 0 {
+		lines = append(lines, styles.ItemStyle.Render("No profiles configured."))
+	}
+	for i, name := range m.names {
+		if i == m.cursor {
+			lines = append(lines, styles.ActiveItemStyle.Render("> "+name))
+		} else {
+			lines = append(lines, styles.ItemStyle.Render("  "+name))
+		}
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		styles.TitleHeaderStyle.Render("Profiles"),
+		strings.Join(lines, "\n"),
+		styles.FooterStyle.Render("Enter: Apply | Esc/p: Close"),
+	)
+	return patterns.Dialog(core.StringModel(content)).View()
+}
@@ -0,0 +1,112 @@
+package components
+
+import (
+	"strings"
+
+	"a-la-carte/internal/ui/core"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// TableColumn is a column of a Table.
+//
+// # Fields
+//   - Title: The column header
+//   - Width: The column width in cells; 0 makes the column take the width left over
+//   - Min:   The narrowest the column may get before the table drops it (0 for Width)
+type TableColumn struct {
+	Title string
+	Width int
+	Min   int
+}
+
+// Table lays out rows of cells in fixed-width columns, for list views with several
+// fields per entry. Columns that do not fit the width are dropped from the right.
+//
+// # Example
+//
+//	t := NewTable(40, TableColumn{Title: "Name"}, TableColumn{Title: "Installer", Width: 10})
+//	t.Header(0, false) // "Name ▲ ... Installer"
+//	t.Row("ripgrep", "apt")
+type Table struct {
+	columns []TableColumn
+	widths  []int
+}
+
+// tableGap is the space between columns.
+const tableGap = 1
+
+// NewTable fits the columns into width.
+//
+// # Parameters
+//   - width:   The width available for the table, in cells
+//   - columns: The columns, from left to right; the first should be the flexible one
+func NewTable(width int, columns ...TableColumn) *Table {
+	t := &Table{columns: columns}
+	for len(t.columns) > 0 {
+		fixed, flexible := 0, -1
+		for i, col := range t.columns {
+			if col.Width == 0 {
+				flexible = i
+				continue
+			}
+			fixed += col.Width + tableGap
+		}
+		rest := width - fixed
+		if flexible < 0 || rest >= max(t.columns[flexible].Min, 1) || len(t.columns) == 1 {
+			t.widths = make([]int, len(t.columns))
+			for i, col := range t.columns {
+				t.widths[i] = col.Width
+			}
+			if flexible >= 0 {
+				t.widths[flexible] = max(rest, 0)
+			}
+			return t
+		}
+		t.columns = t.columns[:len(t.columns)-1]
+	}
+	return t
+}
+
+// Columns returns the number of columns that fit.
+func (t *Table) Columns() int {
+	return len(t.columns)
+}
+
+// Width returns the width of column i, or 0 if it did not fit.
+func (t *Table) Width(i int) int {
+	if i < 0 || i >= len(t.widths) {
+		return 0
+	}
+	return t.widths[i]
+}
+
+// Header renders the column titles, marking the sorted column (-1 for none) with an
+// arrow pointing up, or down if the sort is descending.
+func (t *Table) Header(sorted int, descending bool) string {
+	titles := make([]string, len(t.columns))
+	for i, col := range t.columns {
+		titles[i] = col.Title
+		if i == sorted {
+			titles[i] += core.Glyphs(" ▲")
+			if descending {
+				titles[i] = col.Title + core.Glyphs(" ▼")
+			}
+		}
+	}
+	return t.Row(titles...)
+}
+
+// Row renders one row, truncating cells to their column and dropping cells of columns
+// that did not fit.
+func (t *Table) Row(cells ...string) string {
+	parts := make([]string, len(t.widths))
+	for i, width := range t.widths {
+		cell := ""
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		parts[i] = runewidth.FillRight(runewidth.Truncate(cell, width, core.Glyphs("…")), width)
+	}
+	return strings.Join(parts, strings.Repeat(" ", tableGap))
+}
@@ -0,0 +1,126 @@
+// textdialog.go provides a dialog showing lines of text, such as command output.
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"a-la-carte/internal/ui/core"
+	"a-la-carte/internal/ui/patterns"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// textDialogHeight is how many lines a text dialog shows before it scrolls.
+	textDialogHeight = 15
+	// textDialogWidth is the widest a line of a text dialog gets before it is truncated.
+	textDialogWidth = 76
+)
+
+// TextDialogModel represents a dialog showing lines of text under a title. Long text
+// scrolls with the arrow keys; Esc, q or Enter closes the dialog.
+//
+// # Fields
+//   - title:   The dialog title
+//   - lines:   The text, one line each
+//   - offset:  The first line shown
+//   - visible: Whether the dialog is shown
+//
+// # Example
+//
+//	m.preview.Show("Install preview: bat", []string{"brew install bat"})
+type TextDialogModel struct {
+	title   string
+	lines   []string
+	offset  int
+	visible bool
+}
+
+// NewTextDialogModel creates a hidden text dialog.
+func NewTextDialogModel() *TextDialogModel {
+	return &TextDialogModel{}
+}
+
+// Init does nothing for this model.
+func (m *TextDialogModel) Init() tea.Cmd { return nil }
+
+// Update handles key input while the dialog is visible.
+func (m *TextDialogModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || !m.visible {
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "esc", "q", "enter":
+		m.Hide()
+	case "up", "k":
+		m.scroll(-1)
+	case "down", "j":
+		m.scroll(1)
+	case "pgup":
+		m.scroll(-textDialogHeight)
+	case "pgdown", " ":
+		m.scroll(textDialogHeight)
+	}
+	return m, nil
+}
+
+// scroll moves the text by delta lines, keeping the last line at the bottom at most.
+func (m *TextDialogModel) scroll(delta int) {
+	m.offset = max(0, min(m.offset+delta, len(m.lines)-textDialogHeight))
+}
+
+// Show shows text under a title, scrolled to the top.
+func (m *TextDialogModel) Show(title string, lines []string) {
+	m.title = title
+	m.offset = 0
+	m.visible = true
+	m.SetLines(lines)
+}
+
+// SetLines replaces the text, e.g. once it has been computed, keeping the scroll
+// position where it still fits.
+func (m *TextDialogModel) SetLines(lines []string) {
+	m.lines = lines
+	m.scroll(0)
+}
+
+// Hide hides the dialog.
+func (m *TextDialogModel) Hide() {
+	m.visible = false
+}
+
+// IsVisible returns whether the dialog is visible.
+func (m *TextDialogModel) IsVisible() bool {
+	return m.visible
+}
+
+// View renders the dialog with the visible part of the text.
+func (m *TextDialogModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	styles := core.CurrentStyles()
+
+	end := min(m.offset+textDialogHeight, len(m.lines))
+	shown := make([]string, 0, end-m.offset)
+	for _, line := range m.lines[m.offset:end] {
+		shown = append(shown, styles.ItemStyle.Render(ansi.Truncate(line, textDialogWidth, "…")))
+	}
+	footer := "Esc: Close"
+	if len(m.lines) > textDialogHeight {
+		footer = fmt.Sprintf("↑/↓: Scroll (%d-%d of %d) | Esc: Close", m.offset+1, end, len(m.lines))
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		styles.TitleHeaderStyle.Render(m.title),
+		strings.Join(shown, "\n"),
+		styles.FooterStyle.Render(core.Glyphs(footer)),
+	)
+	return patterns.Dialog(core.StringModel(content)).View()
+}
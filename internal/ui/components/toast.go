@@ -0,0 +1,158 @@
+// toast.go provides transient notifications shown over a view until their timer runs out.
+package components
+
+import (
+	"time"
+
+	"a-la-carte/internal/ui/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ToastLevel is the kind of a toast, which sets its color and how long it shows.
+type ToastLevel int
+
+const (
+	// ToastInfo reports something that happened, e.g. a changed sort order.
+	ToastInfo ToastLevel = iota
+	// ToastSuccess reports a finished action, e.g. an exported selection.
+	ToastSuccess
+	// ToastError reports a failure. Errors show twice as long as other toasts.
+	ToastError
+)
+
+const (
+	// DefaultToastDuration is how long a toast shows before it is dismissed.
+	DefaultToastDuration = 3 * time.Second
+	// maxToasts is how many toasts show at once; older ones are dismissed early.
+	maxToasts = 3
+	// toastWidth is the widest a toast gets, border included, before its text wraps.
+	toastWidth = 40
+)
+
+// ToastExpiredMsg is sent when the timer of a toast runs out. Pass it to
+// ToastsModel.Update to dismiss the toast.
+type ToastExpiredMsg struct {
+	ID int
+}
+
+// toast is a queued notification.
+type toast struct {
+	id    int
+	level ToastLevel
+	text  string
+}
+
+// ToastsModel queues transient notifications and renders them stacked in the top-right
+// corner of a view.
+//
+// # Fields
+//   - toasts:   The toasts showing, oldest first
+//   - nextID:   The ID of the next toast, matching it to its timer
+//   - Duration: How long a toast shows (DefaultToastDuration)
+//
+// # Example
+//
+//	cmd := m.toasts.Push(components.ToastSuccess, "Exported selection")
+//	...
+//	return m.toasts.Overlay(view)
+type ToastsModel struct {
+	toasts   []toast
+	nextID   int
+	Duration time.Duration
+}
+
+// NewToastsModel creates an empty toast queue.
+func NewToastsModel() *ToastsModel {
+	return &ToastsModel{Duration: DefaultToastDuration}
+}
+
+// Init does nothing for this model.
+func (m *ToastsModel) Init() tea.Cmd { return nil }
+
+// Push queues a toast and returns the command that dismisses it when its timer runs out.
+// If more than maxToasts are showing, the oldest is dismissed.
+func (m *ToastsModel) Push(level ToastLevel, text string) tea.Cmd {
+	m.nextID++
+	id := m.nextID
+	m.toasts = append(m.toasts, toast{id: id, level: level, text: text})
+	if len(m.toasts) > maxToasts {
+		m.toasts = m.toasts[len(m.toasts)-maxToasts:]
+	}
+	duration := m.Duration
+	if level == ToastError {
+		duration *= 2
+	}
+	return tea.Tick(duration, func(time.Time) tea.Msg { return ToastExpiredMsg{ID: id} })
+}
+
+// Update dismisses the toast whose timer ran out.
+func (m *ToastsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if expired, ok := msg.(ToastExpiredMsg); ok {
+		for i, t := range m.toasts {
+			if t.id == expired.ID {
+				m.toasts = append(m.toasts[:i], m.toasts[i+1:]...)
+				break
+			}
+		}
+	}
+	return m, nil
+}
+
+// Len returns how many toasts are showing.
+func (m *ToastsModel) Len() int {
+	return len(m.toasts)
+}
+
+// Dismiss removes every toast. Their timers still fire but find nothing to dismiss.
+func (m *ToastsModel) Dismiss() {
+	m.toasts = nil
+}
+
+// View renders the toasts stacked, newest at the bottom, or "" when there are none.
+func (m *ToastsModel) View() string {
+	if len(m.toasts) == 0 {
+		return ""
+	}
+	return lipgloss.JoinVertical(lipgloss.Right, m.boxes()...)
+}
+
+// Overlay draws the toasts over the top-right corner of view, leaving view unchanged
+// when there are none. Only the toasts themselves cover the view.
+func (m *ToastsModel) Overlay(view string) string {
+	width := lipgloss.Width(view)
+	y := 1
+	for _, box := range m.boxes() {
+		view = core.Overlay(view, box, width-lipgloss.Width(box)-1, y)
+		y += lipgloss.Height(box)
+	}
+	return view
+}
+
+// boxes renders each toast in a box bordered in the color of its level.
+func (m *ToastsModel) boxes() []string {
+	styles := core.CurrentStyles()
+	theme := core.CurrentTheme()
+	boxes := make([]string, 0, len(m.toasts))
+	for _, t := range m.toasts {
+		var icon string
+		var color lipgloss.AdaptiveColor
+		switch t.level {
+		case ToastSuccess:
+			icon, color = "✔ ", theme.Accent()
+		case ToastError:
+			icon, color = "✖ ", theme.Secondary()
+		default:
+			icon, color = "• ", theme.Primary()
+		}
+		text := core.Glyphs(icon + t.text)
+		box := lipgloss.NewStyle().
+			Border(core.RoundedBorder()).
+			BorderForeground(color).
+			Padding(0, 1).
+			Render(styles.ItemStyle.Width(min(lipgloss.Width(text), toastWidth-4)).Render(text))
+		boxes = append(boxes, box)
+	}
+	return boxes
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// a11yMode renders the UI for screen readers: decorative borders are left blank,
+// containers announce their aria label, and the focused item is marked with
+// FocusedPrefix instead of only a color.
+var a11yMode bool
+
+// FocusedPrefix starts the line of the focused item, and the label of the focused
+// container, in a11y mode.
+const FocusedPrefix = "[focused] "
+
+// SetA11y turns a11y mode on or off and rebuilds the styles, whose borders depend on it.
+// Emojis and Unicode glyphs are not covered; turn on ASCII mode and turn emojis off too.
+func SetA11y(enabled bool) {
+	a11yMode = enabled
+	currentStyles = BuildStyles()
+	stylesInitialized = true
+}
+
+// A11y reports whether a11y mode is on.
+func A11y() bool {
+	return a11yMode
+}
+
+// Focused returns line prefixed with FocusedPrefix in a11y mode, and unchanged otherwise.
+//
+// # Example
+//
+//	core.Focused("bat") // "[focused] bat" in a11y mode
+func Focused(line string) string {
+	if !a11yMode {
+		return line
+	}
+	return FocusedPrefix + line
+}
+
+// announceLabel writes label over the first line of a rendered container, which in a11y
+// mode is its blank top border, or adds it above the view when the container has none.
+func announceLabel(view, label string, overBorder bool) string {
+	if !overBorder {
+		return label + "\n" + view
+	}
+	first, rest, _ := strings.Cut(view, "\n")
+	width := lipgloss.Width(first)
+	label = ansi.Truncate(label, width, "")
+	return label + strings.Repeat(" ", width-lipgloss.Width(label)) + "\n" + rest
+}
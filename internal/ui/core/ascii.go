@@ -0,0 +1,75 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// asciiMode replaces box-drawing borders, arrows and other Unicode glyphs with plain
+// ASCII, for terminals and CI logs with poor Unicode support.
+var asciiMode bool
+
+// asciiBorder draws borders with +, - and |.
+var asciiBorder = lipgloss.Border{
+	Top:          "-",
+	Bottom:       "-",
+	Left:         "|",
+	Right:        "|",
+	TopLeft:      "+",
+	TopRight:     "+",
+	BottomLeft:   "+",
+	BottomRight:  "+",
+	MiddleLeft:   "+",
+	MiddleRight:  "+",
+	Middle:       "+",
+	MiddleTop:    "+",
+	MiddleBottom: "+",
+}
+
+// asciiGlyphs maps the Unicode glyphs the UI uses to their ASCII equivalents.
+var asciiGlyphs = strings.NewReplacer(
+	"▲", "^", "▼", "v", "▾", "v", "▸", ">",
+	"↑", "^", "↓", "v", "←", "<-", "→", "->", "↔", "<->",
+	"…", "...", "·", "-", "•", "*", "—", "-",
+	"✔", "+", "✖", "x", "─", "-", "│", "|",
+	"🧩", MetaBadge,
+)
+
+// SetASCII turns ASCII mode on or off and rebuilds the styles, whose borders depend on it.
+// Emojis are not covered; turn them off separately.
+func SetASCII(enabled bool) {
+	asciiMode = enabled
+	currentStyles = BuildStyles()
+	stylesInitialized = true
+}
+
+// ASCII reports whether ASCII mode is on.
+func ASCII() bool {
+	return asciiMode
+}
+
+// RoundedBorder returns the border of panels and dialogs: rounded box drawing, +, -
+// and | in ASCII mode, or blank in a11y mode.
+func RoundedBorder() lipgloss.Border {
+	if a11yMode {
+		return lipgloss.HiddenBorder()
+	}
+	if asciiMode {
+		return asciiBorder
+	}
+	return lipgloss.RoundedBorder()
+}
+
+// Glyphs returns s with its arrows, ellipses and other Unicode glyphs replaced by ASCII
+// in ASCII mode, and unchanged otherwise.
+//
+// # Example
+//
+//	core.Glyphs("↑/↓: Move") // "^/v: Move" in ASCII mode
+func Glyphs(s string) string {
+	if !asciiMode {
+		return s
+	}
+	return asciiGlyphs.Replace(s)
+}
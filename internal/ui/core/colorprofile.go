@@ -0,0 +1,129 @@
+// Package core provides the foundational elements for UI components.
+// This file picks the colors the UI is drawn with. Colors are downgraded along
+// truecolor → 256 → 16 → monochrome to what the terminal can show, so the UI stays
+// legible over mosh, screen and basic terminals, and NO_COLOR is honored.
+//
+// Usage:
+//   - Pass the configured color setting to `ParseColorProfile` and the result to
+//     `SetColorProfile` before the program starts.
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Color settings, from most colors to fewest. ColorsAuto detects what the terminal
+// supports (see DetectColorProfile).
+const (
+	ColorsAuto      = "auto"
+	ColorsTrueColor = "truecolor"
+	Colors256       = "256"
+	Colors16        = "16"
+	ColorsNone      = "none"
+)
+
+// monochrome draws the UI without colors, telling items apart by bold, underlined and
+// reversed text instead.
+var monochrome bool
+
+// DetectColorProfile works out the colors a terminal can show from its environment:
+//   - NO_COLOR set, or TERM=dumb: no colors
+//   - COLORTERM=truecolor or 24bit: truecolor, except under GNU screen, which only
+//     passes 256 colors (tmux passes truecolor)
+//   - TERM ending in 256color: 256 colors
+//   - Anything else: the 16 ANSI colors
+//
+// # Parameters
+//   - getenv: Looks up an environment variable, e.g. os.Getenv
+func DetectColorProfile(getenv func(string) string) termenv.Profile {
+	term := getenv("TERM")
+	switch {
+	case getenv("NO_COLOR") != "", term == "dumb":
+		return termenv.Ascii
+	case getenv("COLORTERM") == "truecolor" || getenv("COLORTERM") == "24bit":
+		if strings.HasPrefix(term, "screen") && getenv("TMUX") == "" {
+			return termenv.ANSI256
+		}
+		return termenv.TrueColor
+	case strings.HasSuffix(term, "256color"):
+		return termenv.ANSI256
+	default:
+		return termenv.ANSI
+	}
+}
+
+// ParseColorProfile returns the color profile of a color setting, detecting it from the
+// environment for "" and auto.
+//
+// # Returns
+//   - termenv.Profile: The profile to pass to SetColorProfile
+//   - error: If the setting is not one of auto, truecolor, 256, 16 or none
+func ParseColorProfile(setting string, getenv func(string) string) (termenv.Profile, error) {
+	switch setting {
+	case "", ColorsAuto:
+		return DetectColorProfile(getenv), nil
+	case ColorsTrueColor:
+		return termenv.TrueColor, nil
+	case Colors256:
+		return termenv.ANSI256, nil
+	case Colors16:
+		return termenv.ANSI, nil
+	case ColorsNone:
+		return termenv.Ascii, nil
+	default:
+		return termenv.ANSI, fmt.Errorf("invalid colors %q (must be auto, truecolor, 256, 16 or none)", setting)
+	}
+}
+
+// SetColorProfile draws the UI with the colors of the profile and rebuilds the styles.
+// Colors are converted to the nearest one the profile has. The Ascii profile makes the
+// UI monochrome: lipgloss keeps emitting bold, underline and reverse, but no colors.
+func SetColorProfile(profile termenv.Profile) {
+	monochrome = profile == termenv.Ascii
+	if monochrome {
+		profile = termenv.ANSI
+	}
+	lipgloss.SetColorProfile(profile)
+	styles := BuildStyles()
+	SetStyles(&styles)
+}
+
+// Monochrome reports whether the UI is drawn without colors.
+func Monochrome() bool {
+	return monochrome
+}
+
+// monochromeTheme is the current theme with every color removed.
+type monochromeTheme struct {
+	Theme
+}
+
+func (monochromeTheme) Primary() lipgloss.AdaptiveColor           { return lipgloss.AdaptiveColor{} }
+func (monochromeTheme) Secondary() lipgloss.AdaptiveColor         { return lipgloss.AdaptiveColor{} }
+func (monochromeTheme) Accent() lipgloss.AdaptiveColor            { return lipgloss.AdaptiveColor{} }
+func (monochromeTheme) AccentActive() lipgloss.AdaptiveColor      { return lipgloss.AdaptiveColor{} }
+func (monochromeTheme) Text() lipgloss.AdaptiveColor              { return lipgloss.AdaptiveColor{} }
+func (monochromeTheme) TextMuted() lipgloss.AdaptiveColor         { return lipgloss.AdaptiveColor{} }
+func (monochromeTheme) TextActive() lipgloss.AdaptiveColor        { return lipgloss.AdaptiveColor{} }
+func (monochromeTheme) Background() lipgloss.AdaptiveColor        { return lipgloss.AdaptiveColor{} }
+func (monochromeTheme) BackgroundActive() lipgloss.AdaptiveColor  { return lipgloss.AdaptiveColor{} }
+func (monochromeTheme) BackgroundFocused() lipgloss.AdaptiveColor { return lipgloss.AdaptiveColor{} }
+func (monochromeTheme) Border() lipgloss.AdaptiveColor            { return lipgloss.AdaptiveColor{} }
+func (monochromeTheme) BorderActive() lipgloss.AdaptiveColor      { return lipgloss.AdaptiveColor{} }
+func (monochromeTheme) DialogBg() lipgloss.AdaptiveColor          { return lipgloss.AdaptiveColor{} }
+func (monochromeTheme) DialogBorder() lipgloss.AdaptiveColor      { return lipgloss.AdaptiveColor{} }
+func (monochromeTheme) StatusBarBg() lipgloss.AdaptiveColor       { return lipgloss.AdaptiveColor{} }
+func (monochromeTheme) StatusBarFg() lipgloss.AdaptiveColor       { return lipgloss.AdaptiveColor{} }
+func (monochromeTheme) Header() lipgloss.AdaptiveColor            { return lipgloss.AdaptiveColor{} }
+
+// monochromeStyles marks what colors told apart with text attributes instead.
+func monochromeStyles(styles *Styles) {
+	styles.ActiveItemStyle = styles.ActiveItemStyle.Reverse(true)
+	styles.SelectedItemStyle = styles.SelectedItemStyle.Underline(true)
+	styles.ErrorStyle = styles.ErrorStyle.UnsetForeground()
+	styles.DimStyle = styles.DimStyle.Faint(true)
+}
@@ -0,0 +1,69 @@
+// Package core provides the foundational elements for UI components.
+// This file follows the terminal's light/dark color scheme at runtime. Terminals
+// that implement color scheme reports (DEC mode 2031, e.g. kitty, ghostty, contour)
+// send a report whenever the user switches between light and dark mode; adaptive
+// colors are then re-resolved against the new background.
+//
+// Usage:
+//   - Write `EnableColorSchemeReports` to the terminal before the program starts
+//     and `DisableColorSchemeReports` after it exits.
+//   - Pass incoming messages to `HandleColorSchemeReport`.
+package core
+
+import (
+	"bytes"
+	"reflect"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// EnableColorSchemeReports turns on color scheme change reports and asks for the
+	// current scheme, so the first report arrives right away.
+	EnableColorSchemeReports = "\x1b[?2031h\x1b[?996n"
+	// DisableColorSchemeReports turns color scheme change reports off again.
+	DisableColorSchemeReports = "\x1b[?2031l"
+)
+
+var (
+	darkSchemeReport  = []byte("\x1b[?997;1n")
+	lightSchemeReport = []byte("\x1b[?997;2n")
+)
+
+// ParseColorSchemeReport reports whether seq is a color scheme report, and if so
+// whether it announces a dark background.
+func ParseColorSchemeReport(seq []byte) (dark, ok bool) {
+	switch {
+	case bytes.Equal(seq, darkSchemeReport):
+		return true, true
+	case bytes.Equal(seq, lightSchemeReport):
+		return false, true
+	}
+	return false, false
+}
+
+// HandleColorSchemeReport applies a color scheme report delivered as a Bubble Tea message.
+// Bubble Tea passes CSI sequences it does not know on as byte-slice messages of an
+// unexported type, so the bytes are taken out by reflection.
+//
+// # Returns
+//   - bool: whether msg was a color scheme report
+func HandleColorSchemeReport(msg any) bool {
+	v := reflect.ValueOf(msg)
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Uint8 {
+		return false
+	}
+	dark, ok := ParseColorSchemeReport(v.Bytes())
+	if ok {
+		SetDarkBackground(dark)
+	}
+	return ok
+}
+
+// SetDarkBackground tells lipgloss whether the terminal background is dark, which
+// selects the Light or Dark variant of every AdaptiveColor, and rebuilds the styles.
+func SetDarkBackground(dark bool) {
+	lipgloss.SetHasDarkBackground(dark)
+	styles := BuildStyles()
+	SetStyles(&styles)
+}
@@ -47,6 +47,13 @@ type Container interface {
 	GetPaddingRight() int
 	GetPaddingBottom() int
 	GetPaddingLeft() int
+
+	// Content and state methods, so a container can be kept across frames
+	SetContent(content tea.Model)
+	SetFocused(focused bool)
+	SetActive(active bool)
+	SetHovered(hovered bool)
+	GetState() ContainerState
 }
 
 // ContainerState represents the current state of a container
@@ -193,6 +200,7 @@ func (c *container) View() string {
 	// Render content
 	inner := c.renderOverlayContent()
 	view := style.Render(inner)
+	view = c.announce(view)
 
 	// Add debug overlay if enabled
 	return c.addDebugOverlay(view)
@@ -361,7 +369,7 @@ func (c *container) ViewWithContext(ctx *LayoutContext) string {
 	inner := c.renderInnerContent(innerCtx)
 
 	// Apply style to content
-	view := style.Render(inner)
+	view := c.announce(style.Render(inner))
 
 	// Add debug visualization if enabled
 	if c.debug {
@@ -371,6 +379,19 @@ func (c *container) ViewWithContext(ctx *LayoutContext) string {
 	return view
 }
 
+// announce labels the rendered container with its aria label in a11y mode, marking
+// the label with FocusedPrefix while the container is focused.
+func (c *container) announce(view string) string {
+	if !a11yMode || c.ariaLabel == "" {
+		return view
+	}
+	label := c.ariaLabel
+	if c.state.Focused {
+		label = FocusedPrefix + label
+	}
+	return announceLabel(view, label, c.customStyle == nil && c.borderTop)
+}
+
 // Helper to convert bool to int
 func btoi(b bool) int {
 	if b {
@@ -448,6 +469,11 @@ func (c *container) GetSize() (width, height int) {
 	return c.width, c.height
 }
 
+// SetContent replaces the container's content, keeping its size and state.
+func (c *container) SetContent(content tea.Model) {
+	c.content = content
+}
+
 // State management methods
 func (c *container) SetFocused(focused bool) {
 	if c.state.Focused != focused {
@@ -485,7 +511,14 @@ type ContainerOption func(*container)
 
 // NewContainer creates a new container with the given content and options.
 func NewContainer(content tea.Model, options ...ContainerOption) Container {
-	c := &container{content: content, borderStyle: lipgloss.NormalBorder()}
+	border := lipgloss.NormalBorder()
+	switch {
+	case a11yMode:
+		border = lipgloss.HiddenBorder()
+	case asciiMode:
+		border = asciiBorder
+	}
+	c := &container{content: content, borderStyle: border}
 	for _, opt := range options {
 		opt(c)
 	}
@@ -538,16 +571,15 @@ func WithBorderVertical() ContainerOption {
 
 // Package-level border style variables to allow taking their address
 var (
-	roundedBorderVar = lipgloss.RoundedBorder()
-	thickBorderVar   = lipgloss.ThickBorder()
-	doubleBorderVar  = lipgloss.DoubleBorder()
+	thickBorderVar  = lipgloss.ThickBorder()
+	doubleBorderVar = lipgloss.DoubleBorder()
 )
 
 func WithBorderStyle(style *lipgloss.Border) ContainerOption {
 	return func(c *container) { c.borderStyle = *style }
 }
 
-func WithRoundedBorder() ContainerOption { return WithBorderStyle(&roundedBorderVar) }
+func WithRoundedBorder() ContainerOption { b := RoundedBorder(); return WithBorderStyle(&b) }
 func WithThickBorder() ContainerOption   { return WithBorderStyle(&thickBorderVar) }
 func WithDoubleBorder() ContainerOption  { return WithBorderStyle(&doubleBorderVar) }
 
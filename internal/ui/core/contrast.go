@@ -0,0 +1,139 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Minimum contrast ratios, after WCAG 2: text needs 4.5:1 against its background;
+// muted text, borders and other non-text elements need 3:1.
+const (
+	MinTextContrast    = 4.5
+	MinNonTextContrast = 3.0
+)
+
+// ContrastIssue is a pair of theme colors that do not contrast enough in one variant
+// (light or dark) of the theme.
+//
+// # Fields
+//   - Pair:    The colors, e.g. "Text on Background"
+//   - Variant: "light" or "dark"
+//   - Ratio:   Their contrast ratio
+//   - Min:     The ratio they need
+type ContrastIssue struct {
+	Pair    string
+	Variant string
+	Ratio   float64
+	Min     float64
+}
+
+// String describes the issue, e.g. "Border on Background (dark): 2.6:1, needs 3.0:1".
+func (i ContrastIssue) String() string {
+	return fmt.Sprintf("%s (%s): %.1f:1, needs %.1f:1", i.Pair, i.Variant, i.Ratio, i.Min)
+}
+
+// contrastPair is a foreground and background color of a theme drawn together.
+type contrastPair struct {
+	name   string
+	fg, bg func(Theme) lipgloss.AdaptiveColor
+	min    float64
+}
+
+// contrastPairs are the color pairs the UI draws together, checked by CheckContrast.
+var contrastPairs = []contrastPair{
+	{"Text on Background", Theme.Text, Theme.Background, MinTextContrast},
+	{"Header on Background", Theme.Header, Theme.Background, MinTextContrast},
+	{"TextActive on BackgroundActive", Theme.TextActive, Theme.BackgroundActive, MinTextContrast},
+	{"StatusBarFg on StatusBarBg", Theme.StatusBarFg, Theme.StatusBarBg, MinTextContrast},
+	{"TextMuted on Background", Theme.TextMuted, Theme.Background, MinNonTextContrast},
+	{"Border on Background", Theme.Border, Theme.Background, MinNonTextContrast},
+	{"BorderActive on Background", Theme.BorderActive, Theme.Background, MinNonTextContrast},
+	{"DialogBorder on DialogBg", Theme.DialogBorder, Theme.DialogBg, MinNonTextContrast},
+}
+
+// CheckContrast returns the color pairs of a theme whose contrast is below the
+// minimum, in both its light and dark variant. Colors that are unset or cannot be
+// parsed are not checked.
+//
+// # Example
+//
+//	for _, issue := range core.CheckContrast(theme) {
+//		fmt.Println(issue) // e.g. "Border on Background (dark): 2.6:1, needs 3.0:1"
+//	}
+func CheckContrast(theme Theme) []ContrastIssue {
+	var issues []ContrastIssue
+	for _, pair := range contrastPairs {
+		fg, bg := pair.fg(theme), pair.bg(theme)
+		for _, variant := range []struct{ name, fg, bg string }{
+			{"light", fg.Light, bg.Light},
+			{"dark", fg.Dark, bg.Dark},
+		} {
+			ratio, err := ContrastRatio(variant.fg, variant.bg)
+			if err != nil || ratio >= pair.min {
+				continue
+			}
+			issues = append(issues, ContrastIssue{Pair: pair.name, Variant: variant.name, Ratio: ratio, Min: pair.min})
+		}
+	}
+	return issues
+}
+
+// ContrastRatio returns the WCAG contrast ratio of two colors, from 1 (the same
+// luminance) to 21 (black and white). Colors are hex (#RGB or #RRGGBB) or ANSI color
+// numbers (0-255).
+//
+// # Example
+//
+//	core.ContrastRatio("#000000", "#FFFFFF") // 21
+func ContrastRatio(a, b string) (float64, error) {
+	la, err := luminance(a)
+	if err != nil {
+		return 0, err
+	}
+	lb, err := luminance(b)
+	if err != nil {
+		return 0, err
+	}
+	return (max(la, lb) + 0.05) / (min(la, lb) + 0.05), nil
+}
+
+// luminance returns the relative luminance of a color, from 0 (black) to 1 (white).
+func luminance(color string) (float64, error) {
+	r, g, b, err := parseRGB(color)
+	if err != nil {
+		return 0, err
+	}
+	linear := func(c float64) float64 {
+		if c <= 0.03928 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(r) + 0.7152*linear(g) + 0.0722*linear(b), nil
+}
+
+// parseRGB returns the red, green and blue components of a color, each from 0 to 1.
+func parseRGB(color string) (r, g, b float64, err error) {
+	hex, isHex := strings.CutPrefix(color, "#")
+	if !isHex {
+		n, err := strconv.Atoi(color)
+		if err != nil || n < 0 || n > 255 {
+			return 0, 0, 0, fmt.Errorf("invalid color %q", color)
+		}
+		rgb := termenv.ConvertToRGB(termenv.TrueColor.Color(color))
+		return rgb.R, rgb.G, rgb.B, nil
+	}
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, perr := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || perr != nil {
+		return 0, 0, 0, fmt.Errorf("invalid color %q", color)
+	}
+	return float64(v>>16&0xff) / 255, float64(v>>8&0xff) / 255, float64(v&0xff) / 255, nil
+}
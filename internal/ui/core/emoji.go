@@ -1,6 +1,8 @@
 package core
 
 import (
+	"maps"
+	"slices"
 	"strings"
 
 	"a-la-carte/internal/app"
@@ -8,30 +10,81 @@ import (
 	"github.com/mattn/go-runewidth"
 )
 
-// emojiRule defines a mapping from keywords to an emoji.
+// emojiRule defines a mapping from keywords to an emoji, and to the Nerd Font glyph
+// used instead in the Nerd Font icon mode.
 type emojiRule struct {
 	matches []string
 	emoji   string
+	nerd    string
 }
 
 // emojiRules is the list of rules for matching software entries to emojis.
 var emojiRules = []emojiRule{
-	{matches: []string{"python"}, emoji: "🐍"},
-	{matches: []string{"node", "node.js"}, emoji: "🟩"},
-	{matches: []string{"go", "golang"}, emoji: "🐹"},
-	{matches: []string{"docker"}, emoji: "🐳"},
-	{matches: []string{"git"}, emoji: "🌱"},
-	{matches: []string{"linux"}, emoji: "🐧"},
-	{matches: []string{"mac", "apple"}, emoji: "🍏"},
-	{matches: []string{"brew"}, emoji: "🍺"},
-	{matches: []string{"terminal", "cli", "tui"}, emoji: "💻"},
-	{matches: []string{"test", "testing"}, emoji: "🧪"},
-	{matches: []string{"file", "document"}, emoji: "📄"},
-	{matches: []string{"key", "password", "secret"}, emoji: "🔑"},
-	{matches: []string{"sync", "update"}, emoji: "🔄"},
-	{matches: []string{"note", "write"}, emoji: "📝"},
-	{matches: []string{"package", "install"}, emoji: "📦"},
-	{matches: []string{"tool", "utility"}, emoji: "🧰"},
+	{matches: []string{"python"}, emoji: "🐍", nerd: "\ue73c"},
+	{matches: []string{"node", "node.js"}, emoji: "🟩", nerd: "\ue718"},
+	{matches: []string{"go", "golang"}, emoji: "🐹", nerd: "\ue627"},
+	{matches: []string{"docker"}, emoji: "🐳", nerd: "\ue7b0"},
+	{matches: []string{"git"}, emoji: "🌱", nerd: "\ue702"},
+	{matches: []string{"linux"}, emoji: "🐧", nerd: "\ue712"},
+	{matches: []string{"mac", "apple"}, emoji: "🍏", nerd: "\ue711"},
+	{matches: []string{"brew"}, emoji: "🍺", nerd: "\uf0fc"},
+	{matches: []string{"terminal", "cli", "tui"}, emoji: "💻", nerd: "\ue795"},
+	{matches: []string{"test", "testing"}, emoji: "🧪", nerd: "\uf0c3"},
+	{matches: []string{"file", "document"}, emoji: "📄", nerd: "\uf15c"},
+	{matches: []string{"key", "password", "secret"}, emoji: "🔑", nerd: "\uf084"},
+	{matches: []string{"sync", "update"}, emoji: "🔄", nerd: "\uf021"},
+	{matches: []string{"note", "write"}, emoji: "📝", nerd: "\uf040"},
+	{matches: []string{"package", "install"}, emoji: "📦", nerd: "\uf487"},
+	{matches: []string{"tool", "utility"}, emoji: "🧰", nerd: "\uf0ad"},
+}
+
+// Icons of entries no rule matches, and of meta-packages.
+var (
+	defaultIcon = emojiRule{emoji: "📦", nerd: "\uf487"}
+	metaIcon    = emojiRule{emoji: "🧩", nerd: "\uf12e"}
+)
+
+// IconMode selects the icons shown next to entries.
+type IconMode string
+
+const (
+	// IconsEmoji shows emojis (the default).
+	IconsEmoji IconMode = "emoji"
+	// IconsNerdFont shows Nerd Font glyphs, for terminals with a patched font.
+	IconsNerdFont IconMode = "nerdfont"
+)
+
+var (
+	iconMode      = IconsEmoji
+	keywordEmojis []emojiRule
+)
+
+// SetIconMode switches between emojis and Nerd Font glyphs. An empty mode means emojis.
+func SetIconMode(mode IconMode) {
+	if mode == "" {
+		mode = IconsEmoji
+	}
+	iconMode = mode
+}
+
+// SetEmojiKeywords adds keyword to emoji mappings, e.g. from the configuration. They are
+// tried before the built-in rules, so a keyword the built-in rules know gets the new
+// emoji. The emojis are shown in both icon modes. Keywords are matched like the
+// built-in ones, in alphabetical order.
+func SetEmojiKeywords(keywords map[string]string) {
+	keywordEmojis = nil
+	for _, keyword := range slices.Sorted(maps.Keys(keywords)) {
+		emoji := keywords[keyword]
+		keywordEmojis = append(keywordEmojis, emojiRule{matches: []string{strings.ToLower(keyword)}, emoji: emoji, nerd: emoji})
+	}
+}
+
+// icon returns the icon of a rule in the current icon mode.
+func (r emojiRule) icon() string {
+	if iconMode == IconsNerdFont {
+		return r.nerd
+	}
+	return r.emoji
 }
 
 // checkContains returns true if any of the matches are found in name or desc.
@@ -73,18 +126,27 @@ func NormalizeEmoji(e string) string {
 	return e + strings.Repeat(" ", 2-w)
 }
 
-// EmojiForEntry returns the best-matching emoji for a software entry.
+// EmojiForEntry returns the icon of a software entry: its _emoji if it has one, else the
+// best-matching emoji (🧩 for meta-packages), or Nerd Font glyph in that icon mode.
 //
 // # Parameters
 //   - e: pointer to the SoftwareEntry
 //
 // # Returns
-//   - The emoji string, always 2 columns wide.
+//   - The icon string, always 2 columns wide.
 func EmojiForEntry(e *app.SoftwareEntry) string {
-	for _, rule := range emojiRules {
-		if checkContains(e.Name, e.Desc, rule.matches...) {
-			return NormalizeEmoji(rule.emoji)
+	if e.Emoji != "" {
+		return NormalizeEmoji(e.Emoji)
+	}
+	if e.IsMeta() {
+		return NormalizeEmoji(metaIcon.icon()) // meta-packages only group other entries
+	}
+	for _, rules := range [][]emojiRule{keywordEmojis, emojiRules} {
+		for _, rule := range rules {
+			if checkContains(e.Name, e.Desc, rule.matches...) {
+				return NormalizeEmoji(rule.icon())
+			}
 		}
 	}
-	return NormalizeEmoji("📦") // default emoji
+	return NormalizeEmoji(defaultIcon.icon()) // default emoji
 }
@@ -0,0 +1,107 @@
+package core
+
+import "github.com/charmbracelet/lipgloss"
+
+// HighContrastThemeName is the name the high-contrast theme is chosen by, e.g. with
+// ui.theme in the config file.
+const HighContrastThemeName = "high-contrast"
+
+// HighContrastTheme is a built-in theme for low vision and bright or washed-out
+// screens: text is black or white, and every color pair passes CheckContrast in both
+// the light and the dark variant.
+type HighContrastTheme struct{}
+
+// Primary returns the primary color for the HighContrastTheme.
+func (t HighContrastTheme) Primary() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#00008B", Dark: "#00FFFF"} // dark blue / cyan
+}
+
+// Secondary returns the secondary color for the HighContrastTheme.
+func (t HighContrastTheme) Secondary() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#8B0000", Dark: "#FF8080"} // dark red / light red
+}
+
+// Accent returns the accent color for the HighContrastTheme.
+func (t HighContrastTheme) Accent() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#005000", Dark: "#00FF00"} // dark green / green
+}
+
+// AccentActive returns the active accent color for the HighContrastTheme.
+func (t HighContrastTheme) AccentActive() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#005000", Dark: "#FFFF00"} // dark green / yellow
+}
+
+// Text returns the default text color for the HighContrastTheme.
+func (t HighContrastTheme) Text() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"}
+}
+
+// TextMuted returns the muted text color for the HighContrastTheme.
+func (t HighContrastTheme) TextMuted() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#333333", Dark: "#CCCCCC"}
+}
+
+// TextActive returns the active text color for the HighContrastTheme.
+func (t HighContrastTheme) TextActive() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#000000"} // inverted on the active background
+}
+
+// Background returns the default background color for the HighContrastTheme.
+func (t HighContrastTheme) Background() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#000000"}
+}
+
+// BackgroundActive returns the active background color for the HighContrastTheme.
+func (t HighContrastTheme) BackgroundActive() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#00008B", Dark: "#FFFF00"} // dark blue / yellow
+}
+
+// BackgroundFocused returns the focused background color for the HighContrastTheme.
+func (t HighContrastTheme) BackgroundFocused() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#000000"} // focus is shown by the border
+}
+
+// Border returns the default border color for the HighContrastTheme.
+func (t HighContrastTheme) Border() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"}
+}
+
+// BorderActive returns the active border color for the HighContrastTheme.
+func (t HighContrastTheme) BorderActive() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#0000CC", Dark: "#FFFF00"} // blue / yellow
+}
+
+// DialogBg returns the dialog background color for the HighContrastTheme.
+func (t HighContrastTheme) DialogBg() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#000000"}
+}
+
+// DialogBorder returns the dialog border color for the HighContrastTheme.
+func (t HighContrastTheme) DialogBorder() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#0000CC", Dark: "#FFFF00"} // blue / yellow
+}
+
+// StatusBarBg returns the status bar background color for the HighContrastTheme.
+func (t HighContrastTheme) StatusBarBg() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"}
+}
+
+// StatusBarFg returns the status bar foreground color for the HighContrastTheme.
+func (t HighContrastTheme) StatusBarFg() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#000000"}
+}
+
+// Header returns the header color for the HighContrastTheme.
+func (t HighContrastTheme) Header() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: "#00008B", Dark: "#00FFFF"} // dark blue / cyan
+}
+
+// SoftwarePickerHeight returns the height for software picker elements in the HighContrastTheme.
+func (t HighContrastTheme) SoftwarePickerHeight() int {
+	return 12
+}
+
+// ShowSectionHeaders determines if section headers are shown in the HighContrastTheme.
+func (t HighContrastTheme) ShowSectionHeaders() bool {
+	return true
+}
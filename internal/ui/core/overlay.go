@@ -0,0 +1,169 @@
+package core
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// sgrPattern matches an SGR (Select Graphic Rendition) escape sequence, which sets styles.
+var sgrPattern = regexp.MustCompile(`\x1b\[[0-9;:]*m`)
+
+// Overlay draws foreground on top of background with its top-left corner at column x
+// and line y, keeping the background visible around it. Both may contain ANSI styles;
+// widths are measured in terminal cells. The background is extended with blank lines
+// and spaces where the foreground reaches past it, and a wide rune cut by an edge of the
+// foreground is replaced by a space.
+//
+// # Example
+//
+//	core.Overlay(view, toast, width-lipgloss.Width(toast), 0) // top-right corner
+func Overlay(background, foreground string, x, y int) string {
+	x, y = max(x, 0), max(y, 0)
+	lines := strings.Split(background, "\n")
+	for i, fgLine := range strings.Split(foreground, "\n") {
+		row := y + i
+		for row >= len(lines) {
+			lines = append(lines, "")
+		}
+		bgLine := lines[row]
+		left := ansi.Truncate(bgLine, x, "")
+		if width := ansi.StringWidth(left); width < x {
+			left += strings.Repeat(" ", x-width)
+		}
+		if strings.Contains(left, "\x1b") {
+			// Styles left open by the background must not leak into the foreground.
+			left += ansi.ResetStyle
+		}
+		lines[row] = left + fgLine + overlayRight(bgLine, fgLine, x+ansi.StringWidth(fgLine))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// overlayRight returns the part of bgLine from column cut on, drawn after fgLine. The
+// style the background had at cut is restored first, since fgLine ends with its own.
+func overlayRight(bgLine, fgLine string, cut int) string {
+	width := ansi.StringWidth(bgLine)
+	if cut >= width {
+		return ""
+	}
+	right := ansi.TruncateLeft(bgLine, cut, "")
+	if ansi.StringWidth(right) > width-cut {
+		// A wide rune starts under the foreground: blank the half that shows
+		right = " " + ansi.TruncateLeft(bgLine, cut+1, "")
+	}
+	if !strings.Contains(bgLine, "\x1b") && !strings.Contains(fgLine, "\x1b") {
+		return right
+	}
+	return ansi.ResetStyle + styleAt(bgLine, cut) + right
+}
+
+// styleAt returns the SGR sequences in effect at column col of s: those before it and
+// after the last reset.
+func styleAt(s string, col int) string {
+	var style strings.Builder
+	width, start := 0, 0
+	for _, loc := range sgrPattern.FindAllStringIndex(s, -1) {
+		width += ansi.StringWidth(s[start:loc[0]])
+		start = loc[1]
+		if width > col {
+			break
+		}
+		sgr := s[loc[0]:loc[1]]
+		if sgr == "\x1b[m" || sgr == "\x1b[0m" || strings.HasPrefix(sgr, "\x1b[0;") {
+			style.Reset()
+		}
+		if sgr != "\x1b[m" && sgr != "\x1b[0m" {
+			style.WriteString(sgr)
+		}
+	}
+	return style.String()
+}
+
+// Layer is a modal drawn over the base view by an OverlayManager, such as a dialog.
+// A layer closes itself by becoming invisible, e.g. when Esc hides it.
+type Layer interface {
+	tea.Model
+	IsVisible() bool
+}
+
+// OverlayManager stacks modal layers over a base view. The top layer receives key
+// input; the layers below it and the base view keep showing around it.
+//
+// # Fields
+//   - layers: The open layers, bottom first
+//
+// # Example
+//
+//	m.overlays.Open(m.profileSwitcher)
+//	...
+//	if cmd, handled := m.overlays.HandleKey(keyMsg); handled {
+//		return m, cmd
+//	}
+//	...
+//	return m.overlays.View(base)
+type OverlayManager struct {
+	layers []Layer
+}
+
+// NewOverlayManager creates an overlay manager without layers.
+func NewOverlayManager() *OverlayManager {
+	return &OverlayManager{}
+}
+
+// Open puts a layer on top of the others, moving it there if it is already open.
+func (o *OverlayManager) Open(layer Layer) {
+	o.Close(layer)
+	o.layers = append(o.layers, layer)
+}
+
+// Close removes a layer, giving input back to the layer below it.
+func (o *OverlayManager) Close(layer Layer) {
+	o.layers = slices.DeleteFunc(o.layers, func(l Layer) bool { return l == layer })
+}
+
+// Top returns the layer that receives key input, or nil if no layer is open.
+func (o *OverlayManager) Top() Layer {
+	o.prune()
+	if len(o.layers) == 0 {
+		return nil
+	}
+	return o.layers[len(o.layers)-1]
+}
+
+// Active reports whether a layer is open.
+func (o *OverlayManager) Active() bool {
+	return o.Top() != nil
+}
+
+// HandleKey passes a key press to the top layer. It reports whether a layer was open to
+// take it; if not, the key is for the base view.
+func (o *OverlayManager) HandleKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	top := o.Top()
+	if top == nil {
+		return nil, false
+	}
+	_, cmd := top.Update(msg)
+	o.prune()
+	return cmd, true
+}
+
+// View draws the open layers centered over base, bottom first.
+func (o *OverlayManager) View(base string) string {
+	o.prune()
+	width, height := lipgloss.Width(base), lipgloss.Height(base)
+	for _, layer := range o.layers {
+		view := layer.View()
+		base = Overlay(base, view, (width-lipgloss.Width(view))/2, (height-lipgloss.Height(view))/2)
+	}
+	return base
+}
+
+// prune drops the layers that closed themselves.
+func (o *OverlayManager) prune() {
+	o.layers = slices.DeleteFunc(o.layers, func(l Layer) bool { return !l.IsVisible() })
+}
@@ -66,6 +66,14 @@ const (
 	ListEmptyMsg = "No more software. Press / to edit filter."
 	// SelectedEmptyMsg is the message shown when a list of selected items is empty.
 	SelectedEmptyMsg = "No software selected."
+	// NotInstallableBadge marks list entries that have no install method on the current platform.
+	NotInstallableBadge = "[not installable here]"
+	// MetaBadge marks meta-packages, which only group other entries, when emojis are off.
+	MetaBadge = "[meta]"
+	// ConflictBadge marks list entries that conflict with a selected entry.
+	ConflictBadge = "[conflict]"
+	// NewBadge marks list entries that a change to a manifest file added.
+	NewBadge = "[new]"
 )
 
 // Detail view header and label constants used for consistent labeling in detail panels.
@@ -157,7 +165,7 @@ type Styles struct {
 func BuildStyles() Styles {
 	theme := CurrentTheme()
 
-	return Styles{
+	styles := Styles{
 		TitleStyle: lipgloss.NewStyle().
 			Foreground(theme.Primary()).
 			Bold(true),
@@ -195,7 +203,7 @@ func BuildStyles() Styles {
 			Bold(true),
 
 		BorderStyle: lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
+			Border(RoundedBorder()).
 			BorderForeground(theme.Border()),
 
 		HighlightStyle: lipgloss.NewStyle().
@@ -220,13 +228,13 @@ func BuildStyles() Styles {
 			Foreground(theme.TextActive()), // Consider if this should be different from DetailValueStyle
 
 		ListPanel: lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
+			Border(RoundedBorder()).
 			BorderForeground(theme.Border()).
 			Padding(0, 1).
 			Margin(0, 0),
 
 		DetailPanel: lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
+			Border(RoundedBorder()).
 			BorderForeground(theme.Border()).
 			Padding(1, 2).
 			Margin(0, 0),
@@ -235,6 +243,10 @@ func BuildStyles() Styles {
 			Background(theme.Background()). // Ensure indicator background matches panel
 			Foreground(theme.TextMuted()),  // Default to muted/unfocused color
 	}
+	if monochrome {
+		monochromeStyles(&styles)
+	}
+	return styles
 }
 
 // currentStyles holds the globally accessible current styles.
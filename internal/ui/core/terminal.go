@@ -0,0 +1,49 @@
+// Package core provides the foundational elements for UI components.
+// This file gets escape sequences through terminal multiplexers and saves the
+// terminal title around a program that changes it.
+//
+// Usage:
+//   - Wrap sequences the multiplexer does not forward itself, such as OSC 52
+//     clipboard writes, with `Passthrough`.
+//   - Write `PushTitle` to the terminal before the program starts and `PopTitle`
+//     after it exits, so the user's title comes back.
+package core
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// PushTitle saves the terminal title on the terminal's title stack (XTWINOPS 22).
+	PushTitle = "\x1b[22;0t"
+	// PopTitle restores the title saved by PushTitle (XTWINOPS 23).
+	PopTitle = "\x1b[23;0t"
+)
+
+// screenPassthroughLimit is the longest chunk GNU screen passes through in one
+// device control string.
+const screenPassthroughLimit = 768
+
+// Passthrough wraps an escape sequence so it reaches the outer terminal from inside
+// tmux or GNU screen, which otherwise swallow sequences they do not handle. Under
+// tmux the sequence is sent both as is (tmux forwards it with set-clipboard on) and
+// wrapped (forwarded with allow-passthrough on); elsewhere it is returned unchanged.
+//
+// # Parameters
+//   - seq:    The escape sequence
+//   - getenv: Looks up environment variables, e.g. os.Getenv
+//
+// # Example
+//
+//	fmt.Fprint(os.Stdout, core.Passthrough(ansi.SetSystemClipboard(text), os.Getenv))
+func Passthrough(seq string, getenv func(string) string) string {
+	switch {
+	case getenv("TMUX") != "":
+		return seq + ansi.TmuxPassthrough(seq)
+	case strings.HasPrefix(getenv("TERM"), "screen") || getenv("STY") != "":
+		return ansi.ScreenPassthrough(seq, screenPassthroughLimit)
+	}
+	return seq
+}
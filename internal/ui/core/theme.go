@@ -76,7 +76,11 @@ func SetTheme(theme Theme) {
 // CurrentTheme returns the currently active theme.
 // If no theme has been explicitly set, it might return nil or a default,
 // depending on initialization logic (see init function).
+// In monochrome mode (see SetColorProfile) the theme's colors are left out.
 func CurrentTheme() Theme {
+	if monochrome && currentTheme != nil {
+		return monochromeTheme{currentTheme}
+	}
 	return currentTheme
 }
 
@@ -108,17 +112,17 @@ func (t DefaultTheme) AccentActive() lipgloss.AdaptiveColor {
 
 // Text returns the default text color for the DefaultTheme.
 func (t DefaultTheme) Text() lipgloss.AdaptiveColor {
-	return colorToAdaptive(lipgloss.Color("#EEEEEE")) // normal text
+	return lipgloss.AdaptiveColor{Light: "#282A36", Dark: "#EEEEEE"} // normal text, dark on light terminals
 }
 
 // TextMuted returns the muted text color for the DefaultTheme.
 func (t DefaultTheme) TextMuted() lipgloss.AdaptiveColor {
-	return colorToAdaptive(lipgloss.Color("#D9DCCF")) // muted text
+	return lipgloss.AdaptiveColor{Light: "#6C6F7E", Dark: "#D9DCCF"} // muted text
 }
 
 // TextActive returns the active text color for the DefaultTheme.
 func (t DefaultTheme) TextActive() lipgloss.AdaptiveColor {
-	return colorToAdaptive(lipgloss.Color("#EEEEEE")) // normal for active text
+	return lipgloss.AdaptiveColor{Light: "#282A36", Dark: "#EEEEEE"} // normal for active text
 }
 
 // Background returns the default background color for the DefaultTheme.
@@ -190,12 +194,15 @@ var currentThemeName string
 
 // RegisterTheme adds a new theme to the registeredThemes map.
 // If it's the first theme being registered, it's automatically set as the current theme.
-func RegisterTheme(name string, theme Theme) {
+// The theme is registered even if some of its colors contrast too little; those are
+// returned as warnings (see CheckContrast).
+func RegisterTheme(name string, theme Theme) []ContrastIssue {
 	registeredThemes[name] = theme
 	// If this is the first registered theme, set it as current
 	if currentThemeName == "" {
 		SetThemeName(name)
 	}
+	return CheckContrast(theme)
 }
 
 // GetThemeByName retrieves a theme from the registeredThemes map by its name.
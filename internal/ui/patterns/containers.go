@@ -19,12 +19,15 @@ import (
 //   - Rounded borders on all sides
 //   - 1-space padding
 //   - Theme-aware styling
-func Panel(content tea.Model) core.Container {
+//   - Further options, e.g. core.WithAriaLabel, applied after the defaults
+func Panel(content tea.Model, options ...core.ContainerOption) core.Container {
 	return core.NewContainer(
 		content,
-		core.WithBorderAll(),
-		core.WithRoundedBorder(),
-		core.WithPaddingAll(1),
+		append([]core.ContainerOption{
+			core.WithBorderAll(),
+			core.WithRoundedBorder(),
+			core.WithPaddingAll(1),
+		}, options...)...,
 	)
 }
 
@@ -41,7 +44,7 @@ func Panel(content tea.Model) core.Container {
 func Dialog(content tea.Model) core.Container {
 	theme := core.CurrentTheme()
 	style := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
+		Border(core.RoundedBorder()).
 		BorderForeground(theme.DialogBorder()).
 		Background(theme.DialogBg())
 
@@ -85,17 +88,10 @@ func Card(content tea.Model) core.Container {
 //   - Theme-specific header colors
 func Tab(content tea.Model) core.Container {
 	theme := core.CurrentTheme()
+	border := core.RoundedBorder()
+	border.Bottom, border.BottomLeft, border.BottomRight = "", " ", " "
 	style := lipgloss.NewStyle().
-		Border(lipgloss.Border{
-			Top:         "─",
-			Bottom:      "",
-			Left:        "│",
-			Right:       "│",
-			TopLeft:     "╭",
-			TopRight:    "╮",
-			BottomLeft:  " ",
-			BottomRight: " ",
-		}, true, true, false, true).
+		Border(border, true, true, false, true).
 		BorderForeground(theme.Header()).
 		Padding(0, 1).
 		Foreground(theme.Text())
@@ -212,40 +208,16 @@ func Banner(content tea.Model) core.Container {
 	)
 }
 
-// PlaceOverlay positions an overlay string within a larger panel string.
-// It centers the overlay by default.
+// PlaceOverlay draws overlayContent over panelContent, centered in a panel of the given
+// size or at its top-left corner, keeping panelContent visible around it. For stacked
+// dialogs with input routing, use core.OverlayManager.
 func PlaceOverlay(panelWidth, panelHeight int, overlayContent, panelContent string, center bool) string {
-	// Create a new style for positioning. We assume the panelContent already has its own styling.
-	// We'll render the overlay on top.
-	// This is a simplified approach; for complex layering, a more robust solution might be needed.
-	// For now, we'll place the overlay within the panel's dimensions.
-
-	// This function is more about calculating where to put something if you were to manually merge strings.
-	// A true overlay usually involves rendering one thing, then rendering another on top at specific coordinates.
-	// Lipgloss's Place function is better suited for this if you are rendering the overlay as the primary content of a cell.
-
-	// If we are to return a single string, we'd need to merge them carefully.
-	// However, the typical use case in Bubble Tea is to have a container that renders its content,
-	// and if an overlay is needed, it's rendered *instead* of or *conditionally around* the main content.
-
-	// For the purpose of this helper, let's assume we want to render the panelContent
-	// and then render the overlayContent on top, centered.
-	// This is tricky with just string manipulation if the panelContent is complex.
-
-	// A more practical approach for Bubble Tea is to use lipgloss.Place within a container's View method.
-	// This function might be better named `GetOverlayPlacementStyle` if it were to return a style for the overlay.
-
-	// Given the existing usage, it seems like it's trying to create a combined view.
-	// Let's use lipgloss.Place to put the overlayContent into a box of panelWidth x panelHeight,
-	// and then join that with the panelContent. This isn't a true overlay but a common layout pattern.
-
-	positionedOverlay := lipgloss.NewStyle().Width(panelWidth).Height(panelHeight).Align(lipgloss.Center, lipgloss.Center).Render(overlayContent)
-
-	// This doesn't truly "overlay" in the visual sense of one on top of another with transparency.
-	// It replaces the panelContent if we return just positionedOverlay.
-	// If the goal is to have panelContent as background and overlayContent on top, that's more complex.
-	// For now, let's assume the intent is to show the overlay centered within the panel's dimensions.
-	return positionedOverlay // This will show the overlay centered, effectively replacing panelContent if used directly.
+	if !center {
+		return core.Overlay(panelContent, overlayContent, 0, 0)
+	}
+	x := (panelWidth - lipgloss.Width(overlayContent)) / 2
+	y := (panelHeight - lipgloss.Height(overlayContent)) / 2
+	return core.Overlay(panelContent, overlayContent, x, y)
 }
 
 // SplitPaneLayout defines the interface for a split pane layout.